@@ -15,10 +15,12 @@ import (
 	"zensor-server/internal/infra/async"
 	"zensor-server/internal/infra/config"
 	"zensor-server/internal/infra/httpserver"
+	"zensor-server/internal/infra/leader"
 	"zensor-server/internal/infra/mqtt"
 	"zensor-server/internal/infra/node"
 	"zensor-server/internal/infra/o11y"
 
+	dataPlaneWorkers "zensor-server/internal/data_plane/workers"
 	maintenanceUsecases "zensor-server/internal/maintenance/usecases"
 	victronHTTPAPI "zensor-server/internal/victron/httpapi"
 	victronUsecases "zensor-server/internal/victron/usecases"
@@ -58,15 +60,23 @@ func main() {
 	shutdownOtel := startOTel()
 
 	// DATA PLANE - Set up broker and dependencies first
-	internalBroker := async.NewLocalBroker()
+	internalBroker, err := wire.InitializeInternalBroker()
+	if err != nil {
+		panic(err)
+	}
 
 	controllers := []httpserver.Controller{
 		asController(handleWireInjector(wire.InitializeDeviceController())),
+		asController(handleWireInjector(wire.InitializeDeviceImportController())),
+		asController(handleWireInjector(wire.InitializeDeviceKeyController())),
+		asController(handleWireInjector(wire.InitializeDeviceConfigJobController())),
 		asController(handleWireInjector(wire.InitializeEvaluationRuleController())),
 		asController(handleWireInjector(wire.InitializeTaskController())),
 		asController(handleWireInjector(wire.InitializeTenantController())),
 		asController(handleWireInjector(wire.InitializeTenantConfigurationController())),
 		asController(handleWireInjector(wire.InitializeScheduledTaskController())),
+		asController(handleWireInjector(wire.InitializeCommandTemplateSetController())),
+		asController(handleWireInjector(wire.InitializeWebhookController())),
 		asController(handleWireInjector(wire.InitializeUserController())),
 		asController(handleWireInjector(wire.InitializePushTokenController())),
 		asController(handleWireInjector(wire.InitializeWebPushController())),
@@ -125,29 +135,89 @@ func main() {
 
 	var wg sync.WaitGroup
 	ticker := time.NewTicker(30 * time.Second)
+	var embeddedBroker *mqtt.EmbeddedBroker
+	if appConfig.MQTTBroker.Enabled {
+		embeddedBroker = mqtt.NewEmbeddedBroker(mqtt.EmbeddedBrokerOpts{
+			Address:  appConfig.MQTTBroker.Address,
+			Username: appConfig.MQTTBroker.Username,
+			Password: appConfig.MQTTBroker.Password, // pragma: allowlist secret
+		})
+		if err := embeddedBroker.Start(); err != nil {
+			panic(err)
+		}
+		if appConfig.MQTTClient.PersistentSession || appConfig.MQTTClient.PublishQoS == 2 {
+			slog.Warn("the embedded MQTT broker refuses persistent sessions and disconnects QoS 2 publishers",
+				slog.Bool("persistent_session", appConfig.MQTTClient.PersistentSession),
+				slog.Int("publish_qos", int(appConfig.MQTTClient.PublishQoS)))
+		}
+	}
+
 	var mqttClient mqtt.Client
-	if env == "local" {
+	if env == "local" && embeddedBroker == nil {
 		mqttClient = mqtt.NewNoOpClient()
 	} else {
 		simpleClientOpts := mqtt.SimpleClientOpts{
-			Broker:   appConfig.MQTTClient.Broker,
-			ClientID: appConfig.MQTTClient.ClientID,
-			Username: appConfig.MQTTClient.Username,
-			Password: appConfig.MQTTClient.Password, // pragma: allowlist secret
+			Name:              "ttn",
+			Broker:            appConfig.MQTTClient.Broker,
+			ClientID:          appConfig.MQTTClient.ClientID,
+			Username:          appConfig.MQTTClient.Username,
+			Password:          appConfig.MQTTClient.Password, // pragma: allowlist secret
+			QoS:               appConfig.MQTTClient.PublishQoS,
+			PersistentSession: appConfig.MQTTClient.PersistentSession,
+			Queue:             newMQTTOutboundQueue(appConfig.MQTTClient),
+			TLS:               mqttTLSOpts(appConfig.MQTTClient.TLS),
 		}
-		mqttClient = mqtt.NewSimpleClient(simpleClientOpts)
+		mqttClient = newMQTTClient(simpleClientOpts)
 	}
 
+	// Singleton workers run on the leader replica only. Every replica receives every broker event
+	// on the redis backend, so the consumers with side effects, such as notifications, are
+	// singletons too, or each replica would repeat them.
+	var singletonWorkers []async.Worker
+
 	// TODO: capture workers into a variable to shutdown them later
 	if appConfig.Modules.Permaculture.Enabled {
 		wg.Add(1)
 		go asWorker(handleWireInjector(wire.InitializeLoraIntegrationWorker(ticker, mqttClient, internalBroker))).Run(appCtx, wg.Done)
-		wg.Add(1)
-		go asWorker(handleWireInjector(wire.InitializeCommandWorker(internalBroker))).Run(appCtx, wg.Done)
-		wg.Add(1)
-		go asWorker(handleWireInjector(wire.InitializeScheduledTaskWorker(internalBroker))).Run(appCtx, wg.Done)
-		wg.Add(1)
-		go asWorker(handleWireInjector(wire.InitializeNotificationWorker(internalBroker))).Run(appCtx, wg.Done)
+		if appConfig.Simulator.Enabled {
+			slog.Warn("simulating devices on the MQTT broker", slog.Any("devices", appConfig.Simulator.Devices))
+			simulatorClient := newMQTTClient(mqtt.SimpleClientOpts{
+				Name:     "simulator",
+				Broker:   appConfig.MQTTClient.Broker,
+				ClientID: appConfig.MQTTClient.ClientID + "_simulator",
+				Username: appConfig.MQTTClient.Username,
+				Password: appConfig.MQTTClient.Password, // pragma: allowlist secret
+				QoS:      1,
+				Queue:    mqtt.NewMemoryQueue(_simulatorQueueCapacity),
+				TLS:      mqttTLSOpts(appConfig.MQTTClient.TLS),
+			})
+			wg.Add(1)
+			go dataPlaneWorkers.NewDeviceSimulator(simulatorClient, dataPlaneWorkers.DeviceSimulatorConfig{
+				Devices:        appConfig.Simulator.Devices,
+				UplinkInterval: appConfig.Simulator.UplinkInterval,
+			}).Run(appCtx, wg.Done)
+		}
+		singletonWorkers = append(singletonWorkers,
+			asWorker(handleWireInjector(wire.InitializeCommandWorker(internalBroker))),
+			asWorker(handleWireInjector(wire.InitializeScheduledTaskWorker())),
+			asWorker(handleWireInjector(wire.InitializeOutboxRelayWorker(internalBroker))),
+			asWorker(handleWireInjector(wire.InitializeConnectivityWatchdogWorker(internalBroker))),
+			asWorker(handleWireInjector(wire.InitializeDeviceKeyRotationWorker())),
+			asWorker(handleWireInjector(wire.InitializeDeviceConfigJobWorker())),
+			asWorker(handleWireInjector(wire.InitializeDeviceHealthWorker(internalBroker))),
+			asWorker(handleWireInjector(wire.InitializeWebhookWorker(internalBroker))),
+			asWorker(handleWireInjector(wire.InitializeNotificationWorker(internalBroker))),
+		)
+		if appConfig.TTN.Provisioning.Enabled {
+			singletonWorkers = append(singletonWorkers, asWorker(handleWireInjector(wire.InitializeProvisioningWorker())))
+		}
+		if appConfig.MQTTBridge.Enabled {
+			slog.Info("republishing telemetry on the MQTT bridge",
+				slog.String("sensor_topic", appConfig.MQTTBridge.SensorTopic),
+				slog.Bool("commands", appConfig.MQTTBridge.Commands.Enabled))
+			bridgeClient := newMQTTClient(mqttBridgeClientOpts(appConfig.MQTTBridge))
+			singletonWorkers = append(singletonWorkers, asWorker(handleWireInjector(wire.InitializeMQTTBridgeWorker(bridgeClient, internalBroker))))
+		}
 	}
 
 	if appConfig.Modules.Maintenance.Enabled {
@@ -162,10 +232,8 @@ func main() {
 			panic(err)
 		}
 
-		// Start all push notification workers
 		for _, worker := range pushNotificationWorkers {
-			wg.Add(1)
-			go worker.Run(appCtx, wg.Done)
+			singletonWorkers = append(singletonWorkers, worker)
 		}
 	}
 
@@ -177,10 +245,14 @@ func main() {
 		panic(err)
 	}
 
-	// Start all metric workers
 	for _, worker := range metricWorkers {
+		singletonWorkers = append(singletonWorkers, worker)
+	}
+
+	if len(singletonWorkers) > 0 {
+		elector := asComponents[leader.Elector](handleWireInjector(wire.InitializeLeaderElector(singletonWorkers...)))
 		wg.Add(1)
-		go worker.Run(appCtx, wg.Done)
+		go elector.Run(appCtx, wg.Done)
 	}
 
 	if appConfig.Modules.Victron.Enabled {
@@ -189,11 +261,13 @@ func main() {
 		if env == "local" {
 			victronMQTTClient = mqtt.NewNoOpClient()
 		} else {
-			victronMQTTClient = mqtt.NewSimpleClient(mqtt.SimpleClientOpts{
+			victronMQTTClient = newMQTTClient(mqtt.SimpleClientOpts{
+				Name:     "victron",
 				Broker:   appConfig.Victron.MQTT.Broker,
 				ClientID: appConfig.Victron.MQTT.ClientID,
 				Username: appConfig.Victron.MQTT.Username,
 				Password: appConfig.Victron.MQTT.Password,
+				TLS:      mqttTLSOpts(appConfig.Victron.MQTT.TLS),
 			})
 		}
 		wg.Add(1)
@@ -219,6 +293,10 @@ func main() {
 
 	cancelFn()
 	wg.Wait()
+	internalBroker.Stop()
+	if embeddedBroker != nil {
+		embeddedBroker.Shutdown()
+	}
 	slog.Info("good bye!!!")
 	os.Exit(0)
 }
@@ -237,6 +315,12 @@ func slogReplaceAttr(groups []string, a slog.Attr) slog.Attr {
 
 type ShutdownFunc func() error
 
+// _simulatorQueueCapacity holds the messages the device simulator publishes before connecting.
+const _simulatorQueueCapacity = 100
+
+// _mqttBridgeQueueCapacity holds the messages the MQTT bridge publishes while disconnected.
+const _mqttBridgeQueueCapacity = 1000
+
 const (
 	_defautlEndpoint = "localhost:4317"
 	_collectPeriod   = 30 * time.Second
@@ -405,6 +489,55 @@ func newMeterProvider(metricExporter metric.Exporter) *metric.MeterProvider {
 	)
 }
 
+// newMQTTOutboundQueue returns the queue of the messages published while disconnected from the
+// broker, or nil when disabled.
+func newMQTTOutboundQueue(mqttConfig config.MQTTClientConfig) mqtt.OutboundQueue {
+	if mqttConfig.QueueCapacity <= 0 {
+		return nil
+	}
+	if mqttConfig.QueueDir == "" {
+		return mqtt.NewMemoryQueue(mqttConfig.QueueCapacity)
+	}
+
+	queue, err := mqtt.NewFileQueue(mqttConfig.QueueDir, mqttConfig.QueueCapacity)
+	if err != nil {
+		panic(fmt.Errorf("opening MQTT outbound queue: %w", err))
+	}
+	return queue
+}
+
+func newMQTTClient(opts mqtt.SimpleClientOpts) *mqtt.SimpleClient {
+	client, err := mqtt.NewSimpleClient(opts)
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+func mqttTLSOpts(tlsConfig config.MQTTTLSConfig) mqtt.TLSOpts {
+	return mqtt.TLSOpts{
+		CAFile:             tlsConfig.CAFile,
+		CertFile:           tlsConfig.CertFile,
+		KeyFile:            tlsConfig.KeyFile,
+		ServerName:         tlsConfig.ServerName,
+		InsecureSkipVerify: tlsConfig.InsecureSkipVerify,
+	}
+}
+
+// mqttBridgeClientOpts connects the MQTT bridge to its own broker.
+func mqttBridgeClientOpts(bridge config.MQTTBridgeConfig) mqtt.SimpleClientOpts {
+	return mqtt.SimpleClientOpts{
+		Name:     "bridge",
+		Broker:   bridge.Broker,
+		ClientID: bridge.ClientID,
+		Username: bridge.Username,
+		Password: bridge.Password, // pragma: allowlist secret
+		QoS:      bridge.QoS,
+		Queue:    mqtt.NewMemoryQueue(_mqttBridgeQueueCapacity),
+		TLS:      mqttTLSOpts(bridge.TLS),
+	}
+}
+
 func handleWireInjector(value any, err error) any {
 	if err != nil {
 		panic(err)
@@ -4,6 +4,8 @@
 package wire
 
 import (
+	"encoding/base64"
+	"fmt"
 	"log/slog"
 	"os"
 	"sync"
@@ -15,9 +17,14 @@ import (
 	"zensor-server/internal/infra/async"
 	"zensor-server/internal/infra/cache"
 	"zensor-server/internal/infra/config"
+	"zensor-server/internal/infra/leader"
 	"zensor-server/internal/infra/mqtt"
+	"zensor-server/internal/infra/node"
 	"zensor-server/internal/infra/notification"
+	"zensor-server/internal/infra/secrets"
 	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/infra/ttn"
+	"zensor-server/internal/shared_kernel/domain"
 
 	sharedPersistence "zensor-server/internal/shared_kernel/persistence"
 	sharedUsecases "zensor-server/internal/shared_kernel/usecases"
@@ -44,12 +51,90 @@ func InitializeDeviceController() (*httpapi.DeviceController, error) {
 		provideAppConfig,
 		DeviceServiceSet,
 		wire.Bind(new(usecases.DeviceService), new(*usecases.SimpleDeviceService)),
+		persistence.NewScheduledTaskRepository,
+		wire.Bind(new(usecases.ScheduledTaskRepository), new(*persistence.SimpleScheduledTaskRepository)),
+		persistence.NewScheduledTaskRunRepository,
+		wire.Bind(new(usecases.ScheduledTaskRunRepository), new(*persistence.SimpleScheduledTaskRunRepository)),
+		persistence.NewCommandTemplateSetRepository,
+		wire.Bind(new(usecases.CommandTemplateSetRepository), new(*persistence.SimpleCommandTemplateSetRepository)),
+		persistence.NewEvaluationRuleRepository,
+		wire.Bind(new(usecases.EvaluationRuleRepository), new(*persistence.EvaluationRuleRepository)),
+		persistence.NewTransactor,
+		wire.Bind(new(usecases.Transactor), new(*persistence.SimpleTransactor)),
+		usecases.NewDeviceLifecycleService,
+		wire.Bind(new(usecases.DeviceLifecycleService), new(*usecases.SimpleDeviceLifecycleService)),
+		DeviceSessionServiceSet,
+		sharedPersistence.NewTenantRepository,
+		wire.Bind(new(sharedUsecases.TenantRepository), new(*sharedPersistence.SimpleTenantRepository)),
+		wire.Bind(new(sharedUsecases.DeviceAdopter), new(*usecases.SimpleDeviceService)),
+		sharedUsecases.NewTenantService,
+		wire.Bind(new(sharedUsecases.TenantService), new(*sharedUsecases.SimpleTenantService)),
 		httpapi.NewDeviceController,
 	)
 
 	return nil, nil
 }
 
+func InitializeDeviceImportController() (*httpapi.DeviceImportController, error) {
+	wire.Build(
+		provideAppConfig,
+		provideDatabase,
+		persistence.NewDeviceRepository,
+		provideDeviceKeyCipher,
+		wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
+		persistence.NewCommandRepository,
+		wire.Bind(new(usecases.CommandRepository), new(*persistence.SimpleCommandRepository)),
+		provideNetworkServerProvisioner,
+		usecases.NewDeviceService,
+		sharedPersistence.NewTenantRepository,
+		wire.Bind(new(sharedUsecases.TenantRepository), new(*sharedPersistence.SimpleTenantRepository)),
+		wire.Bind(new(sharedUsecases.DeviceAdopter), new(*usecases.SimpleDeviceService)),
+		sharedUsecases.NewTenantService,
+		wire.Bind(new(sharedUsecases.TenantService), new(*sharedUsecases.SimpleTenantService)),
+		usecases.NewDeviceImportService,
+		wire.Bind(new(usecases.DeviceImportService), new(*usecases.SimpleDeviceImportService)),
+		httpapi.NewDeviceImportController,
+	)
+
+	return nil, nil
+}
+
+func InitializeDeviceKeyController() (*httpapi.DeviceKeyController, error) {
+	wire.Build(
+		provideAppConfig,
+		provideDatabase,
+		persistence.NewDeviceRepository,
+		provideDeviceKeyCipher,
+		wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
+		persistence.NewDeviceKeyAccessRepository,
+		wire.Bind(new(usecases.DeviceKeyAccessRepository), new(*persistence.SimpleDeviceKeyAccessRepository)),
+		usecases.NewDeviceKeyService,
+		wire.Bind(new(usecases.DeviceKeyService), new(*usecases.SimpleDeviceKeyService)),
+		httpapi.NewDeviceKeyController,
+	)
+
+	return nil, nil
+}
+
+func InitializeDeviceConfigJobController() (*httpapi.DeviceConfigJobController, error) {
+	wire.Build(
+		provideAppConfig,
+		provideDatabase,
+		persistence.NewDeviceRepository,
+		provideDeviceKeyCipher,
+		wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
+		persistence.NewCommandRepository,
+		wire.Bind(new(usecases.CommandRepository), new(*persistence.SimpleCommandRepository)),
+		persistence.NewDeviceConfigJobRepository,
+		wire.Bind(new(usecases.DeviceConfigJobRepository), new(*persistence.SimpleDeviceConfigJobRepository)),
+		usecases.NewDeviceConfigJobService,
+		wire.Bind(new(usecases.DeviceConfigJobService), new(*usecases.SimpleDeviceConfigJobService)),
+		httpapi.NewDeviceConfigJobController,
+	)
+
+	return nil, nil
+}
+
 func InitializeTaskController() (*httpapi.TaskController, error) {
 	wire.Build(
 		provideAppConfig,
@@ -57,11 +142,13 @@ func InitializeTaskController() (*httpapi.TaskController, error) {
 		wire.Bind(new(usecases.TaskRepository), new(*persistence.SimpleTaskRepository)),
 		provideDatabase,
 		persistence.NewDeviceRepository,
+		provideDeviceKeyCipher,
 		wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
 		persistence.NewCommandRepository,
 		wire.Bind(new(usecases.CommandRepository), new(*persistence.SimpleCommandRepository)),
 		usecases.NewTaskService,
 		wire.Bind(new(usecases.TaskService), new(*usecases.SimpleTaskService)),
+		provideNetworkServerProvisioner,
 		usecases.NewDeviceService,
 		wire.Bind(new(usecases.DeviceService), new(*usecases.SimpleDeviceService)),
 		httpapi.NewTaskController,
@@ -77,7 +164,9 @@ func InitializeScheduledTaskController() (*httpapi.ScheduledTaskController, erro
 		persistence.NewScheduledTaskRepository,
 		wire.Bind(new(usecases.ScheduledTaskRepository), new(*persistence.SimpleScheduledTaskRepository)),
 		persistence.NewDeviceRepository,
+		provideDeviceKeyCipher,
 		wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
+		provideNetworkServerProvisioner,
 		usecases.NewDeviceService,
 		wire.Bind(new(usecases.DeviceService), new(*usecases.SimpleDeviceService)),
 		sharedPersistence.NewTenantRepository,
@@ -85,6 +174,8 @@ func InitializeScheduledTaskController() (*httpapi.ScheduledTaskController, erro
 		wire.Bind(new(sharedUsecases.DeviceAdopter), new(*usecases.SimpleDeviceService)),
 		sharedUsecases.NewTenantService,
 		wire.Bind(new(sharedUsecases.TenantService), new(*sharedUsecases.SimpleTenantService)),
+		persistence.NewScheduledTaskRunRepository,
+		wire.Bind(new(usecases.ScheduledTaskRunRepository), new(*persistence.SimpleScheduledTaskRunRepository)),
 		usecases.NewScheduledTaskService,
 		wire.Bind(new(usecases.ScheduledTaskService), new(*usecases.SimpleScheduledTaskService)),
 		persistence.NewTaskRepository,
@@ -93,27 +184,101 @@ func InitializeScheduledTaskController() (*httpapi.ScheduledTaskController, erro
 		wire.Bind(new(usecases.CommandRepository), new(*persistence.SimpleCommandRepository)),
 		usecases.NewTaskService,
 		wire.Bind(new(usecases.TaskService), new(*usecases.SimpleTaskService)),
+		sharedPersistence.NewTenantConfigurationRepository,
+		wire.Bind(new(sharedUsecases.TenantConfigurationRepository), new(*sharedPersistence.SimpleTenantConfigurationRepository)),
+		sharedPersistence.NewUserRepository,
+		wire.Bind(new(sharedUsecases.UserRepository), new(*sharedPersistence.SimpleUserRepository)),
+		sharedUsecases.NewUserService,
+		wire.Bind(new(sharedUsecases.UserService), new(*sharedUsecases.SimpleUserService)),
+		sharedUsecases.NewTenantConfigurationService,
+		wire.Bind(new(sharedUsecases.TenantConfigurationService), new(*sharedUsecases.SimpleTenantConfigurationService)),
+		persistence.NewCommandTemplateSetRepository,
+		wire.Bind(new(usecases.CommandTemplateSetRepository), new(*persistence.SimpleCommandTemplateSetRepository)),
+		usecases.NewCommandTemplateSetService,
+		wire.Bind(new(usecases.CommandTemplateSetService), new(*usecases.SimpleCommandTemplateSetService)),
 		httpapi.NewScheduledTaskController,
 	)
 
 	return nil, nil
 }
 
-func InitializeScheduledTaskWorker(broker async.InternalBroker) (*usecases.ScheduledTaskWorker, error) {
+func InitializeCommandTemplateSetController() (*httpapi.CommandTemplateSetController, error) {
+	wire.Build(
+		provideAppConfig,
+		provideDatabase,
+		persistence.NewCommandTemplateSetRepository,
+		wire.Bind(new(usecases.CommandTemplateSetRepository), new(*persistence.SimpleCommandTemplateSetRepository)),
+		persistence.NewScheduledTaskRepository,
+		wire.Bind(new(usecases.ScheduledTaskRepository), new(*persistence.SimpleScheduledTaskRepository)),
+		usecases.NewCommandTemplateSetService,
+		wire.Bind(new(usecases.CommandTemplateSetService), new(*usecases.SimpleCommandTemplateSetService)),
+		persistence.NewDeviceRepository,
+		provideDeviceKeyCipher,
+		wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
+		persistence.NewCommandRepository,
+		wire.Bind(new(usecases.CommandRepository), new(*persistence.SimpleCommandRepository)),
+		provideNetworkServerProvisioner,
+		usecases.NewDeviceService,
+		wire.Bind(new(sharedUsecases.DeviceAdopter), new(*usecases.SimpleDeviceService)),
+		sharedPersistence.NewTenantRepository,
+		wire.Bind(new(sharedUsecases.TenantRepository), new(*sharedPersistence.SimpleTenantRepository)),
+		sharedUsecases.NewTenantService,
+		wire.Bind(new(sharedUsecases.TenantService), new(*sharedUsecases.SimpleTenantService)),
+		httpapi.NewCommandTemplateSetController,
+	)
+
+	return nil, nil
+}
+
+func InitializeWebhookController() (*httpapi.WebhookController, error) {
+	wire.Build(
+		provideAppConfig,
+		provideDatabase,
+		provideDeviceKeyCipher,
+		persistence.NewWebhookSubscriptionRepository,
+		wire.Bind(new(usecases.WebhookSubscriptionRepository), new(*persistence.SimpleWebhookSubscriptionRepository)),
+		persistence.NewWebhookDeliveryRepository,
+		wire.Bind(new(usecases.WebhookDeliveryRepository), new(*persistence.SimpleWebhookDeliveryRepository)),
+		usecases.NewWebhookService,
+		wire.Bind(new(usecases.WebhookService), new(*usecases.SimpleWebhookService)),
+		persistence.NewDeviceRepository,
+		wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
+		persistence.NewCommandRepository,
+		wire.Bind(new(usecases.CommandRepository), new(*persistence.SimpleCommandRepository)),
+		provideNetworkServerProvisioner,
+		usecases.NewDeviceService,
+		wire.Bind(new(sharedUsecases.DeviceAdopter), new(*usecases.SimpleDeviceService)),
+		sharedPersistence.NewTenantRepository,
+		wire.Bind(new(sharedUsecases.TenantRepository), new(*sharedPersistence.SimpleTenantRepository)),
+		sharedUsecases.NewTenantService,
+		wire.Bind(new(sharedUsecases.TenantService), new(*sharedUsecases.SimpleTenantService)),
+		httpapi.NewWebhookController,
+	)
+
+	return nil, nil
+}
+
+func InitializeScheduledTaskWorker() (*usecases.ScheduledTaskWorker, error) {
 	wire.Build(
 		provideAppConfig,
 		provideTicker,
 		provideDatabase,
 		persistence.NewScheduledTaskRepository,
 		wire.Bind(new(usecases.ScheduledTaskRepository), new(*persistence.SimpleScheduledTaskRepository)),
+		persistence.NewScheduledTaskRunRepository,
+		wire.Bind(new(usecases.ScheduledTaskRunRepository), new(*persistence.SimpleScheduledTaskRunRepository)),
+		persistence.NewCommandTemplateSetRepository,
+		wire.Bind(new(usecases.CommandTemplateSetRepository), new(*persistence.SimpleCommandTemplateSetRepository)),
 		persistence.NewTaskRepository,
 		wire.Bind(new(usecases.TaskRepository), new(*persistence.SimpleTaskRepository)),
 		persistence.NewDeviceRepository,
+		provideDeviceKeyCipher,
 		wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
 		persistence.NewCommandRepository,
 		wire.Bind(new(usecases.CommandRepository), new(*persistence.SimpleCommandRepository)),
 		usecases.NewTaskService,
 		wire.Bind(new(usecases.TaskService), new(*usecases.SimpleTaskService)),
+		provideNetworkServerProvisioner,
 		usecases.NewDeviceService,
 		wire.Bind(new(usecases.DeviceService), new(*usecases.SimpleDeviceService)),
 		sharedPersistence.NewTenantConfigurationRepository,
@@ -146,18 +311,31 @@ func InitializeLoraIntegrationWorker(ticker *time.Ticker, mqttClient mqtt.Client
 		provideAppConfig,
 		DeviceServiceSet,
 		wire.Bind(new(usecases.DeviceService), new(*usecases.SimpleDeviceService)),
+		DeviceSessionServiceSet,
 		provideDeviceStateCacheService,
+		persistence.NewOutboxRepository,
+		wire.Bind(new(usecases.OutboxRepository), new(*persistence.SimpleOutboxRepository)),
+		provideLoraIntegrationConfig,
 		workers.NewLoraIntegrationWorker,
 	)
 	return nil, nil
 }
 
+var DeviceSessionServiceSet = wire.NewSet(
+	persistence.NewDeviceSessionRepository,
+	wire.Bind(new(usecases.DeviceSessionRepository), new(*persistence.SimpleDeviceSessionRepository)),
+	usecases.NewDeviceSessionService,
+	wire.Bind(new(usecases.DeviceSessionService), new(*usecases.SimpleDeviceSessionService)),
+)
+
 var DeviceServiceSet = wire.NewSet(
 	provideDatabase,
 	persistence.NewDeviceRepository,
+	provideDeviceKeyCipher,
 	wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
 	persistence.NewCommandRepository,
 	wire.Bind(new(usecases.CommandRepository), new(*persistence.SimpleCommandRepository)),
+	provideNetworkServerProvisioner,
 	usecases.NewDeviceService,
 )
 
@@ -188,6 +366,117 @@ func provideDatabase(config config.AppConfig) sql.ORM {
 	return orm
 }
 
+func InitializeProvisioningWorker() (*usecases.ProvisioningWorker, error) {
+	wire.Build(
+		provideAppConfig,
+		provideTicker,
+		provideDatabase,
+		persistence.NewDeviceRepository,
+		provideDeviceKeyCipher,
+		wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
+		provideNetworkServerProvisioner,
+		usecases.NewProvisioningWorker,
+	)
+	return nil, nil
+}
+
+func InitializeDeviceKeyRotationWorker() (*usecases.DeviceKeyRotationWorker, error) {
+	wire.Build(
+		provideAppConfig,
+		provideTicker,
+		provideDatabase,
+		persistence.NewDeviceRepository,
+		provideDeviceKeyCipher,
+		wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
+		persistence.NewWebhookSubscriptionRepository,
+		wire.Bind(new(usecases.WebhookSubscriptionRepository), new(*persistence.SimpleWebhookSubscriptionRepository)),
+		usecases.NewDeviceKeyRotationWorker,
+	)
+	return nil, nil
+}
+
+func InitializeDeviceConfigJobWorker() (*usecases.DeviceConfigJobWorker, error) {
+	wire.Build(
+		provideAppConfig,
+		provideTicker,
+		provideDatabase,
+		persistence.NewDeviceConfigJobRepository,
+		wire.Bind(new(usecases.DeviceConfigJobRepository), new(*persistence.SimpleDeviceConfigJobRepository)),
+		persistence.NewDeviceRepository,
+		provideDeviceKeyCipher,
+		wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
+		persistence.NewCommandRepository,
+		wire.Bind(new(usecases.CommandRepository), new(*persistence.SimpleCommandRepository)),
+		usecases.NewDeviceConfigJobWorker,
+	)
+	return nil, nil
+}
+
+// The development secrets key is committed in config/server.yaml, so it protects nothing outside
+// ENV=local.
+const (
+	_developmentSecretsKeyID = "dev"
+	_developmentSecretsKey   = "ZGV2LW9ubHktc2VjcmV0cy1rZXktZG8tbm90LXVzZSE="
+)
+
+func provideDeviceKeyCipher(appConfig config.AppConfig) (secrets.Cipher, error) {
+	keys, err := secrets.ParseKeys(appConfig.Secrets.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("parsing secrets keys: %w", err)
+	}
+
+	if env, _ := os.LookupEnv("ENV"); env != "local" {
+		err = checkDevelopmentSecretsKey(appConfig.Secrets.PrimaryKeyID, keys)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return secrets.NewKeyring(appConfig.Secrets.PrimaryKeyID, keys)
+}
+
+// checkDevelopmentSecretsKey refuses to seal secrets with the development key. It may stay listed
+// as a retired key, so secrets sealed with it can still be rewrapped with the primary one.
+func checkDevelopmentSecretsKey(primaryKeyID string, keys []secrets.Key) error {
+	if primaryKeyID == _developmentSecretsKeyID {
+		return fmt.Errorf("secrets primary key %q is the development key; set ZENSOR_SERVER_SECRETS_KEYS and ZENSOR_SERVER_SECRETS_PRIMARY_KEY_ID", primaryKeyID)
+	}
+
+	for _, key := range keys {
+		if key.ID != _developmentSecretsKeyID && base64.StdEncoding.EncodeToString(key.Material) != _developmentSecretsKey {
+			continue
+		}
+		if key.ID == primaryKeyID {
+			return fmt.Errorf("secrets primary key %q is the development key; set ZENSOR_SERVER_SECRETS_KEYS and ZENSOR_SERVER_SECRETS_PRIMARY_KEY_ID", primaryKeyID)
+		}
+		slog.Warn("the development secrets key is still listed; remove it once every secret is rewrapped", slog.String("key_id", key.ID))
+	}
+
+	return nil
+}
+
+// provideNetworkServerProvisioner returns nil when provisioning is disabled, which leaves device
+// registration in TTN to be done by hand.
+func provideNetworkServerProvisioner(appConfig config.AppConfig) usecases.NetworkServerProvisioner {
+	provisioning := appConfig.TTN.Provisioning
+	if !provisioning.Enabled {
+		return nil
+	}
+
+	if env, _ := os.LookupEnv("ENV"); env == "local" {
+		return ttn.NewFakeProvisioner()
+	}
+
+	return ttn.NewRegistryClient(ttn.RegistryConfig{
+		BaseURL:           provisioning.BaseURL,
+		ApplicationID:     provisioning.ApplicationID,
+		APIKey:            provisioning.APIKey,
+		FrequencyPlanID:   provisioning.FrequencyPlanID,
+		LoRaWANVersion:    provisioning.LoRaWANVersion,
+		LoRaWANPHYVersion: provisioning.LoRaWANPHYVersion,
+	})
+}
+
 func InitializeCommandWorker(broker async.InternalBroker) (*usecases.CommandWorker, error) {
 	wire.Build(
 		provideAppConfig,
@@ -195,11 +484,98 @@ func InitializeCommandWorker(broker async.InternalBroker) (*usecases.CommandWork
 		provideDatabase,
 		persistence.NewCommandRepository,
 		wire.Bind(new(usecases.CommandRepository), new(*persistence.SimpleCommandRepository)),
+		persistence.NewOutboxRepository,
+		wire.Bind(new(usecases.OutboxRepository), new(*persistence.SimpleOutboxRepository)),
 		usecases.NewCommandWorker,
 	)
 	return nil, nil
 }
 
+func InitializeOutboxRelayWorker(broker async.InternalBroker) (*usecases.OutboxRelayWorker, error) {
+	wire.Build(
+		provideAppConfig,
+		provideOutboxRelayTicker,
+		provideOutboxRelayConfig,
+		provideDatabase,
+		persistence.NewOutboxRepository,
+		wire.Bind(new(usecases.OutboxRepository), new(*persistence.SimpleOutboxRepository)),
+		usecases.NewOutboxRelayWorker,
+	)
+	return nil, nil
+}
+
+func InitializeWebhookWorker(broker async.InternalBroker) (*usecases.WebhookWorker, error) {
+	wire.Build(
+		provideAppConfig,
+		provideWebhookTicker,
+		provideWebhookWorkerConfig,
+		provideDatabase,
+		provideDeviceKeyCipher,
+		persistence.NewWebhookSubscriptionRepository,
+		wire.Bind(new(usecases.WebhookSubscriptionRepository), new(*persistence.SimpleWebhookSubscriptionRepository)),
+		persistence.NewWebhookDeliveryRepository,
+		wire.Bind(new(usecases.WebhookDeliveryRepository), new(*persistence.SimpleWebhookDeliveryRepository)),
+		persistence.NewDeviceRepository,
+		wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
+		usecases.NewWebhookWorker,
+	)
+	return nil, nil
+}
+
+func InitializeMQTTBridgeWorker(mqttClient mqtt.Client, broker async.InternalBroker) (*usecases.MQTTBridgeWorker, error) {
+	wire.Build(
+		provideAppConfig,
+		provideMQTTBridgeConfig,
+		provideDatabase,
+		provideDeviceKeyCipher,
+		persistence.NewDeviceRepository,
+		wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
+		persistence.NewCommandRepository,
+		wire.Bind(new(usecases.CommandRepository), new(*persistence.SimpleCommandRepository)),
+		persistence.NewTaskRepository,
+		wire.Bind(new(usecases.TaskRepository), new(*persistence.SimpleTaskRepository)),
+		usecases.NewTaskService,
+		wire.Bind(new(usecases.TaskService), new(*usecases.SimpleTaskService)),
+		usecases.NewMQTTBridgeWorker,
+	)
+	return nil, nil
+}
+
+func InitializeConnectivityWatchdogWorker(broker async.InternalBroker) (*usecases.ConnectivityWatchdogWorker, error) {
+	wire.Build(
+		provideAppConfig,
+		provideTicker,
+		provideDatabase,
+		persistence.NewDeviceRepository,
+		provideDeviceKeyCipher,
+		wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
+		persistence.NewDeviceConnectivityRepository,
+		wire.Bind(new(usecases.DeviceConnectivityRepository), new(*persistence.SimpleDeviceConnectivityRepository)),
+		usecases.NewConnectivityWatchdogWorker,
+	)
+	return nil, nil
+}
+
+func InitializeDeviceHealthWorker(broker async.InternalBroker) (*usecases.DeviceHealthWorker, error) {
+	wire.Build(
+		provideAppConfig,
+		provideDeviceHealthTicker,
+		provideDeviceHealthPolicy,
+		provideDatabase,
+		persistence.NewDeviceRepository,
+		provideDeviceKeyCipher,
+		wire.Bind(new(usecases.DeviceRepository), new(*persistence.SimpleDeviceRepository)),
+		persistence.NewDeviceSessionRepository,
+		wire.Bind(new(usecases.DeviceSessionRepository), new(*persistence.SimpleDeviceSessionRepository)),
+		persistence.NewCommandRepository,
+		wire.Bind(new(usecases.CommandRepository), new(*persistence.SimpleCommandRepository)),
+		sharedPersistence.NewTenantConfigurationRepository,
+		wire.Bind(new(sharedUsecases.TenantConfigurationRepository), new(*sharedPersistence.SimpleTenantConfigurationRepository)),
+		usecases.NewDeviceHealthWorker,
+	)
+	return nil, nil
+}
+
 func InitializeNotificationWorker(broker async.InternalBroker) (*usecases.NotificationWorker, error) {
 	wire.Build(
 		provideAppConfig,
@@ -221,6 +597,8 @@ func InitializeNotificationWorker(broker async.InternalBroker) (*usecases.Notifi
 		wire.Bind(new(sharedUsecases.UserService), new(*sharedUsecases.SimpleUserService)),
 		sharedUsecases.NewTenantConfigurationService,
 		wire.Bind(new(sharedUsecases.TenantConfigurationService), new(*sharedUsecases.SimpleTenantConfigurationService)),
+		persistence.NewOutboxRepository,
+		wire.Bind(new(usecases.OutboxRepository), new(*persistence.SimpleOutboxRepository)),
 		usecases.NewNotificationWorker,
 	)
 	return nil, nil
@@ -231,6 +609,66 @@ func provideTicker() *time.Ticker {
 	return ticker
 }
 
+func provideDeviceHealthTicker(appConfig config.AppConfig) *time.Ticker {
+	return time.NewTicker(appConfig.Health.EvaluationInterval)
+}
+
+func provideDeviceHealthPolicy(appConfig config.AppConfig) (domain.DeviceHealthPolicy, error) {
+	policy := domain.DeviceHealthPolicy{
+		BatteryEmptyVoltage: appConfig.Health.BatteryEmptyVoltage,
+		BatteryFullVoltage:  appConfig.Health.BatteryFullVoltage,
+		LowBatteryPercent:   appConfig.Health.LowBatteryPercent,
+	}
+	if err := policy.Validate(); err != nil {
+		return domain.DeviceHealthPolicy{}, fmt.Errorf("health configuration: %w", err)
+	}
+	return policy, nil
+}
+
+func provideOutboxRelayTicker(appConfig config.AppConfig) *time.Ticker {
+	return time.NewTicker(appConfig.Outbox.RelayInterval)
+}
+
+func provideOutboxRelayConfig(appConfig config.AppConfig) usecases.OutboxRelayConfig {
+	return usecases.OutboxRelayConfig{
+		RedeliveryTimeout: appConfig.Outbox.RedeliveryTimeout,
+		MaxAttempts:       appConfig.Outbox.MaxAttempts,
+		Retention:         appConfig.Outbox.Retention,
+	}
+}
+
+func provideWebhookTicker(appConfig config.AppConfig) *time.Ticker {
+	return time.NewTicker(appConfig.Webhooks.DeliveryInterval)
+}
+
+func provideLoraIntegrationConfig(appConfig config.AppConfig) workers.LoraIntegrationConfig {
+	return workers.LoraIntegrationConfig{
+		SubscribeQoS: appConfig.MQTTClient.SubscribeQoS,
+	}
+}
+
+func provideWebhookWorkerConfig(appConfig config.AppConfig) usecases.WebhookWorkerConfig {
+	return usecases.WebhookWorkerConfig{
+		Timeout: appConfig.Webhooks.Timeout,
+		RetryPolicy: domain.WebhookRetryPolicy{
+			MaxAttempts:    appConfig.Webhooks.MaxAttempts,
+			InitialBackoff: appConfig.Webhooks.InitialBackoff,
+			MaxBackoff:     appConfig.Webhooks.MaxBackoff,
+		},
+		AllowPrivateNetworks: appConfig.Webhooks.AllowPrivateNetworks,
+	}
+}
+
+func provideMQTTBridgeConfig(appConfig config.AppConfig) usecases.MQTTBridgeConfig {
+	return usecases.MQTTBridgeConfig{
+		SensorTopic:     appConfig.MQTTBridge.SensorTopic,
+		CommandTopic:    appConfig.MQTTBridge.CommandTopic,
+		QoS:             appConfig.MQTTBridge.QoS,
+		AcceptCommands:  appConfig.MQTTBridge.Commands.Enabled,
+		WritableSensors: appConfig.MQTTBridge.Commands.Sensors,
+	}
+}
+
 func provideNotificationClient(config config.AppConfig) notification.NotificationClient {
 	mailerSendConfig := notification.MailerSendConfig{
 		APIKey:    config.MailerSend.APIKey,
@@ -298,3 +736,77 @@ func provideDeviceStateCacheService() usecases.DeviceStateCacheService {
 func InitializeMetricWorkerFactory(broker async.InternalBroker) *usecases.MetricWorkerFactory {
 	return usecases.NewMetricWorkerFactory(broker)
 }
+
+func InitializeLeaderElector(workers ...async.Worker) (*leader.Elector, error) {
+	appConfig := provideAppConfig()
+	lease, err := provideLeaderLease(appConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return leader.NewElector(lease, node.GetNodeInfo().ID, appConfig.LeaderElection.LeaseTTL, workers...), nil
+}
+
+func provideLeaderLease(appConfig config.AppConfig) (leader.Lease, error) {
+	if !appConfig.LeaderElection.Enabled {
+		return leader.NewLocalLease(), nil
+	}
+	// Singleton workers consume broker events, so they only see the events of every replica when
+	// the broker shares them through Redis.
+	if appConfig.Broker.Backend != config.BrokerBackendRedis {
+		return nil, fmt.Errorf("leader election requires the %q broker backend, got %q", config.BrokerBackendRedis, appConfig.Broker.Backend)
+	}
+
+	redisCache, err := cache.NewRedisCache(&cache.RedisConfig{
+		Addr:     appConfig.Redis.Addr,
+		Password: appConfig.Redis.Password,
+		DB:       appConfig.Redis.DB,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("connecting to leader election lease store: %w", err)
+	}
+
+	return redisCache.Lease(appConfig.LeaderElection.LeaseKey), nil
+}
+
+// InitializeInternalBroker creates the broker of the configured backend. The redis backend shares
+// the connection settings of the cache and joins the stream under the node ID.
+func InitializeInternalBroker() (async.InternalBroker, error) {
+	appConfig := provideAppConfig()
+	localConfig := async.LocalBrokerConfig{
+		QueueCapacity:  appConfig.Broker.QueueCapacity,
+		OverflowPolicy: async.OverflowPolicy(appConfig.Broker.OverflowPolicy),
+	}
+
+	switch appConfig.Broker.Backend {
+	case config.BrokerBackendLocal:
+		broker, err := async.NewLocalBrokerWithConfig(localConfig)
+		if err != nil {
+			return nil, err
+		}
+		return broker, nil
+	case config.BrokerBackendRedis:
+		client, err := cache.Connect(&cache.RedisConfig{
+			Addr:     appConfig.Redis.Addr,
+			Password: appConfig.Redis.Password,
+			DB:       appConfig.Redis.DB,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("connecting to broker stream store: %w", err)
+		}
+
+		broker, err := async.NewRedisStreamBroker(client, async.DefaultEventRegistry, async.RedisStreamBrokerConfig{
+			Stream:           appConfig.Broker.Redis.Stream,
+			Consumer:         node.GetNodeInfo().ID,
+			MaxLen:           appConfig.Broker.Redis.MaxLen,
+			GroupIdleTimeout: appConfig.Broker.Redis.GroupIdleTimeout,
+			Local:            localConfig,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return broker, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown backend %q", async.ErrInvalidBrokerConfig, appConfig.Broker.Backend)
+	}
+}
@@ -8,6 +8,8 @@ package wire
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
 	"github.com/google/wire"
 	"log/slog"
 	"os"
@@ -20,12 +22,17 @@ import (
 	"zensor-server/internal/infra/async"
 	"zensor-server/internal/infra/cache"
 	"zensor-server/internal/infra/config"
+	"zensor-server/internal/infra/leader"
 	"zensor-server/internal/infra/mqtt"
+	"zensor-server/internal/infra/node"
 	"zensor-server/internal/infra/notification"
+	"zensor-server/internal/infra/secrets"
 	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/infra/ttn"
 	httpapi3 "zensor-server/internal/maintenance/httpapi"
 	persistence3 "zensor-server/internal/maintenance/persistence"
 	usecases3 "zensor-server/internal/maintenance/usecases"
+	"zensor-server/internal/shared_kernel/domain"
 	"zensor-server/internal/shared_kernel/httpapi"
 	"zensor-server/internal/shared_kernel/persistence"
 	"zensor-server/internal/shared_kernel/usecases"
@@ -56,7 +63,11 @@ func InitializeTenantController() (*httpapi.TenantController, error) {
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +75,8 @@ func InitializeTenantController() (*httpapi.TenantController, error) {
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository)
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository, networkServerProvisioner)
 	simpleTenantService := usecases.NewTenantService(simpleTenantRepository, simpleDeviceService)
 	tenantController := httpapi.NewTenantController(simpleTenantService)
 	return tenantController, nil
@@ -121,7 +133,11 @@ func InitializeEvaluationRuleController() (*httpapi2.EvaluationRuleController, e
 		return nil, err
 	}
 	simpleEvaluationRuleService := usecases2.NewEvaluationRuleService(evaluationRuleRepository)
-	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
 	if err != nil {
 		return nil, err
 	}
@@ -129,7 +145,8 @@ func InitializeEvaluationRuleController() (*httpapi2.EvaluationRuleController, e
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository)
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository, networkServerProvisioner)
 	evaluationRuleController := httpapi2.NewEvaluationRuleController(simpleEvaluationRuleService, simpleDeviceService)
 	return evaluationRuleController, nil
 }
@@ -137,7 +154,11 @@ func InitializeEvaluationRuleController() (*httpapi2.EvaluationRuleController, e
 func InitializeDeviceController() (*httpapi2.DeviceController, error) {
 	appConfig := provideAppConfig()
 	orm := provideDatabase(appConfig)
-	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
 	if err != nil {
 		return nil, err
 	}
@@ -145,11 +166,111 @@ func InitializeDeviceController() (*httpapi2.DeviceController, error) {
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository)
-	deviceController := httpapi2.NewDeviceController(simpleDeviceService)
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository, networkServerProvisioner)
+	simpleScheduledTaskRepository, err := persistence2.NewScheduledTaskRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleScheduledTaskRunRepository, err := persistence2.NewScheduledTaskRunRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleCommandTemplateSetRepository, err := persistence2.NewCommandTemplateSetRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	evaluationRuleRepository, err := persistence2.NewEvaluationRuleRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleTransactor := persistence2.NewTransactor(orm)
+	simpleDeviceLifecycleService := usecases2.NewDeviceLifecycleService(simpleDeviceRepository, simpleCommandRepository, simpleScheduledTaskRepository, simpleScheduledTaskRunRepository, simpleCommandTemplateSetRepository, evaluationRuleRepository, networkServerProvisioner, simpleTransactor)
+	simpleDeviceSessionRepository, err := persistence2.NewDeviceSessionRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceSessionService := usecases2.NewDeviceSessionService(simpleDeviceRepository, simpleDeviceSessionRepository)
+	simpleTenantRepository, err := persistence.NewTenantRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleTenantService := usecases.NewTenantService(simpleTenantRepository, simpleDeviceService)
+	deviceController := httpapi2.NewDeviceController(simpleDeviceService, simpleDeviceLifecycleService, simpleDeviceSessionService, simpleTenantService)
 	return deviceController, nil
 }
 
+func InitializeDeviceImportController() (*httpapi2.DeviceImportController, error) {
+	appConfig := provideAppConfig()
+	orm := provideDatabase(appConfig)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	simpleTenantRepository, err := persistence.NewTenantRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleCommandRepository, err := persistence2.NewCommandRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository, networkServerProvisioner)
+	simpleTenantService := usecases.NewTenantService(simpleTenantRepository, simpleDeviceService)
+	simpleDeviceImportService := usecases2.NewDeviceImportService(simpleDeviceRepository, simpleTenantService, networkServerProvisioner)
+	deviceImportController := httpapi2.NewDeviceImportController(simpleDeviceImportService)
+	return deviceImportController, nil
+}
+
+func InitializeDeviceKeyController() (*httpapi2.DeviceKeyController, error) {
+	appConfig := provideAppConfig()
+	orm := provideDatabase(appConfig)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceKeyAccessRepository, err := persistence2.NewDeviceKeyAccessRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceKeyService := usecases2.NewDeviceKeyService(simpleDeviceRepository, simpleDeviceKeyAccessRepository)
+	deviceKeyController := httpapi2.NewDeviceKeyController(simpleDeviceKeyService)
+	return deviceKeyController, nil
+}
+
+func InitializeDeviceConfigJobController() (*httpapi2.DeviceConfigJobController, error) {
+	appConfig := provideAppConfig()
+	orm := provideDatabase(appConfig)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	simpleCommandRepository, err := persistence2.NewCommandRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceConfigJobRepository, err := persistence2.NewDeviceConfigJobRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceConfigJobService := usecases2.NewDeviceConfigJobService(simpleDeviceRepository, simpleCommandRepository, simpleDeviceConfigJobRepository)
+	deviceConfigJobController := httpapi2.NewDeviceConfigJobController(simpleDeviceConfigJobService)
+	return deviceConfigJobController, nil
+}
+
 func InitializeTaskController() (*httpapi2.TaskController, error) {
 	appConfig := provideAppConfig()
 	orm := provideDatabase(appConfig)
@@ -161,12 +282,17 @@ func InitializeTaskController() (*httpapi2.TaskController, error) {
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
 	if err != nil {
 		return nil, err
 	}
 	simpleTaskService := usecases2.NewTaskService(simpleTaskRepository, simpleCommandRepository, simpleDeviceRepository)
-	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository)
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository, networkServerProvisioner)
 	taskController := httpapi2.NewTaskController(simpleTaskService, simpleDeviceService)
 	return taskController, nil
 }
@@ -178,8 +304,7 @@ func InitializeScheduledTaskController() (*httpapi2.ScheduledTaskController, err
 	if err != nil {
 		return nil, err
 	}
-	simpleScheduledTaskService := usecases2.NewScheduledTaskService(simpleScheduledTaskRepository)
-	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm)
+	simpleScheduledTaskRunRepository, err := persistence2.NewScheduledTaskRunRepository(orm)
 	if err != nil {
 		return nil, err
 	}
@@ -187,7 +312,17 @@ func InitializeScheduledTaskController() (*httpapi2.ScheduledTaskController, err
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository)
+	simpleScheduledTaskService := usecases2.NewScheduledTaskService(simpleScheduledTaskRepository, simpleScheduledTaskRunRepository)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository, networkServerProvisioner)
 	simpleTenantRepository, err := persistence.NewTenantRepository(orm)
 	if err != nil {
 		return nil, err
@@ -198,11 +333,96 @@ func InitializeScheduledTaskController() (*httpapi2.ScheduledTaskController, err
 		return nil, err
 	}
 	simpleTaskService := usecases2.NewTaskService(simpleTaskRepository, simpleCommandRepository, simpleDeviceRepository)
-	scheduledTaskController := httpapi2.NewScheduledTaskController(simpleScheduledTaskService, simpleDeviceService, simpleTenantService, simpleTaskService)
+	simpleTenantConfigurationRepository, err := persistence.NewTenantConfigurationRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleUserRepository, err := persistence.NewUserRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleUserService := usecases.NewUserService(simpleUserRepository, simpleTenantRepository)
+	simpleTenantConfigurationService := usecases.NewTenantConfigurationService(simpleTenantConfigurationRepository, simpleUserService)
+	simpleCommandTemplateSetRepository, err := persistence2.NewCommandTemplateSetRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleCommandTemplateSetService := usecases2.NewCommandTemplateSetService(simpleCommandTemplateSetRepository, simpleScheduledTaskRepository)
+	scheduledTaskController := httpapi2.NewScheduledTaskController(simpleScheduledTaskService, simpleDeviceService, simpleTenantService, simpleTaskService, simpleTenantConfigurationService, simpleCommandTemplateSetService)
 	return scheduledTaskController, nil
 }
 
-func InitializeScheduledTaskWorker(broker async.InternalBroker) (*usecases2.ScheduledTaskWorker, error) {
+func InitializeCommandTemplateSetController() (*httpapi2.CommandTemplateSetController, error) {
+	appConfig := provideAppConfig()
+	orm := provideDatabase(appConfig)
+	simpleCommandTemplateSetRepository, err := persistence2.NewCommandTemplateSetRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleScheduledTaskRepository, err := persistence2.NewScheduledTaskRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleCommandTemplateSetService := usecases2.NewCommandTemplateSetService(simpleCommandTemplateSetRepository, simpleScheduledTaskRepository)
+	simpleTenantRepository, err := persistence.NewTenantRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	simpleCommandRepository, err := persistence2.NewCommandRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository, networkServerProvisioner)
+	simpleTenantService := usecases.NewTenantService(simpleTenantRepository, simpleDeviceService)
+	commandTemplateSetController := httpapi2.NewCommandTemplateSetController(simpleCommandTemplateSetService, simpleTenantService)
+	return commandTemplateSetController, nil
+}
+
+func InitializeWebhookController() (*httpapi2.WebhookController, error) {
+	appConfig := provideAppConfig()
+	orm := provideDatabase(appConfig)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleWebhookSubscriptionRepository, err := persistence2.NewWebhookSubscriptionRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	simpleWebhookDeliveryRepository, err := persistence2.NewWebhookDeliveryRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleWebhookService := usecases2.NewWebhookService(simpleWebhookSubscriptionRepository, simpleWebhookDeliveryRepository)
+	simpleTenantRepository, err := persistence.NewTenantRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	simpleCommandRepository, err := persistence2.NewCommandRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository, networkServerProvisioner)
+	simpleTenantService := usecases.NewTenantService(simpleTenantRepository, simpleDeviceService)
+	webhookController := httpapi2.NewWebhookController(simpleWebhookService, simpleTenantService)
+	return webhookController, nil
+}
+
+func InitializeScheduledTaskWorker() (*usecases2.ScheduledTaskWorker, error) {
 	ticker := provideTicker()
 	appConfig := provideAppConfig()
 	orm := provideDatabase(appConfig)
@@ -210,6 +430,14 @@ func InitializeScheduledTaskWorker(broker async.InternalBroker) (*usecases2.Sche
 	if err != nil {
 		return nil, err
 	}
+	simpleScheduledTaskRunRepository, err := persistence2.NewScheduledTaskRunRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleCommandTemplateSetRepository, err := persistence2.NewCommandTemplateSetRepository(orm)
+	if err != nil {
+		return nil, err
+	}
 	simpleTaskRepository, err := persistence2.NewTaskRepository(orm)
 	if err != nil {
 		return nil, err
@@ -218,12 +446,17 @@ func InitializeScheduledTaskWorker(broker async.InternalBroker) (*usecases2.Sche
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
 	if err != nil {
 		return nil, err
 	}
 	simpleTaskService := usecases2.NewTaskService(simpleTaskRepository, simpleCommandRepository, simpleDeviceRepository)
-	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository)
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository, networkServerProvisioner)
 	simpleTenantConfigurationRepository, err := persistence.NewTenantConfigurationRepository(orm)
 	if err != nil {
 		return nil, err
@@ -238,14 +471,18 @@ func InitializeScheduledTaskWorker(broker async.InternalBroker) (*usecases2.Sche
 	}
 	simpleUserService := usecases.NewUserService(simpleUserRepository, simpleTenantRepository)
 	simpleTenantConfigurationService := usecases.NewTenantConfigurationService(simpleTenantConfigurationRepository, simpleUserService)
-	scheduledTaskWorker := usecases2.NewScheduledTaskWorker(ticker, simpleScheduledTaskRepository, simpleTaskService, simpleDeviceService, simpleTenantConfigurationService, broker)
+	scheduledTaskWorker := usecases2.NewScheduledTaskWorker(ticker, simpleScheduledTaskRepository, simpleScheduledTaskRunRepository, simpleCommandTemplateSetRepository, simpleTaskService, simpleDeviceService, simpleTenantConfigurationService)
 	return scheduledTaskWorker, nil
 }
 
 func InitializeDeviceService() (usecases2.DeviceService, error) {
 	appConfig := provideAppConfig()
 	orm := provideDatabase(appConfig)
-	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
 	if err != nil {
 		return nil, err
 	}
@@ -253,14 +490,19 @@ func InitializeDeviceService() (usecases2.DeviceService, error) {
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository)
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository, networkServerProvisioner)
 	return simpleDeviceService, nil
 }
 
 func InitializeLoraIntegrationWorker(ticker *time.Ticker, mqttClient mqtt.Client, broker async.InternalBroker) (*workers.LoraIntegrationWorker, error) {
 	appConfig := provideAppConfig()
 	orm := provideDatabase(appConfig)
-	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
 	if err != nil {
 		return nil, err
 	}
@@ -268,12 +510,84 @@ func InitializeLoraIntegrationWorker(ticker *time.Ticker, mqttClient mqtt.Client
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository)
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository, networkServerProvisioner)
+	simpleDeviceSessionRepository, err := persistence2.NewDeviceSessionRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceSessionService := usecases2.NewDeviceSessionService(simpleDeviceRepository, simpleDeviceSessionRepository)
 	usecasesDeviceStateCacheService := provideDeviceStateCacheService()
-	loraIntegrationWorker := workers.NewLoraIntegrationWorker(ticker, simpleDeviceService, usecasesDeviceStateCacheService, mqttClient, broker, simpleCommandRepository)
+	simpleOutboxRepository, err := persistence2.NewOutboxRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	loraIntegrationConfig := provideLoraIntegrationConfig(appConfig)
+	loraIntegrationWorker := workers.NewLoraIntegrationWorker(ticker, simpleDeviceService, simpleDeviceSessionService, usecasesDeviceStateCacheService, mqttClient, broker, simpleCommandRepository, simpleOutboxRepository, loraIntegrationConfig)
 	return loraIntegrationWorker, nil
 }
 
+func InitializeProvisioningWorker() (*usecases2.ProvisioningWorker, error) {
+	ticker := provideTicker()
+	appConfig := provideAppConfig()
+	orm := provideDatabase(appConfig)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	provisioningWorker := usecases2.NewProvisioningWorker(ticker, simpleDeviceRepository, networkServerProvisioner)
+	return provisioningWorker, nil
+}
+
+func InitializeDeviceKeyRotationWorker() (*usecases2.DeviceKeyRotationWorker, error) {
+	ticker := provideTicker()
+	appConfig := provideAppConfig()
+	orm := provideDatabase(appConfig)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	simpleWebhookSubscriptionRepository, err := persistence2.NewWebhookSubscriptionRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	deviceKeyRotationWorker := usecases2.NewDeviceKeyRotationWorker(ticker, simpleDeviceRepository, simpleWebhookSubscriptionRepository)
+	return deviceKeyRotationWorker, nil
+}
+
+func InitializeDeviceConfigJobWorker() (*usecases2.DeviceConfigJobWorker, error) {
+	ticker := provideTicker()
+	appConfig := provideAppConfig()
+	orm := provideDatabase(appConfig)
+	simpleDeviceConfigJobRepository, err := persistence2.NewDeviceConfigJobRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	simpleCommandRepository, err := persistence2.NewCommandRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	deviceConfigJobWorker := usecases2.NewDeviceConfigJobWorker(ticker, simpleDeviceConfigJobRepository, simpleDeviceRepository, simpleCommandRepository)
+	return deviceConfigJobWorker, nil
+}
+
 func InitializeCommandWorker(broker async.InternalBroker) (*usecases2.CommandWorker, error) {
 	ticker := provideTicker()
 	appConfig := provideAppConfig()
@@ -282,16 +596,142 @@ func InitializeCommandWorker(broker async.InternalBroker) (*usecases2.CommandWor
 	if err != nil {
 		return nil, err
 	}
-	commandWorker := usecases2.NewCommandWorker(ticker, simpleCommandRepository, broker)
+	simpleOutboxRepository, err := persistence2.NewOutboxRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	commandWorker := usecases2.NewCommandWorker(ticker, simpleCommandRepository, simpleOutboxRepository, broker)
 	return commandWorker, nil
 }
 
+func InitializeOutboxRelayWorker(broker async.InternalBroker) (*usecases2.OutboxRelayWorker, error) {
+	appConfig := provideAppConfig()
+	ticker := provideOutboxRelayTicker(appConfig)
+	orm := provideDatabase(appConfig)
+	simpleOutboxRepository, err := persistence2.NewOutboxRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	outboxRelayConfig := provideOutboxRelayConfig(appConfig)
+	outboxRelayWorker := usecases2.NewOutboxRelayWorker(ticker, simpleOutboxRepository, broker, outboxRelayConfig)
+	return outboxRelayWorker, nil
+}
+
+func InitializeWebhookWorker(broker async.InternalBroker) (*usecases2.WebhookWorker, error) {
+	appConfig := provideAppConfig()
+	ticker := provideWebhookTicker(appConfig)
+	orm := provideDatabase(appConfig)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleWebhookSubscriptionRepository, err := persistence2.NewWebhookSubscriptionRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	simpleWebhookDeliveryRepository, err := persistence2.NewWebhookDeliveryRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	webhookWorkerConfig := provideWebhookWorkerConfig(appConfig)
+	webhookWorker := usecases2.NewWebhookWorker(ticker, simpleWebhookSubscriptionRepository, simpleWebhookDeliveryRepository, simpleDeviceRepository, broker, webhookWorkerConfig)
+	return webhookWorker, nil
+}
+
+func InitializeMQTTBridgeWorker(mqttClient mqtt.Client, broker async.InternalBroker) (*usecases2.MQTTBridgeWorker, error) {
+	appConfig := provideAppConfig()
+	orm := provideDatabase(appConfig)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	simpleCommandRepository, err := persistence2.NewCommandRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleTaskRepository, err := persistence2.NewTaskRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleTaskService := usecases2.NewTaskService(simpleTaskRepository, simpleCommandRepository, simpleDeviceRepository)
+	mqttBridgeConfig := provideMQTTBridgeConfig(appConfig)
+	mqttBridgeWorker, err := usecases2.NewMQTTBridgeWorker(mqttClient, broker, simpleDeviceRepository, simpleCommandRepository, simpleTaskService, mqttBridgeConfig)
+	if err != nil {
+		return nil, err
+	}
+	return mqttBridgeWorker, nil
+}
+
+func InitializeConnectivityWatchdogWorker(broker async.InternalBroker) (*usecases2.ConnectivityWatchdogWorker, error) {
+	ticker := provideTicker()
+	appConfig := provideAppConfig()
+	orm := provideDatabase(appConfig)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceConnectivityRepository, err := persistence2.NewDeviceConnectivityRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	connectivityWatchdogWorker := usecases2.NewConnectivityWatchdogWorker(ticker, simpleDeviceRepository, simpleDeviceConnectivityRepository, broker)
+	return connectivityWatchdogWorker, nil
+}
+
+func InitializeDeviceHealthWorker(broker async.InternalBroker) (*usecases2.DeviceHealthWorker, error) {
+	appConfig := provideAppConfig()
+	ticker := provideDeviceHealthTicker(appConfig)
+	orm := provideDatabase(appConfig)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceSessionRepository, err := persistence2.NewDeviceSessionRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleCommandRepository, err := persistence2.NewCommandRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	simpleTenantConfigurationRepository, err := persistence.NewTenantConfigurationRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	deviceHealthPolicy, err := provideDeviceHealthPolicy(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	deviceHealthWorker := usecases2.NewDeviceHealthWorker(ticker, simpleDeviceRepository, simpleDeviceSessionRepository, simpleCommandRepository, simpleTenantConfigurationRepository, broker, deviceHealthPolicy)
+	return deviceHealthWorker, nil
+}
+
 func InitializeNotificationWorker(broker async.InternalBroker) (*usecases2.NotificationWorker, error) {
 	ticker := provideTicker()
 	appConfig := provideAppConfig()
 	notificationClient := provideNotificationClient(appConfig)
 	orm := provideDatabase(appConfig)
-	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
 	if err != nil {
 		return nil, err
 	}
@@ -299,7 +739,8 @@ func InitializeNotificationWorker(broker async.InternalBroker) (*usecases2.Notif
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository)
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository, networkServerProvisioner)
 	simpleTenantConfigurationRepository, err := persistence.NewTenantConfigurationRepository(orm)
 	if err != nil {
 		return nil, err
@@ -319,7 +760,11 @@ func InitializeNotificationWorker(broker async.InternalBroker) (*usecases2.Notif
 		return nil, err
 	}
 	simpleTaskService := usecases2.NewTaskService(simpleTaskRepository, simpleCommandRepository, simpleDeviceRepository)
-	notificationWorker := usecases2.NewNotificationWorker(ticker, notificationClient, simpleDeviceService, simpleTenantConfigurationService, simpleTaskService, broker)
+	simpleOutboxRepository, err := persistence2.NewOutboxRepository(orm)
+	if err != nil {
+		return nil, err
+	}
+	notificationWorker := usecases2.NewNotificationWorker(ticker, notificationClient, simpleDeviceService, simpleTenantConfigurationService, simpleTaskService, simpleOutboxRepository, broker)
 	return notificationWorker, nil
 }
 
@@ -348,7 +793,11 @@ func InitializeMaintenanceActivityController() (*httpapi3.ActivityController, er
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
 	if err != nil {
 		return nil, err
 	}
@@ -356,7 +805,8 @@ func InitializeMaintenanceActivityController() (*httpapi3.ActivityController, er
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository)
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository, networkServerProvisioner)
 	simpleTenantService := usecases.NewTenantService(simpleTenantRepository, simpleDeviceService)
 	simpleActivityService := usecases3.NewActivityService(simpleActivityRepository, simpleTenantService)
 	activityController := httpapi3.NewActivityController(simpleActivityService)
@@ -379,7 +829,11 @@ func InitializeMaintenanceExecutionController() (*httpapi3.ExecutionController,
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
 	if err != nil {
 		return nil, err
 	}
@@ -387,7 +841,8 @@ func InitializeMaintenanceExecutionController() (*httpapi3.ExecutionController,
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository)
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository, networkServerProvisioner)
 	simpleTenantService := usecases.NewTenantService(simpleTenantRepository, simpleDeviceService)
 	simpleActivityService := usecases3.NewActivityService(simpleActivityRepository, simpleTenantService)
 	executionController := httpapi3.NewExecutionController(simpleExecutionService, simpleActivityService)
@@ -411,7 +866,11 @@ func InitializeExecutionWorker(broker async.InternalBroker) (*usecases3.Executio
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm)
+	cipher, err := provideDeviceKeyCipher(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	simpleDeviceRepository, err := persistence2.NewDeviceRepository(orm, cipher)
 	if err != nil {
 		return nil, err
 	}
@@ -419,7 +878,8 @@ func InitializeExecutionWorker(broker async.InternalBroker) (*usecases3.Executio
 	if err != nil {
 		return nil, err
 	}
-	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository)
+	networkServerProvisioner := provideNetworkServerProvisioner(appConfig)
+	simpleDeviceService := usecases2.NewDeviceService(simpleDeviceRepository, simpleCommandRepository, networkServerProvisioner)
 	simpleTenantService := usecases.NewTenantService(simpleTenantRepository, simpleDeviceService)
 	simpleTenantConfigurationRepository, err := persistence.NewTenantConfigurationRepository(orm)
 	if err != nil {
@@ -490,8 +950,10 @@ func provideWebPushController(cfg config.AppConfig) *httpapi.WebPushController {
 
 // control_plane.go:
 
+var DeviceSessionServiceSet = wire.NewSet(persistence2.NewDeviceSessionRepository, wire.Bind(new(usecases2.DeviceSessionRepository), new(*persistence2.SimpleDeviceSessionRepository)), usecases2.NewDeviceSessionService, wire.Bind(new(usecases2.DeviceSessionService), new(*usecases2.SimpleDeviceSessionService)))
+
 var DeviceServiceSet = wire.NewSet(
-	provideDatabase, persistence2.NewDeviceRepository, wire.Bind(new(usecases2.DeviceRepository), new(*persistence2.SimpleDeviceRepository)), persistence2.NewCommandRepository, wire.Bind(new(usecases2.CommandRepository), new(*persistence2.SimpleCommandRepository)), usecases2.NewDeviceService,
+	provideDatabase, persistence2.NewDeviceRepository, provideDeviceKeyCipher, wire.Bind(new(usecases2.DeviceRepository), new(*persistence2.SimpleDeviceRepository)), persistence2.NewCommandRepository, wire.Bind(new(usecases2.CommandRepository), new(*persistence2.SimpleCommandRepository)), provideNetworkServerProvisioner, usecases2.NewDeviceService,
 )
 
 func provideAppConfig() config.AppConfig {
@@ -521,11 +983,136 @@ func provideDatabase(config2 config.AppConfig) sql.ORM {
 	return orm
 }
 
+// The development secrets key is committed in config/server.yaml, so it protects nothing outside
+// ENV=local.
+const (
+	_developmentSecretsKeyID = "dev"
+	_developmentSecretsKey   = "ZGV2LW9ubHktc2VjcmV0cy1rZXktZG8tbm90LXVzZSE="
+)
+
+func provideDeviceKeyCipher(appConfig config.AppConfig) (secrets.Cipher, error) {
+	keys, err := secrets.ParseKeys(appConfig.Secrets.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("parsing secrets keys: %w", err)
+	}
+
+	if env, _ := os.LookupEnv("ENV"); env != "local" {
+		err = checkDevelopmentSecretsKey(appConfig.Secrets.PrimaryKeyID, keys)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return secrets.NewKeyring(appConfig.Secrets.PrimaryKeyID, keys)
+}
+
+// checkDevelopmentSecretsKey refuses to seal secrets with the development key. It may stay listed
+// as a retired key, so secrets sealed with it can still be rewrapped with the primary one.
+func checkDevelopmentSecretsKey(primaryKeyID string, keys []secrets.Key) error {
+	if primaryKeyID == _developmentSecretsKeyID {
+		return fmt.Errorf("secrets primary key %q is the development key; set ZENSOR_SERVER_SECRETS_KEYS and ZENSOR_SERVER_SECRETS_PRIMARY_KEY_ID", primaryKeyID)
+	}
+
+	for _, key := range keys {
+		if key.ID != _developmentSecretsKeyID && base64.StdEncoding.EncodeToString(key.Material) != _developmentSecretsKey {
+			continue
+		}
+		if key.ID == primaryKeyID {
+			return fmt.Errorf("secrets primary key %q is the development key; set ZENSOR_SERVER_SECRETS_KEYS and ZENSOR_SERVER_SECRETS_PRIMARY_KEY_ID", primaryKeyID)
+		}
+		slog.Warn("the development secrets key is still listed; remove it once every secret is rewrapped", slog.String("key_id", key.ID))
+	}
+
+	return nil
+}
+
+// provideNetworkServerProvisioner returns nil when provisioning is disabled, which leaves device
+// registration in TTN to be done by hand.
+func provideNetworkServerProvisioner(appConfig config.AppConfig) usecases2.NetworkServerProvisioner {
+	provisioning := appConfig.TTN.Provisioning
+	if !provisioning.Enabled {
+		return nil
+	}
+
+	if env, _ := os.LookupEnv("ENV"); env == "local" {
+		return ttn.NewFakeProvisioner()
+	}
+
+	return ttn.NewRegistryClient(ttn.RegistryConfig{
+		BaseURL:           provisioning.BaseURL,
+		ApplicationID:     provisioning.ApplicationID,
+		APIKey:            provisioning.APIKey,
+		FrequencyPlanID:   provisioning.FrequencyPlanID,
+		LoRaWANVersion:    provisioning.LoRaWANVersion,
+		LoRaWANPHYVersion: provisioning.LoRaWANPHYVersion,
+	})
+}
+
 func provideTicker() *time.Ticker {
 	ticker := time.NewTicker(30 * time.Second)
 	return ticker
 }
 
+func provideDeviceHealthTicker(appConfig config.AppConfig) *time.Ticker {
+	return time.NewTicker(appConfig.Health.EvaluationInterval)
+}
+
+func provideDeviceHealthPolicy(appConfig config.AppConfig) (domain.DeviceHealthPolicy, error) {
+	policy := domain.DeviceHealthPolicy{
+		BatteryEmptyVoltage: appConfig.Health.BatteryEmptyVoltage,
+		BatteryFullVoltage:  appConfig.Health.BatteryFullVoltage,
+		LowBatteryPercent:   appConfig.Health.LowBatteryPercent,
+	}
+	if err := policy.Validate(); err != nil {
+		return domain.DeviceHealthPolicy{}, fmt.Errorf("health configuration: %w", err)
+	}
+	return policy, nil
+}
+
+func provideOutboxRelayTicker(appConfig config.AppConfig) *time.Ticker {
+	return time.NewTicker(appConfig.Outbox.RelayInterval)
+}
+
+func provideOutboxRelayConfig(appConfig config.AppConfig) usecases2.OutboxRelayConfig {
+	return usecases2.OutboxRelayConfig{
+		RedeliveryTimeout: appConfig.Outbox.RedeliveryTimeout,
+		MaxAttempts:       appConfig.Outbox.MaxAttempts,
+		Retention:         appConfig.Outbox.Retention,
+	}
+}
+
+func provideWebhookTicker(appConfig config.AppConfig) *time.Ticker {
+	return time.NewTicker(appConfig.Webhooks.DeliveryInterval)
+}
+
+func provideLoraIntegrationConfig(appConfig config.AppConfig) workers.LoraIntegrationConfig {
+	return workers.LoraIntegrationConfig{
+		SubscribeQoS: appConfig.MQTTClient.SubscribeQoS,
+	}
+}
+
+func provideWebhookWorkerConfig(appConfig config.AppConfig) usecases2.WebhookWorkerConfig {
+	return usecases2.WebhookWorkerConfig{
+		Timeout: appConfig.Webhooks.Timeout,
+		RetryPolicy: domain.WebhookRetryPolicy{
+			MaxAttempts:    appConfig.Webhooks.MaxAttempts,
+			InitialBackoff: appConfig.Webhooks.InitialBackoff,
+			MaxBackoff:     appConfig.Webhooks.MaxBackoff,
+		},
+		AllowPrivateNetworks: appConfig.Webhooks.AllowPrivateNetworks,
+	}
+}
+
+func provideMQTTBridgeConfig(appConfig config.AppConfig) usecases2.MQTTBridgeConfig {
+	return usecases2.MQTTBridgeConfig{
+		SensorTopic:     appConfig.MQTTBridge.SensorTopic,
+		CommandTopic:    appConfig.MQTTBridge.CommandTopic,
+		QoS:             appConfig.MQTTBridge.QoS,
+		AcceptCommands:  appConfig.MQTTBridge.Commands.Enabled,
+		WritableSensors: appConfig.MQTTBridge.Commands.Sensors,
+	}
+}
+
 func provideNotificationClient(config2 config.AppConfig) notification.NotificationClient {
 	mailerSendConfig := notification.MailerSendConfig{
 		APIKey:    config2.MailerSend.APIKey,
@@ -577,6 +1164,80 @@ func InitializeMetricWorkerFactory(broker async.InternalBroker) *usecases2.Metri
 	return usecases2.NewMetricWorkerFactory(broker)
 }
 
+func InitializeLeaderElector(workers2 ...async.Worker) (*leader.Elector, error) {
+	appConfig := provideAppConfig()
+	lease, err := provideLeaderLease(appConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return leader.NewElector(lease, node.GetNodeInfo().ID, appConfig.LeaderElection.LeaseTTL, workers2...), nil
+}
+
+func provideLeaderLease(appConfig config.AppConfig) (leader.Lease, error) {
+	if !appConfig.LeaderElection.Enabled {
+		return leader.NewLocalLease(), nil
+	}
+	// Singleton workers consume broker events, so they only see the events of every replica when
+	// the broker shares them through Redis.
+	if appConfig.Broker.Backend != config.BrokerBackendRedis {
+		return nil, fmt.Errorf("leader election requires the %q broker backend, got %q", config.BrokerBackendRedis, appConfig.Broker.Backend)
+	}
+
+	redisCache, err := cache.NewRedisCache(&cache.RedisConfig{
+		Addr:     appConfig.Redis.Addr,
+		Password: appConfig.Redis.Password,
+		DB:       appConfig.Redis.DB,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("connecting to leader election lease store: %w", err)
+	}
+
+	return redisCache.Lease(appConfig.LeaderElection.LeaseKey), nil
+}
+
+// InitializeInternalBroker creates the broker of the configured backend. The redis backend shares
+// the connection settings of the cache and joins the stream under the node ID.
+func InitializeInternalBroker() (async.InternalBroker, error) {
+	appConfig := provideAppConfig()
+	localConfig := async.LocalBrokerConfig{
+		QueueCapacity:  appConfig.Broker.QueueCapacity,
+		OverflowPolicy: async.OverflowPolicy(appConfig.Broker.OverflowPolicy),
+	}
+
+	switch appConfig.Broker.Backend {
+	case config.BrokerBackendLocal:
+		broker, err := async.NewLocalBrokerWithConfig(localConfig)
+		if err != nil {
+			return nil, err
+		}
+		return broker, nil
+	case config.BrokerBackendRedis:
+		client, err := cache.Connect(&cache.RedisConfig{
+			Addr:     appConfig.Redis.Addr,
+			Password: appConfig.Redis.Password,
+			DB:       appConfig.Redis.DB,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("connecting to broker stream store: %w", err)
+		}
+
+		broker, err := async.NewRedisStreamBroker(client, async.DefaultEventRegistry, async.RedisStreamBrokerConfig{
+			Stream:           appConfig.Broker.Redis.Stream,
+			Consumer:         node.GetNodeInfo().ID,
+			MaxLen:           appConfig.Broker.Redis.MaxLen,
+			GroupIdleTimeout: appConfig.Broker.Redis.GroupIdleTimeout,
+			Local:            localConfig,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return broker, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown backend %q", async.ErrInvalidBrokerConfig, appConfig.Broker.Backend)
+	}
+}
+
 // maintenance.go:
 
 func provideExecutionWorkerTicker(appConfig config.AppConfig) *time.Ticker {
@@ -0,0 +1,220 @@
+package httpapi
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"zensor-server/internal/control_plane/httpapi/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/httpserver"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+const (
+	createCommandTemplateSetErrMessage = "failed to create command template set"
+	updateCommandTemplateSetErrMessage = "failed to update command template set"
+	getCommandTemplateSetErrMessage    = "failed to get command template set"
+	listCommandTemplateSetErrMessage   = "failed to list command template sets"
+	deleteCommandTemplateSetErrMessage = "failed to delete command template set"
+	commandTemplateSetInUseErrMessage  = "command template set is referenced by scheduled tasks"
+)
+
+func NewCommandTemplateSetController(
+	service usecases.CommandTemplateSetService,
+	tenantService usecases.TenantService,
+) *CommandTemplateSetController {
+	return &CommandTemplateSetController{
+		service:       service,
+		tenantService: tenantService,
+	}
+}
+
+var _ httpserver.Controller = (*CommandTemplateSetController)(nil)
+
+type CommandTemplateSetController struct {
+	service       usecases.CommandTemplateSetService
+	tenantService usecases.TenantService
+}
+
+func (c *CommandTemplateSetController) AddRoutes(router *http.ServeMux) {
+	router.Handle("POST /v1/tenants/{tenant_id}/command-template-sets", c.create())
+	router.Handle("GET /v1/tenants/{tenant_id}/command-template-sets", c.list())
+	router.Handle("GET /v1/tenants/{tenant_id}/command-template-sets/{id}", c.get())
+	router.Handle("PUT /v1/tenants/{tenant_id}/command-template-sets/{id}", c.update())
+	router.Handle("DELETE /v1/tenants/{tenant_id}/command-template-sets/{id}", c.delete())
+}
+
+func (c *CommandTemplateSetController) create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := r.PathValue("tenant_id")
+
+		tenant, err := c.tenantService.GetTenant(r.Context(), domain.ID(tenantID))
+		if errors.Is(err, usecases.ErrTenantNotFound) {
+			http.Error(w, createCommandTemplateSetErrMessage, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.Error("get tenant failed", slog.String("error", err.Error()))
+			http.Error(w, createCommandTemplateSetErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		var body internal.CommandTemplateSetCreateRequest
+		err = httpserver.DecodeJSONBody(r, &body)
+		if err != nil {
+			slog.Error("decoding json body", slog.String("error", err.Error()))
+			http.Error(w, createCommandTemplateSetErrMessage, http.StatusBadRequest)
+			return
+		}
+
+		commandTemplates, err := buildCommandTemplates(domain.Device{}, body.Commands)
+		if err != nil {
+			slog.Error("build command template", slog.String("error", err.Error()))
+			http.Error(w, createCommandTemplateSetErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		set, err := domain.NewCommandTemplateSetBuilder().
+			WithTenant(tenant).
+			WithName(body.Name).
+			WithCommandTemplates(commandTemplates).
+			Build()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = c.service.Create(r.Context(), set)
+		if err != nil {
+			slog.Error("create command template set failed", slog.String("error", err.Error()))
+			http.Error(w, createCommandTemplateSetErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		httpserver.ReplyJSONResponse(w, http.StatusCreated, internal.ToCommandTemplateSetResponse(set))
+	}
+}
+
+func (c *CommandTemplateSetController) list() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := r.PathValue("tenant_id")
+
+		params := httpserver.ExtractPaginationParams(r)
+		pagination := usecases.Pagination{Limit: params.Limit, Offset: (params.Page - 1) * params.Limit}
+
+		sets, total, err := c.service.FindAllByTenant(r.Context(), domain.ID(tenantID), pagination)
+		if err != nil {
+			slog.Error("list command template sets failed", slog.String("error", err.Error()))
+			http.Error(w, listCommandTemplateSetErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		responses := make([]internal.CommandTemplateSetResponse, len(sets))
+		for i, set := range sets {
+			responses[i] = internal.ToCommandTemplateSetResponse(set)
+		}
+
+		httpserver.ReplyWithPaginatedData(w, http.StatusOK, responses, total, params)
+	}
+}
+
+func (c *CommandTemplateSetController) get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		set, ok := c.findTenantSet(w, r, getCommandTemplateSetErrMessage)
+		if !ok {
+			return
+		}
+
+		httpserver.ReplyJSONResponse(w, http.StatusOK, internal.ToCommandTemplateSetResponse(set))
+	}
+}
+
+func (c *CommandTemplateSetController) update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		set, ok := c.findTenantSet(w, r, updateCommandTemplateSetErrMessage)
+		if !ok {
+			return
+		}
+
+		var body internal.CommandTemplateSetUpdateRequest
+		err := httpserver.DecodeJSONBody(r, &body)
+		if err != nil {
+			slog.Error("decoding json body", slog.String("error", err.Error()))
+			http.Error(w, updateCommandTemplateSetErrMessage, http.StatusBadRequest)
+			return
+		}
+
+		if body.Name != nil {
+			set.Name = *body.Name
+		}
+		if body.Commands != nil {
+			commandTemplates, err := buildCommandTemplates(domain.Device{}, *body.Commands)
+			if err != nil {
+				slog.Error("build command template", slog.String("error", err.Error()))
+				http.Error(w, updateCommandTemplateSetErrMessage, http.StatusInternalServerError)
+				return
+			}
+			set.CommandTemplates = commandTemplates
+		}
+
+		err = set.Validate()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = c.service.Update(r.Context(), set)
+		if err != nil {
+			slog.Error("update command template set failed", slog.String("error", err.Error()))
+			http.Error(w, updateCommandTemplateSetErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		httpserver.ReplyJSONResponse(w, http.StatusOK, internal.ToCommandTemplateSetResponse(set))
+	}
+}
+
+func (c *CommandTemplateSetController) delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		set, ok := c.findTenantSet(w, r, deleteCommandTemplateSetErrMessage)
+		if !ok {
+			return
+		}
+
+		err := c.service.Delete(r.Context(), set.ID)
+		if errors.Is(err, usecases.ErrCommandTemplateSetInUse) {
+			http.Error(w, commandTemplateSetInUseErrMessage, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			slog.Error("delete command template set failed", slog.String("error", err.Error()))
+			http.Error(w, deleteCommandTemplateSetErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (c *CommandTemplateSetController) findTenantSet(w http.ResponseWriter, r *http.Request, errMessage string) (domain.CommandTemplateSet, bool) {
+	tenantID := r.PathValue("tenant_id")
+	id := r.PathValue("id")
+
+	set, err := c.service.GetByID(r.Context(), domain.ID(id))
+	if errors.Is(err, usecases.ErrCommandTemplateSetNotFound) {
+		http.Error(w, errMessage, http.StatusNotFound)
+		return domain.CommandTemplateSet{}, false
+	}
+	if err != nil {
+		slog.Error("get command template set failed", slog.String("error", err.Error()))
+		http.Error(w, errMessage, http.StatusInternalServerError)
+		return domain.CommandTemplateSet{}, false
+	}
+
+	if set.Tenant.ID != domain.ID(tenantID) {
+		http.Error(w, errMessage, http.StatusNotFound)
+		return domain.CommandTemplateSet{}, false
+	}
+
+	return set, true
+}
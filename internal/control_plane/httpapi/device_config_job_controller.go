@@ -0,0 +1,169 @@
+package httpapi
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"zensor-server/internal/control_plane/httpapi/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/httpserver"
+	"zensor-server/internal/shared_kernel/device"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+const (
+	createDeviceConfigJobErrMessage   = "failed to create device config job"
+	getDeviceConfigJobErrMessage      = "failed to get device config job"
+	listDeviceConfigJobsErrMessage    = "failed to list device config jobs"
+	updateDeviceConfigJobErrMessage   = "failed to update device config job"
+	deviceConfigJobNotFoundErrMessage = "device config job not found"
+	deviceConfigJobConflictErrMessage = "the device config job changed, retry"
+	deviceConfigSourceErrMessage      = "exactly one of config and payload is required"
+)
+
+func NewDeviceConfigJobController(service usecases.DeviceConfigJobService) *DeviceConfigJobController {
+	return &DeviceConfigJobController{
+		service: service,
+	}
+}
+
+var _ httpserver.Controller = &DeviceConfigJobController{}
+
+type DeviceConfigJobController struct {
+	service usecases.DeviceConfigJobService
+}
+
+func (c *DeviceConfigJobController) AddRoutes(router *http.ServeMux) {
+	router.Handle("POST /v1/devices/{id}/config-jobs", c.create())
+	router.Handle("GET /v1/devices/{id}/config-jobs", c.list())
+	router.Handle("GET /v1/devices/{id}/config-jobs/{job_id}", c.get())
+	router.Handle("POST /v1/devices/{id}/config-jobs/{job_id}/cancel", c.cancel())
+	router.Handle("POST /v1/devices/{id}/config-jobs/{job_id}/resume", c.resume())
+}
+
+func (c *DeviceConfigJobController) create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+
+		var body internal.DeviceConfigJobCreateRequest
+		err := httpserver.DecodeJSONBody(r, &body)
+		if err != nil {
+			slog.Error("decoding json body", slog.String("error", err.Error()))
+			http.Error(w, createDeviceConfigJobErrMessage, http.StatusBadRequest)
+			return
+		}
+
+		if (body.Config == nil) == (body.Payload == nil) {
+			http.Error(w, deviceConfigSourceErrMessage, http.StatusBadRequest)
+			return
+		}
+		config := body.Payload
+		if body.Config != nil {
+			config, err = device.EncodeConfig(body.Config)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		job, err := c.service.Create(r.Context(), domain.ID(id), config, body.FragmentSize)
+		if err != nil {
+			replyDeviceConfigJobError(w, err, createDeviceConfigJobErrMessage)
+			return
+		}
+
+		httpserver.ReplyJSONResponse(w, http.StatusCreated, internal.ToDeviceConfigJobResponse(job))
+	}
+}
+
+func (c *DeviceConfigJobController) list() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		paginationParams := httpserver.ExtractPaginationParams(r)
+		pagination := usecases.Pagination{
+			Limit:  paginationParams.Limit,
+			Offset: (paginationParams.Page - 1) * paginationParams.Limit,
+		}
+
+		jobs, total, err := c.service.FindAllByDevice(r.Context(), domain.ID(id), pagination)
+		if err != nil {
+			replyDeviceConfigJobError(w, err, listDeviceConfigJobsErrMessage)
+			return
+		}
+
+		response := make([]internal.DeviceConfigJobResponse, len(jobs))
+		for i, job := range jobs {
+			response[i] = internal.ToDeviceConfigJobResponse(job)
+		}
+
+		httpserver.ReplyWithPaginatedData(w, http.StatusOK, response, total, paginationParams)
+	}
+}
+
+func (c *DeviceConfigJobController) get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, err := c.service.Get(r.Context(), domain.ID(r.PathValue("id")), domain.ID(r.PathValue("job_id")))
+		if err != nil {
+			replyDeviceConfigJobError(w, err, getDeviceConfigJobErrMessage)
+			return
+		}
+
+		httpserver.ReplyJSONResponse(w, http.StatusOK, internal.ToDeviceConfigJobResponse(job))
+	}
+}
+
+func (c *DeviceConfigJobController) cancel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, err := c.service.Cancel(r.Context(), domain.ID(r.PathValue("id")), domain.ID(r.PathValue("job_id")))
+		if err != nil {
+			replyDeviceConfigJobError(w, err, updateDeviceConfigJobErrMessage)
+			return
+		}
+
+		httpserver.ReplyJSONResponse(w, http.StatusOK, internal.ToDeviceConfigJobResponse(job))
+	}
+}
+
+func (c *DeviceConfigJobController) resume() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, err := c.service.Resume(r.Context(), domain.ID(r.PathValue("id")), domain.ID(r.PathValue("job_id")))
+		if err != nil {
+			replyDeviceConfigJobError(w, err, updateDeviceConfigJobErrMessage)
+			return
+		}
+
+		httpserver.ReplyJSONResponse(w, http.StatusOK, internal.ToDeviceConfigJobResponse(job))
+	}
+}
+
+var _deviceConfigValidationErrors = []error{
+	domain.ErrDeviceConfigEmpty,
+	domain.ErrDeviceConfigTooLarge,
+	domain.ErrInvalidDeviceConfigFragmentSize,
+	domain.ErrDeviceConfigTooManyFragments,
+}
+
+func replyDeviceConfigJobError(w http.ResponseWriter, err error, message string) {
+	for _, validationErr := range _deviceConfigValidationErrors {
+		if errors.Is(err, validationErr) {
+			http.Error(w, validationErr.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	switch {
+	case errors.Is(err, usecases.ErrDeviceNotFound):
+		http.Error(w, deviceNotFoundErrMessage, http.StatusNotFound)
+	case errors.Is(err, usecases.ErrDeviceConfigJobNotFound):
+		http.Error(w, deviceConfigJobNotFoundErrMessage, http.StatusNotFound)
+	case errors.Is(err, usecases.ErrDeviceConfigJobInProgress),
+		errors.Is(err, domain.ErrDeviceConfigJobNotActive),
+		errors.Is(err, domain.ErrDeviceConfigJobNotResumable):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, usecases.ErrDeviceConfigJobVersionConflict):
+		http.Error(w, deviceConfigJobConflictErrMessage, http.StatusConflict)
+	default:
+		slog.Error(message, slog.Any("error", err))
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
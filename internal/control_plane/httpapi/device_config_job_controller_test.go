@@ -0,0 +1,168 @@
+package httpapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+	"zensor-server/internal/control_plane/httpapi"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/shared_kernel/domain"
+
+	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("DeviceConfigJobController", func() {
+	var (
+		ctrl        *gomock.Controller
+		mockService *mockusecases.MockDeviceConfigJobService
+		router      *http.ServeMux
+		recorder    *httptest.ResponseRecorder
+		job         domain.DeviceConfigJob
+	)
+
+	BeforeEach(func() {
+		slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+		ctrl = gomock.NewController(GinkgoT())
+		mockService = mockusecases.NewMockDeviceConfigJobService(ctrl)
+		router = http.NewServeMux()
+		httpapi.NewDeviceConfigJobController(mockService).AddRoutes(router)
+		recorder = httptest.NewRecorder()
+
+		var err error
+		job, err = domain.NewDeviceConfigJob(domain.ID("device-1"), bytes.Repeat([]byte{0x01}, 60), 0, time.Now())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("create", func() {
+		post := func(body string) {
+			request := httptest.NewRequest(http.MethodPost, "/v1/devices/device-1/config-jobs", strings.NewReader(body))
+			router.ServeHTTP(recorder, request)
+		}
+
+		It("should encode the settings for the device and start a job", func() {
+			var config []byte
+			mockService.EXPECT().
+				Create(gomock.Any(), domain.ID("device-1"), gomock.Any(), 32).
+				DoAndReturn(func(_ any, _ domain.ID, value []byte, _ int) (domain.DeviceConfigJob, error) {
+					config = value
+					return job, nil
+				})
+
+			post(`{"config": {"interval": 300, "threshold": 21.5}, "fragment_size": 32}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusCreated))
+			var settings map[string]any
+			Expect(msgpack.Unmarshal(config, &settings)).To(Succeed())
+			Expect(settings).To(HaveKeyWithValue("interval", BeNumerically("==", 300)))
+			Expect(settings).To(HaveKeyWithValue("threshold", 21.5))
+
+			var response map[string]any
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+			Expect(response["status"]).To(Equal("pending"))
+			Expect(response["fragment_count"]).To(BeNumerically("==", 2))
+			Expect(response["checksum"]).To(Equal(fmt.Sprintf("%08x", job.Checksum)))
+		})
+
+		It("should send base64 payloads as they are", func() {
+			mockService.EXPECT().
+				Create(gomock.Any(), domain.ID("device-1"), []byte("raw"), 0).
+				Return(job, nil)
+
+			post(`{"payload": "cmF3"}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusCreated))
+		})
+
+		It("should require exactly one configuration source", func() {
+			post(`{"config": {"interval": 300}, "payload": "cmF3"}`)
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+
+			recorder = httptest.NewRecorder()
+			post(`{}`)
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("should reject invalid configurations", func() {
+			mockService.EXPECT().
+				Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(domain.DeviceConfigJob{}, fmt.Errorf("creating config job: %w", domain.ErrInvalidDeviceConfigFragmentSize))
+
+			post(`{"payload": "cmF3", "fragment_size": 4}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(recorder.Body.String()).To(ContainSubstring(domain.ErrInvalidDeviceConfigFragmentSize.Error()))
+		})
+
+		It("should refuse a second job while one is active", func() {
+			mockService.EXPECT().
+				Create(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(domain.DeviceConfigJob{}, usecases.ErrDeviceConfigJobInProgress)
+
+			post(`{"payload": "cmF3"}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	Context("get", func() {
+		It("should return not found for jobs of other devices", func() {
+			mockService.EXPECT().
+				Get(gomock.Any(), domain.ID("device-1"), domain.ID("job-1")).
+				Return(domain.DeviceConfigJob{}, usecases.ErrDeviceConfigJobNotFound)
+
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/devices/device-1/config-jobs/job-1", nil))
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Context("list", func() {
+		It("should return the jobs of the device page by page", func() {
+			mockService.EXPECT().
+				FindAllByDevice(gomock.Any(), domain.ID("device-1"), usecases.Pagination{Limit: 10, Offset: 10}).
+				Return([]domain.DeviceConfigJob{job}, 11, nil)
+
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/devices/device-1/config-jobs?page=2&limit=10", nil))
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(ContainSubstring(job.ID.String()))
+		})
+	})
+
+	Context("resume", func() {
+		It("should refuse jobs that did not fail", func() {
+			mockService.EXPECT().
+				Resume(gomock.Any(), domain.ID("device-1"), domain.ID("job-1")).
+				Return(domain.DeviceConfigJob{}, domain.ErrDeviceConfigJobNotResumable)
+
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/devices/device-1/config-jobs/job-1/resume", nil))
+
+			Expect(recorder.Code).To(Equal(http.StatusConflict))
+		})
+	})
+
+	Context("cancel", func() {
+		It("should return the cancelled job", func() {
+			Expect(job.Cancel("configuration job cancelled", time.Now())).To(Succeed())
+			mockService.EXPECT().
+				Cancel(gomock.Any(), domain.ID("device-1"), domain.ID("job-1")).
+				Return(job, nil)
+
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/devices/device-1/config-jobs/job-1/cancel", nil))
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(ContainSubstring(`"status":"cancelled"`))
+		})
+	})
+})
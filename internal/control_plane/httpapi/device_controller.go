@@ -11,23 +11,46 @@ import (
 	"zensor-server/internal/infra/httpserver"
 	"zensor-server/internal/infra/utils"
 	"zensor-server/internal/shared_kernel/domain"
+
+	sharedHttpapi "zensor-server/internal/shared_kernel/httpapi"
 )
 
 const (
 	createDeviceErrMessage           = "failed to create device"
 	createDeviceDuplicatedErrMessage = "the device already exists"
+	deleteDeviceErrMessage           = "failed to delete device"
+	releaseDeviceErrMessage          = "failed to release device"
+	transferDeviceErrMessage         = "failed to transfer device"
+	deviceNotFoundErrMessage         = "device not found"
+	deviceIsOrphanErrMessage         = "device is not adopted by any tenant"
+	deviceAlreadyInTenantErrMessage  = "device already belongs to the tenant"
+	invalidTransferModeErrMessage    = "mode must be rehome or archive"
+	targetTenantNotFoundErrMessage   = "target tenant not found"
+	invalidUplinkIntervalErrMessage  = "expected_uplink_interval_seconds must not be negative"
+	linkQualityErrMessage            = "failed to get device link quality"
 )
 
-func NewDeviceController(service usecases.DeviceService) *DeviceController {
+func NewDeviceController(
+	service usecases.DeviceService,
+	lifecycleService usecases.DeviceLifecycleService,
+	sessionService usecases.DeviceSessionService,
+	tenantService usecases.TenantService,
+) *DeviceController {
 	return &DeviceController{
-		service,
+		service:          service,
+		lifecycleService: lifecycleService,
+		sessionService:   sessionService,
+		tenantService:    tenantService,
 	}
 }
 
 var _ httpserver.Controller = &DeviceController{}
 
 type DeviceController struct {
-	service usecases.DeviceService
+	service          usecases.DeviceService
+	lifecycleService usecases.DeviceLifecycleService
+	sessionService   usecases.DeviceSessionService
+	tenantService    usecases.TenantService
 }
 
 func (c *DeviceController) AddRoutes(router *http.ServeMux) {
@@ -35,32 +58,42 @@ func (c *DeviceController) AddRoutes(router *http.ServeMux) {
 	router.Handle("GET /v1/devices/{id}", c.getDevice())
 	router.Handle("POST /v1/devices", c.createDevice())
 	router.Handle("PUT /v1/devices/{id}", c.updateDevice())
+	router.Handle("DELETE /v1/devices/{id}", c.deleteDevice())
+	router.Handle("POST /v1/devices/{id}/release", c.releaseDevice())
+	router.Handle("POST /v1/devices/{id}/transfer", c.transferDevice())
 	router.Handle("POST /v1/devices/{id}/commands", c.sendCommand())
+	router.Handle("GET /v1/devices/{id}/link-quality", c.getLinkQuality())
 }
 
 func (c *DeviceController) listDevices() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Extract pagination parameters from query string
-		paginationParams := httpserver.ExtractPaginationParams(r)
-		pagination := usecases.Pagination{
-			Limit:  paginationParams.Limit,
-			Offset: (paginationParams.Page - 1) * paginationParams.Limit,
+		query, paginationParams, err := sharedHttpapi.ParseDeviceQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if tenantID := r.URL.Query().Get("tenant_id"); tenantID != "" {
+			id := domain.ID(tenantID)
+			query.Filter.TenantID = &id
 		}
 
-		devices, total, err := c.service.AllDevices(r.Context(), pagination)
+		page, err := c.service.SearchDevices(r.Context(), query)
+		if errors.Is(err, usecases.ErrInvalidDeviceCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if err != nil {
 			http.Error(w, "service all devices", http.StatusInternalServerError)
 			return
 		}
 
 		// Convert devices to response format
-		deviceResponses := make([]internal.DeviceResponse, len(devices))
-		for i, device := range devices {
+		deviceResponses := make([]internal.DeviceResponse, len(page.Devices))
+		for i, device := range page.Devices {
 			deviceResponses[i] = internal.ToDeviceResponse(device)
 		}
 
-		// Return paginated response
-		httpserver.ReplyWithPaginatedData(w, http.StatusOK, deviceResponses, total, paginationParams)
+		httpserver.ReplyWithCursorPaginatedData(w, http.StatusOK, deviceResponses, page.Total, paginationParams, page.NextCursor)
 	}
 }
 
@@ -100,7 +133,11 @@ func (c *DeviceController) createDevice() http.HandlerFunc {
 		// Start building the device
 		builder := domain.NewDeviceBuilder().
 			WithName(body.Name).
-			WithDisplayName(displayName)
+			WithDisplayName(displayName).
+			WithProfile(body.Profile)
+		if body.Sector != "" {
+			builder = builder.WithSector(body.Sector)
+		}
 
 		// Add LoRaWAN parameters if provided
 		if body.AppEUI != nil && *body.AppEUI != "" {
@@ -112,14 +149,31 @@ func (c *DeviceController) createDevice() http.HandlerFunc {
 		if body.AppKey != nil && *body.AppKey != "" {
 			builder = builder.WithAppKey(*body.AppKey)
 		}
+		if body.ExpectedUplinkIntervalSeconds != nil {
+			if *body.ExpectedUplinkIntervalSeconds < 0 {
+				http.Error(w, invalidUplinkIntervalErrMessage, http.StatusBadRequest)
+				return
+			}
+			builder = builder.WithExpectedUplinkInterval(time.Duration(*body.ExpectedUplinkIntervalSeconds) * time.Second)
+		}
+		if body.Tags != nil {
+			builder = builder.WithTags(body.Tags)
+		}
+		if body.Metadata != nil {
+			builder = builder.WithMetadata(body.Metadata)
+		}
 
 		device, err := builder.Build()
+		if labelErr := deviceLabelError(err); labelErr != nil {
+			http.Error(w, labelErr.Error(), http.StatusBadRequest)
+			return
+		}
 		if err != nil {
 			http.Error(w, createDeviceErrMessage, http.StatusInternalServerError)
 			return
 		}
 
-		err = c.service.CreateDevice(r.Context(), device)
+		device, err = c.service.CreateDevice(r.Context(), device)
 		if errors.Is(err, usecases.ErrDeviceDuplicated) {
 			http.Error(w, createDeviceDuplicatedErrMessage, http.StatusConflict)
 			return
@@ -145,15 +199,39 @@ func (c *DeviceController) updateDevice() http.HandlerFunc {
 			return
 		}
 
-		err = c.service.UpdateDeviceDisplayName(r.Context(), domain.ID(id), body.DisplayName)
-		if errors.Is(err, usecases.ErrDeviceNotFound) {
-			http.Error(w, "device not found", http.StatusNotFound)
+		if body.ExpectedUplinkIntervalSeconds != nil && *body.ExpectedUplinkIntervalSeconds < 0 {
+			http.Error(w, invalidUplinkIntervalErrMessage, http.StatusBadRequest)
 			return
 		}
 
-		if err != nil {
-			http.Error(w, "failed to update device", http.StatusInternalServerError)
-			return
+		updatesOtherFields := body.ExpectedUplinkIntervalSeconds != nil || body.Tags != nil || body.Metadata != nil
+		if body.DisplayName != "" || !updatesOtherFields {
+			err = c.service.UpdateDeviceDisplayName(r.Context(), domain.ID(id), body.DisplayName)
+			if !c.handleUpdateError(w, err) {
+				return
+			}
+		}
+
+		if body.ExpectedUplinkIntervalSeconds != nil {
+			interval := time.Duration(*body.ExpectedUplinkIntervalSeconds) * time.Second
+			err = c.service.UpdateDeviceExpectedUplinkInterval(r.Context(), domain.ID(id), interval)
+			if !c.handleUpdateError(w, err) {
+				return
+			}
+		}
+
+		if body.Tags != nil {
+			err = c.service.UpdateDeviceTags(r.Context(), domain.ID(id), body.Tags)
+			if !c.handleUpdateError(w, err) {
+				return
+			}
+		}
+
+		if body.Metadata != nil {
+			err = c.service.UpdateDeviceMetadata(r.Context(), domain.ID(id), body.Metadata)
+			if !c.handleUpdateError(w, err) {
+				return
+			}
 		}
 
 		// Get the updated device to return it
@@ -168,6 +246,150 @@ func (c *DeviceController) updateDevice() http.HandlerFunc {
 	}
 }
 
+func (c *DeviceController) getLinkQuality() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		paginationParams := httpserver.ExtractPaginationParams(r)
+		pagination := usecases.Pagination{
+			Limit:  paginationParams.Limit,
+			Offset: (paginationParams.Page - 1) * paginationParams.Limit,
+		}
+
+		linkQuality, total, err := c.sessionService.LinkQuality(r.Context(), domain.ID(id), pagination)
+		if errors.Is(err, usecases.ErrDeviceNotFound) {
+			http.Error(w, deviceNotFoundErrMessage, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.Error("getting device link quality", slog.String("device_id", id), slog.Any("error", err))
+			http.Error(w, linkQualityErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		response := internal.ToDeviceLinkQualityResponse(
+			linkQuality.Session,
+			linkQuality.Summary,
+			linkQuality.Samples,
+			total,
+			paginationParams,
+		)
+		httpserver.ReplyJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+func (c *DeviceController) handleUpdateError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, usecases.ErrDeviceNotFound) {
+		http.Error(w, "device not found", http.StatusNotFound)
+		return false
+	}
+
+	if labelErr := deviceLabelError(err); labelErr != nil {
+		http.Error(w, labelErr.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	if err != nil {
+		http.Error(w, "failed to update device", http.StatusInternalServerError)
+		return false
+	}
+
+	return true
+}
+
+func (c *DeviceController) deleteDevice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+
+		err := c.lifecycleService.Decommission(r.Context(), domain.ID(id))
+		if errors.Is(err, usecases.ErrDeviceNotFound) {
+			http.Error(w, deviceNotFoundErrMessage, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.Error("decommission device failed", slog.String("error", err.Error()))
+			http.Error(w, deleteDeviceErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (c *DeviceController) releaseDevice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+
+		device, err := c.lifecycleService.Release(r.Context(), domain.ID(id))
+		if errors.Is(err, usecases.ErrDeviceNotFound) {
+			http.Error(w, deviceNotFoundErrMessage, http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, usecases.ErrDeviceIsOrphan) {
+			http.Error(w, deviceIsOrphanErrMessage, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			slog.Error("release device failed", slog.String("error", err.Error()))
+			http.Error(w, releaseDeviceErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		httpserver.ReplyJSONResponse(w, http.StatusOK, internal.ToDeviceResponse(device))
+	}
+}
+
+func (c *DeviceController) transferDevice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+
+		var body internal.DeviceTransferRequest
+		err := httpserver.DecodeJSONBody(r, &body)
+		if err != nil {
+			slog.Error("decoding json body", slog.String("error", err.Error()))
+			http.Error(w, transferDeviceErrMessage, http.StatusBadRequest)
+			return
+		}
+
+		mode := usecases.DeviceTransferMode(body.Mode)
+		if !mode.IsValid() {
+			http.Error(w, invalidTransferModeErrMessage, http.StatusBadRequest)
+			return
+		}
+
+		tenant, err := c.tenantService.GetTenant(r.Context(), domain.ID(body.TenantID))
+		if errors.Is(err, usecases.ErrTenantNotFound) || (err == nil && tenant.IsDeleted()) {
+			http.Error(w, targetTenantNotFoundErrMessage, http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.Error("get tenant failed", slog.String("error", err.Error()))
+			http.Error(w, transferDeviceErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		device, err := c.lifecycleService.Transfer(r.Context(), domain.ID(id), tenant.ID, mode)
+		if errors.Is(err, usecases.ErrDeviceNotFound) {
+			http.Error(w, deviceNotFoundErrMessage, http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, usecases.ErrDeviceIsOrphan) {
+			http.Error(w, deviceIsOrphanErrMessage, http.StatusConflict)
+			return
+		}
+		if errors.Is(err, usecases.ErrDeviceAlreadyInTenant) {
+			http.Error(w, deviceAlreadyInTenantErrMessage, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			slog.Error("transfer device failed", slog.String("error", err.Error()))
+			http.Error(w, transferDeviceErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		httpserver.ReplyJSONResponse(w, http.StatusOK, internal.ToDeviceResponse(device))
+	}
+}
+
 func (c *DeviceController) sendCommand() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := r.PathValue("id")
@@ -212,3 +434,21 @@ func (c *DeviceController) sendCommand() http.HandlerFunc {
 		httpserver.ReplyJSONResponse(w, http.StatusCreated, nil)
 	}
 }
+
+// deviceLabelError returns the domain error that rejected the name, the tags or the metadata of a
+// device.
+func deviceLabelError(err error) error {
+	for _, target := range []error{
+		domain.ErrInvalidDeviceName,
+		domain.ErrInvalidDeviceTagKey,
+		domain.ErrDeviceTagValueTooLong,
+		domain.ErrTooManyDeviceTags,
+		domain.ErrDeviceMetadataTooLarge,
+		domain.ErrInvalidDeviceMetadata,
+	} {
+		if errors.Is(err, target) {
+			return target
+		}
+	}
+	return nil
+}
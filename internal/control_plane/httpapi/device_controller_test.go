@@ -3,16 +3,20 @@ package httpapi_test
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"time"
 	"zensor-server/internal/control_plane/httpapi"
 	"zensor-server/internal/control_plane/usecases"
 	"zensor-server/internal/infra/httpserver"
 	"zensor-server/internal/shared_kernel/domain"
 
 	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+	mocksharedusecases "zensor-server/test/unit/doubles/shared_kernel/usecases"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -22,6 +26,9 @@ import (
 var _ = Describe("DeviceController", func() {
 	var controller *httpapi.DeviceController
 	var mockService *mockusecases.MockDeviceService
+	var mockLifecycleService *mockusecases.MockDeviceLifecycleService
+	var mockSessionService *mockusecases.MockDeviceSessionService
+	var mockTenantService *mocksharedusecases.MockTenantService
 	var ctrl *gomock.Controller
 	var recorder *httptest.ResponseRecorder
 	var request *http.Request
@@ -30,7 +37,10 @@ var _ = Describe("DeviceController", func() {
 		slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
 		ctrl = gomock.NewController(GinkgoT())
 		mockService = mockusecases.NewMockDeviceService(ctrl)
-		controller = httpapi.NewDeviceController(mockService)
+		mockLifecycleService = mockusecases.NewMockDeviceLifecycleService(ctrl)
+		mockSessionService = mockusecases.NewMockDeviceSessionService(ctrl)
+		mockTenantService = mocksharedusecases.NewMockTenantService(ctrl)
+		controller = httpapi.NewDeviceController(mockService, mockLifecycleService, mockSessionService, mockTenantService)
 		recorder = httptest.NewRecorder()
 	})
 
@@ -73,16 +83,14 @@ var _ = Describe("DeviceController", func() {
 			})
 
 			It("should return paginated response with default parameters", func() {
-				expectedPagination := usecases.Pagination{
-					Limit:  10,
-					Offset: 0,
-				}
+				expectedQuery := listDevicesQuery(10, 0)
 				mockService.EXPECT().
-					AllDevices(gomock.Any(), expectedPagination).
-					Return(devices, 2, nil)
+					SearchDevices(gomock.Any(), expectedQuery).
+					Return(usecases.DevicePage{Devices: devices, Total: 2}, nil)
 
 				router.ServeHTTP(recorder, request)
 
+				Expect(recorder.Body.String()).NotTo(ContainSubstring("app-key-1"))
 				expectPaginatedDeviceResponse(recorder, 1, 10, 2, 1, 2)
 			})
 		})
@@ -103,13 +111,10 @@ var _ = Describe("DeviceController", func() {
 			})
 
 			It("should return paginated response with custom parameters", func() {
-				expectedPagination := usecases.Pagination{
-					Limit:  5,
-					Offset: 5, // (page 2 - 1) * limit 5
-				}
+				expectedQuery := listDevicesQuery(5, 5) // (page 2 - 1) * limit 5
 				mockService.EXPECT().
-					AllDevices(gomock.Any(), expectedPagination).
-					Return(devices, 25, nil)
+					SearchDevices(gomock.Any(), expectedQuery).
+					Return(usecases.DevicePage{Devices: devices, Total: 25}, nil)
 
 				router.ServeHTTP(recorder, request)
 
@@ -123,13 +128,10 @@ var _ = Describe("DeviceController", func() {
 			})
 
 			It("should return internal server error", func() {
-				expectedPagination := usecases.Pagination{
-					Limit:  10,
-					Offset: 0,
-				}
+				expectedQuery := listDevicesQuery(10, 0)
 				mockService.EXPECT().
-					AllDevices(gomock.Any(), expectedPagination).
-					Return(nil, 0, errors.New("database error"))
+					SearchDevices(gomock.Any(), expectedQuery).
+					Return(usecases.DevicePage{}, errors.New("database error"))
 
 				router.ServeHTTP(recorder, request)
 
@@ -154,13 +156,10 @@ var _ = Describe("DeviceController", func() {
 			})
 
 			It("should use default pagination parameters", func() {
-				expectedPagination := usecases.Pagination{
-					Limit:  10,
-					Offset: 0,
-				}
+				expectedQuery := listDevicesQuery(10, 0)
 				mockService.EXPECT().
-					AllDevices(gomock.Any(), expectedPagination).
-					Return(devices, 1, nil)
+					SearchDevices(gomock.Any(), expectedQuery).
+					Return(usecases.DevicePage{Devices: devices, Total: 1}, nil)
 
 				router.ServeHTTP(recorder, request)
 
@@ -191,13 +190,10 @@ var _ = Describe("DeviceController", func() {
 			})
 
 			It("should cap limit at maximum value", func() {
-				expectedPagination := usecases.Pagination{
-					Limit:  100, // Capped at maximum
-					Offset: 0,
-				}
+				expectedQuery := listDevicesQuery(100, 0) // Capped at maximum
 				mockService.EXPECT().
-					AllDevices(gomock.Any(), expectedPagination).
-					Return(devices, 1, nil)
+					SearchDevices(gomock.Any(), expectedQuery).
+					Return(usecases.DevicePage{Devices: devices, Total: 1}, nil)
 
 				router.ServeHTTP(recorder, request)
 
@@ -210,6 +206,262 @@ var _ = Describe("DeviceController", func() {
 				Expect(response.Pagination.Limit).To(Equal(100)) // Maximum limit
 			})
 		})
+
+		When("filters are given", func() {
+			It("should search with the filters, the sort and the cursor", func() {
+				request = httptest.NewRequest(http.MethodGet,
+					"/v1/devices?tenant_id=tenant-1&status=offline&tag=kind:valve&tag=Zone:B&profile=rak&name_prefix=val"+
+						"&last_seen_after=2026-01-01T00:00:00Z&sort=-last_seen&cursor=abc&limit=20", nil)
+
+				tenantID := domain.ID("tenant-1")
+				after := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+				expectedQuery := usecases.DeviceQuery{
+					Filter: usecases.DeviceFilter{
+						TenantID:     &tenantID,
+						Connectivity: domain.ConnectivityStateOffline,
+						Tags: []usecases.DeviceTagSelector{
+							{Key: "kind", Value: "valve", HasValue: true},
+							{Key: "zone", Value: "B", HasValue: true},
+						},
+						Profile:       "rak",
+						NamePrefix:    "val",
+						LastSeenAfter: &after,
+					},
+					Sort:   usecases.DeviceSort{Field: usecases.DeviceSortByLastSeen, Descending: true},
+					Limit:  20,
+					Cursor: "abc",
+				}
+				devices := []domain.Device{{ID: domain.ID("device-1"), Tags: map[string]string{"kind": "valve", "zone": "B"}}}
+				mockService.EXPECT().
+					SearchDevices(gomock.Any(), expectedQuery).
+					Return(usecases.DevicePage{Devices: devices, Total: 7, NextCursor: "next"}, nil)
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				var response httpserver.PaginatedResponse
+				Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+				Expect(response.Pagination.NextCursor).To(Equal("next"))
+				Expect(response.Data).To(ContainElement(HaveKeyWithValue("tags", HaveKeyWithValue("zone", "B"))))
+			})
+		})
+
+		When("a filter is invalid", func() {
+			DescribeTable("should return bad request without searching",
+				func(target string) {
+					request = httptest.NewRequest(http.MethodGet, target, nil)
+
+					router.ServeHTTP(recorder, request)
+
+					Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+				},
+				Entry("unknown status", "/v1/devices?status=sleeping"),
+				Entry("invalid tag key", "/v1/devices?tag=zone%20b:x"),
+				Entry("unknown sort", "/v1/devices?sort=created_at"),
+				Entry("invalid last seen", "/v1/devices?last_seen_before=yesterday"),
+			)
+		})
+
+		When("the cursor is invalid", func() {
+			It("should return bad request", func() {
+				request = httptest.NewRequest(http.MethodGet, "/v1/devices?cursor=bogus", nil)
+				mockService.EXPECT().
+					SearchDevices(gomock.Any(), gomock.Any()).
+					Return(usecases.DevicePage{}, usecases.ErrInvalidDeviceCursor)
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("updateDevice", func() {
+		var router *http.ServeMux
+
+		BeforeEach(func() {
+			router = http.NewServeMux()
+			controller.AddRoutes(router)
+		})
+
+		When("only the expected uplink interval is given", func() {
+			It("should update the interval and keep the display name", func() {
+				request = httptest.NewRequest(http.MethodPut, "/v1/devices/device-1", strings.NewReader(`{"expected_uplink_interval_seconds":1800}`))
+				mockService.EXPECT().UpdateDeviceExpectedUplinkInterval(gomock.Any(), domain.ID("device-1"), 30*time.Minute).Return(nil)
+				mockService.EXPECT().GetDevice(gomock.Any(), domain.ID("device-1")).Return(domain.Device{ID: domain.ID("device-1"), ExpectedUplinkInterval: 30 * time.Minute}, nil)
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				Expect(recorder.Body.String()).To(ContainSubstring(`"expected_uplink_interval_seconds":1800`))
+			})
+		})
+
+		When("only tags are given", func() {
+			It("should replace the tags and keep the display name", func() {
+				request = httptest.NewRequest(http.MethodPut, "/v1/devices/device-1", strings.NewReader(`{"tags":{"zone":"b"}}`))
+				mockService.EXPECT().UpdateDeviceTags(gomock.Any(), domain.ID("device-1"), map[string]string{"zone": "b"}).Return(nil)
+				mockService.EXPECT().GetDevice(gomock.Any(), domain.ID("device-1")).Return(domain.Device{ID: domain.ID("device-1"), Tags: map[string]string{"zone": "b"}}, nil)
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				Expect(recorder.Body.String()).To(ContainSubstring(`"tags":{"zone":"b"}`))
+			})
+		})
+
+		When("the tags are invalid", func() {
+			It("should return bad request", func() {
+				request = httptest.NewRequest(http.MethodPut, "/v1/devices/device-1", strings.NewReader(`{"tags":{"zone b":"x"}}`))
+				mockService.EXPECT().UpdateDeviceTags(gomock.Any(), domain.ID("device-1"), gomock.Any()).
+					Return(fmt.Errorf("updating tags: %w", domain.ErrInvalidDeviceTagKey))
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+				Expect(recorder.Body.String()).To(ContainSubstring(domain.ErrInvalidDeviceTagKey.Error()))
+			})
+		})
+
+		When("the expected uplink interval is negative", func() {
+			It("should return bad request", func() {
+				request = httptest.NewRequest(http.MethodPut, "/v1/devices/device-1", strings.NewReader(`{"expected_uplink_interval_seconds":-1}`))
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("getLinkQuality", func() {
+		var router *http.ServeMux
+
+		BeforeEach(func() {
+			router = http.NewServeMux()
+			controller.AddRoutes(router)
+			request = httptest.NewRequest(http.MethodGet, "/v1/devices/device-1/link-quality", nil)
+		})
+
+		When("the device has uplink samples", func() {
+			It("should return the session, summary and samples", func() {
+				samples := []domain.LinkQualitySample{
+					{FrameCounter: 2, SpreadingFactor: 7, Gateways: []domain.GatewayReception{{GatewayID: "gw-1", RSSI: -80, SNR: 8}}},
+					{FrameCounter: 1, SpreadingFactor: 9, Gateways: []domain.GatewayReception{{GatewayID: "gw-1", RSSI: -100, SNR: 2}}},
+				}
+				mockSessionService.EXPECT().LinkQuality(gomock.Any(), domain.ID("device-1"), usecases.Pagination{Limit: 10, Offset: 0}).
+					Return(usecases.DeviceLinkQuality{
+						Session: domain.DeviceSession{DeviceID: domain.ID("device-1"), DevAddr: "260B1234", FrameCounterUp: 2},
+						Summary: domain.SummarizeLinkQuality(samples),
+						Samples: samples,
+					}, 2, nil)
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				var response map[string]any
+				Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+				Expect(response["session"]).To(HaveKeyWithValue("dev_addr", "260B1234"))
+				Expect(response["summary"]).To(HaveKeyWithValue("average_rssi", float64(-90)))
+				Expect(response["data"]).To(HaveLen(2))
+				Expect(response["pagination"]).To(HaveKeyWithValue("total", float64(2)))
+			})
+		})
+
+		When("the device does not exist", func() {
+			It("should return not found", func() {
+				mockSessionService.EXPECT().LinkQuality(gomock.Any(), domain.ID("device-1"), gomock.Any()).
+					Return(usecases.DeviceLinkQuality{}, 0, usecases.ErrDeviceNotFound)
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Context("deleteDevice", func() {
+		var router *http.ServeMux
+
+		BeforeEach(func() {
+			router = http.NewServeMux()
+			controller.AddRoutes(router)
+			request = httptest.NewRequest(http.MethodDelete, "/v1/devices/device-1", nil)
+		})
+
+		When("the device exists", func() {
+			It("should decommission it", func() {
+				mockLifecycleService.EXPECT().Decommission(gomock.Any(), domain.ID("device-1")).Return(nil)
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusNoContent))
+			})
+		})
+
+		When("the device does not exist", func() {
+			It("should return not found", func() {
+				mockLifecycleService.EXPECT().Decommission(gomock.Any(), domain.ID("device-1")).Return(usecases.ErrDeviceNotFound)
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Context("releaseDevice", func() {
+		var router *http.ServeMux
+
+		BeforeEach(func() {
+			router = http.NewServeMux()
+			controller.AddRoutes(router)
+			request = httptest.NewRequest(http.MethodPost, "/v1/devices/device-1/release", nil)
+		})
+
+		When("the device is already an orphan", func() {
+			It("should return conflict", func() {
+				mockLifecycleService.EXPECT().Release(gomock.Any(), domain.ID("device-1")).Return(domain.Device{}, usecases.ErrDeviceIsOrphan)
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusConflict))
+			})
+		})
+	})
+
+	Context("transferDevice", func() {
+		var router *http.ServeMux
+
+		BeforeEach(func() {
+			router = http.NewServeMux()
+			controller.AddRoutes(router)
+		})
+
+		When("the mode is unknown", func() {
+			It("should return bad request", func() {
+				request = httptest.NewRequest(http.MethodPost, "/v1/devices/device-1/transfer", strings.NewReader(`{"tenant_id":"tenant-2","mode":"copy"}`))
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("the target tenant exists", func() {
+			It("should transfer the device", func() {
+				tenantID := domain.ID("tenant-2")
+				request = httptest.NewRequest(http.MethodPost, "/v1/devices/device-1/transfer", strings.NewReader(`{"tenant_id":"tenant-2","mode":"rehome"}`))
+				mockTenantService.EXPECT().GetTenant(gomock.Any(), tenantID).Return(domain.Tenant{ID: tenantID}, nil)
+				mockLifecycleService.EXPECT().Transfer(gomock.Any(), domain.ID("device-1"), tenantID, usecases.DeviceTransferModeRehome).
+					Return(domain.Device{ID: domain.ID("device-1"), TenantID: &tenantID}, nil)
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				Expect(recorder.Body.String()).To(ContainSubstring(`"tenant_id":"tenant-2"`))
+			})
+		})
 	})
 })
 
@@ -229,3 +481,11 @@ func expectPaginatedDeviceResponse(recorder *httptest.ResponseRecorder, page, li
 	Expect(ok).To(BeTrue())
 	Expect(deviceData).To(HaveLen(dataLen))
 }
+
+func listDevicesQuery(limit, offset int) usecases.DeviceQuery {
+	return usecases.DeviceQuery{
+		Sort:   usecases.DeviceSort{Field: usecases.DeviceSortByName},
+		Limit:  limit,
+		Offset: offset,
+	}
+}
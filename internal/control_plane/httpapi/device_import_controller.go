@@ -0,0 +1,170 @@
+package httpapi
+
+import (
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"zensor-server/internal/control_plane/httpapi/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/httpserver"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+const (
+	_maxDeviceImportRows = 1000
+	_csvContentType      = "text/csv"
+
+	importDevicesErrMessage      = "failed to import devices"
+	importDevicesParseErrMessage = "failed to parse the device import"
+	importDevicesEmptyErrMessage = "the device import has no rows"
+	importDevicesLimitErrMessage = "the device import exceeds 1000 rows"
+	exportDevicesErrMessage      = "failed to export devices"
+	exportFormatErrMessage       = "format must be csv or json"
+)
+
+func NewDeviceImportController(service usecases.DeviceImportService) *DeviceImportController {
+	return &DeviceImportController{
+		service: service,
+	}
+}
+
+var _ httpserver.Controller = &DeviceImportController{}
+
+type DeviceImportController struct {
+	service usecases.DeviceImportService
+}
+
+func (c *DeviceImportController) AddRoutes(router *http.ServeMux) {
+	router.Handle("POST /v1/devices:import", c.importDevices())
+	router.Handle("GET /v1/devices:export", c.exportDevices())
+}
+
+func (c *DeviceImportController) importDevices() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		records, firstLine, err := c.readRecords(r)
+		if err != nil {
+			http.Error(w, importDevicesParseErrMessage, http.StatusBadRequest)
+			return
+		}
+
+		if len(records) == 0 {
+			http.Error(w, importDevicesEmptyErrMessage, http.StatusBadRequest)
+			return
+		}
+		if len(records) > _maxDeviceImportRows {
+			http.Error(w, importDevicesLimitErrMessage, http.StatusBadRequest)
+			return
+		}
+
+		rows := make([]usecases.DeviceImportRow, len(records))
+		for i, record := range records {
+			rows[i] = usecases.DeviceImportRow{
+				Line:        firstLine + i,
+				Name:        record.Name,
+				DisplayName: record.DisplayName,
+				DevEUI:      record.DevEUI,
+				JoinEUI:     record.JoinEUI,
+				AppKey:      record.AppKey,
+				TenantID:    record.TenantID,
+				Profile:     record.Profile,
+				Sector:      record.Sector,
+			}
+		}
+
+		dryRun := r.URL.Query().Get("dry_run") == "true"
+		report, err := c.service.Import(r.Context(), rows, dryRun)
+		if errors.Is(err, usecases.ErrDeviceImportInvalid) {
+			httpserver.ReplyJSONResponse(w, http.StatusUnprocessableEntity, toDeviceImportReportResponse(report))
+			return
+		}
+		if err != nil {
+			slog.Error("importing devices", slog.Any("error", err))
+			http.Error(w, importDevicesErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		status := http.StatusCreated
+		if dryRun {
+			status = http.StatusOK
+		}
+		httpserver.ReplyJSONResponse(w, status, toDeviceImportReportResponse(report))
+	}
+}
+
+// readRecords decodes a CSV or JSON import and returns the line, or position, of its first row.
+func (c *DeviceImportController) readRecords(r *http.Request) ([]internal.DeviceImportRecord, int, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == _csvContentType {
+		records, err := internal.ParseDeviceImportCSV(r.Body)
+		return records, 2, err
+	}
+
+	var body internal.DeviceImportRequest
+	err := httpserver.DecodeJSONBody(r, &body)
+	return body.Devices, 1, err
+}
+
+func (c *DeviceImportController) exportDevices() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "json"
+		}
+		if format != "csv" && format != "json" {
+			http.Error(w, exportFormatErrMessage, http.StatusBadRequest)
+			return
+		}
+
+		var tenantID *domain.ID
+		if value := r.URL.Query().Get("tenant_id"); value != "" {
+			id := domain.ID(value)
+			tenantID = &id
+		}
+
+		devices, err := c.service.Export(r.Context(), tenantID)
+		if err != nil {
+			slog.Error("exporting devices", slog.Any("error", err))
+			http.Error(w, exportDevicesErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		records := make([]internal.DeviceImportRecord, len(devices))
+		for i, device := range devices {
+			records[i] = internal.ToDeviceImportRecord(device)
+		}
+
+		if format == "json" {
+			httpserver.ReplyJSONResponse(w, http.StatusOK, internal.DeviceExportResponse{Devices: records})
+			return
+		}
+
+		w.Header().Set("Content-Type", _csvContentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="devices.csv"`)
+		w.WriteHeader(http.StatusOK)
+		if err := internal.WriteDeviceExportCSV(w, records); err != nil {
+			slog.Error("writing device export", slog.Any("error", err))
+		}
+	}
+}
+
+func toDeviceImportReportResponse(report usecases.DeviceImportReport) internal.DeviceImportReportResponse {
+	rows := make([]internal.DeviceImportRowResultRecord, len(report.Rows))
+	for i, row := range report.Rows {
+		rows[i] = internal.DeviceImportRowResultRecord{
+			Line:     row.Line,
+			Name:     row.Name,
+			DeviceID: row.DeviceID.String(),
+			Status:   string(row.Status),
+			Errors:   row.Errors,
+		}
+	}
+
+	return internal.DeviceImportReportResponse{
+		DryRun:  report.DryRun,
+		Total:   report.Total,
+		Created: report.Created,
+		Invalid: report.Invalid,
+		Rows:    rows,
+	}
+}
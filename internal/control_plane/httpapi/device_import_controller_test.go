@@ -0,0 +1,157 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"zensor-server/internal/control_plane/httpapi"
+	"zensor-server/internal/control_plane/httpapi/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/shared_kernel/domain"
+
+	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("DeviceImportController", func() {
+	var ctrl *gomock.Controller
+	var mockService *mockusecases.MockDeviceImportService
+	var router *http.ServeMux
+	var recorder *httptest.ResponseRecorder
+
+	BeforeEach(func() {
+		slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+		ctrl = gomock.NewController(GinkgoT())
+		mockService = mockusecases.NewMockDeviceImportService(ctrl)
+		router = http.NewServeMux()
+		httpapi.NewDeviceImportController(mockService).AddRoutes(router)
+		httpapi.NewDeviceController(
+			mockusecases.NewMockDeviceService(ctrl),
+			mockusecases.NewMockDeviceLifecycleService(ctrl),
+			mockusecases.NewMockDeviceSessionService(ctrl),
+			nil,
+		).AddRoutes(router)
+		recorder = httptest.NewRecorder()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	Context("importDevices", func() {
+		When("a valid CSV file is posted", func() {
+			It("should create the devices numbering rows by file line", func() {
+				body := "name,dev_eui\nvalve-1,0004A30B001C0530\nvalve-2,\n"
+				request := httptest.NewRequest(http.MethodPost, "/v1/devices:import", strings.NewReader(body))
+				request.Header.Set("Content-Type", "text/csv; charset=utf-8")
+
+				mockService.EXPECT().Import(gomock.Any(), gomock.Any(), false).
+					DoAndReturn(func(_ any, rows []usecases.DeviceImportRow, _ bool) (usecases.DeviceImportReport, error) {
+						Expect(rows).To(HaveLen(2))
+						Expect(rows[0].Line).To(Equal(2))
+						Expect(rows[0].DevEUI).To(Equal("0004A30B001C0530"))
+						Expect(rows[1].Line).To(Equal(3))
+						return usecases.DeviceImportReport{
+							Total:   2,
+							Created: 2,
+							Rows: []usecases.DeviceImportRowResult{
+								{Line: 2, Name: "valve-1", DeviceID: "device-1", Status: usecases.DeviceImportRowStatusCreated},
+								{Line: 3, Name: "valve-2", DeviceID: "device-2", Status: usecases.DeviceImportRowStatusCreated},
+							},
+						}, nil
+					})
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusCreated))
+				var response internal.DeviceImportReportResponse
+				Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+				Expect(response.Created).To(Equal(2))
+				Expect(response.Rows[1].DeviceID).To(Equal("device-2"))
+			})
+		})
+
+		When("running a dry run", func() {
+			It("should reply 200 with the validation report", func() {
+				body := `{"devices":[{"name":"valve-1"}]}`
+				request := httptest.NewRequest(http.MethodPost, "/v1/devices:import?dry_run=true", strings.NewReader(body))
+
+				mockService.EXPECT().Import(gomock.Any(), []usecases.DeviceImportRow{{Line: 1, Name: "valve-1"}}, true).
+					Return(usecases.DeviceImportReport{DryRun: true, Total: 1}, nil)
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+			})
+		})
+
+		When("some rows are invalid", func() {
+			It("should reply 422 with the per-row report", func() {
+				body := `{"devices":[{"name":"valve-1","dev_eui":"nope"}]}`
+				request := httptest.NewRequest(http.MethodPost, "/v1/devices:import", strings.NewReader(body))
+
+				mockService.EXPECT().Import(gomock.Any(), gomock.Any(), false).
+					Return(usecases.DeviceImportReport{
+						Total:   1,
+						Invalid: 1,
+						Rows: []usecases.DeviceImportRowResult{
+							{Line: 1, Name: "valve-1", Status: usecases.DeviceImportRowStatusInvalid, Errors: []string{domain.ErrInvalidDevEUI.Error()}},
+						},
+					}, usecases.ErrDeviceImportInvalid)
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusUnprocessableEntity))
+				Expect(recorder.Body.String()).To(ContainSubstring(domain.ErrInvalidDevEUI.Error()))
+			})
+		})
+
+		When("the CSV file has an unknown column", func() {
+			It("should reply 400", func() {
+				request := httptest.NewRequest(http.MethodPost, "/v1/devices:import", strings.NewReader("name,color\nvalve-1,red\n"))
+				request.Header.Set("Content-Type", "text/csv")
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("exportDevices", func() {
+		When("the CSV format is requested", func() {
+			It("should write the import columns for the tenant devices", func() {
+				tenantID := domain.ID("tenant-1")
+				request := httptest.NewRequest(http.MethodGet, "/v1/devices:export?format=csv&tenant_id=tenant-1", nil)
+
+				mockService.EXPECT().Export(gomock.Any(), &tenantID).
+					Return([]domain.Device{{Name: "valve-1", DevEUI: "0004A30B001C0530", TenantID: &tenantID}}, nil)
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusOK))
+				Expect(recorder.Header().Get("Content-Type")).To(Equal("text/csv"))
+				Expect(recorder.Body.String()).To(Equal(
+					"name,display_name,dev_eui,join_eui,app_key,tenant_id,profile,sector\n" +
+						"valve-1,,0004A30B001C0530,,,tenant-1,,\n",
+				))
+			})
+		})
+
+		When("the format is unknown", func() {
+			It("should reply 400", func() {
+				request := httptest.NewRequest(http.MethodGet, "/v1/devices:export?format=xml", nil)
+
+				router.ServeHTTP(recorder, request)
+
+				Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+})
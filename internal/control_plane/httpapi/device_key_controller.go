@@ -0,0 +1,85 @@
+package httpapi
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"zensor-server/internal/control_plane/httpapi/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/httpserver"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+const (
+	revealDeviceKeysErrMessage = "failed to reveal device keys"
+	deviceKeyAccessErrMessage  = "failed to list device key accesses"
+)
+
+func NewDeviceKeyController(service usecases.DeviceKeyService) *DeviceKeyController {
+	return &DeviceKeyController{
+		service: service,
+	}
+}
+
+var _ httpserver.Controller = &DeviceKeyController{}
+
+// DeviceKeyController serves device root keys under /v1/admin, which only administrators reach.
+type DeviceKeyController struct {
+	service usecases.DeviceKeyService
+}
+
+func (c *DeviceKeyController) AddRoutes(router *http.ServeMux) {
+	router.Handle("GET /v1/admin/devices/{id}/keys", c.revealKeys())
+	router.Handle("GET /v1/admin/devices/{id}/key-accesses", c.listKeyAccesses())
+}
+
+func (c *DeviceKeyController) revealKeys() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		userID := domain.ID(r.Header.Get("X-User-ID"))
+		userEmail := r.Header.Get("X-User-Email")
+
+		device, err := c.service.RevealKeys(r.Context(), domain.ID(id), userID, userEmail)
+		if errors.Is(err, usecases.ErrDeviceNotFound) {
+			http.Error(w, deviceNotFoundErrMessage, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.Error("revealing device keys", slog.String("device_id", id), slog.Any("error", err))
+			http.Error(w, revealDeviceKeysErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		httpserver.ReplyJSONResponse(w, http.StatusOK, internal.ToDeviceKeysResponse(device))
+	}
+}
+
+func (c *DeviceKeyController) listKeyAccesses() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		paginationParams := httpserver.ExtractPaginationParams(r)
+		pagination := usecases.Pagination{
+			Limit:  paginationParams.Limit,
+			Offset: (paginationParams.Page - 1) * paginationParams.Limit,
+		}
+
+		accesses, total, err := c.service.KeyAccesses(r.Context(), domain.ID(id), pagination)
+		if errors.Is(err, usecases.ErrDeviceNotFound) {
+			http.Error(w, deviceNotFoundErrMessage, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.Error("listing device key accesses", slog.String("device_id", id), slog.Any("error", err))
+			http.Error(w, deviceKeyAccessErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		response := make([]internal.DeviceKeyAccessResponse, len(accesses))
+		for i, access := range accesses {
+			response[i] = internal.ToDeviceKeyAccessResponse(access)
+		}
+
+		httpserver.ReplyWithPaginatedData(w, http.StatusOK, response, total, paginationParams)
+	}
+}
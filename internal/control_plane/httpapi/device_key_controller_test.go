@@ -0,0 +1,110 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"time"
+	"zensor-server/internal/control_plane/httpapi"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/shared_kernel/domain"
+
+	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("DeviceKeyController", func() {
+	var (
+		ctrl        *gomock.Controller
+		mockService *mockusecases.MockDeviceKeyService
+		router      *http.ServeMux
+		recorder    *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+		ctrl = gomock.NewController(GinkgoT())
+		mockService = mockusecases.NewMockDeviceKeyService(ctrl)
+		router = http.NewServeMux()
+		httpapi.NewDeviceKeyController(mockService).AddRoutes(router)
+		recorder = httptest.NewRecorder()
+	})
+
+	Context("revealKeys", func() {
+		var request *http.Request
+
+		BeforeEach(func() {
+			request = httptest.NewRequest(http.MethodGet, "/v1/admin/devices/device-1/keys", nil)
+			request.Header.Set("X-User-ID", "user-1")
+			request.Header.Set("X-User-Email", "admin@example.com")
+		})
+
+		It("should return the keys of the device on behalf of the caller", func() {
+			mockService.EXPECT().
+				RevealKeys(gomock.Any(), domain.ID("device-1"), domain.ID("user-1"), "admin@example.com").
+				Return(domain.Device{
+					ID:     domain.ID("device-1"),
+					DevEUI: "0004A30B001C0530",
+					AppEUI: "70B3D57ED0000001",
+					AppKey: "000102030405060708090A0B0C0D0E0F",
+				}, nil)
+
+			router.ServeHTTP(recorder, request)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Header().Get("Cache-Control")).To(Equal("no-store"))
+			var response map[string]string
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+			Expect(response).To(Equal(map[string]string{
+				"device_id": "device-1",
+				"dev_eui":   "0004A30B001C0530",
+				"join_eui":  "70B3D57ED0000001",
+				"app_key":   "000102030405060708090A0B0C0D0E0F",
+			}))
+		})
+
+		It("should return not found for unknown devices", func() {
+			mockService.EXPECT().
+				RevealKeys(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(domain.Device{}, usecases.ErrDeviceNotFound)
+
+			router.ServeHTTP(recorder, request)
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("should not reveal anything when the access cannot be recorded", func() {
+			mockService.EXPECT().
+				RevealKeys(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(domain.Device{}, errors.New("recording key access: database down"))
+
+			router.ServeHTTP(recorder, request)
+
+			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+			Expect(recorder.Body.String()).NotTo(ContainSubstring("app_key"))
+		})
+	})
+
+	Context("listKeyAccesses", func() {
+		It("should return the audit trail of the device", func() {
+			accesses := []domain.DeviceKeyAccess{
+				domain.NewDeviceKeyAccess(domain.ID("device-1"), domain.ID("user-1"), "admin@example.com", time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)),
+			}
+			mockService.EXPECT().
+				KeyAccesses(gomock.Any(), domain.ID("device-1"), usecases.Pagination{Limit: 10, Offset: 0}).
+				Return(accesses, 1, nil)
+
+			request := httptest.NewRequest(http.MethodGet, "/v1/admin/devices/device-1/key-accesses", nil)
+			router.ServeHTTP(recorder, request)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(ContainSubstring(`"user_email":"admin@example.com"`))
+		})
+	})
+})
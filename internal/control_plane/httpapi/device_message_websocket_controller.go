@@ -7,13 +7,15 @@ import (
 	"sync"
 	"time"
 	"zensor-server/internal/control_plane/usecases"
-	"zensor-server/internal/data_plane/dto"
 	"zensor-server/internal/infra/async"
 	"zensor-server/internal/infra/httpserver"
+	"zensor-server/internal/shared_kernel/events"
 
 	"github.com/gorilla/websocket"
 )
 
+const _deviceUplinksTopic async.BrokerTopicName = "devices/+/uplink"
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -150,14 +152,14 @@ func (wsc *DeviceMessageWebSocketController) handlePingPong(conn *websocket.Conn
 }
 
 func (wsc *DeviceMessageWebSocketController) run() {
-	// Subscribe to device messages
-	subscription, err := wsc.broker.Subscribe(async.BrokerTopicName("device_messages"))
+	// Subscribe to device uplinks
+	subscription, err := wsc.broker.Subscribe(_deviceUplinksTopic)
 	if err != nil {
 		slog.Error("failed to subscribe to device messages", slog.String("error", err.Error()))
 		return
 	}
 	defer func() {
-		if err := wsc.broker.Unsubscribe(async.BrokerTopicName("device_messages"), subscription); err != nil {
+		if err := wsc.broker.Unsubscribe(_deviceUplinksTopic, subscription); err != nil {
 			slog.Error("failed to unsubscribe from device messages", slog.String("error", err.Error()))
 		}
 	}()
@@ -238,21 +240,20 @@ func (wsc *DeviceMessageWebSocketController) run() {
 			}
 
 		case brokerMsg := <-subscription.Receiver:
-			if brokerMsg.Event == "uplink" {
-				if envelop, ok := brokerMsg.Value.(dto.Envelop); ok {
-					deviceMsg := DeviceMessage{
-						Type:      "device_state",
-						DeviceID:  envelop.EndDeviceIDs.DeviceID,
-						Timestamp: envelop.ReceivedAt,
-						Data:      envelop.UplinkMessage.DecodedPayload,
-					}
+			if event, err := events.Uplink.Decode(brokerMsg); err == nil {
+				envelop := event.Payload
+				deviceMsg := DeviceMessage{
+					Type:      "device_state",
+					DeviceID:  envelop.EndDeviceIDs.DeviceID,
+					Timestamp: envelop.ReceivedAt,
+					Data:      envelop.UplinkMessage.DecodedPayload,
+				}
 
-					// Non-blocking send to broadcast channel
-					select {
-					case wsc.broadcast <- deviceMsg:
-					default:
-						slog.Warn("broadcast channel full, dropping message")
-					}
+				// Non-blocking send to broadcast channel
+				select {
+				case wsc.broadcast <- deviceMsg:
+				default:
+					slog.Warn("broadcast channel full, dropping message")
 				}
 			}
 		}
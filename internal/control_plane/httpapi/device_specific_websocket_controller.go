@@ -8,14 +8,15 @@ import (
 	"sync"
 	"time"
 	"zensor-server/internal/control_plane/usecases"
-	"zensor-server/internal/data_plane/dto"
 	"zensor-server/internal/infra/async"
 	"zensor-server/internal/infra/httpserver"
-	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
 
 	"github.com/gorilla/websocket"
 )
 
+const _deviceEventsTopic async.BrokerTopicName = "devices/+/+"
+
 // DeviceSpecificMessage represents a message sent to a device-specific WebSocket client.
 type DeviceSpecificMessage struct {
 	Type      string    `json:"type"`
@@ -182,13 +183,13 @@ func (wsc *DeviceSpecificWebSocketController) run() {
 		return
 	}
 
-	subscription, err := wsc.broker.Subscribe(async.BrokerTopicName("device_messages"))
+	subscription, err := wsc.broker.Subscribe(_deviceEventsTopic, events.Uplink.Name, events.CommandSent.Name)
 	if err != nil {
 		slog.Error("failed to subscribe to device messages", slog.String("error", err.Error()))
 		return
 	}
 	defer func() {
-		if err := wsc.broker.Unsubscribe(async.BrokerTopicName("device_messages"), subscription); err != nil {
+		if err := wsc.broker.Unsubscribe(_deviceEventsTopic, subscription); err != nil {
 			slog.Error("failed to unsubscribe from device messages", slog.String("error", err.Error()))
 		}
 	}()
@@ -231,8 +232,9 @@ func (wsc *DeviceSpecificWebSocketController) run() {
 
 		case brokerMsg := <-subscription.Receiver:
 			switch brokerMsg.Event {
-			case "uplink":
-				if envelop, ok := brokerMsg.Value.(dto.Envelop); ok {
+			case events.Uplink.Name:
+				if event, err := events.Uplink.Decode(brokerMsg); err == nil {
+					envelop := event.Payload
 					deviceMsg := DeviceSpecificMessage{
 						Type:      "device_state",
 						DeviceID:  envelop.EndDeviceIDs.DeviceID,
@@ -242,16 +244,17 @@ func (wsc *DeviceSpecificWebSocketController) run() {
 
 					wsc.sendMessageToDeviceClients(envelop.EndDeviceIDs.DeviceID, deviceMsg)
 				}
-			case "command_sent":
-				if command, ok := brokerMsg.Value.(domain.Command); ok {
+			case events.CommandSent.Name:
+				if event, err := events.CommandSent.Decode(brokerMsg); err == nil {
+					command := event.Payload
 					deviceMsg := DeviceSpecificMessage{
 						Type:      "command_sent",
-						DeviceID:  command.Device.ID.String(),
+						DeviceID:  command.DeviceID,
 						Timestamp: command.CreatedAt.Time,
 						Data:      command.Payload,
 					}
 
-					wsc.sendMessageToDeviceClients(command.Device.ID.String(), deviceMsg)
+					wsc.sendMessageToDeviceClients(command.DeviceID, deviceMsg)
 				}
 			}
 		}
@@ -11,6 +11,7 @@ import (
 	"zensor-server/internal/control_plane/usecases"
 	"zensor-server/internal/data_plane/dto"
 	"zensor-server/internal/infra/async"
+	"zensor-server/internal/shared_kernel/events"
 
 	"github.com/gorilla/websocket"
 	"github.com/onsi/ginkgo/v2"
@@ -137,12 +138,9 @@ var _ = ginkgo.Describe("DeviceSpecificWebSocketController", func() {
 					},
 				}
 
-				brokerMsg := async.BrokerMessage{
-					Event: "uplink",
-					Value: envelop,
-				}
+				brokerMsg := events.Uplink.New("", envelop).Message()
 
-				err = broker.Publish(context.Background(), "device_messages", brokerMsg)
+				err = broker.Publish(context.Background(), async.BrokerTopicName("devices/"+device1ID+"/uplink"), brokerMsg)
 				gomega.Expect(err).NotTo(gomega.HaveOccurred())
 
 				// Wait for message to be processed
@@ -0,0 +1,51 @@
+package internal
+
+import (
+	"time"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+type CommandTemplateSetCreateRequest struct {
+	Name     string                      `json:"name"`
+	Commands []CommandSendPayloadRequest `json:"commands"`
+}
+
+type CommandTemplateSetUpdateRequest struct {
+	Name     *string                      `json:"name,omitempty"`
+	Commands *[]CommandSendPayloadRequest `json:"commands,omitempty"`
+}
+
+type CommandTemplateSetResponse struct {
+	ID        string                      `json:"id"`
+	Name      string                      `json:"name"`
+	Commands  []CommandSendPayloadRequest `json:"commands"`
+	CreatedAt time.Time                   `json:"created_at"`
+	UpdatedAt time.Time                   `json:"updated_at"`
+}
+
+// ToCommandTemplateSetResponse converts a domain CommandTemplateSet to CommandTemplateSetResponse.
+func ToCommandTemplateSetResponse(set domain.CommandTemplateSet) CommandTemplateSetResponse {
+	return CommandTemplateSetResponse{
+		ID:        set.ID.String(),
+		Name:      set.Name,
+		Commands:  ToCommandPayloads(set.CommandTemplates),
+		CreatedAt: set.CreatedAt.Time,
+		UpdatedAt: set.UpdatedAt.Time,
+	}
+}
+
+// ToCommandPayloads converts command templates to the payloads they were created from.
+func ToCommandPayloads(templates []domain.CommandTemplate) []CommandSendPayloadRequest {
+	commands := make([]CommandSendPayloadRequest, len(templates))
+	for i, template := range templates {
+		commands[i] = CommandSendPayloadRequest{
+			Index:    uint8(template.Payload.Index),
+			Value:    uint8(template.Payload.Value),
+			Priority: string(template.Priority),
+			WaitFor:  utils.Duration(template.WaitFor),
+		}
+	}
+
+	return commands
+}
@@ -15,10 +15,44 @@ type DeviceResponse struct {
 	DisplayName           string     `json:"display_name"`
 	AppEUI                string     `json:"app_eui"`
 	DevEUI                string     `json:"dev_eui"`
-	AppKey                string     `json:"app_key"`
 	TenantID              *string    `json:"tenant_id,omitempty"`
+	Profile               string     `json:"profile,omitempty"`
+	Sector                string     `json:"sector,omitempty"`
 	Status                string     `json:"status"`
 	LastMessageReceivedAt *time.Time `json:"last_message_received_at,omitempty"`
+
+	ExpectedUplinkIntervalSeconds int `json:"expected_uplink_interval_seconds"`
+
+	Tags     map[string]string `json:"tags,omitempty"`
+	Metadata map[string]any    `json:"metadata,omitempty"`
+
+	Provisioning *DeviceProvisioningResponse `json:"provisioning,omitempty"`
+
+	Health DeviceHealthResponse `json:"health"`
+}
+
+// DeviceProvisioningResponse is the network server registration state of devices managed by Zensor.
+type DeviceProvisioningResponse struct {
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+}
+
+// DeviceHealthResponse is the last health evaluation of a device; components are percentages
+// left out when there is no data for them.
+type DeviceHealthResponse struct {
+	Status             string     `json:"status"`
+	Score              *int       `json:"score,omitempty"`
+	BatteryPercent     *int       `json:"battery_percent,omitempty"`
+	BatteryVoltage     *float64   `json:"battery_voltage,omitempty"`
+	BatteryReportedAt  *time.Time `json:"battery_reported_at,omitempty"`
+	SignalQuality      *int       `json:"signal_quality,omitempty"`
+	UplinkRegularity   *int       `json:"uplink_regularity,omitempty"`
+	CommandFailureRate *int       `json:"command_failure_rate,omitempty"`
+	LowBattery         bool       `json:"low_battery"`
+	EvaluatedAt        *time.Time `json:"evaluated_at,omitempty"`
 }
 
 type DeviceCreateRequest struct {
@@ -27,10 +61,32 @@ type DeviceCreateRequest struct {
 	AppEUI      *string `json:"app_eui,omitempty"`
 	DevEUI      *string `json:"dev_eui,omitempty"`
 	AppKey      *string `json:"app_key,omitempty"`
+	Profile     string  `json:"profile,omitempty"`
+	Sector      string  `json:"sector,omitempty"`
+
+	ExpectedUplinkIntervalSeconds *int `json:"expected_uplink_interval_seconds,omitempty"`
+
+	Tags     map[string]string `json:"tags,omitempty"`
+	Metadata map[string]any    `json:"metadata,omitempty"`
 }
 
+// DeviceUpdateRequest changes the display name, the expected uplink interval, the tags and/or the
+// metadata of a device. Tags and metadata are replaced as a whole; an empty object clears them.
 type DeviceUpdateRequest struct {
-	DisplayName string `json:"display_name" validate:"required,min=1,max=100"`
+	DisplayName string `json:"display_name" validate:"max=100"`
+
+	ExpectedUplinkIntervalSeconds *int `json:"expected_uplink_interval_seconds,omitempty"`
+
+	Tags     map[string]string `json:"tags,omitempty"`
+	Metadata map[string]any    `json:"metadata,omitempty"`
+}
+
+// DeviceTransferRequest moves a device to another tenant. Mode is either "rehome", which takes
+// the device's own scheduled tasks and their history along, or "archive", which leaves them
+// deactivated with the current tenant.
+type DeviceTransferRequest struct {
+	TenantID string `json:"tenant_id"`
+	Mode     string `json:"mode"`
 }
 
 // ToDeviceResponse converts a domain Device to DeviceResponse.
@@ -41,8 +97,15 @@ func ToDeviceResponse(device domain.Device) DeviceResponse {
 		DisplayName: device.DisplayName,
 		AppEUI:      device.AppEUI,
 		DevEUI:      device.DevEUI,
-		AppKey:      device.AppKey,
+		Profile:     device.Profile,
 		Status:      device.GetStatus(),
+
+		ExpectedUplinkIntervalSeconds: int(device.UplinkInterval() / time.Second),
+
+		Tags:     device.Tags,
+		Metadata: device.Metadata,
+
+		Health: toDeviceHealthResponse(device),
 	}
 
 	// Convert utils.Time to *time.Time
@@ -55,6 +118,59 @@ func ToDeviceResponse(device domain.Device) DeviceResponse {
 		response.TenantID = &tenantIDStr
 	}
 
+	if device.Sector != nil {
+		response.Sector = string(device.Sector.Name)
+	}
+
+	if device.Provisioning.IsManaged() {
+		response.Provisioning = toDeviceProvisioningResponse(device.Provisioning)
+	}
+
+	return response
+}
+
+func toDeviceHealthResponse(device domain.Device) DeviceHealthResponse {
+	health := device.Health
+	response := DeviceHealthResponse{
+		Status:             string(health.Status),
+		Score:              health.Score,
+		BatteryPercent:     health.BatteryPercent,
+		SignalQuality:      health.SignalQuality,
+		UplinkRegularity:   health.UplinkRegularity,
+		CommandFailureRate: health.CommandFailureRate,
+		LowBattery:         health.LowBattery,
+	}
+
+	if response.Status == "" {
+		response.Status = string(domain.DeviceHealthStatusUnknown)
+	}
+
+	if device.Battery != nil {
+		response.BatteryVoltage = &device.Battery.Voltage
+		response.BatteryReportedAt = &device.Battery.ReportedAt.Time
+	}
+
+	if health.EvaluatedAt != nil {
+		response.EvaluatedAt = &health.EvaluatedAt.Time
+	}
+
+	return response
+}
+
+func toDeviceProvisioningResponse(provisioning domain.DeviceProvisioning) *DeviceProvisioningResponse {
+	response := &DeviceProvisioningResponse{
+		Status:    string(provisioning.Status),
+		Attempts:  provisioning.Attempts,
+		LastError: provisioning.LastError,
+	}
+
+	if provisioning.NextAttemptAt != nil {
+		response.NextAttemptAt = &provisioning.NextAttemptAt.Time
+	}
+	if provisioning.UpdatedAt != nil {
+		response.UpdatedAt = &provisioning.UpdatedAt.Time
+	}
+
 	return response
 }
 
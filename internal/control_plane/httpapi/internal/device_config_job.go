@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+// DeviceConfigJobCreateRequest takes the configuration either as settings, encoded as MessagePack
+// for the device, or as an already encoded base64 payload.
+type DeviceConfigJobCreateRequest struct {
+	Config       map[string]any `json:"config,omitempty"`
+	Payload      []byte         `json:"payload,omitempty"`
+	FragmentSize int            `json:"fragment_size,omitempty"`
+}
+
+type DeviceConfigFragmentResponse struct {
+	Index     int        `json:"index"`
+	Size      int        `json:"size"`
+	Status    string     `json:"status"`
+	Attempts  int        `json:"attempts"`
+	CommandID string     `json:"command_id,omitempty"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	AckedAt   *time.Time `json:"acked_at,omitempty"`
+}
+
+type DeviceConfigJobResponse struct {
+	ID             string                         `json:"id"`
+	DeviceID       string                         `json:"device_id"`
+	Status         string                         `json:"status"`
+	Port           uint8                          `json:"port"`
+	Size           int                            `json:"size"`
+	Checksum       string                         `json:"checksum"`
+	FragmentSize   int                            `json:"fragment_size"`
+	FragmentCount  int                            `json:"fragment_count"`
+	AckedFragments int                            `json:"acked_fragments"`
+	MaxAttempts    int                            `json:"max_attempts"`
+	LastError      string                         `json:"last_error,omitempty"`
+	Fragments      []DeviceConfigFragmentResponse `json:"fragments"`
+	CreatedAt      time.Time                      `json:"created_at"`
+	UpdatedAt      time.Time                      `json:"updated_at"`
+	CompletedAt    *time.Time                     `json:"completed_at,omitempty"`
+}
+
+func ToDeviceConfigJobResponse(job domain.DeviceConfigJob) DeviceConfigJobResponse {
+	fragments := make([]DeviceConfigFragmentResponse, len(job.Fragments))
+	for i, fragment := range job.Fragments {
+		fragments[i] = DeviceConfigFragmentResponse{
+			Index:    fragment.Index,
+			Size:     len(fragment.Payload),
+			Status:   string(fragment.Status),
+			Attempts: fragment.Attempts,
+		}
+		if fragment.CommandID != nil {
+			fragments[i].CommandID = fragment.CommandID.String()
+		}
+		if fragment.SentAt != nil {
+			fragments[i].SentAt = &fragment.SentAt.Time
+		}
+		if fragment.AckedAt != nil {
+			fragments[i].AckedAt = &fragment.AckedAt.Time
+		}
+	}
+
+	response := DeviceConfigJobResponse{
+		ID:             job.ID.String(),
+		DeviceID:       job.DeviceID.String(),
+		Status:         string(job.Status),
+		Port:           uint8(job.Port),
+		Size:           job.Size,
+		Checksum:       fmt.Sprintf("%08x", job.Checksum),
+		FragmentSize:   job.FragmentSize,
+		FragmentCount:  len(job.Fragments),
+		AckedFragments: job.AckedFragments(),
+		MaxAttempts:    job.MaxAttempts,
+		LastError:      job.LastError,
+		Fragments:      fragments,
+		CreatedAt:      job.CreatedAt.Time,
+		UpdatedAt:      job.UpdatedAt.Time,
+	}
+	if job.CompletedAt != nil {
+		response.CompletedAt = &job.CompletedAt.Time
+	}
+	return response
+}
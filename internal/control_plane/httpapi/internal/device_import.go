@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+// DeviceImportColumns is the column order of device CSV exports. Imports accept the same columns
+// in any order; only name is required.
+var DeviceImportColumns = []string{"name", "display_name", "dev_eui", "join_eui", "app_key", "tenant_id", "profile", "sector"}
+
+var (
+	errDeviceImportMissingName     = errors.New("the name column is required")
+	errDeviceImportUnknownColumn   = errors.New("unknown column")
+	errDeviceImportDuplicateColumn = errors.New("repeated column")
+)
+
+// DeviceImportRecord is a device row of an import or export file.
+type DeviceImportRecord struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name,omitempty"`
+	DevEUI      string `json:"dev_eui,omitempty"`
+	JoinEUI     string `json:"join_eui,omitempty"`
+	AppKey      string `json:"app_key,omitempty"`
+	TenantID    string `json:"tenant_id,omitempty"`
+	Profile     string `json:"profile,omitempty"`
+	Sector      string `json:"sector,omitempty"`
+}
+
+type DeviceImportRequest struct {
+	Devices []DeviceImportRecord `json:"devices"`
+}
+
+type DeviceExportResponse struct {
+	Devices []DeviceImportRecord `json:"devices"`
+}
+
+type DeviceImportReportResponse struct {
+	DryRun  bool                          `json:"dry_run"`
+	Total   int                           `json:"total"`
+	Created int                           `json:"created"`
+	Invalid int                           `json:"invalid"`
+	Rows    []DeviceImportRowResultRecord `json:"rows"`
+}
+
+type DeviceImportRowResultRecord struct {
+	Line     int      `json:"line"`
+	Name     string   `json:"name"`
+	DeviceID string   `json:"device_id,omitempty"`
+	Status   string   `json:"status"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// ParseDeviceImportCSV reads device records from a CSV file with a header row.
+func ParseDeviceImportCSV(r io.Reader) ([]DeviceImportRecord, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if !isDeviceImportColumn(column) {
+			return nil, fmt.Errorf("%w: %s", errDeviceImportUnknownColumn, column)
+		}
+		if _, ok := columns[column]; ok {
+			return nil, fmt.Errorf("%w: %s", errDeviceImportDuplicateColumn, column)
+		}
+		columns[column] = i
+	}
+
+	if _, ok := columns["name"]; !ok {
+		return nil, errDeviceImportMissingName
+	}
+
+	var records []DeviceImportRecord
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading row: %w", err)
+		}
+
+		value := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		records = append(records, DeviceImportRecord{
+			Name:        value("name"),
+			DisplayName: value("display_name"),
+			DevEUI:      value("dev_eui"),
+			JoinEUI:     value("join_eui"),
+			AppKey:      value("app_key"),
+			TenantID:    value("tenant_id"),
+			Profile:     value("profile"),
+			Sector:      value("sector"),
+		})
+	}
+}
+
+// WriteDeviceExportCSV writes the devices as a CSV file that can be imported back.
+func WriteDeviceExportCSV(w io.Writer, records []DeviceImportRecord) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(DeviceImportColumns); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		err := writer.Write([]string{
+			record.Name,
+			record.DisplayName,
+			record.DevEUI,
+			record.JoinEUI,
+			record.AppKey,
+			record.TenantID,
+			record.Profile,
+			record.Sector,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// ToDeviceImportRecord leaves the AppKey out: root keys are only revealed through the audited
+// admin endpoint, so exported devices carry an empty app_key column that has to be filled in before
+// the devices with a dev_eui are imported back.
+func ToDeviceImportRecord(device domain.Device) DeviceImportRecord {
+	record := DeviceImportRecord{
+		Name:        device.Name,
+		DisplayName: device.DisplayName,
+		DevEUI:      device.DevEUI,
+		JoinEUI:     device.AppEUI,
+		Profile:     device.Profile,
+	}
+
+	if device.TenantID != nil {
+		record.TenantID = device.TenantID.String()
+	}
+	if device.Sector != nil {
+		record.Sector = string(device.Sector.Name)
+	}
+
+	return record
+}
+
+func isDeviceImportColumn(column string) bool {
+	return slices.Contains(DeviceImportColumns, column)
+}
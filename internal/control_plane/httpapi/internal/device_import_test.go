@@ -0,0 +1,68 @@
+package internal_test
+
+import (
+	"bytes"
+	"strings"
+	"zensor-server/internal/control_plane/httpapi/internal"
+	"zensor-server/internal/shared_kernel/domain"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeviceImport", func() {
+	Context("ParseDeviceImportCSV", func() {
+		When("the columns come in any order", func() {
+			It("should map every row by header", func() {
+				input := "sector, Name ,dev_eui\nB,valve-1,0004A30B001C0530\n,valve-2,\n"
+
+				records, err := internal.ParseDeviceImportCSV(strings.NewReader(input))
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(records).To(Equal([]internal.DeviceImportRecord{
+					{Name: "valve-1", DevEUI: "0004A30B001C0530", Sector: "B"},
+					{Name: "valve-2"},
+				}))
+			})
+		})
+
+		When("the name column is missing", func() {
+			It("should fail", func() {
+				_, err := internal.ParseDeviceImportCSV(strings.NewReader("dev_eui\n0004A30B001C0530\n"))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		When("a column is unknown", func() {
+			It("should fail", func() {
+				_, err := internal.ParseDeviceImportCSV(strings.NewReader("name,color\nvalve-1,red\n"))
+				Expect(err).To(MatchError(ContainSubstring("color")))
+			})
+		})
+	})
+
+	Context("WriteDeviceExportCSV", func() {
+		It("should round-trip through the import parser", func() {
+			tenantID := domain.ID("tenant-1")
+			device := domain.Device{
+				Name:        "valve-1",
+				DisplayName: "Valve, north",
+				DevEUI:      "0004A30B001C0530",
+				AppEUI:      "70B3D57ED0000001",
+				AppKey:      "000102030405060708090A0B0C0D0E0F",
+				TenantID:    &tenantID,
+				Profile:     "rak-3172",
+				Sector:      &domain.Sector{Name: "B"},
+			}
+			record := internal.ToDeviceImportRecord(device)
+			Expect(record.AppKey).To(BeEmpty())
+
+			var buffer bytes.Buffer
+			Expect(internal.WriteDeviceExportCSV(&buffer, []internal.DeviceImportRecord{record})).To(Succeed())
+
+			records, err := internal.ParseDeviceImportCSV(&buffer)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(Equal([]internal.DeviceImportRecord{record}))
+		})
+	})
+})
@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+// DeviceKeysResponse carries the LoRaWAN root keys of a device. Only the audited admin endpoint
+// returns it.
+type DeviceKeysResponse struct {
+	DeviceID string `json:"device_id"`
+	DevEUI   string `json:"dev_eui"`
+	JoinEUI  string `json:"join_eui"`
+	AppKey   string `json:"app_key"`
+}
+
+func ToDeviceKeysResponse(device domain.Device) DeviceKeysResponse {
+	return DeviceKeysResponse{
+		DeviceID: device.ID.String(),
+		DevEUI:   device.DevEUI,
+		JoinEUI:  device.AppEUI,
+		AppKey:   device.AppKey,
+	}
+}
+
+type DeviceKeyAccessResponse struct {
+	ID         string    `json:"id"`
+	DeviceID   string    `json:"device_id"`
+	UserID     string    `json:"user_id"`
+	UserEmail  string    `json:"user_email,omitempty"`
+	AccessedAt time.Time `json:"accessed_at"`
+}
+
+func ToDeviceKeyAccessResponse(access domain.DeviceKeyAccess) DeviceKeyAccessResponse {
+	return DeviceKeyAccessResponse{
+		ID:         access.ID.String(),
+		DeviceID:   access.DeviceID.String(),
+		UserID:     access.UserID.String(),
+		UserEmail:  access.UserEmail,
+		AccessedAt: access.AccessedAt.Time,
+	}
+}
@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"strconv"
+	"time"
+	"zensor-server/internal/infra/httpserver"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+// DeviceLinkQualityResponse is the LoRaWAN session of a device with a page of its most recent
+// uplink samples, newest first, and a summary of that page.
+type DeviceLinkQualityResponse struct {
+	Session DeviceSessionResponse      `json:"session"`
+	Summary LinkQualitySummaryResponse `json:"summary"`
+	httpserver.PaginatedResponse
+}
+
+type DeviceSessionResponse struct {
+	DeviceID       string     `json:"device_id"`
+	DevAddr        string     `json:"dev_addr,omitempty"`
+	JoinedAt       *time.Time `json:"joined_at,omitempty"`
+	FrameCounterUp uint32     `json:"frame_counter_up"`
+	LastUplinkAt   *time.Time `json:"last_uplink_at,omitempty"`
+}
+
+type LinkQualitySummaryResponse struct {
+	Samples          int            `json:"samples"`
+	AverageRSSI      float64        `json:"average_rssi"`
+	AverageSNR       float64        `json:"average_snr"`
+	MinRSSI          float64        `json:"min_rssi"`
+	MaxRSSI          float64        `json:"max_rssi"`
+	AverageGateways  float64        `json:"average_gateways"`
+	SpreadingFactors map[string]int `json:"spreading_factors"`
+	GatewayIDs       []string       `json:"gateway_ids"`
+}
+
+type LinkQualitySampleResponse struct {
+	FrameCounter    uint32                     `json:"frame_counter"`
+	DevAddr         string                     `json:"dev_addr,omitempty"`
+	SpreadingFactor int                        `json:"spreading_factor,omitempty"`
+	Bandwidth       int                        `json:"bandwidth,omitempty"`
+	FrequencyHz     int64                      `json:"frequency_hz,omitempty"`
+	Gateways        []GatewayReceptionResponse `json:"gateways"`
+	ReceivedAt      time.Time                  `json:"received_at"`
+}
+
+type GatewayReceptionResponse struct {
+	GatewayID string  `json:"gateway_id"`
+	RSSI      float64 `json:"rssi"`
+	SNR       float64 `json:"snr"`
+}
+
+func ToDeviceLinkQualityResponse(
+	session domain.DeviceSession,
+	summary domain.LinkQualitySummary,
+	values []domain.LinkQualitySample,
+	total int,
+	params httpserver.PaginationParams,
+) DeviceLinkQualityResponse {
+	samples := make([]LinkQualitySampleResponse, len(values))
+	for i, sample := range values {
+		samples[i] = toLinkQualitySampleResponse(sample)
+	}
+
+	return DeviceLinkQualityResponse{
+		Session:           toDeviceSessionResponse(session),
+		Summary:           toLinkQualitySummaryResponse(summary),
+		PaginatedResponse: httpserver.NewPaginatedResponse(samples, total, params),
+	}
+}
+
+func toDeviceSessionResponse(session domain.DeviceSession) DeviceSessionResponse {
+	response := DeviceSessionResponse{
+		DeviceID:       session.DeviceID.String(),
+		DevAddr:        session.DevAddr,
+		FrameCounterUp: session.FrameCounterUp,
+	}
+
+	if session.JoinedAt != nil {
+		response.JoinedAt = &session.JoinedAt.Time
+	}
+	if session.LastUplinkAt != nil {
+		response.LastUplinkAt = &session.LastUplinkAt.Time
+	}
+
+	return response
+}
+
+func toLinkQualitySummaryResponse(summary domain.LinkQualitySummary) LinkQualitySummaryResponse {
+	spreadingFactors := make(map[string]int, len(summary.SpreadingFactors))
+	for sf, count := range summary.SpreadingFactors {
+		spreadingFactors["SF"+strconv.Itoa(sf)] = count
+	}
+
+	gatewayIDs := summary.GatewayIDs
+	if gatewayIDs == nil {
+		gatewayIDs = []string{}
+	}
+
+	return LinkQualitySummaryResponse{
+		Samples:          summary.Samples,
+		AverageRSSI:      summary.AverageRSSI,
+		AverageSNR:       summary.AverageSNR,
+		MinRSSI:          summary.MinRSSI,
+		MaxRSSI:          summary.MaxRSSI,
+		AverageGateways:  summary.AverageGateways,
+		SpreadingFactors: spreadingFactors,
+		GatewayIDs:       gatewayIDs,
+	}
+}
+
+func toLinkQualitySampleResponse(sample domain.LinkQualitySample) LinkQualitySampleResponse {
+	gateways := make([]GatewayReceptionResponse, len(sample.Gateways))
+	for i, gateway := range sample.Gateways {
+		gateways[i] = GatewayReceptionResponse{
+			GatewayID: gateway.GatewayID,
+			RSSI:      gateway.RSSI,
+			SNR:       gateway.SNR,
+		}
+	}
+
+	return LinkQualitySampleResponse{
+		FrameCounter:    sample.FrameCounter,
+		DevAddr:         sample.DevAddr,
+		SpreadingFactor: sample.SpreadingFactor,
+		Bandwidth:       sample.Bandwidth,
+		FrequencyHz:     sample.FrequencyHz,
+		Gateways:        gateways,
+		ReceivedAt:      sample.ReceivedAt.Time,
+	}
+}
@@ -26,32 +26,89 @@ type SchedulingConfigurationResponse struct {
 }
 
 type ScheduledTaskCreateRequest struct {
-	Commands   []CommandSendPayloadRequest     `json:"commands"`
-	Schedule   string                          `json:"schedule,omitempty"` // Deprecated: use Scheduling instead
-	Scheduling *SchedulingConfigurationRequest `json:"scheduling,omitempty"`
-	IsActive   bool                            `json:"is_active"`
+	Commands             []CommandSendPayloadRequest     `json:"commands"`
+	CommandTemplateSetID *string                         `json:"command_template_set_id,omitempty"`
+	DeviceIDs            []string                        `json:"device_ids,omitempty"` // Additional target devices
+	Schedule             string                          `json:"schedule,omitempty"`   // Deprecated: use Scheduling instead
+	Scheduling           *SchedulingConfigurationRequest `json:"scheduling,omitempty"`
+	IsActive             bool                            `json:"is_active"`
 }
 
 type ScheduledTaskUpdateRequest struct {
-	Commands   *[]CommandSendPayloadRequest    `json:"commands,omitempty"`
-	Schedule   *string                         `json:"schedule,omitempty"` // Deprecated: use Scheduling instead
-	Scheduling *SchedulingConfigurationRequest `json:"scheduling,omitempty"`
-	IsActive   *bool                           `json:"is_active,omitempty"`
+	Commands             *[]CommandSendPayloadRequest    `json:"commands,omitempty"`
+	CommandTemplateSetID *string                         `json:"command_template_set_id,omitempty"`
+	DeviceIDs            *[]string                       `json:"device_ids,omitempty"` // Additional target devices
+	Schedule             *string                         `json:"schedule,omitempty"`   // Deprecated: use Scheduling instead
+	Scheduling           *SchedulingConfigurationRequest `json:"scheduling,omitempty"`
+	IsActive             *bool                           `json:"is_active,omitempty"`
 }
 
 type ScheduledTaskResponse struct {
-	ID         string                           `json:"id"`
-	DeviceID   string                           `json:"device_id"`
-	Commands   []CommandSendPayloadRequest      `json:"commands"`
-	Schedule   string                           `json:"schedule,omitempty"` // Deprecated: use Scheduling instead
-	Scheduling *SchedulingConfigurationResponse `json:"scheduling,omitempty"`
-	IsActive   bool                             `json:"is_active"`
+	ID                   string                           `json:"id"`
+	DeviceID             string                           `json:"device_id"`
+	DeviceIDs            []string                         `json:"device_ids"` // Additional target devices
+	Commands             []CommandSendPayloadRequest      `json:"commands"`
+	CommandTemplateSetID *string                          `json:"command_template_set_id,omitempty"`
+	Schedule             string                           `json:"schedule,omitempty"` // Deprecated: use Scheduling instead
+	Scheduling           *SchedulingConfigurationResponse `json:"scheduling,omitempty"`
+	IsActive             bool                             `json:"is_active"`
+	IsPaused             bool                             `json:"is_paused"`
+	PausedUntil          *time.Time                       `json:"paused_until,omitempty"`
+	TenantHold           *SchedulesHoldResponse           `json:"tenant_hold,omitempty"`
+}
+
+// SchedulesHoldResponse represents a tenant-wide hold that suspends every scheduled task.
+type SchedulesHoldResponse struct {
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason,omitempty"`
 }
 
 type ScheduledTaskListResponse struct {
 	ScheduledTasks []ScheduledTaskResponse `json:"scheduled_tasks"`
 }
 
+// ToScheduledTaskResponse converts a domain ScheduledTask to ScheduledTaskResponse.
+func ToScheduledTaskResponse(scheduledTask domain.ScheduledTask, nextExecution *time.Time) ScheduledTaskResponse {
+	deviceIDs := make([]string, len(scheduledTask.Devices))
+	for i, device := range scheduledTask.Devices {
+		deviceIDs[i] = device.ID.String()
+	}
+
+	response := ScheduledTaskResponse{
+		ID:         scheduledTask.ID.String(),
+		DeviceID:   scheduledTask.Device.ID.String(),
+		DeviceIDs:  deviceIDs,
+		Commands:   ToCommandPayloads(scheduledTask.CommandTemplates),
+		Schedule:   scheduledTask.Schedule,
+		Scheduling: FromSchedulingConfiguration(scheduledTask.Scheduling, nextExecution),
+		IsActive:   scheduledTask.IsActive,
+		IsPaused:   scheduledTask.IsPaused(time.Now()),
+	}
+
+	if scheduledTask.CommandTemplateSet != nil {
+		setID := scheduledTask.CommandTemplateSet.ID.String()
+		response.CommandTemplateSetID = &setID
+	}
+
+	if response.IsPaused && scheduledTask.PausedUntil != nil {
+		response.PausedUntil = &scheduledTask.PausedUntil.Time
+	}
+
+	return response
+}
+
+// FromSchedulesHold converts the tenant schedules hold to response, or nil when no hold is in effect.
+func FromSchedulesHold(config domain.TenantConfiguration) *SchedulesHoldResponse {
+	if !config.IsSchedulesHeld(time.Now()) {
+		return nil
+	}
+
+	return &SchedulesHoldResponse{
+		Until:  config.SchedulesHold.Until,
+		Reason: config.SchedulesHold.Reason,
+	}
+}
+
 // ToSchedulingConfiguration converts a request to domain SchedulingConfiguration.
 func (req *SchedulingConfigurationRequest) ToSchedulingConfiguration() domain.SchedulingConfiguration {
 	config := domain.SchedulingConfiguration{
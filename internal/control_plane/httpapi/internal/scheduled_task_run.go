@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+type ScheduledTaskRunResponse struct {
+	ID             string    `json:"id"`
+	DeviceID       string    `json:"device_id"`         // Device the run was evaluated for
+	Outcome        string    `json:"outcome"`           // "fired", "skipped", "failed" or "overlap_rejected"
+	TaskID         *string   `json:"task_id,omitempty"` // Task created by a fired run
+	CommandOutcome string    `json:"command_outcome"`   // "none", "pending", "succeeded", "failed" or "partial"
+	Failed         bool      `json:"failed"`            // Whether the run did not deliver its commands
+	Error          string    `json:"error,omitempty"`   // Why the task could not be created
+	EvaluatedAt    time.Time `json:"evaluated_at"`      // When the due schedule was evaluated
+}
+
+// ToScheduledTaskRunResponse converts a domain ScheduledTaskRun to ScheduledTaskRunResponse.
+func ToScheduledTaskRunResponse(run domain.ScheduledTaskRun) ScheduledTaskRunResponse {
+	var taskID *string
+	if run.TaskID != nil {
+		id := run.TaskID.String()
+		taskID = &id
+	}
+
+	return ScheduledTaskRunResponse{
+		ID:             run.ID.String(),
+		DeviceID:       run.DeviceID.String(),
+		Outcome:        string(run.Outcome),
+		TaskID:         taskID,
+		CommandOutcome: string(run.CommandOutcome),
+		Failed:         run.IsFailure(),
+		Error:          run.Error,
+		EvaluatedAt:    run.EvaluatedAt.Time,
+	}
+}
@@ -294,6 +294,80 @@ var _ = Describe("ScheduledTaskResponse", func() {
 	})
 })
 
+var _ = Describe("ScheduledTaskResponse", func() {
+	Context("ToScheduledTaskResponse", func() {
+		var scheduledTask domain.ScheduledTask
+		var result internal.ScheduledTaskResponse
+
+		BeforeEach(func() {
+			scheduledTask = domain.ScheduledTask{
+				ID:         domain.ID("scheduled-task-1"),
+				Device:     domain.Device{ID: domain.ID("device-1")},
+				Schedule:   "0 0 * * *",
+				Scheduling: domain.SchedulingConfiguration{Type: domain.SchedulingTypeCron},
+				IsActive:   true,
+			}
+		})
+
+		When("the scheduled task is paused until a future date", func() {
+			var until time.Time
+
+			BeforeEach(func() {
+				until = time.Now().Add(time.Hour)
+				Expect(scheduledTask.Pause(&utils.Time{Time: until})).To(Succeed())
+				result = internal.ToScheduledTaskResponse(scheduledTask, nil)
+			})
+
+			It("should report the pause and its end date", func() {
+				Expect(result.IsPaused).To(BeTrue())
+				Expect(result.PausedUntil).NotTo(BeNil())
+				Expect(result.PausedUntil.Equal(until)).To(BeTrue())
+			})
+		})
+
+		When("the scheduled task is not paused", func() {
+			BeforeEach(func() {
+				result = internal.ToScheduledTaskResponse(scheduledTask, nil)
+			})
+
+			It("should not report a pause", func() {
+				Expect(result.IsPaused).To(BeFalse())
+				Expect(result.PausedUntil).To(BeNil())
+			})
+		})
+	})
+
+	Context("FromSchedulesHold", func() {
+		var config domain.TenantConfiguration
+
+		When("the tenant holds its schedules", func() {
+			BeforeEach(func() {
+				config = domain.TenantConfiguration{
+					SchedulesHold: &domain.SchedulesHold{Until: time.Now().Add(time.Hour), Reason: "rain season"},
+				}
+			})
+
+			It("should return the hold", func() {
+				hold := internal.FromSchedulesHold(config)
+				Expect(hold).NotTo(BeNil())
+				Expect(hold.Reason).To(Equal("rain season"))
+			})
+		})
+
+		When("the tenant hold has expired", func() {
+			BeforeEach(func() {
+				config = domain.TenantConfiguration{
+					SchedulesHold: &domain.SchedulesHold{Until: time.Now().Add(-time.Hour)},
+				}
+			})
+
+			It("should return nil", func() {
+				Expect(internal.FromSchedulesHold(config)).To(BeNil())
+			})
+		})
+	})
+})
+
 func timePtr(t time.Time) *time.Time {
 	return &t
 }
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"encoding/json"
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+type WebhookCreateRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+}
+
+type WebhookUpdateRequest struct {
+	URL        *string   `json:"url,omitempty"`
+	Secret     *string   `json:"secret,omitempty"`
+	EventTypes *[]string `json:"event_types,omitempty"`
+}
+
+// WebhookResponse leaves the secret out: it is write-only.
+type WebhookResponse struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func ToWebhookResponse(subscription domain.WebhookSubscription) WebhookResponse {
+	return WebhookResponse{
+		ID:         subscription.ID.String(),
+		URL:        subscription.URL,
+		EventTypes: subscription.EventTypes,
+		CreatedAt:  subscription.CreatedAt.Time,
+		UpdatedAt:  subscription.UpdatedAt.Time,
+	}
+}
+
+type WebhookDeliveryResponse struct {
+	ID             string          `json:"id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+func ToWebhookDeliveryResponse(delivery domain.WebhookDelivery) WebhookDeliveryResponse {
+	response := WebhookDeliveryResponse{
+		ID:             delivery.ID.String(),
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		Payload:        json.RawMessage(delivery.Payload),
+		CreatedAt:      delivery.CreatedAt.Time,
+		UpdatedAt:      delivery.UpdatedAt.Time,
+	}
+	if delivery.Status == domain.WebhookDeliveryStatusPending {
+		response.NextAttemptAt = &delivery.NextAttemptAt.Time
+	}
+	if delivery.DeliveredAt != nil {
+		response.DeliveredAt = &delivery.DeliveredAt.Time
+	}
+
+	return response
+}
@@ -1,8 +1,10 @@
 package httpapi
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
@@ -14,34 +16,50 @@ import (
 )
 
 const (
-	createScheduledTaskErrMessage = "failed to create scheduled task"
-	updateScheduledTaskErrMessage = "failed to update scheduled task"
-	getScheduledTaskErrMessage    = "failed to get scheduled task"
-	listScheduledTaskErrMessage   = "failed to list scheduled tasks"
-	deleteScheduledTaskErrMessage = "failed to delete scheduled task"
+	createScheduledTaskErrMessage    = "failed to create scheduled task"
+	updateScheduledTaskErrMessage    = "failed to update scheduled task"
+	getScheduledTaskErrMessage       = "failed to get scheduled task"
+	listScheduledTaskErrMessage      = "failed to list scheduled tasks"
+	deleteScheduledTaskErrMessage    = "failed to delete scheduled task"
+	pauseScheduledTaskErrMessage     = "failed to pause scheduled task"
+	resumeScheduledTaskErrMessage    = "failed to resume scheduled task"
+	invalidPauseUntilErrMessage      = "until must be a future RFC3339 timestamp"
+	listScheduledTaskRunsErrMessage  = "failed to list scheduled task runs"
+	invalidRunsRangeErrMessage       = "from and to must be RFC3339 timestamps"
+	invalidTargetDevicesErrMessage   = "device_ids must reference devices of the tenant"
+	invalidTemplateSetErrMessage     = "command_template_set_id must reference a command template set of the tenant"
+	commandsAndTemplateSetErrMessage = "commands and command_template_set_id are mutually exclusive"
 )
 
+var errTargetOutsideTenant = errors.New("target does not belong to the tenant")
+
 func NewScheduledTaskController(
 	service usecases.ScheduledTaskService,
 	deviceService usecases.DeviceService,
 	tenantService usecases.TenantService,
 	taskService usecases.TaskService,
+	tenantConfigurationService usecases.TenantConfigurationService,
+	commandTemplateSetService usecases.CommandTemplateSetService,
 ) *ScheduledTaskController {
 	return &ScheduledTaskController{
-		service:       service,
-		deviceService: deviceService,
-		tenantService: tenantService,
-		taskService:   taskService,
+		service:                    service,
+		deviceService:              deviceService,
+		tenantService:              tenantService,
+		taskService:                taskService,
+		tenantConfigurationService: tenantConfigurationService,
+		commandTemplateSetService:  commandTemplateSetService,
 	}
 }
 
 var _ httpserver.Controller = &ScheduledTaskController{}
 
 type ScheduledTaskController struct {
-	service       usecases.ScheduledTaskService
-	deviceService usecases.DeviceService
-	tenantService usecases.TenantService
-	taskService   usecases.TaskService
+	service                    usecases.ScheduledTaskService
+	deviceService              usecases.DeviceService
+	tenantService              usecases.TenantService
+	taskService                usecases.TaskService
+	tenantConfigurationService usecases.TenantConfigurationService
+	commandTemplateSetService  usecases.CommandTemplateSetService
 }
 
 func (c *ScheduledTaskController) AddRoutes(router *http.ServeMux) {
@@ -50,7 +68,10 @@ func (c *ScheduledTaskController) AddRoutes(router *http.ServeMux) {
 	router.Handle("GET /v1/tenants/{tenant_id}/devices/{device_id}/scheduled-tasks/{id}", c.get())
 	router.Handle("PUT /v1/tenants/{tenant_id}/devices/{device_id}/scheduled-tasks/{id}", c.update())
 	router.Handle("DELETE /v1/tenants/{tenant_id}/devices/{device_id}/scheduled-tasks/{id}", c.delete())
+	router.Handle("POST /v1/tenants/{tenant_id}/devices/{device_id}/scheduled-tasks/{id}/pause", c.pause())
+	router.Handle("POST /v1/tenants/{tenant_id}/devices/{device_id}/scheduled-tasks/{id}/resume", c.resume())
 	router.Handle("GET /v1/tenants/{tenant_id}/devices/{device_id}/scheduled-tasks/{id}/tasks", c.getTasksByScheduledTask())
+	router.Handle("GET /v1/tenants/{tenant_id}/devices/{device_id}/scheduled-tasks/{id}/runs", c.listRuns())
 }
 
 func (c *ScheduledTaskController) create() http.HandlerFunc {
@@ -83,34 +104,36 @@ func (c *ScheduledTaskController) create() http.HandlerFunc {
 		}
 
 		// Create command templates from the request
-		commandTemplates := make([]domain.CommandTemplate, len(body.Commands))
-		for i, item := range body.Commands {
-			templateBuilder := domain.NewCommandTemplateBuilder()
-			template, err := templateBuilder.
-				WithDevice(device).
-				WithPayload(domain.CommandPayload{
-					Index: domain.Index(item.Index),
-					Value: domain.CommandValue(item.Value),
-				}).
-				WithPriority(domain.CommandPriority(item.Priority)).
-				WithWaitFor(time.Duration(item.WaitFor)).
-				Build()
-			if err != nil {
-				slog.Error("build command template", slog.String("error", err.Error()))
-				http.Error(w, createScheduledTaskErrMessage, http.StatusInternalServerError)
-				return
-			}
+		commandTemplates, err := buildCommandTemplates(device, body.Commands)
+		if err != nil {
+			slog.Error("build command template", slog.String("error", err.Error()))
+			http.Error(w, createScheduledTaskErrMessage, http.StatusInternalServerError)
+			return
+		}
 
-			commandTemplates[i] = template
+		devices, err := c.targetDevices(r.Context(), tenant.ID, body.DeviceIDs)
+		if err != nil {
+			c.replyTargetError(w, err, invalidTargetDevicesErrMessage, createScheduledTaskErrMessage)
+			return
 		}
 
 		// Create the scheduled task with command templates
 		builder := domain.NewScheduledTaskBuilder().
 			WithTenant(tenant).
 			WithDevice(device).
+			WithDevices(devices).
 			WithCommandTemplates(commandTemplates).
 			WithIsActive(body.IsActive)
 
+		if body.CommandTemplateSetID != nil {
+			set, err := c.commandTemplateSet(r.Context(), tenant.ID, domain.ID(*body.CommandTemplateSetID))
+			if err != nil {
+				c.replyTargetError(w, err, invalidTemplateSetErrMessage, createScheduledTaskErrMessage)
+				return
+			}
+			builder = builder.WithCommandTemplateSet(set)
+		}
+
 		if body.Scheduling != nil {
 			schedulingConfig := body.Scheduling.ToSchedulingConfiguration()
 
@@ -137,32 +160,7 @@ func (c *ScheduledTaskController) create() http.HandlerFunc {
 			return
 		}
 
-		responseCommands := make([]internal.CommandSendPayloadRequest, len(scheduledTask.CommandTemplates))
-		for i, template := range scheduledTask.CommandTemplates {
-			responseCommands[i] = internal.CommandSendPayloadRequest{
-				Index:    uint8(template.Payload.Index),
-				Value:    uint8(template.Payload.Value),
-				Priority: string(template.Priority),
-				WaitFor:  utils.Duration(template.WaitFor),
-			}
-		}
-
-		var nextExecution *time.Time
-		if scheduledTask.Scheduling.Type == domain.SchedulingTypeInterval {
-			nextExec, err := scheduledTask.CalculateNextExecution("UTC") // TODO: Get tenant timezone
-			if err == nil {
-				nextExecution = &nextExec
-			}
-		}
-
-		response := internal.ScheduledTaskResponse{
-			ID:         scheduledTask.ID.String(),
-			DeviceID:   scheduledTask.Device.ID.String(),
-			Commands:   responseCommands,
-			Schedule:   scheduledTask.Schedule,
-			Scheduling: internal.FromSchedulingConfiguration(scheduledTask.Scheduling, nextExecution),
-			IsActive:   scheduledTask.IsActive,
-		}
+		response := internal.ToScheduledTaskResponse(scheduledTask, nextExecution(scheduledTask))
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusCreated)
@@ -187,35 +185,17 @@ func (c *ScheduledTaskController) list() http.HandlerFunc {
 			return
 		}
 
+		tenantHold, err := c.tenantHold(r.Context(), domain.ID(tenantID))
+		if err != nil {
+			slog.Error("get tenant schedules hold failed", slog.String("error", err.Error()))
+			http.Error(w, listScheduledTaskErrMessage, http.StatusInternalServerError)
+			return
+		}
+
 		responses := make([]internal.ScheduledTaskResponse, len(scheduledTasks))
 		for i, scheduledTask := range scheduledTasks {
-			// Convert domain command templates to API commands
-			apiCommands := make([]internal.CommandSendPayloadRequest, len(scheduledTask.CommandTemplates))
-			for j, template := range scheduledTask.CommandTemplates {
-				apiCommands[j] = internal.CommandSendPayloadRequest{
-					Index:    uint8(template.Payload.Index),
-					Value:    uint8(template.Payload.Value),
-					Priority: string(template.Priority),
-					WaitFor:  utils.Duration(template.WaitFor),
-				}
-			}
-
-			var nextExecution *time.Time
-			if scheduledTask.Scheduling.Type == domain.SchedulingTypeInterval {
-				nextExec, err := scheduledTask.CalculateNextExecution("UTC")
-				if err == nil {
-					nextExecution = &nextExec
-				}
-			}
-
-			responses[i] = internal.ScheduledTaskResponse{
-				ID:         scheduledTask.ID.String(),
-				DeviceID:   scheduledTask.Device.ID.String(),
-				Commands:   apiCommands,
-				Schedule:   scheduledTask.Schedule,
-				Scheduling: internal.FromSchedulingConfiguration(scheduledTask.Scheduling, nextExecution),
-				IsActive:   scheduledTask.IsActive,
-			}
+			responses[i] = internal.ToScheduledTaskResponse(scheduledTask, nextExecution(scheduledTask))
+			responses[i].TenantHold = tenantHold
 		}
 
 		httpserver.ReplyWithPaginatedData(w, http.StatusOK, responses, total, params)
@@ -239,39 +219,21 @@ func (c *ScheduledTaskController) get() http.HandlerFunc {
 			return
 		}
 
-		// Verify the scheduled task belongs to the tenant and device
-		if scheduledTask.Tenant.ID != domain.ID(tenantID) || scheduledTask.Device.ID != domain.ID(deviceID) {
+		// Verify the scheduled task belongs to the tenant and targets the device
+		if scheduledTask.Tenant.ID != domain.ID(tenantID) || !scheduledTask.TargetsDevice(domain.ID(deviceID)) {
 			http.Error(w, getScheduledTaskErrMessage, http.StatusNotFound)
 			return
 		}
 
-		// Convert command templates to response format
-		responseCommands := make([]internal.CommandSendPayloadRequest, len(scheduledTask.CommandTemplates))
-		for i, template := range scheduledTask.CommandTemplates {
-			responseCommands[i] = internal.CommandSendPayloadRequest{
-				Index:    uint8(template.Payload.Index),
-				Value:    uint8(template.Payload.Value),
-				Priority: string(template.Priority),
-				WaitFor:  utils.Duration(template.WaitFor),
-			}
-		}
-
-		var nextExecution *time.Time
-		if scheduledTask.Scheduling.Type == domain.SchedulingTypeInterval {
-			nextExec, err := scheduledTask.CalculateNextExecution("UTC")
-			if err == nil {
-				nextExecution = &nextExec
-			}
+		tenantHold, err := c.tenantHold(r.Context(), scheduledTask.Tenant.ID)
+		if err != nil {
+			slog.Error("get tenant schedules hold failed", slog.String("error", err.Error()))
+			http.Error(w, getScheduledTaskErrMessage, http.StatusInternalServerError)
+			return
 		}
 
-		response := internal.ScheduledTaskResponse{
-			ID:         scheduledTask.ID.String(),
-			DeviceID:   scheduledTask.Device.ID.String(),
-			Commands:   responseCommands,
-			Schedule:   scheduledTask.Schedule,
-			Scheduling: internal.FromSchedulingConfiguration(scheduledTask.Scheduling, nextExecution),
-			IsActive:   scheduledTask.IsActive,
-		}
+		response := internal.ToScheduledTaskResponse(scheduledTask, nextExecution(scheduledTask))
+		response.TenantHold = tenantHold
 
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -295,7 +257,7 @@ func (c *ScheduledTaskController) update() http.HandlerFunc {
 		}
 
 		// Verify the scheduled task belongs to the tenant and device
-		if scheduledTask.Tenant.ID != domain.ID(tenantID) || scheduledTask.Device.ID != domain.ID(deviceID) {
+		if scheduledTask.Tenant.ID != domain.ID(tenantID) || !scheduledTask.TargetsDevice(domain.ID(deviceID)) {
 			http.Error(w, updateScheduledTaskErrMessage, http.StatusNotFound)
 			return
 		}
@@ -324,6 +286,10 @@ func (c *ScheduledTaskController) update() http.HandlerFunc {
 				scheduledTask.Schedule = *body.Scheduling.Schedule
 			}
 		}
+		if body.Commands != nil && body.CommandTemplateSetID != nil {
+			http.Error(w, commandsAndTemplateSetErrMessage, http.StatusBadRequest)
+			return
+		}
 		if body.Commands != nil {
 			// Convert API commands to domain command templates
 			device, err := c.deviceService.GetDevice(r.Context(), scheduledTask.Device.ID)
@@ -333,28 +299,34 @@ func (c *ScheduledTaskController) update() http.HandlerFunc {
 				return
 			}
 
-			commandTemplates := make([]domain.CommandTemplate, len(*body.Commands))
-			for i, item := range *body.Commands {
-				templateBuilder := domain.NewCommandTemplateBuilder()
-				template, err := templateBuilder.
-					WithDevice(device).
-					WithPayload(domain.CommandPayload{
-						Index: domain.Index(item.Index),
-						Value: domain.CommandValue(item.Value),
-					}).
-					WithPriority(domain.CommandPriority(item.Priority)).
-					WithWaitFor(time.Duration(item.WaitFor)).
-					Build()
-				if err != nil {
-					slog.Error("build command template", slog.String("error", err.Error()))
-					http.Error(w, updateScheduledTaskErrMessage, http.StatusInternalServerError)
-					return
-				}
-
-				commandTemplates[i] = template
+			commandTemplates, err := buildCommandTemplates(device, *body.Commands)
+			if err != nil {
+				slog.Error("build command template", slog.String("error", err.Error()))
+				http.Error(w, updateScheduledTaskErrMessage, http.StatusInternalServerError)
+				return
 			}
 
 			scheduledTask.CommandTemplates = commandTemplates
+			scheduledTask.CommandTemplateSet = nil
+		}
+		if body.CommandTemplateSetID != nil {
+			set, err := c.commandTemplateSet(r.Context(), scheduledTask.Tenant.ID, domain.ID(*body.CommandTemplateSetID))
+			if err != nil {
+				c.replyTargetError(w, err, invalidTemplateSetErrMessage, updateScheduledTaskErrMessage)
+				return
+			}
+
+			scheduledTask.CommandTemplateSet = &set
+			scheduledTask.CommandTemplates = nil
+		}
+		if body.DeviceIDs != nil {
+			devices, err := c.targetDevices(r.Context(), scheduledTask.Tenant.ID, *body.DeviceIDs)
+			if err != nil {
+				c.replyTargetError(w, err, invalidTargetDevicesErrMessage, updateScheduledTaskErrMessage)
+				return
+			}
+
+			scheduledTask.Devices = devices
 		}
 
 		err = c.service.Update(r.Context(), scheduledTask)
@@ -364,41 +336,184 @@ func (c *ScheduledTaskController) update() http.HandlerFunc {
 			return
 		}
 
-		// Convert command templates to response format
-		responseCommands := make([]internal.CommandSendPayloadRequest, len(scheduledTask.CommandTemplates))
-		for i, template := range scheduledTask.CommandTemplates {
-			responseCommands[i] = internal.CommandSendPayloadRequest{
-				Index:    uint8(template.Payload.Index),
-				Value:    uint8(template.Payload.Value),
-				Priority: string(template.Priority),
-				WaitFor:  utils.Duration(template.WaitFor),
+		response := internal.ToScheduledTaskResponse(scheduledTask, nextExecution(scheduledTask))
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			slog.Error("encoding scheduled task response", slog.String("error", err.Error()))
+		}
+	}
+}
+
+func (c *ScheduledTaskController) pause() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := r.PathValue("tenant_id")
+		deviceID := r.PathValue("device_id")
+		id := r.PathValue("id")
+
+		var until *utils.Time
+		if value := r.URL.Query().Get("until"); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				http.Error(w, invalidPauseUntilErrMessage, http.StatusBadRequest)
+				return
 			}
+			until = &utils.Time{Time: parsed}
 		}
 
-		var nextExecution *time.Time
-		if scheduledTask.Scheduling.Type == domain.SchedulingTypeInterval {
-			nextExec, err := scheduledTask.CalculateNextExecution("UTC")
-			if err == nil {
-				nextExecution = &nextExec
+		scheduledTask, err := c.service.GetByID(r.Context(), domain.ID(id))
+		if err != nil {
+			if errors.Is(err, usecases.ErrScheduledTaskNotFound) {
+				http.Error(w, pauseScheduledTaskErrMessage, http.StatusNotFound)
+				return
 			}
+			slog.Error("get scheduled task failed", slog.String("error", err.Error()))
+			http.Error(w, pauseScheduledTaskErrMessage, http.StatusInternalServerError)
+			return
 		}
 
-		response := internal.ScheduledTaskResponse{
-			ID:         scheduledTask.ID.String(),
-			DeviceID:   scheduledTask.Device.ID.String(),
-			Commands:   responseCommands,
-			Schedule:   scheduledTask.Schedule,
-			Scheduling: internal.FromSchedulingConfiguration(scheduledTask.Scheduling, nextExecution),
-			IsActive:   scheduledTask.IsActive,
+		if scheduledTask.Tenant.ID != domain.ID(tenantID) || !scheduledTask.TargetsDevice(domain.ID(deviceID)) {
+			http.Error(w, pauseScheduledTaskErrMessage, http.StatusNotFound)
+			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(response); err != nil {
-			slog.Error("encoding scheduled task response", slog.String("error", err.Error()))
+		scheduledTask, err = c.service.Pause(r.Context(), scheduledTask, until)
+		if errors.Is(err, domain.ErrPauseUntilMustBeInFuture) {
+			http.Error(w, invalidPauseUntilErrMessage, http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			slog.Error("pause scheduled task failed", slog.String("error", err.Error()))
+			http.Error(w, pauseScheduledTaskErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		response := internal.ToScheduledTaskResponse(scheduledTask, nextExecution(scheduledTask))
+		httpserver.ReplyJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+func (c *ScheduledTaskController) resume() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := r.PathValue("tenant_id")
+		deviceID := r.PathValue("device_id")
+		id := r.PathValue("id")
+
+		scheduledTask, err := c.service.GetByID(r.Context(), domain.ID(id))
+		if err != nil {
+			if errors.Is(err, usecases.ErrScheduledTaskNotFound) {
+				http.Error(w, resumeScheduledTaskErrMessage, http.StatusNotFound)
+				return
+			}
+			slog.Error("get scheduled task failed", slog.String("error", err.Error()))
+			http.Error(w, resumeScheduledTaskErrMessage, http.StatusInternalServerError)
+			return
 		}
+
+		if scheduledTask.Tenant.ID != domain.ID(tenantID) || !scheduledTask.TargetsDevice(domain.ID(deviceID)) {
+			http.Error(w, resumeScheduledTaskErrMessage, http.StatusNotFound)
+			return
+		}
+
+		scheduledTask, err = c.service.Resume(r.Context(), scheduledTask)
+		if err != nil {
+			slog.Error("resume scheduled task failed", slog.String("error", err.Error()))
+			http.Error(w, resumeScheduledTaskErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		response := internal.ToScheduledTaskResponse(scheduledTask, nextExecution(scheduledTask))
+		httpserver.ReplyJSONResponse(w, http.StatusOK, response)
 	}
 }
 
+func (c *ScheduledTaskController) tenantHold(ctx context.Context, tenantID domain.ID) (*internal.SchedulesHoldResponse, error) {
+	config, err := c.tenantConfigurationService.GetTenantConfiguration(ctx, domain.Tenant{ID: tenantID})
+	if errors.Is(err, usecases.ErrTenantConfigurationNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return internal.FromSchedulesHold(config), nil
+}
+
+func (c *ScheduledTaskController) targetDevices(ctx context.Context, tenantID domain.ID, ids []string) ([]domain.Device, error) {
+	devices := make([]domain.Device, len(ids))
+	for i, id := range ids {
+		device, err := c.deviceService.GetDevice(ctx, domain.ID(id))
+		if err != nil {
+			return nil, fmt.Errorf("getting target device %s: %w", id, err)
+		}
+		if !device.BelongsToTenant(tenantID) {
+			return nil, fmt.Errorf("device %s: %w", id, errTargetOutsideTenant)
+		}
+		devices[i] = device
+	}
+
+	return devices, nil
+}
+
+func (c *ScheduledTaskController) commandTemplateSet(ctx context.Context, tenantID domain.ID, id domain.ID) (domain.CommandTemplateSet, error) {
+	set, err := c.commandTemplateSetService.GetByID(ctx, id)
+	if err != nil {
+		return domain.CommandTemplateSet{}, fmt.Errorf("getting command template set %s: %w", id, err)
+	}
+	if set.Tenant.ID != tenantID {
+		return domain.CommandTemplateSet{}, fmt.Errorf("command template set %s: %w", id, errTargetOutsideTenant)
+	}
+
+	return set, nil
+}
+
+func (c *ScheduledTaskController) replyTargetError(w http.ResponseWriter, err error, invalidMessage, failedMessage string) {
+	if errors.Is(err, errTargetOutsideTenant) ||
+		errors.Is(err, usecases.ErrDeviceNotFound) ||
+		errors.Is(err, usecases.ErrCommandTemplateSetNotFound) {
+		http.Error(w, invalidMessage, http.StatusBadRequest)
+		return
+	}
+
+	slog.Error("resolving scheduled task targets failed", slog.String("error", err.Error()))
+	http.Error(w, failedMessage, http.StatusInternalServerError)
+}
+
+func buildCommandTemplates(device domain.Device, items []internal.CommandSendPayloadRequest) ([]domain.CommandTemplate, error) {
+	commandTemplates := make([]domain.CommandTemplate, len(items))
+	for i, item := range items {
+		template, err := domain.NewCommandTemplateBuilder().
+			WithDevice(device).
+			WithPayload(domain.CommandPayload{
+				Index: domain.Index(item.Index),
+				Value: domain.CommandValue(item.Value),
+			}).
+			WithPriority(domain.CommandPriority(item.Priority)).
+			WithWaitFor(time.Duration(item.WaitFor)).
+			Build()
+		if err != nil {
+			return nil, err
+		}
+
+		commandTemplates[i] = template
+	}
+
+	return commandTemplates, nil
+}
+
+func nextExecution(scheduledTask domain.ScheduledTask) *time.Time {
+	if scheduledTask.Scheduling.Type != domain.SchedulingTypeInterval {
+		return nil
+	}
+
+	nextExec, err := scheduledTask.CalculateNextExecution("UTC") // TODO: Get tenant timezone
+	if err != nil {
+		return nil
+	}
+
+	return &nextExec
+}
+
 func (c *ScheduledTaskController) getTasksByScheduledTask() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tenantID := r.PathValue("tenant_id")
@@ -429,7 +544,7 @@ func (c *ScheduledTaskController) getTasksByScheduledTask() http.HandlerFunc {
 			return
 		}
 
-		if scheduledTask.Tenant.ID != domain.ID(tenantID) || scheduledTask.Device.ID != domain.ID(deviceID) {
+		if scheduledTask.Tenant.ID != domain.ID(tenantID) || !scheduledTask.TargetsDevice(domain.ID(deviceID)) {
 			http.Error(w, "failed to get tasks", http.StatusNotFound)
 			return
 		}
@@ -483,6 +598,73 @@ func (c *ScheduledTaskController) getTasksByScheduledTask() http.HandlerFunc {
 	}
 }
 
+func (c *ScheduledTaskController) listRuns() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := r.PathValue("tenant_id")
+		deviceID := r.PathValue("device_id")
+		id := r.PathValue("id")
+
+		filter, err := runFilter(r)
+		if err != nil {
+			http.Error(w, invalidRunsRangeErrMessage, http.StatusBadRequest)
+			return
+		}
+
+		scheduledTask, err := c.service.GetByID(r.Context(), domain.ID(id))
+		if err != nil {
+			if errors.Is(err, usecases.ErrScheduledTaskNotFound) {
+				http.Error(w, listScheduledTaskRunsErrMessage, http.StatusNotFound)
+				return
+			}
+			slog.Error("get scheduled task failed", slog.String("error", err.Error()))
+			http.Error(w, listScheduledTaskRunsErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		if scheduledTask.Tenant.ID != domain.ID(tenantID) || !scheduledTask.TargetsDevice(domain.ID(deviceID)) {
+			http.Error(w, listScheduledTaskRunsErrMessage, http.StatusNotFound)
+			return
+		}
+
+		params := httpserver.ExtractPaginationParams(r)
+		pagination := usecases.Pagination{Limit: params.Limit, Offset: (params.Page - 1) * params.Limit}
+
+		runs, total, err := c.service.FindRuns(r.Context(), scheduledTask.ID, filter, pagination)
+		if err != nil {
+			slog.Error("list scheduled task runs failed", slog.String("error", err.Error()))
+			http.Error(w, listScheduledTaskRunsErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		responses := make([]internal.ScheduledTaskRunResponse, len(runs))
+		for i, run := range runs {
+			responses[i] = internal.ToScheduledTaskRunResponse(run)
+		}
+
+		httpserver.ReplyWithPaginatedData(w, http.StatusOK, responses, total, params)
+	}
+}
+
+func runFilter(r *http.Request) (usecases.ScheduledTaskRunFilter, error) {
+	var filter usecases.ScheduledTaskRunFilter
+	if value := r.URL.Query().Get("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return usecases.ScheduledTaskRunFilter{}, err
+		}
+		filter.From = &from
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return usecases.ScheduledTaskRunFilter{}, err
+		}
+		filter.To = &to
+	}
+
+	return filter, nil
+}
+
 func (c *ScheduledTaskController) delete() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		tenantID := r.PathValue("tenant_id")
@@ -502,7 +684,7 @@ func (c *ScheduledTaskController) delete() http.HandlerFunc {
 		}
 
 		// Verify the scheduled task belongs to the tenant and device
-		if scheduledTask.Tenant.ID != domain.ID(tenantID) || scheduledTask.Device.ID != domain.ID(deviceID) {
+		if scheduledTask.Tenant.ID != domain.ID(tenantID) || !scheduledTask.TargetsDevice(domain.ID(deviceID)) {
 			http.Error(w, deleteScheduledTaskErrMessage, http.StatusNotFound)
 			return
 		}
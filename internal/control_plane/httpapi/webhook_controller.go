@@ -0,0 +1,286 @@
+package httpapi
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"zensor-server/internal/control_plane/httpapi/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/httpserver"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+const (
+	createWebhookErrMessage                = "failed to create webhook"
+	updateWebhookErrMessage                = "failed to update webhook"
+	getWebhookErrMessage                   = "failed to get webhook"
+	listWebhookErrMessage                  = "failed to list webhooks"
+	deleteWebhookErrMessage                = "failed to delete webhook"
+	listWebhookDeliveriesErrMessage        = "failed to list webhook deliveries"
+	redeliverWebhookErrMessage             = "failed to redeliver webhook delivery"
+	invalidWebhookDeliveryStatusErrMessage = "status must be one of pending, succeeded and dead"
+)
+
+func NewWebhookController(
+	service usecases.WebhookService,
+	tenantService usecases.TenantService,
+) *WebhookController {
+	return &WebhookController{
+		service:       service,
+		tenantService: tenantService,
+	}
+}
+
+var _ httpserver.Controller = (*WebhookController)(nil)
+
+type WebhookController struct {
+	service       usecases.WebhookService
+	tenantService usecases.TenantService
+}
+
+func (c *WebhookController) AddRoutes(router *http.ServeMux) {
+	router.Handle("POST /v1/tenants/{tenant_id}/webhooks", c.create())
+	router.Handle("GET /v1/tenants/{tenant_id}/webhooks", c.list())
+	router.Handle("GET /v1/tenants/{tenant_id}/webhooks/{id}", c.get())
+	router.Handle("PUT /v1/tenants/{tenant_id}/webhooks/{id}", c.update())
+	router.Handle("DELETE /v1/tenants/{tenant_id}/webhooks/{id}", c.delete())
+	router.Handle("GET /v1/tenants/{tenant_id}/webhooks/{id}/deliveries", c.listDeliveries())
+	router.Handle("POST /v1/tenants/{tenant_id}/webhooks/{id}/deliveries/{delivery_id}/redeliver", c.redeliver())
+}
+
+func (c *WebhookController) create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := r.PathValue("tenant_id")
+
+		tenant, err := c.tenantService.GetTenant(r.Context(), domain.ID(tenantID))
+		if errors.Is(err, usecases.ErrTenantNotFound) {
+			http.Error(w, createWebhookErrMessage, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			slog.Error("get tenant failed", slog.String("error", err.Error()))
+			http.Error(w, createWebhookErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		var body internal.WebhookCreateRequest
+		err = httpserver.DecodeJSONBody(r, &body)
+		if err != nil {
+			slog.Error("decoding json body", slog.String("error", err.Error()))
+			http.Error(w, createWebhookErrMessage, http.StatusBadRequest)
+			return
+		}
+
+		subscription, err := domain.NewWebhookSubscriptionBuilder().
+			WithTenant(tenant).
+			WithURL(body.URL).
+			WithSecret(body.Secret).
+			WithEventTypes(body.EventTypes).
+			Build()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = c.service.Create(r.Context(), subscription)
+		if err != nil {
+			slog.Error("create webhook failed", slog.String("error", err.Error()))
+			http.Error(w, createWebhookErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		httpserver.ReplyJSONResponse(w, http.StatusCreated, internal.ToWebhookResponse(subscription))
+	}
+}
+
+func (c *WebhookController) list() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := r.PathValue("tenant_id")
+
+		params := httpserver.ExtractPaginationParams(r)
+		pagination := usecases.Pagination{Limit: params.Limit, Offset: (params.Page - 1) * params.Limit}
+
+		subscriptions, total, err := c.service.FindAllByTenant(r.Context(), domain.ID(tenantID), pagination)
+		if err != nil {
+			slog.Error("list webhooks failed", slog.String("error", err.Error()))
+			http.Error(w, listWebhookErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		responses := make([]internal.WebhookResponse, len(subscriptions))
+		for i, subscription := range subscriptions {
+			responses[i] = internal.ToWebhookResponse(subscription)
+		}
+
+		httpserver.ReplyWithPaginatedData(w, http.StatusOK, responses, total, params)
+	}
+}
+
+func (c *WebhookController) get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subscription, ok := c.findTenantWebhook(w, r, getWebhookErrMessage)
+		if !ok {
+			return
+		}
+
+		httpserver.ReplyJSONResponse(w, http.StatusOK, internal.ToWebhookResponse(subscription))
+	}
+}
+
+func (c *WebhookController) update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subscription, ok := c.findTenantWebhook(w, r, updateWebhookErrMessage)
+		if !ok {
+			return
+		}
+
+		var body internal.WebhookUpdateRequest
+		err := httpserver.DecodeJSONBody(r, &body)
+		if err != nil {
+			slog.Error("decoding json body", slog.String("error", err.Error()))
+			http.Error(w, updateWebhookErrMessage, http.StatusBadRequest)
+			return
+		}
+
+		if body.URL != nil {
+			subscription.URL = *body.URL
+		}
+		if body.Secret != nil {
+			subscription.Secret = *body.Secret
+		}
+		if body.EventTypes != nil {
+			subscription.EventTypes = *body.EventTypes
+		}
+
+		err = subscription.Validate()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = c.service.Update(r.Context(), subscription)
+		if err != nil {
+			slog.Error("update webhook failed", slog.String("error", err.Error()))
+			http.Error(w, updateWebhookErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		httpserver.ReplyJSONResponse(w, http.StatusOK, internal.ToWebhookResponse(subscription))
+	}
+}
+
+func (c *WebhookController) delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subscription, ok := c.findTenantWebhook(w, r, deleteWebhookErrMessage)
+		if !ok {
+			return
+		}
+
+		err := c.service.Delete(r.Context(), subscription.ID)
+		if err != nil {
+			slog.Error("delete webhook failed", slog.String("error", err.Error()))
+			http.Error(w, deleteWebhookErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (c *WebhookController) listDeliveries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := deliveryFilter(r)
+		if err != nil {
+			http.Error(w, invalidWebhookDeliveryStatusErrMessage, http.StatusBadRequest)
+			return
+		}
+
+		subscription, ok := c.findTenantWebhook(w, r, listWebhookDeliveriesErrMessage)
+		if !ok {
+			return
+		}
+
+		params := httpserver.ExtractPaginationParams(r)
+		pagination := usecases.Pagination{Limit: params.Limit, Offset: (params.Page - 1) * params.Limit}
+
+		deliveries, total, err := c.service.Deliveries(r.Context(), subscription.ID, filter, pagination)
+		if err != nil {
+			slog.Error("list webhook deliveries failed", slog.String("error", err.Error()))
+			http.Error(w, listWebhookDeliveriesErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		responses := make([]internal.WebhookDeliveryResponse, len(deliveries))
+		for i, delivery := range deliveries {
+			responses[i] = internal.ToWebhookDeliveryResponse(delivery)
+		}
+
+		httpserver.ReplyWithPaginatedData(w, http.StatusOK, responses, total, params)
+	}
+}
+
+func (c *WebhookController) redeliver() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		subscription, ok := c.findTenantWebhook(w, r, redeliverWebhookErrMessage)
+		if !ok {
+			return
+		}
+
+		delivery, err := c.service.Redeliver(r.Context(), subscription.ID, domain.ID(r.PathValue("delivery_id")))
+		if errors.Is(err, usecases.ErrWebhookDeliveryNotFound) {
+			http.Error(w, redeliverWebhookErrMessage, http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, domain.ErrWebhookDeliveryNotRetryable) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			slog.Error("redeliver webhook delivery failed", slog.String("error", err.Error()))
+			http.Error(w, redeliverWebhookErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		httpserver.ReplyJSONResponse(w, http.StatusOK, internal.ToWebhookDeliveryResponse(delivery))
+	}
+}
+
+func deliveryFilter(r *http.Request) (usecases.WebhookDeliveryFilter, error) {
+	var filter usecases.WebhookDeliveryFilter
+	value := r.URL.Query().Get("status")
+	if value == "" {
+		return filter, nil
+	}
+
+	status := domain.WebhookDeliveryStatus(value)
+	switch status {
+	case domain.WebhookDeliveryStatusPending, domain.WebhookDeliveryStatusSucceeded, domain.WebhookDeliveryStatusDead:
+		filter.Status = &status
+		return filter, nil
+	default:
+		return filter, errors.New(invalidWebhookDeliveryStatusErrMessage)
+	}
+}
+
+func (c *WebhookController) findTenantWebhook(w http.ResponseWriter, r *http.Request, errMessage string) (domain.WebhookSubscription, bool) {
+	tenantID := r.PathValue("tenant_id")
+	id := r.PathValue("id")
+
+	subscription, err := c.service.GetByID(r.Context(), domain.ID(id))
+	if errors.Is(err, usecases.ErrWebhookSubscriptionNotFound) {
+		http.Error(w, errMessage, http.StatusNotFound)
+		return domain.WebhookSubscription{}, false
+	}
+	if err != nil {
+		slog.Error("get webhook failed", slog.String("error", err.Error()))
+		http.Error(w, errMessage, http.StatusInternalServerError)
+		return domain.WebhookSubscription{}, false
+	}
+
+	if subscription.Tenant.ID != domain.ID(tenantID) {
+		http.Error(w, errMessage, http.StatusNotFound)
+		return domain.WebhookSubscription{}, false
+	}
+
+	return subscription, true
+}
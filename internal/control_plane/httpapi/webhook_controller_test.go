@@ -0,0 +1,170 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+	"zensor-server/internal/control_plane/httpapi"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/shared_kernel/domain"
+
+	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+	mocksharedusecases "zensor-server/test/unit/doubles/shared_kernel/usecases"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = Describe("WebhookController", func() {
+	var (
+		ctrl              *gomock.Controller
+		mockService       *mockusecases.MockWebhookService
+		mockTenantService *mocksharedusecases.MockTenantService
+		router            *http.ServeMux
+		recorder          *httptest.ResponseRecorder
+		tenant            domain.Tenant
+		subscription      domain.WebhookSubscription
+	)
+
+	BeforeEach(func() {
+		slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+		ctrl = gomock.NewController(GinkgoT())
+		mockService = mockusecases.NewMockWebhookService(ctrl)
+		mockTenantService = mocksharedusecases.NewMockTenantService(ctrl)
+		router = http.NewServeMux()
+		httpapi.NewWebhookController(mockService, mockTenantService).AddRoutes(router)
+		recorder = httptest.NewRecorder()
+
+		tenant = domain.Tenant{ID: domain.ID("tenant-1")}
+		var err error
+		subscription, err = domain.NewWebhookSubscriptionBuilder().
+			WithTenant(tenant).
+			WithURL("https://example.com/hooks").
+			WithSecret("top-secret").
+			WithEventTypes([]string{"uplink"}).
+			Build()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	serve := func(method, target, body string) {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		router.ServeHTTP(recorder, request)
+	}
+
+	Context("create", func() {
+		It("should create the webhook without returning its secret", func() {
+			mockTenantService.EXPECT().GetTenant(gomock.Any(), tenant.ID).Return(tenant, nil)
+			var created domain.WebhookSubscription
+			mockService.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, value domain.WebhookSubscription) error {
+				created = value
+				return nil
+			})
+
+			serve(http.MethodPost, "/v1/tenants/tenant-1/webhooks",
+				`{"url": "https://example.com/hooks", "secret": "top-secret", "event_types": ["uplink", "device_offline"]}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusCreated))
+			Expect(created.Secret).To(Equal("top-secret"))
+			Expect(created.EventTypes).To(Equal([]string{"uplink", "device_offline"}))
+			Expect(recorder.Body.String()).NotTo(ContainSubstring("top-secret"))
+
+			var response map[string]any
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+			Expect(response["id"]).To(Equal(created.ID.String()))
+			Expect(response["url"]).To(Equal("https://example.com/hooks"))
+		})
+
+		It("should reject unknown event types", func() {
+			mockTenantService.EXPECT().GetTenant(gomock.Any(), tenant.ID).Return(tenant, nil)
+
+			serve(http.MethodPost, "/v1/tenants/tenant-1/webhooks",
+				`{"url": "https://example.com/hooks", "secret": "top-secret", "event_types": ["unknown"]}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("should return 404 for unknown tenants", func() {
+			mockTenantService.EXPECT().GetTenant(gomock.Any(), tenant.ID).Return(domain.Tenant{}, usecases.ErrTenantNotFound)
+
+			serve(http.MethodPost, "/v1/tenants/tenant-1/webhooks", `{}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Context("update", func() {
+		It("should change the fields sent only", func() {
+			mockService.EXPECT().GetByID(gomock.Any(), subscription.ID).Return(subscription, nil)
+			var updated domain.WebhookSubscription
+			mockService.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, value domain.WebhookSubscription) error {
+				updated = value
+				return nil
+			})
+
+			serve(http.MethodPut, "/v1/tenants/tenant-1/webhooks/"+subscription.ID.String(), `{"event_types": ["device_online"]}`)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(updated.EventTypes).To(Equal([]string{"device_online"}))
+			Expect(updated.URL).To(Equal(subscription.URL))
+			Expect(updated.Secret).To(Equal("top-secret"))
+		})
+	})
+
+	Context("get", func() {
+		It("should hide the webhooks of other tenants", func() {
+			mockService.EXPECT().GetByID(gomock.Any(), subscription.ID).Return(subscription, nil)
+
+			serve(http.MethodGet, "/v1/tenants/tenant-2/webhooks/"+subscription.ID.String(), "")
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Context("deliveries", func() {
+		It("should filter the deliveries by status", func() {
+			delivery := domain.NewWebhookDelivery(subscription, "event-1", "uplink", []byte(`{"type":"uplink"}`), time.Now())
+			delivery.Abandon("the webhook subscription was deleted", time.Now())
+
+			mockService.EXPECT().GetByID(gomock.Any(), subscription.ID).Return(subscription, nil)
+			mockService.EXPECT().
+				Deliveries(gomock.Any(), subscription.ID, gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ any, _ domain.ID, filter usecases.WebhookDeliveryFilter, _ usecases.Pagination) ([]domain.WebhookDelivery, int, error) {
+					Expect(*filter.Status).To(Equal(domain.WebhookDeliveryStatusDead))
+					return []domain.WebhookDelivery{delivery}, 1, nil
+				})
+
+			serve(http.MethodGet, "/v1/tenants/tenant-1/webhooks/"+subscription.ID.String()+"/deliveries?status=dead", "")
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			var response struct {
+				Data []map[string]any `json:"data"`
+			}
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+			Expect(response.Data).To(HaveLen(1))
+			Expect(response.Data[0]["status"]).To(Equal("dead"))
+			Expect(response.Data[0]["payload"]).To(HaveKeyWithValue("type", "uplink"))
+		})
+
+		It("should reject unknown statuses", func() {
+			serve(http.MethodGet, "/v1/tenants/tenant-1/webhooks/"+subscription.ID.String()+"/deliveries?status=lost", "")
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("should only redeliver dead deliveries", func() {
+			mockService.EXPECT().GetByID(gomock.Any(), subscription.ID).Return(subscription, nil)
+			mockService.EXPECT().
+				Redeliver(gomock.Any(), subscription.ID, domain.ID("delivery-1")).
+				Return(domain.WebhookDelivery{}, domain.ErrWebhookDeliveryNotRetryable)
+
+			serve(http.MethodPost, "/v1/tenants/tenant-1/webhooks/"+subscription.ID.String()+"/deliveries/delivery-1/redeliver", "")
+
+			Expect(recorder.Code).To(Equal(http.StatusConflict))
+		})
+	})
+})
@@ -3,13 +3,18 @@ package persistence
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 	"zensor-server/internal/control_plane/persistence/internal"
 	"zensor-server/internal/control_plane/usecases"
 	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/infra/utils"
 	"zensor-server/internal/shared_kernel/domain"
 )
 
+var errCommandNotFound = errors.New("command not found in database")
+
 func NewCommandRepository(orm sql.ORM) (*SimpleCommandRepository, error) {
 	err := orm.AutoMigrate(&internal.Command{})
 	if err != nil {
@@ -37,37 +42,44 @@ func (r *SimpleCommandRepository) Create(ctx context.Context, cmd domain.Command
 	return nil
 }
 
+// Update writes the dispatch and status columns of the command by ID. The other columns, and the
+// dispatch claim in particular, are left as stored so a concurrent claim is never overwritten. An
+// empty status and unset status timestamps keep their stored values.
 func (r *SimpleCommandRepository) Update(ctx context.Context, cmd domain.Command) error {
-	var existingCmd internal.Command
-	err := r.orm.WithContext(ctx).First(&existingCmd, "id = ?", cmd.ID.String()).Error()
-	if err != nil {
-		return fmt.Errorf("command not found in database: %w", err)
+	entity := internal.FromCommand(cmd)
+	values := map[string]any{
+		"version":       entity.Version + 1,
+		"ready":         entity.Ready,
+		"sent":          entity.Sent,
+		"sent_at":       entity.SentAt,
+		"error_message": entity.ErrorMessage,
 	}
-
-	cmd.CreatedAt = existingCmd.CreatedAt
-
-	if cmd.Status == "" {
-		cmd.Status = domain.CommandStatus(existingCmd.Status)
+	if entity.Status != "" {
+		values["status"] = entity.Status
 	}
-
-	if cmd.QueuedAt == nil && existingCmd.QueuedAt != nil {
-		cmd.QueuedAt = existingCmd.QueuedAt
+	if entity.QueuedAt != nil {
+		values["queued_at"] = entity.QueuedAt
 	}
-	if cmd.AckedAt == nil && existingCmd.AckedAt != nil {
-		cmd.AckedAt = existingCmd.AckedAt
+	if entity.AckedAt != nil {
+		values["acked_at"] = entity.AckedAt
 	}
-	if cmd.FailedAt == nil && existingCmd.FailedAt != nil {
-		cmd.FailedAt = existingCmd.FailedAt
+	if entity.FailedAt != nil {
+		values["failed_at"] = entity.FailedAt
 	}
 
-	entity := internal.FromCommand(cmd)
-	entity.Version = existingCmd.Version + 1
-
-	err = r.orm.WithContext(ctx).Save(&entity).Error()
-	if err != nil {
+	result := r.orm.
+		WithContext(ctx).
+		Model(&internal.Command{}).
+		Where("id = ?", entity.ID).
+		Updates(values)
+	if err := result.Error(); err != nil {
 		return fmt.Errorf("updating command in database: %w", err)
 	}
 
+	if result.RowsAffected() == 0 {
+		return errCommandNotFound
+	}
+
 	return nil
 }
 
@@ -75,7 +87,7 @@ func (r *SimpleCommandRepository) FindAllPending(ctx context.Context) ([]domain.
 	var entities internal.CommandSet
 	err := r.orm.
 		WithContext(ctx).
-		Where("sent = ? AND ready = ?", false, false).
+		Where("sent = ? AND ready = ? AND status <> ?", false, false, domain.CommandStatusCancelled).
 		Find(&entities).
 		Error()
 	if err != nil {
@@ -85,25 +97,84 @@ func (r *SimpleCommandRepository) FindAllPending(ctx context.Context) ([]domain.
 	return entities.ToDomain(), nil
 }
 
-func (r *SimpleCommandRepository) FindAllReadyToDispatch(ctx context.Context) ([]domain.Command, error) {
-	var entities internal.CommandSet
+func (r *SimpleCommandRepository) ClaimReadyToDispatch(ctx context.Context, claimer string, lease time.Duration) ([]domain.Command, error) {
+	now := time.Now().UTC()
+
+	var candidates internal.CommandSet
 	err := r.orm.
 		WithContext(ctx).
-		Where("ready = ? AND sent = ?", true, false).
-		Find(&entities).
+		Where("ready = ? AND sent = ? AND status <> ? AND (claimed_until IS NULL OR claimed_until < ?)", true, false, domain.CommandStatusCancelled, now).
+		Find(&candidates).
 		Error()
 	if err != nil {
 		return nil, fmt.Errorf("database query: %w", err)
 	}
 
-	return entities.ToDomain(), nil
+	claimed := make([]domain.Command, 0, len(candidates))
+	for _, candidate := range candidates {
+		ok, err := r.claim(ctx, candidate.ID, claimer, now, now.Add(lease))
+		if err != nil {
+			return nil, fmt.Errorf("claiming command %s: %w", candidate.ID, err)
+		}
+		if ok {
+			claimed = append(claimed, candidate.ToDomain())
+		}
+	}
+
+	return claimed, nil
+}
+
+func (r *SimpleCommandRepository) claim(ctx context.Context, id string, claimer string, now time.Time, until time.Time) (bool, error) {
+	result := r.orm.
+		WithContext(ctx).
+		Model(&internal.Command{}).
+		Where("id = ? AND sent = ? AND status <> ? AND (claimed_until IS NULL OR claimed_until < ?)", id, false, domain.CommandStatusCancelled, now).
+		Updates(map[string]any{"claimed_by": claimer, "claimed_until": utils.Time{Time: until}})
+	if err := result.Error(); err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+func (r *SimpleCommandRepository) ReleaseClaim(ctx context.Context, id domain.ID, claimer string) error {
+	err := r.orm.
+		WithContext(ctx).
+		Model(&internal.Command{}).
+		Where("id = ? AND claimed_by = ?", id.String(), claimer).
+		Updates(map[string]any{"claimed_by": nil, "claimed_until": nil}).
+		Error()
+	if err != nil {
+		return fmt.Errorf("releasing command claim: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SimpleCommandRepository) MarkSent(ctx context.Context, id domain.ID, sentAt time.Time) error {
+	err := r.orm.
+		WithContext(ctx).
+		Model(&internal.Command{}).
+		Where("id = ?", id.String()).
+		Updates(map[string]any{
+			"sent":          true,
+			"sent_at":       utils.Time{Time: sentAt},
+			"claimed_by":    nil,
+			"claimed_until": nil,
+		}).
+		Error()
+	if err != nil {
+		return fmt.Errorf("marking command sent: %w", err)
+	}
+
+	return nil
 }
 
 func (r *SimpleCommandRepository) FindPendingByDevice(ctx context.Context, deviceID domain.ID) ([]domain.Command, error) {
 	var entities internal.CommandSet
 	err := r.orm.
 		WithContext(ctx).
-		Where("sent = ? AND device_id = ?", false, deviceID.String()).
+		Where("sent = ? AND device_id = ? AND status <> ?", false, deviceID.String(), domain.CommandStatusCancelled).
 		Find(&entities).
 		Error()
 	if err != nil {
@@ -113,6 +184,25 @@ func (r *SimpleCommandRepository) FindPendingByDevice(ctx context.Context, devic
 	return entities.ToDomain(), nil
 }
 
+func (r *SimpleCommandRepository) CancelPendingByDevice(ctx context.Context, deviceID domain.ID, reason string) (int, error) {
+	result := r.orm.
+		WithContext(ctx).
+		Model(&internal.Command{}).
+		Where("sent = ? AND device_id = ? AND status <> ?", false, deviceID.String(), domain.CommandStatusCancelled).
+		Updates(map[string]any{
+			"status":        string(domain.CommandStatusCancelled),
+			"ready":         false,
+			"error_message": reason,
+			"claimed_by":    nil,
+			"claimed_until": nil,
+		})
+	if err := result.Error(); err != nil {
+		return 0, fmt.Errorf("cancelling pending commands: %w", err)
+	}
+
+	return int(result.RowsAffected()), nil
+}
+
 func (r *SimpleCommandRepository) GetByID(ctx context.Context, id domain.ID) (domain.Command, error) {
 	var entity internal.Command
 	err := r.orm.
@@ -140,3 +230,29 @@ func (r *SimpleCommandRepository) FindByTaskID(ctx context.Context, taskID domai
 
 	return entities.ToDomain(), nil
 }
+
+// CountOutcomesByDevice counts the commands of the device acknowledged or failed since the given instant.
+func (r *SimpleCommandRepository) CountOutcomesByDevice(ctx context.Context, deviceID domain.ID, since time.Time) (domain.CommandOutcomes, error) {
+	var succeeded, failed int64
+	err := r.orm.
+		WithContext(ctx).
+		Model(&internal.Command{}).
+		Where("device_id = ? AND status = ? AND acked_at >= ?", deviceID.String(), domain.CommandStatusAck, since).
+		Count(&succeeded).
+		Error()
+	if err != nil {
+		return domain.CommandOutcomes{}, fmt.Errorf("counting acknowledged commands: %w", err)
+	}
+
+	err = r.orm.
+		WithContext(ctx).
+		Model(&internal.Command{}).
+		Where("device_id = ? AND status = ? AND failed_at >= ?", deviceID.String(), domain.CommandStatusFailed, since).
+		Count(&failed).
+		Error()
+	if err != nil {
+		return domain.CommandOutcomes{}, fmt.Errorf("counting failed commands: %w", err)
+	}
+
+	return domain.CommandOutcomes{Succeeded: int(succeeded), Failed: int(failed)}, nil
+}
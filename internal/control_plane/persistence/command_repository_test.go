@@ -53,6 +53,153 @@ var _ = ginkgo.Describe("CommandRepository", func() {
 		})
 	})
 
+	ginkgo.Context("CountOutcomesByDevice", func() {
+		ginkgo.It("should count the commands acknowledged or failed in the window", func() {
+			device := domain.Device{ID: domain.ID(utils.GenerateUUID()), Name: "outcome-device"}
+			now := time.Now()
+			recent := utils.Time{Time: now.Add(-time.Hour)}
+			old := utils.Time{Time: now.Add(-48 * time.Hour)}
+			commands := []domain.Command{
+				{Status: domain.CommandStatusAck, AckedAt: &recent},
+				{Status: domain.CommandStatusAck, AckedAt: &recent},
+				{Status: domain.CommandStatusFailed, FailedAt: &recent},
+				{Status: domain.CommandStatusFailed, FailedAt: &old},
+				{Status: domain.CommandStatusPending},
+			}
+			for _, command := range commands {
+				command.ID = domain.ID(utils.GenerateUUID())
+				command.Version = 1
+				command.Device = device
+				gomega.Expect(repo.Create(ctx, command)).To(gomega.Succeed())
+			}
+
+			outcomes, err := repo.CountOutcomesByDevice(ctx, device.ID, now.Add(-24*time.Hour))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(outcomes).To(gomega.Equal(domain.CommandOutcomes{Succeeded: 2, Failed: 1}))
+		})
+	})
+
+	ginkgo.Context("ClaimReadyToDispatch", func() {
+		var command domain.Command
+
+		ginkgo.BeforeEach(func() {
+			command = domain.Command{
+				ID:      domain.ID(utils.GenerateUUID()),
+				Version: 1,
+				Device:  domain.Device{ID: domain.ID(utils.GenerateUUID()), Name: "claim-device"},
+				Port:    domain.Port(15),
+				Ready:   true,
+				Status:  domain.CommandStatusPending,
+			}
+			gomega.Expect(repo.Create(ctx, command)).To(gomega.Succeed())
+		})
+
+		ginkgo.When("two claimers race for the same command", func() {
+			ginkgo.It("should hand the command to the first claimer only", func() {
+				claimed, err := repo.ClaimReadyToDispatch(ctx, "replica-1", time.Minute)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(claimed).To(gomega.HaveLen(1))
+				gomega.Expect(claimed[0].ID).To(gomega.Equal(command.ID))
+
+				claimed, err = repo.ClaimReadyToDispatch(ctx, "replica-2", time.Minute)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(claimed).To(gomega.BeEmpty())
+			})
+		})
+
+		ginkgo.When("the claim is released", func() {
+			ginkgo.It("should let another claimer take the command", func() {
+				_, err := repo.ClaimReadyToDispatch(ctx, "replica-1", time.Minute)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				gomega.Expect(repo.ReleaseClaim(ctx, command.ID, "replica-1")).To(gomega.Succeed())
+
+				claimed, err := repo.ClaimReadyToDispatch(ctx, "replica-2", time.Minute)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(claimed).To(gomega.HaveLen(1))
+			})
+		})
+
+		ginkgo.When("the claimer crashed and its lease expired", func() {
+			ginkgo.It("should let another claimer take the command", func() {
+				_, err := repo.ClaimReadyToDispatch(ctx, "replica-1", -time.Second)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				claimed, err := repo.ClaimReadyToDispatch(ctx, "replica-2", time.Minute)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(claimed).To(gomega.HaveLen(1))
+			})
+		})
+
+		ginkgo.When("the claimer marks the command sent", func() {
+			ginkgo.It("should record it and release the claim for good", func() {
+				_, err := repo.ClaimReadyToDispatch(ctx, "replica-1", -time.Second)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				sentAt := time.Now()
+				gomega.Expect(repo.MarkSent(ctx, command.ID, sentAt)).To(gomega.Succeed())
+
+				stored, err := repo.GetByID(ctx, command.ID)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(stored.Sent).To(gomega.BeTrue())
+				gomega.Expect(stored.SentAt.Time).To(gomega.BeTemporally("~", sentAt, time.Second))
+
+				claimed, err := repo.ClaimReadyToDispatch(ctx, "replica-2", time.Minute)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(claimed).To(gomega.BeEmpty())
+			})
+		})
+
+		ginkgo.When("the command was already sent", func() {
+			ginkgo.It("should not claim it", func() {
+				command.Sent = true
+				gomega.Expect(repo.Update(ctx, command)).To(gomega.Succeed())
+
+				claimed, err := repo.ClaimReadyToDispatch(ctx, "replica-1", time.Minute)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(claimed).To(gomega.BeEmpty())
+			})
+		})
+	})
+
+	ginkgo.Context("CancelPendingByDevice", func() {
+		var deviceID domain.ID
+
+		ginkgo.BeforeEach(func() {
+			deviceID = domain.ID(utils.GenerateUUID())
+			for _, ready := range []bool{false, true} {
+				gomega.Expect(repo.Create(ctx, domain.Command{
+					ID:      domain.ID(utils.GenerateUUID()),
+					Version: 1,
+					Device:  domain.Device{ID: deviceID, Name: "cancel-device"},
+					Port:    domain.Port(15),
+					Ready:   ready,
+					Status:  domain.CommandStatusPending,
+				})).To(gomega.Succeed())
+			}
+		})
+
+		ginkgo.When("the device has unsent commands", func() {
+			ginkgo.It("should cancel them so they are never dispatched", func() {
+				cancelled, err := repo.CancelPendingByDevice(ctx, deviceID, "device decommissioned")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(cancelled).To(gomega.Equal(2))
+
+				pending, err := repo.FindPendingByDevice(ctx, deviceID)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(pending).To(gomega.BeEmpty())
+
+				claimed, err := repo.ClaimReadyToDispatch(ctx, "replica-1", time.Minute)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(claimed).To(gomega.BeEmpty())
+
+				cancelled, err = repo.CancelPendingByDevice(ctx, deviceID, "device decommissioned")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(cancelled).To(gomega.BeZero())
+			})
+		})
+	})
+
 	ginkgo.Context("FindPendingByDevice", func() {
 		var deviceID domain.ID
 
@@ -160,6 +307,24 @@ var _ = ginkgo.Describe("CommandRepository", func() {
 				gomega.Expect(result.Ready).To(gomega.BeTrue())
 				gomega.Expect(result.Sent).To(gomega.BeTrue())
 			})
+
+			ginkgo.It("should keep a dispatch claim taken after the command was read", func() {
+				claimedUntil := utils.Time{Time: time.Now().Add(time.Minute)}
+				gomega.Expect(orm.WithContext(ctx).
+					Model(&internal.Command{}).
+					Where("id = ?", cmd.ID.String()).
+					Updates(map[string]any{"claimed_by": "replica-2", "claimed_until": claimedUntil}).
+					Error()).To(gomega.Succeed())
+
+				cmd.UpdateStatus(domain.CommandStatusQueued, nil)
+				gomega.Expect(repo.Update(ctx, cmd)).To(gomega.Succeed())
+
+				var entity internal.Command
+				gomega.Expect(orm.WithContext(ctx).First(&entity, "id = ?", cmd.ID.String()).Error()).To(gomega.Succeed())
+				gomega.Expect(entity.Status).To(gomega.Equal(string(domain.CommandStatusQueued)))
+				gomega.Expect(entity.ClaimedBy).To(gomega.HaveValue(gomega.Equal("replica-2")))
+				gomega.Expect(entity.ClaimedUntil).NotTo(gomega.BeNil())
+			})
 		})
 
 		ginkgo.When("updating a non-existent command", func() {
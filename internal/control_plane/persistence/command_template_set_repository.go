@@ -0,0 +1,126 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"zensor-server/internal/control_plane/persistence/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+func NewCommandTemplateSetRepository(orm sql.ORM) (*SimpleCommandTemplateSetRepository, error) {
+	err := orm.AutoMigrate(&internal.CommandTemplateSet{})
+	if err != nil {
+		return nil, fmt.Errorf("auto migrating: %w", err)
+	}
+
+	return &SimpleCommandTemplateSetRepository{
+		orm: orm,
+	}, nil
+}
+
+var _ usecases.CommandTemplateSetRepository = (*SimpleCommandTemplateSetRepository)(nil)
+
+type SimpleCommandTemplateSetRepository struct {
+	orm sql.ORM
+}
+
+func (r *SimpleCommandTemplateSetRepository) Create(ctx context.Context, set domain.CommandTemplateSet) error {
+	entity := internal.FromCommandTemplateSet(set)
+
+	err := r.orm.WithContext(ctx).Create(&entity).Error()
+	if err != nil {
+		return fmt.Errorf("creating command template set in database: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SimpleCommandTemplateSetRepository) Update(ctx context.Context, set domain.CommandTemplateSet) error {
+	set.Version++
+	set.UpdatedAt = utils.Time{Time: time.Now()}
+
+	entity := internal.FromCommandTemplateSet(set)
+
+	err := r.orm.WithContext(ctx).Save(&entity).Error()
+	if err != nil {
+		return fmt.Errorf("updating command template set in database: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SimpleCommandTemplateSetRepository) GetByID(ctx context.Context, id domain.ID) (domain.CommandTemplateSet, error) {
+	var entity internal.CommandTemplateSet
+	err := r.orm.
+		WithContext(ctx).
+		Where("id = ? AND deleted_at IS NULL", id.String()).
+		First(&entity).
+		Error()
+
+	if errors.Is(err, sql.ErrRecordNotFound) {
+		return domain.CommandTemplateSet{}, usecases.ErrCommandTemplateSetNotFound
+	}
+
+	if err != nil {
+		return domain.CommandTemplateSet{}, fmt.Errorf("database query: %w", err)
+	}
+
+	return entity.ToDomain(), nil
+}
+
+func (r *SimpleCommandTemplateSetRepository) FindAllByTenant(ctx context.Context, tenantID domain.ID, pagination usecases.Pagination) ([]domain.CommandTemplateSet, int, error) {
+	var total int64
+	err := r.orm.
+		WithContext(ctx).
+		Model(&internal.CommandTemplateSet{}).
+		Where("tenant_id = ? AND deleted_at IS NULL", tenantID.String()).
+		Count(&total).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("counting command template sets: %w", err)
+	}
+
+	var entities []internal.CommandTemplateSet
+	err = r.orm.
+		WithContext(ctx).
+		Where("tenant_id = ? AND deleted_at IS NULL", tenantID.String()).
+		Order("name ASC").
+		Limit(pagination.Limit).
+		Offset(pagination.Offset).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("database query: %w", err)
+	}
+
+	result := make([]domain.CommandTemplateSet, len(entities))
+	for i, entity := range entities {
+		result[i] = entity.ToDomain()
+	}
+
+	return result, int(total), nil
+}
+
+func (r *SimpleCommandTemplateSetRepository) Delete(ctx context.Context, id domain.ID) error {
+	set, err := r.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("getting command template set for deletion: %w", err)
+	}
+
+	set.SoftDelete()
+	set.Version++
+
+	entity := internal.FromCommandTemplateSet(set)
+
+	err = r.orm.WithContext(ctx).Save(&entity).Error()
+	if err != nil {
+		return fmt.Errorf("deleting command template set in database: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package persistence_test
+
+import (
+	"context"
+	"time"
+	"zensor-server/internal/control_plane/persistence"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("CommandTemplateSetRepository", func() {
+	var (
+		repo     usecases.CommandTemplateSetRepository
+		ctx      context.Context
+		tenantID domain.ID
+		set      domain.CommandTemplateSet
+	)
+
+	ginkgo.BeforeEach(func() {
+		orm, err := sql.NewMemoryORM("migrations")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		repo, err = persistence.NewCommandTemplateSetRepository(orm)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		ctx = context.Background()
+		tenantID = domain.ID(utils.GenerateUUID())
+		set, err = domain.NewCommandTemplateSetBuilder().
+			WithTenant(domain.Tenant{ID: tenantID}).
+			WithName("Zone A morning cycle").
+			WithCommandTemplates([]domain.CommandTemplate{
+				{
+					Port:     domain.Port(15),
+					Priority: domain.CommandPriority("NORMAL"),
+					Payload:  domain.CommandPayload{Index: 2, Value: 1},
+					WaitFor:  5 * time.Minute,
+				},
+			}).
+			Build()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		gomega.Expect(repo.Create(ctx, set)).To(gomega.Succeed())
+	})
+
+	ginkgo.Context("GetByID", func() {
+		ginkgo.When("the set exists", func() {
+			ginkgo.It("should return it with its templates", func() {
+				result, err := repo.GetByID(ctx, set.ID)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(result.Name).To(gomega.Equal("Zone A morning cycle"))
+				gomega.Expect(result.Tenant.ID).To(gomega.Equal(tenantID))
+				gomega.Expect(result.CommandTemplates).To(gomega.HaveLen(1))
+				gomega.Expect(result.CommandTemplates[0].WaitFor).To(gomega.Equal(5 * time.Minute))
+				gomega.Expect(result.CommandTemplates[0].Payload.Index).To(gomega.Equal(domain.Index(2)))
+			})
+		})
+
+		ginkgo.When("the set was deleted", func() {
+			ginkgo.It("should return ErrCommandTemplateSetNotFound", func() {
+				gomega.Expect(repo.Delete(ctx, set.ID)).To(gomega.Succeed())
+
+				_, err := repo.GetByID(ctx, set.ID)
+				gomega.Expect(err).To(gomega.MatchError(usecases.ErrCommandTemplateSetNotFound))
+			})
+		})
+	})
+
+	ginkgo.Context("Update", func() {
+		ginkgo.It("should persist the new name and bump the version", func() {
+			set.Name = "Zone A evening cycle"
+			gomega.Expect(repo.Update(ctx, set)).To(gomega.Succeed())
+
+			result, err := repo.GetByID(ctx, set.ID)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(result.Name).To(gomega.Equal("Zone A evening cycle"))
+			gomega.Expect(result.Version).To(gomega.Equal(domain.Version(2)))
+		})
+	})
+
+	ginkgo.Context("FindAllByTenant", func() {
+		ginkgo.It("should return only the sets of the tenant", func() {
+			other, err := domain.NewCommandTemplateSetBuilder().
+				WithTenant(domain.Tenant{ID: domain.ID(utils.GenerateUUID())}).
+				WithName("Other").
+				WithCommandTemplates(set.CommandTemplates).
+				Build()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(repo.Create(ctx, other)).To(gomega.Succeed())
+
+			result, total, err := repo.FindAllByTenant(ctx, tenantID, usecases.Pagination{Limit: 10})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(total).To(gomega.Equal(1))
+			gomega.Expect(result[0].ID).To(gomega.Equal(set.ID))
+		})
+	})
+})
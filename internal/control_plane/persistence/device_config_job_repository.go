@@ -0,0 +1,155 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"zensor-server/internal/control_plane/persistence/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+var _activeDeviceConfigJobStatuses = []string{
+	string(domain.DeviceConfigJobStatusPending),
+	string(domain.DeviceConfigJobStatusRunning),
+}
+
+func NewDeviceConfigJobRepository(orm sql.ORM) (*SimpleDeviceConfigJobRepository, error) {
+	err := orm.AutoMigrate(&internal.DeviceConfigJob{})
+	if err != nil {
+		return nil, fmt.Errorf("auto migrating: %w", err)
+	}
+
+	return &SimpleDeviceConfigJobRepository{
+		orm: orm,
+	}, nil
+}
+
+var _ usecases.DeviceConfigJobRepository = (*SimpleDeviceConfigJobRepository)(nil)
+
+type SimpleDeviceConfigJobRepository struct {
+	orm sql.ORM
+}
+
+func (r *SimpleDeviceConfigJobRepository) Create(ctx context.Context, job domain.DeviceConfigJob) error {
+	entity := internal.FromDeviceConfigJob(job)
+
+	err := r.orm.WithContext(ctx).Create(&entity).Error()
+	if err != nil {
+		return fmt.Errorf("creating device config job in database: %w", err)
+	}
+
+	return nil
+}
+
+// Update writes the job over the version it was read at and bumps that version.
+func (r *SimpleDeviceConfigJobRepository) Update(ctx context.Context, job domain.DeviceConfigJob) error {
+	entity := internal.FromDeviceConfigJob(job)
+
+	result := r.orm.
+		WithContext(ctx).
+		Model(&internal.DeviceConfigJob{}).
+		Where("id = ? AND version = ?", entity.ID, entity.Version).
+		Updates(map[string]any{
+			"version":      entity.Version + 1,
+			"fragments":    entity.Fragments,
+			"status":       entity.Status,
+			"last_error":   entity.LastError,
+			"updated_at":   entity.UpdatedAt,
+			"completed_at": entity.CompletedAt,
+		})
+	if err := result.Error(); err != nil {
+		return fmt.Errorf("updating device config job in database: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return usecases.ErrDeviceConfigJobVersionConflict
+	}
+
+	return nil
+}
+
+func (r *SimpleDeviceConfigJobRepository) Get(ctx context.Context, id domain.ID) (domain.DeviceConfigJob, error) {
+	var entity internal.DeviceConfigJob
+	err := r.orm.
+		WithContext(ctx).
+		Where("id = ?", id.String()).
+		First(&entity).
+		Error()
+	if errors.Is(err, sql.ErrRecordNotFound) {
+		return domain.DeviceConfigJob{}, usecases.ErrDeviceConfigJobNotFound
+	}
+	if err != nil {
+		return domain.DeviceConfigJob{}, fmt.Errorf("database query: %w", err)
+	}
+
+	return entity.ToDomain(), nil
+}
+
+func (r *SimpleDeviceConfigJobRepository) FindAllByDevice(ctx context.Context, deviceID domain.ID, pagination usecases.Pagination) ([]domain.DeviceConfigJob, int, error) {
+	var total int64
+	err := r.orm.
+		WithContext(ctx).
+		Model(&internal.DeviceConfigJob{}).
+		Where("device_id = ?", deviceID.String()).
+		Count(&total).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("count query: %w", err)
+	}
+
+	var entities []internal.DeviceConfigJob
+	err = r.orm.
+		WithContext(ctx).
+		Where("device_id = ?", deviceID.String()).
+		Order("created_at DESC").
+		Limit(pagination.Limit).
+		Offset(pagination.Offset).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("database query: %w", err)
+	}
+
+	return toDeviceConfigJobs(entities), int(total), nil
+}
+
+func (r *SimpleDeviceConfigJobRepository) FindActive(ctx context.Context, limit int) ([]domain.DeviceConfigJob, error) {
+	var entities []internal.DeviceConfigJob
+	err := r.orm.
+		WithContext(ctx).
+		Where("status IN ?", _activeDeviceConfigJobStatuses).
+		Order("created_at").
+		Limit(limit).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, fmt.Errorf("database query: %w", err)
+	}
+
+	return toDeviceConfigJobs(entities), nil
+}
+
+func (r *SimpleDeviceConfigJobRepository) FindActiveByDevice(ctx context.Context, deviceID domain.ID) ([]domain.DeviceConfigJob, error) {
+	var entities []internal.DeviceConfigJob
+	err := r.orm.
+		WithContext(ctx).
+		Where("device_id = ? AND status IN ?", deviceID.String(), _activeDeviceConfigJobStatuses).
+		Order("created_at").
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, fmt.Errorf("database query: %w", err)
+	}
+
+	return toDeviceConfigJobs(entities), nil
+}
+
+func toDeviceConfigJobs(entities []internal.DeviceConfigJob) []domain.DeviceConfigJob {
+	result := make([]domain.DeviceConfigJob, len(entities))
+	for i, entity := range entities {
+		result[i] = entity.ToDomain()
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package persistence_test
+
+import (
+	"bytes"
+	"context"
+	"time"
+	"zensor-server/internal/control_plane/persistence"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("DeviceConfigJobRepository", func() {
+	var (
+		repo     usecases.DeviceConfigJobRepository
+		ctx      context.Context
+		deviceID domain.ID
+		job      domain.DeviceConfigJob
+	)
+
+	ginkgo.BeforeEach(func() {
+		orm, err := sql.NewMemoryORM("migrations")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		repo, err = persistence.NewDeviceConfigJobRepository(orm)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		ctx = context.Background()
+		deviceID = domain.ID(utils.GenerateUUID())
+		job, err = domain.NewDeviceConfigJob(deviceID, bytes.Repeat([]byte{0x07}, 30), 16, time.Now())
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(repo.Create(ctx, job)).To(gomega.Succeed())
+	})
+
+	ginkgo.Context("Get", func() {
+		ginkgo.It("should return the job with its fragments", func() {
+			result, err := repo.Get(ctx, job.ID)
+
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(result.DeviceID).To(gomega.Equal(deviceID))
+			gomega.Expect(result.Checksum).To(gomega.Equal(job.Checksum))
+			gomega.Expect(result.Fragments).To(gomega.HaveLen(len(job.Fragments)))
+			gomega.Expect(result.Fragments[1].Payload).To(gomega.Equal(job.Fragments[1].Payload))
+		})
+
+		ginkgo.It("should return ErrDeviceConfigJobNotFound for unknown jobs", func() {
+			_, err := repo.Get(ctx, domain.ID(utils.GenerateUUID()))
+			gomega.Expect(err).To(gomega.MatchError(usecases.ErrDeviceConfigJobNotFound))
+		})
+	})
+
+	ginkgo.Context("Update", func() {
+		ginkgo.It("should store the progress and bump the version", func() {
+			gomega.Expect(job.RecordFragmentSent(0, domain.ID("command-1"), time.Now())).To(gomega.Succeed())
+			gomega.Expect(repo.Update(ctx, job)).To(gomega.Succeed())
+
+			result, err := repo.Get(ctx, job.ID)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(result.Version).To(gomega.Equal(job.Version + 1))
+			gomega.Expect(result.Status).To(gomega.Equal(domain.DeviceConfigJobStatusRunning))
+			gomega.Expect(*result.Fragments[0].CommandID).To(gomega.Equal(domain.ID("command-1")))
+			gomega.Expect(result.Fragments[0].Attempts).To(gomega.Equal(1))
+		})
+
+		ginkgo.It("should reject writes over a stale version", func() {
+			gomega.Expect(repo.Update(ctx, job)).To(gomega.Succeed())
+
+			gomega.Expect(job.Cancel("superseded", time.Now())).To(gomega.Succeed())
+			err := repo.Update(ctx, job)
+			gomega.Expect(err).To(gomega.MatchError(usecases.ErrDeviceConfigJobVersionConflict))
+		})
+	})
+
+	ginkgo.Context("FindActiveByDevice", func() {
+		ginkgo.It("should leave finished jobs out", func() {
+			active, err := repo.FindActiveByDevice(ctx, deviceID)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(active).To(gomega.HaveLen(1))
+
+			gomega.Expect(job.Cancel("superseded", time.Now())).To(gomega.Succeed())
+			gomega.Expect(repo.Update(ctx, job)).To(gomega.Succeed())
+
+			active, err = repo.FindActiveByDevice(ctx, deviceID)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(active).To(gomega.BeEmpty())
+
+			jobs, total, err := repo.FindAllByDevice(ctx, deviceID, usecases.Pagination{Limit: 10})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(total).To(gomega.Equal(1))
+			gomega.Expect(jobs[0].Status).To(gomega.Equal(domain.DeviceConfigJobStatusCancelled))
+		})
+	})
+})
@@ -0,0 +1,90 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"zensor-server/internal/control_plane/persistence/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+func NewDeviceConnectivityRepository(orm sql.ORM) (*SimpleDeviceConnectivityRepository, error) {
+	err := orm.AutoMigrate(&internal.DeviceConnectivityTransition{})
+	if err != nil {
+		return nil, fmt.Errorf("auto migrating: %w", err)
+	}
+
+	return &SimpleDeviceConnectivityRepository{
+		orm: orm,
+	}, nil
+}
+
+var _ usecases.DeviceConnectivityRepository = (*SimpleDeviceConnectivityRepository)(nil)
+
+type SimpleDeviceConnectivityRepository struct {
+	orm sql.ORM
+}
+
+func (r *SimpleDeviceConnectivityRepository) Create(ctx context.Context, transition domain.DeviceConnectivityTransition) error {
+	entity := internal.FromDeviceConnectivityTransition(transition)
+
+	err := r.orm.WithContext(ctx).Create(&entity).Error()
+	if err != nil {
+		return fmt.Errorf("creating device connectivity transition in database: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SimpleDeviceConnectivityRepository) FindLatestByDevice(ctx context.Context, deviceID domain.ID) (domain.DeviceConnectivityTransition, error) {
+	var entity internal.DeviceConnectivityTransition
+	err := r.orm.
+		WithContext(ctx).
+		Where("device_id = ?", deviceID.String()).
+		Order("occurred_at DESC").
+		First(&entity).
+		Error()
+	if errors.Is(err, sql.ErrRecordNotFound) {
+		return domain.DeviceConnectivityTransition{}, usecases.ErrDeviceConnectivityNotFound
+	}
+	if err != nil {
+		return domain.DeviceConnectivityTransition{}, fmt.Errorf("database query: %w", err)
+	}
+
+	return entity.ToDomain(), nil
+}
+
+func (r *SimpleDeviceConnectivityRepository) FindAllByDevice(ctx context.Context, deviceID domain.ID, pagination usecases.Pagination) ([]domain.DeviceConnectivityTransition, int, error) {
+	var total int64
+	err := r.orm.
+		WithContext(ctx).
+		Model(&internal.DeviceConnectivityTransition{}).
+		Where("device_id = ?", deviceID.String()).
+		Count(&total).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("count query: %w", err)
+	}
+
+	var entities []internal.DeviceConnectivityTransition
+	err = r.orm.
+		WithContext(ctx).
+		Where("device_id = ?", deviceID.String()).
+		Order("occurred_at DESC").
+		Limit(pagination.Limit).
+		Offset(pagination.Offset).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("database query: %w", err)
+	}
+
+	result := make([]domain.DeviceConnectivityTransition, len(entities))
+	for i, entity := range entities {
+		result[i] = entity.ToDomain()
+	}
+
+	return result, int(total), nil
+}
@@ -0,0 +1,81 @@
+package persistence_test
+
+import (
+	"context"
+	"time"
+	"zensor-server/internal/control_plane/persistence"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("DeviceConnectivityRepository", func() {
+	var (
+		repo     usecases.DeviceConnectivityRepository
+		ctx      context.Context
+		deviceID domain.ID
+		now      time.Time
+	)
+
+	ginkgo.BeforeEach(func() {
+		orm, err := sql.NewMemoryORM("migrations")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		repo, err = persistence.NewDeviceConnectivityRepository(orm)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		ctx = context.Background()
+		deviceID = domain.ID(utils.GenerateUUID())
+		now = time.Now().UTC()
+	})
+
+	createTransition := func(from, to domain.ConnectivityState, occurredAt time.Time) {
+		err := repo.Create(ctx, domain.DeviceConnectivityTransition{
+			ID:         domain.ID(utils.GenerateUUID()),
+			DeviceID:   deviceID,
+			From:       from,
+			To:         to,
+			OccurredAt: utils.Time{Time: occurredAt},
+		})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+
+	ginkgo.Context("FindLatestByDevice", func() {
+		ginkgo.When("the device has no transitions", func() {
+			ginkgo.It("should return ErrDeviceConnectivityNotFound", func() {
+				_, err := repo.FindLatestByDevice(ctx, deviceID)
+				gomega.Expect(err).To(gomega.MatchError(usecases.ErrDeviceConnectivityNotFound))
+			})
+		})
+
+		ginkgo.When("the device has several transitions", func() {
+			ginkgo.It("should return the most recent one", func() {
+				createTransition(domain.ConnectivityStateUnknown, domain.ConnectivityStateOnline, now.Add(-2*time.Hour))
+				createTransition(domain.ConnectivityStateOnline, domain.ConnectivityStateOffline, now.Add(-time.Hour))
+
+				latest, err := repo.FindLatestByDevice(ctx, deviceID)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(latest.To).To(gomega.Equal(domain.ConnectivityStateOffline))
+			})
+		})
+	})
+
+	ginkgo.Context("FindAllByDevice", func() {
+		ginkgo.It("should return the transitions newest first", func() {
+			createTransition(domain.ConnectivityStateUnknown, domain.ConnectivityStateOnline, now.Add(-2*time.Hour))
+			createTransition(domain.ConnectivityStateOnline, domain.ConnectivityStateOffline, now.Add(-time.Hour))
+			createTransition(domain.ConnectivityStateOffline, domain.ConnectivityStateOnline, now)
+
+			transitions, total, err := repo.FindAllByDevice(ctx, deviceID, usecases.Pagination{Limit: 2})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(total).To(gomega.Equal(3))
+			gomega.Expect(transitions).To(gomega.HaveLen(2))
+			gomega.Expect(transitions[0].From).To(gomega.Equal(domain.ConnectivityStateOffline))
+			gomega.Expect(transitions[1].To).To(gomega.Equal(domain.ConnectivityStateOffline))
+		})
+	})
+})
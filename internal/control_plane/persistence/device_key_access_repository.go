@@ -0,0 +1,71 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"zensor-server/internal/control_plane/persistence/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+func NewDeviceKeyAccessRepository(orm sql.ORM) (*SimpleDeviceKeyAccessRepository, error) {
+	err := orm.AutoMigrate(&internal.DeviceKeyAccess{})
+	if err != nil {
+		return nil, fmt.Errorf("auto migrating: %w", err)
+	}
+
+	return &SimpleDeviceKeyAccessRepository{
+		orm: orm,
+	}, nil
+}
+
+var _ usecases.DeviceKeyAccessRepository = (*SimpleDeviceKeyAccessRepository)(nil)
+
+type SimpleDeviceKeyAccessRepository struct {
+	orm sql.ORM
+}
+
+func (r *SimpleDeviceKeyAccessRepository) Create(ctx context.Context, access domain.DeviceKeyAccess) error {
+	entity := internal.FromDeviceKeyAccess(access)
+
+	err := r.orm.WithContext(ctx).Create(&entity).Error()
+	if err != nil {
+		return fmt.Errorf("creating device key access in database: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SimpleDeviceKeyAccessRepository) FindAllByDevice(ctx context.Context, deviceID domain.ID, pagination usecases.Pagination) ([]domain.DeviceKeyAccess, int, error) {
+	var total int64
+	err := r.orm.
+		WithContext(ctx).
+		Model(&internal.DeviceKeyAccess{}).
+		Where("device_id = ?", deviceID.String()).
+		Count(&total).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("count query: %w", err)
+	}
+
+	var entities []internal.DeviceKeyAccess
+	err = r.orm.
+		WithContext(ctx).
+		Where("device_id = ?", deviceID.String()).
+		Order("accessed_at DESC").
+		Limit(pagination.Limit).
+		Offset(pagination.Offset).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("database query: %w", err)
+	}
+
+	result := make([]domain.DeviceKeyAccess, len(entities))
+	for i, entity := range entities {
+		result[i] = entity.ToDomain()
+	}
+
+	return result, int(total), nil
+}
@@ -2,29 +2,39 @@ package persistence
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 	"zensor-server/internal/control_plane/persistence/internal"
 	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/secrets"
 	"zensor-server/internal/infra/sql"
 	"zensor-server/internal/shared_kernel/domain"
 )
 
-func NewDeviceRepository(orm sql.ORM) (*SimpleDeviceRepository, error) {
+func NewDeviceRepository(orm sql.ORM, cipher secrets.Cipher) (*SimpleDeviceRepository, error) {
 	err := orm.AutoMigrate(&internal.Device{})
 	if err != nil {
 		return nil, fmt.Errorf("auto migrating: %w", err)
 	}
 
 	return &SimpleDeviceRepository{
-		orm: orm,
+		orm:    orm,
+		cipher: cipher,
 	}, nil
 }
 
 var _ usecases.DeviceRepository = (*SimpleDeviceRepository)(nil)
 
+// SimpleDeviceRepository stores the AppKey of devices sealed with the cipher, bound to the device
+// ID, and opens it on read. AppKeys stored before sealing was introduced are read as they are
+// until ResealAppKeys seals them.
 type SimpleDeviceRepository struct {
-	orm sql.ORM
+	orm    sql.ORM
+	cipher secrets.Cipher
 }
 
 func (s *SimpleDeviceRepository) CreateDevice(ctx context.Context, device domain.Device) error {
@@ -37,7 +47,11 @@ func (s *SimpleDeviceRepository) CreateDevice(ctx context.Context, device domain
 		return usecases.ErrDeviceDuplicated
 	}
 
-	entity := internal.FromDevice(device)
+	entity, err := s.toEntity(device)
+	if err != nil {
+		return err
+	}
+
 	err = s.orm.WithContext(ctx).Create(&entity).Error()
 	if err != nil {
 		return fmt.Errorf("creating device in database: %w", err)
@@ -46,6 +60,25 @@ func (s *SimpleDeviceRepository) CreateDevice(ctx context.Context, device domain
 	return nil
 }
 
+// CreateDevices inserts the devices in a single transaction, so either all or none are created.
+func (s *SimpleDeviceRepository) CreateDevices(ctx context.Context, devices []domain.Device) error {
+	return s.orm.WithContext(ctx).Transaction(func(tx sql.ORM) error {
+		for _, device := range devices {
+			entity, err := s.toEntity(device)
+			if err != nil {
+				return err
+			}
+
+			err = tx.Create(&entity).Error()
+			if err != nil {
+				return fmt.Errorf("creating device %s in database: %w", device.Name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
 func (s *SimpleDeviceRepository) UpdateDevice(ctx context.Context, device domain.Device) error {
 	currentDevice, err := s.GetByName(ctx, device.Name)
 	if err != nil && !errors.Is(err, usecases.ErrDeviceNotFound) {
@@ -56,7 +89,11 @@ func (s *SimpleDeviceRepository) UpdateDevice(ctx context.Context, device domain
 		return usecases.ErrDeviceNotFound
 	}
 
-	entity := internal.FromDevice(device)
+	entity, err := s.toEntity(device)
+	if err != nil {
+		return err
+	}
+
 	err = s.orm.WithContext(ctx).Save(&entity).Error()
 	if err != nil {
 		return fmt.Errorf("updating device in database: %w", err)
@@ -65,6 +102,111 @@ func (s *SimpleDeviceRepository) UpdateDevice(ctx context.Context, device domain
 	return nil
 }
 
+// UpdateProvisioning writes only the provisioning state, so it neither races with full device
+// updates nor skips decommissioned devices.
+func (s *SimpleDeviceRepository) UpdateProvisioning(ctx context.Context, device domain.Device) error {
+	entity := internal.FromDevice(device)
+	result := s.orm.
+		WithContext(ctx).
+		Model(&internal.Device{}).
+		Where("id = ?", entity.ID).
+		Updates(map[string]any{
+			"provisioning_status":          entity.ProvisioningStatus,
+			"provisioning_attempts":        entity.ProvisioningAttempts,
+			"provisioning_last_error":      entity.ProvisioningLastError,
+			"provisioning_next_attempt_at": entity.ProvisioningNextAttemptAt,
+			"provisioning_updated_at":      entity.ProvisioningUpdatedAt,
+		})
+	if err := result.Error(); err != nil {
+		return fmt.Errorf("updating device provisioning: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return usecases.ErrDeviceNotFound
+	}
+
+	return nil
+}
+
+// UpdateBattery writes only the last battery reading of the device.
+func (s *SimpleDeviceRepository) UpdateBattery(ctx context.Context, device domain.Device) error {
+	entity := internal.FromDevice(device)
+	result := s.orm.
+		WithContext(ctx).
+		Model(&internal.Device{}).
+		Where("id = ?", entity.ID).
+		Updates(map[string]any{
+			"battery_voltage":     entity.BatteryVoltage,
+			"battery_reported_at": entity.BatteryReportedAt,
+		})
+	if err := result.Error(); err != nil {
+		return fmt.Errorf("updating device battery: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return usecases.ErrDeviceNotFound
+	}
+
+	return nil
+}
+
+// UpdateHealth writes only the last health evaluation of the device.
+func (s *SimpleDeviceRepository) UpdateHealth(ctx context.Context, device domain.Device) error {
+	entity := internal.FromDevice(device)
+	result := s.orm.
+		WithContext(ctx).
+		Model(&internal.Device{}).
+		Where("id = ?", entity.ID).
+		Updates(map[string]any{
+			"health_status":               entity.HealthStatus,
+			"health_score":                entity.HealthScore,
+			"health_battery_percent":      entity.HealthBatteryPercent,
+			"health_signal_quality":       entity.HealthSignalQuality,
+			"health_uplink_regularity":    entity.HealthUplinkRegularity,
+			"health_command_failure_rate": entity.HealthCommandFailureRate,
+			"health_low_battery":          entity.HealthLowBattery,
+			"health_evaluated_at":         entity.HealthEvaluatedAt,
+		})
+	if err := result.Error(); err != nil {
+		return fmt.Errorf("updating device health: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return usecases.ErrDeviceNotFound
+	}
+
+	return nil
+}
+
+// FindDueForProvisioning returns the devices, decommissioned ones included, whose network server
+// sync is pending or failed and due by now.
+func (s *SimpleDeviceRepository) FindDueForProvisioning(ctx context.Context, now time.Time, limit int) ([]domain.Device, error) {
+	statuses := []string{
+		string(domain.ProvisioningStatusPending),
+		string(domain.ProvisioningStatusFailed),
+		string(domain.ProvisioningStatusDeprovisioningFailed),
+	}
+
+	var entities []internal.Device
+	err := s.orm.
+		WithContext(ctx).
+		Where("provisioning_status IN ? AND (provisioning_next_attempt_at IS NULL OR provisioning_next_attempt_at <= ?)", statuses, now).
+		Order("provisioning_next_attempt_at").
+		Limit(limit).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, fmt.Errorf("database query: %w", err)
+	}
+
+	result, err := s.toDomains(entities)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 func (s *SimpleDeviceRepository) AddEvaluationRule(ctx context.Context, device domain.Device, rule domain.EvaluationRule) error {
 	return nil
 }
@@ -73,7 +215,7 @@ func (s *SimpleDeviceRepository) FindByName(ctx context.Context, name string) (d
 	var entity internal.Device
 	err := s.orm.
 		WithContext(ctx).
-		Where("name = ?", name).
+		Where("name = ? AND deleted_at IS NULL", name).
 		First(&entity).
 		Error()
 
@@ -85,7 +227,26 @@ func (s *SimpleDeviceRepository) FindByName(ctx context.Context, name string) (d
 		return domain.Device{}, fmt.Errorf("database query: %w", err)
 	}
 
-	return entity.ToDomain(), nil
+	return s.toDomain(entity)
+}
+
+func (s *SimpleDeviceRepository) FindByDevEUI(ctx context.Context, devEUI string) (domain.Device, error) {
+	var entity internal.Device
+	err := s.orm.
+		WithContext(ctx).
+		Where("UPPER(dev_eui) = UPPER(?) AND deleted_at IS NULL", devEUI).
+		First(&entity).
+		Error()
+
+	if errors.Is(err, sql.ErrRecordNotFound) {
+		return domain.Device{}, usecases.ErrDeviceNotFound
+	}
+
+	if err != nil {
+		return domain.Device{}, fmt.Errorf("database query: %w", err)
+	}
+
+	return s.toDomain(entity)
 }
 
 func (s *SimpleDeviceRepository) GetByName(ctx context.Context, name string) (domain.Device, error) {
@@ -96,7 +257,7 @@ func (s *SimpleDeviceRepository) Get(ctx context.Context, id string) (domain.Dev
 	var entity internal.Device
 	err := s.orm.
 		WithContext(ctx).
-		First(&entity, "id = ?", id).
+		First(&entity, "id = ? AND deleted_at IS NULL", id).
 		Error()
 
 	if errors.Is(err, sql.ErrRecordNotFound) {
@@ -107,7 +268,7 @@ func (s *SimpleDeviceRepository) Get(ctx context.Context, id string) (domain.Dev
 		return domain.Device{}, fmt.Errorf("database query: %w", err)
 	}
 
-	return entity.ToDomain(), nil
+	return s.toDomain(entity)
 }
 
 func (s *SimpleDeviceRepository) FindAll(ctx context.Context, pagination usecases.Pagination) ([]domain.Device, int, error) {
@@ -115,6 +276,7 @@ func (s *SimpleDeviceRepository) FindAll(ctx context.Context, pagination usecase
 	err := s.orm.
 		WithContext(ctx).
 		Model(&internal.Device{}).
+		Where("deleted_at IS NULL").
 		Count(&total).
 		Error()
 	if err != nil {
@@ -124,6 +286,7 @@ func (s *SimpleDeviceRepository) FindAll(ctx context.Context, pagination usecase
 	var entities []internal.Device
 	err = s.orm.
 		WithContext(ctx).
+		Where("deleted_at IS NULL").
 		Limit(pagination.Limit).
 		Offset(pagination.Offset).
 		Find(&entities).
@@ -132,9 +295,9 @@ func (s *SimpleDeviceRepository) FindAll(ctx context.Context, pagination usecase
 		return nil, 0, fmt.Errorf("database query: %w", err)
 	}
 
-	result := make([]domain.Device, len(entities))
-	for i, entity := range entities {
-		result[i] = entity.ToDomain()
+	result, err := s.toDomains(entities)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	return result, int(total), nil
@@ -145,7 +308,7 @@ func (s *SimpleDeviceRepository) FindByTenant(ctx context.Context, tenantID stri
 	err := s.orm.
 		WithContext(ctx).
 		Model(&internal.Device{}).
-		Where("tenant_id = ?", tenantID).
+		Where("tenant_id = ? AND deleted_at IS NULL", tenantID).
 		Count(&total).
 		Error()
 	if err != nil {
@@ -155,7 +318,7 @@ func (s *SimpleDeviceRepository) FindByTenant(ctx context.Context, tenantID stri
 	var entities []internal.Device
 	err = s.orm.
 		WithContext(ctx).
-		Where("tenant_id = ?", tenantID).
+		Where("tenant_id = ? AND deleted_at IS NULL", tenantID).
 		Limit(pagination.Limit).
 		Offset(pagination.Offset).
 		Find(&entities).
@@ -164,12 +327,280 @@ func (s *SimpleDeviceRepository) FindByTenant(ctx context.Context, tenantID stri
 		return nil, 0, fmt.Errorf("database query: %w", err)
 	}
 
+	result, err := s.toDomains(entities)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, int(total), nil
+}
+
+// FindDevices counts the devices matching the filter and returns one page of them. Cursor pages
+// are keyset based, so they stay consistent while devices are added or removed.
+func (s *SimpleDeviceRepository) FindDevices(ctx context.Context, query usecases.DeviceQuery) (usecases.DevicePage, error) {
+	now := time.Now()
+	column, direction := deviceSortColumn(query.Sort)
+
+	var after *deviceCursor
+	if query.Cursor != "" {
+		cursor, err := decodeDeviceCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return usecases.DevicePage{}, err
+		}
+		after = &cursor
+	}
+
+	var total int64
+	err := filterDevices(s.orm.WithContext(ctx).Model(&internal.Device{}), query.Filter, now).
+		Count(&total).
+		Error()
+	if err != nil {
+		return usecases.DevicePage{}, fmt.Errorf("count query: %w", err)
+	}
+
+	find := filterDevices(s.orm.WithContext(ctx), query.Filter, now)
+	if after != nil {
+		comparison := ">"
+		if query.Sort.Descending {
+			comparison = "<"
+		}
+		find = find.Where(
+			fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", column, comparison),
+			after.value(), after.value(), after.ID,
+		)
+	} else {
+		find = find.Offset(query.Offset)
+	}
+
+	var entities []internal.Device
+	err = find.
+		Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).
+		Limit(query.Limit + 1).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return usecases.DevicePage{}, fmt.Errorf("database query: %w", err)
+	}
+
+	page := usecases.DevicePage{Total: int(total)}
+	if len(entities) > query.Limit {
+		entities = entities[:query.Limit]
+		page.NextCursor = encodeDeviceCursor(entities[len(entities)-1], query.Sort)
+	}
+
+	page.Devices, err = s.toDomains(entities)
+	if err != nil {
+		return usecases.DevicePage{}, err
+	}
+
+	return page, nil
+}
+
+func filterDevices(orm sql.ORM, filter usecases.DeviceFilter, now time.Time) sql.ORM {
+	orm = orm.Where("deleted_at IS NULL")
+
+	if filter.TenantID != nil {
+		orm = orm.Where("tenant_id = ?", filter.TenantID.String())
+	}
+
+	switch filter.Connectivity {
+	case domain.ConnectivityStateOnline:
+		orm = orm.Where("online_until > ?", now)
+	case domain.ConnectivityStateOffline:
+		orm = orm.Where("(online_until IS NULL OR online_until <= ?)", now)
+	}
+
+	for _, tag := range filter.Tags {
+		orm = orm.Where(`tags LIKE ? ESCAPE '\'`, tagPattern(tag))
+	}
+
+	if filter.Profile != "" {
+		orm = orm.Where("profile = ?", filter.Profile)
+	}
+
+	if filter.NamePrefix != "" {
+		orm = orm.Where(`name LIKE ? ESCAPE '\'`, escapeLike(filter.NamePrefix)+"%")
+	}
+
+	if filter.LastSeenAfter != nil {
+		orm = orm.Where("last_message_received_at >= ?", *filter.LastSeenAfter)
+	}
+
+	if filter.LastSeenBefore != nil {
+		orm = orm.Where("last_message_received_at < ?", *filter.LastSeenBefore)
+	}
+
+	return orm
+}
+
+// tagPattern matches the "key":"value" pair, or the "key": prefix, in the JSON tags column. The
+// key and value are encoded the way the column is, so quotes inside them cannot fake a match.
+func tagPattern(tag usecases.DeviceTagSelector) string {
+	key, _ := json.Marshal(tag.Key)
+	pattern := "%" + escapeLike(string(key)) + ":"
+	if tag.HasValue {
+		value, _ := json.Marshal(tag.Value)
+		pattern += escapeLike(string(value))
+	}
+	return pattern + "%"
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+func deviceSortColumn(sort usecases.DeviceSort) (string, string) {
+	column := "name"
+	switch sort.Field {
+	case usecases.DeviceSortByDisplayName:
+		column = "display_name"
+	case usecases.DeviceSortByLastSeen:
+		column = "last_message_received_at"
+	}
+
+	if sort.Descending {
+		return column, "DESC"
+	}
+	return column, "ASC"
+}
+
+// deviceCursor is the sort value and ID of the last device of a page. It records the sort it was
+// issued for, so it cannot be replayed against a different order.
+type deviceCursor struct {
+	Sort     string     `json:"s"`
+	Text     string     `json:"t,omitempty"`
+	LastSeen *time.Time `json:"l,omitempty"`
+	ID       string     `json:"i"`
+}
+
+func (c deviceCursor) value() any {
+	if c.LastSeen != nil {
+		return *c.LastSeen
+	}
+	return c.Text
+}
+
+func deviceCursorSort(sort usecases.DeviceSort) string {
+	column, direction := deviceSortColumn(sort)
+	return column + " " + direction
+}
+
+func encodeDeviceCursor(entity internal.Device, sort usecases.DeviceSort) string {
+	cursor := deviceCursor{Sort: deviceCursorSort(sort), ID: entity.ID}
+	switch sort.Field {
+	case usecases.DeviceSortByDisplayName:
+		cursor.Text = entity.DisplayName
+	case usecases.DeviceSortByLastSeen:
+		lastSeen := entity.LastMessageReceivedAt.Time
+		cursor.LastSeen = &lastSeen
+	default:
+		cursor.Text = entity.Name
+	}
+
+	payload, _ := json.Marshal(cursor) // Strings and a time always marshal
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+func decodeDeviceCursor(value string, sort usecases.DeviceSort) (deviceCursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return deviceCursor{}, usecases.ErrInvalidDeviceCursor
+	}
+
+	var cursor deviceCursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return deviceCursor{}, usecases.ErrInvalidDeviceCursor
+	}
+
+	lastSeenSort := sort.Field == usecases.DeviceSortByLastSeen
+	if cursor.Sort != deviceCursorSort(sort) || cursor.ID == "" || (cursor.LastSeen != nil) != lastSeenSort {
+		return deviceCursor{}, usecases.ErrInvalidDeviceCursor
+	}
+
+	return cursor, nil
+}
+
+// ResealAppKeys seals the AppKeys still stored in plaintext and rewraps the ones sealed with a
+// retired key, decommissioned devices included. It handles up to limit devices and returns how
+// many it resealed.
+func (s *SimpleDeviceRepository) ResealAppKeys(ctx context.Context, limit int) (int, error) {
+	var entities []internal.Device
+	err := s.orm.
+		WithContext(ctx).
+		Where(`app_key <> '' AND app_key NOT LIKE ? ESCAPE '\'`, escapeLike(secrets.SealedPrefix(s.cipher.PrimaryKeyID()))+"%").
+		Order("id").
+		Limit(limit).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return 0, fmt.Errorf("database query: %w", err)
+	}
+
+	for i, entity := range entities {
+		sealed, err := s.reseal(entity)
+		if err != nil {
+			return i, fmt.Errorf("resealing app key of device %s: %w", entity.ID, err)
+		}
+
+		err = s.orm.
+			WithContext(ctx).
+			Model(&internal.Device{}).
+			Where("id = ? AND app_key = ?", entity.ID, entity.AppKey).
+			Updates(map[string]any{"app_key": sealed}).
+			Error()
+		if err != nil {
+			return i, fmt.Errorf("updating app key of device %s: %w", entity.ID, err)
+		}
+	}
+
+	return len(entities), nil
+}
+
+func (s *SimpleDeviceRepository) reseal(entity internal.Device) (string, error) {
+	if secrets.IsSealed(entity.AppKey) {
+		return s.cipher.Rewrap(entity.AppKey)
+	}
+	return s.cipher.Seal([]byte(entity.AppKey), []byte(entity.ID))
+}
+
+func (s *SimpleDeviceRepository) toEntity(device domain.Device) (internal.Device, error) {
+	entity := internal.FromDevice(device)
+	if entity.AppKey == "" {
+		return entity, nil
+	}
+
+	sealed, err := s.cipher.Seal([]byte(entity.AppKey), []byte(entity.ID))
+	if err != nil {
+		return internal.Device{}, fmt.Errorf("sealing app key: %w", err)
+	}
+	entity.AppKey = sealed
+
+	return entity, nil
+}
+
+func (s *SimpleDeviceRepository) toDomain(entity internal.Device) (domain.Device, error) {
+	if secrets.IsSealed(entity.AppKey) {
+		appKey, err := s.cipher.Open(entity.AppKey, []byte(entity.ID))
+		if err != nil {
+			return domain.Device{}, fmt.Errorf("opening app key of device %s: %w", entity.ID, err)
+		}
+		entity.AppKey = string(appKey)
+	}
+
+	return entity.ToDomain(), nil
+}
+
+func (s *SimpleDeviceRepository) toDomains(entities []internal.Device) ([]domain.Device, error) {
 	result := make([]domain.Device, len(entities))
 	for i, entity := range entities {
-		result[i] = entity.ToDomain()
+		device, err := s.toDomain(entity)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = device
 	}
 
-	return result, int(total), nil
+	return result, nil
 }
 
 func (s *SimpleDeviceRepository) FindAllEvaluationRules(ctx context.Context, device domain.Device) ([]domain.EvaluationRule, error) {
@@ -1,9 +1,14 @@
 package persistence_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"time"
 	"zensor-server/internal/control_plane/persistence"
+	"zensor-server/internal/control_plane/persistence/internal"
 	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/secrets"
 	"zensor-server/internal/infra/sql"
 	"zensor-server/internal/infra/utils"
 	"zensor-server/internal/shared_kernel/domain"
@@ -12,6 +17,19 @@ import (
 	"github.com/onsi/gomega"
 )
 
+// Every keyring used in these tests knows both keys, so devices sealed by one spec stay readable by
+// the others sharing the in-memory database.
+var deviceTestKeys = []secrets.Key{
+	{ID: "current", Material: bytes.Repeat([]byte{1}, 32)},
+	{ID: "next", Material: bytes.Repeat([]byte{2}, 32)},
+}
+
+func newDeviceTestKeyring(primaryID string) secrets.Cipher {
+	ring, err := secrets.NewKeyring(primaryID, deviceTestKeys)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	return ring
+}
+
 var _ = ginkgo.Describe("DeviceRepository", func() {
 	var (
 		orm  sql.ORM
@@ -24,7 +42,7 @@ var _ = ginkgo.Describe("DeviceRepository", func() {
 		orm, err = sql.NewMemoryORM("migrations")
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 
-		repo, err = persistence.NewDeviceRepository(orm)
+		repo, err = persistence.NewDeviceRepository(orm, newDeviceTestKeyring("current"))
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		gomega.Expect(repo).NotTo(gomega.BeNil())
 
@@ -66,6 +84,130 @@ var _ = ginkgo.Describe("DeviceRepository", func() {
 		})
 	})
 
+	ginkgo.Context("app keys", func() {
+		const appKey = "000102030405060708090A0B0C0D0E0F"
+
+		var device domain.Device
+
+		storedAppKey := func(id domain.ID) string {
+			var entity internal.Device
+			gomega.Expect(orm.WithContext(ctx).Where("id = ?", id.String()).First(&entity).Error()).To(gomega.Succeed())
+			return entity.AppKey
+		}
+
+		ginkgo.BeforeEach(func() {
+			id := utils.GenerateUUID()
+			device = domain.Device{ID: domain.ID(id), Name: "keyed-" + id, AppKey: appKey}
+		})
+
+		ginkgo.It("should store them sealed and read them back in plaintext", func() {
+			gomega.Expect(repo.CreateDevice(ctx, device)).To(gomega.Succeed())
+
+			gomega.Expect(storedAppKey(device.ID)).To(gomega.HavePrefix(secrets.SealedPrefix("current")))
+			gomega.Expect(storedAppKey(device.ID)).NotTo(gomega.ContainSubstring(appKey))
+
+			result, err := repo.Get(ctx, device.ID.String())
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(result.AppKey).To(gomega.Equal(appKey))
+		})
+
+		ginkgo.It("should seal the ones stored in plaintext", func() {
+			entity := internal.FromDevice(device)
+			gomega.Expect(orm.WithContext(ctx).Create(&entity).Error()).To(gomega.Succeed())
+
+			result, err := repo.Get(ctx, device.ID.String())
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(result.AppKey).To(gomega.Equal(appKey))
+
+			for {
+				count, err := repo.ResealAppKeys(ctx, 100)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				if count < 100 {
+					break
+				}
+			}
+
+			gomega.Expect(storedAppKey(device.ID)).To(gomega.HavePrefix(secrets.SealedPrefix("current")))
+			result, err = repo.Get(ctx, device.ID.String())
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(result.AppKey).To(gomega.Equal(appKey))
+		})
+
+		ginkgo.It("should rewrap them when the primary key is rotated", func() {
+			gomega.Expect(repo.CreateDevice(ctx, device)).To(gomega.Succeed())
+
+			rotated, err := persistence.NewDeviceRepository(orm, newDeviceTestKeyring("next"))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			for {
+				count, err := rotated.ResealAppKeys(ctx, 100)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				if count < 100 {
+					break
+				}
+			}
+
+			gomega.Expect(storedAppKey(device.ID)).To(gomega.HavePrefix(secrets.SealedPrefix("next")))
+			result, err := rotated.Get(ctx, device.ID.String())
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(result.AppKey).To(gomega.Equal(appKey))
+		})
+
+		ginkgo.It("should not open a sealed key copied to another device", func() {
+			gomega.Expect(repo.CreateDevice(ctx, device)).To(gomega.Succeed())
+
+			id := utils.GenerateUUID()
+			other := domain.Device{ID: domain.ID(id), Name: "other-" + id}
+			gomega.Expect(repo.CreateDevice(ctx, other)).To(gomega.Succeed())
+			gomega.Expect(orm.WithContext(ctx).Model(&internal.Device{}).Where("id = ?", id).
+				Updates(map[string]any{"app_key": storedAppKey(device.ID)}).Error()).To(gomega.Succeed())
+
+			_, err := repo.Get(ctx, id)
+			gomega.Expect(err).To(gomega.MatchError(secrets.ErrSecretCorrupted))
+
+			// Clear the copied key so listings in other specs are not affected by it.
+			gomega.Expect(orm.WithContext(ctx).Model(&internal.Device{}).Where("id = ?", id).
+				Updates(map[string]any{"app_key": ""}).Error()).To(gomega.Succeed())
+		})
+	})
+
+	ginkgo.Context("CreateDevices", func() {
+		var devices []domain.Device
+
+		ginkgo.BeforeEach(func() {
+			id := utils.GenerateUUID()
+			devices = []domain.Device{
+				{ID: domain.ID(utils.GenerateUUID()), Name: "first-" + id, DevEUI: "0004a30b001c0530", Profile: "rak-3172", Sector: &domain.Sector{Name: "B"}},
+				{ID: domain.ID(utils.GenerateUUID()), Name: "second-" + id},
+			}
+		})
+
+		ginkgo.It("should create every device", func() {
+			gomega.Expect(repo.CreateDevices(ctx, devices)).To(gomega.Succeed())
+
+			result, err := repo.FindByDevEUI(ctx, "0004A30B001C0530")
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(result.ID).To(gomega.Equal(devices[0].ID))
+			gomega.Expect(result.Profile).To(gomega.Equal("rak-3172"))
+			gomega.Expect(result.Sector.Name).To(gomega.Equal(domain.Name("B")))
+
+			_, err = repo.FindByName(ctx, devices[1].Name)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.When("one of the devices fails to be created", func() {
+			ginkgo.BeforeEach(func() {
+				gomega.Expect(repo.CreateDevice(ctx, devices[1])).To(gomega.Succeed())
+			})
+
+			ginkgo.It("should create none of them", func() {
+				gomega.Expect(repo.CreateDevices(ctx, devices)).NotTo(gomega.Succeed())
+
+				_, err := repo.FindByName(ctx, devices[0].Name)
+				gomega.Expect(err).To(gomega.MatchError(usecases.ErrDeviceNotFound))
+			})
+		})
+	})
+
 	ginkgo.Context("UpdateDevice", func() {
 		var device domain.Device
 
@@ -100,4 +242,282 @@ var _ = ginkgo.Describe("DeviceRepository", func() {
 			})
 		})
 	})
+
+	ginkgo.Context("provisioning", func() {
+		var (
+			pending        domain.Device
+			decommissioned domain.Device
+			now            time.Time
+		)
+
+		ginkgo.BeforeEach(func() {
+			now = time.Now()
+			pending = domain.Device{ID: domain.ID(utils.GenerateUUID()), Name: "pending-" + utils.GenerateUUID()}
+			pending.RequestProvisioning(now.Add(-time.Minute))
+			gomega.Expect(repo.CreateDevice(ctx, pending)).To(gomega.Succeed())
+
+			decommissioned = domain.Device{ID: domain.ID(utils.GenerateUUID()), Name: "gone-" + utils.GenerateUUID()}
+			decommissioned.RecordProvisioningSuccess(now)
+			gomega.Expect(repo.CreateDevice(ctx, decommissioned)).To(gomega.Succeed())
+			decommissioned.Decommission()
+			decommissioned.RecordProvisioningFailure(errors.New("registry unavailable"), now.Add(-time.Hour))
+			gomega.Expect(repo.UpdateDevice(ctx, decommissioned)).To(gomega.Succeed())
+		})
+
+		ginkgo.It("should find due devices, decommissioned ones included", func() {
+			devices, err := repo.FindDueForProvisioning(ctx, now, 100)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			ids := make([]domain.ID, len(devices))
+			for i, device := range devices {
+				ids[i] = device.ID
+			}
+			gomega.Expect(ids).To(gomega.ContainElements(pending.ID, decommissioned.ID))
+		})
+
+		ginkgo.It("should update the provisioning of a decommissioned device", func() {
+			decommissioned.RecordProvisioningSuccess(now)
+			gomega.Expect(repo.UpdateProvisioning(ctx, decommissioned)).To(gomega.Succeed())
+
+			devices, err := repo.FindDueForProvisioning(ctx, now, 100)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			for _, device := range devices {
+				gomega.Expect(device.ID).NotTo(gomega.Equal(decommissioned.ID))
+			}
+		})
+
+		ginkgo.It("should not find devices whose retry is not due yet", func() {
+			pending.RecordProvisioningFailure(errors.New("registry unavailable"), now)
+			gomega.Expect(repo.UpdateProvisioning(ctx, pending)).To(gomega.Succeed())
+
+			result, err := repo.Get(ctx, pending.ID.String())
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(result.Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusFailed))
+			gomega.Expect(result.Provisioning.Attempts).To(gomega.Equal(1))
+
+			devices, err := repo.FindDueForProvisioning(ctx, now, 100)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			for _, device := range devices {
+				gomega.Expect(device.ID).NotTo(gomega.Equal(pending.ID))
+			}
+		})
+	})
+
+	ginkgo.Context("decommissioned devices", func() {
+		var device domain.Device
+
+		ginkgo.BeforeEach(func() {
+			id := utils.GenerateUUID()
+			device = domain.Device{
+				ID:   domain.ID(id),
+				Name: "device-" + id,
+			}
+			gomega.Expect(repo.CreateDevice(ctx, device)).To(gomega.Succeed())
+
+			device.Decommission()
+			gomega.Expect(repo.UpdateDevice(ctx, device)).To(gomega.Succeed())
+		})
+
+		ginkgo.It("should no longer be found by ID or name", func() {
+			_, err := repo.Get(ctx, device.ID.String())
+			gomega.Expect(err).To(gomega.MatchError(usecases.ErrDeviceNotFound))
+
+			_, err = repo.FindByName(ctx, device.Name)
+			gomega.Expect(err).To(gomega.MatchError(usecases.ErrDeviceNotFound))
+		})
+
+		ginkgo.It("should be left out of the device listing", func() {
+			devices, _, err := repo.FindAll(ctx, usecases.Pagination{Limit: 100})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			for _, listed := range devices {
+				gomega.Expect(listed.ID).NotTo(gomega.Equal(device.ID))
+			}
+		})
+	})
+
+	ginkgo.Context("FindDevices", func() {
+		var (
+			tenantID domain.ID
+			valveB   domain.Device
+			valveA   domain.Device
+			sensorB  domain.Device
+			now      time.Time
+		)
+
+		newDevice := func(name string, tags map[string]string, lastSeen time.Time) domain.Device {
+			device, err := domain.NewDeviceBuilder().
+				WithName(name + "-" + utils.GenerateUUID()).
+				WithDisplayName(name).
+				WithTenant(tenantID).
+				WithProfile("rak").
+				WithTags(tags).
+				Build()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			device.LastMessageReceivedAt = utils.Time{Time: lastSeen}
+			gomega.Expect(repo.CreateDevice(ctx, device)).To(gomega.Succeed())
+			return device
+		}
+
+		query := func() usecases.DeviceQuery {
+			return usecases.DeviceQuery{
+				Filter: usecases.DeviceFilter{TenantID: &tenantID},
+				Limit:  10,
+			}
+		}
+
+		displayNames := func(page usecases.DevicePage) []string {
+			names := make([]string, len(page.Devices))
+			for i, device := range page.Devices {
+				names[i] = device.DisplayName
+			}
+			return names
+		}
+
+		ginkgo.BeforeEach(func() {
+			tenantID = domain.ID(utils.GenerateUUID())
+			now = time.Now()
+			valveB = newDevice("valve-b", map[string]string{"kind": "valve", "zone": "b"}, now.Add(-time.Hour))
+			valveA = newDevice("valve-a", map[string]string{"kind": "valve", "zone": "a"}, now.Add(-time.Minute))
+			sensorB = newDevice("sensor-b", map[string]string{"kind": "sensor", "zone": "b"}, now.Add(-2*time.Hour))
+		})
+
+		ginkgo.It("should find the offline devices carrying a tag", func() {
+			q := query()
+			q.Filter.Connectivity = domain.ConnectivityStateOffline
+			q.Filter.Tags = []usecases.DeviceTagSelector{
+				{Key: "kind", Value: "valve", HasValue: true},
+				{Key: "zone", Value: "b", HasValue: true},
+			}
+
+			page, err := repo.FindDevices(ctx, q)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(page.Total).To(gomega.Equal(1))
+			gomega.Expect(page.Devices[0].ID).To(gomega.Equal(valveB.ID))
+			gomega.Expect(page.Devices[0].Tags).To(gomega.Equal(valveB.Tags))
+		})
+
+		ginkgo.It("should find the online devices", func() {
+			q := query()
+			q.Filter.Connectivity = domain.ConnectivityStateOnline
+
+			page, err := repo.FindDevices(ctx, q)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(displayNames(page)).To(gomega.Equal([]string{"valve-a"}))
+		})
+
+		ginkgo.It("should match a tag key with any value", func() {
+			q := query()
+			q.Filter.Tags = []usecases.DeviceTagSelector{{Key: "zone"}}
+
+			page, err := repo.FindDevices(ctx, q)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(page.Total).To(gomega.Equal(3))
+		})
+
+		ginkgo.It("should filter on the name prefix and the last seen range", func() {
+			q := query()
+			q.Filter.NamePrefix = "valve"
+			after := now.Add(-90 * time.Minute)
+			before := now.Add(-30 * time.Minute)
+			q.Filter.LastSeenAfter = &after
+			q.Filter.LastSeenBefore = &before
+
+			page, err := repo.FindDevices(ctx, q)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(displayNames(page)).To(gomega.Equal([]string{"valve-b"}))
+		})
+
+		ginkgo.It("should page through the devices with a cursor", func() {
+			q := query()
+			q.Sort = usecases.DeviceSort{Field: usecases.DeviceSortByLastSeen, Descending: true}
+			q.Limit = 2
+
+			first, err := repo.FindDevices(ctx, q)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(first.Total).To(gomega.Equal(3))
+			gomega.Expect(displayNames(first)).To(gomega.Equal([]string{"valve-a", "valve-b"}))
+			gomega.Expect(first.NextCursor).NotTo(gomega.BeEmpty())
+
+			q.Cursor = first.NextCursor
+			second, err := repo.FindDevices(ctx, q)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(displayNames(second)).To(gomega.Equal([]string{"sensor-b"}))
+			gomega.Expect(second.NextCursor).To(gomega.BeEmpty())
+		})
+
+		ginkgo.It("should sort by display name", func() {
+			q := query()
+			q.Sort = usecases.DeviceSort{Field: usecases.DeviceSortByDisplayName}
+
+			page, err := repo.FindDevices(ctx, q)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(displayNames(page)).To(gomega.Equal([]string{"sensor-b", "valve-a", "valve-b"}))
+		})
+
+		ginkgo.It("should reject a cursor issued for another sort", func() {
+			q := query()
+			q.Limit = 1
+			page, err := repo.FindDevices(ctx, q)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			q.Cursor = page.NextCursor
+			q.Sort = usecases.DeviceSort{Field: usecases.DeviceSortByLastSeen}
+			_, err = repo.FindDevices(ctx, q)
+			gomega.Expect(err).To(gomega.MatchError(usecases.ErrInvalidDeviceCursor))
+
+			q.Cursor = "not-a-cursor"
+			_, err = repo.FindDevices(ctx, q)
+			gomega.Expect(err).To(gomega.MatchError(usecases.ErrInvalidDeviceCursor))
+		})
+
+		ginkgo.It("should keep the metadata", func() {
+			gomega.Expect(valveA.UpdateMetadata(map[string]any{"install": "2026-01-01"})).To(gomega.Succeed())
+			gomega.Expect(repo.UpdateDevice(ctx, valveA)).To(gomega.Succeed())
+
+			result, err := repo.Get(ctx, valveA.ID.String())
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(result.Metadata).To(gomega.Equal(map[string]any{"install": "2026-01-01"}))
+		})
+
+		ginkgo.It("should leave decommissioned devices out", func() {
+			sensorB.Decommission()
+			gomega.Expect(repo.UpdateDevice(ctx, sensorB)).To(gomega.Succeed())
+
+			page, err := repo.FindDevices(ctx, query())
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(page.Total).To(gomega.Equal(2))
+		})
+	})
+
+	ginkgo.Context("battery and health", func() {
+		ginkgo.It("should write them without touching the rest of the device", func() {
+			id := utils.GenerateUUID()
+			device := domain.Device{ID: domain.ID(id), Name: "device-" + id, DisplayName: "Pump"}
+			gomega.Expect(repo.CreateDevice(ctx, device)).To(gomega.Succeed())
+
+			now := time.Now().UTC().Truncate(time.Second)
+			device.DisplayName = "Renamed"
+			gomega.Expect(device.RecordBatteryReading(3.6, now)).To(gomega.BeTrue())
+			gomega.Expect(repo.UpdateBattery(ctx, device)).To(gomega.Succeed())
+
+			device.Health = domain.EvaluateDeviceHealth(device.Health, domain.DeviceHealthInputs{Battery: device.Battery},
+				domain.DeviceHealthPolicy{BatteryEmptyVoltage: 3.0, BatteryFullVoltage: 4.2, LowBatteryPercent: 60}, now)
+			gomega.Expect(repo.UpdateHealth(ctx, device)).To(gomega.Succeed())
+
+			result, err := repo.Get(ctx, id)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(result.DisplayName).To(gomega.Equal("Pump"))
+			gomega.Expect(result.Battery.Voltage).To(gomega.Equal(3.6))
+			gomega.Expect(result.Battery.ReportedAt.Equal(now)).To(gomega.BeTrue())
+			gomega.Expect(result.Health.Status).To(gomega.Equal(domain.DeviceHealthStatusDegraded))
+			gomega.Expect(*result.Health.BatteryPercent).To(gomega.Equal(50))
+			gomega.Expect(result.Health.LowBattery).To(gomega.BeTrue())
+			gomega.Expect(result.Health.SignalQuality).To(gomega.BeNil())
+		})
+
+		ginkgo.It("should return ErrDeviceNotFound for unknown devices", func() {
+			device := domain.Device{ID: domain.ID(utils.GenerateUUID())}
+			gomega.Expect(repo.UpdateHealth(ctx, device)).To(gomega.MatchError(usecases.ErrDeviceNotFound))
+		})
+	})
 })
@@ -0,0 +1,123 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"zensor-server/internal/control_plane/persistence/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+func NewDeviceSessionRepository(orm sql.ORM) (*SimpleDeviceSessionRepository, error) {
+	err := orm.AutoMigrate(&internal.DeviceSession{}, &internal.LinkQualitySample{})
+	if err != nil {
+		return nil, fmt.Errorf("auto migrating: %w", err)
+	}
+
+	return &SimpleDeviceSessionRepository{
+		orm: orm,
+	}, nil
+}
+
+var _ usecases.DeviceSessionRepository = (*SimpleDeviceSessionRepository)(nil)
+
+type SimpleDeviceSessionRepository struct {
+	orm sql.ORM
+}
+
+func (r *SimpleDeviceSessionRepository) GetSession(ctx context.Context, deviceID domain.ID) (domain.DeviceSession, error) {
+	var entity internal.DeviceSession
+	err := r.orm.
+		WithContext(ctx).
+		Where("device_id = ?", deviceID.String()).
+		First(&entity).
+		Error()
+	if errors.Is(err, sql.ErrRecordNotFound) {
+		return domain.DeviceSession{}, usecases.ErrDeviceSessionNotFound
+	}
+	if err != nil {
+		return domain.DeviceSession{}, fmt.Errorf("database query: %w", err)
+	}
+
+	return entity.ToDomain(), nil
+}
+
+func (r *SimpleDeviceSessionRepository) SaveSession(ctx context.Context, session domain.DeviceSession) error {
+	entity := internal.FromDeviceSession(session)
+
+	err := r.orm.WithContext(ctx).Save(&entity).Error()
+	if err != nil {
+		return fmt.Errorf("saving device session: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SimpleDeviceSessionRepository) CreateSample(ctx context.Context, sample domain.LinkQualitySample) error {
+	entity := internal.FromLinkQualitySample(sample)
+
+	err := r.orm.WithContext(ctx).Create(&entity).Error()
+	if err != nil {
+		return fmt.Errorf("creating link quality sample in database: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SimpleDeviceSessionRepository) FindSamplesByDevice(ctx context.Context, deviceID domain.ID, pagination usecases.Pagination) ([]domain.LinkQualitySample, int, error) {
+	var total int64
+	err := r.orm.
+		WithContext(ctx).
+		Model(&internal.LinkQualitySample{}).
+		Where("device_id = ?", deviceID.String()).
+		Count(&total).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("count query: %w", err)
+	}
+
+	var entities []internal.LinkQualitySample
+	err = r.orm.
+		WithContext(ctx).
+		Where("device_id = ?", deviceID.String()).
+		Order("received_at DESC").
+		Limit(pagination.Limit).
+		Offset(pagination.Offset).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("database query: %w", err)
+	}
+
+	result := make([]domain.LinkQualitySample, len(entities))
+	for i, entity := range entities {
+		result[i] = entity.ToDomain()
+	}
+
+	return result, int(total), nil
+}
+
+// FindSamplesSince returns up to limit samples of the device received since the given instant, newest first.
+func (r *SimpleDeviceSessionRepository) FindSamplesSince(ctx context.Context, deviceID domain.ID, since time.Time, limit int) ([]domain.LinkQualitySample, error) {
+	var entities []internal.LinkQualitySample
+	err := r.orm.
+		WithContext(ctx).
+		Where("device_id = ? AND received_at >= ?", deviceID.String(), since).
+		Order("received_at DESC").
+		Limit(limit).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, fmt.Errorf("database query: %w", err)
+	}
+
+	result := make([]domain.LinkQualitySample, len(entities))
+	for i, entity := range entities {
+		result[i] = entity.ToDomain()
+	}
+
+	return result, nil
+}
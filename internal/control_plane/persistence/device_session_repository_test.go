@@ -0,0 +1,102 @@
+package persistence_test
+
+import (
+	"context"
+	"time"
+	"zensor-server/internal/control_plane/persistence"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("DeviceSessionRepository", func() {
+	var (
+		repo     usecases.DeviceSessionRepository
+		ctx      context.Context
+		deviceID domain.ID
+	)
+
+	ginkgo.BeforeEach(func() {
+		orm, err := sql.NewMemoryORM("migrations")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		repo, err = persistence.NewDeviceSessionRepository(orm)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		ctx = context.Background()
+		deviceID = domain.ID(utils.GenerateUUID())
+	})
+
+	ginkgo.Context("GetSession", func() {
+		ginkgo.When("the device never joined", func() {
+			ginkgo.It("should return ErrDeviceSessionNotFound", func() {
+				_, err := repo.GetSession(ctx, deviceID)
+				gomega.Expect(err).To(gomega.MatchError(usecases.ErrDeviceSessionNotFound))
+			})
+		})
+
+		ginkgo.When("the session was saved twice", func() {
+			ginkgo.It("should return the latest state", func() {
+				session := domain.DeviceSession{DeviceID: deviceID}
+				session.Join("260B0001", time.Now())
+				gomega.Expect(repo.SaveSession(ctx, session)).To(gomega.Succeed())
+
+				session.RecordUplink("260B0001", 5, time.Now())
+				gomega.Expect(repo.SaveSession(ctx, session)).To(gomega.Succeed())
+
+				result, err := repo.GetSession(ctx, deviceID)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(result.DevAddr).To(gomega.Equal("260B0001"))
+				gomega.Expect(result.FrameCounterUp).To(gomega.Equal(uint32(5)))
+				gomega.Expect(result.JoinedAt).NotTo(gomega.BeNil())
+			})
+		})
+	})
+
+	ginkgo.Context("FindSamplesSince", func() {
+		ginkgo.It("should only return the samples received in the window", func() {
+			now := time.Now().UTC()
+			for i := range 3 {
+				err := repo.CreateSample(ctx, domain.LinkQualitySample{
+					ID:           domain.ID(utils.GenerateUUID()),
+					DeviceID:     deviceID,
+					FrameCounter: uint32(i),
+					ReceivedAt:   utils.Time{Time: now.Add(-time.Duration(i) * time.Hour)},
+				})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			}
+
+			samples, err := repo.FindSamplesSince(ctx, deviceID, now.Add(-90*time.Minute), 10)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(samples).To(gomega.HaveLen(2))
+			gomega.Expect(samples[0].FrameCounter).To(gomega.BeZero())
+		})
+	})
+
+	ginkgo.Context("FindSamplesByDevice", func() {
+		ginkgo.It("should return the samples newest first with their gateways", func() {
+			now := time.Now().UTC()
+			for i := range 3 {
+				err := repo.CreateSample(ctx, domain.LinkQualitySample{
+					ID:           domain.ID(utils.GenerateUUID()),
+					DeviceID:     deviceID,
+					FrameCounter: uint32(i),
+					Gateways:     []domain.GatewayReception{{GatewayID: "gw-1", RSSI: -90, SNR: 4.5}},
+					ReceivedAt:   utils.Time{Time: now.Add(time.Duration(i) * time.Minute)},
+				})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			}
+
+			samples, total, err := repo.FindSamplesByDevice(ctx, deviceID, usecases.Pagination{Limit: 2})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(total).To(gomega.Equal(3))
+			gomega.Expect(samples).To(gomega.HaveLen(2))
+			gomega.Expect(samples[0].FrameCounter).To(gomega.Equal(uint32(2)))
+			gomega.Expect(samples[0].Gateways).To(gomega.ConsistOf(domain.GatewayReception{GatewayID: "gw-1", RSSI: -90, SNR: 4.5}))
+		})
+	})
+})
@@ -57,3 +57,17 @@ func (e *EvaluationRuleRepository) FindAllByDeviceID(ctx context.Context, device
 
 	return domainRules, nil
 }
+
+func (e *EvaluationRuleRepository) DisableAllByDeviceID(ctx context.Context, deviceID string) error {
+	err := e.orm.
+		WithContext(ctx).
+		Model(&internal.EvaluationRule{}).
+		Where("device_id = ?", deviceID).
+		Updates(map[string]any{"enabled": false}).
+		Error()
+	if err != nil {
+		return fmt.Errorf("disabling evaluation rules: %w", err)
+	}
+
+	return nil
+}
@@ -36,6 +36,8 @@ type Command struct {
 	TaskID        string     `json:"task_id" gorm:"foreignKey:task_id"`
 	PayloadIndex  int        `json:"payload_index" gorm:"column:payload_index"`
 	PayloadValue  int        `json:"payload_value" gorm:"column:payload_value"`
+	RawPayload    []byte     `json:"raw_payload,omitempty"`
+	Confirmed     bool       `json:"confirmed"`
 	DispatchAfter utils.Time `json:"dispatch_after"`
 	Port          uint8      `json:"port"`
 	Priority      string     `json:"priority"`
@@ -50,6 +52,10 @@ type Command struct {
 	QueuedAt     *utils.Time `json:"queued_at,omitempty"`
 	AckedAt      *utils.Time `json:"acked_at,omitempty"`
 	FailedAt     *utils.Time `json:"failed_at,omitempty"`
+
+	// Dispatch claim fields
+	ClaimedBy    *string     `json:"claimed_by,omitempty"`
+	ClaimedUntil *utils.Time `json:"claimed_until,omitempty" gorm:"index"`
 }
 
 func (Command) TableName() string {
@@ -94,6 +100,8 @@ func (c Command) ToDomain() domain.Command {
 			Index: domain.Index(toUint8(c.PayloadIndex)),
 			Value: domain.CommandValue(toUint8(c.PayloadValue)),
 		},
+		RawPayload:    c.RawPayload,
+		Confirmed:     c.Confirmed,
 		DispatchAfter: c.DispatchAfter,
 		CreatedAt:     c.CreatedAt,
 		Ready:         c.Ready,
@@ -128,6 +136,8 @@ func FromCommand(cmd domain.Command) Command {
 		TaskID:        cmd.Task.ID.String(),
 		PayloadIndex:  int(cmd.Payload.Index),
 		PayloadValue:  int(cmd.Payload.Value),
+		RawPayload:    cmd.RawPayload,
+		Confirmed:     cmd.Confirmed,
 		DispatchAfter: cmd.DispatchAfter,
 		Port:          uint8(cmd.Port),
 		Priority:      string(cmd.Priority),
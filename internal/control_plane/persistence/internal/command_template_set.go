@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"encoding/json"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+type CommandTemplateSet struct {
+	ID               string      `json:"id" gorm:"primaryKey"`
+	Version          uint        `json:"version"`
+	TenantID         string      `json:"tenant_id" gorm:"index"`
+	Name             string      `json:"name"`
+	CommandTemplates string      `json:"command_templates"` // JSON array of command templates
+	CreatedAt        utils.Time  `json:"created_at"`
+	UpdatedAt        utils.Time  `json:"updated_at"`
+	DeletedAt        *utils.Time `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+func (CommandTemplateSet) TableName() string {
+	return "command_template_sets"
+}
+
+func FromCommandTemplateSet(value domain.CommandTemplateSet) CommandTemplateSet {
+	commandTemplateData := make([]CommandTemplateData, len(value.CommandTemplates))
+	for i, template := range value.CommandTemplates {
+		commandTemplateData[i] = ToCommandTemplateData(template)
+	}
+
+	return CommandTemplateSet{
+		ID:               value.ID.String(),
+		Version:          uint(value.Version),
+		TenantID:         value.Tenant.ID.String(),
+		Name:             value.Name,
+		CommandTemplates: string(mustMarshal(commandTemplateData)),
+		CreatedAt:        value.CreatedAt,
+		UpdatedAt:        value.UpdatedAt,
+		DeletedAt:        value.DeletedAt,
+	}
+}
+
+func (s CommandTemplateSet) ToDomain() domain.CommandTemplateSet {
+	var commandTemplateData []CommandTemplateData
+	if err := json.Unmarshal([]byte(s.CommandTemplates), &commandTemplateData); err != nil {
+		return domain.CommandTemplateSet{}
+	}
+
+	commandTemplates := make([]domain.CommandTemplate, len(commandTemplateData))
+	for i, data := range commandTemplateData {
+		commandTemplates[i] = data.ToCommandTemplate(domain.Device{})
+	}
+
+	return domain.CommandTemplateSet{
+		ID:               domain.ID(s.ID),
+		Version:          domain.Version(s.Version),
+		Tenant:           domain.Tenant{ID: domain.ID(s.TenantID)},
+		Name:             s.Name,
+		CommandTemplates: commandTemplates,
+		CreatedAt:        s.CreatedAt,
+		UpdatedAt:        s.UpdatedAt,
+		DeletedAt:        s.DeletedAt,
+	}
+}
@@ -1,23 +1,56 @@
 package internal
 
 import (
+	"encoding/json"
+	"log/slog"
 	"time"
 	"zensor-server/internal/infra/utils"
 	"zensor-server/internal/shared_kernel/domain"
 )
 
 type Device struct {
-	ID                    string     `json:"id" gorm:"primaryKey"`
-	Version               int        `json:"version"`
-	Name                  string     `json:"name"`
-	DisplayName           string     `json:"display_name"`
-	AppEUI                string     `json:"app_eui" gorm:"column:app_eui"`
-	DevEUI                string     `json:"dev_eui" gorm:"column:dev_eui"`
-	AppKey                string     `json:"app_key"`
-	TenantID              *string    `json:"tenant_id,omitempty" gorm:"index"`
-	LastMessageReceivedAt utils.Time `json:"last_message_received_at,omitempty"`
-	CreatedAt             time.Time  `json:"created_at"`
-	UpdatedAt             time.Time  `json:"updated_at"`
+	ID                    string      `json:"id" gorm:"primaryKey"`
+	Version               int         `json:"version"`
+	Name                  string      `json:"name"`
+	DisplayName           string      `json:"display_name"`
+	AppEUI                string      `json:"app_eui" gorm:"column:app_eui"`
+	DevEUI                string      `json:"dev_eui" gorm:"column:dev_eui"`
+	AppKey                string      `json:"app_key"`
+	TenantID              *string     `json:"tenant_id,omitempty" gorm:"index"`
+	Profile               string      `json:"profile"`
+	Sector                string      `json:"sector"`
+	LastMessageReceivedAt utils.Time  `json:"last_message_received_at,omitempty"`
+	CreatedAt             time.Time   `json:"created_at"`
+	UpdatedAt             time.Time   `json:"updated_at"`
+	DeletedAt             *utils.Time `json:"deleted_at,omitempty" gorm:"index"`
+
+	ExpectedUplinkIntervalSeconds int64 `json:"expected_uplink_interval_seconds"`
+
+	// OnlineUntil is when the device goes offline unless it uplinks again. It is derived from the
+	// last message and the uplink interval on every save, so listings can filter on connectivity.
+	OnlineUntil *utils.Time `json:"online_until,omitempty" gorm:"index"`
+
+	// Tags is the JSON object of the tags with sorted keys, which tag filters match against.
+	Tags     string `json:"tags"`
+	Metadata string `json:"metadata"`
+
+	ProvisioningStatus        string      `json:"provisioning_status" gorm:"index"`
+	ProvisioningAttempts      int         `json:"provisioning_attempts"`
+	ProvisioningLastError     string      `json:"provisioning_last_error"`
+	ProvisioningNextAttemptAt *utils.Time `json:"provisioning_next_attempt_at,omitempty"`
+	ProvisioningUpdatedAt     *utils.Time `json:"provisioning_updated_at,omitempty"`
+
+	BatteryVoltage    *float64    `json:"battery_voltage,omitempty"`
+	BatteryReportedAt *utils.Time `json:"battery_reported_at,omitempty"`
+
+	HealthStatus             string      `json:"health_status" gorm:"index"`
+	HealthScore              *int        `json:"health_score,omitempty"`
+	HealthBatteryPercent     *int        `json:"health_battery_percent,omitempty"`
+	HealthSignalQuality      *int        `json:"health_signal_quality,omitempty"`
+	HealthUplinkRegularity   *int        `json:"health_uplink_regularity,omitempty"`
+	HealthCommandFailureRate *int        `json:"health_command_failure_rate,omitempty"`
+	HealthLowBattery         bool        `json:"health_low_battery"`
+	HealthEvaluatedAt        *utils.Time `json:"health_evaluated_at,omitempty"`
 }
 
 func (Device) TableName() string {
@@ -32,7 +65,29 @@ func (s Device) ToDomain() domain.Device {
 		AppEUI:                s.AppEUI,
 		DevEUI:                s.DevEUI,
 		AppKey:                s.AppKey,
+		Profile:               s.Profile,
 		LastMessageReceivedAt: utils.Time{Time: s.LastMessageReceivedAt.Time},
+		DeletedAt:             s.DeletedAt,
+
+		ExpectedUplinkInterval: time.Duration(s.ExpectedUplinkIntervalSeconds) * time.Second,
+		Provisioning:           s.provisioningToDomain(),
+		Health:                 s.healthToDomain(),
+	}
+
+	if s.BatteryVoltage != nil && s.BatteryReportedAt != nil {
+		device.Battery = &domain.BatteryReading{Voltage: *s.BatteryVoltage, ReportedAt: *s.BatteryReportedAt}
+	}
+
+	if s.Tags != "" {
+		if err := json.Unmarshal([]byte(s.Tags), &device.Tags); err != nil {
+			slog.Error("failed to unmarshal device tags", slog.String("id", s.ID), slog.Any("error", err))
+		}
+	}
+
+	if s.Metadata != "" {
+		if err := json.Unmarshal([]byte(s.Metadata), &device.Metadata); err != nil {
+			slog.Error("failed to unmarshal device metadata", slog.String("id", s.ID), slog.Any("error", err))
+		}
 	}
 
 	if s.TenantID != nil {
@@ -40,9 +95,41 @@ func (s Device) ToDomain() domain.Device {
 		device.TenantID = &tenantID
 	}
 
+	if s.Sector != "" {
+		device.Sector = &domain.Sector{Name: domain.Name(s.Sector)}
+	}
+
 	return device
 }
 
+func (s Device) provisioningToDomain() domain.DeviceProvisioning {
+	return domain.DeviceProvisioning{
+		Status:        domain.ProvisioningStatus(s.ProvisioningStatus),
+		Attempts:      s.ProvisioningAttempts,
+		LastError:     s.ProvisioningLastError,
+		NextAttemptAt: s.ProvisioningNextAttemptAt,
+		UpdatedAt:     s.ProvisioningUpdatedAt,
+	}
+}
+
+func (s Device) healthToDomain() domain.DeviceHealth {
+	status := domain.DeviceHealthStatus(s.HealthStatus)
+	if status == "" {
+		status = domain.DeviceHealthStatusUnknown
+	}
+
+	return domain.DeviceHealth{
+		Status:             status,
+		Score:              s.HealthScore,
+		BatteryPercent:     s.HealthBatteryPercent,
+		SignalQuality:      s.HealthSignalQuality,
+		UplinkRegularity:   s.HealthUplinkRegularity,
+		CommandFailureRate: s.HealthCommandFailureRate,
+		LowBattery:         s.HealthLowBattery,
+		EvaluatedAt:        s.HealthEvaluatedAt,
+	}
+}
+
 func FromDevice(value domain.Device) Device {
 	device := Device{
 		ID:                    value.ID.String(),
@@ -52,9 +139,51 @@ func FromDevice(value domain.Device) Device {
 		AppEUI:                value.AppEUI,
 		DevEUI:                value.DevEUI,
 		AppKey:                value.AppKey,
+		Profile:               value.Profile,
 		LastMessageReceivedAt: value.LastMessageReceivedAt,
 		CreatedAt:             time.Now(),
 		UpdatedAt:             time.Now(),
+		DeletedAt:             value.DeletedAt,
+
+		ExpectedUplinkIntervalSeconds: int64(value.ExpectedUplinkInterval / time.Second),
+
+		ProvisioningStatus:        string(value.Provisioning.Status),
+		ProvisioningAttempts:      value.Provisioning.Attempts,
+		ProvisioningLastError:     value.Provisioning.LastError,
+		ProvisioningNextAttemptAt: value.Provisioning.NextAttemptAt,
+		ProvisioningUpdatedAt:     value.Provisioning.UpdatedAt,
+
+		HealthStatus:             string(value.Health.Status),
+		HealthScore:              value.Health.Score,
+		HealthBatteryPercent:     value.Health.BatteryPercent,
+		HealthSignalQuality:      value.Health.SignalQuality,
+		HealthUplinkRegularity:   value.Health.UplinkRegularity,
+		HealthCommandFailureRate: value.Health.CommandFailureRate,
+		HealthLowBattery:         value.Health.LowBattery,
+		HealthEvaluatedAt:        value.Health.EvaluatedAt,
+	}
+
+	if value.Battery != nil {
+		voltage, reportedAt := value.Battery.Voltage, value.Battery.ReportedAt
+		device.BatteryVoltage = &voltage
+		device.BatteryReportedAt = &reportedAt
+	}
+
+	if value.Sector != nil {
+		device.Sector = string(value.Sector.Name)
+	}
+
+	if !value.LastMessageReceivedAt.IsZero() {
+		onlineUntil := utils.Time{Time: value.LastMessageReceivedAt.Add(value.UplinkInterval())}
+		device.OnlineUntil = &onlineUntil
+	}
+
+	if len(value.Tags) > 0 {
+		device.Tags = string(mustMarshal(value.Tags))
+	}
+
+	if len(value.Metadata) > 0 {
+		device.Metadata = string(mustMarshal(value.Metadata))
 	}
 
 	if value.TenantID != nil {
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+type DeviceConfigJob struct {
+	ID           string      `json:"id" gorm:"primaryKey"`
+	Version      int         `json:"version"`
+	DeviceID     string      `json:"device_id" gorm:"index"`
+	Port         uint8       `json:"port"`
+	Size         int         `json:"size"`
+	Checksum     int64       `json:"checksum"`
+	FragmentSize int         `json:"fragment_size"`
+	MaxAttempts  int         `json:"max_attempts"`
+	Fragments    string      `json:"fragments"`
+	Status       string      `json:"status" gorm:"index"`
+	LastError    string      `json:"last_error"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+	CompletedAt  *utils.Time `json:"completed_at"`
+}
+
+func (DeviceConfigJob) TableName() string {
+	return "device_config_jobs"
+}
+
+type deviceConfigFragmentData struct {
+	Index     int         `json:"index"`
+	Payload   []byte      `json:"payload"`
+	Status    string      `json:"status"`
+	Attempts  int         `json:"attempts"`
+	CommandID *string     `json:"command_id,omitempty"`
+	SentAt    *utils.Time `json:"sent_at,omitempty"`
+	AckedAt   *utils.Time `json:"acked_at,omitempty"`
+}
+
+func FromDeviceConfigJob(value domain.DeviceConfigJob) DeviceConfigJob {
+	fragments := make([]deviceConfigFragmentData, len(value.Fragments))
+	for i, fragment := range value.Fragments {
+		data := deviceConfigFragmentData{
+			Index:    fragment.Index,
+			Payload:  fragment.Payload,
+			Status:   string(fragment.Status),
+			Attempts: fragment.Attempts,
+			SentAt:   fragment.SentAt,
+			AckedAt:  fragment.AckedAt,
+		}
+		if fragment.CommandID != nil {
+			commandID := fragment.CommandID.String()
+			data.CommandID = &commandID
+		}
+		fragments[i] = data
+	}
+
+	return DeviceConfigJob{
+		ID:           value.ID.String(),
+		Version:      value.Version,
+		DeviceID:     value.DeviceID.String(),
+		Port:         uint8(value.Port),
+		Size:         value.Size,
+		Checksum:     int64(value.Checksum),
+		FragmentSize: value.FragmentSize,
+		MaxAttempts:  value.MaxAttempts,
+		Fragments:    string(mustMarshal(fragments)),
+		Status:       string(value.Status),
+		LastError:    value.LastError,
+		CreatedAt:    value.CreatedAt.Time,
+		UpdatedAt:    value.UpdatedAt.Time,
+		CompletedAt:  value.CompletedAt,
+	}
+}
+
+func (s DeviceConfigJob) ToDomain() domain.DeviceConfigJob {
+	var data []deviceConfigFragmentData
+	if err := json.Unmarshal([]byte(s.Fragments), &data); err != nil {
+		slog.Error("failed to unmarshal device config fragments", slog.String("job_id", s.ID), slog.Any("error", err))
+	}
+
+	fragments := make([]domain.DeviceConfigFragment, len(data))
+	for i, fragment := range data {
+		value := domain.DeviceConfigFragment{
+			Index:    fragment.Index,
+			Payload:  fragment.Payload,
+			Status:   domain.DeviceConfigFragmentStatus(fragment.Status),
+			Attempts: fragment.Attempts,
+			SentAt:   fragment.SentAt,
+			AckedAt:  fragment.AckedAt,
+		}
+		if fragment.CommandID != nil {
+			commandID := domain.ID(*fragment.CommandID)
+			value.CommandID = &commandID
+		}
+		fragments[i] = value
+	}
+
+	return domain.DeviceConfigJob{
+		ID:           domain.ID(s.ID),
+		Version:      s.Version,
+		DeviceID:     domain.ID(s.DeviceID),
+		Port:         domain.Port(s.Port),
+		Size:         s.Size,
+		Checksum:     uint32(s.Checksum),
+		FragmentSize: s.FragmentSize,
+		MaxAttempts:  s.MaxAttempts,
+		Fragments:    fragments,
+		Status:       domain.DeviceConfigJobStatus(s.Status),
+		LastError:    s.LastError,
+		CreatedAt:    utils.Time{Time: s.CreatedAt},
+		UpdatedAt:    utils.Time{Time: s.UpdatedAt},
+		CompletedAt:  s.CompletedAt,
+	}
+}
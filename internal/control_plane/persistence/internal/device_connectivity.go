@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"time"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+type DeviceConnectivityTransition struct {
+	ID                    string      `json:"id" gorm:"primaryKey"`
+	DeviceID              string      `json:"device_id" gorm:"index:idx_device_connectivity_transitions_device_occurred_at"`
+	TenantID              *string     `json:"tenant_id,omitempty"`
+	FromState             string      `json:"from_state"`
+	ToState               string      `json:"to_state"`
+	LastMessageReceivedAt *utils.Time `json:"last_message_received_at,omitempty"`
+	OccurredAt            time.Time   `json:"occurred_at" gorm:"index:idx_device_connectivity_transitions_device_occurred_at"`
+}
+
+func (DeviceConnectivityTransition) TableName() string {
+	return "device_connectivity_transitions"
+}
+
+func FromDeviceConnectivityTransition(value domain.DeviceConnectivityTransition) DeviceConnectivityTransition {
+	var tenantID *string
+	if value.TenantID != nil {
+		id := value.TenantID.String()
+		tenantID = &id
+	}
+
+	return DeviceConnectivityTransition{
+		ID:                    value.ID.String(),
+		DeviceID:              value.DeviceID.String(),
+		TenantID:              tenantID,
+		FromState:             string(value.From),
+		ToState:               string(value.To),
+		LastMessageReceivedAt: value.LastMessageReceivedAt,
+		OccurredAt:            value.OccurredAt.Time,
+	}
+}
+
+func (t DeviceConnectivityTransition) ToDomain() domain.DeviceConnectivityTransition {
+	var tenantID *domain.ID
+	if t.TenantID != nil {
+		id := domain.ID(*t.TenantID)
+		tenantID = &id
+	}
+
+	return domain.DeviceConnectivityTransition{
+		ID:                    domain.ID(t.ID),
+		DeviceID:              domain.ID(t.DeviceID),
+		TenantID:              tenantID,
+		From:                  domain.ConnectivityState(t.FromState),
+		To:                    domain.ConnectivityState(t.ToState),
+		LastMessageReceivedAt: t.LastMessageReceivedAt,
+		OccurredAt:            utils.Time{Time: t.OccurredAt},
+	}
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"time"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+type DeviceKeyAccess struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	DeviceID   string    `json:"device_id" gorm:"index"`
+	UserID     string    `json:"user_id"`
+	UserEmail  string    `json:"user_email"`
+	AccessedAt time.Time `json:"accessed_at"`
+}
+
+func (DeviceKeyAccess) TableName() string {
+	return "device_key_accesses"
+}
+
+func FromDeviceKeyAccess(value domain.DeviceKeyAccess) DeviceKeyAccess {
+	return DeviceKeyAccess{
+		ID:         value.ID.String(),
+		DeviceID:   value.DeviceID.String(),
+		UserID:     value.UserID.String(),
+		UserEmail:  value.UserEmail,
+		AccessedAt: value.AccessedAt.Time,
+	}
+}
+
+func (s DeviceKeyAccess) ToDomain() domain.DeviceKeyAccess {
+	return domain.DeviceKeyAccess{
+		ID:         domain.ID(s.ID),
+		DeviceID:   domain.ID(s.DeviceID),
+		UserID:     domain.ID(s.UserID),
+		UserEmail:  s.UserEmail,
+		AccessedAt: utils.Time{Time: s.AccessedAt},
+	}
+}
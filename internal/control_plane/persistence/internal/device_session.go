@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+type DeviceSession struct {
+	DeviceID       string      `json:"device_id" gorm:"primaryKey"`
+	DevAddr        string      `json:"dev_addr"`
+	JoinedAt       *utils.Time `json:"joined_at,omitempty"`
+	FrameCounterUp uint32      `json:"frame_counter_up"`
+	LastUplinkAt   *utils.Time `json:"last_uplink_at,omitempty"`
+}
+
+func (DeviceSession) TableName() string {
+	return "device_sessions"
+}
+
+func FromDeviceSession(value domain.DeviceSession) DeviceSession {
+	return DeviceSession{
+		DeviceID:       value.DeviceID.String(),
+		DevAddr:        value.DevAddr,
+		JoinedAt:       value.JoinedAt,
+		FrameCounterUp: value.FrameCounterUp,
+		LastUplinkAt:   value.LastUplinkAt,
+	}
+}
+
+func (s DeviceSession) ToDomain() domain.DeviceSession {
+	return domain.DeviceSession{
+		DeviceID:       domain.ID(s.DeviceID),
+		DevAddr:        s.DevAddr,
+		JoinedAt:       s.JoinedAt,
+		FrameCounterUp: s.FrameCounterUp,
+		LastUplinkAt:   s.LastUplinkAt,
+	}
+}
+
+// GatewayReceptionData is a gateway reception as stored in the JSON gateways column.
+type GatewayReceptionData struct {
+	GatewayID string  `json:"gateway_id"`
+	RSSI      float64 `json:"rssi"`
+	SNR       float64 `json:"snr"`
+}
+
+type LinkQualitySample struct {
+	ID              string    `json:"id" gorm:"primaryKey"`
+	DeviceID        string    `json:"device_id" gorm:"index:idx_link_quality_samples_device_received_at"`
+	DevAddr         string    `json:"dev_addr"`
+	FrameCounter    uint32    `json:"frame_counter"`
+	SpreadingFactor int       `json:"spreading_factor"`
+	Bandwidth       int       `json:"bandwidth"`
+	FrequencyHz     int64     `json:"frequency_hz"`
+	Gateways        string    `json:"gateways"` // JSON array of gateway receptions
+	ReceivedAt      time.Time `json:"received_at" gorm:"index:idx_link_quality_samples_device_received_at"`
+}
+
+func (LinkQualitySample) TableName() string {
+	return "link_quality_samples"
+}
+
+func FromLinkQualitySample(value domain.LinkQualitySample) LinkQualitySample {
+	gateways := make([]GatewayReceptionData, len(value.Gateways))
+	for i, gateway := range value.Gateways {
+		gateways[i] = GatewayReceptionData{
+			GatewayID: gateway.GatewayID,
+			RSSI:      gateway.RSSI,
+			SNR:       gateway.SNR,
+		}
+	}
+
+	return LinkQualitySample{
+		ID:              value.ID.String(),
+		DeviceID:        value.DeviceID.String(),
+		DevAddr:         value.DevAddr,
+		FrameCounter:    value.FrameCounter,
+		SpreadingFactor: value.SpreadingFactor,
+		Bandwidth:       value.Bandwidth,
+		FrequencyHz:     value.FrequencyHz,
+		Gateways:        string(mustMarshal(gateways)),
+		ReceivedAt:      value.ReceivedAt.Time,
+	}
+}
+
+func (s LinkQualitySample) ToDomain() domain.LinkQualitySample {
+	var gateways []GatewayReceptionData
+	if s.Gateways != "" {
+		if err := json.Unmarshal([]byte(s.Gateways), &gateways); err != nil {
+			slog.Error("failed to unmarshal link quality gateways", slog.String("id", s.ID), slog.Any("error", err))
+		}
+	}
+
+	receptions := make([]domain.GatewayReception, len(gateways))
+	for i, gateway := range gateways {
+		receptions[i] = domain.GatewayReception{
+			GatewayID: gateway.GatewayID,
+			RSSI:      gateway.RSSI,
+			SNR:       gateway.SNR,
+		}
+	}
+
+	return domain.LinkQualitySample{
+		ID:              domain.ID(s.ID),
+		DeviceID:        domain.ID(s.DeviceID),
+		DevAddr:         s.DevAddr,
+		FrameCounter:    s.FrameCounter,
+		SpreadingFactor: s.SpreadingFactor,
+		Bandwidth:       s.Bandwidth,
+		FrequencyHz:     s.FrequencyHz,
+		Gateways:        receptions,
+		ReceivedAt:      utils.Time{Time: s.ReceivedAt},
+	}
+}
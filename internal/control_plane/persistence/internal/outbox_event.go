@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"time"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+type OutboxEvent struct {
+	ID          string      `json:"id" gorm:"primaryKey"`
+	TenantID    string      `json:"tenant_id"`
+	Topic       string      `json:"topic"`
+	Event       string      `json:"event"`
+	Payload     []byte      `json:"payload"`
+	Attempts    int         `json:"attempts"`
+	CreatedAt   time.Time   `json:"created_at" gorm:"index"`
+	PublishedAt *utils.Time `json:"published_at,omitempty"`
+	AckedAt     *utils.Time `json:"acked_at,omitempty" gorm:"index"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+func FromOutboxEvent(value domain.OutboxEvent) OutboxEvent {
+	return OutboxEvent{
+		ID:          value.ID.String(),
+		TenantID:    value.TenantID.String(),
+		Topic:       value.Topic,
+		Event:       value.Event,
+		Payload:     value.Payload,
+		Attempts:    value.Attempts,
+		CreatedAt:   value.CreatedAt.Time,
+		PublishedAt: value.PublishedAt,
+		AckedAt:     value.AckedAt,
+	}
+}
+
+func (e OutboxEvent) ToDomain() domain.OutboxEvent {
+	return domain.OutboxEvent{
+		ID:          domain.ID(e.ID),
+		TenantID:    domain.ID(e.TenantID),
+		Topic:       e.Topic,
+		Event:       e.Event,
+		Payload:     e.Payload,
+		Attempts:    e.Attempts,
+		CreatedAt:   utils.Time{Time: e.CreatedAt},
+		PublishedAt: e.PublishedAt,
+		AckedAt:     e.AckedAt,
+	}
+}
+
+// OutboxConsumption records that a consumer processed an outbox event, so redeliveries of the
+// event are discarded.
+type OutboxConsumption struct {
+	Consumer    string    `json:"consumer" gorm:"primaryKey"`
+	EventID     string    `json:"event_id" gorm:"primaryKey"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+func (OutboxConsumption) TableName() string {
+	return "outbox_consumptions"
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"time"
 	"zensor-server/internal/infra/utils"
@@ -54,6 +55,22 @@ func ToCommandTemplateData(template domain.CommandTemplate) CommandTemplateData
 	}
 }
 
+// ToCommandTemplate converts a CommandTemplateData back to a domain CommandTemplate for device.
+func (ctd CommandTemplateData) ToCommandTemplate(device domain.Device) domain.CommandTemplate {
+	waitFor, _ := time.ParseDuration(ctd.WaitFor)
+
+	return domain.CommandTemplate{
+		Device:   device,
+		Port:     domain.Port(ctd.Port),
+		Priority: domain.CommandPriority(ctd.Priority),
+		Payload: domain.CommandPayload{
+			Index: domain.Index(ctd.Payload.Index),
+			Value: domain.CommandValue(ctd.Payload.Value),
+		},
+		WaitFor: waitFor,
+	}
+}
+
 // ToCommand converts a CommandTemplateData to a domain Command with calculated DispatchAfter.
 func (ctd CommandTemplateData) ToCommand(device domain.Device, task domain.Task, baseTime time.Time) domain.Command {
 	waitFor, _ := time.ParseDuration(ctd.WaitFor)
@@ -77,18 +94,22 @@ func (ctd CommandTemplateData) ToCommand(device domain.Device, task domain.Task,
 }
 
 type ScheduledTask struct {
-	ID               string      `json:"id" gorm:"primaryKey"`
-	Version          uint        `json:"version"`
-	TenantID         string      `json:"tenant_id"`
-	DeviceID         string      `json:"device_id"`
-	CommandTemplates string      `json:"command_templates"` // JSON array of command templates
-	Schedule         string      `json:"schedule"`          // Deprecated: use SchedulingConfig instead
-	SchedulingConfig string      `json:"scheduling_config"` // JSON scheduling configuration
-	IsActive         bool        `json:"is_active"`
-	CreatedAt        utils.Time  `json:"created_at"`
-	UpdatedAt        utils.Time  `json:"updated_at"`
-	LastExecutedAt   *utils.Time `json:"last_executed_at"`
-	DeletedAt        *utils.Time `json:"deleted_at,omitempty" gorm:"index"`
+	ID                   string      `json:"id" gorm:"primaryKey"`
+	Version              uint        `json:"version"`
+	TenantID             string      `json:"tenant_id"`
+	DeviceID             string      `json:"device_id"`
+	DeviceIDs            string      `json:"device_ids"`        // JSON array of additional target device IDs
+	CommandTemplates     string      `json:"command_templates"` // JSON array of command templates
+	CommandTemplateSetID *string     `json:"command_template_set_id" gorm:"index"`
+	Schedule             string      `json:"schedule"`          // Deprecated: use SchedulingConfig instead
+	SchedulingConfig     string      `json:"scheduling_config"` // JSON scheduling configuration
+	IsActive             bool        `json:"is_active"`
+	CreatedAt            utils.Time  `json:"created_at"`
+	UpdatedAt            utils.Time  `json:"updated_at"`
+	LastExecutedAt       *utils.Time `json:"last_executed_at"`
+	PausedAt             *utils.Time `json:"paused_at"`
+	PausedUntil          *utils.Time `json:"paused_until"`
+	DeletedAt            *utils.Time `json:"deleted_at,omitempty" gorm:"index"`
 }
 
 func (ScheduledTask) TableName() string {
@@ -104,6 +125,17 @@ func FromScheduledTask(value domain.ScheduledTask) ScheduledTask {
 
 	commandTemplatesJSON := mustMarshal(commandTemplateData)
 
+	deviceIDs := make([]string, len(value.Devices))
+	for i, device := range value.Devices {
+		deviceIDs[i] = device.ID.String()
+	}
+
+	var commandTemplateSetID *string
+	if value.CommandTemplateSet != nil {
+		id := value.CommandTemplateSet.ID.String()
+		commandTemplateSetID = &id
+	}
+
 	// Convert scheduling configuration to persistence format
 	var schedulingConfigJSON []byte
 	var schedulingConfigStr string
@@ -124,75 +156,92 @@ func FromScheduledTask(value domain.ScheduledTask) ScheduledTask {
 	}
 
 	return ScheduledTask{
-		ID:               value.ID.String(),
-		Version:          uint(value.Version),
-		TenantID:         value.Tenant.ID.String(),
-		DeviceID:         value.Device.ID.String(),
-		CommandTemplates: string(commandTemplatesJSON),
-		Schedule:         value.Schedule,
-		SchedulingConfig: schedulingConfigStr,
-		IsActive:         value.IsActive,
-		CreatedAt:        value.CreatedAt,
-		UpdatedAt:        value.UpdatedAt,
-		LastExecutedAt:   value.LastExecutedAt,
-		DeletedAt:        value.DeletedAt,
+		ID:                   value.ID.String(),
+		Version:              uint(value.Version),
+		TenantID:             value.Tenant.ID.String(),
+		DeviceID:             value.Device.ID.String(),
+		DeviceIDs:            string(mustMarshal(deviceIDs)),
+		CommandTemplates:     string(commandTemplatesJSON),
+		CommandTemplateSetID: commandTemplateSetID,
+		Schedule:             value.Schedule,
+		SchedulingConfig:     schedulingConfigStr,
+		IsActive:             value.IsActive,
+		CreatedAt:            value.CreatedAt,
+		UpdatedAt:            value.UpdatedAt,
+		LastExecutedAt:       value.LastExecutedAt,
+		PausedAt:             value.PausedAt,
+		PausedUntil:          value.PausedUntil,
+		DeletedAt:            value.DeletedAt,
 	}
 }
 
-func (s ScheduledTask) ToDomain() domain.ScheduledTask {
+// ToDomain decodes the scheduled task, failing on JSON columns it cannot read rather than
+// returning a task without its targets or templates.
+func (s ScheduledTask) ToDomain() (domain.ScheduledTask, error) {
 	var commandTemplateData []CommandTemplateData
 	if err := json.Unmarshal([]byte(s.CommandTemplates), &commandTemplateData); err != nil {
-		return domain.ScheduledTask{}
+		return domain.ScheduledTask{}, fmt.Errorf("decoding command templates of scheduled task %s: %w", s.ID, err)
 	}
 
 	// Convert CommandTemplateData back to domain CommandTemplates
 	commandTemplates := make([]domain.CommandTemplate, len(commandTemplateData))
 	for i, data := range commandTemplateData {
-		// Parse the WaitFor duration from the stored string
-		waitFor, _ := time.ParseDuration(data.WaitFor)
-
-		commandTemplates[i] = domain.CommandTemplate{
-			Device:   domain.Device{ID: domain.ID(s.DeviceID)}, // Set device from scheduled task
-			Port:     domain.Port(data.Port),
-			Priority: domain.CommandPriority(data.Priority),
-			Payload: domain.CommandPayload{
-				Index: domain.Index(data.Payload.Index),
-				Value: domain.CommandValue(data.Payload.Value),
-			},
-			WaitFor: waitFor,
+		commandTemplates[i] = data.ToCommandTemplate(domain.Device{ID: domain.ID(s.DeviceID)})
+	}
+
+	var deviceIDs []string
+	if s.DeviceIDs != "" {
+		if err := json.Unmarshal([]byte(s.DeviceIDs), &deviceIDs); err != nil {
+			return domain.ScheduledTask{}, fmt.Errorf("decoding device ids of scheduled task %s: %w", s.ID, err)
 		}
 	}
 
+	devices := make([]domain.Device, len(deviceIDs))
+	for i, id := range deviceIDs {
+		devices[i] = domain.Device{ID: domain.ID(id)}
+	}
+
+	var commandTemplateSet *domain.CommandTemplateSet
+	if s.CommandTemplateSetID != nil {
+		commandTemplateSet = &domain.CommandTemplateSet{ID: domain.ID(*s.CommandTemplateSetID)}
+	}
+
 	// Parse scheduling configuration
 	var schedulingConfig domain.SchedulingConfiguration
 	if s.SchedulingConfig != "" {
 		var schedulingData SchedulingConfigurationData
-		if err := json.Unmarshal([]byte(s.SchedulingConfig), &schedulingData); err == nil {
-			schedulingConfig.Type = domain.SchedulingType(schedulingData.Type)
-			schedulingConfig.DayInterval = schedulingData.DayInterval
-			schedulingConfig.ExecutionTime = schedulingData.ExecutionTime
-
-			if schedulingData.InitialDay != nil {
-				parsedTime, err := time.Parse(time.RFC3339, *schedulingData.InitialDay)
-				if err == nil {
-					schedulingConfig.InitialDay = &utils.Time{Time: parsedTime}
-				}
+		if err := json.Unmarshal([]byte(s.SchedulingConfig), &schedulingData); err != nil {
+			return domain.ScheduledTask{}, fmt.Errorf("decoding scheduling configuration of scheduled task %s: %w", s.ID, err)
+		}
+		schedulingConfig.Type = domain.SchedulingType(schedulingData.Type)
+		schedulingConfig.DayInterval = schedulingData.DayInterval
+		schedulingConfig.ExecutionTime = schedulingData.ExecutionTime
+
+		if schedulingData.InitialDay != nil {
+			parsedTime, err := time.Parse(time.RFC3339, *schedulingData.InitialDay)
+			if err != nil {
+				return domain.ScheduledTask{}, fmt.Errorf("parsing initial day of scheduled task %s: %w", s.ID, err)
 			}
+			schedulingConfig.InitialDay = &utils.Time{Time: parsedTime}
 		}
 	}
 
 	return domain.ScheduledTask{
-		ID:               domain.ID(s.ID),
-		Version:          domain.Version(s.Version),
-		Tenant:           domain.Tenant{ID: domain.ID(s.TenantID)},
-		Device:           domain.Device{ID: domain.ID(s.DeviceID)},
-		CommandTemplates: commandTemplates,
-		Schedule:         s.Schedule,
-		Scheduling:       schedulingConfig,
-		IsActive:         s.IsActive,
-		CreatedAt:        s.CreatedAt,
-		UpdatedAt:        s.UpdatedAt,
-		LastExecutedAt:   s.LastExecutedAt,
-		DeletedAt:        s.DeletedAt,
-	}
+		ID:                 domain.ID(s.ID),
+		Version:            domain.Version(s.Version),
+		Tenant:             domain.Tenant{ID: domain.ID(s.TenantID)},
+		Device:             domain.Device{ID: domain.ID(s.DeviceID)},
+		Devices:            devices,
+		CommandTemplates:   commandTemplates,
+		CommandTemplateSet: commandTemplateSet,
+		Schedule:           s.Schedule,
+		Scheduling:         schedulingConfig,
+		IsActive:           s.IsActive,
+		CreatedAt:          s.CreatedAt,
+		UpdatedAt:          s.UpdatedAt,
+		LastExecutedAt:     s.LastExecutedAt,
+		PausedAt:           s.PausedAt,
+		PausedUntil:        s.PausedUntil,
+		DeletedAt:          s.DeletedAt,
+	}, nil
 }
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"time"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+type ScheduledTaskRun struct {
+	ID              string    `json:"id" gorm:"primaryKey"`
+	ScheduledTaskID string    `json:"scheduled_task_id" gorm:"index:idx_scheduled_task_runs_scheduled_task_evaluated_at"`
+	TenantID        string    `json:"tenant_id"`
+	DeviceID        string    `json:"device_id"`
+	Outcome         string    `json:"outcome"`
+	TaskID          *string   `json:"task_id,omitempty"`
+	Error           string    `json:"error,omitempty"`
+	EvaluatedAt     time.Time `json:"evaluated_at" gorm:"index:idx_scheduled_task_runs_scheduled_task_evaluated_at"`
+
+	// Commands of the task created by the run, preloaded to aggregate their outcome.
+	Commands []Command `json:"-" gorm:"foreignKey:TaskID;references:TaskID;constraint:-"`
+}
+
+func (ScheduledTaskRun) TableName() string {
+	return "scheduled_task_runs"
+}
+
+func FromScheduledTaskRun(value domain.ScheduledTaskRun) ScheduledTaskRun {
+	var taskID *string
+	if value.TaskID != nil {
+		id := value.TaskID.String()
+		taskID = &id
+	}
+
+	return ScheduledTaskRun{
+		ID:              value.ID.String(),
+		ScheduledTaskID: value.ScheduledTaskID.String(),
+		TenantID:        value.TenantID.String(),
+		DeviceID:        value.DeviceID.String(),
+		Outcome:         string(value.Outcome),
+		TaskID:          taskID,
+		Error:           value.Error,
+		EvaluatedAt:     value.EvaluatedAt.Time,
+	}
+}
+
+func (r ScheduledTaskRun) ToDomain() domain.ScheduledTaskRun {
+	var taskID *domain.ID
+	if r.TaskID != nil {
+		id := domain.ID(*r.TaskID)
+		taskID = &id
+	}
+
+	run := domain.ScheduledTaskRun{
+		ID:              domain.ID(r.ID),
+		ScheduledTaskID: domain.ID(r.ScheduledTaskID),
+		TenantID:        domain.ID(r.TenantID),
+		DeviceID:        domain.ID(r.DeviceID),
+		Outcome:         domain.ScheduledTaskRunOutcome(r.Outcome),
+		TaskID:          taskID,
+		Error:           r.Error,
+		CommandOutcome:  domain.CommandOutcomeNone,
+		EvaluatedAt:     utils.Time{Time: r.EvaluatedAt},
+	}
+	run.AggregateCommandOutcome(CommandSet(r.Commands).ToDomain())
+
+	return run
+}
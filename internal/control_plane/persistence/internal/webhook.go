@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+type WebhookSubscription struct {
+	ID         string      `json:"id" gorm:"primaryKey"`
+	Version    uint        `json:"version"`
+	TenantID   string      `json:"tenant_id" gorm:"index"`
+	URL        string      `json:"url"`
+	Secret     string      `json:"secret"`      // Sealed with the secrets keyring
+	EventTypes string      `json:"event_types"` // JSON array of event types
+	CreatedAt  utils.Time  `json:"created_at"`
+	UpdatedAt  utils.Time  `json:"updated_at"`
+	DeletedAt  *utils.Time `json:"deleted_at,omitempty" gorm:"index"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+func FromWebhookSubscription(value domain.WebhookSubscription) WebhookSubscription {
+	return WebhookSubscription{
+		ID:         value.ID.String(),
+		Version:    uint(value.Version),
+		TenantID:   value.Tenant.ID.String(),
+		URL:        value.URL,
+		Secret:     value.Secret,
+		EventTypes: string(mustMarshal(value.EventTypes)),
+		CreatedAt:  value.CreatedAt,
+		UpdatedAt:  value.UpdatedAt,
+		DeletedAt:  value.DeletedAt,
+	}
+}
+
+func (s WebhookSubscription) ToDomain() domain.WebhookSubscription {
+	eventTypes := make([]string, 0)
+	if err := json.Unmarshal([]byte(s.EventTypes), &eventTypes); err != nil {
+		slog.Error("failed to unmarshal webhook event types", slog.String("subscription_id", s.ID), slog.Any("error", err))
+	}
+
+	return domain.WebhookSubscription{
+		ID:         domain.ID(s.ID),
+		Version:    domain.Version(s.Version),
+		Tenant:     domain.Tenant{ID: domain.ID(s.TenantID)},
+		URL:        s.URL,
+		Secret:     s.Secret,
+		EventTypes: eventTypes,
+		CreatedAt:  s.CreatedAt,
+		UpdatedAt:  s.UpdatedAt,
+		DeletedAt:  s.DeletedAt,
+	}
+}
+
+type WebhookDelivery struct {
+	ID             string      `json:"id" gorm:"primaryKey"`
+	SubscriptionID string      `json:"subscription_id" gorm:"uniqueIndex:idx_webhook_deliveries_subscription_event"`
+	TenantID       string      `json:"tenant_id" gorm:"index"`
+	EventID        string      `json:"event_id" gorm:"uniqueIndex:idx_webhook_deliveries_subscription_event"`
+	EventType      string      `json:"event_type"`
+	Payload        []byte      `json:"payload"`
+	Status         string      `json:"status" gorm:"index"`
+	Attempts       int         `json:"attempts"`
+	NextAttemptAt  time.Time   `json:"next_attempt_at" gorm:"index"`
+	LastStatusCode int         `json:"last_status_code"`
+	LastError      string      `json:"last_error"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+	DeliveredAt    *utils.Time `json:"delivered_at,omitempty"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+func FromWebhookDelivery(value domain.WebhookDelivery) WebhookDelivery {
+	return WebhookDelivery{
+		ID:             value.ID.String(),
+		SubscriptionID: value.SubscriptionID.String(),
+		TenantID:       value.TenantID.String(),
+		EventID:        value.EventID,
+		EventType:      value.EventType,
+		Payload:        value.Payload,
+		Status:         string(value.Status),
+		Attempts:       value.Attempts,
+		NextAttemptAt:  value.NextAttemptAt.Time,
+		LastStatusCode: value.LastStatusCode,
+		LastError:      value.LastError,
+		CreatedAt:      value.CreatedAt.Time,
+		UpdatedAt:      value.UpdatedAt.Time,
+		DeliveredAt:    value.DeliveredAt,
+	}
+}
+
+func (d WebhookDelivery) ToDomain() domain.WebhookDelivery {
+	return domain.WebhookDelivery{
+		ID:             domain.ID(d.ID),
+		SubscriptionID: domain.ID(d.SubscriptionID),
+		TenantID:       domain.ID(d.TenantID),
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        d.Payload,
+		Status:         domain.WebhookDeliveryStatus(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  utils.Time{Time: d.NextAttemptAt},
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      utils.Time{Time: d.CreatedAt},
+		UpdatedAt:      utils.Time{Time: d.UpdatedAt},
+		DeliveredAt:    d.DeliveredAt,
+	}
+}
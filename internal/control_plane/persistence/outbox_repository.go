@@ -0,0 +1,194 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"zensor-server/internal/control_plane/persistence/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+func NewOutboxRepository(orm sql.ORM) (*SimpleOutboxRepository, error) {
+	err := orm.AutoMigrate(&internal.OutboxEvent{}, &internal.OutboxConsumption{})
+	if err != nil {
+		return nil, fmt.Errorf("auto migrating: %w", err)
+	}
+
+	return &SimpleOutboxRepository{
+		orm: orm,
+	}, nil
+}
+
+var _ usecases.OutboxRepository = (*SimpleOutboxRepository)(nil)
+
+type SimpleOutboxRepository struct {
+	orm sql.ORM
+}
+
+func (r *SimpleOutboxRepository) Append(ctx context.Context, event domain.OutboxEvent) error {
+	return appendOutboxEvent(r.orm.WithContext(ctx), event)
+}
+
+// appendOutboxEvent inserts the event with orm, which may be a transaction shared with the state
+// change the event announces.
+func appendOutboxEvent(orm sql.ORM, event domain.OutboxEvent) error {
+	entity := internal.FromOutboxEvent(event)
+
+	err := orm.Create(&entity).Error()
+	if err != nil {
+		return fmt.Errorf("creating outbox event in database: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SimpleOutboxRepository) FindPending(ctx context.Context, redeliverBefore time.Time, maxAttempts, limit int) ([]domain.OutboxEvent, error) {
+	var entities []internal.OutboxEvent
+	err := r.orm.
+		WithContext(ctx).
+		Where("acked_at IS NULL AND attempts < ?", maxAttempts).
+		Where("published_at IS NULL OR published_at < ?", redeliverBefore).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, fmt.Errorf("database query: %w", err)
+	}
+
+	result := make([]domain.OutboxEvent, len(entities))
+	for i, entity := range entities {
+		result[i] = entity.ToDomain()
+	}
+
+	return result, nil
+}
+
+func (r *SimpleOutboxRepository) MarkPublished(ctx context.Context, event domain.OutboxEvent, at time.Time) error {
+	err := r.orm.
+		WithContext(ctx).
+		Model(&internal.OutboxEvent{}).
+		Where("id = ?", event.ID.String()).
+		Updates(map[string]any{
+			"published_at": utils.Time{Time: at},
+			"attempts":     event.Attempts + 1,
+		}).
+		Error()
+	if err != nil {
+		return fmt.Errorf("marking outbox event as published: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimProcessing inserts the consumption unless it exists, so concurrent claims of the same event
+// by replicas resolve in the database and only one of them succeeds.
+func (r *SimpleOutboxRepository) ClaimProcessing(ctx context.Context, consumer string, eventID domain.ID, at time.Time) (bool, error) {
+	consumption := internal.OutboxConsumption{
+		Consumer:    consumer,
+		EventID:     eventID.String(),
+		ProcessedAt: at,
+	}
+	result := r.orm.
+		WithContext(ctx).
+		OnConflictDoNothing().
+		Create(&consumption)
+	if err := result.Error(); err != nil {
+		return false, fmt.Errorf("claiming outbox event: %w", err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+func (r *SimpleOutboxRepository) ReleaseProcessing(ctx context.Context, consumer string, eventID domain.ID) error {
+	err := r.orm.
+		WithContext(ctx).
+		Where("consumer = ? AND event_id = ?", consumer, eventID.String()).
+		Delete(&internal.OutboxConsumption{}).
+		Error()
+	if err != nil {
+		return fmt.Errorf("releasing outbox event: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SimpleOutboxRepository) MarkProcessed(ctx context.Context, consumer string, eventID domain.ID, at time.Time) error {
+	return r.orm.WithContext(ctx).Transaction(func(tx sql.ORM) error {
+		consumption := internal.OutboxConsumption{
+			Consumer:    consumer,
+			EventID:     eventID.String(),
+			ProcessedAt: at,
+		}
+		if err := tx.Save(&consumption).Error(); err != nil {
+			return fmt.Errorf("recording outbox event consumption: %w", err)
+		}
+
+		var event internal.OutboxEvent
+		err := tx.Where("id = ?", eventID.String()).First(&event).Error()
+		if errors.Is(err, sql.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("getting outbox event: %w", err)
+		}
+
+		// Events stay pending, and are redelivered, until every consumer of their type
+		// processed them.
+		if consumers := usecases.OutboxEventConsumers(event.Event); len(consumers) > 0 {
+			var processed int64
+			err := tx.
+				Model(&internal.OutboxConsumption{}).
+				Where("event_id = ? AND consumer IN ?", eventID.String(), consumers).
+				Count(&processed).
+				Error()
+			if err != nil {
+				return fmt.Errorf("counting outbox event consumptions: %w", err)
+			}
+			if int(processed) < len(consumers) {
+				return nil
+			}
+		}
+
+		err = tx.
+			Model(&internal.OutboxEvent{}).
+			Where("id = ? AND acked_at IS NULL", eventID.String()).
+			Updates(map[string]any{"acked_at": utils.Time{Time: at}}).
+			Error()
+		if err != nil {
+			return fmt.Errorf("acknowledging outbox event: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *SimpleOutboxRepository) DeleteAcknowledgedBefore(ctx context.Context, before time.Time) (int, error) {
+	var deleted int64
+	err := r.orm.WithContext(ctx).Transaction(func(tx sql.ORM) error {
+		err := tx.
+			Where("event_id IN (SELECT id FROM outbox_events WHERE acked_at < ?)", before).
+			Delete(&internal.OutboxConsumption{}).
+			Error()
+		if err != nil {
+			return fmt.Errorf("deleting outbox event consumptions: %w", err)
+		}
+
+		result := tx.Where("acked_at < ?", before).Delete(&internal.OutboxEvent{})
+		if err := result.Error(); err != nil {
+			return fmt.Errorf("deleting outbox events: %w", err)
+		}
+		deleted = result.RowsAffected()
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(deleted), nil
+}
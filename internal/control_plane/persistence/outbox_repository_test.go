@@ -0,0 +1,166 @@
+package persistence_test
+
+import (
+	"context"
+	"time"
+	"zensor-server/internal/control_plane/persistence"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("OutboxRepository", func() {
+	var (
+		repo *persistence.SimpleOutboxRepository
+		ctx  context.Context
+		now  time.Time
+	)
+
+	ginkgo.BeforeEach(func() {
+		orm, err := sql.NewMemoryORM("migrations")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		repo, err = persistence.NewOutboxRepository(orm)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		ctx = context.Background()
+		now = time.Now().UTC()
+	})
+
+	appendEvent := func() domain.OutboxEvent {
+		event, err := domain.NewOutboxEvent("devices/device-1/command_status", "command_status_update",
+			domain.CommandStatusUpdate{CommandID: "command-1", Status: domain.CommandStatusAck}, now)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(repo.Append(ctx, event)).To(gomega.Succeed())
+		return event
+	}
+
+	pendingIDs := func(redeliverBefore time.Time, maxAttempts int) []domain.ID {
+		events, err := repo.FindPending(ctx, redeliverBefore, maxAttempts, 1000)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		ids := make([]domain.ID, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+		return ids
+	}
+
+	ginkgo.Context("FindPending", func() {
+		ginkgo.It("should return events never published with their payload", func() {
+			event := appendEvent()
+
+			events, err := repo.FindPending(ctx, now, 5, 1000)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(events).To(gomega.ContainElement(gomega.SatisfyAll(
+				gomega.HaveField("ID", event.ID),
+				gomega.HaveField("Payload", gomega.MatchJSON(event.Payload)),
+			)))
+		})
+
+		ginkgo.It("should hold published events back until the redelivery deadline", func() {
+			event := appendEvent()
+			gomega.Expect(repo.MarkPublished(ctx, event, now)).To(gomega.Succeed())
+
+			gomega.Expect(pendingIDs(now.Add(-time.Minute), 5)).NotTo(gomega.ContainElement(event.ID))
+			gomega.Expect(pendingIDs(now.Add(time.Minute), 5)).To(gomega.ContainElement(event.ID))
+		})
+
+		ginkgo.It("should give up on events out of attempts", func() {
+			event := appendEvent()
+			gomega.Expect(repo.MarkPublished(ctx, event, now)).To(gomega.Succeed())
+
+			gomega.Expect(pendingIDs(now.Add(time.Minute), 1)).NotTo(gomega.ContainElement(event.ID))
+		})
+	})
+
+	claim := func(consumer string, eventID domain.ID) bool {
+		claimed, err := repo.ClaimProcessing(ctx, consumer, eventID, now)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		return claimed
+	}
+
+	ginkgo.Context("ClaimProcessing", func() {
+		ginkgo.It("should let a single claim of the consumer succeed", func() {
+			event := appendEvent()
+
+			gomega.Expect(claim("command_worker", event.ID)).To(gomega.BeTrue())
+			gomega.Expect(claim("command_worker", event.ID)).To(gomega.BeFalse())
+			gomega.Expect(claim("mqtt_bridge", event.ID)).To(gomega.BeTrue())
+			gomega.Expect(pendingIDs(now.Add(time.Minute), 5)).To(gomega.ContainElement(event.ID))
+		})
+
+		ginkgo.It("should accept a new claim once released", func() {
+			event := appendEvent()
+
+			gomega.Expect(claim("command_worker", event.ID)).To(gomega.BeTrue())
+			gomega.Expect(repo.ReleaseProcessing(ctx, "command_worker", event.ID)).To(gomega.Succeed())
+
+			gomega.Expect(claim("command_worker", event.ID)).To(gomega.BeTrue())
+		})
+	})
+
+	ginkgo.Context("MarkProcessed", func() {
+		ginkgo.It("should record the consumer and acknowledge the event", func() {
+			event := appendEvent()
+
+			gomega.Expect(claim("command_worker", event.ID)).To(gomega.BeTrue())
+			gomega.Expect(repo.MarkProcessed(ctx, "command_worker", event.ID, now)).To(gomega.Succeed())
+			gomega.Expect(repo.MarkProcessed(ctx, "command_worker", event.ID, now)).To(gomega.Succeed())
+
+			gomega.Expect(claim("command_worker", event.ID)).To(gomega.BeFalse())
+			gomega.Expect(pendingIDs(now.Add(time.Minute), 5)).NotTo(gomega.ContainElement(event.ID))
+		})
+
+		ginkgo.It("should keep the event pending until every consumer of its type processed it", func() {
+			event := appendEvent()
+
+			gomega.Expect(repo.MarkProcessed(ctx, "mqtt_bridge", event.ID, now)).To(gomega.Succeed())
+
+			gomega.Expect(claim("mqtt_bridge", event.ID)).To(gomega.BeFalse())
+			gomega.Expect(pendingIDs(now.Add(time.Minute), 5)).To(gomega.ContainElement(event.ID))
+
+			gomega.Expect(repo.MarkProcessed(ctx, "command_worker", event.ID, now)).To(gomega.Succeed())
+
+			gomega.Expect(pendingIDs(now.Add(time.Minute), 5)).NotTo(gomega.ContainElement(event.ID))
+		})
+
+		ginkgo.It("should acknowledge events without registered consumers on the first consumption", func() {
+			event, err := domain.NewOutboxEvent("devices/device-1/custom", "custom_event", map[string]any{}, now)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(repo.Append(ctx, event)).To(gomega.Succeed())
+
+			gomega.Expect(repo.MarkProcessed(ctx, "custom_worker", event.ID, now)).To(gomega.Succeed())
+
+			gomega.Expect(pendingIDs(now.Add(time.Minute), 5)).NotTo(gomega.ContainElement(event.ID))
+		})
+	})
+
+	ginkgo.Context("DeleteAcknowledgedBefore", func() {
+		ginkgo.It("should purge acknowledged events and their consumptions only", func() {
+			acknowledged := appendEvent()
+			pending := appendEvent()
+			gomega.Expect(repo.MarkProcessed(ctx, "command_worker", acknowledged.ID, now.Add(-2*time.Hour))).To(gomega.Succeed())
+
+			deleted, err := repo.DeleteAcknowledgedBefore(ctx, now.Add(-time.Hour))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(deleted).To(gomega.BeNumerically(">=", 1))
+
+			gomega.Expect(claim("command_worker", acknowledged.ID)).To(gomega.BeTrue())
+			gomega.Expect(pendingIDs(now.Add(time.Minute), 5)).To(gomega.ContainElement(pending.ID))
+		})
+
+		ginkgo.It("should keep events that a consumer of their type did not process", func() {
+			partial := appendEvent()
+			gomega.Expect(repo.MarkProcessed(ctx, "mqtt_bridge", partial.ID, now.Add(-2*time.Hour))).To(gomega.Succeed())
+
+			_, err := repo.DeleteAcknowledgedBefore(ctx, now.Add(-time.Hour))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			gomega.Expect(claim("mqtt_bridge", partial.ID)).To(gomega.BeFalse())
+			gomega.Expect(pendingIDs(now.Add(time.Minute), 5)).To(gomega.ContainElement(partial.ID))
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 	"zensor-server/internal/control_plane/persistence/internal"
 	"zensor-server/internal/control_plane/usecases"
@@ -13,7 +14,7 @@ import (
 )
 
 func NewScheduledTaskRepository(orm sql.ORM) (*SimpleScheduledTaskRepository, error) {
-	err := orm.AutoMigrate(&internal.ScheduledTask{})
+	err := orm.AutoMigrate(&internal.ScheduledTask{}, &internal.OutboxEvent{})
 	if err != nil {
 		return nil, fmt.Errorf("auto migrating: %w", err)
 	}
@@ -51,9 +52,9 @@ func (r *SimpleScheduledTaskRepository) FindAllByTenant(ctx context.Context, ten
 		return nil, fmt.Errorf("database query: %w", err)
 	}
 
-	result := make([]domain.ScheduledTask, len(entities))
-	for i, entity := range entities {
-		result[i] = entity.ToDomain()
+	result, err := toScheduledTasks(entities)
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -62,12 +63,13 @@ func (r *SimpleScheduledTaskRepository) FindAllByTenant(ctx context.Context, ten
 func (r *SimpleScheduledTaskRepository) FindAllByTenantAndDevice(ctx context.Context, tenantID domain.ID, deviceID domain.ID, pagination usecases.Pagination) ([]domain.ScheduledTask, int, error) {
 	var entities []internal.ScheduledTask
 	var total int64
+	targetPattern := `%"` + escapeLike(deviceID.String()) + `"%`
 
 	// Get total count
 	err := r.orm.
 		WithContext(ctx).
 		Model(&internal.ScheduledTask{}).
-		Where("tenant_id = ? AND device_id = ? AND deleted_at IS NULL", tenantID.String(), deviceID.String()).
+		Where(`tenant_id = ? AND (device_id = ? OR device_ids LIKE ? ESCAPE '\') AND deleted_at IS NULL`, tenantID.String(), deviceID.String(), targetPattern).
 		Count(&total).
 		Error()
 	if err != nil {
@@ -77,7 +79,7 @@ func (r *SimpleScheduledTaskRepository) FindAllByTenantAndDevice(ctx context.Con
 	// Get paginated results
 	err = r.orm.
 		WithContext(ctx).
-		Where("tenant_id = ? AND device_id = ? AND deleted_at IS NULL", tenantID.String(), deviceID.String()).
+		Where(`tenant_id = ? AND (device_id = ? OR device_ids LIKE ? ESCAPE '\') AND deleted_at IS NULL`, tenantID.String(), deviceID.String(), targetPattern).
 		Limit(pagination.Limit).
 		Offset(pagination.Offset).
 		Find(&entities).
@@ -86,14 +88,50 @@ func (r *SimpleScheduledTaskRepository) FindAllByTenantAndDevice(ctx context.Con
 		return nil, 0, fmt.Errorf("database query: %w", err)
 	}
 
-	result := make([]domain.ScheduledTask, len(entities))
-	for i, entity := range entities {
-		result[i] = entity.ToDomain()
+	result, err := toScheduledTasks(entities)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	return result, int(total), nil
 }
 
+func (r *SimpleScheduledTaskRepository) FindAllByDevice(ctx context.Context, deviceID domain.ID) ([]domain.ScheduledTask, error) {
+	var entities []internal.ScheduledTask
+	targetPattern := `%"` + escapeLike(deviceID.String()) + `"%`
+
+	err := r.orm.
+		WithContext(ctx).
+		Where(`(device_id = ? OR device_ids LIKE ? ESCAPE '\') AND deleted_at IS NULL`, deviceID.String(), targetPattern).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, fmt.Errorf("database query: %w", err)
+	}
+
+	result, err := toScheduledTasks(entities)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (r *SimpleScheduledTaskRepository) CountByCommandTemplateSet(ctx context.Context, setID domain.ID) (int, error) {
+	var total int64
+	err := r.orm.
+		WithContext(ctx).
+		Model(&internal.ScheduledTask{}).
+		Where("command_template_set_id = ? AND deleted_at IS NULL", setID.String()).
+		Count(&total).
+		Error()
+	if err != nil {
+		return 0, fmt.Errorf("counting scheduled tasks: %w", err)
+	}
+
+	return int(total), nil
+}
+
 func (r *SimpleScheduledTaskRepository) FindAllActive(ctx context.Context) ([]domain.ScheduledTask, error) {
 	var entities []internal.ScheduledTask
 	err := r.orm.
@@ -105,9 +143,17 @@ func (r *SimpleScheduledTaskRepository) FindAllActive(ctx context.Context) ([]do
 		return nil, fmt.Errorf("database query: %w", err)
 	}
 
-	result := make([]domain.ScheduledTask, len(entities))
-	for i, entity := range entities {
-		result[i] = entity.ToDomain()
+	// A single row that cannot be decoded must not stop every other schedule from running
+	result := make([]domain.ScheduledTask, 0, len(entities))
+	for _, entity := range entities {
+		scheduledTask, err := entity.ToDomain()
+		if err != nil {
+			slog.Error("skipping scheduled task that cannot be decoded",
+				slog.String("scheduled_task_id", entity.ID),
+				slog.Any("error", err))
+			continue
+		}
+		result = append(result, scheduledTask)
 	}
 
 	return result, nil
@@ -127,6 +173,21 @@ func (r *SimpleScheduledTaskRepository) Update(ctx context.Context, scheduledTas
 	return nil
 }
 
+func (r *SimpleScheduledTaskRepository) RecordExecution(ctx context.Context, scheduledTask domain.ScheduledTask, event domain.OutboxEvent) error {
+	scheduledTask.Version++
+	scheduledTask.UpdatedAt = utils.Time{Time: time.Now()}
+
+	entity := internal.FromScheduledTask(scheduledTask)
+
+	return r.orm.WithContext(ctx).Transaction(func(tx sql.ORM) error {
+		if err := tx.Save(&entity).Error(); err != nil {
+			return fmt.Errorf("updating scheduled task in database: %w", err)
+		}
+
+		return appendOutboxEvent(tx, event)
+	})
+}
+
 func (r *SimpleScheduledTaskRepository) Delete(ctx context.Context, id domain.ID) error {
 	scheduledTask, err := r.GetByID(ctx, id)
 	if err != nil {
@@ -163,5 +224,18 @@ func (r *SimpleScheduledTaskRepository) GetByID(ctx context.Context, id domain.I
 		return domain.ScheduledTask{}, err
 	}
 
-	return entity.ToDomain(), nil
+	return entity.ToDomain()
+}
+
+func toScheduledTasks(entities []internal.ScheduledTask) ([]domain.ScheduledTask, error) {
+	result := make([]domain.ScheduledTask, len(entities))
+	for i, entity := range entities {
+		scheduledTask, err := entity.ToDomain()
+		if err != nil {
+			return nil, err
+		}
+		result[i] = scheduledTask
+	}
+
+	return result, nil
 }
@@ -2,7 +2,10 @@ package persistence_test
 
 import (
 	"context"
+	"strings"
+	"time"
 	"zensor-server/internal/control_plane/persistence"
+	"zensor-server/internal/control_plane/persistence/internal"
 	"zensor-server/internal/control_plane/usecases"
 	"zensor-server/internal/infra/sql"
 	"zensor-server/internal/infra/utils"
@@ -86,6 +89,37 @@ var _ = ginkgo.Describe("ScheduledTaskRepository", func() {
 		})
 	})
 
+	ginkgo.Context("RecordExecution", func() {
+		ginkgo.It("should update the scheduled task and append the event to the outbox", func() {
+			scheduledTask := domain.ScheduledTask{
+				ID:       domain.ID(utils.GenerateUUID()),
+				Version:  1,
+				Tenant:   domain.Tenant{ID: domain.ID(utils.GenerateUUID())},
+				Device:   domain.Device{ID: domain.ID(utils.GenerateUUID())},
+				Schedule: "0 0 * * *",
+				IsActive: true,
+			}
+			gomega.Expect(repo.Create(ctx, scheduledTask)).To(gomega.Succeed())
+
+			executedAt := utils.Time{Time: time.Now()}
+			scheduledTask.LastExecutedAt = &executedAt
+			event, err := domain.NewOutboxEvent("scheduled_tasks", "scheduled_task_executed", scheduledTask, executedAt.Time)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			gomega.Expect(repo.RecordExecution(ctx, scheduledTask, event)).To(gomega.Succeed())
+
+			result, err := repo.GetByID(ctx, scheduledTask.ID)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(result.LastExecutedAt).NotTo(gomega.BeNil())
+
+			outbox, err := persistence.NewOutboxRepository(orm)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			pending, err := outbox.FindPending(ctx, time.Now(), 5, 1000)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(pending).To(gomega.ContainElement(gomega.HaveField("ID", event.ID)))
+		})
+	})
+
 	ginkgo.Context("Delete", func() {
 		var scheduledTask domain.ScheduledTask
 
@@ -118,5 +152,164 @@ var _ = ginkgo.Describe("ScheduledTaskRepository", func() {
 				gomega.Expect(err).To(gomega.MatchError(usecases.ErrScheduledTaskNotFound))
 			})
 		})
+
+		ginkgo.When("the stored targets cannot be decoded", func() {
+			ginkgo.It("should return an error instead of an empty scheduled task", func() {
+				id := domain.ID(utils.GenerateUUID())
+				gomega.Expect(repo.Create(ctx, domain.ScheduledTask{
+					ID:       id,
+					Version:  1,
+					Tenant:   domain.Tenant{ID: domain.ID(utils.GenerateUUID())},
+					Device:   domain.Device{ID: domain.ID(utils.GenerateUUID())},
+					Schedule: "0 0 * * *",
+					IsActive: true,
+				})).To(gomega.Succeed())
+				gomega.Expect(orm.WithContext(ctx).
+					Model(&internal.ScheduledTask{}).
+					Where("id = ?", id.String()).
+					Updates(map[string]any{"device_ids": "not json"}).
+					Error()).To(gomega.Succeed())
+
+				_, err := repo.GetByID(ctx, id)
+				gomega.Expect(err).To(gomega.HaveOccurred())
+				gomega.Expect(err).NotTo(gomega.MatchError(usecases.ErrScheduledTaskNotFound))
+			})
+		})
+	})
+
+	ginkgo.Context("FindAllByTenantAndDevice", func() {
+		var tenantID domain.ID
+		var owner, target domain.Device
+
+		ginkgo.BeforeEach(func() {
+			tenantID = domain.ID(utils.GenerateUUID())
+			owner = domain.Device{ID: domain.ID(utils.GenerateUUID())}
+			target = domain.Device{ID: domain.ID(utils.GenerateUUID())}
+
+			err := repo.Create(ctx, domain.ScheduledTask{
+				ID:                 domain.ID(utils.GenerateUUID()),
+				Version:            1,
+				Tenant:             domain.Tenant{ID: tenantID},
+				Device:             owner,
+				Devices:            []domain.Device{target},
+				CommandTemplateSet: &domain.CommandTemplateSet{ID: domain.ID("set-1")},
+				Schedule:           "0 0 * * *",
+				IsActive:           true,
+			})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.When("the device is an additional target", func() {
+			ginkgo.It("should return the scheduled task with its targets and template set", func() {
+				result, total, err := repo.FindAllByTenantAndDevice(ctx, tenantID, target.ID, usecases.Pagination{Limit: 10})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(total).To(gomega.Equal(1))
+				gomega.Expect(result[0].Device.ID).To(gomega.Equal(owner.ID))
+				gomega.Expect(result[0].Devices).To(gomega.ConsistOf(target))
+				gomega.Expect(result[0].CommandTemplateSet.ID).To(gomega.Equal(domain.ID("set-1")))
+			})
+		})
+
+		ginkgo.When("the device ID only matches part of a target ID", func() {
+			ginkgo.It("should return nothing", func() {
+				for _, id := range []string{target.ID.String()[:8], strings.Replace(target.ID.String(), "-", "_", 1)} {
+					_, total, err := repo.FindAllByTenantAndDevice(ctx, tenantID, domain.ID(id), usecases.Pagination{Limit: 10})
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					gomega.Expect(total).To(gomega.BeZero())
+				}
+			})
+		})
+
+		ginkgo.When("the device is not targeted", func() {
+			ginkgo.It("should return nothing", func() {
+				_, total, err := repo.FindAllByTenantAndDevice(ctx, tenantID, domain.ID(utils.GenerateUUID()), usecases.Pagination{Limit: 10})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(total).To(gomega.BeZero())
+			})
+		})
+	})
+
+	ginkgo.Context("FindAllByDevice", func() {
+		var owner, target domain.Device
+
+		ginkgo.BeforeEach(func() {
+			owner = domain.Device{ID: domain.ID(utils.GenerateUUID())}
+			target = domain.Device{ID: domain.ID(utils.GenerateUUID())}
+
+			for _, tenantID := range []domain.ID{domain.ID(utils.GenerateUUID()), domain.ID(utils.GenerateUUID())} {
+				err := repo.Create(ctx, domain.ScheduledTask{
+					ID:       domain.ID(utils.GenerateUUID()),
+					Version:  1,
+					Tenant:   domain.Tenant{ID: tenantID},
+					Device:   owner,
+					Devices:  []domain.Device{target},
+					Schedule: "0 0 * * *",
+					IsActive: true,
+				})
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			}
+		})
+
+		ginkgo.It("should return the scheduled tasks of every tenant owned by or targeting the device", func() {
+			result, err := repo.FindAllByDevice(ctx, owner.ID)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(result).To(gomega.HaveLen(2))
+
+			result, err = repo.FindAllByDevice(ctx, target.ID)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(result).To(gomega.HaveLen(2))
+		})
+	})
+
+	ginkgo.Context("FindAllActive", func() {
+		ginkgo.When("the stored targets of a scheduled task cannot be decoded", func() {
+			ginkgo.It("should skip it and return the other active scheduled tasks", func() {
+				corruptID := domain.ID(utils.GenerateUUID())
+				validID := domain.ID(utils.GenerateUUID())
+				for _, id := range []domain.ID{corruptID, validID} {
+					gomega.Expect(repo.Create(ctx, domain.ScheduledTask{
+						ID:       id,
+						Version:  1,
+						Tenant:   domain.Tenant{ID: domain.ID(utils.GenerateUUID())},
+						Device:   domain.Device{ID: domain.ID(utils.GenerateUUID())},
+						Schedule: "0 0 * * *",
+						IsActive: true,
+					})).To(gomega.Succeed())
+				}
+				gomega.Expect(orm.WithContext(ctx).
+					Model(&internal.ScheduledTask{}).
+					Where("id = ?", corruptID.String()).
+					Updates(map[string]any{"device_ids": "not json"}).
+					Error()).To(gomega.Succeed())
+
+				result, err := repo.FindAllActive(ctx)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				ids := make([]domain.ID, len(result))
+				for i, scheduledTask := range result {
+					ids[i] = scheduledTask.ID
+				}
+				gomega.Expect(ids).To(gomega.ContainElement(validID))
+				gomega.Expect(ids).NotTo(gomega.ContainElement(corruptID))
+			})
+		})
+	})
+
+	ginkgo.Context("CountByCommandTemplateSet", func() {
+		ginkgo.It("should count only scheduled tasks referencing the set", func() {
+			err := repo.Create(ctx, domain.ScheduledTask{
+				ID:                 domain.ID(utils.GenerateUUID()),
+				Version:            1,
+				Tenant:             domain.Tenant{ID: domain.ID(utils.GenerateUUID())},
+				Device:             domain.Device{ID: domain.ID(utils.GenerateUUID())},
+				CommandTemplateSet: &domain.CommandTemplateSet{ID: domain.ID("set-2")},
+				Schedule:           "0 0 * * *",
+			})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			total, err := repo.CountByCommandTemplateSet(ctx, domain.ID("set-2"))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(total).To(gomega.Equal(1))
+		})
 	})
 })
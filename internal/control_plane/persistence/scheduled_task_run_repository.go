@@ -0,0 +1,93 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"zensor-server/internal/control_plane/persistence/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+func NewScheduledTaskRunRepository(orm sql.ORM) (*SimpleScheduledTaskRunRepository, error) {
+	err := orm.AutoMigrate(&internal.ScheduledTaskRun{}, &internal.Command{})
+	if err != nil {
+		return nil, fmt.Errorf("auto migrating: %w", err)
+	}
+
+	return &SimpleScheduledTaskRunRepository{
+		orm: orm,
+	}, nil
+}
+
+var _ usecases.ScheduledTaskRunRepository = (*SimpleScheduledTaskRunRepository)(nil)
+
+type SimpleScheduledTaskRunRepository struct {
+	orm sql.ORM
+}
+
+func (r *SimpleScheduledTaskRunRepository) Create(ctx context.Context, run domain.ScheduledTaskRun) error {
+	entity := internal.FromScheduledTaskRun(run)
+
+	err := r.orm.WithContext(ctx).Create(&entity).Error()
+	if err != nil {
+		return fmt.Errorf("creating scheduled task run in database: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SimpleScheduledTaskRunRepository) FindAllByScheduledTask(ctx context.Context, scheduledTaskID domain.ID, filter usecases.ScheduledTaskRunFilter, pagination usecases.Pagination) ([]domain.ScheduledTaskRun, int, error) {
+	var total int64
+	err := r.filtered(r.orm.WithContext(ctx).Model(&internal.ScheduledTaskRun{}), scheduledTaskID, filter).
+		Count(&total).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("count query: %w", err)
+	}
+
+	var entities []internal.ScheduledTaskRun
+	err = r.filtered(r.orm.WithContext(ctx), scheduledTaskID, filter).
+		Preload("Commands").
+		Order("evaluated_at DESC").
+		Limit(pagination.Limit).
+		Offset(pagination.Offset).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("database query: %w", err)
+	}
+
+	runs := make([]domain.ScheduledTaskRun, len(entities))
+	for i, entity := range entities {
+		runs[i] = entity.ToDomain()
+	}
+
+	return runs, int(total), nil
+}
+
+func (r *SimpleScheduledTaskRunRepository) filtered(query sql.ORM, scheduledTaskID domain.ID, filter usecases.ScheduledTaskRunFilter) sql.ORM {
+	query = query.Where("scheduled_task_id = ?", scheduledTaskID.String())
+	if filter.From != nil {
+		query = query.Where("evaluated_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("evaluated_at < ?", *filter.To)
+	}
+
+	return query
+}
+
+func (r *SimpleScheduledTaskRunRepository) ReassignTenant(ctx context.Context, scheduledTaskID domain.ID, tenantID domain.ID) error {
+	err := r.orm.
+		WithContext(ctx).
+		Model(&internal.ScheduledTaskRun{}).
+		Where("scheduled_task_id = ?", scheduledTaskID.String()).
+		Updates(map[string]any{"tenant_id": tenantID.String()}).
+		Error()
+	if err != nil {
+		return fmt.Errorf("reassigning scheduled task runs: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package persistence_test
+
+import (
+	"context"
+	"time"
+	"zensor-server/internal/control_plane/persistence"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("ScheduledTaskRunRepository", func() {
+	var (
+		orm  sql.ORM
+		repo usecases.ScheduledTaskRunRepository
+		ctx  context.Context
+	)
+
+	ginkgo.BeforeEach(func() {
+		var err error
+		orm, err = sql.NewMemoryORM("migrations")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		repo, err = persistence.NewScheduledTaskRunRepository(orm)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(repo).NotTo(gomega.BeNil())
+
+		ctx = context.Background()
+	})
+
+	ginkgo.Context("FindAllByScheduledTask", func() {
+		var scheduledTask domain.ScheduledTask
+		var now time.Time
+		var taskID domain.ID
+
+		ginkgo.BeforeEach(func() {
+			now = time.Now().UTC()
+			scheduledTask = domain.ScheduledTask{
+				ID:     domain.ID(utils.GenerateUUID()),
+				Tenant: domain.Tenant{ID: domain.ID(utils.GenerateUUID())},
+				Device: domain.Device{ID: domain.ID(utils.GenerateUUID())},
+			}
+
+			for i, outcome := range []domain.ScheduledTaskRunOutcome{
+				domain.ScheduledTaskRunOutcomeFired,
+				domain.ScheduledTaskRunOutcomeSkipped,
+				domain.ScheduledTaskRunOutcomeFailed,
+			} {
+				builder := domain.NewScheduledTaskRunBuilder().
+					WithScheduledTask(scheduledTask).
+					WithOutcome(outcome).
+					WithEvaluatedAt(now.Add(-time.Duration(i) * time.Hour))
+				if outcome == domain.ScheduledTaskRunOutcomeFired {
+					taskID = domain.ID(utils.GenerateUUID())
+					builder = builder.WithTaskID(taskID)
+				}
+				run, err := builder.Build()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(repo.Create(ctx, run)).To(gomega.Succeed())
+			}
+		})
+
+		ginkgo.It("should return the most recent runs first", func() {
+			runs, total, err := repo.FindAllByScheduledTask(ctx, scheduledTask.ID, usecases.ScheduledTaskRunFilter{}, usecases.Pagination{Limit: 10})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(total).To(gomega.Equal(3))
+			gomega.Expect(runs).To(gomega.HaveLen(3))
+			gomega.Expect(runs[0].Outcome).To(gomega.Equal(domain.ScheduledTaskRunOutcomeFired))
+			gomega.Expect(runs[0].TaskID).NotTo(gomega.BeNil())
+			gomega.Expect(runs[2].Outcome).To(gomega.Equal(domain.ScheduledTaskRunOutcomeFailed))
+		})
+
+		ginkgo.It("should aggregate the outcome of the commands created by each run", func() {
+			commandRepo, err := persistence.NewCommandRepository(orm)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			for _, status := range []domain.CommandStatus{domain.CommandStatusAck, domain.CommandStatusAck} {
+				gomega.Expect(commandRepo.Create(ctx, domain.Command{
+					ID:      domain.ID(utils.GenerateUUID()),
+					Version: 1,
+					Device:  scheduledTask.Device,
+					Task:    domain.Task{ID: taskID},
+					Port:    domain.Port(15),
+					Status:  status,
+				})).To(gomega.Succeed())
+			}
+
+			runs, _, err := repo.FindAllByScheduledTask(ctx, scheduledTask.ID, usecases.ScheduledTaskRunFilter{}, usecases.Pagination{Limit: 10})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(runs).To(gomega.HaveLen(3))
+			gomega.Expect(runs[0].CommandOutcome).To(gomega.Equal(domain.CommandOutcomeSucceeded))
+			gomega.Expect(runs[1].CommandOutcome).To(gomega.Equal(domain.CommandOutcomeNone))
+		})
+
+		ginkgo.It("should only return runs evaluated within the date range", func() {
+			from := now.Add(-90 * time.Minute)
+			to := now.Add(-30 * time.Minute)
+
+			runs, total, err := repo.FindAllByScheduledTask(ctx, scheduledTask.ID, usecases.ScheduledTaskRunFilter{From: &from, To: &to}, usecases.Pagination{Limit: 10})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(total).To(gomega.Equal(1))
+			gomega.Expect(runs).To(gomega.HaveLen(1))
+			gomega.Expect(runs[0].Outcome).To(gomega.Equal(domain.ScheduledTaskRunOutcomeSkipped))
+		})
+	})
+})
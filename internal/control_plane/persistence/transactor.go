@@ -0,0 +1,26 @@
+package persistence
+
+import (
+	"context"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/sql"
+)
+
+func NewTransactor(orm sql.ORM) *SimpleTransactor {
+	return &SimpleTransactor{
+		orm: orm,
+	}
+}
+
+var _ usecases.Transactor = (*SimpleTransactor)(nil)
+
+type SimpleTransactor struct {
+	orm sql.ORM
+}
+
+// InTransaction runs the function in one database transaction, committed when it returns no error.
+func (t *SimpleTransactor) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.orm.WithContext(ctx).Transaction(func(tx sql.ORM) error {
+		return fn(sql.ContextWithTransaction(ctx, tx))
+	})
+}
@@ -0,0 +1,71 @@
+package persistence_test
+
+import (
+	"context"
+	"errors"
+	"zensor-server/internal/control_plane/persistence"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Transactor", func() {
+	var (
+		transactor  *persistence.SimpleTransactor
+		commandRepo *persistence.SimpleCommandRepository
+		ctx         context.Context
+		deviceID    domain.ID
+	)
+
+	ginkgo.BeforeEach(func() {
+		orm, err := sql.NewMemoryORM("migrations")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		commandRepo, err = persistence.NewCommandRepository(orm)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		transactor = persistence.NewTransactor(orm)
+		ctx = context.Background()
+
+		deviceID = domain.ID(utils.GenerateUUID())
+		gomega.Expect(commandRepo.Create(ctx, domain.Command{
+			ID:      domain.ID(utils.GenerateUUID()),
+			Version: 1,
+			Device:  domain.Device{ID: deviceID, Name: "transactor-device"},
+			Port:    domain.Port(15),
+			Status:  domain.CommandStatusPending,
+		})).To(gomega.Succeed())
+	})
+
+	cancelPending := func(ctx context.Context) error {
+		_, err := commandRepo.CancelPendingByDevice(ctx, deviceID, "device decommissioned")
+		return err
+	}
+
+	ginkgo.When("the function succeeds", func() {
+		ginkgo.It("should commit the writes of the repositories called with its context", func() {
+			gomega.Expect(transactor.InTransaction(ctx, cancelPending)).To(gomega.Succeed())
+
+			pending, err := commandRepo.FindPendingByDevice(ctx, deviceID)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(pending).To(gomega.BeEmpty())
+		})
+	})
+
+	ginkgo.When("the function fails", func() {
+		ginkgo.It("should roll the writes back and return the error", func() {
+			failure := errors.New("updating device")
+			err := transactor.InTransaction(ctx, func(ctx context.Context) error {
+				gomega.Expect(cancelPending(ctx)).To(gomega.Succeed())
+				return failure
+			})
+			gomega.Expect(err).To(gomega.MatchError(failure))
+
+			pending, err := commandRepo.FindPendingByDevice(ctx, deviceID)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(pending).To(gomega.HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,151 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"zensor-server/internal/control_plane/persistence/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+func NewWebhookDeliveryRepository(orm sql.ORM) (*SimpleWebhookDeliveryRepository, error) {
+	err := orm.AutoMigrate(&internal.WebhookDelivery{})
+	if err != nil {
+		return nil, fmt.Errorf("auto migrating: %w", err)
+	}
+
+	return &SimpleWebhookDeliveryRepository{
+		orm: orm,
+	}, nil
+}
+
+var _ usecases.WebhookDeliveryRepository = (*SimpleWebhookDeliveryRepository)(nil)
+
+type SimpleWebhookDeliveryRepository struct {
+	orm sql.ORM
+}
+
+func (r *SimpleWebhookDeliveryRepository) Create(ctx context.Context, delivery domain.WebhookDelivery) error {
+	var total int64
+	err := r.orm.
+		WithContext(ctx).
+		Model(&internal.WebhookDelivery{}).
+		Where("subscription_id = ? AND event_id = ?", delivery.SubscriptionID.String(), delivery.EventID).
+		Count(&total).
+		Error()
+	if err != nil {
+		return fmt.Errorf("count query: %w", err)
+	}
+	if total > 0 {
+		return usecases.ErrWebhookDeliveryDuplicated
+	}
+
+	entity := internal.FromWebhookDelivery(delivery)
+
+	err = r.orm.WithContext(ctx).Create(&entity).Error()
+	if err != nil {
+		return fmt.Errorf("creating webhook delivery in database: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SimpleWebhookDeliveryRepository) Update(ctx context.Context, delivery domain.WebhookDelivery) error {
+	entity := internal.FromWebhookDelivery(delivery)
+
+	err := r.orm.
+		WithContext(ctx).
+		Model(&internal.WebhookDelivery{}).
+		Where("id = ?", entity.ID).
+		Updates(map[string]any{
+			"status":           entity.Status,
+			"attempts":         entity.Attempts,
+			"next_attempt_at":  entity.NextAttemptAt,
+			"last_status_code": entity.LastStatusCode,
+			"last_error":       entity.LastError,
+			"updated_at":       entity.UpdatedAt,
+			"delivered_at":     entity.DeliveredAt,
+		}).
+		Error()
+	if err != nil {
+		return fmt.Errorf("updating webhook delivery in database: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SimpleWebhookDeliveryRepository) GetByID(ctx context.Context, id domain.ID) (domain.WebhookDelivery, error) {
+	var entity internal.WebhookDelivery
+	err := r.orm.
+		WithContext(ctx).
+		Where("id = ?", id.String()).
+		First(&entity).
+		Error()
+	if errors.Is(err, sql.ErrRecordNotFound) {
+		return domain.WebhookDelivery{}, usecases.ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return domain.WebhookDelivery{}, fmt.Errorf("database query: %w", err)
+	}
+
+	return entity.ToDomain(), nil
+}
+
+func (r *SimpleWebhookDeliveryRepository) FindAllBySubscription(ctx context.Context, subscriptionID domain.ID, filter usecases.WebhookDeliveryFilter, pagination usecases.Pagination) ([]domain.WebhookDelivery, int, error) {
+	var total int64
+	err := r.filtered(r.orm.WithContext(ctx).Model(&internal.WebhookDelivery{}), subscriptionID, filter).
+		Count(&total).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("count query: %w", err)
+	}
+
+	var entities []internal.WebhookDelivery
+	err = r.filtered(r.orm.WithContext(ctx), subscriptionID, filter).
+		Order("created_at DESC").
+		Limit(pagination.Limit).
+		Offset(pagination.Offset).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("database query: %w", err)
+	}
+
+	return toWebhookDeliveries(entities), int(total), nil
+}
+
+func (r *SimpleWebhookDeliveryRepository) filtered(query sql.ORM, subscriptionID domain.ID, filter usecases.WebhookDeliveryFilter) sql.ORM {
+	query = query.Where("subscription_id = ?", subscriptionID.String())
+	if filter.Status != nil {
+		query = query.Where("status = ?", string(*filter.Status))
+	}
+
+	return query
+}
+
+func (r *SimpleWebhookDeliveryRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	var entities []internal.WebhookDelivery
+	err := r.orm.
+		WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", string(domain.WebhookDeliveryStatusPending), now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, fmt.Errorf("database query: %w", err)
+	}
+
+	return toWebhookDeliveries(entities), nil
+}
+
+func toWebhookDeliveries(entities []internal.WebhookDelivery) []domain.WebhookDelivery {
+	result := make([]domain.WebhookDelivery, len(entities))
+	for i, entity := range entities {
+		result[i] = entity.ToDomain()
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package persistence_test
+
+import (
+	"context"
+	"time"
+	"zensor-server/internal/control_plane/persistence"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("WebhookDeliveryRepository", func() {
+	var (
+		repo         usecases.WebhookDeliveryRepository
+		ctx          context.Context
+		now          time.Time
+		subscription domain.WebhookSubscription
+	)
+
+	newDelivery := func(eventID string) domain.WebhookDelivery {
+		return domain.NewWebhookDelivery(subscription, eventID, "uplink", []byte(`{"type":"uplink"}`), now)
+	}
+
+	ginkgo.BeforeEach(func() {
+		orm, err := sql.NewMemoryORM("migrations")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		repo, err = persistence.NewWebhookDeliveryRepository(orm)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		ctx = context.Background()
+		now = time.Now().UTC().Truncate(time.Second)
+		subscription = domain.WebhookSubscription{
+			ID:     domain.ID(utils.GenerateUUID()),
+			Tenant: domain.Tenant{ID: domain.ID(utils.GenerateUUID())},
+		}
+	})
+
+	ginkgo.It("should create a delivery once per subscription and event", func() {
+		delivery := newDelivery("event-1")
+		gomega.Expect(repo.Create(ctx, delivery)).To(gomega.Succeed())
+
+		gomega.Expect(repo.Create(ctx, newDelivery("event-1"))).To(gomega.MatchError(usecases.ErrWebhookDeliveryDuplicated))
+
+		result, err := repo.GetByID(ctx, delivery.ID)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(result.Payload).To(gomega.Equal(delivery.Payload))
+		gomega.Expect(result.Status).To(gomega.Equal(domain.WebhookDeliveryStatusPending))
+	})
+
+	ginkgo.It("should return ErrWebhookDeliveryNotFound for unknown deliveries", func() {
+		_, err := repo.GetByID(ctx, domain.ID(utils.GenerateUUID()))
+		gomega.Expect(err).To(gomega.MatchError(usecases.ErrWebhookDeliveryNotFound))
+	})
+
+	ginkgo.It("should find the pending deliveries that are due", func() {
+		due := newDelivery("event-due")
+		gomega.Expect(repo.Create(ctx, due)).To(gomega.Succeed())
+
+		later := newDelivery("event-later")
+		later.RecordFailure(500, "unexpected status 500", domain.WebhookRetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour}, now)
+		gomega.Expect(repo.Create(ctx, later)).To(gomega.Succeed())
+
+		delivered := newDelivery("event-delivered")
+		delivered.RecordSuccess(200, now)
+		gomega.Expect(repo.Create(ctx, delivered)).To(gomega.Succeed())
+
+		result, err := repo.FindDue(ctx, now.Add(time.Minute), 100)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		ids := make([]domain.ID, 0, len(result))
+		for _, delivery := range result {
+			ids = append(ids, delivery.ID)
+		}
+		gomega.Expect(ids).To(gomega.ContainElement(due.ID))
+		gomega.Expect(ids).NotTo(gomega.ContainElement(later.ID))
+		gomega.Expect(ids).NotTo(gomega.ContainElement(delivered.ID))
+	})
+
+	ginkgo.It("should update attempts and filter the deliveries of a subscription by status", func() {
+		dead := newDelivery("event-dead")
+		gomega.Expect(repo.Create(ctx, dead)).To(gomega.Succeed())
+		gomega.Expect(repo.Create(ctx, newDelivery("event-pending"))).To(gomega.Succeed())
+
+		dead.RecordFailure(0, "connection refused", domain.WebhookRetryPolicy{MaxAttempts: 1}, now)
+		gomega.Expect(repo.Update(ctx, dead)).To(gomega.Succeed())
+
+		status := domain.WebhookDeliveryStatusDead
+		result, total, err := repo.FindAllBySubscription(ctx, subscription.ID, usecases.WebhookDeliveryFilter{Status: &status}, usecases.Pagination{Limit: 10})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(total).To(gomega.Equal(1))
+		gomega.Expect(result[0].ID).To(gomega.Equal(dead.ID))
+		gomega.Expect(result[0].Attempts).To(gomega.Equal(1))
+		gomega.Expect(result[0].LastError).To(gomega.Equal("connection refused"))
+
+		_, total, err = repo.FindAllBySubscription(ctx, subscription.ID, usecases.WebhookDeliveryFilter{}, usecases.Pagination{Limit: 10})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(total).To(gomega.Equal(2))
+	})
+})
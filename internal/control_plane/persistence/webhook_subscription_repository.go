@@ -0,0 +1,222 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"zensor-server/internal/control_plane/persistence/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/secrets"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+func NewWebhookSubscriptionRepository(orm sql.ORM, cipher secrets.Cipher) (*SimpleWebhookSubscriptionRepository, error) {
+	err := orm.AutoMigrate(&internal.WebhookSubscription{})
+	if err != nil {
+		return nil, fmt.Errorf("auto migrating: %w", err)
+	}
+
+	return &SimpleWebhookSubscriptionRepository{
+		orm:    orm,
+		cipher: cipher,
+	}, nil
+}
+
+var _ usecases.WebhookSubscriptionRepository = (*SimpleWebhookSubscriptionRepository)(nil)
+
+// SimpleWebhookSubscriptionRepository stores the signing secret of subscriptions sealed with the
+// cipher, bound to the subscription ID, and opens it on read.
+type SimpleWebhookSubscriptionRepository struct {
+	orm    sql.ORM
+	cipher secrets.Cipher
+}
+
+func (r *SimpleWebhookSubscriptionRepository) Create(ctx context.Context, subscription domain.WebhookSubscription) error {
+	entity, err := r.toEntity(subscription)
+	if err != nil {
+		return err
+	}
+
+	err = r.orm.WithContext(ctx).Create(&entity).Error()
+	if err != nil {
+		return fmt.Errorf("creating webhook subscription in database: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SimpleWebhookSubscriptionRepository) Update(ctx context.Context, subscription domain.WebhookSubscription) error {
+	subscription.Version++
+	subscription.UpdatedAt = utils.Time{Time: time.Now()}
+
+	entity, err := r.toEntity(subscription)
+	if err != nil {
+		return err
+	}
+
+	err = r.orm.WithContext(ctx).Save(&entity).Error()
+	if err != nil {
+		return fmt.Errorf("updating webhook subscription in database: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SimpleWebhookSubscriptionRepository) GetByID(ctx context.Context, id domain.ID) (domain.WebhookSubscription, error) {
+	var entity internal.WebhookSubscription
+	err := r.orm.
+		WithContext(ctx).
+		Where("id = ? AND deleted_at IS NULL", id.String()).
+		First(&entity).
+		Error()
+	if errors.Is(err, sql.ErrRecordNotFound) {
+		return domain.WebhookSubscription{}, usecases.ErrWebhookSubscriptionNotFound
+	}
+	if err != nil {
+		return domain.WebhookSubscription{}, fmt.Errorf("database query: %w", err)
+	}
+
+	return r.toDomain(entity)
+}
+
+func (r *SimpleWebhookSubscriptionRepository) FindAllByTenant(ctx context.Context, tenantID domain.ID, pagination usecases.Pagination) ([]domain.WebhookSubscription, int, error) {
+	var total int64
+	err := r.orm.
+		WithContext(ctx).
+		Model(&internal.WebhookSubscription{}).
+		Where("tenant_id = ? AND deleted_at IS NULL", tenantID.String()).
+		Count(&total).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("counting webhook subscriptions: %w", err)
+	}
+
+	var entities []internal.WebhookSubscription
+	err = r.orm.
+		WithContext(ctx).
+		Where("tenant_id = ? AND deleted_at IS NULL", tenantID.String()).
+		Order("created_at ASC").
+		Limit(pagination.Limit).
+		Offset(pagination.Offset).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, 0, fmt.Errorf("database query: %w", err)
+	}
+
+	result, err := r.toDomains(entities)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return result, int(total), nil
+}
+
+func (r *SimpleWebhookSubscriptionRepository) FindActiveByTenant(ctx context.Context, tenantID domain.ID) ([]domain.WebhookSubscription, error) {
+	var entities []internal.WebhookSubscription
+	err := r.orm.
+		WithContext(ctx).
+		Where("tenant_id = ? AND deleted_at IS NULL", tenantID.String()).
+		Order("created_at ASC").
+		Find(&entities).
+		Error()
+	if err != nil {
+		return nil, fmt.Errorf("database query: %w", err)
+	}
+
+	return r.toDomains(entities)
+}
+
+func (r *SimpleWebhookSubscriptionRepository) Delete(ctx context.Context, id domain.ID) error {
+	subscription, err := r.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("getting webhook subscription for deletion: %w", err)
+	}
+
+	subscription.SoftDelete()
+	subscription.Version++
+
+	entity, err := r.toEntity(subscription)
+	if err != nil {
+		return err
+	}
+
+	err = r.orm.WithContext(ctx).Save(&entity).Error()
+	if err != nil {
+		return fmt.Errorf("deleting webhook subscription in database: %w", err)
+	}
+
+	return nil
+}
+
+// ResealSecrets rewraps the secrets sealed with a retired key, deleted subscriptions included. It
+// handles up to limit subscriptions and returns how many it resealed.
+func (r *SimpleWebhookSubscriptionRepository) ResealSecrets(ctx context.Context, limit int) (int, error) {
+	var entities []internal.WebhookSubscription
+	err := r.orm.
+		WithContext(ctx).
+		Where(`secret NOT LIKE ? ESCAPE '\'`, escapeLike(secrets.SealedPrefix(r.cipher.PrimaryKeyID()))+"%").
+		Order("id").
+		Limit(limit).
+		Find(&entities).
+		Error()
+	if err != nil {
+		return 0, fmt.Errorf("database query: %w", err)
+	}
+
+	for i, entity := range entities {
+		sealed, err := r.cipher.Rewrap(entity.Secret)
+		if err != nil {
+			return i, fmt.Errorf("rewrapping secret of webhook subscription %s: %w", entity.ID, err)
+		}
+
+		err = r.orm.
+			WithContext(ctx).
+			Model(&internal.WebhookSubscription{}).
+			Where("id = ? AND secret = ?", entity.ID, entity.Secret).
+			Updates(map[string]any{"secret": sealed}).
+			Error()
+		if err != nil {
+			return i, fmt.Errorf("updating secret of webhook subscription %s: %w", entity.ID, err)
+		}
+	}
+
+	return len(entities), nil
+}
+
+func (r *SimpleWebhookSubscriptionRepository) toEntity(subscription domain.WebhookSubscription) (internal.WebhookSubscription, error) {
+	entity := internal.FromWebhookSubscription(subscription)
+
+	sealed, err := r.cipher.Seal([]byte(entity.Secret), []byte(entity.ID))
+	if err != nil {
+		return internal.WebhookSubscription{}, fmt.Errorf("sealing webhook secret: %w", err)
+	}
+	entity.Secret = sealed
+
+	return entity, nil
+}
+
+func (r *SimpleWebhookSubscriptionRepository) toDomain(entity internal.WebhookSubscription) (domain.WebhookSubscription, error) {
+	secret, err := r.cipher.Open(entity.Secret, []byte(entity.ID))
+	if err != nil {
+		return domain.WebhookSubscription{}, fmt.Errorf("opening secret of webhook subscription %s: %w", entity.ID, err)
+	}
+	entity.Secret = string(secret)
+
+	return entity.ToDomain(), nil
+}
+
+func (r *SimpleWebhookSubscriptionRepository) toDomains(entities []internal.WebhookSubscription) ([]domain.WebhookSubscription, error) {
+	result := make([]domain.WebhookSubscription, len(entities))
+	for i, entity := range entities {
+		subscription, err := r.toDomain(entity)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = subscription
+	}
+	return result, nil
+}
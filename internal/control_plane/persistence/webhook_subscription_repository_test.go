@@ -0,0 +1,135 @@
+package persistence_test
+
+import (
+	"context"
+	"zensor-server/internal/control_plane/persistence"
+	"zensor-server/internal/control_plane/persistence/internal"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/secrets"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("WebhookSubscriptionRepository", func() {
+	var (
+		orm    sql.ORM
+		repo   usecases.WebhookSubscriptionRepository
+		ctx    context.Context
+		tenant domain.Tenant
+	)
+
+	newSubscription := func() domain.WebhookSubscription {
+		subscription, err := domain.NewWebhookSubscriptionBuilder().
+			WithTenant(tenant).
+			WithURL("https://example.com/hooks").
+			WithSecret("top-secret").
+			WithEventTypes([]string{"uplink"}).
+			Build()
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		return subscription
+	}
+
+	ginkgo.BeforeEach(func() {
+		var err error
+		orm, err = sql.NewMemoryORM("migrations")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		repo, err = persistence.NewWebhookSubscriptionRepository(orm, newDeviceTestKeyring("current"))
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		ctx = context.Background()
+		tenant = domain.Tenant{ID: domain.ID(utils.GenerateUUID())}
+	})
+
+	ginkgo.It("should store the secret sealed and read it back in plaintext", func() {
+		subscription := newSubscription()
+		gomega.Expect(repo.Create(ctx, subscription)).To(gomega.Succeed())
+
+		var entity internal.WebhookSubscription
+		gomega.Expect(orm.WithContext(ctx).Where("id = ?", subscription.ID.String()).First(&entity).Error()).To(gomega.Succeed())
+		gomega.Expect(entity.Secret).To(gomega.HavePrefix(secrets.SealedPrefix("current")))
+		gomega.Expect(entity.Secret).NotTo(gomega.ContainSubstring("top-secret"))
+
+		result, err := repo.GetByID(ctx, subscription.ID)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(result.Secret).To(gomega.Equal("top-secret"))
+		gomega.Expect(result.EventTypes).To(gomega.Equal([]string{"uplink"}))
+		gomega.Expect(result.Tenant.ID).To(gomega.Equal(tenant.ID))
+	})
+
+	ginkgo.It("should rewrap the secret when the primary key is rotated", func() {
+		subscription := newSubscription()
+		gomega.Expect(repo.Create(ctx, subscription)).To(gomega.Succeed())
+
+		rotated, err := persistence.NewWebhookSubscriptionRepository(orm, newDeviceTestKeyring("next"))
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		for {
+			count, err := rotated.ResealSecrets(ctx, 100)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			if count < 100 {
+				break
+			}
+		}
+
+		var entity internal.WebhookSubscription
+		gomega.Expect(orm.WithContext(ctx).Where("id = ?", subscription.ID.String()).First(&entity).Error()).To(gomega.Succeed())
+		gomega.Expect(entity.Secret).To(gomega.HavePrefix(secrets.SealedPrefix("next")))
+
+		result, err := rotated.GetByID(ctx, subscription.ID)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(result.Secret).To(gomega.Equal("top-secret"))
+	})
+
+	ginkgo.It("should update the subscription and bump its version", func() {
+		subscription := newSubscription()
+		gomega.Expect(repo.Create(ctx, subscription)).To(gomega.Succeed())
+
+		subscription.URL = "https://example.com/other"
+		subscription.EventTypes = []string{"device_online", "device_offline"}
+		gomega.Expect(repo.Update(ctx, subscription)).To(gomega.Succeed())
+
+		result, err := repo.GetByID(ctx, subscription.ID)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(result.URL).To(gomega.Equal("https://example.com/other"))
+		gomega.Expect(result.EventTypes).To(gomega.Equal([]string{"device_online", "device_offline"}))
+		gomega.Expect(result.Version).To(gomega.Equal(subscription.Version + 1))
+		gomega.Expect(result.Secret).To(gomega.Equal("top-secret"))
+	})
+
+	ginkgo.It("should list the subscriptions of the tenant only", func() {
+		first, second := newSubscription(), newSubscription()
+		gomega.Expect(repo.Create(ctx, first)).To(gomega.Succeed())
+		gomega.Expect(repo.Create(ctx, second)).To(gomega.Succeed())
+
+		tenant = domain.Tenant{ID: domain.ID(utils.GenerateUUID())}
+		gomega.Expect(repo.Create(ctx, newSubscription())).To(gomega.Succeed())
+
+		result, total, err := repo.FindAllByTenant(ctx, first.Tenant.ID, usecases.Pagination{Limit: 1})
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(total).To(gomega.Equal(2))
+		gomega.Expect(result).To(gomega.HaveLen(1))
+
+		active, err := repo.FindActiveByTenant(ctx, first.Tenant.ID)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(active).To(gomega.HaveLen(2))
+	})
+
+	ginkgo.It("should hide deleted subscriptions", func() {
+		subscription := newSubscription()
+		gomega.Expect(repo.Create(ctx, subscription)).To(gomega.Succeed())
+		gomega.Expect(repo.Delete(ctx, subscription.ID)).To(gomega.Succeed())
+
+		_, err := repo.GetByID(ctx, subscription.ID)
+		gomega.Expect(err).To(gomega.MatchError(usecases.ErrWebhookSubscriptionNotFound))
+
+		active, err := repo.FindActiveByTenant(ctx, tenant.ID)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(active).To(gomega.BeEmpty())
+
+		gomega.Expect(repo.Delete(ctx, subscription.ID)).To(gomega.MatchError(usecases.ErrWebhookSubscriptionNotFound))
+	})
+})
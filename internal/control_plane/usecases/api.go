@@ -3,6 +3,8 @@ package usecases
 
 import (
 	"context"
+	"time"
+	"zensor-server/internal/infra/utils"
 	"zensor-server/internal/shared_kernel/domain"
 
 	sharedUsecases "zensor-server/internal/shared_kernel/usecases"
@@ -11,15 +13,61 @@ import (
 //go:generate mockgen -source=./api.go -destination=../../../test/unit/doubles/control_plane/usecases/api.go -package=usecases
 
 type DeviceService interface {
-	CreateDevice(context.Context, domain.Device) error
+	// CreateDevice stores the device and, when provisioning is enabled, registers it in the
+	// network server. The returned device carries the provisioning outcome.
+	CreateDevice(context.Context, domain.Device) (domain.Device, error)
 	GetDevice(context.Context, domain.ID) (domain.Device, error)
 	AllDevices(context.Context, Pagination) ([]domain.Device, int, error)
-	DevicesByTenant(context.Context, domain.ID, Pagination) ([]domain.Device, int, error)
+	// SearchDevices lists the active devices matching the query filters, in the query order.
+	SearchDevices(context.Context, DeviceQuery) (DevicePage, error)
 	UpdateDeviceDisplayName(context.Context, domain.ID, string) error
+	UpdateDeviceExpectedUplinkInterval(context.Context, domain.ID, time.Duration) error
+	UpdateDeviceTags(context.Context, domain.ID, map[string]string) error
+	UpdateDeviceMetadata(context.Context, domain.ID, map[string]any) error
 	QueueCommand(context.Context, domain.Command) error
 	QueueCommandSequence(context.Context, domain.CommandSequence) error
 	AdoptDeviceToTenant(context.Context, domain.ID, domain.ID) error
 	UpdateLastMessageReceivedAt(context.Context, string) error
+	// RecordBatteryReading keeps the battery voltage reported by the named device, unless a later
+	// reading was already recorded.
+	RecordBatteryReading(ctx context.Context, deviceName string, voltage float64, reportedAt time.Time) error
+}
+
+// DeviceLifecycleService retires devices and moves them between tenants, cleaning up the
+// commands and scheduled tasks that would otherwise keep driving them.
+type DeviceLifecycleService interface {
+	Decommission(ctx context.Context, deviceID domain.ID) error
+	Release(ctx context.Context, deviceID domain.ID) (domain.Device, error)
+	Transfer(ctx context.Context, deviceID, tenantID domain.ID, mode DeviceTransferMode) (domain.Device, error)
+}
+
+// DeviceImportService provisions devices in bulk and lists them in the same shape for re-import.
+type DeviceImportService interface {
+	Import(ctx context.Context, rows []DeviceImportRow, dryRun bool) (DeviceImportReport, error)
+	Export(ctx context.Context, tenantID *domain.ID) ([]domain.Device, error)
+}
+
+// DeviceSessionService tracks the LoRaWAN session of devices and the radio quality of their uplinks.
+type DeviceSessionService interface {
+	RecordJoin(ctx context.Context, deviceName, devAddr string, joinedAt time.Time) error
+	RecordUplink(ctx context.Context, deviceName string, sample domain.LinkQualitySample) error
+	LinkQuality(ctx context.Context, deviceID domain.ID, pagination Pagination) (DeviceLinkQuality, int, error)
+}
+
+// DeviceConfigJobService pushes configuration blobs to devices as fragmented, acknowledged
+// downlinks. A device runs one job at a time.
+type DeviceConfigJobService interface {
+	Create(ctx context.Context, deviceID domain.ID, config []byte, fragmentSize int) (domain.DeviceConfigJob, error)
+	Get(ctx context.Context, deviceID, jobID domain.ID) (domain.DeviceConfigJob, error)
+	FindAllByDevice(ctx context.Context, deviceID domain.ID, pagination Pagination) ([]domain.DeviceConfigJob, int, error)
+	Cancel(ctx context.Context, deviceID, jobID domain.ID) (domain.DeviceConfigJob, error)
+	Resume(ctx context.Context, deviceID, jobID domain.ID) (domain.DeviceConfigJob, error)
+}
+
+// DeviceKeyService reveals the root keys of devices to administrators, recording every access.
+type DeviceKeyService interface {
+	RevealKeys(ctx context.Context, deviceID, userID domain.ID, userEmail string) (domain.Device, error)
+	KeyAccesses(ctx context.Context, deviceID domain.ID, pagination Pagination) ([]domain.DeviceKeyAccess, int, error)
 }
 
 type EvaluationRuleService interface {
@@ -39,7 +87,30 @@ type ScheduledTaskService interface {
 	FindAllByTenantAndDevice(context.Context, domain.ID, domain.ID, Pagination) ([]domain.ScheduledTask, int, error)
 	GetByID(context.Context, domain.ID) (domain.ScheduledTask, error)
 	Update(context.Context, domain.ScheduledTask) error
+	Pause(context.Context, domain.ScheduledTask, *utils.Time) (domain.ScheduledTask, error)
+	Resume(context.Context, domain.ScheduledTask) (domain.ScheduledTask, error)
+	Delete(context.Context, domain.ID) error
+	FindRuns(ctx context.Context, scheduledTaskID domain.ID, filter ScheduledTaskRunFilter, pagination Pagination) ([]domain.ScheduledTaskRun, int, error)
+}
+
+type CommandTemplateSetService interface {
+	Create(context.Context, domain.CommandTemplateSet) error
+	GetByID(context.Context, domain.ID) (domain.CommandTemplateSet, error)
+	FindAllByTenant(context.Context, domain.ID, Pagination) ([]domain.CommandTemplateSet, int, error)
+	Update(context.Context, domain.CommandTemplateSet) error
+	Delete(context.Context, domain.ID) error
+}
+
+// WebhookService manages the webhook subscriptions of tenants and their delivery log.
+type WebhookService interface {
+	Create(context.Context, domain.WebhookSubscription) error
+	GetByID(context.Context, domain.ID) (domain.WebhookSubscription, error)
+	FindAllByTenant(context.Context, domain.ID, Pagination) ([]domain.WebhookSubscription, int, error)
+	Update(context.Context, domain.WebhookSubscription) error
 	Delete(context.Context, domain.ID) error
+	Deliveries(ctx context.Context, subscriptionID domain.ID, filter WebhookDeliveryFilter, pagination Pagination) ([]domain.WebhookDelivery, int, error)
+	// Redeliver queues a dead delivery of the subscription again with a fresh set of attempts.
+	Redeliver(ctx context.Context, subscriptionID, deliveryID domain.ID) (domain.WebhookDelivery, error)
 }
 
 // Type aliases for types moved to shared_kernel/usecases.
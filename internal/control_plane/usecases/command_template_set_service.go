@@ -0,0 +1,86 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+var (
+	ErrCommandTemplateSetNotFound = errors.New("command template set not found")
+	ErrCommandTemplateSetInUse    = errors.New("command template set is referenced by scheduled tasks")
+)
+
+func NewCommandTemplateSetService(
+	repository CommandTemplateSetRepository,
+	scheduledTaskRepository ScheduledTaskRepository,
+) *SimpleCommandTemplateSetService {
+	return &SimpleCommandTemplateSetService{
+		repository:              repository,
+		scheduledTaskRepository: scheduledTaskRepository,
+	}
+}
+
+var _ CommandTemplateSetService = (*SimpleCommandTemplateSetService)(nil)
+
+type SimpleCommandTemplateSetService struct {
+	repository              CommandTemplateSetRepository
+	scheduledTaskRepository ScheduledTaskRepository
+}
+
+func (s *SimpleCommandTemplateSetService) Create(ctx context.Context, set domain.CommandTemplateSet) error {
+	err := s.repository.Create(ctx, set)
+	if err != nil {
+		return fmt.Errorf("creating command template set: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SimpleCommandTemplateSetService) GetByID(ctx context.Context, id domain.ID) (domain.CommandTemplateSet, error) {
+	set, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrCommandTemplateSetNotFound) {
+			return domain.CommandTemplateSet{}, ErrCommandTemplateSetNotFound
+		}
+		return domain.CommandTemplateSet{}, err
+	}
+
+	return set, nil
+}
+
+func (s *SimpleCommandTemplateSetService) FindAllByTenant(ctx context.Context, tenantID domain.ID, pagination Pagination) ([]domain.CommandTemplateSet, int, error) {
+	sets, total, err := s.repository.FindAllByTenant(ctx, tenantID, pagination)
+	if err != nil {
+		return nil, 0, fmt.Errorf("finding command template sets by tenant: %w", err)
+	}
+
+	return sets, total, nil
+}
+
+func (s *SimpleCommandTemplateSetService) Update(ctx context.Context, set domain.CommandTemplateSet) error {
+	err := s.repository.Update(ctx, set)
+	if err != nil {
+		return fmt.Errorf("updating command template set: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SimpleCommandTemplateSetService) Delete(ctx context.Context, id domain.ID) error {
+	references, err := s.scheduledTaskRepository.CountByCommandTemplateSet(ctx, id)
+	if err != nil {
+		return fmt.Errorf("counting scheduled tasks using command template set: %w", err)
+	}
+	if references > 0 {
+		return ErrCommandTemplateSetInUse
+	}
+
+	err = s.repository.Delete(ctx, id)
+	if err != nil {
+		return fmt.Errorf("deleting command template set: %w", err)
+	}
+
+	return nil
+}
@@ -2,27 +2,31 @@ package usecases
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
 	"sync"
 	"time"
 	"zensor-server/internal/infra/async"
-	"zensor-server/internal/infra/utils"
-	"zensor-server/internal/shared_kernel/device"
 	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// _deviceCommandsTopic matches the events of every device; the worker narrows it to command
+// events when subscribing.
+const _deviceCommandsTopic async.BrokerTopicName = "devices/+/+"
+
 func NewCommandWorker(
 	ticker *time.Ticker,
 	commandRepository CommandRepository,
+	outboxRepository OutboxRepository,
 	broker async.InternalBroker,
 ) *CommandWorker {
 	return &CommandWorker{
 		ticker:            ticker,
 		commandRepository: commandRepository,
+		outbox:            outboxConsumer{name: _commandWorkerOutboxConsumer, outboxRepository: outboxRepository},
 		broker:            broker,
 	}
 }
@@ -30,17 +34,23 @@ func NewCommandWorker(
 type CommandWorker struct {
 	ticker            *time.Ticker
 	commandRepository CommandRepository
+	outbox            outboxConsumer
 	broker            async.InternalBroker
 }
 
 func (w *CommandWorker) Run(ctx context.Context, done func()) {
 	slog.Debug("run with context initialized")
 	defer done()
-	subscription, err := w.broker.Subscribe(async.BrokerTopicName("device_messages"))
+	subscription, err := w.broker.Subscribe(_deviceCommandsTopic, events.CommandStatusUpdated.Name)
 	if err != nil {
 		slog.Error("subscribing to topic", slog.Any("error", err))
 		return
 	}
+	defer func() {
+		if err := w.broker.Unsubscribe(_deviceCommandsTopic, subscription); err != nil {
+			slog.Error("unsubscribing from topic", slog.Any("error", err))
+		}
+	}()
 	var wg sync.WaitGroup
 	for {
 		select {
@@ -50,28 +60,14 @@ func (w *CommandWorker) Run(ctx context.Context, done func()) {
 			return
 		case msg := <-subscription.Receiver:
 			switch msg.Event {
-			case "command_sent":
-				wg.Add(1)
-				procCtx := context.Background()
-				command, ok := msg.Value.(device.Command)
-				if !ok {
-					slog.Error("failed to cast command data",
-						slog.String("type", fmt.Sprintf("%T", msg.Value)),
-						slog.String("expected", "device.Command"))
-					return
+			case events.CommandStatusUpdated.Name:
+				event, err := events.CommandStatusUpdated.Decode(msg)
+				if err != nil {
+					slog.Error("failed to decode command status update event", slog.Any("error", err))
+					continue
 				}
-				w.handleCommandSent(procCtx, command, wg.Done)
-			case "command_status_update":
 				wg.Add(1)
-				procCtx := context.Background()
-				cmdStatusUpdate, ok := msg.Value.(domain.CommandStatusUpdate)
-				if !ok {
-					slog.Error("failed to cast command status update data",
-						slog.String("type", fmt.Sprintf("%T", msg.Value)),
-						slog.String("expected", "domain.CommandStatusUpdate"))
-					return
-				}
-				w.handleCommandStatusUpdate(procCtx, cmdStatusUpdate, wg.Done)
+				w.handleCommandStatusUpdate(context.Background(), msg, event.Payload, wg.Done)
 			default:
 				slog.Warn("event not supported", slog.String("event", msg.Event))
 			}
@@ -131,10 +127,11 @@ func (w *CommandWorker) handle(ctx context.Context, cmd domain.Command) {
 
 	slog.Debug("new message ready to be sent", slog.String("id", cmd.ID.String()))
 
-	brokerMsg := async.BrokerMessage{
-		Event: "command_processed",
-		Value: cmd,
+	tenantID := ""
+	if cmd.Device.TenantID != nil {
+		tenantID = cmd.Device.TenantID.String()
 	}
+	brokerMsg := events.CommandProcessed.New(tenantID, cmd).Message()
 	if err := w.broker.Publish(ctx, async.BrokerTopicName("command_events"), brokerMsg); err != nil {
 		slog.Error("failed to publish command processed event",
 			slog.String("command_id", cmd.ID.String()),
@@ -147,26 +144,10 @@ func (w *CommandWorker) handle(ctx context.Context, cmd domain.Command) {
 	}
 }
 
-func (w *CommandWorker) handleCommandSent(ctx context.Context, cmd device.Command, done func()) {
-	defer done()
-
-	existingCmd, err := w.commandRepository.GetByID(ctx, domain.ID(cmd.ID))
-	if err != nil {
-		slog.Error("failed to get command by ID", slog.Any("error", err))
-		return
-	}
-
-	existingCmd.Sent = true
-	existingCmd.SentAt = utils.Time{Time: time.Now()}
-	err = w.commandRepository.Update(ctx, existingCmd)
-	if err != nil {
-		slog.Error("failed to update command", slog.Any("error", err))
-	}
-
-	// Metrics are now handled by MetricPublisherWorker
-}
-
-func (w *CommandWorker) handleCommandStatusUpdate(ctx context.Context, statusUpdate domain.CommandStatusUpdate, done func()) {
+// handleCommandStatusUpdate persists the status reported for a command. Updates are relayed
+// from the outbox, so redeliveries of an update already applied are discarded and the update is
+// only acknowledged once persisted.
+func (w *CommandWorker) handleCommandStatusUpdate(ctx context.Context, msg async.BrokerMessage, statusUpdate domain.CommandStatusUpdate, done func()) {
 	defer done()
 	slog.Debug("received command status update",
 		slog.String("command_id", statusUpdate.CommandID),
@@ -174,10 +155,15 @@ func (w *CommandWorker) handleCommandStatusUpdate(ctx context.Context, statusUpd
 		slog.String("status", string(statusUpdate.Status)),
 	)
 
+	if !w.outbox.claim(ctx, msg) {
+		return
+	}
+
 	if statusUpdate.CommandID == "" {
 		slog.Error("command status update received without command ID",
 			slog.String("device_name", statusUpdate.DeviceName),
 			slog.String("status", string(statusUpdate.Status)))
+		w.outbox.release(ctx, msg)
 		return
 	}
 
@@ -187,6 +173,7 @@ func (w *CommandWorker) handleCommandStatusUpdate(ctx context.Context, statusUpd
 		slog.Error("failed to find command by ID",
 			slog.String("command_id", statusUpdate.CommandID),
 			slog.String("error", err.Error()))
+		w.outbox.release(ctx, msg)
 		return
 	}
 
@@ -197,8 +184,10 @@ func (w *CommandWorker) handleCommandStatusUpdate(ctx context.Context, statusUpd
 			slog.String("command_id", targetCommand.ID.String()),
 			slog.String("status", string(statusUpdate.Status)),
 			slog.String("error", err.Error()))
+		w.outbox.release(ctx, msg)
 		return
 	}
+	w.outbox.markProcessed(ctx, msg)
 
 	slog.Info("command status updated successfully",
 		slog.String("command_id", targetCommand.ID.String()),
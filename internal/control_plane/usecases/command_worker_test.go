@@ -2,10 +2,13 @@ package usecases_test
 
 import (
 	"context"
+	"errors"
 	"time"
 	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/async"
 	"zensor-server/internal/infra/utils"
 	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
 
 	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
 	mockasync "zensor-server/test/unit/doubles/infra/async"
@@ -20,6 +23,7 @@ var _ = ginkgo.Describe("CommandWorker", func() {
 		var (
 			ctrl       *gomock.Controller
 			mockRepo   *mockusecases.MockCommandRepository
+			mockOutbox *mockusecases.MockOutboxRepository
 			mockBroker *mockasync.MockInternalBroker
 			ticker     *time.Ticker
 		)
@@ -27,6 +31,7 @@ var _ = ginkgo.Describe("CommandWorker", func() {
 		ginkgo.BeforeEach(func() {
 			ctrl = gomock.NewController(ginkgo.GinkgoT())
 			mockRepo = mockusecases.NewMockCommandRepository(ctrl)
+			mockOutbox = mockusecases.NewMockOutboxRepository(ctrl)
 			mockBroker = mockasync.NewMockInternalBroker(ctrl)
 			ticker = time.NewTicker(100 * time.Millisecond)
 		})
@@ -38,7 +43,7 @@ var _ = ginkgo.Describe("CommandWorker", func() {
 
 		ginkgo.It("should create a new command worker with mocks", func() {
 			// Create a command worker
-			worker := usecases.NewCommandWorker(ticker, mockRepo, mockBroker)
+			worker := usecases.NewCommandWorker(ticker, mockRepo, mockOutbox, mockBroker)
 
 			// Verify the worker was created
 			gomega.Expect(worker).NotTo(gomega.BeNil())
@@ -69,7 +74,7 @@ var _ = ginkgo.Describe("CommandWorker", func() {
 			mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
 
 			// Create a command worker
-			worker := usecases.NewCommandWorker(ticker, mockRepo, mockBroker)
+			worker := usecases.NewCommandWorker(ticker, mockRepo, mockOutbox, mockBroker)
 			gomega.Expect(worker).NotTo(gomega.BeNil())
 
 			// Verify the mocks work correctly
@@ -82,4 +87,115 @@ var _ = ginkgo.Describe("CommandWorker", func() {
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
 	})
+
+	ginkgo.Context("command status updates relayed from the outbox", func() {
+		var (
+			ctrl       *gomock.Controller
+			mockRepo   *mockusecases.MockCommandRepository
+			mockOutbox *mockusecases.MockOutboxRepository
+			broker     *async.LocalBroker
+			ticker     *time.Ticker
+			cancel     context.CancelFunc
+			done       chan struct{}
+			message    async.BrokerMessage
+		)
+
+		ginkgo.BeforeEach(func() {
+			ctrl = gomock.NewController(ginkgo.GinkgoT())
+			mockRepo = mockusecases.NewMockCommandRepository(ctrl)
+			mockOutbox = mockusecases.NewMockOutboxRepository(ctrl)
+			broker = async.NewLocalBroker()
+			ticker = time.NewTicker(time.Hour)
+			message = events.CommandStatusUpdated.New("", domain.CommandStatusUpdate{CommandID: "command-1", DeviceName: "device-1", Status: domain.CommandStatusAck}).Message()
+			message.ID = "event-1"
+
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			done = make(chan struct{})
+			worker := usecases.NewCommandWorker(ticker, mockRepo, mockOutbox, broker)
+			go worker.Run(ctx, func() { close(done) })
+			gomega.Eventually(func() error {
+				return broker.Publish(context.Background(), "devices/device-1/probe", async.BrokerMessage{Event: "probe"})
+			}).Should(gomega.Succeed())
+		})
+
+		ginkgo.AfterEach(func() {
+			cancel()
+			gomega.Eventually(done).Should(gomega.BeClosed())
+			ticker.Stop()
+		})
+
+		ginkgo.When("the update was not processed yet", func() {
+			ginkgo.It("should persist the status and acknowledge the event", func() {
+				acked := make(chan domain.ID, 1)
+				mockOutbox.EXPECT().ClaimProcessing(gomock.Any(), "command_worker", domain.ID("event-1"), gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().GetByID(gomock.Any(), domain.ID("command-1")).Return(domain.Command{ID: "command-1"}, nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, command domain.Command) error {
+					gomega.Expect(command.Status).To(gomega.Equal(domain.CommandStatusAck))
+					return nil
+				})
+				mockOutbox.EXPECT().MarkProcessed(gomock.Any(), "command_worker", domain.ID("event-1"), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, eventID domain.ID, _ time.Time) error {
+						acked <- eventID
+						return nil
+					})
+
+				gomega.Expect(broker.Publish(context.Background(), "devices/device-1/command_status", message)).To(gomega.Succeed())
+
+				gomega.Eventually(acked).Should(gomega.Receive(gomega.Equal(domain.ID("event-1"))))
+			})
+		})
+
+		ginkgo.When("the update cannot be persisted", func() {
+			ginkgo.It("should release the event so its redelivery is processed", func() {
+				released := make(chan domain.ID, 1)
+				mockOutbox.EXPECT().ClaimProcessing(gomock.Any(), "command_worker", domain.ID("event-1"), gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().GetByID(gomock.Any(), domain.ID("command-1")).Return(domain.Command{ID: "command-1"}, nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(errors.New("database unavailable"))
+				mockOutbox.EXPECT().ReleaseProcessing(gomock.Any(), "command_worker", domain.ID("event-1")).
+					DoAndReturn(func(_ context.Context, _ string, eventID domain.ID) error {
+						released <- eventID
+						return nil
+					})
+
+				gomega.Expect(broker.Publish(context.Background(), "devices/device-1/command_status", message)).To(gomega.Succeed())
+
+				gomega.Eventually(released).Should(gomega.Receive(gomega.Equal(domain.ID("event-1"))))
+			})
+		})
+
+		ginkgo.When("the update is a redelivery", func() {
+			ginkgo.It("should discard it", func() {
+				checked := make(chan struct{}, 1)
+				mockOutbox.EXPECT().ClaimProcessing(gomock.Any(), "command_worker", domain.ID("event-1"), gomock.Any()).
+					DoAndReturn(func(context.Context, string, domain.ID, time.Time) (bool, error) {
+						checked <- struct{}{}
+						return false, nil
+					})
+
+				gomega.Expect(broker.Publish(context.Background(), "devices/device-1/command_status", message)).To(gomega.Succeed())
+
+				gomega.Eventually(checked).Should(gomega.Receive())
+			})
+		})
+
+		ginkgo.When("an event does not match its schema", func() {
+			ginkgo.It("should skip it and keep processing the following events", func() {
+				checked := make(chan struct{}, 1)
+				mockOutbox.EXPECT().ClaimProcessing(gomock.Any(), "command_worker", domain.ID("event-1"), gomock.Any()).
+					DoAndReturn(func(context.Context, string, domain.ID, time.Time) (bool, error) {
+						checked <- struct{}{}
+						return false, nil
+					})
+
+				gomega.Expect(broker.Publish(context.Background(), "devices/device-1/command_status", async.BrokerMessage{
+					Event: events.CommandStatusUpdated.Name,
+					Value: "not a status update",
+				})).To(gomega.Succeed())
+				gomega.Expect(broker.Publish(context.Background(), "devices/device-1/command_status", message)).To(gomega.Succeed())
+
+				gomega.Eventually(checked).Should(gomega.Receive())
+			})
+		})
+	})
 })
@@ -0,0 +1,181 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
+)
+
+const (
+	_deviceConnectivityTopic      = "device_connectivity"
+	_connectivityWatchdogPageSize = 500
+)
+
+func NewConnectivityWatchdogWorker(
+	ticker *time.Ticker,
+	deviceRepository DeviceRepository,
+	connectivityRepository DeviceConnectivityRepository,
+	broker async.InternalBroker,
+) *ConnectivityWatchdogWorker {
+	return &ConnectivityWatchdogWorker{
+		ticker:                 ticker,
+		deviceRepository:       deviceRepository,
+		connectivityRepository: connectivityRepository,
+		broker:                 broker,
+		states:                 make(map[domain.ID]domain.ConnectivityState),
+	}
+}
+
+var _ async.Worker = &ConnectivityWatchdogWorker{}
+
+// ConnectivityWatchdogWorker detects devices going online or offline according to their
+// expected uplink interval, records each transition and announces it on the internal broker.
+type ConnectivityWatchdogWorker struct {
+	ticker                 *time.Ticker
+	deviceRepository       DeviceRepository
+	connectivityRepository DeviceConnectivityRepository
+	broker                 async.InternalBroker
+	mu                     sync.Mutex
+	states                 map[domain.ID]domain.ConnectivityState
+}
+
+func (w *ConnectivityWatchdogWorker) Run(ctx context.Context, done func()) {
+	slog.Info("connectivity watchdog worker started")
+	defer done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("connectivity watchdog worker cancelled")
+			return
+		case <-w.ticker.C:
+			w.evaluate(context.Background(), time.Now())
+		}
+	}
+}
+
+func (w *ConnectivityWatchdogWorker) evaluate(ctx context.Context, now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	seen := make(map[domain.ID]struct{}, len(w.states))
+	for offset := 0; ; offset += _connectivityWatchdogPageSize {
+		devices, total, err := w.deviceRepository.FindAll(ctx, Pagination{Limit: _connectivityWatchdogPageSize, Offset: offset})
+		if err != nil {
+			slog.Error("finding devices for connectivity evaluation", slog.Any("error", err))
+			return
+		}
+
+		for _, device := range devices {
+			seen[device.ID] = struct{}{}
+			w.evaluateDevice(ctx, device, now)
+		}
+
+		if len(devices) == 0 || offset+len(devices) >= total {
+			break
+		}
+	}
+
+	for id := range w.states {
+		if _, ok := seen[id]; !ok {
+			delete(w.states, id)
+		}
+	}
+}
+
+func (w *ConnectivityWatchdogWorker) evaluateDevice(ctx context.Context, device domain.Device, now time.Time) {
+	previous, err := w.knownState(ctx, device.ID)
+	if err != nil {
+		slog.Error("loading device connectivity state",
+			slog.String("device_id", device.ID.String()),
+			slog.Any("error", err))
+		return
+	}
+
+	transition, changed := domain.EvaluateConnectivity(device, previous, now)
+	if !changed {
+		return
+	}
+
+	err = w.connectivityRepository.Create(ctx, transition)
+	if err != nil {
+		slog.Error("recording device connectivity transition",
+			slog.String("device_id", device.ID.String()),
+			slog.Any("error", err))
+		return
+	}
+	w.states[device.ID] = transition.To
+
+	if transition.IsBaseline() {
+		return
+	}
+
+	slog.Info("device connectivity changed",
+		slog.String("device_id", device.ID.String()),
+		slog.String("from", string(transition.From)),
+		slog.String("to", string(transition.To)))
+	w.publish(ctx, device, transition)
+}
+
+func (w *ConnectivityWatchdogWorker) knownState(ctx context.Context, deviceID domain.ID) (domain.ConnectivityState, error) {
+	if state, ok := w.states[deviceID]; ok {
+		return state, nil
+	}
+
+	latest, err := w.connectivityRepository.FindLatestByDevice(ctx, deviceID)
+	if errors.Is(err, ErrDeviceConnectivityNotFound) {
+		return domain.ConnectivityStateUnknown, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	w.states[deviceID] = latest.To
+	return latest.To, nil
+}
+
+func (w *ConnectivityWatchdogWorker) publish(ctx context.Context, device domain.Device, transition domain.DeviceConnectivityTransition) {
+	tenantID := ""
+	if device.TenantID != nil {
+		tenantID = device.TenantID.String()
+	}
+
+	displayName := device.DisplayName
+	if displayName == "" {
+		displayName = device.Name
+	}
+
+	eventType := events.DeviceOffline
+	if transition.To == domain.ConnectivityStateOnline {
+		eventType = events.DeviceOnline
+	}
+
+	var lastMessageReceivedAt *time.Time
+	if transition.LastMessageReceivedAt != nil {
+		lastMessageReceivedAt = &transition.LastMessageReceivedAt.Time
+	}
+
+	event := eventType.New(tenantID, events.DeviceConnectivity{
+		DeviceID:                      device.ID.String(),
+		DeviceName:                    device.Name,
+		DisplayName:                   displayName,
+		Status:                        string(transition.To),
+		From:                          string(transition.From),
+		LastMessageReceivedAt:         lastMessageReceivedAt,
+		ExpectedUplinkIntervalSeconds: int(device.UplinkInterval() / time.Second),
+	})
+	event.OccurredAt = transition.OccurredAt.Time
+	brokerMsg := event.Message()
+	if err := w.broker.Publish(ctx, async.BrokerTopicName(_deviceConnectivityTopic), brokerMsg); err != nil {
+		slog.Error("failed to publish device connectivity event", slog.Any("error", err))
+	}
+}
+
+func (w *ConnectivityWatchdogWorker) Shutdown() {
+	slog.Warn("connectivity watchdog worker shutdown is not yet implemented")
+}
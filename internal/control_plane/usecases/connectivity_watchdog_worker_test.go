@@ -0,0 +1,112 @@
+package usecases_test
+
+import (
+	"context"
+	"time"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
+
+	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+	mockasync "zensor-server/test/unit/doubles/infra/async"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = ginkgo.Describe("ConnectivityWatchdogWorker", func() {
+	var (
+		ctrl             *gomock.Controller
+		mockDeviceRepo   *mockusecases.MockDeviceRepository
+		mockConnectivity *mockusecases.MockDeviceConnectivityRepository
+		mockBroker       *mockasync.MockInternalBroker
+		ticker           *time.Ticker
+		tenantID         domain.ID
+		device           domain.Device
+		recorded         chan domain.DeviceConnectivityTransition
+		published        chan async.BrokerMessage
+	)
+
+	ginkgo.BeforeEach(func() {
+		ctrl = gomock.NewController(ginkgo.GinkgoT())
+		mockDeviceRepo = mockusecases.NewMockDeviceRepository(ctrl)
+		mockConnectivity = mockusecases.NewMockDeviceConnectivityRepository(ctrl)
+		mockBroker = mockasync.NewMockInternalBroker(ctrl)
+		ticker = time.NewTicker(10 * time.Millisecond)
+		tenantID = domain.ID("tenant-1")
+		device = domain.Device{
+			ID:                     domain.ID("device-1"),
+			Name:                   "device-1",
+			TenantID:               &tenantID,
+			ExpectedUplinkInterval: 30 * time.Minute,
+			LastMessageReceivedAt:  utils.Time{Time: time.Now().Add(-time.Hour)},
+		}
+		recorded = make(chan domain.DeviceConnectivityTransition, 1)
+		published = make(chan async.BrokerMessage, 1)
+	})
+
+	ginkgo.AfterEach(func() {
+		ticker.Stop()
+	})
+
+	expectEvaluation := func() {
+		mockDeviceRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return([]domain.Device{device}, 1, nil).MinTimes(1)
+		mockConnectivity.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, value domain.DeviceConnectivityTransition) error {
+			select {
+			case recorded <- value:
+			default:
+			}
+			return nil
+		}).Times(1)
+	}
+
+	runUntilRecorded := func() domain.DeviceConnectivityTransition {
+		worker := usecases.NewConnectivityWatchdogWorker(ticker, mockDeviceRepo, mockConnectivity, mockBroker)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go worker.Run(ctx, func() { close(done) })
+
+		var transition domain.DeviceConnectivityTransition
+		gomega.Eventually(recorded).Should(gomega.Receive(&transition))
+		gomega.Consistently(recorded, 50*time.Millisecond).ShouldNot(gomega.Receive())
+		cancel()
+		gomega.Eventually(done).Should(gomega.BeClosed())
+		return transition
+	}
+
+	ginkgo.When("an online device misses its expected uplink", func() {
+		ginkgo.It("should record the transition and publish a device_offline event once", func() {
+			expectEvaluation()
+			mockConnectivity.EXPECT().FindLatestByDevice(gomock.Any(), device.ID).Return(domain.DeviceConnectivityTransition{To: domain.ConnectivityStateOnline}, nil)
+			mockBroker.EXPECT().Publish(gomock.Any(), async.BrokerTopicName("device_connectivity"), gomock.Any()).DoAndReturn(func(_ context.Context, _ async.BrokerTopicName, msg async.BrokerMessage) error {
+				published <- msg
+				return nil
+			}).Times(1)
+
+			transition := runUntilRecorded()
+			gomega.Expect(transition.From).To(gomega.Equal(domain.ConnectivityStateOnline))
+			gomega.Expect(transition.To).To(gomega.Equal(domain.ConnectivityStateOffline))
+
+			var msg async.BrokerMessage
+			gomega.Expect(published).To(gomega.Receive(&msg))
+			gomega.Expect(msg.Event).To(gomega.Equal("device_offline"))
+			event, err := events.DeviceOffline.Decode(msg)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(event.TenantID).To(gomega.Equal("tenant-1"))
+			gomega.Expect(event.Payload.ExpectedUplinkIntervalSeconds).To(gomega.Equal(1800))
+		})
+	})
+
+	ginkgo.When("the device has never been evaluated", func() {
+		ginkgo.It("should record a baseline without publishing", func() {
+			expectEvaluation()
+			mockConnectivity.EXPECT().FindLatestByDevice(gomock.Any(), device.ID).Return(domain.DeviceConnectivityTransition{}, usecases.ErrDeviceConnectivityNotFound)
+
+			transition := runUntilRecorded()
+			gomega.Expect(transition.IsBaseline()).To(gomega.BeTrue())
+		})
+	})
+})
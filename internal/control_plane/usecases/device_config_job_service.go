@@ -0,0 +1,170 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+var ErrDeviceConfigJobInProgress = errors.New("the device already has an active configuration job")
+
+const _cancelledConfigFragmentReason = "configuration job cancelled"
+
+func NewDeviceConfigJobService(
+	deviceRepository DeviceRepository,
+	commandRepository CommandRepository,
+	repository DeviceConfigJobRepository,
+) *SimpleDeviceConfigJobService {
+	return &SimpleDeviceConfigJobService{
+		deviceRepository:  deviceRepository,
+		commandRepository: commandRepository,
+		repository:        repository,
+	}
+}
+
+var _ DeviceConfigJobService = (*SimpleDeviceConfigJobService)(nil)
+
+type SimpleDeviceConfigJobService struct {
+	deviceRepository  DeviceRepository
+	commandRepository CommandRepository
+	repository        DeviceConfigJobRepository
+}
+
+func (s *SimpleDeviceConfigJobService) Create(ctx context.Context, deviceID domain.ID, config []byte, fragmentSize int) (domain.DeviceConfigJob, error) {
+	_, err := s.deviceRepository.Get(ctx, deviceID.String())
+	if err != nil {
+		return domain.DeviceConfigJob{}, fmt.Errorf("getting device: %w", err)
+	}
+
+	if err := s.checkNoActiveJob(ctx, deviceID); err != nil {
+		return domain.DeviceConfigJob{}, err
+	}
+
+	job, err := domain.NewDeviceConfigJob(deviceID, config, fragmentSize, time.Now())
+	if err != nil {
+		return domain.DeviceConfigJob{}, fmt.Errorf("creating config job: %w", err)
+	}
+
+	err = s.repository.Create(ctx, job)
+	if err != nil {
+		return domain.DeviceConfigJob{}, fmt.Errorf("storing config job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (s *SimpleDeviceConfigJobService) Get(ctx context.Context, deviceID, jobID domain.ID) (domain.DeviceConfigJob, error) {
+	job, err := s.repository.Get(ctx, jobID)
+	if err != nil {
+		return domain.DeviceConfigJob{}, fmt.Errorf("getting config job: %w", err)
+	}
+	if job.DeviceID != deviceID {
+		return domain.DeviceConfigJob{}, ErrDeviceConfigJobNotFound
+	}
+
+	return job, nil
+}
+
+func (s *SimpleDeviceConfigJobService) FindAllByDevice(ctx context.Context, deviceID domain.ID, pagination Pagination) ([]domain.DeviceConfigJob, int, error) {
+	_, err := s.deviceRepository.Get(ctx, deviceID.String())
+	if err != nil {
+		return nil, 0, fmt.Errorf("getting device: %w", err)
+	}
+
+	jobs, total, err := s.repository.FindAllByDevice(ctx, deviceID, pagination)
+	if err != nil {
+		return nil, 0, fmt.Errorf("finding config jobs: %w", err)
+	}
+
+	return jobs, total, nil
+}
+
+// Cancel stops the job and withdraws the fragment waiting to be sent, if any.
+func (s *SimpleDeviceConfigJobService) Cancel(ctx context.Context, deviceID, jobID domain.ID) (domain.DeviceConfigJob, error) {
+	job, err := s.Get(ctx, deviceID, jobID)
+	if err != nil {
+		return domain.DeviceConfigJob{}, err
+	}
+
+	var inFlight *domain.ID
+	if index, found := job.CurrentFragment(); found {
+		inFlight = job.Fragments[index].CommandID
+	}
+
+	if err := job.Cancel(_cancelledConfigFragmentReason, time.Now()); err != nil {
+		return domain.DeviceConfigJob{}, err
+	}
+	if err := s.update(ctx, &job); err != nil {
+		return domain.DeviceConfigJob{}, err
+	}
+
+	if inFlight != nil {
+		if err := withdrawCommand(ctx, s.commandRepository, *inFlight, _cancelledConfigFragmentReason); err != nil {
+			return domain.DeviceConfigJob{}, err
+		}
+	}
+
+	return job, nil
+}
+
+// Resume restarts a failed job, unless the device started another one in the meantime.
+func (s *SimpleDeviceConfigJobService) Resume(ctx context.Context, deviceID, jobID domain.ID) (domain.DeviceConfigJob, error) {
+	job, err := s.Get(ctx, deviceID, jobID)
+	if err != nil {
+		return domain.DeviceConfigJob{}, err
+	}
+
+	if err := job.Resume(time.Now()); err != nil {
+		return domain.DeviceConfigJob{}, err
+	}
+	if err := s.checkNoActiveJob(ctx, deviceID); err != nil {
+		return domain.DeviceConfigJob{}, err
+	}
+	if err := s.update(ctx, &job); err != nil {
+		return domain.DeviceConfigJob{}, err
+	}
+
+	return job, nil
+}
+
+func (s *SimpleDeviceConfigJobService) checkNoActiveJob(ctx context.Context, deviceID domain.ID) error {
+	active, err := s.repository.FindActiveByDevice(ctx, deviceID)
+	if err != nil {
+		return fmt.Errorf("finding active config jobs: %w", err)
+	}
+	if len(active) > 0 {
+		return ErrDeviceConfigJobInProgress
+	}
+	return nil
+}
+
+func (s *SimpleDeviceConfigJobService) update(ctx context.Context, job *domain.DeviceConfigJob) error {
+	err := s.repository.Update(ctx, *job)
+	if err != nil {
+		return fmt.Errorf("updating config job: %w", err)
+	}
+
+	job.Version++
+	return nil
+}
+
+// withdrawCommand cancels a fragment command the network server has not been handed yet.
+func withdrawCommand(ctx context.Context, repository CommandRepository, commandID domain.ID, reason string) error {
+	command, err := repository.GetByID(ctx, commandID)
+	if err != nil {
+		return fmt.Errorf("getting fragment command: %w", err)
+	}
+	if command.Sent || command.IsCompleted() {
+		return nil
+	}
+
+	command.UpdateStatus(domain.CommandStatusCancelled, &reason)
+	err = repository.Update(ctx, command)
+	if err != nil {
+		return fmt.Errorf("cancelling fragment command: %w", err)
+	}
+
+	return nil
+}
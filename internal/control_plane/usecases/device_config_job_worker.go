@@ -0,0 +1,204 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+const (
+	_deviceConfigJobBatchSize = 100
+	// _configFragmentAckTimeout bounds the wait for a fragment acknowledgement. Class A devices only
+	// receive downlinks after an uplink, so it spans several uplink intervals.
+	_configFragmentAckTimeout = 30 * time.Minute
+	_configFragmentPriority   = domain.CommandPriority("NORMAL")
+)
+
+func NewDeviceConfigJobWorker(
+	ticker *time.Ticker,
+	repository DeviceConfigJobRepository,
+	deviceRepository DeviceRepository,
+	commandRepository CommandRepository,
+) *DeviceConfigJobWorker {
+	return &DeviceConfigJobWorker{
+		ticker:            ticker,
+		repository:        repository,
+		deviceRepository:  deviceRepository,
+		commandRepository: commandRepository,
+	}
+}
+
+var _ async.Worker = &DeviceConfigJobWorker{}
+
+// DeviceConfigJobWorker delivers the fragments of active configuration jobs. Each fragment is
+// queued as a confirmed command; the worker sends the next one once the command is acknowledged
+// and retries it when the command fails or is not acknowledged in time. Job state lives in the
+// database, so delivery picks up where it left after a restart.
+type DeviceConfigJobWorker struct {
+	ticker            *time.Ticker
+	repository        DeviceConfigJobRepository
+	deviceRepository  DeviceRepository
+	commandRepository CommandRepository
+}
+
+func (w *DeviceConfigJobWorker) Run(ctx context.Context, done func()) {
+	slog.Info("device config job worker started")
+	defer done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("device config job worker cancelled")
+			return
+		case <-w.ticker.C:
+			w.advanceAll(ctx, time.Now())
+		}
+	}
+}
+
+func (w *DeviceConfigJobWorker) advanceAll(ctx context.Context, now time.Time) {
+	jobs, err := w.repository.FindActive(ctx, _deviceConfigJobBatchSize)
+	if err != nil {
+		slog.Error("finding active device config jobs", slog.Any("error", err))
+		return
+	}
+
+	for _, job := range jobs {
+		w.advance(ctx, job, now)
+	}
+}
+
+func (w *DeviceConfigJobWorker) advance(ctx context.Context, job domain.DeviceConfigJob, now time.Time) {
+	changed := false
+	var queued []domain.ID
+
+	for job.IsActive() {
+		index, found := job.CurrentFragment()
+		if !found {
+			break
+		}
+
+		if job.Fragments[index].CommandID == nil {
+			commandID, err := w.sendFragment(ctx, &job, index, now)
+			if err != nil {
+				slog.Error("sending config fragment",
+					slog.String("job_id", job.ID.String()),
+					slog.Int("fragment", index),
+					slog.Any("error", err),
+				)
+				break
+			}
+			if commandID != "" {
+				queued = append(queued, commandID)
+			}
+			changed = true
+			break
+		}
+
+		settled, err := w.settleFragment(ctx, &job, index, now)
+		if err != nil {
+			slog.Error("checking config fragment",
+				slog.String("job_id", job.ID.String()),
+				slog.Int("fragment", index),
+				slog.Any("error", err),
+			)
+			break
+		}
+		if !settled {
+			break
+		}
+		changed = true
+	}
+
+	if !changed {
+		return
+	}
+
+	err := w.repository.Update(ctx, job)
+	if err == nil {
+		return
+	}
+
+	// The job moved on without this pass, for example it was cancelled, so the fragments queued
+	// by it must not reach the device.
+	for _, commandID := range queued {
+		if err := withdrawCommand(ctx, w.commandRepository, commandID, "configuration job changed"); err != nil {
+			slog.Error("withdrawing config fragment", slog.String("command_id", commandID.String()), slog.Any("error", err))
+		}
+	}
+	if errors.Is(err, ErrDeviceConfigJobVersionConflict) {
+		slog.Debug("device config job changed while advancing it", slog.String("job_id", job.ID.String()))
+		return
+	}
+	slog.Error("updating device config job", slog.String("job_id", job.ID.String()), slog.Any("error", err))
+}
+
+// sendFragment queues the fragment as a confirmed downlink and returns the command ID. The job is
+// cancelled instead when its device is gone.
+func (w *DeviceConfigJobWorker) sendFragment(ctx context.Context, job *domain.DeviceConfigJob, index int, now time.Time) (domain.ID, error) {
+	device, err := w.deviceRepository.Get(ctx, job.DeviceID.String())
+	if errors.Is(err, ErrDeviceNotFound) {
+		return "", job.Cancel("device not found", now)
+	}
+	if err != nil {
+		return "", fmt.Errorf("getting device: %w", err)
+	}
+
+	command, err := domain.NewCommandBuilder().
+		WithDevice(device).
+		WithPort(job.Port).
+		WithPriority(_configFragmentPriority).
+		WithDispatchAfter(utils.Time{Time: now}).
+		Build()
+	if err != nil {
+		return "", fmt.Errorf("building command: %w", err)
+	}
+	command.RawPayload = job.Fragments[index].Payload
+	command.Confirmed = true
+
+	err = w.commandRepository.Create(ctx, command)
+	if err != nil {
+		return "", fmt.Errorf("creating command: %w", err)
+	}
+
+	return command.ID, job.RecordFragmentSent(index, command.ID, now)
+}
+
+// settleFragment records the outcome of the command carrying the fragment, and tells whether there
+// was one to record.
+func (w *DeviceConfigJobWorker) settleFragment(ctx context.Context, job *domain.DeviceConfigJob, index int, now time.Time) (bool, error) {
+	fragment := job.Fragments[index]
+	command, err := w.commandRepository.GetByID(ctx, *fragment.CommandID)
+	if err != nil {
+		return false, fmt.Errorf("getting command: %w", err)
+	}
+
+	switch {
+	case command.IsSuccessful():
+		return true, job.RecordFragmentAcked(index, now)
+	case command.IsFailed(), command.IsCancelled():
+		reason := fmt.Sprintf("fragment %d was not delivered", index)
+		if command.ErrorMessage != nil && *command.ErrorMessage != "" {
+			reason = fmt.Sprintf("fragment %d was not delivered: %s", index, *command.ErrorMessage)
+		}
+		return true, job.RecordFragmentFailure(index, reason, now)
+	case fragment.SentAt != nil && now.Sub(fragment.SentAt.Time) > _configFragmentAckTimeout:
+		err := withdrawCommand(ctx, w.commandRepository, command.ID, "fragment acknowledgement timed out")
+		if err != nil {
+			return false, err
+		}
+		reason := fmt.Sprintf("fragment %d was not acknowledged within %s", index, _configFragmentAckTimeout)
+		return true, job.RecordFragmentFailure(index, reason, now)
+	default:
+		return false, nil
+	}
+}
+
+func (w *DeviceConfigJobWorker) Shutdown() {
+	slog.Warn("device config job worker shutdown is not yet implemented")
+}
@@ -0,0 +1,167 @@
+package usecases_test
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"time"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/shared_kernel/domain"
+
+	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = ginkgo.Describe("DeviceConfigJobWorker", func() {
+	var (
+		ctrl            *gomock.Controller
+		mockJobRepo     *mockusecases.MockDeviceConfigJobRepository
+		mockDeviceRepo  *mockusecases.MockDeviceRepository
+		mockCommandRepo *mockusecases.MockCommandRepository
+		ticker          *time.Ticker
+		device          domain.Device
+		job             domain.DeviceConfigJob
+		updated         chan domain.DeviceConfigJob
+		created         chan domain.Command
+	)
+
+	ginkgo.BeforeEach(func() {
+		ctrl = gomock.NewController(ginkgo.GinkgoT())
+		mockJobRepo = mockusecases.NewMockDeviceConfigJobRepository(ctrl)
+		mockDeviceRepo = mockusecases.NewMockDeviceRepository(ctrl)
+		mockCommandRepo = mockusecases.NewMockCommandRepository(ctrl)
+		ticker = time.NewTicker(10 * time.Millisecond)
+		device = domain.Device{ID: domain.ID("device-1"), Name: "valve-1"}
+		updated = make(chan domain.DeviceConfigJob, 1)
+		created = make(chan domain.Command, 4)
+
+		var err error
+		job, err = domain.NewDeviceConfigJob(device.ID, bytes.Repeat([]byte{0x01}, 20), 16, time.Now())
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		mockDeviceRepo.EXPECT().Get(gomock.Any(), "device-1").Return(device, nil).AnyTimes()
+		mockCommandRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, command domain.Command) error {
+			select {
+			case created <- command:
+			default:
+			}
+			return nil
+		}).AnyTimes()
+	})
+
+	ginkgo.AfterEach(func() {
+		ticker.Stop()
+	})
+
+	// runOnce lets the worker advance the job once and returns the job it stored.
+	runOnce := func(updateErr error) domain.DeviceConfigJob {
+		mockJobRepo.EXPECT().FindActive(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, int) ([]domain.DeviceConfigJob, error) {
+			stored := job
+			stored.Fragments = slices.Clone(job.Fragments)
+			return []domain.DeviceConfigJob{stored}, nil
+		}).MinTimes(1)
+		mockJobRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, value domain.DeviceConfigJob) error {
+			select {
+			case updated <- value:
+			default:
+			}
+			return updateErr
+		}).MinTimes(1)
+
+		worker := usecases.NewDeviceConfigJobWorker(ticker, mockJobRepo, mockDeviceRepo, mockCommandRepo)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go worker.Run(ctx, func() { close(done) })
+
+		var result domain.DeviceConfigJob
+		gomega.Eventually(updated).Should(gomega.Receive(&result))
+		cancel()
+		gomega.Eventually(done).Should(gomega.BeClosed())
+		return result
+	}
+
+	ginkgo.When("the job was not started", func() {
+		ginkgo.It("should queue the first fragment as a confirmed raw downlink", func() {
+			result := runOnce(nil)
+
+			var command domain.Command
+			gomega.Expect(created).To(gomega.Receive(&command))
+			gomega.Expect(command.Port).To(gomega.Equal(domain.DeviceConfigPort))
+			gomega.Expect(command.Confirmed).To(gomega.BeTrue())
+			gomega.Expect(command.RawPayload).To(gomega.Equal(job.Fragments[0].Payload))
+
+			gomega.Expect(result.Status).To(gomega.Equal(domain.DeviceConfigJobStatusRunning))
+			gomega.Expect(result.Fragments[0].Status).To(gomega.Equal(domain.DeviceConfigFragmentStatusSent))
+			gomega.Expect(*result.Fragments[0].CommandID).To(gomega.Equal(command.ID))
+		})
+
+		ginkgo.It("should withdraw the fragment when the job changed meanwhile", func() {
+			cancelled := make(chan domain.Command, 1)
+			mockCommandRepo.EXPECT().GetByID(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id domain.ID) (domain.Command, error) {
+				return domain.Command{ID: id, Status: domain.CommandStatusPending}, nil
+			}).MinTimes(1)
+			mockCommandRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, command domain.Command) error {
+				select {
+				case cancelled <- command:
+				default:
+				}
+				return nil
+			}).MinTimes(1)
+
+			runOnce(usecases.ErrDeviceConfigJobVersionConflict)
+
+			var command domain.Command
+			gomega.Eventually(cancelled).Should(gomega.Receive(&command))
+			gomega.Expect(command.Status).To(gomega.Equal(domain.CommandStatusCancelled))
+		})
+	})
+
+	ginkgo.When("the fragment being delivered was acknowledged", func() {
+		ginkgo.BeforeEach(func() {
+			gomega.Expect(job.RecordFragmentSent(0, domain.ID("command-0"), time.Now())).To(gomega.Succeed())
+			mockCommandRepo.EXPECT().GetByID(gomock.Any(), domain.ID("command-0")).
+				Return(domain.Command{ID: domain.ID("command-0"), Status: domain.CommandStatusAck}, nil).MinTimes(1)
+		})
+
+		ginkgo.It("should move on to the next fragment", func() {
+			result := runOnce(nil)
+
+			gomega.Expect(result.Fragments[0].Status).To(gomega.Equal(domain.DeviceConfigFragmentStatusAcked))
+			gomega.Expect(result.Fragments[1].Status).To(gomega.Equal(domain.DeviceConfigFragmentStatusSent))
+
+			var command domain.Command
+			gomega.Expect(created).To(gomega.Receive(&command))
+			gomega.Expect(command.RawPayload).To(gomega.Equal(job.Fragments[1].Payload))
+		})
+	})
+
+	ginkgo.When("the fragment being delivered failed", func() {
+		ginkgo.BeforeEach(func() {
+			gomega.Expect(job.RecordFragmentSent(0, domain.ID("command-0"), time.Now())).To(gomega.Succeed())
+			reason := "downlink queue full"
+			mockCommandRepo.EXPECT().GetByID(gomock.Any(), domain.ID("command-0")).
+				Return(domain.Command{ID: domain.ID("command-0"), Status: domain.CommandStatusFailed, ErrorMessage: &reason}, nil).MinTimes(1)
+		})
+
+		ginkgo.It("should send it again", func() {
+			result := runOnce(nil)
+
+			gomega.Expect(result.Status).To(gomega.Equal(domain.DeviceConfigJobStatusRunning))
+			gomega.Expect(result.Fragments[0].Attempts).To(gomega.Equal(2))
+			gomega.Expect(result.Fragments[0].Status).To(gomega.Equal(domain.DeviceConfigFragmentStatusSent))
+			gomega.Expect(result.LastError).To(gomega.ContainSubstring("downlink queue full"))
+		})
+
+		ginkgo.It("should fail the job once the fragment ran out of attempts", func() {
+			job.Fragments[0].Attempts = job.MaxAttempts
+
+			result := runOnce(nil)
+
+			gomega.Expect(result.Status).To(gomega.Equal(domain.DeviceConfigJobStatusFailed))
+			gomega.Expect(created).NotTo(gomega.Receive())
+		})
+	})
+})
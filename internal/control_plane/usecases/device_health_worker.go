@@ -0,0 +1,214 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
+)
+
+const (
+	_deviceHealthTopic       = "device_health"
+	_deviceHealthPageSize    = 500
+	_deviceHealthWindow      = 24 * time.Hour
+	_deviceHealthSampleLimit = 2000
+)
+
+func NewDeviceHealthWorker(
+	ticker *time.Ticker,
+	deviceRepository DeviceRepository,
+	sessionRepository DeviceSessionRepository,
+	commandRepository CommandRepository,
+	tenantConfigurationRepository TenantConfigurationRepository,
+	broker async.InternalBroker,
+	policy domain.DeviceHealthPolicy,
+) *DeviceHealthWorker {
+	return &DeviceHealthWorker{
+		ticker:                        ticker,
+		deviceRepository:              deviceRepository,
+		sessionRepository:             sessionRepository,
+		commandRepository:             commandRepository,
+		tenantConfigurationRepository: tenantConfigurationRepository,
+		broker:                        broker,
+		policy:                        policy,
+	}
+}
+
+var _ async.Worker = &DeviceHealthWorker{}
+
+// DeviceHealthWorker scores every device from its battery, the signal quality and regularity of
+// its uplinks and the failure rate of its commands over the last day, stores the result and
+// announces low battery transitions on the internal broker.
+type DeviceHealthWorker struct {
+	ticker                        *time.Ticker
+	deviceRepository              DeviceRepository
+	sessionRepository             DeviceSessionRepository
+	commandRepository             CommandRepository
+	tenantConfigurationRepository TenantConfigurationRepository
+	broker                        async.InternalBroker
+	policy                        domain.DeviceHealthPolicy
+}
+
+func (w *DeviceHealthWorker) Run(ctx context.Context, done func()) {
+	slog.Info("device health worker started")
+	defer done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("device health worker cancelled")
+			return
+		case <-w.ticker.C:
+			w.evaluate(context.Background(), time.Now())
+		}
+	}
+}
+
+func (w *DeviceHealthWorker) evaluate(ctx context.Context, now time.Time) {
+	policies := make(map[domain.ID]domain.DeviceHealthPolicy)
+	for offset := 0; ; offset += _deviceHealthPageSize {
+		devices, total, err := w.deviceRepository.FindAll(ctx, Pagination{Limit: _deviceHealthPageSize, Offset: offset})
+		if err != nil {
+			slog.Error("finding devices for health evaluation", slog.Any("error", err))
+			return
+		}
+
+		for _, device := range devices {
+			err := w.evaluateDevice(ctx, device, w.tenantPolicy(ctx, device, policies), now)
+			if err != nil {
+				slog.Error("evaluating device health",
+					slog.String("device_id", device.ID.String()),
+					slog.Any("error", err))
+			}
+		}
+
+		if len(devices) == 0 || offset+len(devices) >= total {
+			break
+		}
+	}
+}
+
+// tenantPolicy applies the low battery threshold of the device's tenant, loading each tenant
+// configuration once per evaluation.
+func (w *DeviceHealthWorker) tenantPolicy(ctx context.Context, device domain.Device, policies map[domain.ID]domain.DeviceHealthPolicy) domain.DeviceHealthPolicy {
+	if device.TenantID == nil {
+		return w.policy
+	}
+	if policy, ok := policies[*device.TenantID]; ok {
+		return policy
+	}
+
+	policy := w.policy
+	config, err := w.tenantConfigurationRepository.GetByTenantID(ctx, *device.TenantID)
+	switch {
+	case err == nil:
+		policy = policy.WithLowBatteryPercent(config.LowBatteryThreshold)
+	case !errors.Is(err, ErrTenantConfigurationNotFound):
+		slog.Warn("loading tenant configuration for health evaluation",
+			slog.String("tenant_id", device.TenantID.String()),
+			slog.Any("error", err))
+	}
+
+	policies[*device.TenantID] = policy
+	return policy
+}
+
+func (w *DeviceHealthWorker) evaluateDevice(ctx context.Context, device domain.Device, policy domain.DeviceHealthPolicy, now time.Time) error {
+	since := now.Add(-_deviceHealthWindow)
+	inputs := domain.DeviceHealthInputs{Battery: device.Battery}
+
+	samples, err := w.sessionRepository.FindSamplesSince(ctx, device.ID, since, _deviceHealthSampleLimit)
+	if err != nil {
+		return err
+	}
+	inputs.LinkQuality = domain.SummarizeLinkQuality(samples)
+	inputs.UplinksReceived = len(samples)
+
+	expected, err := w.expectedUplinks(ctx, device, since, now)
+	if err != nil {
+		return err
+	}
+	inputs.UplinksExpected = expected
+
+	inputs.Commands, err = w.commandRepository.CountOutcomesByDevice(ctx, device.ID, since)
+	if err != nil {
+		return err
+	}
+
+	previous := device.Health
+	device.Health = domain.EvaluateDeviceHealth(previous, inputs, policy, now)
+	err = w.deviceRepository.UpdateHealth(ctx, device)
+	if err != nil {
+		return err
+	}
+
+	// Without a battery reading the flag is cleared, but nothing is known to have recovered
+	if device.Health.LowBattery != previous.LowBattery && device.Health.BatteryPercent != nil {
+		slog.Info("device battery changed",
+			slog.String("device_id", device.ID.String()),
+			slog.Bool("low_battery", device.Health.LowBattery))
+		w.publish(ctx, device, policy, now)
+	}
+	return nil
+}
+
+// expectedUplinks counts the uplinks the device should have sent over the window, or since it
+// joined when that is later. Devices that never joined are not expected to uplink.
+func (w *DeviceHealthWorker) expectedUplinks(ctx context.Context, device domain.Device, since, now time.Time) (int, error) {
+	session, err := w.sessionRepository.GetSession(ctx, device.ID)
+	if errors.Is(err, ErrDeviceSessionNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if session.JoinedAt == nil {
+		return 0, nil
+	}
+
+	start := since
+	if session.JoinedAt.After(start) {
+		start = session.JoinedAt.Time
+	}
+
+	expected := int(now.Sub(start) / device.UplinkInterval())
+	return min(expected, _deviceHealthSampleLimit), nil
+}
+
+func (w *DeviceHealthWorker) publish(ctx context.Context, device domain.Device, policy domain.DeviceHealthPolicy, now time.Time) {
+	tenantID := ""
+	if device.TenantID != nil {
+		tenantID = device.TenantID.String()
+	}
+
+	displayName := device.DisplayName
+	if displayName == "" {
+		displayName = device.Name
+	}
+
+	eventType := events.DeviceBatteryRecovered
+	if device.Health.LowBattery {
+		eventType = events.DeviceBatteryLow
+	}
+
+	event := eventType.New(tenantID, events.DeviceBattery{
+		DeviceID:          device.ID.String(),
+		DeviceName:        device.Name,
+		DisplayName:       displayName,
+		BatteryPercent:    *device.Health.BatteryPercent,
+		BatteryVoltage:    device.Battery.Voltage,
+		LowBatteryPercent: policy.LowBatteryPercent,
+	})
+	event.OccurredAt = now
+	brokerMsg := event.Message()
+	if err := w.broker.Publish(ctx, async.BrokerTopicName(_deviceHealthTopic), brokerMsg); err != nil {
+		slog.Error("failed to publish device health event", slog.Any("error", err))
+	}
+}
+
+func (w *DeviceHealthWorker) Shutdown() {
+	slog.Warn("device health worker shutdown is not yet implemented")
+}
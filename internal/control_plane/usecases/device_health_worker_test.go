@@ -0,0 +1,150 @@
+package usecases_test
+
+import (
+	"context"
+	"time"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
+
+	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+	mockasync "zensor-server/test/unit/doubles/infra/async"
+	sharedkernel_mocks "zensor-server/test/unit/doubles/shared_kernel/usecases"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = ginkgo.Describe("DeviceHealthWorker", func() {
+	var (
+		ctrl              *gomock.Controller
+		mockDeviceRepo    *mockusecases.MockDeviceRepository
+		mockSessionRepo   *mockusecases.MockDeviceSessionRepository
+		mockCommandRepo   *mockusecases.MockCommandRepository
+		mockTenantConfigs *sharedkernel_mocks.MockTenantConfigurationRepository
+		mockBroker        *mockasync.MockInternalBroker
+		ticker            *time.Ticker
+		policy            domain.DeviceHealthPolicy
+		tenantID          domain.ID
+		device            domain.Device
+		evaluated         chan domain.Device
+		published         chan async.BrokerMessage
+	)
+
+	ginkgo.BeforeEach(func() {
+		ctrl = gomock.NewController(ginkgo.GinkgoT())
+		mockDeviceRepo = mockusecases.NewMockDeviceRepository(ctrl)
+		mockSessionRepo = mockusecases.NewMockDeviceSessionRepository(ctrl)
+		mockCommandRepo = mockusecases.NewMockCommandRepository(ctrl)
+		mockTenantConfigs = sharedkernel_mocks.NewMockTenantConfigurationRepository(ctrl)
+		mockBroker = mockasync.NewMockInternalBroker(ctrl)
+		ticker = time.NewTicker(10 * time.Millisecond)
+		policy = domain.DeviceHealthPolicy{BatteryEmptyVoltage: 3.0, BatteryFullVoltage: 4.2, LowBatteryPercent: 20}
+		tenantID = domain.ID("tenant-1")
+		device = domain.Device{
+			ID:                     domain.ID("device-1"),
+			Name:                   "device-1",
+			TenantID:               &tenantID,
+			ExpectedUplinkInterval: time.Hour,
+			Battery:                &domain.BatteryReading{Voltage: 3.42, ReportedAt: utils.Time{Time: time.Now()}},
+		}
+		evaluated = make(chan domain.Device, 1)
+		published = make(chan async.BrokerMessage, 1)
+	})
+
+	ginkgo.AfterEach(func() {
+		ticker.Stop()
+	})
+
+	expectEvaluation := func(threshold int) {
+		joinedAt := utils.Time{Time: time.Now().Add(-48 * time.Hour)}
+		samples := make([]domain.LinkQualitySample, 12)
+		for i := range samples {
+			samples[i] = domain.LinkQualitySample{Gateways: []domain.GatewayReception{{GatewayID: "gateway-1", RSSI: -80, SNR: 7}}}
+		}
+
+		mockDeviceRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return([]domain.Device{device}, 1, nil).MinTimes(1)
+		mockTenantConfigs.EXPECT().GetByTenantID(gomock.Any(), tenantID).
+			Return(domain.TenantConfiguration{TenantID: tenantID, LowBatteryThreshold: &threshold}, nil).MinTimes(1)
+		mockSessionRepo.EXPECT().FindSamplesSince(gomock.Any(), device.ID, gomock.Any(), gomock.Any()).Return(samples, nil).MinTimes(1)
+		mockSessionRepo.EXPECT().GetSession(gomock.Any(), device.ID).
+			Return(domain.DeviceSession{DeviceID: device.ID, JoinedAt: &joinedAt}, nil).MinTimes(1)
+		mockCommandRepo.EXPECT().CountOutcomesByDevice(gomock.Any(), device.ID, gomock.Any()).
+			Return(domain.CommandOutcomes{Succeeded: 1}, nil).MinTimes(1)
+		mockDeviceRepo.EXPECT().UpdateHealth(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, value domain.Device) error {
+			select {
+			case evaluated <- value:
+			default:
+			}
+			return nil
+		}).MinTimes(1)
+	}
+
+	runUntilEvaluated := func() domain.Device {
+		worker := usecases.NewDeviceHealthWorker(ticker, mockDeviceRepo, mockSessionRepo, mockCommandRepo, mockTenantConfigs, mockBroker, policy)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go worker.Run(ctx, func() { close(done) })
+
+		var result domain.Device
+		gomega.Eventually(evaluated).Should(gomega.Receive(&result))
+		cancel()
+		gomega.Eventually(done).Should(gomega.BeClosed())
+		return result
+	}
+
+	ginkgo.When("the battery drops below the tenant threshold", func() {
+		ginkgo.It("should store the health and publish a device_battery_low event", func() {
+			expectEvaluation(40)
+			mockBroker.EXPECT().Publish(gomock.Any(), async.BrokerTopicName("device_health"), gomock.Any()).DoAndReturn(func(_ context.Context, _ async.BrokerTopicName, msg async.BrokerMessage) error {
+				select {
+				case published <- msg:
+				default:
+				}
+				return nil
+			}).MinTimes(1)
+
+			result := runUntilEvaluated()
+			gomega.Expect(result.Health.LowBattery).To(gomega.BeTrue())
+			gomega.Expect(*result.Health.BatteryPercent).To(gomega.Equal(35))
+			gomega.Expect(*result.Health.SignalQuality).To(gomega.Equal(85))
+			gomega.Expect(*result.Health.UplinkRegularity).To(gomega.Equal(50))
+			gomega.Expect(*result.Health.CommandFailureRate).To(gomega.BeZero())
+			gomega.Expect(result.Health.Status).To(gomega.Equal(domain.DeviceHealthStatusDegraded))
+
+			var msg async.BrokerMessage
+			gomega.Eventually(published).Should(gomega.Receive(&msg))
+			gomega.Expect(msg.Event).To(gomega.Equal("device_battery_low"))
+			event, err := events.DeviceBatteryLow.Decode(msg)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(event.TenantID).To(gomega.Equal("tenant-1"))
+			gomega.Expect(event.Payload.BatteryPercent).To(gomega.Equal(35))
+			gomega.Expect(event.Payload.LowBatteryPercent).To(gomega.Equal(40))
+		})
+	})
+
+	ginkgo.When("the battery stays above the tenant threshold", func() {
+		ginkgo.It("should store the health without publishing", func() {
+			expectEvaluation(20)
+
+			result := runUntilEvaluated()
+			gomega.Expect(result.Health.LowBattery).To(gomega.BeFalse())
+			gomega.Expect(result.Health.Score).NotTo(gomega.BeNil())
+		})
+	})
+
+	ginkgo.When("a device flagged with a low battery has no battery reading", func() {
+		ginkgo.It("should store the health without publishing a recovery", func() {
+			device.Battery = nil
+			device.Health.LowBattery = true
+			expectEvaluation(20)
+
+			result := runUntilEvaluated()
+			gomega.Expect(result.Health.LowBattery).To(gomega.BeFalse())
+			gomega.Expect(result.Health.BatteryPercent).To(gomega.BeNil())
+		})
+	})
+})
@@ -0,0 +1,267 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+var ErrDeviceImportInvalid = errors.New("device import has invalid rows")
+
+const (
+	_exportPageSize = 500
+)
+
+// DeviceImportRow is a device to provision as read from an import file. Empty keys are generated.
+type DeviceImportRow struct {
+	Line        int
+	Name        string
+	DisplayName string
+	DevEUI      string
+	JoinEUI     string
+	AppKey      string
+	TenantID    string
+	Profile     string
+	Sector      string
+}
+
+type DeviceImportRowStatus string
+
+const (
+	DeviceImportRowStatusValid   DeviceImportRowStatus = "valid"   // The row passed validation in a dry run
+	DeviceImportRowStatusCreated DeviceImportRowStatus = "created" // The device was created
+	DeviceImportRowStatusInvalid DeviceImportRowStatus = "invalid" // The row has errors and nothing was created
+)
+
+type DeviceImportRowResult struct {
+	Line     int
+	Name     string
+	DeviceID domain.ID
+	Status   DeviceImportRowStatus
+	Errors   []string
+}
+
+// DeviceImportReport describes the outcome of every row of an import. The batch is all or
+// nothing: when any row is invalid no device is created.
+type DeviceImportReport struct {
+	DryRun  bool
+	Total   int
+	Created int
+	Invalid int
+	Rows    []DeviceImportRowResult
+}
+
+// NewDeviceImportService builds the import service. When a provisioner is given, imported devices
+// are left pending for the provisioning worker to register in the network server.
+func NewDeviceImportService(
+	repository DeviceRepository,
+	tenantService TenantService,
+	provisioner NetworkServerProvisioner,
+) *SimpleDeviceImportService {
+	return &SimpleDeviceImportService{
+		repository:    repository,
+		tenantService: tenantService,
+		provisioner:   provisioner,
+	}
+}
+
+var _ DeviceImportService = (*SimpleDeviceImportService)(nil)
+
+type SimpleDeviceImportService struct {
+	repository    DeviceRepository
+	tenantService TenantService
+	provisioner   NetworkServerProvisioner
+}
+
+func (s *SimpleDeviceImportService) Import(ctx context.Context, rows []DeviceImportRow, dryRun bool) (DeviceImportReport, error) {
+	report := DeviceImportReport{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Rows:   make([]DeviceImportRowResult, len(rows)),
+	}
+
+	devices := make([]domain.Device, len(rows))
+	names := make(map[string]int, len(rows))
+	devEUIs := make(map[string]int, len(rows))
+	tenants := make(map[string]error)
+
+	for i, row := range rows {
+		device, errs := s.validateRow(ctx, row, tenants)
+		if device.Name != "" {
+			if line, ok := names[device.Name]; ok {
+				errs = append(errs, fmt.Sprintf("name is repeated from line %d", line))
+			}
+			names[device.Name] = row.Line
+		}
+		if row.DevEUI != "" {
+			key := strings.ToUpper(device.DevEUI)
+			if line, ok := devEUIs[key]; ok {
+				errs = append(errs, fmt.Sprintf("dev_eui is repeated from line %d", line))
+			}
+			devEUIs[key] = row.Line
+		}
+
+		devices[i] = device
+		report.Rows[i] = DeviceImportRowResult{
+			Line:     row.Line,
+			Name:     row.Name,
+			DeviceID: device.ID,
+			Status:   DeviceImportRowStatusValid,
+			Errors:   errs,
+		}
+		if len(errs) > 0 {
+			report.Rows[i].Status = DeviceImportRowStatusInvalid
+			report.Rows[i].DeviceID = ""
+			report.Invalid++
+		}
+	}
+
+	if report.Invalid > 0 {
+		return report, ErrDeviceImportInvalid
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	if s.provisioner != nil {
+		now := time.Now()
+		for i := range devices {
+			devices[i].RequestProvisioning(now)
+		}
+	}
+
+	err := s.repository.CreateDevices(ctx, devices)
+	if err != nil {
+		return DeviceImportReport{}, fmt.Errorf("creating devices: %w", err)
+	}
+
+	for i := range report.Rows {
+		report.Rows[i].Status = DeviceImportRowStatusCreated
+	}
+	report.Created = len(devices)
+
+	slog.Info("devices imported", slog.Int("count", report.Created))
+	return report, nil
+}
+
+func (s *SimpleDeviceImportService) validateRow(ctx context.Context, row DeviceImportRow, tenants map[string]error) (domain.Device, []string) {
+	var errs []string
+
+	name := strings.TrimSpace(row.Name)
+	if name == "" {
+		errs = append(errs, "name is required")
+	}
+
+	displayName := row.DisplayName
+	if displayName == "" {
+		displayName = name
+	}
+
+	builder := domain.NewDeviceBuilder().
+		WithName(name).
+		WithDisplayName(displayName).
+		WithProfile(row.Profile)
+	if row.DevEUI != "" {
+		builder = builder.WithDevEUI(row.DevEUI)
+	}
+	if row.JoinEUI != "" {
+		builder = builder.WithAppEUI(row.JoinEUI)
+	}
+	if row.AppKey != "" {
+		builder = builder.WithAppKey(row.AppKey)
+	} else if row.DevEUI != "" {
+		// A generated key would not match the one flashed on the device, which could never join
+		errs = append(errs, "app_key is required when dev_eui is set")
+	}
+	if row.Sector != "" {
+		builder = builder.WithSector(row.Sector)
+	}
+	if row.TenantID != "" {
+		builder = builder.WithTenant(domain.ID(row.TenantID))
+	}
+
+	device, err := builder.Build()
+	if err != nil {
+		return domain.Device{}, append(errs, err.Error())
+	}
+
+	if err := device.ValidateLoRaWANKeys(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if name != "" {
+		_, err := s.repository.FindByName(ctx, name)
+		switch {
+		case err == nil:
+			errs = append(errs, "a device with this name already exists")
+		case !errors.Is(err, ErrDeviceNotFound):
+			errs = append(errs, "could not check the device name")
+		}
+	}
+
+	if row.DevEUI != "" {
+		_, err := s.repository.FindByDevEUI(ctx, device.DevEUI)
+		switch {
+		case err == nil:
+			errs = append(errs, "a device with this dev_eui already exists")
+		case !errors.Is(err, ErrDeviceNotFound):
+			errs = append(errs, "could not check the dev_eui")
+		}
+	}
+
+	if row.TenantID != "" {
+		if err := s.checkTenant(ctx, row.TenantID, tenants); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	return device, errs
+}
+
+func (s *SimpleDeviceImportService) checkTenant(ctx context.Context, tenantID string, checked map[string]error) error {
+	if err, ok := checked[tenantID]; ok {
+		return err
+	}
+
+	tenant, err := s.tenantService.GetTenant(ctx, domain.ID(tenantID))
+	switch {
+	case errors.Is(err, ErrTenantNotFound), err == nil && tenant.IsDeleted():
+		err = errors.New("tenant not found")
+	case err != nil:
+		err = errors.New("could not check the tenant")
+	}
+
+	checked[tenantID] = err
+	return err
+}
+
+func (s *SimpleDeviceImportService) Export(ctx context.Context, tenantID *domain.ID) ([]domain.Device, error) {
+	var result []domain.Device
+	for offset := 0; ; offset += _exportPageSize {
+		pagination := Pagination{Limit: _exportPageSize, Offset: offset}
+
+		var (
+			devices []domain.Device
+			total   int
+			err     error
+		)
+		if tenantID != nil {
+			devices, total, err = s.repository.FindByTenant(ctx, tenantID.String(), pagination)
+		} else {
+			devices, total, err = s.repository.FindAll(ctx, pagination)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("listing devices: %w", err)
+		}
+
+		result = append(result, devices...)
+		if len(devices) == 0 || offset+len(devices) >= total {
+			return result, nil
+		}
+	}
+}
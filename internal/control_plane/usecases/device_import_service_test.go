@@ -0,0 +1,123 @@
+package usecases_test
+
+import (
+	"context"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/ttn"
+	"zensor-server/internal/shared_kernel/domain"
+
+	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+	mocksharedusecases "zensor-server/test/unit/doubles/shared_kernel/usecases"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = ginkgo.Describe("DeviceImportService", func() {
+	var (
+		ctrl              *gomock.Controller
+		mockDeviceRepo    *mockusecases.MockDeviceRepository
+		mockTenantService *mocksharedusecases.MockTenantService
+		service           *usecases.SimpleDeviceImportService
+		ctx               context.Context
+		rows              []usecases.DeviceImportRow
+	)
+
+	ginkgo.BeforeEach(func() {
+		ctrl = gomock.NewController(ginkgo.GinkgoT())
+		mockDeviceRepo = mockusecases.NewMockDeviceRepository(ctrl)
+		mockTenantService = mocksharedusecases.NewMockTenantService(ctrl)
+		service = usecases.NewDeviceImportService(mockDeviceRepo, mockTenantService, ttn.NewFakeProvisioner())
+		ctx = context.Background()
+
+		rows = []usecases.DeviceImportRow{
+			{Line: 2, Name: "valve-1", DevEUI: "0004A30B001C0530", AppKey: "2B7E151628AED2A6ABF7158809CF4F3C", TenantID: "tenant-1", Profile: "rak-3172", Sector: "B"},
+			{Line: 3, Name: "valve-2", TenantID: "tenant-1"},
+		}
+	})
+
+	ginkgo.AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	expectLookups := func() {
+		mockDeviceRepo.EXPECT().FindByName(ctx, gomock.Any()).Return(domain.Device{}, usecases.ErrDeviceNotFound).AnyTimes()
+		mockDeviceRepo.EXPECT().FindByDevEUI(ctx, gomock.Any()).Return(domain.Device{}, usecases.ErrDeviceNotFound).AnyTimes()
+		mockTenantService.EXPECT().GetTenant(ctx, domain.ID("tenant-1")).Return(domain.Tenant{ID: "tenant-1"}, nil).Times(1)
+	}
+
+	ginkgo.When("every row is valid", func() {
+		ginkgo.It("should create all the devices in one batch pending provisioning", func() {
+			expectLookups()
+			mockDeviceRepo.EXPECT().CreateDevices(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, devices []domain.Device) error {
+				gomega.Expect(devices).To(gomega.HaveLen(2))
+				gomega.Expect(devices[0].DevEUI).To(gomega.Equal("0004A30B001C0530"))
+				gomega.Expect(devices[0].Profile).To(gomega.Equal("rak-3172"))
+				gomega.Expect(devices[0].Sector.Name).To(gomega.Equal(domain.Name("B")))
+				gomega.Expect(devices[1].BelongsToTenant("tenant-1")).To(gomega.BeTrue())
+				gomega.Expect(devices[1].Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusPending))
+				return nil
+			})
+
+			report, err := service.Import(ctx, rows, false)
+
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(report.Created).To(gomega.Equal(2))
+			gomega.Expect(report.Rows[0].Status).To(gomega.Equal(usecases.DeviceImportRowStatusCreated))
+			gomega.Expect(report.Rows[0].DeviceID).NotTo(gomega.BeEmpty())
+		})
+	})
+
+	ginkgo.When("running a dry run", func() {
+		ginkgo.It("should validate without creating anything", func() {
+			expectLookups()
+
+			report, err := service.Import(ctx, rows, true)
+
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(report.Created).To(gomega.BeZero())
+			gomega.Expect(report.Rows[1].Status).To(gomega.Equal(usecases.DeviceImportRowStatusValid))
+		})
+	})
+
+	ginkgo.When("some rows are invalid", func() {
+		ginkgo.It("should report every error and create nothing", func() {
+			rows = append(rows,
+				usecases.DeviceImportRow{Line: 4, Name: "valve-1", AppKey: "short"},
+				usecases.DeviceImportRow{Line: 5, DevEUI: "0004a30b001c0530"},
+				usecases.DeviceImportRow{Line: 6, Name: "field/valve-3"},
+			)
+			expectLookups()
+
+			report, err := service.Import(ctx, rows, false)
+
+			gomega.Expect(err).To(gomega.MatchError(usecases.ErrDeviceImportInvalid))
+			gomega.Expect(report.Invalid).To(gomega.Equal(3))
+			gomega.Expect(report.Rows[0].Status).To(gomega.Equal(usecases.DeviceImportRowStatusValid))
+			gomega.Expect(report.Rows[2].Errors).To(gomega.ConsistOf(
+				domain.ErrInvalidAppKey.Error(),
+				"name is repeated from line 2",
+			))
+			gomega.Expect(report.Rows[3].Errors).To(gomega.ConsistOf(
+				"name is required",
+				"app_key is required when dev_eui is set",
+				"dev_eui is repeated from line 2",
+			))
+			gomega.Expect(report.Rows[4].Errors).To(gomega.ConsistOf(domain.ErrInvalidDeviceName.Error()))
+		})
+	})
+
+	ginkgo.When("the tenant does not exist", func() {
+		ginkgo.It("should reject the rows of that tenant", func() {
+			mockDeviceRepo.EXPECT().FindByName(ctx, gomock.Any()).Return(domain.Device{}, usecases.ErrDeviceNotFound).AnyTimes()
+			mockDeviceRepo.EXPECT().FindByDevEUI(ctx, gomock.Any()).Return(domain.Device{}, usecases.ErrDeviceNotFound).AnyTimes()
+			mockTenantService.EXPECT().GetTenant(ctx, domain.ID("tenant-1")).Return(domain.Tenant{}, usecases.ErrTenantNotFound)
+
+			report, err := service.Import(ctx, rows, true)
+
+			gomega.Expect(err).To(gomega.MatchError(usecases.ErrDeviceImportInvalid))
+			gomega.Expect(report.Invalid).To(gomega.Equal(2))
+		})
+	})
+})
@@ -0,0 +1,71 @@
+package usecases
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"zensor-server/internal/infra/async"
+)
+
+const (
+	_deviceKeyRotationBatchSize = 100
+)
+
+func NewDeviceKeyRotationWorker(ticker *time.Ticker, repository DeviceRepository, webhookRepository WebhookSubscriptionRepository) *DeviceKeyRotationWorker {
+	return &DeviceKeyRotationWorker{
+		ticker:            ticker,
+		repository:        repository,
+		webhookRepository: webhookRepository,
+	}
+}
+
+var _ async.Worker = &DeviceKeyRotationWorker{}
+
+// DeviceKeyRotationWorker seals the AppKeys still stored in plaintext and rewraps the AppKeys and
+// webhook secrets sealed with a retired key, so retired keys can be dropped from the
+// configuration once it catches up.
+type DeviceKeyRotationWorker struct {
+	ticker            *time.Ticker
+	repository        DeviceRepository
+	webhookRepository WebhookSubscriptionRepository
+}
+
+func (w *DeviceKeyRotationWorker) Run(ctx context.Context, done func()) {
+	slog.Info("device key rotation worker started")
+	defer done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("device key rotation worker cancelled")
+			return
+		case <-w.ticker.C:
+			w.reseal(ctx, "device app keys", w.repository.ResealAppKeys)
+			w.reseal(ctx, "webhook secrets", w.webhookRepository.ResealSecrets)
+		}
+	}
+}
+
+func (w *DeviceKeyRotationWorker) reseal(ctx context.Context, kind string, reseal func(ctx context.Context, limit int) (int, error)) {
+	total := 0
+	for ctx.Err() == nil {
+		count, err := reseal(ctx, _deviceKeyRotationBatchSize)
+		if err != nil {
+			slog.Error("resealing "+kind, slog.Any("error", err))
+			return
+		}
+
+		total += count
+		if count < _deviceKeyRotationBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		slog.Info(kind+" resealed", slog.Int("count", total))
+	}
+}
+
+func (w *DeviceKeyRotationWorker) Shutdown() {
+	slog.Warn("device key rotation worker shutdown is not yet implemented")
+}
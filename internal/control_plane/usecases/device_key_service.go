@@ -0,0 +1,61 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+func NewDeviceKeyService(
+	deviceRepository DeviceRepository,
+	accessRepository DeviceKeyAccessRepository,
+) *SimpleDeviceKeyService {
+	return &SimpleDeviceKeyService{
+		deviceRepository: deviceRepository,
+		accessRepository: accessRepository,
+	}
+}
+
+var _ DeviceKeyService = (*SimpleDeviceKeyService)(nil)
+
+type SimpleDeviceKeyService struct {
+	deviceRepository DeviceRepository
+	accessRepository DeviceKeyAccessRepository
+}
+
+func (s *SimpleDeviceKeyService) RevealKeys(ctx context.Context, deviceID, userID domain.ID, userEmail string) (domain.Device, error) {
+	device, err := s.deviceRepository.Get(ctx, deviceID.String())
+	if err != nil {
+		return domain.Device{}, fmt.Errorf("getting device: %w", err)
+	}
+
+	// The access is recorded before the keys leave the service, so no reveal goes unaudited.
+	access := domain.NewDeviceKeyAccess(device.ID, userID, userEmail, time.Now())
+	err = s.accessRepository.Create(ctx, access)
+	if err != nil {
+		return domain.Device{}, fmt.Errorf("recording key access: %w", err)
+	}
+
+	slog.Info("device keys revealed",
+		slog.String("device_id", device.ID.String()),
+		slog.String("user_id", userID.String()),
+		slog.String("user_email", userEmail),
+	)
+	return device, nil
+}
+
+func (s *SimpleDeviceKeyService) KeyAccesses(ctx context.Context, deviceID domain.ID, pagination Pagination) ([]domain.DeviceKeyAccess, int, error) {
+	_, err := s.deviceRepository.Get(ctx, deviceID.String())
+	if err != nil {
+		return nil, 0, fmt.Errorf("getting device: %w", err)
+	}
+
+	accesses, total, err := s.accessRepository.FindAllByDevice(ctx, deviceID, pagination)
+	if err != nil {
+		return nil, 0, fmt.Errorf("finding key accesses: %w", err)
+	}
+
+	return accesses, total, nil
+}
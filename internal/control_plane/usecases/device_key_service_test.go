@@ -0,0 +1,74 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/shared_kernel/domain"
+
+	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = ginkgo.Describe("DeviceKeyService", func() {
+	var (
+		ctrl           *gomock.Controller
+		mockDeviceRepo *mockusecases.MockDeviceRepository
+		mockAccessRepo *mockusecases.MockDeviceKeyAccessRepository
+		service        *usecases.SimpleDeviceKeyService
+		ctx            context.Context
+		device         domain.Device
+	)
+
+	ginkgo.BeforeEach(func() {
+		ctrl = gomock.NewController(ginkgo.GinkgoT())
+		mockDeviceRepo = mockusecases.NewMockDeviceRepository(ctrl)
+		mockAccessRepo = mockusecases.NewMockDeviceKeyAccessRepository(ctrl)
+		service = usecases.NewDeviceKeyService(mockDeviceRepo, mockAccessRepo)
+		ctx = context.Background()
+		device = domain.Device{ID: domain.ID("device-1"), Name: "valve-1", AppKey: "000102030405060708090A0B0C0D0E0F"}
+	})
+
+	ginkgo.AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	ginkgo.When("the keys are revealed", func() {
+		ginkgo.It("should record who accessed them", func() {
+			mockDeviceRepo.EXPECT().Get(ctx, "device-1").Return(device, nil)
+			mockAccessRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, access domain.DeviceKeyAccess) error {
+				gomega.Expect(access.DeviceID).To(gomega.Equal(domain.ID("device-1")))
+				gomega.Expect(access.UserID).To(gomega.Equal(domain.ID("user-1")))
+				gomega.Expect(access.UserEmail).To(gomega.Equal("admin@example.com"))
+				gomega.Expect(access.AccessedAt.IsZero()).To(gomega.BeFalse())
+				return nil
+			})
+
+			result, err := service.RevealKeys(ctx, device.ID, domain.ID("user-1"), "admin@example.com")
+
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(result.AppKey).To(gomega.Equal(device.AppKey))
+		})
+
+		ginkgo.It("should not reveal them when the access cannot be recorded", func() {
+			mockDeviceRepo.EXPECT().Get(ctx, "device-1").Return(device, nil)
+			mockAccessRepo.EXPECT().Create(ctx, gomock.Any()).Return(errors.New("database down"))
+
+			result, err := service.RevealKeys(ctx, device.ID, domain.ID("user-1"), "admin@example.com")
+
+			gomega.Expect(err).To(gomega.HaveOccurred())
+			gomega.Expect(result.AppKey).To(gomega.BeEmpty())
+		})
+
+		ginkgo.It("should report unknown devices", func() {
+			mockDeviceRepo.EXPECT().Get(ctx, "device-1").Return(domain.Device{}, usecases.ErrDeviceNotFound)
+
+			_, err := service.RevealKeys(ctx, device.ID, domain.ID("user-1"), "admin@example.com")
+
+			gomega.Expect(err).To(gomega.MatchError(usecases.ErrDeviceNotFound))
+		})
+	})
+})
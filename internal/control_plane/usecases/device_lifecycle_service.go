@@ -0,0 +1,367 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+var (
+	ErrDeviceIsOrphan            = errors.New("device is not adopted by any tenant")
+	ErrDeviceAlreadyInTenant     = errors.New("device already belongs to the tenant")
+	ErrInvalidDeviceTransferMode = errors.New("invalid device transfer mode")
+)
+
+// DeviceTransferMode decides what happens to the scheduled tasks of a device moving between tenants.
+type DeviceTransferMode string
+
+const (
+	// DeviceTransferModeRehome moves the scheduled tasks that only drive the device, with their
+	// run history, to the new tenant.
+	DeviceTransferModeRehome DeviceTransferMode = "rehome"
+	// DeviceTransferModeArchive leaves the scheduled tasks and their history with the old tenant,
+	// deactivated.
+	DeviceTransferModeArchive DeviceTransferMode = "archive"
+)
+
+func (m DeviceTransferMode) IsValid() bool {
+	return m == DeviceTransferModeRehome || m == DeviceTransferModeArchive
+}
+
+const (
+	_decommissionedReason = "device decommissioned"
+	_releasedReason       = "device released from tenant"
+	_transferredReason    = "device transferred to another tenant"
+)
+
+func NewDeviceLifecycleService(
+	repository DeviceRepository,
+	commandRepository CommandRepository,
+	scheduledTaskRepository ScheduledTaskRepository,
+	scheduledTaskRunRepository ScheduledTaskRunRepository,
+	commandTemplateSetRepository CommandTemplateSetRepository,
+	evaluationRuleRepository EvaluationRuleRepository,
+	provisioner NetworkServerProvisioner,
+	transactor Transactor,
+) *SimpleDeviceLifecycleService {
+	return &SimpleDeviceLifecycleService{
+		repository:                   repository,
+		commandRepository:            commandRepository,
+		scheduledTaskRepository:      scheduledTaskRepository,
+		scheduledTaskRunRepository:   scheduledTaskRunRepository,
+		commandTemplateSetRepository: commandTemplateSetRepository,
+		evaluationRuleRepository:     evaluationRuleRepository,
+		provisioner:                  provisioner,
+		transactor:                   transactor,
+	}
+}
+
+var _ DeviceLifecycleService = (*SimpleDeviceLifecycleService)(nil)
+
+type SimpleDeviceLifecycleService struct {
+	repository                   DeviceRepository
+	commandRepository            CommandRepository
+	scheduledTaskRepository      ScheduledTaskRepository
+	scheduledTaskRunRepository   ScheduledTaskRunRepository
+	commandTemplateSetRepository CommandTemplateSetRepository
+	evaluationRuleRepository     EvaluationRuleRepository
+	provisioner                  NetworkServerProvisioner
+	transactor                   Transactor
+}
+
+// Decommission, Release and Transfer each write the device, its commands and its scheduled tasks
+// in one transaction, so a failure part way leaves none of them changed.
+func (s *SimpleDeviceLifecycleService) Decommission(ctx context.Context, deviceID domain.ID) error {
+	var device domain.Device
+	err := s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		device, err = s.getDevice(ctx, deviceID)
+		if err != nil {
+			return err
+		}
+
+		err = s.cancelPendingCommands(ctx, device, _decommissionedReason)
+		if err != nil {
+			return err
+		}
+
+		err = s.detachScheduledTasks(ctx, device)
+		if err != nil {
+			return err
+		}
+
+		err = s.evaluationRuleRepository.DisableAllByDeviceID(ctx, device.ID.String())
+		if err != nil {
+			return fmt.Errorf("disabling evaluation rules: %w", err)
+		}
+
+		device.Decommission()
+		if device.Provisioning.IsManaged() {
+			device.RequestProvisioning(time.Now())
+		}
+
+		err = s.repository.UpdateDevice(ctx, device)
+		if err != nil {
+			return fmt.Errorf("updating device: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	slog.Info("device decommissioned", slog.String("device_id", device.ID.String()))
+
+	if s.provisioner != nil && device.Provisioning.IsManaged() {
+		s.deprovision(ctx, device)
+	}
+
+	return nil
+}
+
+func (s *SimpleDeviceLifecycleService) Release(ctx context.Context, deviceID domain.ID) (domain.Device, error) {
+	var device domain.Device
+	var tenantID domain.ID
+	err := s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		device, err = s.getDevice(ctx, deviceID)
+		if err != nil {
+			return err
+		}
+
+		if device.IsOrphan() {
+			return ErrDeviceIsOrphan
+		}
+
+		err = s.cancelPendingCommands(ctx, device, _releasedReason)
+		if err != nil {
+			return err
+		}
+
+		err = s.detachScheduledTasks(ctx, device)
+		if err != nil {
+			return err
+		}
+
+		tenantID = *device.TenantID
+		device.Release()
+		err = s.repository.UpdateDevice(ctx, device)
+		if err != nil {
+			return fmt.Errorf("updating device: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Device{}, err
+	}
+
+	slog.Info("device released from tenant",
+		slog.String("device_id", device.ID.String()),
+		slog.String("tenant_id", tenantID.String()))
+	return device, nil
+}
+
+func (s *SimpleDeviceLifecycleService) Transfer(ctx context.Context, deviceID, tenantID domain.ID, mode DeviceTransferMode) (domain.Device, error) {
+	if !mode.IsValid() {
+		return domain.Device{}, ErrInvalidDeviceTransferMode
+	}
+
+	var device domain.Device
+	var previousTenantID domain.ID
+	err := s.transactor.InTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		device, err = s.getDevice(ctx, deviceID)
+		if err != nil {
+			return err
+		}
+
+		if device.IsOrphan() {
+			return ErrDeviceIsOrphan
+		}
+
+		if device.BelongsToTenant(tenantID) {
+			return ErrDeviceAlreadyInTenant
+		}
+
+		err = s.cancelPendingCommands(ctx, device, _transferredReason)
+		if err != nil {
+			return err
+		}
+
+		if mode == DeviceTransferModeRehome {
+			err = s.rehomeScheduledTasks(ctx, device, tenantID)
+		} else {
+			err = s.detachScheduledTasks(ctx, device)
+		}
+		if err != nil {
+			return err
+		}
+
+		previousTenantID = *device.TenantID
+		device.AdoptToTenant(tenantID)
+		err = s.repository.UpdateDevice(ctx, device)
+		if err != nil {
+			return fmt.Errorf("updating device: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Device{}, err
+	}
+
+	slog.Info("device transferred to tenant",
+		slog.String("device_id", device.ID.String()),
+		slog.String("from_tenant_id", previousTenantID.String()),
+		slog.String("to_tenant_id", tenantID.String()),
+		slog.String("mode", string(mode)))
+	return device, nil
+}
+
+// deprovision removes the decommissioned device from the network server once the decommission is
+// committed, so the transaction never waits on it. A failure does not undo the decommission; the
+// device stays due and the provisioning worker retries it.
+func (s *SimpleDeviceLifecycleService) deprovision(ctx context.Context, device domain.Device) {
+	err := s.provisioner.Deprovision(ctx, device)
+	if err != nil {
+		slog.Warn("deprovisioning device from network server",
+			slog.String("device_id", device.ID.String()),
+			slog.Any("error", err))
+		device.RecordProvisioningFailure(err, time.Now())
+	} else {
+		device.RecordProvisioningSuccess(time.Now())
+	}
+
+	err = s.repository.UpdateProvisioning(ctx, device)
+	if err != nil {
+		slog.Error("updating device provisioning",
+			slog.String("device_id", device.ID.String()),
+			slog.Any("error", err))
+	}
+}
+
+func (s *SimpleDeviceLifecycleService) getDevice(ctx context.Context, deviceID domain.ID) (domain.Device, error) {
+	device, err := s.repository.Get(ctx, deviceID.String())
+	if errors.Is(err, ErrDeviceNotFound) {
+		return domain.Device{}, ErrDeviceNotFound
+	}
+	if err != nil {
+		return domain.Device{}, fmt.Errorf("getting device: %w", err)
+	}
+
+	return device, nil
+}
+
+func (s *SimpleDeviceLifecycleService) cancelPendingCommands(ctx context.Context, device domain.Device, reason string) error {
+	cancelled, err := s.commandRepository.CancelPendingByDevice(ctx, device.ID, reason)
+	if err != nil {
+		return fmt.Errorf("cancelling pending commands: %w", err)
+	}
+
+	if cancelled > 0 {
+		slog.Info("pending commands cancelled",
+			slog.String("device_id", device.ID.String()),
+			slog.Int("count", cancelled),
+			slog.String("reason", reason))
+	}
+
+	return nil
+}
+
+// detachScheduledTasks deactivates the scheduled tasks owned by the device and removes it from
+// the targets of the others, which keep driving their remaining devices.
+func (s *SimpleDeviceLifecycleService) detachScheduledTasks(ctx context.Context, device domain.Device) error {
+	scheduledTasks, err := s.scheduledTaskRepository.FindAllByDevice(ctx, device.ID)
+	if err != nil {
+		return fmt.Errorf("finding scheduled tasks: %w", err)
+	}
+
+	for _, scheduledTask := range scheduledTasks {
+		if scheduledTask.Device.ID == device.ID {
+			scheduledTask.IsActive = false
+		} else {
+			scheduledTask.Devices = withoutDevice(scheduledTask.Devices, device.ID)
+		}
+
+		err = s.scheduledTaskRepository.Update(ctx, scheduledTask)
+		if err != nil {
+			return fmt.Errorf("updating scheduled task %s: %w", scheduledTask.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// rehomeScheduledTasks moves the scheduled tasks that drive only the device to the new tenant,
+// inlining the templates of any tenant command template set. Tasks that also drive devices of
+// the old tenant stay there and are detached like on archive.
+func (s *SimpleDeviceLifecycleService) rehomeScheduledTasks(ctx context.Context, device domain.Device, tenantID domain.ID) error {
+	scheduledTasks, err := s.scheduledTaskRepository.FindAllByDevice(ctx, device.ID)
+	if err != nil {
+		return fmt.Errorf("finding scheduled tasks: %w", err)
+	}
+
+	for _, scheduledTask := range scheduledTasks {
+		switch {
+		case scheduledTask.Device.ID != device.ID:
+			scheduledTask.Devices = withoutDevice(scheduledTask.Devices, device.ID)
+		case len(scheduledTask.TargetDevices()) > 1:
+			scheduledTask.IsActive = false
+		default:
+			err = s.moveScheduledTask(ctx, &scheduledTask, tenantID)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = s.scheduledTaskRepository.Update(ctx, scheduledTask)
+		if err != nil {
+			return fmt.Errorf("updating scheduled task %s: %w", scheduledTask.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *SimpleDeviceLifecycleService) moveScheduledTask(ctx context.Context, scheduledTask *domain.ScheduledTask, tenantID domain.ID) error {
+	if scheduledTask.CommandTemplateSet != nil {
+		set, err := s.commandTemplateSetRepository.GetByID(ctx, scheduledTask.CommandTemplateSet.ID)
+		if err != nil {
+			return fmt.Errorf("getting command template set: %w", err)
+		}
+
+		templates := make([]domain.CommandTemplate, len(set.CommandTemplates))
+		for i, template := range set.CommandTemplates {
+			template.Device = scheduledTask.Device
+			templates[i] = template
+		}
+		scheduledTask.CommandTemplates = templates
+		scheduledTask.CommandTemplateSet = nil
+	}
+
+	scheduledTask.Tenant = domain.Tenant{ID: tenantID}
+	scheduledTask.Devices = nil
+
+	err := s.scheduledTaskRunRepository.ReassignTenant(ctx, scheduledTask.ID, tenantID)
+	if err != nil {
+		return fmt.Errorf("reassigning scheduled task runs: %w", err)
+	}
+
+	return nil
+}
+
+func withoutDevice(devices []domain.Device, id domain.ID) []domain.Device {
+	result := make([]domain.Device, 0, len(devices))
+	for _, device := range devices {
+		if device.ID != id {
+			result = append(result, device)
+		}
+	}
+
+	return result
+}
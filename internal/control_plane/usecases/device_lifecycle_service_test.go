@@ -0,0 +1,251 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"time"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/ttn"
+	"zensor-server/internal/shared_kernel/domain"
+
+	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = ginkgo.Describe("DeviceLifecycleService", func() {
+	var (
+		ctrl                  *gomock.Controller
+		mockDeviceRepo        *mockusecases.MockDeviceRepository
+		mockCommandRepo       *mockusecases.MockCommandRepository
+		mockScheduledTaskRepo *mockusecases.MockScheduledTaskRepository
+		mockRunRepo           *mockusecases.MockScheduledTaskRunRepository
+		mockTemplateSetRepo   *mockusecases.MockCommandTemplateSetRepository
+		mockRuleRepo          *mockusecases.MockEvaluationRuleRepository
+		mockTransactor        *mockusecases.MockTransactor
+		provisioner           *ttn.FakeProvisioner
+		service               *usecases.SimpleDeviceLifecycleService
+		ctx                   context.Context
+		tenantID              domain.ID
+		device                domain.Device
+		owned                 domain.ScheduledTask
+		shared                domain.ScheduledTask
+		updated               map[domain.ID]domain.ScheduledTask
+	)
+
+	ginkgo.BeforeEach(func() {
+		ctrl = gomock.NewController(ginkgo.GinkgoT())
+		mockDeviceRepo = mockusecases.NewMockDeviceRepository(ctrl)
+		mockCommandRepo = mockusecases.NewMockCommandRepository(ctrl)
+		mockScheduledTaskRepo = mockusecases.NewMockScheduledTaskRepository(ctrl)
+		mockRunRepo = mockusecases.NewMockScheduledTaskRunRepository(ctrl)
+		mockTemplateSetRepo = mockusecases.NewMockCommandTemplateSetRepository(ctrl)
+		mockRuleRepo = mockusecases.NewMockEvaluationRuleRepository(ctrl)
+		mockTransactor = mockusecases.NewMockTransactor(ctrl)
+		mockTransactor.EXPECT().InTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			return fn(ctx)
+		}).AnyTimes()
+		provisioner = ttn.NewFakeProvisioner()
+		service = usecases.NewDeviceLifecycleService(mockDeviceRepo, mockCommandRepo, mockScheduledTaskRepo, mockRunRepo, mockTemplateSetRepo, mockRuleRepo, provisioner, mockTransactor)
+		ctx = context.Background()
+
+		tenantID = domain.ID("tenant-1")
+		device = domain.Device{ID: domain.ID("device-1"), Name: "device-1", TenantID: &tenantID}
+		owned = domain.ScheduledTask{
+			ID:                 domain.ID("owned"),
+			Tenant:             domain.Tenant{ID: tenantID},
+			Device:             device,
+			CommandTemplateSet: &domain.CommandTemplateSet{ID: domain.ID("set-1")},
+			IsActive:           true,
+		}
+		shared = domain.ScheduledTask{
+			ID:       domain.ID("shared"),
+			Tenant:   domain.Tenant{ID: tenantID},
+			Device:   domain.Device{ID: domain.ID("device-2")},
+			Devices:  []domain.Device{device, {ID: domain.ID("device-3")}},
+			IsActive: true,
+		}
+		updated = map[domain.ID]domain.ScheduledTask{}
+	})
+
+	ginkgo.AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	expectScheduledTaskUpdates := func() {
+		mockScheduledTaskRepo.EXPECT().FindAllByDevice(ctx, device.ID).Return([]domain.ScheduledTask{owned, shared}, nil)
+		mockScheduledTaskRepo.EXPECT().Update(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, value domain.ScheduledTask) error {
+			updated[value.ID] = value
+			return nil
+		}).Times(2)
+	}
+
+	ginkgo.Context("Decommission", func() {
+		ginkgo.When("the device exists", func() {
+			ginkgo.It("should cancel its commands, detach its schedules, disable its rules and soft-delete it", func() {
+				mockDeviceRepo.EXPECT().Get(ctx, device.ID.String()).Return(device, nil)
+				mockCommandRepo.EXPECT().CancelPendingByDevice(ctx, device.ID, gomock.Any()).Return(2, nil)
+				expectScheduledTaskUpdates()
+				mockRuleRepo.EXPECT().DisableAllByDeviceID(ctx, device.ID.String()).Return(nil)
+				mockDeviceRepo.EXPECT().UpdateDevice(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, value domain.Device) error {
+					gomega.Expect(value.IsDeleted()).To(gomega.BeTrue())
+					return nil
+				})
+
+				gomega.Expect(service.Decommission(ctx, device.ID)).To(gomega.Succeed())
+				gomega.Expect(updated[owned.ID].IsActive).To(gomega.BeFalse())
+				gomega.Expect(updated[shared.ID].IsActive).To(gomega.BeTrue())
+				gomega.Expect(updated[shared.ID].Devices).To(gomega.ConsistOf(domain.Device{ID: domain.ID("device-3")}))
+			})
+		})
+
+		ginkgo.When("the device was provisioned in the network server", func() {
+			ginkgo.BeforeEach(func() {
+				device.RecordProvisioningSuccess(time.Now())
+				gomega.Expect(provisioner.Provision(ctx, device)).To(gomega.Succeed())
+
+				mockDeviceRepo.EXPECT().Get(ctx, device.ID.String()).Return(device, nil)
+				mockCommandRepo.EXPECT().CancelPendingByDevice(ctx, device.ID, gomock.Any()).Return(0, nil)
+				expectScheduledTaskUpdates()
+				mockRuleRepo.EXPECT().DisableAllByDeviceID(ctx, device.ID.String()).Return(nil)
+			})
+
+			ginkgo.It("should remove it from the network server after the decommission is committed", func() {
+				mockDeviceRepo.EXPECT().UpdateDevice(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, value domain.Device) error {
+					gomega.Expect(value.Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusPending))
+					_, registered := provisioner.Device(device.Name)
+					gomega.Expect(registered).To(gomega.BeTrue())
+					return nil
+				})
+				mockDeviceRepo.EXPECT().UpdateProvisioning(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, value domain.Device) error {
+					gomega.Expect(value.Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusDeprovisioned))
+					return nil
+				})
+
+				gomega.Expect(service.Decommission(ctx, device.ID)).To(gomega.Succeed())
+				_, registered := provisioner.Device(device.Name)
+				gomega.Expect(registered).To(gomega.BeFalse())
+			})
+
+			ginkgo.It("should still decommission it when the network server fails", func() {
+				provisioner.FailWith(errors.New("registry unavailable"))
+				mockDeviceRepo.EXPECT().UpdateDevice(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, value domain.Device) error {
+					gomega.Expect(value.IsDeleted()).To(gomega.BeTrue())
+					return nil
+				})
+				mockDeviceRepo.EXPECT().UpdateProvisioning(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, value domain.Device) error {
+					gomega.Expect(value.Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusDeprovisioningFailed))
+					gomega.Expect(value.Provisioning.NextAttemptAt).NotTo(gomega.BeNil())
+					return nil
+				})
+
+				gomega.Expect(service.Decommission(ctx, device.ID)).To(gomega.Succeed())
+			})
+
+			ginkgo.It("should not call the network server when the decommission fails", func() {
+				mockDeviceRepo.EXPECT().UpdateDevice(ctx, gomock.Any()).Return(errors.New("database unavailable"))
+
+				gomega.Expect(service.Decommission(ctx, device.ID)).NotTo(gomega.Succeed())
+				_, registered := provisioner.Device(device.Name)
+				gomega.Expect(registered).To(gomega.BeTrue())
+			})
+		})
+
+		ginkgo.When("the device does not exist", func() {
+			ginkgo.It("should return ErrDeviceNotFound", func() {
+				mockDeviceRepo.EXPECT().Get(ctx, device.ID.String()).Return(domain.Device{}, usecases.ErrDeviceNotFound)
+
+				gomega.Expect(service.Decommission(ctx, device.ID)).To(gomega.MatchError(usecases.ErrDeviceNotFound))
+			})
+		})
+	})
+
+	ginkgo.Context("Release", func() {
+		ginkgo.When("the device is an orphan", func() {
+			ginkgo.It("should return ErrDeviceIsOrphan", func() {
+				mockDeviceRepo.EXPECT().Get(ctx, device.ID.String()).Return(domain.Device{ID: device.ID}, nil)
+
+				_, err := service.Release(ctx, device.ID)
+				gomega.Expect(err).To(gomega.MatchError(usecases.ErrDeviceIsOrphan))
+			})
+		})
+
+		ginkgo.When("the device belongs to a tenant", func() {
+			ginkgo.It("should detach it and leave it orphaned", func() {
+				mockDeviceRepo.EXPECT().Get(ctx, device.ID.String()).Return(device, nil)
+				mockCommandRepo.EXPECT().CancelPendingByDevice(ctx, device.ID, gomock.Any()).Return(0, nil)
+				expectScheduledTaskUpdates()
+				mockDeviceRepo.EXPECT().UpdateDevice(ctx, gomock.Any()).Return(nil)
+
+				result, err := service.Release(ctx, device.ID)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(result.IsOrphan()).To(gomega.BeTrue())
+				gomega.Expect(updated[owned.ID].IsActive).To(gomega.BeFalse())
+			})
+		})
+	})
+
+	ginkgo.Context("Transfer", func() {
+		var newTenantID domain.ID
+
+		ginkgo.BeforeEach(func() {
+			newTenantID = domain.ID("tenant-2")
+		})
+
+		ginkgo.When("the history is rehomed", func() {
+			ginkgo.It("should move the device's own scheduled tasks with inlined templates", func() {
+				set := domain.CommandTemplateSet{
+					ID:     domain.ID("set-1"),
+					Tenant: domain.Tenant{ID: tenantID},
+					CommandTemplates: []domain.CommandTemplate{
+						{Port: domain.Port(15), Payload: domain.CommandPayload{Index: 1, Value: 1}},
+					},
+				}
+
+				mockDeviceRepo.EXPECT().Get(ctx, device.ID.String()).Return(device, nil)
+				mockCommandRepo.EXPECT().CancelPendingByDevice(ctx, device.ID, gomock.Any()).Return(0, nil)
+				expectScheduledTaskUpdates()
+				mockTemplateSetRepo.EXPECT().GetByID(ctx, set.ID).Return(set, nil)
+				mockRunRepo.EXPECT().ReassignTenant(ctx, owned.ID, newTenantID).Return(nil)
+				mockDeviceRepo.EXPECT().UpdateDevice(ctx, gomock.Any()).Return(nil)
+
+				result, err := service.Transfer(ctx, device.ID, newTenantID, usecases.DeviceTransferModeRehome)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(result.BelongsToTenant(newTenantID)).To(gomega.BeTrue())
+
+				moved := updated[owned.ID]
+				gomega.Expect(moved.Tenant.ID).To(gomega.Equal(newTenantID))
+				gomega.Expect(moved.IsActive).To(gomega.BeTrue())
+				gomega.Expect(moved.CommandTemplateSet).To(gomega.BeNil())
+				gomega.Expect(moved.CommandTemplates).To(gomega.HaveLen(1))
+				gomega.Expect(moved.CommandTemplates[0].Device.ID).To(gomega.Equal(device.ID))
+				gomega.Expect(updated[shared.ID].Tenant.ID).To(gomega.Equal(tenantID))
+			})
+		})
+
+		ginkgo.When("the history is archived", func() {
+			ginkgo.It("should leave the scheduled tasks deactivated with the old tenant", func() {
+				mockDeviceRepo.EXPECT().Get(ctx, device.ID.String()).Return(device, nil)
+				mockCommandRepo.EXPECT().CancelPendingByDevice(ctx, device.ID, gomock.Any()).Return(0, nil)
+				expectScheduledTaskUpdates()
+				mockDeviceRepo.EXPECT().UpdateDevice(ctx, gomock.Any()).Return(nil)
+
+				_, err := service.Transfer(ctx, device.ID, newTenantID, usecases.DeviceTransferModeArchive)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(updated[owned.ID].Tenant.ID).To(gomega.Equal(tenantID))
+				gomega.Expect(updated[owned.ID].IsActive).To(gomega.BeFalse())
+			})
+		})
+
+		ginkgo.When("the device already belongs to the tenant", func() {
+			ginkgo.It("should return ErrDeviceAlreadyInTenant", func() {
+				mockDeviceRepo.EXPECT().Get(ctx, device.ID.String()).Return(device, nil)
+
+				_, err := service.Transfer(ctx, device.ID, tenantID, usecases.DeviceTransferModeArchive)
+				gomega.Expect(err).To(gomega.MatchError(usecases.ErrDeviceAlreadyInTenant))
+			})
+		})
+	})
+})
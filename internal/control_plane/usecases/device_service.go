@@ -12,13 +12,17 @@ import (
 
 var errUnknown = errors.New("unknown error")
 
+// NewDeviceService builds the device service. A nil provisioner disables network server
+// provisioning.
 func NewDeviceService(
 	repository DeviceRepository,
 	commandRepository CommandRepository,
+	provisioner NetworkServerProvisioner,
 ) *SimpleDeviceService {
 	return &SimpleDeviceService{
 		repository,
 		commandRepository,
+		provisioner,
 	}
 }
 
@@ -27,25 +31,60 @@ var _ DeviceService = &SimpleDeviceService{}
 type SimpleDeviceService struct {
 	repository        DeviceRepository
 	commandRepository CommandRepository
+	provisioner       NetworkServerProvisioner
 }
 
-func (s *SimpleDeviceService) CreateDevice(ctx context.Context, device domain.Device) error {
+func (s *SimpleDeviceService) CreateDevice(ctx context.Context, device domain.Device) (domain.Device, error) {
+	if s.provisioner != nil {
+		device.RequestProvisioning(time.Now())
+	}
+
 	err := s.repository.CreateDevice(ctx, device)
 	if errors.Is(ErrDeviceDuplicated, err) {
 		slog.Warn("device duplicated", slog.String("name", device.Name))
-		return ErrDeviceDuplicated
+		return domain.Device{}, ErrDeviceDuplicated
 	}
 
 	if err != nil {
 		slog.Error("creating device", slog.String("error", err.Error()))
-		return errUnknown
+		return domain.Device{}, errUnknown
 	}
 
-	return nil
+	if s.provisioner != nil {
+		device = s.provision(ctx, device)
+	}
+
+	return device, nil
+}
+
+// provision registers the device in the network server. A failure leaves the device created with
+// a failed status for the provisioning worker to retry.
+func (s *SimpleDeviceService) provision(ctx context.Context, device domain.Device) domain.Device {
+	err := s.provisioner.Provision(ctx, device)
+	if err != nil {
+		slog.Warn("provisioning device in network server",
+			slog.String("device_id", device.ID.String()),
+			slog.Any("error", err))
+		device.RecordProvisioningFailure(err, time.Now())
+	} else {
+		device.RecordProvisioningSuccess(time.Now())
+	}
+
+	err = s.repository.UpdateProvisioning(ctx, device)
+	if err != nil {
+		slog.Error("updating device provisioning",
+			slog.String("device_id", device.ID.String()),
+			slog.Any("error", err))
+	}
+
+	return device
 }
 
 func (s *SimpleDeviceService) GetDevice(ctx context.Context, id domain.ID) (domain.Device, error) {
 	devices, err := s.repository.Get(ctx, string(id))
+	if errors.Is(err, ErrDeviceNotFound) {
+		return domain.Device{}, ErrDeviceNotFound
+	}
 	if err != nil {
 		slog.Error("getting device", slog.String("error", err.Error()))
 		return domain.Device{}, errUnknown
@@ -64,16 +103,17 @@ func (s *SimpleDeviceService) AllDevices(ctx context.Context, pagination Paginat
 	return devices, total, nil
 }
 
-func (s *SimpleDeviceService) DevicesByTenant(ctx context.Context, tenantID domain.ID, pagination Pagination) ([]domain.Device, int, error) {
-	devices, total, err := s.repository.FindByTenant(ctx, tenantID.String(), pagination)
+func (s *SimpleDeviceService) SearchDevices(ctx context.Context, query DeviceQuery) (DevicePage, error) {
+	page, err := s.repository.FindDevices(ctx, query)
+	if errors.Is(err, ErrInvalidDeviceCursor) {
+		return DevicePage{}, ErrInvalidDeviceCursor
+	}
 	if err != nil {
-		slog.Error("getting devices by tenant",
-			slog.String("tenant_id", tenantID.String()),
-			slog.String("error", err.Error()))
-		return nil, 0, errUnknown
+		slog.Error("searching devices", slog.String("error", err.Error()))
+		return DevicePage{}, errUnknown
 	}
 
-	return devices, total, nil
+	return page, nil
 }
 
 func (s *SimpleDeviceService) UpdateDeviceDisplayName(ctx context.Context, deviceID domain.ID, displayName string) error {
@@ -102,6 +142,91 @@ func (s *SimpleDeviceService) UpdateDeviceDisplayName(ctx context.Context, devic
 	return nil
 }
 
+func (s *SimpleDeviceService) UpdateDeviceExpectedUplinkInterval(ctx context.Context, deviceID domain.ID, interval time.Duration) error {
+	device, err := s.repository.Get(ctx, deviceID.String())
+	if errors.Is(err, ErrDeviceNotFound) {
+		slog.Warn("device not found for expected uplink interval update", slog.String("device_id", deviceID.String()))
+		return ErrDeviceNotFound
+	}
+	if err != nil {
+		slog.Error("getting device for expected uplink interval update", slog.String("error", err.Error()))
+		return errUnknown
+	}
+
+	err = device.UpdateExpectedUplinkInterval(interval)
+	if err != nil {
+		return fmt.Errorf("updating expected uplink interval: %w", err)
+	}
+
+	err = s.repository.UpdateDevice(ctx, device)
+	if err != nil {
+		slog.Error("updating device expected uplink interval", slog.String("error", err.Error()))
+		return errUnknown
+	}
+
+	slog.Info("device expected uplink interval updated",
+		slog.String("device_id", deviceID.String()),
+		slog.Duration("expected_uplink_interval", interval))
+
+	return nil
+}
+
+func (s *SimpleDeviceService) UpdateDeviceTags(ctx context.Context, deviceID domain.ID, tags map[string]string) error {
+	device, err := s.repository.Get(ctx, deviceID.String())
+	if errors.Is(err, ErrDeviceNotFound) {
+		slog.Warn("device not found for tags update", slog.String("device_id", deviceID.String()))
+		return ErrDeviceNotFound
+	}
+	if err != nil {
+		slog.Error("getting device for tags update", slog.String("error", err.Error()))
+		return errUnknown
+	}
+
+	err = device.UpdateTags(tags)
+	if err != nil {
+		return fmt.Errorf("updating tags: %w", err)
+	}
+
+	err = s.repository.UpdateDevice(ctx, device)
+	if err != nil {
+		slog.Error("updating device tags", slog.String("error", err.Error()))
+		return errUnknown
+	}
+
+	slog.Info("device tags updated",
+		slog.String("device_id", deviceID.String()),
+		slog.Int("tag_count", len(device.Tags)))
+
+	return nil
+}
+
+func (s *SimpleDeviceService) UpdateDeviceMetadata(ctx context.Context, deviceID domain.ID, metadata map[string]any) error {
+	device, err := s.repository.Get(ctx, deviceID.String())
+	if errors.Is(err, ErrDeviceNotFound) {
+		slog.Warn("device not found for metadata update", slog.String("device_id", deviceID.String()))
+		return ErrDeviceNotFound
+	}
+	if err != nil {
+		slog.Error("getting device for metadata update", slog.String("error", err.Error()))
+		return errUnknown
+	}
+
+	err = device.UpdateMetadata(metadata)
+	if err != nil {
+		return fmt.Errorf("updating metadata: %w", err)
+	}
+
+	err = s.repository.UpdateDevice(ctx, device)
+	if err != nil {
+		slog.Error("updating device metadata", slog.String("error", err.Error()))
+		return errUnknown
+	}
+
+	slog.Info("device metadata updated", slog.String("device_id", deviceID.String()))
+
+	return nil
+}
+
 func (s *SimpleDeviceService) QueueCommand(ctx context.Context, cmd domain.Command) error {
 	if cmd.Port == 0 {
 		cmd.Port = 1
@@ -186,3 +311,24 @@ func (s *SimpleDeviceService) UpdateLastMessageReceivedAt(ctx context.Context, d
 
 	return nil
 }
+
+func (s *SimpleDeviceService) RecordBatteryReading(ctx context.Context, deviceName string, voltage float64, reportedAt time.Time) error {
+	device, err := s.repository.FindByName(ctx, deviceName)
+	if err != nil {
+		return fmt.Errorf("finding device: %w", err)
+	}
+
+	if !device.RecordBatteryReading(voltage, reportedAt) {
+		return nil
+	}
+
+	err = s.repository.UpdateBattery(ctx, device)
+	if err != nil {
+		return fmt.Errorf("updating device battery: %w", err)
+	}
+
+	slog.Debug("device battery recorded",
+		slog.String("device_id", device.ID.String()),
+		slog.Float64("voltage", voltage))
+	return nil
+}
@@ -0,0 +1,121 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/ttn"
+	"zensor-server/internal/shared_kernel/domain"
+
+	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = ginkgo.Describe("DeviceService", func() {
+	var (
+		ctrl            *gomock.Controller
+		mockDeviceRepo  *mockusecases.MockDeviceRepository
+		mockCommandRepo *mockusecases.MockCommandRepository
+		provisioner     *ttn.FakeProvisioner
+		ctx             context.Context
+		device          domain.Device
+	)
+
+	ginkgo.BeforeEach(func() {
+		ctrl = gomock.NewController(ginkgo.GinkgoT())
+		mockDeviceRepo = mockusecases.NewMockDeviceRepository(ctrl)
+		mockCommandRepo = mockusecases.NewMockCommandRepository(ctrl)
+		provisioner = ttn.NewFakeProvisioner()
+		ctx = context.Background()
+		device = domain.Device{ID: domain.ID("device-1"), Name: "valve-1"}
+	})
+
+	ginkgo.Context("CreateDevice", func() {
+		ginkgo.When("provisioning is disabled", func() {
+			ginkgo.It("should only store the device", func() {
+				service := usecases.NewDeviceService(mockDeviceRepo, mockCommandRepo, nil)
+				mockDeviceRepo.EXPECT().CreateDevice(ctx, device).Return(nil)
+
+				result, err := service.CreateDevice(ctx, device)
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(result.Provisioning.IsManaged()).To(gomega.BeFalse())
+			})
+		})
+
+		ginkgo.When("provisioning is enabled", func() {
+			var service *usecases.SimpleDeviceService
+
+			ginkgo.BeforeEach(func() {
+				service = usecases.NewDeviceService(mockDeviceRepo, mockCommandRepo, provisioner)
+				mockDeviceRepo.EXPECT().CreateDevice(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, value domain.Device) error {
+					gomega.Expect(value.Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusPending))
+					return nil
+				})
+			})
+
+			ginkgo.It("should register the device in the network server", func() {
+				mockDeviceRepo.EXPECT().UpdateProvisioning(ctx, gomock.Any()).Return(nil)
+
+				result, err := service.CreateDevice(ctx, device)
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(result.Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusProvisioned))
+				_, registered := provisioner.Device("valve-1")
+				gomega.Expect(registered).To(gomega.BeTrue())
+			})
+
+			ginkgo.It("should keep the device and schedule a retry when the network server fails", func() {
+				provisioner.FailWith(errors.New("registry unavailable"))
+				mockDeviceRepo.EXPECT().UpdateProvisioning(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, value domain.Device) error {
+					gomega.Expect(value.Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusFailed))
+					gomega.Expect(value.Provisioning.NextAttemptAt).NotTo(gomega.BeNil())
+					return nil
+				})
+
+				result, err := service.CreateDevice(ctx, device)
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(result.Provisioning.LastError).To(gomega.Equal("registry unavailable"))
+			})
+		})
+	})
+
+	ginkgo.Context("UpdateDeviceTags", func() {
+		var service *usecases.SimpleDeviceService
+
+		ginkgo.BeforeEach(func() {
+			service = usecases.NewDeviceService(mockDeviceRepo, mockCommandRepo, nil)
+			mockDeviceRepo.EXPECT().Get(ctx, device.ID.String()).Return(device, nil)
+		})
+
+		ginkgo.It("should store the normalized tags", func() {
+			mockDeviceRepo.EXPECT().UpdateDevice(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, value domain.Device) error {
+				gomega.Expect(value.Tags).To(gomega.Equal(map[string]string{"zone": "B"}))
+				return nil
+			})
+
+			gomega.Expect(service.UpdateDeviceTags(ctx, device.ID, map[string]string{"Zone": "B"})).To(gomega.Succeed())
+		})
+
+		ginkgo.It("should reject invalid tags without storing them", func() {
+			err := service.UpdateDeviceTags(ctx, device.ID, map[string]string{"zone b": "x"})
+
+			gomega.Expect(err).To(gomega.MatchError(domain.ErrInvalidDeviceTagKey))
+		})
+	})
+
+	ginkgo.Context("SearchDevices", func() {
+		ginkgo.It("should pass an invalid cursor error through", func() {
+			service := usecases.NewDeviceService(mockDeviceRepo, mockCommandRepo, nil)
+			mockDeviceRepo.EXPECT().FindDevices(ctx, gomock.Any()).Return(usecases.DevicePage{}, usecases.ErrInvalidDeviceCursor)
+
+			_, err := service.SearchDevices(ctx, usecases.DeviceQuery{Cursor: "bogus", Limit: 10})
+
+			gomega.Expect(err).To(gomega.MatchError(usecases.ErrInvalidDeviceCursor))
+		})
+	})
+})
@@ -0,0 +1,127 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+// DeviceLinkQuality is the current session of a device together with a page of its most recent
+// uplink samples and their summary.
+type DeviceLinkQuality struct {
+	Session domain.DeviceSession
+	Summary domain.LinkQualitySummary
+	Samples []domain.LinkQualitySample
+}
+
+func NewDeviceSessionService(
+	deviceRepository DeviceRepository,
+	repository DeviceSessionRepository,
+) *SimpleDeviceSessionService {
+	return &SimpleDeviceSessionService{
+		deviceRepository: deviceRepository,
+		repository:       repository,
+	}
+}
+
+var _ DeviceSessionService = (*SimpleDeviceSessionService)(nil)
+
+type SimpleDeviceSessionService struct {
+	deviceRepository DeviceRepository
+	repository       DeviceSessionRepository
+}
+
+func (s *SimpleDeviceSessionService) RecordJoin(ctx context.Context, deviceName, devAddr string, joinedAt time.Time) error {
+	device, err := s.deviceRepository.FindByName(ctx, deviceName)
+	if err != nil {
+		return fmt.Errorf("finding device: %w", err)
+	}
+
+	session, err := s.session(ctx, device.ID)
+	if err != nil {
+		return err
+	}
+
+	session.Join(devAddr, joinedAt)
+	err = s.repository.SaveSession(ctx, session)
+	if err != nil {
+		return fmt.Errorf("saving device session: %w", err)
+	}
+
+	slog.Info("device joined",
+		slog.String("device_id", device.ID.String()),
+		slog.String("dev_addr", devAddr))
+	return nil
+}
+
+func (s *SimpleDeviceSessionService) RecordUplink(ctx context.Context, deviceName string, sample domain.LinkQualitySample) error {
+	device, err := s.deviceRepository.FindByName(ctx, deviceName)
+	if err != nil {
+		return fmt.Errorf("finding device: %w", err)
+	}
+
+	session, err := s.session(ctx, device.ID)
+	if err != nil {
+		return err
+	}
+
+	session.RecordUplink(sample.DevAddr, sample.FrameCounter, sample.ReceivedAt.Time)
+	err = s.repository.SaveSession(ctx, session)
+	if err != nil {
+		return fmt.Errorf("saving device session: %w", err)
+	}
+
+	if sample.ID == "" {
+		sample.ID = domain.ID(utils.GenerateUUID())
+	}
+	sample.DeviceID = device.ID
+	sample.DevAddr = session.DevAddr
+	err = s.repository.CreateSample(ctx, sample)
+	if err != nil {
+		return fmt.Errorf("creating link quality sample: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SimpleDeviceSessionService) LinkQuality(ctx context.Context, deviceID domain.ID, pagination Pagination) (DeviceLinkQuality, int, error) {
+	_, err := s.deviceRepository.Get(ctx, deviceID.String())
+	if errors.Is(err, ErrDeviceNotFound) {
+		return DeviceLinkQuality{}, 0, ErrDeviceNotFound
+	}
+	if err != nil {
+		return DeviceLinkQuality{}, 0, fmt.Errorf("getting device: %w", err)
+	}
+
+	session, err := s.session(ctx, deviceID)
+	if err != nil {
+		return DeviceLinkQuality{}, 0, err
+	}
+
+	samples, total, err := s.repository.FindSamplesByDevice(ctx, deviceID, pagination)
+	if err != nil {
+		return DeviceLinkQuality{}, 0, fmt.Errorf("finding link quality samples: %w", err)
+	}
+
+	return DeviceLinkQuality{
+		Session: session,
+		Summary: domain.SummarizeLinkQuality(samples),
+		Samples: samples,
+	}, total, nil
+}
+
+func (s *SimpleDeviceSessionService) session(ctx context.Context, deviceID domain.ID) (domain.DeviceSession, error) {
+	session, err := s.repository.GetSession(ctx, deviceID)
+	if errors.Is(err, ErrDeviceSessionNotFound) {
+		return domain.DeviceSession{DeviceID: deviceID}, nil
+	}
+	if err != nil {
+		return domain.DeviceSession{}, fmt.Errorf("getting device session: %w", err)
+	}
+
+	return session, nil
+}
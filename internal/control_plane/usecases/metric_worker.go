@@ -8,7 +8,6 @@ import (
 	"zensor-server/internal/infra/async"
 	"zensor-server/internal/infra/config"
 	"zensor-server/internal/infra/node"
-	"zensor-server/internal/infra/utils"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -71,7 +70,11 @@ func (w *MetricWorker) Run(ctx context.Context, done func()) {
 		slog.String("type", w.config.Type),
 		slog.String("topic", w.config.Topic))
 
-	subscription, err := w.broker.Subscribe(async.BrokerTopicName(w.config.Topic))
+	var events []string
+	if w.config.EventType != "" {
+		events = append(events, w.config.EventType)
+	}
+	subscription, err := w.broker.Subscribe(async.BrokerTopicName(w.config.Topic), events...)
 	if err != nil {
 		slog.Error("failed to subscribe to topic",
 			slog.String("topic", w.config.Topic),
@@ -114,15 +117,13 @@ func (w *MetricWorker) Shutdown() {
 func createCounterHandler(metricInstance any, _ string, customAttributes map[string]string) func(context.Context, async.BrokerMessage) {
 	return func(ctx context.Context, msg async.BrokerMessage) {
 		if counter, ok := metricInstance.(metric.Float64Counter); ok {
-			attributes := getGlobalAttributes()
-
-			for labelName, path := range customAttributes {
-				if value := utils.ExtractStringValue(msg.Value, path); value != "" {
-					attributes = append(attributes, attribute.String(labelName, value))
-				}
+			fields, err := async.NewEventFields(msg)
+			if err != nil {
+				slog.Warn("metric event without fields", slog.String("event", msg.Event), slog.Any("error", err))
+				return
 			}
 
-			counter.Add(ctx, 1, metric.WithAttributes(attributes...))
+			counter.Add(ctx, 1, metric.WithAttributes(eventAttributes(fields, customAttributes)...))
 		}
 	}
 }
@@ -130,16 +131,13 @@ func createCounterHandler(metricInstance any, _ string, customAttributes map[str
 func createGaugeHandler(metricInstance any, propertyName string, customAttributes map[string]string) func(ctx context.Context, msg async.BrokerMessage) {
 	return func(ctx context.Context, msg async.BrokerMessage) {
 		if gauge, ok := metricInstance.(metric.Float64Gauge); ok {
-			value := utils.ExtractFloat64Value(msg.Value, propertyName)
-			attributes := getGlobalAttributes()
-
-			for labelName, path := range customAttributes {
-				if value := utils.ExtractStringValue(msg.Value, path); value != "" {
-					attributes = append(attributes, attribute.String(labelName, value))
-				}
+			fields, err := async.NewEventFields(msg)
+			if err != nil {
+				slog.Warn("metric event without fields", slog.String("event", msg.Event), slog.Any("error", err))
+				return
 			}
 
-			gauge.Record(ctx, value, metric.WithAttributes(attributes...))
+			gauge.Record(ctx, fields.Float64(propertyName), metric.WithAttributes(eventAttributes(fields, customAttributes)...))
 		}
 	}
 }
@@ -147,18 +145,27 @@ func createGaugeHandler(metricInstance any, propertyName string, customAttribute
 func createHistogramHandler(metricInstance any, propertyName string, customAttributes map[string]string) func(context.Context, async.BrokerMessage) {
 	return func(ctx context.Context, msg async.BrokerMessage) {
 		if histogram, ok := metricInstance.(metric.Float64Histogram); ok {
-			value := utils.ExtractFloat64Value(msg.Value, propertyName)
-			attributes := getGlobalAttributes()
-
-			for labelName, path := range customAttributes {
-				if value := utils.ExtractStringValue(msg.Value, path); value != "" {
-					attributes = append(attributes, attribute.String(labelName, value))
-				}
+			fields, err := async.NewEventFields(msg)
+			if err != nil {
+				slog.Warn("metric event without fields", slog.String("event", msg.Event), slog.Any("error", err))
+				return
 			}
 
-			histogram.Record(ctx, value, metric.WithAttributes(attributes...))
+			histogram.Record(ctx, fields.Float64(propertyName), metric.WithAttributes(eventAttributes(fields, customAttributes)...))
+		}
+	}
+}
+
+// eventAttributes returns the global attributes and the custom attributes resolved from the
+// event fields. Attributes whose path is missing are left out.
+func eventAttributes(fields async.EventFields, customAttributes map[string]string) []attribute.KeyValue {
+	attributes := getGlobalAttributes()
+	for labelName, path := range customAttributes {
+		if value := fields.String(path); value != "" {
+			attributes = append(attributes, attribute.String(labelName, value))
 		}
 	}
+	return attributes
 }
 
 // getGlobalAttributes returns global attributes that should be included in all metrics.
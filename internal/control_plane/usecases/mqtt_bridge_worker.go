@@ -0,0 +1,451 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/infra/mqtt"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
+)
+
+const (
+	// _mqttBridgeEventsTopic matches every topic; the worker narrows it to readings and command
+	// status updates when subscribing.
+	_mqttBridgeEventsTopic     async.BrokerTopicName = "#"
+	_mqttBridgeSetSuffix                             = "/set"
+	_mqttBridgeCommandPriority                       = domain.CommandPriority("NORMAL")
+	// _mqttBridgeTenantCacheTTL bounds how long a device keeps being published under a tenant
+	// after it was transferred to another one.
+	_mqttBridgeTenantCacheTTL = 5 * time.Minute
+)
+
+// Placeholders of the MQTT bridge topic templates, each filling a whole topic level.
+const (
+	MQTTBridgeTenantPlaceholder = "{tenant}"
+	MQTTBridgeDevicePlaceholder = "{device}"
+	MQTTBridgeSensorPlaceholder = "{sensor}"
+	MQTTBridgeIndexPlaceholder  = "{index}"
+)
+
+var ErrInvalidMQTTTopicTemplate = errors.New("invalid MQTT topic template")
+
+// MQTTBridgeConfig controls the topics the bridge publishes on. SensorTopic is expanded for every
+// reading and CommandTopic for every command status update. When AcceptCommands is set, messages
+// published on SensorTopic followed by "/set" for one of the WritableSensors become commands; the
+// SensorTopic must then have a {tenant} level.
+type MQTTBridgeConfig struct {
+	SensorTopic     string
+	CommandTopic    string
+	QoS             byte
+	AcceptCommands  bool
+	WritableSensors []string
+}
+
+// mqttBridgeReading is the retained message published for the last reading of a sensor.
+type mqttBridgeReading struct {
+	Device    string    `json:"device"`
+	Sensor    string    `json:"sensor"`
+	Index     uint      `json:"index"`
+	Value     float64   `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// mqttBridgeCommandStatus is the retained message published for the last command sent to an index.
+type mqttBridgeCommandStatus struct {
+	CommandID string               `json:"command_id"`
+	Device    string               `json:"device"`
+	Index     domain.Index         `json:"index"`
+	Value     domain.CommandValue  `json:"value"`
+	Status    domain.CommandStatus `json:"status"`
+	Error     *string              `json:"error,omitempty"`
+	Timestamp time.Time            `json:"timestamp"`
+}
+
+// mqttBridgeSetRequest is the body of the messages published on the set topics. It carries no
+// credentials, as every client subscribed to the topic tree would read them: the broker of the
+// bridge authorizes who publishes on the set topics of a tenant.
+type mqttBridgeSetRequest struct {
+	Value *int `json:"value"`
+}
+
+func NewMQTTBridgeWorker(
+	mqttClient mqtt.Client,
+	broker async.InternalBroker,
+	deviceRepository DeviceRepository,
+	commandRepository CommandRepository,
+	taskService TaskService,
+	config MQTTBridgeConfig,
+) (*MQTTBridgeWorker, error) {
+	sensorTopic, err := parseMQTTTopicTemplate(config.SensorTopic, MQTTBridgeDevicePlaceholder, MQTTBridgeSensorPlaceholder, MQTTBridgeIndexPlaceholder)
+	if err != nil {
+		return nil, fmt.Errorf("sensor topic: %w", err)
+	}
+	commandTopic, err := parseMQTTTopicTemplate(config.CommandTopic, MQTTBridgeDevicePlaceholder, MQTTBridgeIndexPlaceholder)
+	if err != nil {
+		return nil, fmt.Errorf("command topic: %w", err)
+	}
+	if config.AcceptCommands && !slices.Contains(sensorTopic, MQTTBridgeTenantPlaceholder) {
+		return nil, fmt.Errorf("sensor topic: %w: %q lacks %s to accept commands", ErrInvalidMQTTTopicTemplate, config.SensorTopic, MQTTBridgeTenantPlaceholder)
+	}
+
+	return &MQTTBridgeWorker{
+		mqttClient:        mqttClient,
+		broker:            broker,
+		deviceRepository:  deviceRepository,
+		commandRepository: commandRepository,
+		taskService:       taskService,
+		config:            config,
+		sensorTopic:       sensorTopic,
+		commandTopic:      commandTopic,
+		tenants:           make(map[string]mqttBridgeTenant),
+	}, nil
+}
+
+var _ async.Worker = &MQTTBridgeWorker{}
+
+// MQTTBridgeWorker republishes the readings and command status updates of devices as retained
+// JSON messages on an MQTT topic tree, so other systems read the last value of every sensor
+// without decoding uplinks. Optionally, messages published on the set topic of a writable sensor
+// become commands for its index, when the device belongs to the tenant of the topic. The broker
+// authorizes the publishers of the set topics, so its ACLs must restrict every tenant's set topics
+// to the clients of that tenant.
+type MQTTBridgeWorker struct {
+	mqttClient        mqtt.Client
+	broker            async.InternalBroker
+	deviceRepository  DeviceRepository
+	commandRepository CommandRepository
+	taskService       TaskService
+	config            MQTTBridgeConfig
+	sensorTopic       mqttTopicTemplate
+	commandTopic      mqttTopicTemplate
+	// tenants caches the tenant of the devices published, keyed by device name. It is only used
+	// by the Run goroutine.
+	tenants map[string]mqttBridgeTenant
+}
+
+type mqttBridgeTenant struct {
+	id        string
+	expiresAt time.Time
+}
+
+func (w *MQTTBridgeWorker) Run(ctx context.Context, done func()) {
+	slog.Info("mqtt bridge worker started")
+	defer done()
+
+	subscription, err := w.broker.Subscribe(_mqttBridgeEventsTopic, events.SensorDataReceived.Name, events.CommandStatusUpdated.Name)
+	if err != nil {
+		slog.Error("subscribing to mqtt bridge events", slog.Any("error", err))
+		return
+	}
+	defer func() {
+		if err := w.broker.Unsubscribe(_mqttBridgeEventsTopic, subscription); err != nil {
+			slog.Error("unsubscribing from mqtt bridge events", slog.Any("error", err))
+		}
+	}()
+
+	if w.config.AcceptCommands {
+		setTopic := w.sensorTopic.filter() + _mqttBridgeSetSuffix
+		if err := w.mqttClient.Subscribe(setTopic, w.config.QoS, w.setHandler(ctx)); err != nil {
+			slog.Error("subscribing to mqtt bridge set topics", slog.String("topic", setTopic), slog.Any("error", err))
+			return
+		}
+		defer func() {
+			if err := w.mqttClient.Unsubscribe(setTopic); err != nil {
+				slog.Error("unsubscribing from mqtt bridge set topics", slog.Any("error", err))
+			}
+		}()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("mqtt bridge worker cancelled")
+			return
+		case msg := <-subscription.Receiver:
+			switch msg.Event {
+			case events.SensorDataReceived.Name:
+				event, err := events.SensorDataReceived.Decode(msg)
+				if err != nil {
+					slog.Error("failed to decode sensor data received event", slog.Any("error", err))
+					continue
+				}
+				w.publishReading(ctx, event)
+			case events.CommandStatusUpdated.Name:
+				event, err := events.CommandStatusUpdated.Decode(msg)
+				if err != nil {
+					slog.Error("failed to decode command status update event", slog.Any("error", err))
+					continue
+				}
+				w.publishCommandStatus(ctx, event.Payload)
+			}
+		}
+	}
+}
+
+func (w *MQTTBridgeWorker) Shutdown() {
+	slog.Debug("mqtt bridge worker shutdown")
+}
+
+func (w *MQTTBridgeWorker) publishReading(ctx context.Context, event async.Event[events.SensorData]) {
+	reading := event.Payload
+	if reading.Sensor == "" {
+		slog.Debug("ignoring reading without sensor", slog.String("device_name", reading.DeviceName))
+		return
+	}
+
+	topic, ok := w.sensorTopic.expand(map[string]string{
+		MQTTBridgeTenantPlaceholder: w.tenantOf(ctx, reading.DeviceName),
+		MQTTBridgeDevicePlaceholder: reading.DeviceName,
+		MQTTBridgeSensorPlaceholder: reading.Sensor,
+		MQTTBridgeIndexPlaceholder:  strconv.FormatUint(uint64(reading.Index), 10),
+	})
+	if !ok {
+		slog.Debug("ignoring reading without a topic", slog.String("device_name", reading.DeviceName), slog.String("sensor", reading.Sensor))
+		return
+	}
+
+	w.publish(ctx, topic, mqttBridgeReading{
+		Device:    reading.DeviceName,
+		Sensor:    reading.Sensor,
+		Index:     reading.Index,
+		Value:     reading.Value,
+		Timestamp: event.OccurredAt,
+	})
+}
+
+func (w *MQTTBridgeWorker) publishCommandStatus(ctx context.Context, update domain.CommandStatusUpdate) {
+	command, err := w.commandRepository.GetByID(ctx, domain.ID(update.CommandID))
+	if err != nil {
+		slog.Error("getting command of status update", slog.String("command_id", update.CommandID), slog.Any("error", err))
+		return
+	}
+
+	topic, ok := w.commandTopic.expand(map[string]string{
+		MQTTBridgeTenantPlaceholder: w.tenantOf(ctx, update.DeviceName),
+		MQTTBridgeDevicePlaceholder: update.DeviceName,
+		MQTTBridgeIndexPlaceholder:  strconv.Itoa(int(command.Payload.Index)),
+	})
+	if !ok {
+		slog.Debug("ignoring command status update without a topic", slog.String("command_id", update.CommandID))
+		return
+	}
+
+	w.publish(ctx, topic, mqttBridgeCommandStatus{
+		CommandID: update.CommandID,
+		Device:    update.DeviceName,
+		Index:     command.Payload.Index,
+		Value:     command.Payload.Value,
+		Status:    update.Status,
+		Error:     update.ErrorMessage,
+		Timestamp: update.Timestamp,
+	})
+}
+
+func (w *MQTTBridgeWorker) publish(ctx context.Context, topic string, msg any) {
+	if err := w.mqttClient.Publish(ctx, topic, msg, mqtt.WithRetain(), mqtt.WithQoS(w.config.QoS)); err != nil {
+		slog.Error("publishing to mqtt bridge", slog.String("topic", topic), slog.Any("error", err))
+	}
+}
+
+// tenantOf returns the tenant owning the device, or an empty string when it has none. Tenants are
+// cached for _mqttBridgeTenantCacheTTL so readings do not look the device up every time.
+func (w *MQTTBridgeWorker) tenantOf(ctx context.Context, deviceName string) string {
+	now := time.Now()
+	if tenant, found := w.tenants[deviceName]; found && now.Before(tenant.expiresAt) {
+		return tenant.id
+	}
+
+	device, err := w.deviceRepository.FindByName(ctx, deviceName)
+	if err != nil {
+		if !errors.Is(err, ErrDeviceNotFound) {
+			slog.Error("finding device of mqtt bridge event", slog.String("device_name", deviceName), slog.Any("error", err))
+		}
+		return ""
+	}
+
+	tenant := mqttBridgeTenant{expiresAt: now.Add(_mqttBridgeTenantCacheTTL)}
+	if device.TenantID != nil {
+		tenant.id = device.TenantID.String()
+	}
+	w.tenants[deviceName] = tenant
+	return tenant.id
+}
+
+// setHandler turns set messages into commands. Retained messages would replay the last command on
+// every reconnection and duplicates are redeliveries of a message already handled, so both are
+// ignored.
+func (w *MQTTBridgeWorker) setHandler(ctx context.Context) mqtt.MessageHandler {
+	return func(_ mqtt.Client, msg mqtt.Message) {
+		if msg.Retained() || msg.Duplicate() {
+			slog.Warn("ignoring retained or duplicate mqtt bridge command",
+				slog.String("topic", msg.Topic()),
+				slog.Bool("retained", msg.Retained()),
+				slog.Bool("duplicate", msg.Duplicate()))
+			return
+		}
+		if err := w.handleSet(ctx, msg.Topic(), msg.Payload()); err != nil {
+			slog.Warn("rejecting mqtt bridge command", slog.String("topic", msg.Topic()), slog.Any("error", err))
+		}
+	}
+}
+
+// handleSet creates a task with a single command setting the index of the sensor named by the
+// topic. Only the tasks created are reported, through the command topic.
+func (w *MQTTBridgeWorker) handleSet(ctx context.Context, topic string, payload []byte) error {
+	values, ok := w.sensorTopic.match(strings.TrimSuffix(topic, _mqttBridgeSetSuffix))
+	if !ok {
+		return errors.New("the topic does not match the sensor topic")
+	}
+	if !slices.Contains(w.config.WritableSensors, values[MQTTBridgeSensorPlaceholder]) {
+		return fmt.Errorf("sensor %q is not writable", values[MQTTBridgeSensorPlaceholder])
+	}
+	index, err := strconv.ParseUint(values[MQTTBridgeIndexPlaceholder], 10, 8)
+	if err != nil {
+		return fmt.Errorf("invalid index %q", values[MQTTBridgeIndexPlaceholder])
+	}
+
+	var request mqttBridgeSetRequest
+	if err := json.Unmarshal(payload, &request); err != nil {
+		return fmt.Errorf("decoding payload: %w", err)
+	}
+	if request.Value == nil || *request.Value < 0 || *request.Value > 255 {
+		return errors.New("value must be between 0 and 255")
+	}
+
+	deviceName := values[MQTTBridgeDevicePlaceholder]
+	device, err := w.deviceRepository.FindByName(ctx, deviceName)
+	if err != nil {
+		return fmt.Errorf("finding device %q: %w", deviceName, err)
+	}
+	if tenantID := values[MQTTBridgeTenantPlaceholder]; device.TenantID == nil || device.TenantID.String() != tenantID {
+		return fmt.Errorf("device %q does not belong to tenant %q", deviceName, tenantID)
+	}
+
+	command, err := domain.NewCommandBuilder().
+		WithDevice(device).
+		WithPayload(domain.CommandPayload{
+			Index: domain.Index(index),
+			Value: domain.CommandValue(*request.Value),
+		}).
+		WithPriority(_mqttBridgeCommandPriority).
+		WithDispatchAfter(utils.Time{Time: time.Now()}).
+		Build()
+	if err != nil {
+		return fmt.Errorf("building command: %w", err)
+	}
+
+	task, err := domain.NewTaskBuilder().
+		WithDevice(device).
+		WithCommands([]domain.Command{command}).
+		Build()
+	if err != nil {
+		return fmt.Errorf("building task: %w", err)
+	}
+	for i := range task.Commands {
+		task.Commands[i].Task = task
+	}
+
+	if err := w.taskService.Create(ctx, task); err != nil {
+		return fmt.Errorf("creating task: %w", err)
+	}
+
+	slog.Info("mqtt bridge command accepted",
+		slog.String("device_name", deviceName),
+		slog.String("task_id", task.ID.String()),
+		slog.Uint64("index", index),
+		slog.Int("value", *request.Value))
+	return nil
+}
+
+// mqttTopicTemplate holds the levels of a topic template, where a level is either literal or one
+// of the placeholders.
+type mqttTopicTemplate []string
+
+func parseMQTTTopicTemplate(template string, required ...string) (mqttTopicTemplate, error) {
+	if template == "" {
+		return nil, fmt.Errorf("%w: empty template", ErrInvalidMQTTTopicTemplate)
+	}
+
+	levels := mqttTopicTemplate(strings.Split(template, "/"))
+	for _, level := range levels {
+		if strings.ContainsAny(level, "+#") {
+			return nil, fmt.Errorf("%w: %q has wildcards", ErrInvalidMQTTTopicTemplate, template)
+		}
+		if strings.ContainsAny(level, "{}") && !isMQTTBridgePlaceholder(level) {
+			return nil, fmt.Errorf("%w: %q has an unknown placeholder %q", ErrInvalidMQTTTopicTemplate, template, level)
+		}
+	}
+	for _, placeholder := range required {
+		if !slices.Contains(levels, placeholder) {
+			return nil, fmt.Errorf("%w: %q lacks %s", ErrInvalidMQTTTopicTemplate, template, placeholder)
+		}
+	}
+
+	return levels, nil
+}
+
+func isMQTTBridgePlaceholder(level string) bool {
+	switch level {
+	case MQTTBridgeTenantPlaceholder, MQTTBridgeDevicePlaceholder, MQTTBridgeSensorPlaceholder, MQTTBridgeIndexPlaceholder:
+		return true
+	}
+	return false
+}
+
+// expand replaces the placeholders with values. It fails when a value is missing or would not fit
+// in a single topic level.
+func (t mqttTopicTemplate) expand(values map[string]string) (string, bool) {
+	levels := make([]string, len(t))
+	for i, level := range t {
+		if !isMQTTBridgePlaceholder(level) {
+			levels[i] = level
+			continue
+		}
+		value := values[level]
+		if value == "" || strings.ContainsAny(value, "/+#") {
+			return "", false
+		}
+		levels[i] = value
+	}
+	return strings.Join(levels, "/"), true
+}
+
+// filter returns the subscription matching every topic the template expands to.
+func (t mqttTopicTemplate) filter() string {
+	levels := make([]string, len(t))
+	for i, level := range t {
+		if isMQTTBridgePlaceholder(level) {
+			level = "+"
+		}
+		levels[i] = level
+	}
+	return strings.Join(levels, "/")
+}
+
+// match returns the values of the placeholders in topic, when it is an expansion of the template.
+func (t mqttTopicTemplate) match(topic string) (map[string]string, bool) {
+	levels := strings.Split(topic, "/")
+	if len(levels) != len(t) {
+		return nil, false
+	}
+
+	values := make(map[string]string)
+	for i, level := range t {
+		switch {
+		case isMQTTBridgePlaceholder(level):
+			values[level] = levels[i]
+		case level != levels[i]:
+			return nil, false
+		}
+	}
+	return values, true
+}
@@ -0,0 +1,280 @@
+package usecases_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/infra/mqtt"
+	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
+
+	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+	mockasync "zensor-server/test/unit/doubles/infra/async"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+type bridgePublication struct {
+	topic   string
+	payload map[string]any
+}
+
+// bridgeMQTTClient keeps the messages published and the subscription of the bridge.
+type bridgeMQTTClient struct {
+	mu        sync.Mutex
+	topic     string
+	handler   mqtt.MessageHandler
+	published chan bridgePublication
+}
+
+func (c *bridgeMQTTClient) Subscribe(topic string, _ byte, handler mqtt.MessageHandler) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.topic, c.handler = topic, handler
+	return nil
+}
+
+func (c *bridgeMQTTClient) Unsubscribe(...string) error {
+	return nil
+}
+
+func (c *bridgeMQTTClient) Publish(_ context.Context, topic string, msg any, _ ...mqtt.PublishOption) error {
+	data, err := json.Marshal(msg)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	var payload map[string]any
+	gomega.Expect(json.Unmarshal(data, &payload)).To(gomega.Succeed())
+	c.published <- bridgePublication{topic: topic, payload: payload}
+	return nil
+}
+
+func (c *bridgeMQTTClient) Disconnect() {}
+
+func (c *bridgeMQTTClient) subscription() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.topic
+}
+
+func (c *bridgeMQTTClient) deliver(msg *bridgeMessage) {
+	c.mu.Lock()
+	handler := c.handler
+	c.mu.Unlock()
+	handler(c, msg)
+}
+
+type bridgeMessage struct {
+	topic     string
+	payload   string
+	retained  bool
+	duplicate bool
+}
+
+func (m *bridgeMessage) Duplicate() bool   { return m.duplicate }
+func (m *bridgeMessage) Qos() byte         { return 1 }
+func (m *bridgeMessage) Retained() bool    { return m.retained }
+func (m *bridgeMessage) Topic() string     { return m.topic }
+func (m *bridgeMessage) MessageID() uint16 { return 1 }
+func (m *bridgeMessage) Payload() []byte   { return []byte(m.payload) }
+func (m *bridgeMessage) Ack()              {}
+
+var _ = ginkgo.Describe("MQTTBridgeWorker", func() {
+	var (
+		ctrl            *gomock.Controller
+		mockDeviceRepo  *mockusecases.MockDeviceRepository
+		mockCommandRepo *mockusecases.MockCommandRepository
+		mockTaskService *mockusecases.MockTaskService
+		mockBroker      *mockasync.MockInternalBroker
+		client          *bridgeMQTTClient
+		config          usecases.MQTTBridgeConfig
+		receiver        chan async.BrokerMessage
+		tenantID        domain.ID
+		device          domain.Device
+	)
+
+	ginkgo.BeforeEach(func() {
+		ctrl = gomock.NewController(ginkgo.GinkgoT())
+		mockDeviceRepo = mockusecases.NewMockDeviceRepository(ctrl)
+		mockCommandRepo = mockusecases.NewMockCommandRepository(ctrl)
+		mockTaskService = mockusecases.NewMockTaskService(ctrl)
+		mockBroker = mockasync.NewMockInternalBroker(ctrl)
+		client = &bridgeMQTTClient{published: make(chan bridgePublication, 1)}
+		config = usecases.MQTTBridgeConfig{
+			SensorTopic:     "zensor/{tenant}/{device}/{sensor}/{index}",
+			CommandTopic:    "zensor/{tenant}/{device}/commands/{index}",
+			QoS:             1,
+			WritableSensors: []string{"relay"},
+		}
+		receiver = make(chan async.BrokerMessage, 1)
+		tenantID = domain.ID("tenant-1")
+		device = domain.Device{ID: domain.ID("device-id"), Name: "device-1", TenantID: &tenantID}
+	})
+
+	run := func(wait func()) {
+		mockBroker.EXPECT().Subscribe(async.BrokerTopicName("#"), events.SensorDataReceived.Name, events.CommandStatusUpdated.Name).
+			Return(async.Subscription{ID: "subscription", Receiver: receiver}, nil)
+		mockBroker.EXPECT().Unsubscribe(async.BrokerTopicName("#"), gomock.Any()).Return(nil)
+
+		worker, err := usecases.NewMQTTBridgeWorker(client, mockBroker, mockDeviceRepo, mockCommandRepo, mockTaskService, config)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go worker.Run(ctx, func() { close(done) })
+
+		wait()
+		cancel()
+		gomega.Eventually(done).Should(gomega.BeClosed())
+	}
+
+	ginkgo.Context("republishing events", func() {
+		ginkgo.It("should publish readings on the topic of their sensor", func() {
+			mockDeviceRepo.EXPECT().FindByName(gomock.Any(), "device-1").Return(device, nil)
+			event := events.SensorDataReceived.New("", events.SensorData{DeviceName: "device-1", Sensor: "temperature", Value: 21.5, Index: 1})
+			receiver <- event.Message()
+
+			var publication bridgePublication
+			run(func() { gomega.Eventually(client.published).Should(gomega.Receive(&publication)) })
+
+			gomega.Expect(publication.topic).To(gomega.Equal("zensor/tenant-1/device-1/temperature/1"))
+			gomega.Expect(publication.payload).To(gomega.HaveKeyWithValue("device", "device-1"))
+			gomega.Expect(publication.payload).To(gomega.HaveKeyWithValue("sensor", "temperature"))
+			gomega.Expect(publication.payload).To(gomega.HaveKeyWithValue("index", 1.0))
+			gomega.Expect(publication.payload).To(gomega.HaveKeyWithValue("value", 21.5))
+			gomega.Expect(publication.payload).To(gomega.HaveKeyWithValue("timestamp", event.OccurredAt.Format(time.RFC3339Nano)))
+		})
+
+		ginkgo.It("should look the tenant of a device up once for its readings", func() {
+			mockDeviceRepo.EXPECT().FindByName(gomock.Any(), "device-1").Return(device, nil).Times(1)
+			client.published = make(chan bridgePublication, 2)
+			receiver = make(chan async.BrokerMessage, 2)
+			receiver <- events.SensorDataReceived.New("", events.SensorData{DeviceName: "device-1", Sensor: "temperature", Value: 21.5, Index: 1}).Message()
+			receiver <- events.SensorDataReceived.New("", events.SensorData{DeviceName: "device-1", Sensor: "temperature", Value: 22, Index: 1}).Message()
+
+			run(func() { gomega.Eventually(client.published).Should(gomega.HaveLen(2)) })
+
+			for range 2 {
+				var publication bridgePublication
+				gomega.Expect(client.published).To(gomega.Receive(&publication))
+				gomega.Expect(publication.topic).To(gomega.Equal("zensor/tenant-1/device-1/temperature/1"))
+			}
+		})
+
+		ginkgo.It("should skip the readings of devices without tenant", func() {
+			mockDeviceRepo.EXPECT().FindByName(gomock.Any(), "device-1").Return(domain.Device{Name: "device-1"}, nil)
+			receiver <- events.SensorDataReceived.New("", events.SensorData{DeviceName: "device-1", Sensor: "temperature", Value: 21.5}).Message()
+
+			run(func() { gomega.Consistently(client.published, 100*time.Millisecond).ShouldNot(gomega.Receive()) })
+		})
+
+		ginkgo.It("should publish command status updates on the topic of their index", func() {
+			mockCommandRepo.EXPECT().GetByID(gomock.Any(), domain.ID("command-1")).
+				Return(domain.Command{ID: "command-1", Payload: domain.CommandPayload{Index: 2, Value: 1}}, nil)
+			mockDeviceRepo.EXPECT().FindByName(gomock.Any(), "device-1").Return(device, nil)
+			receiver <- events.CommandStatusUpdated.New("", domain.CommandStatusUpdate{
+				CommandID:  "command-1",
+				DeviceName: "device-1",
+				Status:     domain.CommandStatusAck,
+				Timestamp:  time.Now(),
+			}).Message()
+
+			var publication bridgePublication
+			run(func() { gomega.Eventually(client.published).Should(gomega.Receive(&publication)) })
+
+			gomega.Expect(publication.topic).To(gomega.Equal("zensor/tenant-1/device-1/commands/2"))
+			gomega.Expect(publication.payload).To(gomega.HaveKeyWithValue("command_id", "command-1"))
+			gomega.Expect(publication.payload).To(gomega.HaveKeyWithValue("value", 1.0))
+			gomega.Expect(publication.payload).To(gomega.HaveKeyWithValue("status", "ack"))
+			gomega.Expect(publication.payload).NotTo(gomega.HaveKey("error"))
+		})
+	})
+
+	ginkgo.Context("accepting commands", func() {
+		ginkgo.BeforeEach(func() {
+			config.AcceptCommands = true
+		})
+
+		deliver := func(msg *bridgeMessage) {
+			run(func() {
+				gomega.Eventually(client.subscription).Should(gomega.Equal("zensor/+/+/+/+/set"))
+				client.deliver(msg)
+			})
+		}
+
+		set := func(topic, payload string) {
+			deliver(&bridgeMessage{topic: topic, payload: payload})
+		}
+
+		ginkgo.It("should create a task setting the index of writable sensors", func() {
+			mockDeviceRepo.EXPECT().FindByName(gomock.Any(), "device-1").Return(device, nil)
+			var task domain.Task
+			mockTaskService.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, created domain.Task) error {
+				task = created
+				return nil
+			})
+
+			set("zensor/tenant-1/device-1/relay/3/set", `{"value": 1}`)
+
+			gomega.Expect(task.Device.ID).To(gomega.Equal(device.ID))
+			gomega.Expect(task.Commands).To(gomega.HaveLen(1))
+			gomega.Expect(task.Commands[0].Payload).To(gomega.Equal(domain.CommandPayload{Index: 3, Value: 1}))
+			gomega.Expect(task.Commands[0].Task.ID).To(gomega.Equal(task.ID))
+		})
+
+		ginkgo.It("should ignore retained messages", func() {
+			deliver(&bridgeMessage{topic: "zensor/tenant-1/device-1/relay/3/set", payload: `{"value": 1}`, retained: true})
+		})
+
+		ginkgo.It("should ignore duplicate messages", func() {
+			deliver(&bridgeMessage{topic: "zensor/tenant-1/device-1/relay/3/set", payload: `{"value": 1}`, duplicate: true})
+		})
+
+		ginkgo.It("should reject sensors that are not writable", func() {
+			set("zensor/tenant-1/device-1/temperature/1/set", `{"value": 1}`)
+		})
+
+		ginkgo.It("should reject values that do not fit a command", func() {
+			set("zensor/tenant-1/device-1/relay/1/set", `{"value": 256}`)
+		})
+
+		ginkgo.It("should reject devices of other tenants", func() {
+			mockDeviceRepo.EXPECT().FindByName(gomock.Any(), "device-1").Return(device, nil)
+
+			set("zensor/tenant-2/device-1/relay/1/set", `{"value": 1}`)
+		})
+
+		ginkgo.It("should reject devices without tenant", func() {
+			device.TenantID = nil
+			mockDeviceRepo.EXPECT().FindByName(gomock.Any(), "device-1").Return(device, nil)
+
+			set("zensor/tenant-1/device-1/relay/1/set", `{"value": 1}`)
+		})
+	})
+
+	ginkgo.Context("NewMQTTBridgeWorker", func() {
+		ginkgo.DescribeTable("should reject invalid topic templates",
+			func(sensorTopic, commandTopic string) {
+				config.SensorTopic, config.CommandTopic = sensorTopic, commandTopic
+				_, err := usecases.NewMQTTBridgeWorker(client, mockBroker, mockDeviceRepo, mockCommandRepo, mockTaskService, config)
+				gomega.Expect(errors.Is(err, usecases.ErrInvalidMQTTTopicTemplate)).To(gomega.BeTrue())
+			},
+			ginkgo.Entry("empty", "", "zensor/{device}/commands/{index}"),
+			ginkgo.Entry("without sensor", "zensor/{device}/{index}", "zensor/{device}/commands/{index}"),
+			ginkgo.Entry("with wildcards", "zensor/+/{device}/{sensor}/{index}", "zensor/{device}/commands/{index}"),
+			ginkgo.Entry("with unknown placeholders", "zensor/{site}/{device}/{sensor}/{index}", "zensor/{device}/commands/{index}"),
+			ginkgo.Entry("command topic without index", "zensor/{device}/{sensor}/{index}", "zensor/{device}/commands"),
+		)
+
+		ginkgo.It("should require a tenant in the sensor topic to accept commands", func() {
+			config.SensorTopic = "zensor/{device}/{sensor}/{index}"
+			config.AcceptCommands = true
+
+			_, err := usecases.NewMQTTBridgeWorker(client, mockBroker, mockDeviceRepo, mockCommandRepo, mockTaskService, config)
+			gomega.Expect(errors.Is(err, usecases.ErrInvalidMQTTTopicTemplate)).To(gomega.BeTrue())
+		})
+	})
+})
@@ -11,6 +11,7 @@ import (
 	"zensor-server/internal/infra/async"
 	"zensor-server/internal/infra/notification"
 	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -32,6 +33,7 @@ func NewNotificationWorker(
 	deviceService DeviceService,
 	tenantConfigurationService TenantConfigurationService,
 	taskService TaskService,
+	outboxRepository OutboxRepository,
 	broker async.InternalBroker,
 ) *NotificationWorker {
 	return &NotificationWorker{
@@ -40,6 +42,7 @@ func NewNotificationWorker(
 		deviceService:              deviceService,
 		tenantConfigurationService: tenantConfigurationService,
 		taskService:                taskService,
+		outbox:                     outboxConsumer{name: _notificationWorkerOutboxConsumer, outboxRepository: outboxRepository},
 		broker:                     broker,
 		metricCounters:             make(map[string]metric.Float64Counter),
 	}
@@ -53,6 +56,7 @@ type NotificationWorker struct {
 	deviceService              DeviceService
 	tenantConfigurationService TenantConfigurationService
 	taskService                TaskService
+	outbox                     outboxConsumer
 	broker                     async.InternalBroker
 	metricCounters             map[string]metric.Float64Counter
 }
@@ -105,17 +109,22 @@ func (w *NotificationWorker) processScheduledTaskEvent(ctx context.Context, mess
 	defer span.End()
 	defer done()
 
-	if message.Event != "scheduled_task_executed" {
+	if message.Event != events.ScheduledTaskExecuted.Name {
 		slog.Debug("ignoring event", slog.String("event", message.Event))
 		return
 	}
 
-	scheduledTask, ok := message.Value.(domain.ScheduledTask)
-	if !ok {
-		slog.Warn("invalid scheduled task message format", slog.Any("value", message.Value))
+	event, err := events.ScheduledTaskExecuted.Decode(message)
+	if err != nil {
+		slog.Warn("invalid scheduled task message format", slog.Any("error", err))
 		span.RecordError(errInvalidScheduledTaskMessage)
 		return
 	}
+	scheduledTask := event.Payload
+
+	if !w.outbox.claim(ctx, message) {
+		return
+	}
 
 	scheduledTaskID := scheduledTask.ID
 	span.SetAttributes(attribute.String("scheduled_task.id", scheduledTaskID.String()))
@@ -130,9 +139,14 @@ func (w *NotificationWorker) processScheduledTaskEvent(ctx context.Context, mess
 			slog.String("scheduled_task_id", scheduledTaskID.String()),
 			slog.Any("error", err))
 		span.RecordError(fmt.Errorf("failed to find tasks: %w", err))
+		w.outbox.release(ctx, message)
 		return
 	}
 
+	// Notifications that fail are not retried, so the event is acknowledged once the tasks were
+	// found rather than resending the emails that did go out.
+	defer w.outbox.markProcessed(ctx, message)
+
 	if len(tasks) == 0 {
 		slog.Debug("no tasks found for scheduled task",
 			slog.String("scheduled_task_id", scheduledTaskID.String()))
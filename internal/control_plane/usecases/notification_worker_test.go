@@ -10,6 +10,7 @@ import (
 	"zensor-server/internal/infra/notification"
 	"zensor-server/internal/infra/utils"
 	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
 
 	usecases_mocks "zensor-server/test/unit/doubles/control_plane/usecases"
 	notification_mocks "zensor-server/test/unit/doubles/infra/notification"
@@ -28,6 +29,7 @@ var _ = Describe("NotificationWorker", func() {
 		mockDeviceService       *usecases_mocks.MockDeviceService
 		mockTenantConfigService *sharedkernel_mocks.MockTenantConfigurationService
 		mockTaskService         *usecases_mocks.MockTaskService
+		mockOutbox              *usecases_mocks.MockOutboxRepository
 		worker                  *usecases.NotificationWorker
 		ctx                     context.Context
 		cancel                  context.CancelFunc
@@ -41,6 +43,7 @@ var _ = Describe("NotificationWorker", func() {
 		mockDeviceService = usecases_mocks.NewMockDeviceService(ctrl)
 		mockTenantConfigService = sharedkernel_mocks.NewMockTenantConfigurationService(ctrl)
 		mockTaskService = usecases_mocks.NewMockTaskService(ctrl)
+		mockOutbox = usecases_mocks.NewMockOutboxRepository(ctrl)
 		realBroker = async.NewLocalBroker()
 		ctx, cancel = context.WithCancel(context.Background()) //nolint:fatcontext // fresh root context per test setup
 
@@ -50,6 +53,7 @@ var _ = Describe("NotificationWorker", func() {
 			mockDeviceService,
 			mockTenantConfigService,
 			mockTaskService,
+			mockOutbox,
 			realBroker,
 		)
 	})
@@ -157,10 +161,7 @@ var _ = Describe("NotificationWorker", func() {
 
 				time.Sleep(100 * time.Millisecond)
 
-				brokerMessage := async.BrokerMessage{
-					Event: "scheduled_task_executed",
-					Value: scheduledTask,
-				}
+				brokerMessage := events.ScheduledTaskExecuted.New("", scheduledTask).Message()
 				err := realBroker.Publish(ctx, async.BrokerTopicName("scheduled_tasks"), brokerMessage)
 				Expect(err).ToNot(HaveOccurred())
 
@@ -213,10 +214,7 @@ var _ = Describe("NotificationWorker", func() {
 
 				time.Sleep(100 * time.Millisecond)
 
-				brokerMessage := async.BrokerMessage{
-					Event: "scheduled_task_executed",
-					Value: scheduledTask,
-				}
+				brokerMessage := events.ScheduledTaskExecuted.New("", scheduledTask).Message()
 				err := realBroker.Publish(ctx, async.BrokerTopicName("scheduled_tasks"), brokerMessage)
 				Expect(err).ToNot(HaveOccurred())
 
@@ -267,10 +265,7 @@ var _ = Describe("NotificationWorker", func() {
 
 				time.Sleep(100 * time.Millisecond)
 
-				brokerMessage := async.BrokerMessage{
-					Event: "scheduled_task_executed",
-					Value: scheduledTask,
-				}
+				brokerMessage := events.ScheduledTaskExecuted.New("", scheduledTask).Message()
 				err := realBroker.Publish(ctx, async.BrokerTopicName("scheduled_tasks"), brokerMessage)
 				Expect(err).ToNot(HaveOccurred())
 
@@ -299,10 +294,7 @@ var _ = Describe("NotificationWorker", func() {
 
 				time.Sleep(100 * time.Millisecond)
 
-				brokerMessage := async.BrokerMessage{
-					Event: "scheduled_task_executed",
-					Value: scheduledTask,
-				}
+				brokerMessage := events.ScheduledTaskExecuted.New("", scheduledTask).Message()
 				err := realBroker.Publish(ctx, async.BrokerTopicName("scheduled_tasks"), brokerMessage)
 				Expect(err).ToNot(HaveOccurred())
 
@@ -336,10 +328,7 @@ var _ = Describe("NotificationWorker", func() {
 
 				time.Sleep(100 * time.Millisecond)
 
-				brokerMessage := async.BrokerMessage{
-					Event: "scheduled_task_executed",
-					Value: scheduledTask,
-				}
+				brokerMessage := events.ScheduledTaskExecuted.New("", scheduledTask).Message()
 				err := realBroker.Publish(ctx, async.BrokerTopicName("scheduled_tasks"), brokerMessage)
 				Expect(err).ToNot(HaveOccurred())
 
@@ -383,10 +372,7 @@ var _ = Describe("NotificationWorker", func() {
 
 				time.Sleep(100 * time.Millisecond)
 
-				brokerMessage := async.BrokerMessage{
-					Event: "scheduled_task_executed",
-					Value: scheduledTask,
-				}
+				brokerMessage := events.ScheduledTaskExecuted.New("", scheduledTask).Message()
 				err := realBroker.Publish(ctx, async.BrokerTopicName("scheduled_tasks"), brokerMessage)
 				Expect(err).ToNot(HaveOccurred())
 
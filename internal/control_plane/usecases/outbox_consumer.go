@@ -0,0 +1,93 @@
+package usecases
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
+)
+
+const (
+	_commandWorkerOutboxConsumer      = "command_worker"
+	_notificationWorkerOutboxConsumer = "notification_worker"
+)
+
+// _outboxEventConsumers lists, per event, the consumers that record the outbox events they
+// process. An event is acknowledged once every consumer of its type processed it.
+var _outboxEventConsumers = map[string][]string{
+	events.CommandStatusUpdated.Name:  {_commandWorkerOutboxConsumer},
+	events.ScheduledTaskExecuted.Name: {_notificationWorkerOutboxConsumer},
+}
+
+// OutboxEventConsumers returns the consumers that must process an outbox event of the given type
+// before it is acknowledged. Events without registered consumers are acknowledged by the first
+// consumer that processes them.
+func OutboxEventConsumers(event string) []string {
+	return slices.Clone(_outboxEventConsumers[event])
+}
+
+// outboxConsumer makes a consumer idempotent for events relayed from the outbox, which may be
+// delivered more than once and to several replicas. The consumer claims a message before acting
+// on it, releases the claim when it fails so a redelivery retries, and marks it processed once
+// done. Messages without an ID were not relayed and are always processed.
+type outboxConsumer struct {
+	name             string
+	outboxRepository OutboxRepository
+}
+
+// claim reports whether the consumer may process the message, which it may not when it was
+// claimed before, on this replica or another. Claim failures are logged and the message is
+// processed, favouring a duplicate over a lost event.
+func (c outboxConsumer) claim(ctx context.Context, msg async.BrokerMessage) bool {
+	if msg.ID == "" {
+		return true
+	}
+
+	claimed, err := c.outboxRepository.ClaimProcessing(ctx, c.name, domain.ID(msg.ID), time.Now())
+	if err != nil {
+		slog.Error("claiming outbox event",
+			slog.String("consumer", c.name),
+			slog.String("event_id", msg.ID),
+			slog.Any("error", err))
+		return true
+	}
+	if !claimed {
+		slog.Debug("discarding redelivered outbox event",
+			slog.String("consumer", c.name),
+			slog.String("event_id", msg.ID))
+	}
+	return claimed
+}
+
+// release drops the claim on a message the consumer failed to process, so it is processed again
+// when the outbox redelivers it.
+func (c outboxConsumer) release(ctx context.Context, msg async.BrokerMessage) {
+	if msg.ID == "" {
+		return
+	}
+
+	if err := c.outboxRepository.ReleaseProcessing(ctx, c.name, domain.ID(msg.ID)); err != nil {
+		slog.Error("releasing outbox event",
+			slog.String("consumer", c.name),
+			slog.String("event_id", msg.ID),
+			slog.Any("error", err))
+	}
+}
+
+// markProcessed records that the consumer processed the message, which acknowledges it once every
+// consumer of its event did.
+func (c outboxConsumer) markProcessed(ctx context.Context, msg async.BrokerMessage) {
+	if msg.ID == "" {
+		return
+	}
+
+	if err := c.outboxRepository.MarkProcessed(ctx, c.name, domain.ID(msg.ID), time.Now()); err != nil {
+		slog.Error("marking outbox event as processed",
+			slog.String("consumer", c.name),
+			slog.String("event_id", msg.ID),
+			slog.Any("error", err))
+	}
+}
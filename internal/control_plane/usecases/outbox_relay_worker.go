@@ -0,0 +1,144 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+const _outboxRelayBatchSize = 100
+
+// OutboxRelayConfig controls how the outbox is relayed. Published events that stay
+// unacknowledged for RedeliveryTimeout are published again, up to MaxAttempts times, and
+// acknowledged events are purged once older than Retention.
+type OutboxRelayConfig struct {
+	RedeliveryTimeout time.Duration
+	MaxAttempts       int
+	Retention         time.Duration
+}
+
+func NewOutboxRelayWorker(
+	ticker *time.Ticker,
+	outboxRepository OutboxRepository,
+	broker async.InternalBroker,
+	config OutboxRelayConfig,
+) *OutboxRelayWorker {
+	return &OutboxRelayWorker{
+		ticker:           ticker,
+		outboxRepository: outboxRepository,
+		broker:           broker,
+		config:           config,
+	}
+}
+
+var _ async.Worker = &OutboxRelayWorker{}
+
+// OutboxRelayWorker publishes the pending outbox events on the internal broker, giving them
+// at-least-once delivery: an event is published again until a consumer acknowledges it.
+type OutboxRelayWorker struct {
+	ticker           *time.Ticker
+	outboxRepository OutboxRepository
+	broker           async.InternalBroker
+	config           OutboxRelayConfig
+	lastPurgeAt      time.Time
+}
+
+func (w *OutboxRelayWorker) Run(ctx context.Context, done func()) {
+	slog.Info("outbox relay worker started")
+	defer done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("outbox relay worker cancelled")
+			return
+		case <-w.ticker.C:
+			w.relay(context.Background(), time.Now())
+			w.purge(context.Background(), time.Now())
+		}
+	}
+}
+
+func (w *OutboxRelayWorker) relay(ctx context.Context, now time.Time) {
+	events, err := w.outboxRepository.FindPending(ctx, now.Add(-w.config.RedeliveryTimeout), w.config.MaxAttempts, _outboxRelayBatchSize)
+	if err != nil {
+		slog.Error("finding pending outbox events", slog.Any("error", err))
+		return
+	}
+
+	for _, event := range events {
+		w.publish(ctx, event)
+
+		// The attempt is recorded even when publishing failed, so an event nobody subscribes to
+		// backs off until the redelivery timeout instead of being retried on every tick.
+		if err := w.outboxRepository.MarkPublished(ctx, event, now); err != nil {
+			slog.Error("marking outbox event as published",
+				slog.String("event_id", event.ID.String()),
+				slog.Any("error", err))
+			continue
+		}
+
+		if event.Attempts+1 >= w.config.MaxAttempts {
+			slog.Warn("outbox event ran out of delivery attempts",
+				slog.String("event_id", event.ID.String()),
+				slog.String("event", event.Event),
+				slog.Int("attempts", event.Attempts+1))
+		}
+	}
+}
+
+func (w *OutboxRelayWorker) publish(ctx context.Context, event domain.OutboxEvent) {
+	// The envelope is rebuilt from the stored payload with the registered type of the event, so
+	// consumers receive the same value as if it was published directly.
+	value, err := async.DefaultEventRegistry.Build(async.Event[json.RawMessage]{
+		ID:         event.ID.String(),
+		Type:       event.Event,
+		OccurredAt: event.CreatedAt.Time,
+		TenantID:   event.TenantID.String(),
+		Payload:    event.Payload,
+	})
+	if err != nil {
+		slog.Error("decoding outbox event",
+			slog.String("event_id", event.ID.String()),
+			slog.String("event", event.Event),
+			slog.Any("error", err))
+		return
+	}
+
+	brokerMsg := async.BrokerMessage{
+		ID:    event.ID.String(),
+		Event: event.Event,
+		Value: value,
+	}
+	if err := w.broker.Publish(ctx, async.BrokerTopicName(event.Topic), brokerMsg); err != nil {
+		slog.Error("publishing outbox event",
+			slog.String("event_id", event.ID.String()),
+			slog.String("topic", event.Topic),
+			slog.Any("error", err))
+	}
+}
+
+// purge removes acknowledged events past the retention, at most once per retention tenth so the
+// delete does not run on every tick.
+func (w *OutboxRelayWorker) purge(ctx context.Context, now time.Time) {
+	if now.Sub(w.lastPurgeAt) < w.config.Retention/10 {
+		return
+	}
+	w.lastPurgeAt = now
+
+	deleted, err := w.outboxRepository.DeleteAcknowledgedBefore(ctx, now.Add(-w.config.Retention))
+	if err != nil {
+		slog.Error("purging acknowledged outbox events", slog.Any("error", err))
+		return
+	}
+	if deleted > 0 {
+		slog.Debug("purged acknowledged outbox events", slog.Int("deleted", deleted))
+	}
+}
+
+func (w *OutboxRelayWorker) Shutdown() {
+	slog.Warn("outbox relay worker shutdown is not yet implemented")
+}
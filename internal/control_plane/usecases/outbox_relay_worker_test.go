@@ -0,0 +1,104 @@
+package usecases_test
+
+import (
+	"context"
+	"time"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
+
+	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+	mockasync "zensor-server/test/unit/doubles/infra/async"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = ginkgo.Describe("OutboxRelayWorker", func() {
+	var (
+		ctrl       *gomock.Controller
+		mockOutbox *mockusecases.MockOutboxRepository
+		mockBroker *mockasync.MockInternalBroker
+		ticker     *time.Ticker
+		config     usecases.OutboxRelayConfig
+		event      domain.OutboxEvent
+		published  chan domain.OutboxEvent
+	)
+
+	ginkgo.BeforeEach(func() {
+		ctrl = gomock.NewController(ginkgo.GinkgoT())
+		mockOutbox = mockusecases.NewMockOutboxRepository(ctrl)
+		mockBroker = mockasync.NewMockInternalBroker(ctrl)
+		ticker = time.NewTicker(10 * time.Millisecond)
+		config = usecases.OutboxRelayConfig{RedeliveryTimeout: time.Minute, MaxAttempts: 5, Retention: time.Hour}
+		published = make(chan domain.OutboxEvent, 1)
+
+		var err error
+		event, err = domain.NewOutboxEvent("devices/device-1/command_status", "command_status_update",
+			domain.CommandStatusUpdate{CommandID: "command-1", DeviceName: "device-1", Status: domain.CommandStatusAck}, time.Now())
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		mockOutbox.EXPECT().DeleteAcknowledgedBefore(gomock.Any(), gomock.Any()).Return(0, nil).AnyTimes()
+	})
+
+	ginkgo.AfterEach(func() {
+		ticker.Stop()
+	})
+
+	run := func() {
+		worker := usecases.NewOutboxRelayWorker(ticker, mockOutbox, mockBroker, config)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go worker.Run(ctx, func() { close(done) })
+
+		gomega.Eventually(published).Should(gomega.Receive())
+		cancel()
+		gomega.Eventually(done).Should(gomega.BeClosed())
+	}
+
+	expectMarkPublished := func() {
+		mockOutbox.EXPECT().MarkPublished(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, value domain.OutboxEvent, _ time.Time) error {
+				select {
+				case published <- value:
+				default:
+				}
+				return nil
+			}).MinTimes(1)
+	}
+
+	ginkgo.When("there are pending events", func() {
+		ginkgo.It("should publish them with their ID and typed value", func() {
+			mockOutbox.EXPECT().FindPending(gomock.Any(), gomock.Any(), 5, gomock.Any()).Return([]domain.OutboxEvent{event}, nil).MinTimes(1)
+			mockBroker.EXPECT().Publish(gomock.Any(), async.BrokerTopicName("devices/device-1/command_status"), gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ async.BrokerTopicName, msg async.BrokerMessage) error {
+					gomega.Expect(msg.ID).To(gomega.Equal(event.ID.String()))
+					gomega.Expect(msg.Event).To(gomega.Equal("command_status_update"))
+					statusUpdate, err := events.CommandStatusUpdated.Decode(msg)
+					gomega.Expect(err).NotTo(gomega.HaveOccurred())
+					gomega.Expect(statusUpdate.ID).To(gomega.Equal(event.ID.String()))
+					gomega.Expect(statusUpdate.Payload).To(gomega.Equal(domain.CommandStatusUpdate{
+						CommandID:  "command-1",
+						DeviceName: "device-1",
+						Status:     domain.CommandStatusAck,
+					}))
+					return nil
+				}).MinTimes(1)
+			expectMarkPublished()
+
+			run()
+		})
+	})
+
+	ginkgo.When("nobody subscribes to the event topic", func() {
+		ginkgo.It("should still record the attempt so the event backs off", func() {
+			mockOutbox.EXPECT().FindPending(gomock.Any(), gomock.Any(), 5, gomock.Any()).Return([]domain.OutboxEvent{event}, nil).MinTimes(1)
+			mockBroker.EXPECT().Publish(gomock.Any(), gomock.Any(), gomock.Any()).Return(async.ErrTopicNotFound).MinTimes(1)
+			expectMarkPublished()
+
+			run()
+		})
+	})
+})
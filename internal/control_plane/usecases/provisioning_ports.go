@@ -0,0 +1,16 @@
+package usecases
+
+import (
+	"context"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+//go:generate mockgen -source=provisioning_ports.go -destination=../../../test/unit/doubles/control_plane/usecases/provisioning_ports_mock.go -package=usecases -mock_names=NetworkServerProvisioner=MockNetworkServerProvisioner
+
+// NetworkServerProvisioner registers devices in the LoRaWAN network server with the identifiers
+// and root key Zensor holds for them. Both operations must be safe to retry.
+type NetworkServerProvisioner interface {
+	Provision(ctx context.Context, device domain.Device) error
+	// Deprovision removes the device; a device the network server does not know is not an error.
+	Deprovision(ctx context.Context, device domain.Device) error
+}
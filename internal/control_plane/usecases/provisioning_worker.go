@@ -0,0 +1,112 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+const (
+	_provisioningBatchSize = 100
+)
+
+func NewProvisioningWorker(
+	ticker *time.Ticker,
+	repository DeviceRepository,
+	provisioner NetworkServerProvisioner,
+) *ProvisioningWorker {
+	return &ProvisioningWorker{
+		ticker:      ticker,
+		repository:  repository,
+		provisioner: provisioner,
+	}
+}
+
+var _ async.Worker = &ProvisioningWorker{}
+
+// ProvisioningWorker retries the network server registration of devices whose provisioning is
+// pending or failed, and the removal of decommissioned devices whose deprovisioning failed.
+type ProvisioningWorker struct {
+	ticker      *time.Ticker
+	repository  DeviceRepository
+	provisioner NetworkServerProvisioner
+}
+
+func (w *ProvisioningWorker) Run(ctx context.Context, done func()) {
+	slog.Info("provisioning worker started")
+	defer done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("provisioning worker cancelled")
+			return
+		case <-w.ticker.C:
+			w.retryDue(ctx, time.Now())
+		}
+	}
+}
+
+func (w *ProvisioningWorker) retryDue(ctx context.Context, now time.Time) {
+	devices, err := w.repository.FindDueForProvisioning(ctx, now, _provisioningBatchSize)
+	if err != nil {
+		slog.Error("finding devices due for provisioning", slog.Any("error", err))
+		return
+	}
+
+	for _, device := range devices {
+		w.sync(ctx, device, now)
+	}
+}
+
+func (w *ProvisioningWorker) sync(ctx context.Context, device domain.Device, now time.Time) {
+	var err error
+	if device.IsDeleted() {
+		err = w.deprovision(ctx, device)
+	} else {
+		err = w.provisioner.Provision(ctx, device)
+	}
+
+	if err != nil {
+		slog.Warn("syncing device with network server",
+			slog.String("device_id", device.ID.String()),
+			slog.Int("attempt", device.Provisioning.Attempts+1),
+			slog.Any("error", err))
+		device.RecordProvisioningFailure(err, now)
+	} else {
+		device.RecordProvisioningSuccess(now)
+	}
+
+	err = w.repository.UpdateProvisioning(ctx, device)
+	if err != nil {
+		slog.Error("updating device provisioning",
+			slog.String("device_id", device.ID.String()),
+			slog.Any("error", err))
+	}
+}
+
+// deprovision removes a decommissioned device unless its name, which is its network server ID, was
+// reused by an active device that now owns the registration.
+func (w *ProvisioningWorker) deprovision(ctx context.Context, device domain.Device) error {
+	active, err := w.repository.FindByName(ctx, device.Name)
+	if err != nil && !errors.Is(err, ErrDeviceNotFound) {
+		return fmt.Errorf("finding active device with the same name: %w", err)
+	}
+
+	if err == nil && active.ID != device.ID {
+		slog.Info("skipping deprovisioning of a device whose name was reused",
+			slog.String("device_id", device.ID.String()),
+			slog.String("device_name", device.Name))
+		return nil
+	}
+
+	return w.provisioner.Deprovision(ctx, device)
+}
+
+func (w *ProvisioningWorker) Shutdown() {
+	slog.Warn("provisioning worker shutdown is not yet implemented")
+}
@@ -0,0 +1,115 @@
+package usecases_test
+
+import (
+	"context"
+	"errors"
+	"time"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/infra/ttn"
+	"zensor-server/internal/shared_kernel/domain"
+
+	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = ginkgo.Describe("ProvisioningWorker", func() {
+	var (
+		ctrl           *gomock.Controller
+		mockDeviceRepo *mockusecases.MockDeviceRepository
+		provisioner    *ttn.FakeProvisioner
+		ticker         *time.Ticker
+		device         domain.Device
+		updated        chan domain.Device
+	)
+
+	ginkgo.BeforeEach(func() {
+		ctrl = gomock.NewController(ginkgo.GinkgoT())
+		mockDeviceRepo = mockusecases.NewMockDeviceRepository(ctrl)
+		provisioner = ttn.NewFakeProvisioner()
+		ticker = time.NewTicker(10 * time.Millisecond)
+		device = domain.Device{ID: domain.ID("device-1"), Name: "valve-1"}
+		updated = make(chan domain.Device, 1)
+
+		mockDeviceRepo.EXPECT().UpdateProvisioning(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, value domain.Device) error {
+			select {
+			case updated <- value:
+			default:
+			}
+			return nil
+		}).AnyTimes()
+	})
+
+	ginkgo.AfterEach(func() {
+		ticker.Stop()
+	})
+
+	runUntilUpdated := func() domain.Device {
+		mockDeviceRepo.EXPECT().FindDueForProvisioning(gomock.Any(), gomock.Any(), gomock.Any()).Return([]domain.Device{device}, nil).MinTimes(1)
+
+		worker := usecases.NewProvisioningWorker(ticker, mockDeviceRepo, provisioner)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go worker.Run(ctx, func() { close(done) })
+
+		var result domain.Device
+		gomega.Eventually(updated).Should(gomega.Receive(&result))
+		cancel()
+		gomega.Eventually(done).Should(gomega.BeClosed())
+		return result
+	}
+
+	ginkgo.When("a device failed to be provisioned", func() {
+		ginkgo.BeforeEach(func() {
+			device.RecordProvisioningFailure(errors.New("registry unavailable"), time.Now().Add(-time.Hour))
+		})
+
+		ginkgo.It("should register it and mark it provisioned", func() {
+			result := runUntilUpdated()
+
+			gomega.Expect(result.Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusProvisioned))
+			_, registered := provisioner.Device("valve-1")
+			gomega.Expect(registered).To(gomega.BeTrue())
+		})
+
+		ginkgo.It("should schedule another attempt when it fails again", func() {
+			provisioner.FailWith(errors.New("still unavailable"))
+
+			result := runUntilUpdated()
+
+			gomega.Expect(result.Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusFailed))
+			gomega.Expect(result.Provisioning.Attempts).To(gomega.Equal(2))
+			gomega.Expect(result.Provisioning.LastError).To(gomega.Equal("still unavailable"))
+		})
+	})
+
+	ginkgo.When("a decommissioned device failed to be removed", func() {
+		ginkgo.BeforeEach(func() {
+			gomega.Expect(provisioner.Provision(context.Background(), device)).To(gomega.Succeed())
+			device.Decommission()
+			device.RecordProvisioningFailure(errors.New("registry unavailable"), time.Now().Add(-time.Hour))
+		})
+
+		ginkgo.It("should remove it from the network server", func() {
+			mockDeviceRepo.EXPECT().FindByName(gomock.Any(), "valve-1").Return(domain.Device{}, usecases.ErrDeviceNotFound).MinTimes(1)
+
+			result := runUntilUpdated()
+
+			gomega.Expect(result.Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusDeprovisioned))
+			_, registered := provisioner.Device("valve-1")
+			gomega.Expect(registered).To(gomega.BeFalse())
+		})
+
+		ginkgo.It("should leave the registration alone when an active device reused the name", func() {
+			mockDeviceRepo.EXPECT().FindByName(gomock.Any(), "valve-1").Return(domain.Device{ID: domain.ID("device-2"), Name: "valve-1"}, nil).MinTimes(1)
+
+			result := runUntilUpdated()
+
+			gomega.Expect(result.Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusDeprovisioned))
+			_, registered := provisioner.Device("valve-1")
+			gomega.Expect(registered).To(gomega.BeTrue())
+		})
+	})
+})
@@ -3,28 +3,70 @@ package usecases
 import (
 	"context"
 	"errors"
+	"time"
 	"zensor-server/internal/shared_kernel/domain"
 
 	sharedUsecases "zensor-server/internal/shared_kernel/usecases"
 )
 
-//go:generate mockgen -source=repository_port.go -destination=../../../test/unit/doubles/control_plane/usecases/repository_port_mock.go -package=usecases -mock_names=DeviceRepository=MockDeviceRepository,CommandRepository=MockCommandRepository,EvaluationRuleRepository=MockEvaluationRuleRepository,TaskRepository=MockTaskRepository,ScheduledTaskRepository=MockScheduledTaskRepository
+//go:generate mockgen -source=repository_port.go -destination=../../../test/unit/doubles/control_plane/usecases/repository_port_mock.go -package=usecases -mock_names=DeviceRepository=MockDeviceRepository,CommandRepository=MockCommandRepository,EvaluationRuleRepository=MockEvaluationRuleRepository,TaskRepository=MockTaskRepository,ScheduledTaskRepository=MockScheduledTaskRepository,ScheduledTaskRunRepository=MockScheduledTaskRunRepository,CommandTemplateSetRepository=MockCommandTemplateSetRepository,DeviceConnectivityRepository=MockDeviceConnectivityRepository,DeviceSessionRepository=MockDeviceSessionRepository,DeviceKeyAccessRepository=MockDeviceKeyAccessRepository,DeviceConfigJobRepository=MockDeviceConfigJobRepository,OutboxRepository=MockOutboxRepository,WebhookSubscriptionRepository=MockWebhookSubscriptionRepository,WebhookDeliveryRepository=MockWebhookDeliveryRepository,Transactor=MockTransactor
 
-type Pagination = sharedUsecases.Pagination
+type (
+	Pagination        = sharedUsecases.Pagination
+	DeviceQuery       = sharedUsecases.DeviceQuery
+	DeviceFilter      = sharedUsecases.DeviceFilter
+	DeviceSort        = sharedUsecases.DeviceSort
+	DeviceTagSelector = sharedUsecases.DeviceTagSelector
+	DevicePage        = sharedUsecases.DevicePage
+)
+
+const (
+	DeviceSortByName        = sharedUsecases.DeviceSortByName
+	DeviceSortByDisplayName = sharedUsecases.DeviceSortByDisplayName
+	DeviceSortByLastSeen    = sharedUsecases.DeviceSortByLastSeen
+)
 
 var (
 	ErrDeviceNotFound   = errors.New("device not found")
 	ErrDeviceDuplicated = errors.New("device already exists")
 	ErrCommandOverlap   = errors.New("command overlap detected")
+
+	ErrDeviceConnectivityNotFound = errors.New("device connectivity not found")
+	ErrDeviceSessionNotFound      = errors.New("device session not found")
+
+	ErrDeviceConfigJobNotFound        = errors.New("device config job not found")
+	ErrDeviceConfigJobVersionConflict = errors.New("device config job was modified concurrently")
+
+	ErrWebhookSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound     = errors.New("webhook delivery not found")
+	ErrWebhookDeliveryDuplicated   = errors.New("the event was already delivered to the webhook")
+
+	ErrInvalidDeviceCursor = sharedUsecases.ErrInvalidDeviceCursor
 )
 
 type DeviceRepository interface {
 	CreateDevice(context.Context, domain.Device) error
+	// CreateDevices creates all the devices or none of them.
+	CreateDevices(context.Context, []domain.Device) error
 	UpdateDevice(context.Context, domain.Device) error
+	// UpdateProvisioning writes only the provisioning state of the device, decommissioned or not.
+	UpdateProvisioning(context.Context, domain.Device) error
+	FindDueForProvisioning(ctx context.Context, now time.Time, limit int) ([]domain.Device, error)
+	// UpdateBattery writes only the last battery reading of the device.
+	UpdateBattery(context.Context, domain.Device) error
+	// UpdateHealth writes only the last health evaluation of the device.
+	UpdateHealth(context.Context, domain.Device) error
 	Get(context.Context, string) (domain.Device, error)
 	FindByName(context.Context, string) (domain.Device, error)
+	FindByDevEUI(context.Context, string) (domain.Device, error)
 	FindAll(context.Context, Pagination) ([]domain.Device, int, error)
 	FindByTenant(context.Context, string, Pagination) ([]domain.Device, int, error)
+	// FindDevices lists the active devices matching the query; an undecodable cursor returns
+	// ErrInvalidDeviceCursor.
+	FindDevices(context.Context, DeviceQuery) (DevicePage, error)
+	// ResealAppKeys seals plaintext AppKeys and rewraps those sealed with a retired key, up to
+	// limit devices, and returns how many it resealed.
+	ResealAppKeys(ctx context.Context, limit int) (int, error)
 	AddEvaluationRule(context.Context, domain.Device, domain.EvaluationRule) error
 	FindAllEvaluationRules(context.Context, domain.Device) ([]domain.EvaluationRule, error)
 }
@@ -36,12 +78,58 @@ type CommandRepository interface {
 	FindAllPending(context.Context) ([]domain.Command, error)
 	FindPendingByDevice(context.Context, domain.ID) ([]domain.Command, error)
 	FindByTaskID(context.Context, domain.ID) ([]domain.Command, error)
-	FindAllReadyToDispatch(context.Context) ([]domain.Command, error)
+	// CancelPendingByDevice cancels every unsent command of the device and returns how many were cancelled.
+	CancelPendingByDevice(ctx context.Context, deviceID domain.ID, reason string) (int, error)
+	// ClaimReadyToDispatch atomically claims every ready, unsent command that is not claimed by
+	// anyone else for lease, and returns only the commands claimed by claimer. Claims left by a
+	// crashed claimer expire after lease.
+	ClaimReadyToDispatch(ctx context.Context, claimer string, lease time.Duration) ([]domain.Command, error)
+	ReleaseClaim(ctx context.Context, id domain.ID, claimer string) error
+	// MarkSent records the command as sent at sentAt and releases its claim, so the replica that
+	// dispatched it persists the outcome and no other replica claims it again.
+	MarkSent(ctx context.Context, id domain.ID, sentAt time.Time) error
+	// CountOutcomesByDevice counts the commands of the device acknowledged or failed since the given instant.
+	CountOutcomesByDevice(ctx context.Context, deviceID domain.ID, since time.Time) (domain.CommandOutcomes, error)
+}
+
+type DeviceConnectivityRepository interface {
+	Create(context.Context, domain.DeviceConnectivityTransition) error
+	// FindLatestByDevice returns the last recorded transition, which holds the device's known state.
+	FindLatestByDevice(ctx context.Context, deviceID domain.ID) (domain.DeviceConnectivityTransition, error)
+	FindAllByDevice(ctx context.Context, deviceID domain.ID, pagination Pagination) ([]domain.DeviceConnectivityTransition, int, error)
+}
+
+// DeviceConfigJobRepository stores configuration pushes. Update only succeeds when the job was not
+// modified since it was read, and returns ErrDeviceConfigJobVersionConflict otherwise.
+type DeviceConfigJobRepository interface {
+	Create(context.Context, domain.DeviceConfigJob) error
+	Update(context.Context, domain.DeviceConfigJob) error
+	Get(ctx context.Context, id domain.ID) (domain.DeviceConfigJob, error)
+	FindAllByDevice(ctx context.Context, deviceID domain.ID, pagination Pagination) ([]domain.DeviceConfigJob, int, error)
+	// FindActive returns up to limit pending or running jobs, oldest first.
+	FindActive(ctx context.Context, limit int) ([]domain.DeviceConfigJob, error)
+	FindActiveByDevice(ctx context.Context, deviceID domain.ID) ([]domain.DeviceConfigJob, error)
+}
+
+// DeviceKeyAccessRepository keeps the audit trail of revealed device root keys.
+type DeviceKeyAccessRepository interface {
+	Create(context.Context, domain.DeviceKeyAccess) error
+	FindAllByDevice(ctx context.Context, deviceID domain.ID, pagination Pagination) ([]domain.DeviceKeyAccess, int, error)
+}
+
+type DeviceSessionRepository interface {
+	GetSession(ctx context.Context, deviceID domain.ID) (domain.DeviceSession, error)
+	SaveSession(context.Context, domain.DeviceSession) error
+	CreateSample(context.Context, domain.LinkQualitySample) error
+	FindSamplesByDevice(ctx context.Context, deviceID domain.ID, pagination Pagination) ([]domain.LinkQualitySample, int, error)
+	// FindSamplesSince returns up to limit samples of the device received since the given instant, newest first.
+	FindSamplesSince(ctx context.Context, deviceID domain.ID, since time.Time, limit int) ([]domain.LinkQualitySample, error)
 }
 
 type EvaluationRuleRepository interface {
 	AddToDevice(context.Context, domain.Device, domain.EvaluationRule) error
 	FindAllByDeviceID(ctx context.Context, deviceID string) ([]domain.EvaluationRule, error)
+	DisableAllByDeviceID(ctx context.Context, deviceID string) error
 }
 
 type TaskRepository interface {
@@ -53,9 +141,95 @@ type TaskRepository interface {
 type ScheduledTaskRepository interface {
 	Create(context.Context, domain.ScheduledTask) error
 	FindAllByTenant(context.Context, domain.ID) ([]domain.ScheduledTask, error)
+	// FindAllByTenantAndDevice returns the scheduled tasks owned by or targeting the device.
 	FindAllByTenantAndDevice(context.Context, domain.ID, domain.ID, Pagination) ([]domain.ScheduledTask, int, error)
+	// FindAllByDevice returns the scheduled tasks owned by or targeting the device in any tenant.
+	FindAllByDevice(ctx context.Context, deviceID domain.ID) ([]domain.ScheduledTask, error)
+	CountByCommandTemplateSet(ctx context.Context, setID domain.ID) (int, error)
 	FindAllActive(context.Context) ([]domain.ScheduledTask, error)
 	Update(context.Context, domain.ScheduledTask) error
+	// RecordExecution updates the scheduled task and appends the event announcing its execution
+	// to the outbox in the same transaction.
+	RecordExecution(ctx context.Context, scheduledTask domain.ScheduledTask, event domain.OutboxEvent) error
 	GetByID(context.Context, domain.ID) (domain.ScheduledTask, error)
 	Delete(context.Context, domain.ID) error
 }
+
+// ScheduledTaskRunFilter narrows scheduled task runs to those evaluated in [From, To).
+type ScheduledTaskRunFilter struct {
+	From *time.Time
+	To   *time.Time
+}
+
+type ScheduledTaskRunRepository interface {
+	Create(context.Context, domain.ScheduledTaskRun) error
+	FindAllByScheduledTask(ctx context.Context, scheduledTaskID domain.ID, filter ScheduledTaskRunFilter, pagination Pagination) ([]domain.ScheduledTaskRun, int, error)
+	ReassignTenant(ctx context.Context, scheduledTaskID domain.ID, tenantID domain.ID) error
+}
+
+type CommandTemplateSetRepository interface {
+	Create(context.Context, domain.CommandTemplateSet) error
+	Update(context.Context, domain.CommandTemplateSet) error
+	GetByID(context.Context, domain.ID) (domain.CommandTemplateSet, error)
+	FindAllByTenant(ctx context.Context, tenantID domain.ID, pagination Pagination) ([]domain.CommandTemplateSet, int, error)
+	Delete(context.Context, domain.ID) error
+}
+
+// OutboxRepository stores broker events that must survive a restart. Events are pending until
+// acknowledged, which happens once every consumer of their type, see OutboxEventConsumers, marked
+// them processed; published events that stay unacknowledged past the redelivery deadline are
+// pending again.
+type OutboxRepository interface {
+	Append(context.Context, domain.OutboxEvent) error
+	// FindPending returns, oldest first, the unacknowledged events with attempts left that were
+	// never published or were last published before redeliverBefore.
+	FindPending(ctx context.Context, redeliverBefore time.Time, maxAttempts, limit int) ([]domain.OutboxEvent, error)
+	MarkPublished(ctx context.Context, event domain.OutboxEvent, at time.Time) error
+	// ClaimProcessing records that the consumer takes the event and reports whether it did: a
+	// consumer that already claimed it, on any replica, holds it and the claim fails.
+	ClaimProcessing(ctx context.Context, consumer string, eventID domain.ID, at time.Time) (bool, error)
+	// ReleaseProcessing drops the claim of the consumer, so a redelivery of the event is processed.
+	ReleaseProcessing(ctx context.Context, consumer string, eventID domain.ID) error
+	// MarkProcessed records that the consumer processed the event and acknowledges it when every
+	// consumer of its type did.
+	MarkProcessed(ctx context.Context, consumer string, eventID domain.ID, at time.Time) error
+	// DeleteAcknowledgedBefore purges the events every consumer acknowledged before the given
+	// time, along with their consumption records, and returns how many events were removed.
+	DeleteAcknowledgedBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+type WebhookSubscriptionRepository interface {
+	Create(context.Context, domain.WebhookSubscription) error
+	Update(context.Context, domain.WebhookSubscription) error
+	GetByID(context.Context, domain.ID) (domain.WebhookSubscription, error)
+	FindAllByTenant(ctx context.Context, tenantID domain.ID, pagination Pagination) ([]domain.WebhookSubscription, int, error)
+	// FindActiveByTenant returns every subscription of the tenant that is not deleted.
+	FindActiveByTenant(ctx context.Context, tenantID domain.ID) ([]domain.WebhookSubscription, error)
+	Delete(context.Context, domain.ID) error
+	// ResealSecrets rewraps the secrets sealed with a retired key, up to limit subscriptions, and
+	// returns how many it resealed.
+	ResealSecrets(ctx context.Context, limit int) (int, error)
+}
+
+// WebhookDeliveryFilter narrows the deliveries of a subscription to those in Status, when set.
+type WebhookDeliveryFilter struct {
+	Status *domain.WebhookDeliveryStatus
+}
+
+// WebhookDeliveryRepository keeps the delivery log of webhook subscriptions. An event is
+// delivered once per subscription: Create returns ErrWebhookDeliveryDuplicated for an event the
+// subscription already has a delivery for.
+type WebhookDeliveryRepository interface {
+	Create(context.Context, domain.WebhookDelivery) error
+	Update(context.Context, domain.WebhookDelivery) error
+	GetByID(context.Context, domain.ID) (domain.WebhookDelivery, error)
+	FindAllBySubscription(ctx context.Context, subscriptionID domain.ID, filter WebhookDeliveryFilter, pagination Pagination) ([]domain.WebhookDelivery, int, error)
+	// FindDue returns up to limit pending deliveries whose next attempt is due at now, oldest first.
+	FindDue(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error)
+}
+
+// Transactor runs a function in one database transaction. The repositories called with the
+// context it passes write through that transaction, so their writes commit or roll back together.
+type Transactor interface {
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
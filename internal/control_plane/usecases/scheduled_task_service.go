@@ -4,21 +4,27 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"zensor-server/internal/infra/utils"
 	"zensor-server/internal/shared_kernel/domain"
 )
 
 var ErrScheduledTaskNotFound = errors.New("scheduled task not found")
 
-func NewScheduledTaskService(repository ScheduledTaskRepository) *SimpleScheduledTaskService {
+func NewScheduledTaskService(
+	repository ScheduledTaskRepository,
+	runRepository ScheduledTaskRunRepository,
+) *SimpleScheduledTaskService {
 	return &SimpleScheduledTaskService{
-		repository: repository,
+		repository:    repository,
+		runRepository: runRepository,
 	}
 }
 
 var _ ScheduledTaskService = (*SimpleScheduledTaskService)(nil)
 
 type SimpleScheduledTaskService struct {
-	repository ScheduledTaskRepository
+	repository    ScheduledTaskRepository
+	runRepository ScheduledTaskRunRepository
 }
 
 func (s *SimpleScheduledTaskService) Create(ctx context.Context, scheduledTask domain.ScheduledTask) error {
@@ -69,6 +75,31 @@ func (s *SimpleScheduledTaskService) Update(ctx context.Context, scheduledTask d
 	return nil
 }
 
+func (s *SimpleScheduledTaskService) Pause(ctx context.Context, scheduledTask domain.ScheduledTask, until *utils.Time) (domain.ScheduledTask, error) {
+	err := scheduledTask.Pause(until)
+	if err != nil {
+		return domain.ScheduledTask{}, err
+	}
+
+	err = s.repository.Update(ctx, scheduledTask)
+	if err != nil {
+		return domain.ScheduledTask{}, fmt.Errorf("pausing scheduled task: %w", err)
+	}
+
+	return scheduledTask, nil
+}
+
+func (s *SimpleScheduledTaskService) Resume(ctx context.Context, scheduledTask domain.ScheduledTask) (domain.ScheduledTask, error) {
+	scheduledTask.Resume()
+
+	err := s.repository.Update(ctx, scheduledTask)
+	if err != nil {
+		return domain.ScheduledTask{}, fmt.Errorf("resuming scheduled task: %w", err)
+	}
+
+	return scheduledTask, nil
+}
+
 func (s *SimpleScheduledTaskService) Delete(ctx context.Context, id domain.ID) error {
 	err := s.repository.Delete(ctx, id)
 	if err != nil {
@@ -77,3 +108,12 @@ func (s *SimpleScheduledTaskService) Delete(ctx context.Context, id domain.ID) e
 
 	return nil
 }
+
+func (s *SimpleScheduledTaskService) FindRuns(ctx context.Context, scheduledTaskID domain.ID, filter ScheduledTaskRunFilter, pagination Pagination) ([]domain.ScheduledTaskRun, int, error) {
+	runs, total, err := s.runRepository.FindAllByScheduledTask(ctx, scheduledTaskID, filter, pagination)
+	if err != nil {
+		return nil, 0, fmt.Errorf("finding scheduled task runs: %w", err)
+	}
+
+	return runs, total, nil
+}
@@ -10,6 +10,7 @@ import (
 	"zensor-server/internal/infra/async"
 	"zensor-server/internal/infra/utils"
 	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
 
 	"github.com/robfig/cron/v3"
 )
@@ -22,37 +23,41 @@ const (
 var (
 	ErrCronScheduleRequired         = errors.New("cron schedule is required for cron scheduling type")
 	ErrNoValidSchedulingConfigFound = errors.New("no valid scheduling configuration found")
+	ErrDeviceOutsideTenant          = errors.New("device does not belong to the scheduled task tenant")
 )
 
 func NewScheduledTaskWorker(
 	ticker *time.Ticker,
 	scheduledTaskRepository ScheduledTaskRepository,
+	scheduledTaskRunRepository ScheduledTaskRunRepository,
+	commandTemplateSetRepository CommandTemplateSetRepository,
 	taskService TaskService,
 	deviceService DeviceService,
 	tenantConfigurationService TenantConfigurationService,
-	broker async.InternalBroker,
 ) *ScheduledTaskWorker {
 	return &ScheduledTaskWorker{
-		ticker:                     ticker,
-		scheduledTaskRepository:    scheduledTaskRepository,
-		taskService:                taskService,
-		deviceService:              deviceService,
-		tenantConfigurationService: tenantConfigurationService,
-		broker:                     broker,
-		cronParser:                 cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow),
+		ticker:                       ticker,
+		scheduledTaskRepository:      scheduledTaskRepository,
+		scheduledTaskRunRepository:   scheduledTaskRunRepository,
+		commandTemplateSetRepository: commandTemplateSetRepository,
+		taskService:                  taskService,
+		deviceService:                deviceService,
+		tenantConfigurationService:   tenantConfigurationService,
+		cronParser:                   cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow),
 	}
 }
 
 var _ async.Worker = &ScheduledTaskWorker{}
 
 type ScheduledTaskWorker struct {
-	ticker                     *time.Ticker
-	scheduledTaskRepository    ScheduledTaskRepository
-	taskService                TaskService
-	deviceService              DeviceService
-	tenantConfigurationService TenantConfigurationService
-	broker                     async.InternalBroker
-	cronParser                 cron.Parser
+	ticker                       *time.Ticker
+	scheduledTaskRepository      ScheduledTaskRepository
+	scheduledTaskRunRepository   ScheduledTaskRunRepository
+	commandTemplateSetRepository CommandTemplateSetRepository
+	taskService                  TaskService
+	deviceService                DeviceService
+	tenantConfigurationService   TenantConfigurationService
+	cronParser                   cron.Parser
 }
 
 func (w *ScheduledTaskWorker) Run(ctx context.Context, done func()) {
@@ -106,7 +111,8 @@ func (w *ScheduledTaskWorker) evaluateAndMaybeCreateTask(ctx context.Context, sc
 		lastExecuted = scheduledTask.CreatedAt.Time
 	}
 
-	shouldExecute, err := w.shouldExecuteSchedule(ctx, scheduledTask, lastExecuted)
+	tenantConfig := w.tenantConfiguration(ctx, scheduledTask.Tenant)
+	shouldExecute, err := w.shouldExecuteSchedule(scheduledTask, tenantConfig, lastExecuted)
 	if err != nil {
 		slog.Error("evaluating schedule",
 			slog.String("scheduled_task_id", scheduledTask.ID.String()),
@@ -115,23 +121,35 @@ func (w *ScheduledTaskWorker) evaluateAndMaybeCreateTask(ctx context.Context, sc
 		return
 	}
 
-	if shouldExecute {
-		w.createTaskFromScheduledTask(ctx, scheduledTask)
+	if !shouldExecute {
+		return
+	}
+
+	now := time.Now()
+	if scheduledTask.IsPaused(now) || tenantConfig.IsSchedulesHeld(now) {
+		w.skipScheduledTask(ctx, scheduledTask, now)
+		return
 	}
+
+	w.createTaskFromScheduledTask(ctx, scheduledTask)
 }
 
-func (w *ScheduledTaskWorker) shouldExecuteSchedule(ctx context.Context, scheduledTask domain.ScheduledTask, lastExecuted time.Time) (bool, error) {
-	tenantConfig, err := w.tenantConfigurationService.GetOrCreateTenantConfiguration(ctx, scheduledTask.Tenant, _defaultTimezone)
+func (w *ScheduledTaskWorker) tenantConfiguration(ctx context.Context, tenant domain.Tenant) domain.TenantConfiguration {
+	tenantConfig, err := w.tenantConfigurationService.GetOrCreateTenantConfiguration(ctx, tenant, _defaultTimezone)
 	if err != nil {
 		slog.Error("getting tenant configuration for timezone",
-			slog.String("tenant_id", scheduledTask.Tenant.ID.String()),
+			slog.String("tenant_id", tenant.ID.String()),
 			slog.String("error", err.Error()))
 		tenantConfig, _ = domain.NewTenantConfigurationBuilder().
-			WithTenantID(scheduledTask.Tenant.ID).
+			WithTenantID(tenant.ID).
 			WithTimezone(_defaultTimezone).
 			Build()
 	}
 
+	return tenantConfig
+}
+
+func (w *ScheduledTaskWorker) shouldExecuteSchedule(scheduledTask domain.ScheduledTask, tenantConfig domain.TenantConfiguration, lastExecuted time.Time) (bool, error) {
 	location, err := time.LoadLocation(tenantConfig.Timezone)
 	if err != nil {
 		slog.Error("loading timezone location",
@@ -195,19 +213,119 @@ func (w *ScheduledTaskWorker) shouldExecuteSchedule(ctx context.Context, schedul
 }
 
 func (w *ScheduledTaskWorker) createTaskFromScheduledTask(ctx context.Context, scheduledTask domain.ScheduledTask) {
-	device, err := w.deviceService.GetDevice(ctx, scheduledTask.Device.ID)
+	commandTemplates, err := w.commandTemplates(ctx, scheduledTask)
 	if err != nil {
-		slog.Error("getting device for scheduled task",
+		slog.Error("resolving command templates for scheduled task",
 			slog.String("scheduled_task_id", scheduledTask.ID.String()),
-			slog.String("device_id", scheduledTask.Device.ID.String()),
 			slog.Any("error", err))
+		w.recordRun(ctx, domain.NewScheduledTaskRunBuilder().
+			WithScheduledTask(scheduledTask).
+			WithOutcome(domain.ScheduledTaskRunOutcomeFailed).
+			WithError(err))
+		w.consumeFailedExecution(ctx, scheduledTask, time.Now())
 		return
 	}
 
-	commands := make([]domain.Command, len(scheduledTask.CommandTemplates))
 	now := time.Now()
+	fired := 0
+	for _, target := range scheduledTask.TargetDevices() {
+		if w.createTaskForDevice(ctx, scheduledTask, commandTemplates, target.ID, now) {
+			fired++
+		}
+	}
+
+	if fired == 0 {
+		w.consumeFailedExecution(ctx, scheduledTask, now)
+		return
+	}
+
+	currentTime := utils.Time{Time: time.Now()}
+	updatedScheduledTask := scheduledTask
+	updatedScheduledTask.LastExecutedAt = &currentTime
+	updatedScheduledTask.UpdatedAt = currentTime
+
+	// The executed event feeds notifications and metrics; it is stored with the update so it is
+	// relayed even if the process stops right after.
+	event, err := domain.NewOutboxEvent(_scheduledTasksTopic, events.ScheduledTaskExecuted.Name, executedScheduledTask(scheduledTask), currentTime.Time)
+	if err != nil {
+		slog.Error("creating scheduled task executed event",
+			slog.String("scheduled_task_id", scheduledTask.ID.String()),
+			slog.Any("error", err))
+		err = w.scheduledTaskRepository.Update(ctx, updatedScheduledTask)
+	} else {
+		event.TenantID = scheduledTask.Tenant.ID
+		err = w.scheduledTaskRepository.RecordExecution(ctx, updatedScheduledTask, event)
+	}
+	if err != nil {
+		slog.Error("updating scheduled task last executed time",
+			slog.String("scheduled_task_id", scheduledTask.ID.String()),
+			slog.Any("error", err))
+	}
+
+	// Metrics are now handled by MetricPublisherWorker
+}
+
+// executedScheduledTask is the scheduled task announced once executed, without the device keys
+// that would otherwise be stored in the outbox.
+func executedScheduledTask(scheduledTask domain.ScheduledTask) domain.ScheduledTask {
+	scheduledTask.Device.AppKey = ""
+	devices := make([]domain.Device, len(scheduledTask.Devices))
+	for i, device := range scheduledTask.Devices {
+		device.AppKey = ""
+		devices[i] = device
+	}
+	scheduledTask.Devices = devices
+	return scheduledTask
+}
+
+func (w *ScheduledTaskWorker) commandTemplates(ctx context.Context, scheduledTask domain.ScheduledTask) ([]domain.CommandTemplate, error) {
+	if scheduledTask.CommandTemplateSet == nil {
+		return scheduledTask.CommandTemplates, nil
+	}
+
+	set, err := w.commandTemplateSetRepository.GetByID(ctx, scheduledTask.CommandTemplateSet.ID)
+	if err != nil {
+		return nil, fmt.Errorf("getting command template set %s: %w", scheduledTask.CommandTemplateSet.ID, err)
+	}
+
+	return set.CommandTemplates, nil
+}
+
+func (w *ScheduledTaskWorker) createTaskForDevice(
+	ctx context.Context,
+	scheduledTask domain.ScheduledTask,
+	commandTemplates []domain.CommandTemplate,
+	deviceID domain.ID,
+	now time.Time,
+) bool {
+	run := domain.NewScheduledTaskRunBuilder().
+		WithScheduledTask(scheduledTask).
+		WithDevice(domain.Device{ID: deviceID})
+
+	device, err := w.deviceService.GetDevice(ctx, deviceID)
+	if err != nil {
+		slog.Error("getting device for scheduled task",
+			slog.String("scheduled_task_id", scheduledTask.ID.String()),
+			slog.String("device_id", deviceID.String()),
+			slog.Any("error", err))
+		w.recordRun(ctx, run.
+			WithOutcome(domain.ScheduledTaskRunOutcomeFailed).
+			WithError(err))
+		return false
+	}
+
+	if !device.BelongsToTenant(scheduledTask.Tenant.ID) {
+		slog.Warn("scheduled task target left the tenant",
+			slog.String("scheduled_task_id", scheduledTask.ID.String()),
+			slog.String("device_id", deviceID.String()))
+		w.recordRun(ctx, run.
+			WithOutcome(domain.ScheduledTaskRunOutcomeFailed).
+			WithError(ErrDeviceOutsideTenant))
+		return false
+	}
 
-	for i, template := range scheduledTask.CommandTemplates {
+	commands := make([]domain.Command, len(commandTemplates))
+	for i, template := range commandTemplates {
 		commandTemplate := domain.CommandTemplate{
 			Device:   device,
 			Port:     template.Port,
@@ -227,8 +345,12 @@ func (w *ScheduledTaskWorker) createTaskFromScheduledTask(ctx context.Context, s
 	if err != nil {
 		slog.Error("building task for scheduled task",
 			slog.String("scheduled_task_id", scheduledTask.ID.String()),
+			slog.String("device_id", deviceID.String()),
 			slog.Any("error", err))
-		return
+		w.recordRun(ctx, run.
+			WithOutcome(domain.ScheduledTaskRunOutcomeFailed).
+			WithError(err))
+		return false
 	}
 
 	for i := range task.Commands {
@@ -240,37 +362,82 @@ func (w *ScheduledTaskWorker) createTaskFromScheduledTask(ctx context.Context, s
 		slog.Error("creating task from scheduled task",
 			slog.String("scheduled_task_id", scheduledTask.ID.String()),
 			slog.String("task_id", task.ID.String()),
+			slog.String("device_id", deviceID.String()),
 			slog.Any("error", err))
-		return
+		outcome := domain.ScheduledTaskRunOutcomeFailed
+		if errors.Is(err, ErrCommandOverlap) {
+			outcome = domain.ScheduledTaskRunOutcomeOverlapRejected
+		}
+		w.recordRun(ctx, run.
+			WithOutcome(outcome).
+			WithError(err))
+		return false
 	}
 
-	// Publish scheduled task executed event for metrics
-	brokerMsg := async.BrokerMessage{
-		Event: "scheduled_task_executed",
-		Value: scheduledTask,
-	}
-	if err := w.broker.Publish(ctx, async.BrokerTopicName(_scheduledTasksTopic), brokerMsg); err != nil {
-		slog.Error("failed to publish scheduled task executed event", slog.Any("error", err))
-	}
+	w.recordRun(ctx, run.
+		WithOutcome(domain.ScheduledTaskRunOutcomeFired).
+		WithTaskID(task.ID))
 
-	currentTime := utils.Time{Time: time.Now()}
-	updatedScheduledTask := scheduledTask
-	updatedScheduledTask.LastExecutedAt = &currentTime
-	updatedScheduledTask.UpdatedAt = currentTime
+	slog.Info("created task from scheduled task",
+		slog.String("scheduled_task_id", scheduledTask.ID.String()),
+		slog.String("task_id", task.ID.String()),
+		slog.String("device_name", device.Name))
+
+	return true
+}
+
+func (w *ScheduledTaskWorker) skipScheduledTask(ctx context.Context, scheduledTask domain.ScheduledTask, now time.Time) {
+	w.recordRun(ctx, domain.NewScheduledTaskRunBuilder().
+		WithScheduledTask(scheduledTask).
+		WithOutcome(domain.ScheduledTaskRunOutcomeSkipped).
+		WithEvaluatedAt(now))
+
+	scheduledTask.SkipExecution(now)
 
-	err = w.scheduledTaskRepository.Update(ctx, updatedScheduledTask)
+	err := w.scheduledTaskRepository.Update(ctx, scheduledTask)
 	if err != nil {
-		slog.Error("updating scheduled task last executed time",
+		slog.Error("updating skipped scheduled task",
 			slog.String("scheduled_task_id", scheduledTask.ID.String()),
 			slog.Any("error", err))
+		return
 	}
 
-	slog.Info("created task from scheduled task",
+	slog.Info("skipped scheduled task execution while paused or held",
 		slog.String("scheduled_task_id", scheduledTask.ID.String()),
-		slog.String("task_id", task.ID.String()),
-		slog.String("device_name", device.Name))
+		slog.String("tenant_id", scheduledTask.Tenant.ID.String()))
+}
 
-	// Metrics are now handled by MetricPublisherWorker
+// consumeFailedExecution advances the schedule past a due execution that fired on no device.
+// The failure is already recorded as a run, so retrying it on every tick would only repeat it.
+func (w *ScheduledTaskWorker) consumeFailedExecution(ctx context.Context, scheduledTask domain.ScheduledTask, now time.Time) {
+	scheduledTask.SkipExecution(now)
+
+	err := w.scheduledTaskRepository.Update(ctx, scheduledTask)
+	if err != nil {
+		slog.Error("updating failed scheduled task",
+			slog.String("scheduled_task_id", scheduledTask.ID.String()),
+			slog.Any("error", err))
+	}
+}
+
+type scheduledTaskRunBuilder interface {
+	Build() (domain.ScheduledTaskRun, error)
+}
+
+func (w *ScheduledTaskWorker) recordRun(ctx context.Context, builder scheduledTaskRunBuilder) {
+	run, err := builder.Build()
+	if err != nil {
+		slog.Error("building scheduled task run", slog.Any("error", err))
+		return
+	}
+
+	err = w.scheduledTaskRunRepository.Create(ctx, run)
+	if err != nil {
+		slog.Error("recording scheduled task run",
+			slog.String("scheduled_task_id", run.ScheduledTaskID.String()),
+			slog.String("outcome", string(run.Outcome)),
+			slog.Any("error", err))
+	}
 }
 
 func (w *ScheduledTaskWorker) Shutdown() {
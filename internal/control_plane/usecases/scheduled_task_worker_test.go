@@ -2,13 +2,14 @@ package usecases_test
 
 import (
 	"context"
+	"errors"
 	"time"
 	"zensor-server/internal/control_plane/usecases"
 	"zensor-server/internal/infra/utils"
 	"zensor-server/internal/shared_kernel/domain"
 
 	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
-	mockasync "zensor-server/test/unit/doubles/infra/async"
+	mocksharedusecases "zensor-server/test/unit/doubles/shared_kernel/usecases"
 
 	"github.com/onsi/ginkgo/v2"
 	"github.com/onsi/gomega"
@@ -20,18 +21,20 @@ var _ = ginkgo.Describe("ScheduledTaskWorker", func() {
 		var (
 			ctrl                  *gomock.Controller
 			mockScheduledTaskRepo *mockusecases.MockScheduledTaskRepository
+			mockRunRepo           *mockusecases.MockScheduledTaskRunRepository
+			mockTemplateSetRepo   *mockusecases.MockCommandTemplateSetRepository
 			mockTaskService       *mockusecases.MockTaskService
 			mockDeviceService     *mockusecases.MockDeviceService
-			mockBroker            *mockasync.MockInternalBroker
 			ticker                *time.Ticker
 		)
 
 		ginkgo.BeforeEach(func() {
 			ctrl = gomock.NewController(ginkgo.GinkgoT())
 			mockScheduledTaskRepo = mockusecases.NewMockScheduledTaskRepository(ctrl)
+			mockRunRepo = mockusecases.NewMockScheduledTaskRunRepository(ctrl)
+			mockTemplateSetRepo = mockusecases.NewMockCommandTemplateSetRepository(ctrl)
 			mockTaskService = mockusecases.NewMockTaskService(ctrl)
 			mockDeviceService = mockusecases.NewMockDeviceService(ctrl)
-			mockBroker = mockasync.NewMockInternalBroker(ctrl)
 			ticker = time.NewTicker(100 * time.Millisecond)
 		})
 
@@ -45,10 +48,11 @@ var _ = ginkgo.Describe("ScheduledTaskWorker", func() {
 			worker := usecases.NewScheduledTaskWorker(
 				ticker,
 				mockScheduledTaskRepo,
+				mockRunRepo,
+				mockTemplateSetRepo,
 				mockTaskService,
 				mockDeviceService,
 				nil, // TenantConfigurationService not available in mocks yet
-				mockBroker,
 			)
 
 			// Verify the worker was created
@@ -85,10 +89,11 @@ var _ = ginkgo.Describe("ScheduledTaskWorker", func() {
 			worker := usecases.NewScheduledTaskWorker(
 				ticker,
 				mockScheduledTaskRepo,
+				mockRunRepo,
+				mockTemplateSetRepo,
 				mockTaskService,
 				mockDeviceService,
 				nil, // TenantConfigurationService not available in mocks yet
-				mockBroker,
 			)
 			gomega.Expect(worker).NotTo(gomega.BeNil())
 
@@ -102,4 +107,247 @@ var _ = ginkgo.Describe("ScheduledTaskWorker", func() {
 			gomega.Expect(err).NotTo(gomega.HaveOccurred())
 		})
 	})
+
+	ginkgo.Context("Run", func() {
+		var (
+			ctrl                  *gomock.Controller
+			mockScheduledTaskRepo *mockusecases.MockScheduledTaskRepository
+			mockRunRepo           *mockusecases.MockScheduledTaskRunRepository
+			mockTemplateSetRepo   *mockusecases.MockCommandTemplateSetRepository
+			mockTaskService       *mockusecases.MockTaskService
+			mockDeviceService     *mockusecases.MockDeviceService
+			mockTenantConfig      *mocksharedusecases.MockTenantConfigurationService
+			tenantID              domain.ID
+			ticker                *time.Ticker
+			scheduledTask         domain.ScheduledTask
+			tenantConfig          domain.TenantConfiguration
+			updated               chan domain.ScheduledTask
+			recorded              chan domain.ScheduledTaskRun
+		)
+
+		ginkgo.BeforeEach(func() {
+			ctrl = gomock.NewController(ginkgo.GinkgoT())
+			mockScheduledTaskRepo = mockusecases.NewMockScheduledTaskRepository(ctrl)
+			mockRunRepo = mockusecases.NewMockScheduledTaskRunRepository(ctrl)
+			mockTemplateSetRepo = mockusecases.NewMockCommandTemplateSetRepository(ctrl)
+			mockTaskService = mockusecases.NewMockTaskService(ctrl)
+			mockDeviceService = mockusecases.NewMockDeviceService(ctrl)
+			mockTenantConfig = mocksharedusecases.NewMockTenantConfigurationService(ctrl)
+			ticker = time.NewTicker(10 * time.Millisecond)
+			updated = make(chan domain.ScheduledTask, 1)
+			recorded = make(chan domain.ScheduledTaskRun, 2)
+			tenantID = domain.ID("tenant-1")
+
+			scheduledTask = domain.ScheduledTask{
+				ID:         domain.ID("scheduled-task-1"),
+				Tenant:     domain.Tenant{ID: tenantID},
+				Device:     domain.Device{ID: domain.ID("device-1"), TenantID: &tenantID},
+				Schedule:   "* * * * *",
+				Scheduling: domain.SchedulingConfiguration{Type: domain.SchedulingTypeCron},
+				IsActive:   true,
+				CreatedAt:  utils.Time{Time: time.Now().Add(-time.Hour)},
+			}
+			tenantConfig = domain.TenantConfiguration{TenantID: domain.ID("tenant-1"), Timezone: "UTC"}
+		})
+
+		ginkgo.AfterEach(func() {
+			ticker.Stop()
+		})
+
+		expectEvaluation := func() {
+			mockScheduledTaskRepo.EXPECT().FindAllActive(gomock.Any()).Return([]domain.ScheduledTask{scheduledTask}, nil).MinTimes(1)
+			mockTenantConfig.EXPECT().GetOrCreateTenantConfiguration(gomock.Any(), gomock.Any(), gomock.Any()).Return(tenantConfig, nil).MinTimes(1)
+			mockRunRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, value domain.ScheduledTaskRun) error {
+				select {
+				case recorded <- value:
+				default:
+				}
+				return nil
+			}).MinTimes(1)
+		}
+
+		runUntil := func(received any) {
+			worker := usecases.NewScheduledTaskWorker(ticker, mockScheduledTaskRepo, mockRunRepo, mockTemplateSetRepo, mockTaskService, mockDeviceService, mockTenantConfig)
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go worker.Run(ctx, func() { close(done) })
+
+			gomega.Eventually(recorded).Should(gomega.Receive(received))
+			cancel()
+			gomega.Eventually(done).Should(gomega.BeClosed())
+		}
+
+		expectUpdate := func() {
+			mockScheduledTaskRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, value domain.ScheduledTask) error {
+				select {
+				case updated <- value:
+				default:
+				}
+				return nil
+			}).MinTimes(1)
+		}
+
+		receiveUpdate := func() domain.ScheduledTask {
+			var result domain.ScheduledTask
+			gomega.Eventually(updated).Should(gomega.Receive(&result))
+			return result
+		}
+
+		runUntilUpdated := func() domain.ScheduledTask {
+			expectEvaluation()
+			expectUpdate()
+
+			var run domain.ScheduledTaskRun
+			runUntil(&run)
+			gomega.Expect(run.Outcome).To(gomega.Equal(domain.ScheduledTaskRunOutcomeSkipped))
+
+			return receiveUpdate()
+		}
+
+		ginkgo.When("the scheduled task is paused", func() {
+			ginkgo.BeforeEach(func() {
+				gomega.Expect(scheduledTask.Pause(nil)).To(gomega.Succeed())
+			})
+
+			ginkgo.It("should skip the due execution without creating a task", func() {
+				result := runUntilUpdated()
+				gomega.Expect(result.LastExecutedAt).NotTo(gomega.BeNil())
+			})
+		})
+
+		ginkgo.When("the tenant holds its schedules", func() {
+			ginkgo.BeforeEach(func() {
+				gomega.Expect(tenantConfig.HoldSchedules(time.Now().Add(time.Hour), "maintenance")).To(gomega.Succeed())
+			})
+
+			ginkgo.It("should skip the due execution without creating a task", func() {
+				result := runUntilUpdated()
+				gomega.Expect(result.LastExecutedAt).NotTo(gomega.BeNil())
+			})
+		})
+
+		ginkgo.When("pending commands overlap the new ones", func() {
+			ginkgo.BeforeEach(func() {
+				scheduledTask.CommandTemplates = []domain.CommandTemplate{
+					{Port: domain.Port(15), Priority: domain.CommandPriority("NORMAL"), Payload: domain.CommandPayload{Index: 1, Value: 1}},
+				}
+			})
+
+			ginkgo.It("should record an overlap rejected run without a task", func() {
+				expectEvaluation()
+				expectUpdate()
+				mockDeviceService.EXPECT().GetDevice(gomock.Any(), scheduledTask.Device.ID).Return(scheduledTask.Device, nil).MinTimes(1)
+				mockTaskService.EXPECT().Create(gomock.Any(), gomock.Any()).Return(usecases.ErrCommandOverlap).MinTimes(1)
+
+				var run domain.ScheduledTaskRun
+				runUntil(&run)
+				gomega.Expect(run.Outcome).To(gomega.Equal(domain.ScheduledTaskRunOutcomeOverlapRejected))
+				gomega.Expect(run.ScheduledTaskID).To(gomega.Equal(scheduledTask.ID))
+				gomega.Expect(run.TaskID).To(gomega.BeNil())
+				gomega.Expect(run.Error).To(gomega.Equal(usecases.ErrCommandOverlap.Error()))
+			})
+
+			ginkgo.It("should consume the due execution so it is not retried on every tick", func() {
+				expectEvaluation()
+				expectUpdate()
+				mockDeviceService.EXPECT().GetDevice(gomock.Any(), scheduledTask.Device.ID).Return(scheduledTask.Device, nil).MinTimes(1)
+				mockTaskService.EXPECT().Create(gomock.Any(), gomock.Any()).Return(usecases.ErrCommandOverlap).MinTimes(1)
+				mockScheduledTaskRepo.EXPECT().RecordExecution(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				var run domain.ScheduledTaskRun
+				runUntil(&run)
+				result := receiveUpdate()
+				gomega.Expect(result.LastExecutedAt).NotTo(gomega.BeNil())
+				gomega.Expect(result.LastExecutedAt.Time).To(gomega.BeTemporally(">", scheduledTask.CreatedAt.Time))
+			})
+		})
+
+		ginkgo.When("the device was transferred to another tenant", func() {
+			ginkgo.BeforeEach(func() {
+				scheduledTask.CommandTemplates = []domain.CommandTemplate{
+					{Port: domain.Port(15), Priority: domain.CommandPriority("NORMAL"), Payload: domain.CommandPayload{Index: 1, Value: 1}},
+				}
+			})
+
+			ginkgo.It("should record a failed run without creating a task", func() {
+				otherTenantID := domain.ID("tenant-2")
+				expectEvaluation()
+				expectUpdate()
+				mockDeviceService.EXPECT().GetDevice(gomock.Any(), scheduledTask.Device.ID).
+					Return(domain.Device{ID: scheduledTask.Device.ID, TenantID: &otherTenantID}, nil).MinTimes(1)
+
+				var run domain.ScheduledTaskRun
+				runUntil(&run)
+				gomega.Expect(run.Outcome).To(gomega.Equal(domain.ScheduledTaskRunOutcomeFailed))
+				gomega.Expect(run.Error).To(gomega.Equal(usecases.ErrDeviceOutsideTenant.Error()))
+			})
+		})
+
+		ginkgo.When("the scheduled task targets several devices with a command template set", func() {
+			var set domain.CommandTemplateSet
+
+			ginkgo.BeforeEach(func() {
+				set = domain.CommandTemplateSet{
+					ID:     domain.ID("set-1"),
+					Tenant: scheduledTask.Tenant,
+					Name:   "Zone A morning cycle",
+					CommandTemplates: []domain.CommandTemplate{
+						{Port: domain.Port(15), Priority: domain.CommandPriority("NORMAL"), Payload: domain.CommandPayload{Index: 1, Value: 1}},
+					},
+				}
+				scheduledTask.Devices = []domain.Device{{ID: domain.ID("device-2")}}
+				scheduledTask.CommandTemplateSet = &domain.CommandTemplateSet{ID: set.ID}
+			})
+
+			ginkgo.It("should record a failed run and consume the due execution when the set cannot be resolved", func() {
+				expectEvaluation()
+				expectUpdate()
+				mockTemplateSetRepo.EXPECT().GetByID(gomock.Any(), set.ID).Return(domain.CommandTemplateSet{}, errors.New("database unavailable")).MinTimes(1)
+
+				var run domain.ScheduledTaskRun
+				runUntil(&run)
+				gomega.Expect(run.Outcome).To(gomega.Equal(domain.ScheduledTaskRunOutcomeFailed))
+				gomega.Expect(receiveUpdate().LastExecutedAt).NotTo(gomega.BeNil())
+			})
+
+			ginkgo.It("should create one task per device from the set templates", func() {
+				created := make(chan domain.Task, 2)
+				expectEvaluation()
+				mockTemplateSetRepo.EXPECT().GetByID(gomock.Any(), set.ID).Return(set, nil).MinTimes(1)
+				mockDeviceService.EXPECT().GetDevice(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, id domain.ID) (domain.Device, error) {
+					return domain.Device{ID: id, TenantID: &tenantID}, nil
+				}).MinTimes(2)
+				mockTaskService.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, task domain.Task) error {
+					select {
+					case created <- task:
+					default:
+					}
+					return nil
+				}).MinTimes(2)
+				mockScheduledTaskRepo.EXPECT().RecordExecution(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, value domain.ScheduledTask, event domain.OutboxEvent) error {
+						gomega.Expect(value.LastExecutedAt).NotTo(gomega.BeNil())
+						gomega.Expect(event.Topic).To(gomega.Equal("scheduled_tasks"))
+						gomega.Expect(event.Event).To(gomega.Equal("scheduled_task_executed"))
+						return nil
+					}).MinTimes(1)
+
+				var first, second domain.ScheduledTaskRun
+				runUntil(&first)
+				gomega.Eventually(recorded).Should(gomega.Receive(&second))
+				gomega.Expect(first.Outcome).To(gomega.Equal(domain.ScheduledTaskRunOutcomeFired))
+				gomega.Expect(first.DeviceID).To(gomega.Equal(domain.ID("device-1")))
+				gomega.Expect(second.Outcome).To(gomega.Equal(domain.ScheduledTaskRunOutcomeFired))
+				gomega.Expect(second.DeviceID).To(gomega.Equal(domain.ID("device-2")))
+
+				var firstTask, secondTask domain.Task
+				gomega.Eventually(created).Should(gomega.Receive(&firstTask))
+				gomega.Eventually(created).Should(gomega.Receive(&secondTask))
+				gomega.Expect(firstTask.Device.ID).To(gomega.Equal(domain.ID("device-1")))
+				gomega.Expect(secondTask.Device.ID).To(gomega.Equal(domain.ID("device-2")))
+				gomega.Expect(secondTask.Commands).To(gomega.HaveLen(1))
+				gomega.Expect(secondTask.Commands[0].Device.ID).To(gomega.Equal(domain.ID("device-2")))
+			})
+		})
+	})
 })
@@ -0,0 +1,96 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+func NewWebhookService(
+	repository WebhookSubscriptionRepository,
+	deliveryRepository WebhookDeliveryRepository,
+) *SimpleWebhookService {
+	return &SimpleWebhookService{
+		repository:         repository,
+		deliveryRepository: deliveryRepository,
+	}
+}
+
+var _ WebhookService = (*SimpleWebhookService)(nil)
+
+type SimpleWebhookService struct {
+	repository         WebhookSubscriptionRepository
+	deliveryRepository WebhookDeliveryRepository
+}
+
+func (s *SimpleWebhookService) Create(ctx context.Context, subscription domain.WebhookSubscription) error {
+	err := s.repository.Create(ctx, subscription)
+	if err != nil {
+		return fmt.Errorf("creating webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SimpleWebhookService) GetByID(ctx context.Context, id domain.ID) (domain.WebhookSubscription, error) {
+	return s.repository.GetByID(ctx, id)
+}
+
+func (s *SimpleWebhookService) FindAllByTenant(ctx context.Context, tenantID domain.ID, pagination Pagination) ([]domain.WebhookSubscription, int, error) {
+	subscriptions, total, err := s.repository.FindAllByTenant(ctx, tenantID, pagination)
+	if err != nil {
+		return nil, 0, fmt.Errorf("finding webhook subscriptions by tenant: %w", err)
+	}
+
+	return subscriptions, total, nil
+}
+
+func (s *SimpleWebhookService) Update(ctx context.Context, subscription domain.WebhookSubscription) error {
+	err := s.repository.Update(ctx, subscription)
+	if err != nil {
+		return fmt.Errorf("updating webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the subscription; its pending deliveries are dropped by the webhook worker.
+func (s *SimpleWebhookService) Delete(ctx context.Context, id domain.ID) error {
+	err := s.repository.Delete(ctx, id)
+	if err != nil {
+		return fmt.Errorf("deleting webhook subscription: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SimpleWebhookService) Deliveries(ctx context.Context, subscriptionID domain.ID, filter WebhookDeliveryFilter, pagination Pagination) ([]domain.WebhookDelivery, int, error) {
+	deliveries, total, err := s.deliveryRepository.FindAllBySubscription(ctx, subscriptionID, filter, pagination)
+	if err != nil {
+		return nil, 0, fmt.Errorf("finding webhook deliveries: %w", err)
+	}
+
+	return deliveries, total, nil
+}
+
+func (s *SimpleWebhookService) Redeliver(ctx context.Context, subscriptionID, deliveryID domain.ID) (domain.WebhookDelivery, error) {
+	delivery, err := s.deliveryRepository.GetByID(ctx, deliveryID)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	if delivery.SubscriptionID != subscriptionID {
+		return domain.WebhookDelivery{}, ErrWebhookDeliveryNotFound
+	}
+
+	if err := delivery.Redeliver(time.Now()); err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	err = s.deliveryRepository.Update(ctx, delivery)
+	if err != nil {
+		return domain.WebhookDelivery{}, fmt.Errorf("updating webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
@@ -0,0 +1,354 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+const (
+	// _webhookEventsTopic matches every topic; the worker narrows it to the webhook event types
+	// when subscribing.
+	_webhookEventsTopic       async.BrokerTopicName = "#"
+	_webhookDeliveryBatchSize                       = 100
+	_webhookDrainBodySize                           = 4 << 10
+)
+
+var ErrWebhookAddressNotAllowed = errors.New("webhooks may not reach loopback, private or link-local addresses")
+
+// _webhookDeviceNamePaths locate the device of events published without a tenant, such as
+// uplinks and command status updates, which belong to the tenant owning the device.
+var _webhookDeviceNamePaths = []string{"device_name", "DeviceName", "end_device_ids.device_id"}
+
+// WebhookWorkerConfig controls how webhooks are delivered. Requests give up after Timeout and
+// failed deliveries are retried following RetryPolicy. Unless AllowPrivateNetworks is set,
+// webhooks cannot reach loopback, private or link-local addresses, so tenants cannot use them to
+// reach the services next to the server.
+type WebhookWorkerConfig struct {
+	Timeout              time.Duration
+	RetryPolicy          domain.WebhookRetryPolicy
+	AllowPrivateNetworks bool
+}
+
+func NewWebhookWorker(
+	ticker *time.Ticker,
+	subscriptionRepository WebhookSubscriptionRepository,
+	deliveryRepository WebhookDeliveryRepository,
+	deviceRepository DeviceRepository,
+	broker async.InternalBroker,
+	config WebhookWorkerConfig,
+) *WebhookWorker {
+	return &WebhookWorker{
+		ticker:                 ticker,
+		subscriptionRepository: subscriptionRepository,
+		deliveryRepository:     deliveryRepository,
+		deviceRepository:       deviceRepository,
+		broker:                 broker,
+		config:                 config,
+		httpClient:             newWebhookHTTPClient(config),
+	}
+}
+
+// newWebhookHTTPClient returns a client that does not follow redirects, as the URL of a redirect
+// is not validated, and that checks the addresses it dials once resolved, unless private networks
+// are allowed. Proxies are not used since they would dial on behalf of the client.
+func newWebhookHTTPClient(config WebhookWorkerConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivateNetworks {
+		dialer.Control = refusePrivateAddress
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   config.Timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// refusePrivateAddress runs before every connection, with the address resolved, so host names
+// resolving to internal addresses are refused as well as literal ones.
+func refusePrivateAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, ip)
+	}
+	return nil
+}
+
+var _ async.Worker = &WebhookWorker{}
+
+// WebhookWorker delivers platform events to the webhook subscriptions of their tenant. Each event
+// received from the broker is recorded as a delivery per matching subscription, then deliveries
+// are POSTed on every tick, apart from the broker consumer, until the receiver answers with a 2xx
+// status or they run out of attempts. Deliveries live in the database, so they survive restarts,
+// and an event redelivered by the outbox is delivered once per subscription.
+type WebhookWorker struct {
+	ticker                 *time.Ticker
+	subscriptionRepository WebhookSubscriptionRepository
+	deliveryRepository     WebhookDeliveryRepository
+	deviceRepository       DeviceRepository
+	broker                 async.InternalBroker
+	config                 WebhookWorkerConfig
+	httpClient             *http.Client
+}
+
+func (w *WebhookWorker) Run(ctx context.Context, done func()) {
+	slog.Info("webhook worker started")
+	defer done()
+
+	subscription, err := w.broker.Subscribe(_webhookEventsTopic, domain.WebhookEventTypes...)
+	if err != nil {
+		slog.Error("subscribing to webhook events", slog.Any("error", err))
+		return
+	}
+	defer func() {
+		if err := w.broker.Unsubscribe(_webhookEventsTopic, subscription); err != nil {
+			slog.Error("unsubscribing from webhook events", slog.Any("error", err))
+		}
+	}()
+
+	// Deliveries wait on the receivers of the webhooks, so they run apart from the broker
+	// consumer, which keeps recording events while slow receivers are posted to.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go w.deliverOnTick(ctx, wg.Done)
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("webhook worker cancelled")
+			return
+		case msg := <-subscription.Receiver:
+			w.record(context.Background(), msg, time.Now())
+		}
+	}
+}
+
+func (w *WebhookWorker) deliverOnTick(ctx context.Context, done func()) {
+	defer done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.ticker.C:
+			w.deliverDue(context.Background(), time.Now())
+		}
+	}
+}
+
+// record stores a delivery of the event for every subscription of its tenant to its type. The
+// body sent is the event envelope, with the tenant set when it was resolved from the device.
+func (w *WebhookWorker) record(ctx context.Context, msg async.BrokerMessage, now time.Time) {
+	fields, err := async.NewEventFields(msg)
+	if err != nil {
+		slog.Warn("ignoring webhook event", slog.String("event", msg.Event), slog.Any("error", err))
+		return
+	}
+
+	eventType := fields.String("type")
+	if eventType == "" {
+		eventType = msg.Event
+	}
+	eventID := fields.String("id")
+	if eventID == "" {
+		eventID = msg.ID
+	}
+	if eventID == "" {
+		eventID = utils.GenerateUUID()
+	}
+
+	tenantID := w.tenantOf(ctx, fields)
+	if tenantID == "" {
+		slog.Debug("ignoring webhook event without tenant", slog.String("event", eventType), slog.String("event_id", eventID))
+		return
+	}
+	fields["tenant_id"] = tenantID.String()
+
+	subscriptions, err := w.subscriptionRepository.FindActiveByTenant(ctx, tenantID)
+	if err != nil {
+		slog.Error("finding webhook subscriptions",
+			slog.String("tenant_id", tenantID.String()),
+			slog.Any("error", err))
+		return
+	}
+
+	var body []byte
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(eventType) {
+			continue
+		}
+
+		if body == nil {
+			body, err = json.Marshal(fields)
+			if err != nil {
+				slog.Error("encoding webhook payload", slog.String("event_id", eventID), slog.Any("error", err))
+				return
+			}
+		}
+
+		delivery := domain.NewWebhookDelivery(subscription, eventID, eventType, body, now)
+		err := w.deliveryRepository.Create(ctx, delivery)
+		if errors.Is(err, ErrWebhookDeliveryDuplicated) {
+			slog.Debug("discarding redelivered webhook event",
+				slog.String("subscription_id", subscription.ID.String()),
+				slog.String("event_id", eventID))
+			continue
+		}
+		if err != nil {
+			slog.Error("creating webhook delivery",
+				slog.String("subscription_id", subscription.ID.String()),
+				slog.String("event_id", eventID),
+				slog.Any("error", err))
+		}
+	}
+}
+
+// tenantOf returns the tenant of the event, falling back to the tenant owning its device.
+func (w *WebhookWorker) tenantOf(ctx context.Context, fields async.EventFields) domain.ID {
+	if tenantID := fields.String("tenant_id"); tenantID != "" {
+		return domain.ID(tenantID)
+	}
+
+	for _, path := range _webhookDeviceNamePaths {
+		deviceName := fields.String(path)
+		if deviceName == "" {
+			continue
+		}
+
+		device, err := w.deviceRepository.FindByName(ctx, deviceName)
+		if err != nil {
+			if !errors.Is(err, ErrDeviceNotFound) {
+				slog.Error("finding device of webhook event", slog.String("device_name", deviceName), slog.Any("error", err))
+			}
+			return ""
+		}
+		if device.TenantID == nil {
+			return ""
+		}
+		return *device.TenantID
+	}
+
+	return ""
+}
+
+func (w *WebhookWorker) deliverDue(ctx context.Context, now time.Time) {
+	deliveries, err := w.deliveryRepository.FindDue(ctx, now, _webhookDeliveryBatchSize)
+	if err != nil {
+		slog.Error("finding due webhook deliveries", slog.Any("error", err))
+		return
+	}
+
+	subscriptions := make(map[domain.ID]domain.WebhookSubscription)
+	for _, delivery := range deliveries {
+		subscription, found := subscriptions[delivery.SubscriptionID]
+		if !found {
+			subscription, err = w.subscriptionRepository.GetByID(ctx, delivery.SubscriptionID)
+			if errors.Is(err, ErrWebhookSubscriptionNotFound) {
+				delivery.Abandon("the webhook subscription was deleted", now)
+				w.update(ctx, delivery)
+				continue
+			}
+			if err != nil {
+				slog.Error("getting webhook subscription",
+					slog.String("subscription_id", delivery.SubscriptionID.String()),
+					slog.Any("error", err))
+				continue
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+
+		w.deliver(ctx, subscription, delivery, now)
+	}
+}
+
+func (w *WebhookWorker) deliver(ctx context.Context, subscription domain.WebhookSubscription, delivery domain.WebhookDelivery, now time.Time) {
+	statusCode, err := w.post(ctx, subscription, delivery, now)
+	if err != nil {
+		delivery.RecordFailure(statusCode, err.Error(), w.config.RetryPolicy, time.Now())
+		if delivery.Status == domain.WebhookDeliveryStatusDead {
+			slog.Warn("webhook delivery ran out of attempts",
+				slog.String("delivery_id", delivery.ID.String()),
+				slog.String("subscription_id", subscription.ID.String()),
+				slog.Int("attempts", delivery.Attempts),
+				slog.Any("error", err))
+		}
+	} else {
+		delivery.RecordSuccess(statusCode, time.Now())
+	}
+
+	w.update(ctx, delivery)
+}
+
+// post sends the delivery to the subscription URL. The signature covers the timestamp and the
+// body, see domain.WebhookSubscription.Sign. Only the status of failed requests is kept, as
+// tenants read the errors of their deliveries back.
+func (w *WebhookWorker) post(ctx context.Context, subscription domain.WebhookSubscription, delivery domain.WebhookDelivery, now time.Time) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("creating request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "zensor-webhooks/1.0")
+	request.Header.Set("X-Zensor-Event", delivery.EventType)
+	request.Header.Set("X-Zensor-Event-ID", delivery.EventID)
+	request.Header.Set("X-Zensor-Delivery", delivery.ID.String())
+	request.Header.Set("X-Zensor-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	request.Header.Set("X-Zensor-Signature", "sha256="+subscription.Sign(now, delivery.Payload))
+
+	response, err := w.httpClient.Do(request)
+	if err != nil {
+		return 0, fmt.Errorf("sending request: %w", err)
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, _webhookDrainBodySize))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+
+	return response.StatusCode, nil
+}
+
+func (w *WebhookWorker) update(ctx context.Context, delivery domain.WebhookDelivery) {
+	if err := w.deliveryRepository.Update(ctx, delivery); err != nil {
+		slog.Error("updating webhook delivery",
+			slog.String("delivery_id", delivery.ID.String()),
+			slog.Any("error", err))
+	}
+}
+
+func (w *WebhookWorker) Shutdown() {
+	slog.Debug("webhook worker shutdown")
+}
@@ -0,0 +1,285 @@
+package usecases_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+	"zensor-server/internal/control_plane/usecases"
+	"zensor-server/internal/data_plane/dto"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
+
+	mockusecases "zensor-server/test/unit/doubles/control_plane/usecases"
+	mockasync "zensor-server/test/unit/doubles/infra/async"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = ginkgo.Describe("WebhookWorker", func() {
+	var (
+		ctrl             *gomock.Controller
+		mockSubscription *mockusecases.MockWebhookSubscriptionRepository
+		mockDelivery     *mockusecases.MockWebhookDeliveryRepository
+		mockDeviceRepo   *mockusecases.MockDeviceRepository
+		mockBroker       *mockasync.MockInternalBroker
+		ticker           *time.Ticker
+		config           usecases.WebhookWorkerConfig
+		receiver         chan async.BrokerMessage
+		tenantID         domain.ID
+		subscription     domain.WebhookSubscription
+		created          chan domain.WebhookDelivery
+		updated          chan domain.WebhookDelivery
+	)
+
+	ginkgo.BeforeEach(func() {
+		ctrl = gomock.NewController(ginkgo.GinkgoT())
+		mockSubscription = mockusecases.NewMockWebhookSubscriptionRepository(ctrl)
+		mockDelivery = mockusecases.NewMockWebhookDeliveryRepository(ctrl)
+		mockDeviceRepo = mockusecases.NewMockDeviceRepository(ctrl)
+		mockBroker = mockasync.NewMockInternalBroker(ctrl)
+		ticker = time.NewTicker(10 * time.Millisecond)
+		config = usecases.WebhookWorkerConfig{
+			Timeout:     time.Second,
+			RetryPolicy: domain.WebhookRetryPolicy{MaxAttempts: 3, InitialBackoff: time.Minute, MaxBackoff: time.Hour},
+		}
+		receiver = make(chan async.BrokerMessage, 1)
+		tenantID = domain.ID("tenant-1")
+		subscription = domain.WebhookSubscription{
+			ID:         domain.ID("subscription-1"),
+			Tenant:     domain.Tenant{ID: tenantID},
+			URL:        "http://127.0.0.1:1/hooks",
+			Secret:     "secret",
+			EventTypes: []string{"uplink", "device_offline"},
+		}
+		created = make(chan domain.WebhookDelivery, 1)
+		updated = make(chan domain.WebhookDelivery, 1)
+
+		mockBroker.EXPECT().Subscribe(async.BrokerTopicName("#"), domain.WebhookEventTypes).
+			Return(async.Subscription{ID: "subscription", Receiver: receiver}, nil)
+		mockBroker.EXPECT().Unsubscribe(async.BrokerTopicName("#"), gomock.Any()).Return(nil)
+	})
+
+	ginkgo.AfterEach(func() {
+		ticker.Stop()
+	})
+
+	run := func(wait func()) {
+		worker := usecases.NewWebhookWorker(ticker, mockSubscription, mockDelivery, mockDeviceRepo, mockBroker, config)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go worker.Run(ctx, func() { close(done) })
+
+		wait()
+		cancel()
+		gomega.Eventually(done).Should(gomega.BeClosed())
+	}
+
+	captureCreated := func() {
+		mockDelivery.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, delivery domain.WebhookDelivery) error {
+			created <- delivery
+			return nil
+		})
+	}
+
+	captureUpdated := func() {
+		mockDelivery.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, delivery domain.WebhookDelivery) error {
+			select {
+			case updated <- delivery:
+			default:
+			}
+			return nil
+		}).MinTimes(1)
+	}
+
+	ginkgo.Context("recording events", func() {
+		ginkgo.BeforeEach(func() {
+			mockDelivery.EXPECT().FindDue(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+		})
+
+		ginkgo.It("should record a delivery of the events the tenant subscribed to", func() {
+			other := subscription
+			other.ID = "subscription-2"
+			other.EventTypes = []string{"device_online"}
+			mockSubscription.EXPECT().FindActiveByTenant(gomock.Any(), tenantID).Return([]domain.WebhookSubscription{subscription, other}, nil)
+			captureCreated()
+
+			event := events.DeviceOffline.New(tenantID.String(), events.DeviceConnectivity{DeviceName: "device-1", Status: "offline"})
+			receiver <- event.Message()
+
+			var delivery domain.WebhookDelivery
+			run(func() { gomega.Eventually(created).Should(gomega.Receive(&delivery)) })
+
+			gomega.Expect(delivery.SubscriptionID).To(gomega.Equal(subscription.ID))
+			gomega.Expect(delivery.EventID).To(gomega.Equal(event.ID))
+			gomega.Expect(delivery.EventType).To(gomega.Equal("device_offline"))
+
+			var body map[string]any
+			gomega.Expect(json.Unmarshal(delivery.Payload, &body)).To(gomega.Succeed())
+			gomega.Expect(body).To(gomega.HaveKeyWithValue("tenant_id", "tenant-1"))
+			gomega.Expect(body).To(gomega.HaveKeyWithValue("payload", gomega.HaveKeyWithValue("status", "offline")))
+		})
+
+		ginkgo.It("should resolve the tenant of events published without one from their device", func() {
+			mockDeviceRepo.EXPECT().FindByName(gomock.Any(), "device-1").Return(domain.Device{Name: "device-1", TenantID: &tenantID}, nil)
+			mockSubscription.EXPECT().FindActiveByTenant(gomock.Any(), tenantID).Return([]domain.WebhookSubscription{subscription}, nil)
+			captureCreated()
+
+			receiver <- events.Uplink.New("", dto.Envelop{EndDeviceIDs: dto.EndDeviceIDs{DeviceID: "device-1"}}).Message()
+
+			var delivery domain.WebhookDelivery
+			run(func() { gomega.Eventually(created).Should(gomega.Receive(&delivery)) })
+
+			gomega.Expect(delivery.TenantID).To(gomega.Equal(tenantID))
+			gomega.Expect(delivery.EventType).To(gomega.Equal("uplink"))
+		})
+	})
+
+	ginkgo.Context("delivering", func() {
+		var (
+			server   *httptest.Server
+			status   int
+			requests chan *http.Request
+			bodies   chan []byte
+			hold     chan struct{}
+		)
+
+		ginkgo.BeforeEach(func() {
+			status = http.StatusNoContent
+			requests = make(chan *http.Request, 1)
+			bodies = make(chan []byte, 1)
+			hold = nil
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				select {
+				case requests <- r:
+					bodies <- body
+				default:
+				}
+				if hold != nil {
+					<-hold
+				}
+				if status == http.StatusFound {
+					w.Header().Set("Location", "/hooks")
+				}
+				w.WriteHeader(status)
+			}))
+			subscription.URL = server.URL + "/hooks"
+			config.AllowPrivateNetworks = true
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		dueDelivery := func() domain.WebhookDelivery {
+			delivery := domain.NewWebhookDelivery(subscription, "event-1", "device_offline", []byte(`{"type":"device_offline"}`), time.Now())
+			mockDelivery.EXPECT().FindDue(gomock.Any(), gomock.Any(), gomock.Any()).Return([]domain.WebhookDelivery{delivery}, nil).MinTimes(1)
+			return delivery
+		}
+
+		ginkgo.It("should POST the signed event and mark the delivery succeeded", func() {
+			delivery := dueDelivery()
+			mockSubscription.EXPECT().GetByID(gomock.Any(), subscription.ID).Return(subscription, nil).MinTimes(1)
+			captureUpdated()
+
+			var result domain.WebhookDelivery
+			run(func() { gomega.Eventually(updated).Should(gomega.Receive(&result)) })
+
+			var request *http.Request
+			gomega.Expect(requests).To(gomega.Receive(&request))
+			body := <-bodies
+			gomega.Expect(body).To(gomega.Equal(delivery.Payload))
+			gomega.Expect(request.Header.Get("X-Zensor-Event")).To(gomega.Equal("device_offline"))
+			gomega.Expect(request.Header.Get("X-Zensor-Event-ID")).To(gomega.Equal("event-1"))
+			gomega.Expect(request.Header.Get("X-Zensor-Delivery")).To(gomega.Equal(delivery.ID.String()))
+
+			timestamp, err := strconv.ParseInt(request.Header.Get("X-Zensor-Timestamp"), 10, 64)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(request.Header.Get("X-Zensor-Signature")).To(gomega.Equal("sha256=" + subscription.Sign(time.Unix(timestamp, 0), body)))
+
+			gomega.Expect(result.Status).To(gomega.Equal(domain.WebhookDeliveryStatusSucceeded))
+			gomega.Expect(result.LastStatusCode).To(gomega.Equal(http.StatusNoContent))
+			gomega.Expect(result.Attempts).To(gomega.Equal(1))
+		})
+
+		ginkgo.It("should schedule a retry when the receiver fails", func() {
+			status = http.StatusInternalServerError
+			dueDelivery()
+			mockSubscription.EXPECT().GetByID(gomock.Any(), subscription.ID).Return(subscription, nil).MinTimes(1)
+			captureUpdated()
+
+			var result domain.WebhookDelivery
+			run(func() { gomega.Eventually(updated).Should(gomega.Receive(&result)) })
+
+			gomega.Expect(result.Status).To(gomega.Equal(domain.WebhookDeliveryStatusPending))
+			gomega.Expect(result.LastStatusCode).To(gomega.Equal(http.StatusInternalServerError))
+			gomega.Expect(result.LastError).To(gomega.Equal("unexpected status 500"))
+			gomega.Expect(result.NextAttemptAt.Time).To(gomega.BeTemporally("~", time.Now().Add(time.Minute), 5*time.Second))
+		})
+
+		ginkgo.It("should not follow redirects", func() {
+			status = http.StatusFound
+			dueDelivery()
+			mockSubscription.EXPECT().GetByID(gomock.Any(), subscription.ID).Return(subscription, nil).MinTimes(1)
+			captureUpdated()
+
+			var result domain.WebhookDelivery
+			run(func() { gomega.Eventually(updated).Should(gomega.Receive(&result)) })
+
+			gomega.Expect(result.Status).To(gomega.Equal(domain.WebhookDeliveryStatusPending))
+			gomega.Expect(result.LastStatusCode).To(gomega.Equal(http.StatusFound))
+		})
+
+		ginkgo.It("should refuse private addresses unless allowed", func() {
+			config.AllowPrivateNetworks = false
+			dueDelivery()
+			mockSubscription.EXPECT().GetByID(gomock.Any(), subscription.ID).Return(subscription, nil).MinTimes(1)
+			captureUpdated()
+
+			var result domain.WebhookDelivery
+			run(func() { gomega.Eventually(updated).Should(gomega.Receive(&result)) })
+
+			gomega.Expect(result.Status).To(gomega.Equal(domain.WebhookDeliveryStatusPending))
+			gomega.Expect(result.LastStatusCode).To(gomega.BeZero())
+			gomega.Expect(result.LastError).To(gomega.ContainSubstring(usecases.ErrWebhookAddressNotAllowed.Error()))
+			gomega.Expect(requests).NotTo(gomega.Receive())
+		})
+
+		ginkgo.It("should keep recording events while a receiver is slow", func() {
+			hold = make(chan struct{})
+			dueDelivery()
+			mockSubscription.EXPECT().GetByID(gomock.Any(), subscription.ID).Return(subscription, nil).MinTimes(1)
+			mockSubscription.EXPECT().FindActiveByTenant(gomock.Any(), tenantID).Return([]domain.WebhookSubscription{subscription}, nil)
+			mockDelivery.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			captureCreated()
+
+			run(func() {
+				defer close(hold)
+				gomega.Eventually(requests).Should(gomega.Receive())
+				gomega.Expect(bodies).To(gomega.Receive())
+				receiver <- events.DeviceOffline.New(tenantID.String(), events.DeviceConnectivity{DeviceName: "device-1", Status: "offline"}).Message()
+				gomega.Eventually(created).Should(gomega.Receive())
+			})
+		})
+
+		ginkgo.It("should abandon the deliveries of deleted subscriptions", func() {
+			dueDelivery()
+			mockSubscription.EXPECT().GetByID(gomock.Any(), subscription.ID).Return(domain.WebhookSubscription{}, usecases.ErrWebhookSubscriptionNotFound).MinTimes(1)
+			captureUpdated()
+
+			var result domain.WebhookDelivery
+			run(func() { gomega.Eventually(updated).Should(gomega.Receive(&result)) })
+
+			gomega.Expect(result.Status).To(gomega.Equal(domain.WebhookDeliveryStatusDead))
+			gomega.Expect(result.Attempts).To(gomega.BeZero())
+			gomega.Expect(requests).NotTo(gomega.Receive())
+		})
+	})
+})
@@ -2,7 +2,11 @@ package dto
 
 import (
 	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
 
 	"github.com/vmihailenco/msgpack/v5"
 )
@@ -13,6 +17,28 @@ type Envelop struct {
 	UplinkMessage  UplinkMessage `json:"uplink_message"`
 	CorrelationIDs []string      `json:"correlation_ids"`
 	Error          Error         `json:"error"`
+	JoinAccept     *JoinAccept   `json:"join_accept,omitempty"`
+}
+
+// _uplinkCorrelationPrefix marks the correlation ID the application server gives each uplink.
+const _uplinkCorrelationPrefix = "as:up:"
+
+// UplinkID returns the ID the network server gave the uplink, the same on every delivery of it, or
+// an empty string when there is none.
+func (e Envelop) UplinkID() string {
+	for _, id := range e.CorrelationIDs {
+		if strings.HasPrefix(id, _uplinkCorrelationPrefix) {
+			return id
+		}
+	}
+	return ""
+}
+
+// JoinAccept is sent by the network server when a device completes an OTAA join. The DevAddr
+// of the new session comes in the end device identifiers.
+type JoinAccept struct {
+	SessionKeyID string    `json:"session_key_id"`
+	ReceivedAt   time.Time `json:"received_at"`
 }
 
 type Error struct {
@@ -33,8 +59,71 @@ type EndDeviceIDs struct {
 
 type UplinkMessage struct {
 	Port           uint8                   `json:"port"`
+	FCnt           *uint32                 `json:"f_cnt,omitempty"`
 	RawPayload     []byte                  `json:"frm_payload"`
 	DecodedPayload map[string][]SensorData `json:"decoded_payload,omitempty"`
+	RxMetadata     []RxMetadata            `json:"rx_metadata,omitempty"`
+	Settings       TxSettings              `json:"settings"`
+}
+
+// RxMetadata is how a single gateway received an uplink.
+type RxMetadata struct {
+	GatewayIDs GatewayIDs `json:"gateway_ids"`
+	RSSI       float64    `json:"rssi"`
+	SNR        float64    `json:"snr"`
+}
+
+type GatewayIDs struct {
+	GatewayID string `json:"gateway_id"`
+	EUI       string `json:"eui"`
+}
+
+// TxSettings are the radio settings the uplink was transmitted with. TTN encodes the
+// frequency as a string.
+type TxSettings struct {
+	DataRate  DataRate `json:"data_rate"`
+	Frequency string   `json:"frequency"`
+}
+
+type DataRate struct {
+	LoRa LoRaDataRate `json:"lora"`
+}
+
+type LoRaDataRate struct {
+	Bandwidth       int `json:"bandwidth"`
+	SpreadingFactor int `json:"spreading_factor"`
+}
+
+// FrameCounter is the uplink frame counter. TTN omits it when it is 0, so it is 0 when absent.
+func (m UplinkMessage) FrameCounter() uint32 {
+	if m.FCnt == nil {
+		return 0
+	}
+	return *m.FCnt
+}
+
+// LinkQualitySample extracts the radio metadata of the uplink.
+func (m UplinkMessage) LinkQualitySample(devAddr string, receivedAt time.Time) domain.LinkQualitySample {
+	gateways := make([]domain.GatewayReception, len(m.RxMetadata))
+	for i, metadata := range m.RxMetadata {
+		gateways[i] = domain.GatewayReception{
+			GatewayID: metadata.GatewayIDs.GatewayID,
+			RSSI:      metadata.RSSI,
+			SNR:       metadata.SNR,
+		}
+	}
+
+	frequency, _ := strconv.ParseInt(m.Settings.Frequency, 10, 64)
+
+	return domain.LinkQualitySample{
+		DevAddr:         devAddr,
+		FrameCounter:    m.FrameCounter(),
+		SpreadingFactor: m.Settings.DataRate.LoRa.SpreadingFactor,
+		Bandwidth:       m.Settings.DataRate.LoRa.Bandwidth,
+		FrequencyHz:     frequency,
+		Gateways:        gateways,
+		ReceivedAt:      utils.Time{Time: receivedAt},
+	}
 }
 
 type SensorData struct {
@@ -47,6 +136,16 @@ var codeToNameMapping = map[string]string{
 	"h": "humidity",
 	"w": "waterFlow",
 	"r": "relay",
+	"b": "battery",
+}
+
+// BatteryVoltage returns the battery voltage reported in the decoded payload, if any.
+func (m UplinkMessage) BatteryVoltage() (float64, bool) {
+	readings := m.DecodedPayload["battery"]
+	if len(readings) == 0 {
+		return 0, false
+	}
+	return readings[0].Value, true
 }
 
 func (m *UplinkMessage) FromMessagePack() any {
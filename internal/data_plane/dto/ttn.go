@@ -8,5 +8,6 @@ type TTNMessageDownlink struct {
 	FPort          uint8    `json:"f_port"`
 	FrmPayload     []byte   `json:"frm_payload"`
 	Priority       string   `json:"priority"`
+	Confirmed      bool     `json:"confirmed,omitempty"`
 	CorrelationIDs []string `json:"correlation_ids,omitempty"`
 }
@@ -0,0 +1,223 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+	"zensor-server/internal/data_plane/dto"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/infra/mqtt"
+
+	"github.com/google/uuid"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	_simulatorGatewayID = "zensor-simulator"
+	_simulatorFPort     = 1
+)
+
+// DeviceSimulatorConfig lists the simulated devices and how often each publishes an uplink.
+type DeviceSimulatorConfig struct {
+	Devices        []string
+	UplinkInterval time.Duration
+}
+
+func NewDeviceSimulator(mqttClient mqtt.Client, config DeviceSimulatorConfig) *DeviceSimulator {
+	devices := make(map[string]*simulatedDevice, len(config.Devices))
+	for i, name := range config.Devices {
+		devices[name] = &simulatedDevice{
+			name:        name,
+			devEUI:      fmt.Sprintf("70B3D57ED%07X", i+1),
+			devAddr:     fmt.Sprintf("260B%04X", i+1),
+			temperature: 20 + rand.Float64()*5,  //nolint:gosec // simulated readings
+			humidity:    50 + rand.Float64()*10, //nolint:gosec // simulated readings
+			battery:     3.7,
+		}
+	}
+
+	return &DeviceSimulator{
+		mqttClient: mqttClient,
+		config:     config,
+		devices:    devices,
+	}
+}
+
+var _ async.Worker = (*DeviceSimulator)(nil)
+
+// DeviceSimulator plays the network server for simulated LoRaWAN devices on the MQTT broker the
+// LoraIntegrationWorker listens to, so a local environment exercises the real integration: each
+// device joins on start and publishes TTN-shaped uplinks with msgpack-encoded temperature,
+// humidity and battery readings, and the downlinks pushed to it are reported queued, sent and,
+// when confirmed, acknowledged with their correlation IDs.
+type DeviceSimulator struct {
+	mqttClient mqtt.Client
+	config     DeviceSimulatorConfig
+	devices    map[string]*simulatedDevice
+}
+
+type simulatedDevice struct {
+	name        string
+	devEUI      string
+	devAddr     string
+	frameCount  uint32
+	temperature float64
+	humidity    float64
+	battery     float64
+}
+
+func (s *DeviceSimulator) Run(ctx context.Context, done func()) {
+	defer done()
+
+	downlinks := fmt.Sprintf("%s/+/down/push", topicBase)
+	if err := s.mqttClient.Subscribe(downlinks, 1, s.downlinkHandler(ctx)); err != nil {
+		slog.Error("subscribing to simulated device downlinks", slog.String("topic", downlinks), slog.Any("error", err))
+		return
+	}
+
+	slog.Info("device simulator started",
+		slog.Int("devices", len(s.devices)),
+		slog.Duration("uplink_interval", s.config.UplinkInterval),
+	)
+	for _, device := range s.devices {
+		s.publish(ctx, device, "join", s.joinEnvelop(device))
+	}
+	s.publishUplinks(ctx)
+
+	ticker := time.NewTicker(s.config.UplinkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("device simulator cancelled")
+			return
+		case <-ticker.C:
+			s.publishUplinks(ctx)
+		}
+	}
+}
+
+func (s *DeviceSimulator) Shutdown() {
+	_ = s.mqttClient.Unsubscribe(fmt.Sprintf("%s/+/down/push", topicBase))
+}
+
+func (s *DeviceSimulator) publishUplinks(ctx context.Context) {
+	for _, device := range s.devices {
+		envelop, err := s.uplinkEnvelop(device)
+		if err != nil {
+			slog.Error("encoding simulated uplink", slog.String("device_name", device.name), slog.Any("error", err))
+			continue
+		}
+		s.publish(ctx, device, "up", envelop)
+	}
+}
+
+func (s *DeviceSimulator) publish(ctx context.Context, device *simulatedDevice, suffix string, envelop dto.Envelop) {
+	topic := fmt.Sprintf("%s/%s/%s", topicBase, device.name, suffix)
+	if err := s.mqttClient.Publish(ctx, topic, envelop); err != nil {
+		slog.Error("publishing simulated device message", slog.String("topic", topic), slog.Any("error", err))
+	}
+}
+
+func (s *DeviceSimulator) joinEnvelop(device *simulatedDevice) dto.Envelop {
+	now := time.Now()
+	return dto.Envelop{
+		EndDeviceIDs: device.ids(),
+		ReceivedAt:   now,
+		JoinAccept:   &dto.JoinAccept{SessionKeyID: uuid.NewString(), ReceivedAt: now},
+	}
+}
+
+// uplinkEnvelop advances the readings of the device with a random walk, within what a byte can
+// encode, and encodes them the way the devices do: a msgpack map from sensor code to (index,
+// integer part, hundredths) triplets.
+func (s *DeviceSimulator) uplinkEnvelop(device *simulatedDevice) (dto.Envelop, error) {
+	device.frameCount++
+	device.temperature = clamp(device.temperature+rand.NormFloat64()*0.3, 0, 50) //nolint:gosec // simulated readings
+	device.humidity = clamp(device.humidity+rand.NormFloat64(), 0, 100)          //nolint:gosec // simulated readings
+	device.battery = clamp(device.battery-0.001, 3.0, 4.2)
+
+	payload, err := msgpack.Marshal(map[string][]byte{
+		"t": encodeReading(1, device.temperature),
+		"h": encodeReading(1, device.humidity),
+		"b": encodeReading(1, device.battery),
+	})
+	if err != nil {
+		return dto.Envelop{}, err
+	}
+
+	frameCount := device.frameCount
+	return dto.Envelop{
+		EndDeviceIDs:   device.ids(),
+		ReceivedAt:     time.Now(),
+		CorrelationIDs: []string{"as:up:" + uuid.NewString()},
+		UplinkMessage: dto.UplinkMessage{
+			Port:       _simulatorFPort,
+			FCnt:       &frameCount,
+			RawPayload: payload,
+			RxMetadata: []dto.RxMetadata{{
+				GatewayIDs: dto.GatewayIDs{GatewayID: _simulatorGatewayID},
+				RSSI:       -60 - rand.Float64()*40, //nolint:gosec // simulated readings
+				SNR:        5 + rand.Float64()*5,    //nolint:gosec // simulated readings
+			}},
+			Settings: dto.TxSettings{
+				DataRate:  dto.DataRate{LoRa: dto.LoRaDataRate{Bandwidth: 125000, SpreadingFactor: 7}},
+				Frequency: "916800000",
+			},
+		},
+	}, nil
+}
+
+func (s *DeviceSimulator) downlinkHandler(ctx context.Context) mqtt.MessageHandler {
+	return func(_ mqtt.Client, msg mqtt.Message) {
+		name := strings.TrimSuffix(strings.TrimPrefix(msg.Topic(), topicBase+"/"), "/down/push")
+		device, found := s.devices[name]
+		if !found {
+			return
+		}
+
+		var downlinks dto.TTNMessage
+		if err := json.Unmarshal(msg.Payload(), &downlinks); err != nil {
+			slog.Error("decoding simulated device downlink", slog.String("topic", msg.Topic()), slog.Any("error", err))
+			return
+		}
+
+		// Publishing waits for the broker, which must not happen on the client's delivery path.
+		go s.acknowledge(ctx, device, downlinks.Downlinks)
+	}
+}
+
+func (s *DeviceSimulator) acknowledge(ctx context.Context, device *simulatedDevice, downlinks []dto.TTNMessageDownlink) {
+	for _, downlink := range downlinks {
+		statuses := []string{"down/queued", "down/sent"}
+		if downlink.Confirmed {
+			statuses = append(statuses, "down/ack")
+		}
+		for _, status := range statuses {
+			s.publish(ctx, device, status, dto.Envelop{
+				EndDeviceIDs:   device.ids(),
+				ReceivedAt:     time.Now(),
+				CorrelationIDs: downlink.CorrelationIDs,
+			})
+		}
+	}
+}
+
+func (d *simulatedDevice) ids() dto.EndDeviceIDs {
+	return dto.EndDeviceIDs{DeviceID: d.name, DevEUI: d.devEUI, DevAddr: d.devAddr}
+}
+
+func encodeReading(index uint8, value float64) []byte {
+	hundredths := uint16(math.Round(value * 100))                          //nolint:gosec // readings are clamped to a byte
+	return []byte{index, uint8(hundredths / 100), uint8(hundredths % 100)} //nolint:gosec // readings are clamped to a byte
+}
+
+func clamp(value, lower, upper float64) float64 {
+	return min(max(value, lower), upper)
+}
@@ -0,0 +1,155 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+	"zensor-server/internal/data_plane/dto"
+	"zensor-server/internal/infra/mqtt"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+type publishedMessage struct {
+	topic   string
+	envelop dto.Envelop
+}
+
+// recordingMQTTClient keeps the messages published and the handler of the last subscription.
+type recordingMQTTClient struct {
+	mu        sync.Mutex
+	handler   mqtt.MessageHandler
+	published []publishedMessage
+}
+
+func (c *recordingMQTTClient) Subscribe(_ string, _ byte, handler mqtt.MessageHandler) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler = handler
+	return nil
+}
+
+func (c *recordingMQTTClient) Unsubscribe(...string) error {
+	return nil
+}
+
+func (c *recordingMQTTClient) Publish(_ context.Context, topic string, msg any, _ ...mqtt.PublishOption) error {
+	data, err := json.Marshal(msg)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	var envelop dto.Envelop
+	gomega.Expect(json.Unmarshal(data, &envelop)).To(gomega.Succeed())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.published = append(c.published, publishedMessage{topic: topic, envelop: envelop})
+	return nil
+}
+
+func (c *recordingMQTTClient) Disconnect() {}
+
+func (c *recordingMQTTClient) messages(suffix string) []publishedMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var result []publishedMessage
+	for _, msg := range c.published {
+		if strings.HasSuffix(msg.topic, "/"+suffix) {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+func (c *recordingMQTTClient) deliver(topic, payload string) {
+	c.mu.Lock()
+	handler := c.handler
+	c.mu.Unlock()
+
+	gomega.Expect(handler).NotTo(gomega.BeNil())
+	handler(c, &fakeMessage{topic: topic, payload: payload})
+}
+
+var _ = ginkgo.Describe("DeviceSimulator", func() {
+	var (
+		client *recordingMQTTClient
+		cancel context.CancelFunc
+		wg     sync.WaitGroup
+	)
+
+	ginkgo.BeforeEach(func() {
+		client = &recordingMQTTClient{}
+		simulator := NewDeviceSimulator(client, DeviceSimulatorConfig{
+			Devices:        []string{"device-1", "device-2"},
+			UplinkInterval: 50 * time.Millisecond,
+		})
+
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background()) //nolint:fatcontext // fresh root context per test setup
+		wg.Add(1)
+		go simulator.Run(ctx, wg.Done)
+	})
+
+	ginkgo.AfterEach(func() {
+		cancel()
+		wg.Wait()
+	})
+
+	ginkgo.It("should join every device", func() {
+		gomega.Eventually(func() []publishedMessage { return client.messages("join") }).Should(gomega.HaveLen(2))
+
+		join := client.messages("join")[0]
+		gomega.Expect(join.envelop.JoinAccept).NotTo(gomega.BeNil())
+		gomega.Expect(join.topic).To(gomega.Equal(topicBase + "/" + join.envelop.EndDeviceIDs.DeviceID + "/join"))
+		gomega.Expect(join.envelop.EndDeviceIDs.DevAddr).NotTo(gomega.BeEmpty())
+	})
+
+	ginkgo.It("should publish uplinks the LoRa integration decodes", func() {
+		gomega.Eventually(func() []publishedMessage { return client.messages("up") }).Should(gomega.HaveLen(4))
+
+		var frameCounts []uint32
+		for _, uplink := range client.messages("up") {
+			if uplink.envelop.EndDeviceIDs.DeviceID != "device-1" {
+				continue
+			}
+			frameCounts = append(frameCounts, uplink.envelop.UplinkMessage.FrameCounter())
+			gomega.Expect(uplink.envelop.UplinkID()).To(gomega.HavePrefix("as:up:"))
+		}
+		gomega.Expect(frameCounts[:2]).To(gomega.Equal([]uint32{1, 2}))
+
+		message := client.messages("up")[0].envelop.UplinkMessage
+		message.FromMessagePack()
+		gomega.Expect(message.DecodedPayload).To(gomega.HaveKey("temperature"))
+		gomega.Expect(message.DecodedPayload).To(gomega.HaveKey("humidity"))
+		gomega.Expect(message.DecodedPayload["temperature"][0].Index).To(gomega.Equal(uint(1)))
+		voltage, found := message.BatteryVoltage()
+		gomega.Expect(found).To(gomega.BeTrue())
+		gomega.Expect(voltage).To(gomega.BeNumerically("~", 3.7, 0.01))
+	})
+
+	ginkgo.It("should report the downlinks queued, sent and acknowledged when confirmed", func() {
+		gomega.Eventually(func() []publishedMessage { return client.messages("join") }).Should(gomega.HaveLen(2))
+
+		client.deliver(topicBase+"/device-1/down/push",
+			`{"downlinks":[{"f_port":15,"frm_payload":"AQI=","priority":"NORMAL","confirmed":true,"correlation_ids":["zensor:cmd-1"]},`+
+				`{"f_port":15,"frm_payload":"AQI=","priority":"NORMAL","correlation_ids":["zensor:cmd-2"]}]}`)
+
+		gomega.Eventually(func() []publishedMessage { return client.messages("down/sent") }).Should(gomega.HaveLen(2))
+		gomega.Expect(client.messages("down/queued")).To(gomega.HaveLen(2))
+		acks := client.messages("down/ack")
+		gomega.Expect(acks).To(gomega.HaveLen(1))
+		gomega.Expect(acks[0].topic).To(gomega.Equal(topicBase + "/device-1/down/ack"))
+		gomega.Expect(acks[0].envelop.CorrelationIDs).To(gomega.Equal([]string{"zensor:cmd-1"}))
+		gomega.Expect(acks[0].envelop.EndDeviceIDs.DeviceID).To(gomega.Equal("device-1"))
+	})
+
+	ginkgo.It("should ignore the downlinks of devices it does not simulate", func() {
+		gomega.Eventually(func() []publishedMessage { return client.messages("join") }).Should(gomega.HaveLen(2))
+
+		client.deliver(topicBase+"/other-device/down/push", `{"downlinks":[{"confirmed":true,"correlation_ids":["zensor:cmd-1"]}]}`)
+
+		gomega.Consistently(func() []publishedMessage { return client.messages("down/queued") }, 100*time.Millisecond).Should(gomega.BeEmpty())
+	})
+})
@@ -14,35 +14,62 @@ import (
 	"zensor-server/internal/data_plane/dto"
 	"zensor-server/internal/infra/async"
 	"zensor-server/internal/infra/mqtt"
+	"zensor-server/internal/infra/node"
 	"zensor-server/internal/infra/utils"
 	devicepkg "zensor-server/internal/shared_kernel/device"
 	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	BrokerTopicUplinkMessage async.BrokerTopicName = "device_messages"
+	// _commandClaimLease bounds how long a dispatching replica owns a command before another
+	// replica may claim it again.
+	_commandClaimLease = time.Minute
 
-	_defaultQoS byte = 0 // At most once
+	_reconciliationPageSize = 1000
 )
 
+// deviceTopic is the internal broker topic of a kind of event of a device, such as
+// devices/{device_name}/uplink, so consumers can subscribe to a single device or kind of event
+// through wildcards.
+func deviceTopic(deviceName string, kind ...string) async.BrokerTopicName {
+	return async.BrokerTopicName(strings.Join(append([]string{"devices", deviceName}, kind...), "/"))
+}
+
+// LoraIntegrationConfig holds the quality of service of the subscriptions to device topics.
+// Downlinks are published with the default quality of service of the MQTT client.
+type LoraIntegrationConfig struct {
+	SubscribeQoS byte
+}
+
 func NewLoraIntegrationWorker(
 	ticker *time.Ticker,
 	service usecases.DeviceService,
+	sessionService usecases.DeviceSessionService,
 	stateCache usecases.DeviceStateCacheService,
 	mqttClient mqtt.Client,
 	broker async.InternalBroker,
 	commandRepository usecases.CommandRepository,
+	outboxRepository usecases.OutboxRepository,
+	config LoraIntegrationConfig,
 ) *LoraIntegrationWorker {
 	return &LoraIntegrationWorker{
 		ticker:            ticker,
 		service:           service,
+		sessionService:    sessionService,
 		stateCache:        stateCache,
 		mqttClient:        mqttClient,
 		broker:            broker,
 		commandRepository: commandRepository,
+		outboxRepository:  outboxRepository,
+		config:            config,
+		deduplicator:      NewUplinkDeduplicator(_uplinkReplayWindow),
 	}
 }
 
@@ -51,16 +78,25 @@ var _ async.Worker = &LoraIntegrationWorker{}
 type LoraIntegrationWorker struct {
 	ticker            *time.Ticker
 	service           usecases.DeviceService
+	sessionService    usecases.DeviceSessionService
 	stateCache        usecases.DeviceStateCacheService
 	mqttClient        mqtt.Client
 	broker            async.InternalBroker
 	commandRepository usecases.CommandRepository
+	outboxRepository  usecases.OutboxRepository
+	config            LoraIntegrationConfig
 	devices           sync.Map
+	deduplicator      *UplinkDeduplicator
+	droppedUplinks    metric.Float64Counter
 }
 
 func (w *LoraIntegrationWorker) Run(ctx context.Context, done func()) {
 	slog.Debug("run with context initialized")
 	defer done()
+
+	if err := w.initializeMetrics(); err != nil {
+		slog.Error("initializing metrics", slog.Any("error", err))
+	}
 	var wg sync.WaitGroup
 
 	for {
@@ -87,9 +123,9 @@ func (w *LoraIntegrationWorker) dispatchReadyCommands(ctx context.Context, done
 	defer done()
 	span := trace.SpanFromContext(ctx)
 
-	commands, err := w.commandRepository.FindAllReadyToDispatch(ctx)
+	commands, err := w.commandRepository.ClaimReadyToDispatch(ctx, node.GetNodeInfo().ID, _commandClaimLease)
 	if err != nil {
-		slog.Error("finding commands ready to dispatch",
+		slog.Error("claiming commands ready to dispatch",
 			slog.String("trace_id", span.SpanContext().TraceID().String()),
 			slog.String("span_id", span.SpanContext().SpanID().String()),
 			slog.Any("error", err),
@@ -107,7 +143,7 @@ func (w *LoraIntegrationWorker) reconciliation(ctx context.Context, done func())
 	span := trace.SpanFromContext(ctx)
 
 	defer done()
-	devices, _, err := w.service.AllDevices(ctx, usecases.Pagination{Limit: 1000, Offset: 0})
+	devices, total, err := w.service.AllDevices(ctx, usecases.Pagination{Limit: _reconciliationPageSize, Offset: 0})
 	if err != nil {
 		slog.Error("getting all devices",
 			slog.String("trace_id", span.SpanContext().TraceID().String()),
@@ -117,9 +153,20 @@ func (w *LoraIntegrationWorker) reconciliation(ctx context.Context, done func())
 		return
 	}
 
+	current := make(map[domain.ID]struct{}, len(devices))
 	for _, device := range devices {
+		current[device.ID] = struct{}{}
 		w.handleDevice(ctx, device)
 	}
+
+	if total > len(devices) {
+		slog.Warn("device listing is partial, skipping removal of stale subscriptions",
+			slog.Int("listed", len(devices)),
+			slog.Int("total", total),
+		)
+	} else {
+		w.removeStaleDevices(current)
+	}
 	slog.Debug("reconciliation end", slog.Time("time", time.Now()))
 }
 
@@ -152,14 +199,13 @@ func (w *LoraIntegrationWorker) handleDevice(ctx context.Context, device domain.
 		return
 	}
 	w.devices.Store(device.ID, device)
-	for _, suffix := range topics {
-		topic := fmt.Sprintf("%s/%s/%s", topicBase, device.Name, suffix)
+	for _, topic := range deviceTopics(device) {
 		slog.Debug("final topic",
 			slog.String("value", topic),
 			slog.String("trace_id", span.SpanContext().TraceID().String()),
 			slog.String("span_id", span.SpanContext().SpanID().String()),
 		)
-		err := w.mqttClient.Subscribe(topic, _defaultQoS, w.messageHandler(ctx))
+		err := w.mqttClient.Subscribe(topic, w.config.SubscribeQoS, w.messageHandler(ctx))
 		if err != nil {
 			slog.Error("failed to subscribe to topic",
 				slog.String("topic", topic),
@@ -169,6 +215,40 @@ func (w *LoraIntegrationWorker) handleDevice(ctx context.Context, device domain.
 	}
 }
 
+// removeStaleDevices unsubscribes the topics of devices that were decommissioned or
+// otherwise dropped from the device listing since the last reconciliation.
+func (w *LoraIntegrationWorker) removeStaleDevices(current map[domain.ID]struct{}) {
+	w.devices.Range(func(key, value any) bool {
+		id := key.(domain.ID)
+		if _, ok := current[id]; ok {
+			return true
+		}
+
+		device := value.(domain.Device)
+		err := w.mqttClient.Unsubscribe(deviceTopics(device)...)
+		if err != nil {
+			slog.Error("failed to unsubscribe removed device",
+				slog.String("device", device.Name),
+				slog.String("error", err.Error()),
+			)
+			return true
+		}
+
+		w.devices.Delete(id)
+		w.deduplicator.Reset(device.Name)
+		slog.Info("removed device subscriptions", slog.String("device", device.Name))
+		return true
+	})
+}
+
+func deviceTopics(device domain.Device) []string {
+	result := make([]string, len(topics))
+	for i, suffix := range topics {
+		result[i] = fmt.Sprintf("%s/%s/%s", topicBase, device.Name, suffix)
+	}
+	return result
+}
+
 var topicRegex = regexp.MustCompile(`^.*/devices/[\w-_]*/(.*)$`)
 
 func (w *LoraIntegrationWorker) messageHandler(ctx context.Context) mqtt.MessageHandler {
@@ -193,6 +273,8 @@ func (w *LoraIntegrationWorker) messageHandler(ctx context.Context) mqtt.Message
 
 		topicSuffix := result[1]
 		switch topicSuffix {
+		case "join":
+			w.joinMessageHandler(ctx, msg)
 		case "up":
 			w.uplinkMessageHandler(ctx, msg)
 		case "down/queued":
@@ -254,6 +336,49 @@ func (w *LoraIntegrationWorker) handleDownlinkResponse(ctx context.Context, msg
 	w.updateCommandStatus(ctx, envelop, status, errorMessage)
 }
 
+func (w *LoraIntegrationWorker) joinMessageHandler(ctx context.Context, msg mqtt.Message) {
+	span := trace.SpanFromContext(ctx)
+	var envelop dto.Envelop
+	err := json.Unmarshal(msg.Payload(), &envelop)
+	if err != nil {
+		slog.Error("failed to unmarshal join message",
+			slog.String("error", err.Error()),
+			slog.String("trace_id", span.SpanContext().TraceID().String()),
+			slog.String("span_id", span.SpanContext().SpanID().String()),
+		)
+		return
+	}
+
+	if envelop.JoinAccept == nil {
+		slog.Debug("join message without join accept",
+			slog.String("topic", msg.Topic()),
+			slog.String("trace_id", span.SpanContext().TraceID().String()),
+			slog.String("span_id", span.SpanContext().SpanID().String()),
+		)
+		return
+	}
+
+	joinedAt := envelop.JoinAccept.ReceivedAt
+	if joinedAt.IsZero() {
+		joinedAt = envelop.ReceivedAt
+	}
+	if joinedAt.IsZero() {
+		joinedAt = time.Now()
+	}
+
+	deviceName := envelop.EndDeviceIDs.DeviceID
+	w.deduplicator.Reset(deviceName)
+	err = w.sessionService.RecordJoin(ctx, deviceName, envelop.EndDeviceIDs.DevAddr, joinedAt)
+	if err != nil {
+		slog.Error("failed to record device join",
+			slog.String("device_name", deviceName),
+			slog.String("error", err.Error()),
+			slog.String("trace_id", span.SpanContext().TraceID().String()),
+			slog.String("span_id", span.SpanContext().SpanID().String()),
+		)
+	}
+}
+
 func (w *LoraIntegrationWorker) uplinkMessageHandler(ctx context.Context, msg mqtt.Message) {
 	span := trace.SpanFromContext(ctx)
 	var envelop dto.Envelop
@@ -267,9 +392,28 @@ func (w *LoraIntegrationWorker) uplinkMessageHandler(ctx context.Context, msg mq
 		return
 	}
 
+	deviceName := envelop.EndDeviceIDs.DeviceID
+	receivedAt := envelop.ReceivedAt
+	if receivedAt.IsZero() {
+		receivedAt = time.Now()
+	}
+
+	verdict := w.deduplicator.Check(deviceName, envelop.UplinkID(), envelop.UplinkMessage.FCnt, receivedAt)
+	if verdict != UplinkAccepted {
+		slog.Warn("dropping uplink",
+			slog.String("device_name", deviceName),
+			slog.String("reason", string(verdict)),
+			slog.Uint64("f_cnt", uint64(envelop.UplinkMessage.FrameCounter())),
+			slog.String("uplink_id", envelop.UplinkID()),
+			slog.String("trace_id", span.SpanContext().TraceID().String()),
+			slog.String("span_id", span.SpanContext().SpanID().String()),
+		)
+		w.recordDroppedUplink(ctx, verdict)
+		return
+	}
+
 	envelop.UplinkMessage.FromMessagePack()
 
-	deviceName := envelop.EndDeviceIDs.DeviceID
 	err = w.service.UpdateLastMessageReceivedAt(ctx, deviceName)
 	if err != nil {
 		slog.Error("failed to update device last message timestamp",
@@ -280,6 +424,29 @@ func (w *LoraIntegrationWorker) uplinkMessageHandler(ctx context.Context, msg mq
 		)
 	}
 
+	if voltage, ok := envelop.UplinkMessage.BatteryVoltage(); ok {
+		err = w.service.RecordBatteryReading(ctx, deviceName, voltage, receivedAt)
+		if err != nil {
+			slog.Error("failed to record device battery",
+				slog.String("device_name", deviceName),
+				slog.String("error", err.Error()),
+				slog.String("trace_id", span.SpanContext().TraceID().String()),
+				slog.String("span_id", span.SpanContext().SpanID().String()),
+			)
+		}
+	}
+
+	sample := envelop.UplinkMessage.LinkQualitySample(envelop.EndDeviceIDs.DevAddr, receivedAt)
+	err = w.sessionService.RecordUplink(ctx, deviceName, sample)
+	if err != nil {
+		slog.Error("failed to record uplink link quality",
+			slog.String("device_name", deviceName),
+			slog.String("error", err.Error()),
+			slog.String("trace_id", span.SpanContext().TraceID().String()),
+			slog.String("span_id", span.SpanContext().SpanID().String()),
+		)
+	}
+
 	err = w.stateCache.SetState(ctx, deviceName, envelop.UplinkMessage.DecodedPayload)
 	if err != nil {
 		slog.Error("failed to update device state cache",
@@ -290,11 +457,8 @@ func (w *LoraIntegrationWorker) uplinkMessageHandler(ctx context.Context, msg mq
 		)
 	}
 
-	brokerMsg := async.BrokerMessage{
-		Event: "uplink",
-		Value: envelop,
-	}
-	err = w.broker.Publish(ctx, BrokerTopicUplinkMessage, brokerMsg)
+	brokerMsg := events.Uplink.New("", envelop).Message()
+	err = w.broker.Publish(ctx, deviceTopic(deviceName, "uplink"), brokerMsg)
 	if err != nil {
 		slog.Error("failed to publish message", slog.String("error", err.Error()))
 	}
@@ -324,7 +488,7 @@ func (w *LoraIntegrationWorker) dispatchCommand(ctx context.Context, cmd domain.
 	}
 
 	topic := fmt.Sprintf("%s/%s/%s", topicBase, command.DeviceName, "down/push")
-	rawPayload, err := command.Payload.ToMessagePack()
+	rawPayload, err := commandFramePayload(command)
 	if err != nil {
 		slog.Error("converting to message pack failed",
 			slog.String("trace_id", span.SpanContext().TraceID().String()),
@@ -339,6 +503,7 @@ func (w *LoraIntegrationWorker) dispatchCommand(ctx context.Context, cmd domain.
 				FPort:          command.Port,
 				Priority:       command.Priority,
 				FrmPayload:     rawPayload,
+				Confirmed:      command.Confirmed,
 				CorrelationIDs: []string{"zensor:" + command.ID},
 			},
 		},
@@ -346,7 +511,9 @@ func (w *LoraIntegrationWorker) dispatchCommand(ctx context.Context, cmd domain.
 	slog.Debug("ttn message",
 		slog.Any("msg", ttnMsg),
 	)
-	err = w.mqttClient.Publish(ctx, topic, ttnMsg)
+	// Queued downlinks would be reported sent and reach the device whenever the broker comes
+	// back, so they fail instead and the command is dispatched again once it is connected.
+	err = w.mqttClient.Publish(ctx, topic, ttnMsg, mqtt.WithoutQueue())
 	if err != nil {
 		slog.Error("publishing command",
 			slog.String("trace_id", span.SpanContext().TraceID().String()),
@@ -354,6 +521,9 @@ func (w *LoraIntegrationWorker) dispatchCommand(ctx context.Context, cmd domain.
 			slog.String("device_id", command.DeviceID),
 			slog.String("error", err.Error()),
 		)
+		if err := w.commandRepository.ReleaseClaim(ctx, cmd.ID, node.GetNodeInfo().ID); err != nil {
+			slog.Error("releasing command claim", slog.String("command_id", command.ID), slog.Any("error", err))
+		}
 		return
 	}
 
@@ -364,18 +534,39 @@ func (w *LoraIntegrationWorker) dispatchCommand(ctx context.Context, cmd domain.
 		slog.String("topic", topic),
 	)
 
+	// The dispatching replica records the outcome itself: the command_sent event only reaches the
+	// consumers of this replica, which may not run the leader workers.
+	if err := w.commandRepository.MarkSent(ctx, cmd.ID, time.Now()); err != nil {
+		slog.Error("marking command sent",
+			slog.String("trace_id", span.SpanContext().TraceID().String()),
+			slog.String("span_id", span.SpanContext().SpanID().String()),
+			slog.String("command_id", command.ID),
+			slog.Any("error", err),
+		)
+	}
+
+	tenantID := ""
+	if cmd.Device.TenantID != nil {
+		tenantID = cmd.Device.TenantID.String()
+	}
 	if err := w.broker.Publish(
 		ctx,
-		BrokerTopicUplinkMessage,
-		async.BrokerMessage{
-			Event: "command_sent",
-			Value: *command,
-		},
+		deviceTopic(command.DeviceName, "command_sent"),
+		events.CommandSent.New(tenantID, *command).Message(),
 	); err != nil {
 		slog.Error("failed to publish command sent event", slog.Any("error", err))
 	}
 }
 
+// commandFramePayload is the raw payload of the command when it has one, such as a configuration
+// fragment, and its index/value payload encoded as MessagePack otherwise.
+func commandFramePayload(command *devicepkg.Command) ([]byte, error) {
+	if len(command.RawPayload) > 0 {
+		return command.RawPayload, nil
+	}
+	return command.Payload.ToMessagePack()
+}
+
 func domainCommandToDeviceCommand(cmd domain.Command) *devicepkg.Command {
 	return &devicepkg.Command{
 		ID:         cmd.ID.String(),
@@ -387,6 +578,8 @@ func domainCommandToDeviceCommand(cmd domain.Command) *devicepkg.Command {
 			Index: uint8(cmd.Payload.Index),
 			Value: uint8(cmd.Payload.Value),
 		},
+		RawPayload:    cmd.RawPayload,
+		Confirmed:     cmd.Confirmed,
 		DispatchAfter: cmd.DispatchAfter,
 		Port:          uint8(cmd.Port),
 		Priority:      string(cmd.Priority),
@@ -445,12 +638,12 @@ func (w *LoraIntegrationWorker) updateCommandStatus(ctx context.Context, envelop
 		Timestamp:    time.Now(),
 	}
 
-	brokerMsg := async.BrokerMessage{
-		Event: "command_status_update",
-		Value: statusUpdate,
+	// The update is stored in the outbox rather than published directly, so it reaches the
+	// command worker even if the process stops before the status is persisted.
+	event, err := domain.NewOutboxEvent(string(deviceTopic(deviceName, "command_status")), events.CommandStatusUpdated.Name, statusUpdate, statusUpdate.Timestamp)
+	if err == nil {
+		err = w.outboxRepository.Append(ctx, event)
 	}
-
-	err := w.broker.Publish(ctx, BrokerTopicUplinkMessage, brokerMsg)
 	if err != nil {
 		slog.Error("failed to publish command status update",
 			slog.String("command_id", commandID),
@@ -472,13 +665,6 @@ func (w *LoraIntegrationWorker) updateCommandStatus(ctx context.Context, envelop
 	)
 }
 
-type SensorDataReceived struct {
-	DeviceName string  `json:"device_name"`
-	AppID      string  `json:"app_id"`
-	Value      float64 `json:"value"`
-	Index      uint    `json:"index"`
-}
-
 func (w *LoraIntegrationWorker) handleSensorData(ctx context.Context, envelope dto.Envelop) {
 	slog.Warn("handleSensorData called")
 	deviceID := envelope.EndDeviceIDs.DeviceID
@@ -486,21 +672,19 @@ func (w *LoraIntegrationWorker) handleSensorData(ctx context.Context, envelope d
 	uplink := envelope.UplinkMessage
 
 	for sensorType, sensorDataArray := range uplink.DecodedPayload {
+		sensorName := utils.ToSnakeCase(sensorType)
 		for _, sensorData := range sensorDataArray {
-			sensorDataReceived := SensorDataReceived{
+			sensorDataReceived := events.SensorData{
 				DeviceName: deviceID,
+				Sensor:     sensorName,
 				AppID:      appID,
 				Value:      sensorData.Value,
 				Index:      sensorData.Index,
 			}
 
-			eventName := utils.ToSnakeCase(sensorType) + "_data_received"
-			brokerMsg := async.BrokerMessage{
-				Event: eventName,
-				Value: sensorDataReceived,
-			}
+			brokerMsg := events.SensorDataReceived.New("", sensorDataReceived).Message()
 
-			if err := w.broker.Publish(ctx, BrokerTopicUplinkMessage, brokerMsg); err != nil {
+			if err := w.broker.Publish(ctx, deviceTopic(deviceID, "sensors", sensorName), brokerMsg); err != nil {
 				slog.Error("failed to publish sensor data to internal broker",
 					slog.String("device_id", deviceID),
 					slog.String("sensor_type", sensorType),
@@ -518,6 +702,34 @@ func (w *LoraIntegrationWorker) handleSensorData(ctx context.Context, envelope d
 	}
 }
 
+func (w *LoraIntegrationWorker) initializeMetrics() error {
+	meter := otel.Meter("lora-integration-worker")
+
+	droppedUplinks, err := meter.Float64Counter(
+		"zensor_server_uplinks_dropped_total",
+		metric.WithDescription("Total number of duplicated or replayed uplinks dropped"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("creating dropped uplinks counter: %w", err)
+	}
+
+	w.droppedUplinks = droppedUplinks
+	return nil
+}
+
+func (w *LoraIntegrationWorker) recordDroppedUplink(ctx context.Context, verdict UplinkVerdict) {
+	if w.droppedUplinks == nil {
+		return
+	}
+
+	w.droppedUplinks.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("reason", string(verdict)),
+		semconv.ServiceNameKey.String("zensor-server"),
+		semconv.ServiceVersionKey.String("1.0.0"),
+	))
+}
+
 func (w *LoraIntegrationWorker) Shutdown() {
 	slog.Error("implement me")
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 	"zensor-server/internal/infra/mqtt"
+	"zensor-server/internal/shared_kernel/domain"
 
 	"github.com/onsi/ginkgo/v2"
 	"github.com/onsi/gomega"
@@ -17,20 +18,24 @@ var _ = ginkgo.Describe("LoRaIntegrationCorrelation", func() {
 	var (
 		ctrl                  *gomock.Controller
 		mockDeviceService     *mockusecases.MockDeviceService
+		mockSessionService    *mockusecases.MockDeviceSessionService
 		mockDeviceStateCache  *mockusecases.MockDeviceStateCacheService
 		mockMQTTClient        *MockMQTTClient
 		mockInternalBroker    *mockasync.MockInternalBroker
 		mockCommandRepository *mockusecases.MockCommandRepository
+		mockOutboxRepository  *mockusecases.MockOutboxRepository
 		worker                *LoraIntegrationWorker
 	)
 
 	ginkgo.BeforeEach(func() {
 		ctrl = gomock.NewController(ginkgo.GinkgoT())
 		mockDeviceService = mockusecases.NewMockDeviceService(ctrl)
+		mockSessionService = mockusecases.NewMockDeviceSessionService(ctrl)
 		mockDeviceStateCache = mockusecases.NewMockDeviceStateCacheService(ctrl)
 		mockMQTTClient = NewMockMQTTClient(ctrl)
 		mockInternalBroker = mockasync.NewMockInternalBroker(ctrl)
 		mockCommandRepository = mockusecases.NewMockCommandRepository(ctrl)
+		mockOutboxRepository = mockusecases.NewMockOutboxRepository(ctrl)
 
 		// Create a ticker for testing
 		ticker := time.NewTicker(1 * time.Second)
@@ -39,10 +44,13 @@ var _ = ginkgo.Describe("LoRaIntegrationCorrelation", func() {
 		worker = NewLoraIntegrationWorker(
 			ticker,
 			mockDeviceService,
+			mockSessionService,
 			mockDeviceStateCache,
 			mockMQTTClient,
 			mockInternalBroker,
 			mockCommandRepository,
+			mockOutboxRepository,
+			LoraIntegrationConfig{},
 		)
 	})
 
@@ -56,18 +64,157 @@ var _ = ginkgo.Describe("LoRaIntegrationCorrelation", func() {
 			gomega.Expect(worker).NotTo(gomega.BeNil())
 		})
 	})
+
+	ginkgo.Context("reconciliation", func() {
+		var (
+			ctx     context.Context
+			kept    domain.Device
+			removed domain.Device
+		)
+
+		ginkgo.BeforeEach(func() {
+			ctx = context.Background()
+			kept = domain.Device{ID: "device-1", Name: "kept-device"}
+			removed = domain.Device{ID: "device-2", Name: "removed-device"}
+
+			mockDeviceService.EXPECT().AllDevices(gomock.Any(), gomock.Any()).
+				Return([]domain.Device{kept, removed}, 2, nil)
+			worker.reconciliation(ctx, func() {})
+		})
+
+		ginkgo.When("a device disappears from the complete listing", func() {
+			ginkgo.It("should unsubscribe its topics", func() {
+				mockDeviceService.EXPECT().AllDevices(gomock.Any(), gomock.Any()).
+					Return([]domain.Device{kept}, 1, nil)
+
+				worker.reconciliation(ctx, func() {})
+
+				gomega.Expect(mockMQTTClient.unsubscribed).To(gomega.Equal(deviceTopics(removed)))
+				_, stillTracked := worker.devices.Load(removed.ID)
+				gomega.Expect(stillTracked).To(gomega.BeFalse())
+			})
+		})
+
+		ginkgo.When("the listing is partial", func() {
+			ginkgo.It("should keep the subscriptions of unlisted devices", func() {
+				mockDeviceService.EXPECT().AllDevices(gomock.Any(), gomock.Any()).
+					Return([]domain.Device{kept}, 2, nil)
+
+				worker.reconciliation(ctx, func() {})
+
+				gomega.Expect(mockMQTTClient.unsubscribed).To(gomega.BeEmpty())
+			})
+		})
+	})
+
+	ginkgo.Context("messageHandler", func() {
+		var handle mqtt.MessageHandler
+
+		ginkgo.BeforeEach(func() {
+			handle = worker.messageHandler(context.Background())
+		})
+
+		ginkgo.When("a join accept is received", func() {
+			ginkgo.It("should record the new session", func() {
+				joinedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+				mockSessionService.EXPECT().RecordJoin(gomock.Any(), "sensor-1", "260B1234", joinedAt).Return(nil)
+
+				handle(mockMQTTClient, &fakeMessage{
+					topic:   topicBase + "/sensor-1/join",
+					payload: `{"end_device_ids":{"device_id":"sensor-1","dev_addr":"260B1234"},"join_accept":{"session_key_id":"abc","received_at":"2024-05-01T10:00:00Z"}}`,
+				})
+			})
+		})
+
+		ginkgo.When("an uplink is received", func() {
+			ginkgo.It("should record its radio metadata", func() {
+				mockDeviceService.EXPECT().UpdateLastMessageReceivedAt(gomock.Any(), "sensor-1").Return(nil)
+				mockDeviceStateCache.EXPECT().SetState(gomock.Any(), "sensor-1", gomock.Any()).Return(nil)
+				mockInternalBroker.EXPECT().Publish(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mockSessionService.EXPECT().RecordUplink(gomock.Any(), "sensor-1", gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, sample domain.LinkQualitySample) error {
+						gomega.Expect(sample.FrameCounter).To(gomega.Equal(uint32(42)))
+						gomega.Expect(sample.SpreadingFactor).To(gomega.Equal(9))
+						gomega.Expect(sample.FrequencyHz).To(gomega.Equal(int64(868100000)))
+						gomega.Expect(sample.Gateways).To(gomega.ConsistOf(
+							domain.GatewayReception{GatewayID: "gw-1", RSSI: -110, SNR: -4.5},
+							domain.GatewayReception{GatewayID: "gw-2", RSSI: -90, SNR: 6},
+						))
+						return nil
+					})
+
+				handle(mockMQTTClient, &fakeMessage{
+					topic: topicBase + "/sensor-1/up",
+					payload: `{"end_device_ids":{"device_id":"sensor-1","dev_addr":"260B1234"},"received_at":"2024-05-01T10:05:00Z",` +
+						`"uplink_message":{"f_cnt":42,"rx_metadata":[{"gateway_ids":{"gateway_id":"gw-1"},"rssi":-110,"snr":-4.5},` +
+						`{"gateway_ids":{"gateway_id":"gw-2"},"rssi":-90,"snr":6}],` +
+						`"settings":{"data_rate":{"lora":{"bandwidth":125000,"spreading_factor":9}},"frequency":"868100000"}}}`,
+				})
+			})
+
+			ginkgo.It("should process it only once when it is delivered again", func() {
+				mockDeviceService.EXPECT().UpdateLastMessageReceivedAt(gomock.Any(), "sensor-1").Return(nil).Times(1)
+				mockDeviceStateCache.EXPECT().SetState(gomock.Any(), "sensor-1", gomock.Any()).Return(nil).Times(1)
+				mockSessionService.EXPECT().RecordUplink(gomock.Any(), "sensor-1", gomock.Any()).Return(nil).Times(1)
+				mockInternalBroker.EXPECT().Publish(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+				uplink := &fakeMessage{
+					topic: topicBase + "/sensor-1/up",
+					payload: `{"end_device_ids":{"device_id":"sensor-1"},"received_at":"2024-05-01T10:05:00Z",` +
+						`"correlation_ids":["as:up:01HX","rpc:/ttn.lorawan.v3.AppAs/Up"],"uplink_message":{"f_cnt":42}}`,
+				}
+				handle(mockMQTTClient, uplink)
+				handle(mockMQTTClient, uplink)
+			})
+		})
+
+		ginkgo.When("a downlink is acknowledged", func() {
+			ginkgo.It("should store the command status update in the outbox", func() {
+				mockOutboxRepository.EXPECT().Append(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, event domain.OutboxEvent) error {
+						gomega.Expect(event.Topic).To(gomega.Equal("devices/sensor-1/command_status"))
+						gomega.Expect(event.Event).To(gomega.Equal("command_status_update"))
+
+						var update domain.CommandStatusUpdate
+						gomega.Expect(event.Decode(&update)).To(gomega.Succeed())
+						gomega.Expect(update.CommandID).To(gomega.Equal("cmd-1"))
+						gomega.Expect(update.Status).To(gomega.Equal(domain.CommandStatusAck))
+						return nil
+					})
+
+				handle(mockMQTTClient, &fakeMessage{
+					topic:   topicBase + "/sensor-1/down/ack",
+					payload: `{"end_device_ids":{"device_id":"sensor-1"},"correlation_ids":["zensor:cmd-1"]}`,
+				})
+			})
+		})
+	})
 })
 
+type fakeMessage struct {
+	topic   string
+	payload string
+}
+
+func (m *fakeMessage) Duplicate() bool   { return false }
+func (m *fakeMessage) Qos() byte         { return 0 }
+func (m *fakeMessage) Retained() bool    { return false }
+func (m *fakeMessage) Topic() string     { return m.topic }
+func (m *fakeMessage) MessageID() uint16 { return 1 }
+func (m *fakeMessage) Payload() []byte   { return []byte(m.payload) }
+func (m *fakeMessage) Ack()              {}
+
 // MockMQTTClient is a simple mock for MQTT client (keeping this as it's not a generated interface).
 type MockMQTTClient struct {
-	ctrl *gomock.Controller
+	ctrl         *gomock.Controller
+	unsubscribed []string
 }
 
 func NewMockMQTTClient(ctrl *gomock.Controller) *MockMQTTClient {
 	return &MockMQTTClient{ctrl: ctrl}
 }
 
-func (m *MockMQTTClient) Publish(ctx context.Context, topic string, payload any) error {
+func (m *MockMQTTClient) Publish(ctx context.Context, topic string, payload any, opts ...mqtt.PublishOption) error {
 	return nil
 }
 
@@ -75,6 +222,11 @@ func (m *MockMQTTClient) Subscribe(topic string, qos byte, handler mqtt.MessageH
 	return nil
 }
 
+func (m *MockMQTTClient) Unsubscribe(topics ...string) error {
+	m.unsubscribed = append(m.unsubscribed, topics...)
+	return nil
+}
+
 func (m *MockMQTTClient) Disconnect() {
 	// No-op for mock
 }
@@ -0,0 +1,89 @@
+package workers
+
+import (
+	"context"
+	"time"
+	"zensor-server/internal/control_plane/persistence"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/infra/sql"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+// replica is a LoRa integration worker with the in-process broker of its own server.
+type replica struct {
+	worker *LoraIntegrationWorker
+	broker *async.LocalBroker
+	client *recordingMQTTClient
+}
+
+var _ = ginkgo.Describe("LoraIntegrationWorker dispatch across replicas", func() {
+	var (
+		ctx        context.Context
+		repository *persistence.SimpleCommandRepository
+		leader     replica
+		follower   replica
+		command    domain.Command
+	)
+
+	newReplica := func() replica {
+		broker := async.NewLocalBroker()
+		client := &recordingMQTTClient{}
+		ticker := time.NewTicker(time.Hour)
+		ginkgo.DeferCleanup(ticker.Stop)
+		ginkgo.DeferCleanup(broker.Stop)
+		worker := NewLoraIntegrationWorker(ticker, nil, nil, nil, client, broker, repository, nil, LoraIntegrationConfig{})
+		return replica{worker: worker, broker: broker, client: client}
+	}
+
+	ginkgo.BeforeEach(func() {
+		ctx = context.Background()
+		orm, err := sql.NewMemoryORM("migrations")
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		repository, err = persistence.NewCommandRepository(orm)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+		leader = newReplica()
+		follower = newReplica()
+
+		command = domain.Command{
+			ID:            domain.ID(utils.GenerateUUID()),
+			Version:       1,
+			Device:        domain.Device{ID: domain.ID(utils.GenerateUUID()), Name: "dispatch-device"},
+			Port:          domain.Port(15),
+			Priority:      domain.CommandPriority("NORMAL"),
+			Payload:       domain.CommandPayload{Index: 1, Value: 1},
+			DispatchAfter: utils.Time{Time: time.Now()},
+			Ready:         true,
+			Status:        domain.CommandStatusPending,
+		}
+		gomega.Expect(repository.Create(ctx, command)).To(gomega.Succeed())
+	})
+
+	ginkgo.When("a replica that is not the leader dispatches a command", func() {
+		ginkgo.It("should mark it sent without the leader and never send it again", func() {
+			sent, err := leader.broker.Subscribe("#", events.CommandSent.Name)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			follower.worker.dispatchReadyCommands(ctx, func() {})
+
+			gomega.Expect(follower.client.messages("down/push")).To(gomega.HaveLen(1))
+			gomega.Consistently(sent.Receiver, 100*time.Millisecond).ShouldNot(gomega.Receive())
+
+			stored, err := repository.GetByID(ctx, command.ID)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(stored.Sent).To(gomega.BeTrue())
+			gomega.Expect(stored.SentAt.IsZero()).To(gomega.BeFalse())
+
+			leader.worker.dispatchReadyCommands(ctx, func() {})
+			follower.worker.dispatchReadyCommands(ctx, func() {})
+
+			gomega.Expect(leader.client.messages("down/push")).To(gomega.BeEmpty())
+			gomega.Expect(follower.client.messages("down/push")).To(gomega.HaveLen(1))
+		})
+	})
+})
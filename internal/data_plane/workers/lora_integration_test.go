@@ -43,6 +43,22 @@ var _ = ginkgo.Describe("LoraIntegrationWorker", func() {
 				gomega.Expect(command.Ready).To(gomega.BeTrue())
 				gomega.Expect(command.Sent).To(gomega.BeFalse())
 			})
+
+			ginkgo.It("should send raw payloads verbatim", func() {
+				cmd := domain.Command{
+					ID:         domain.ID("fragment-command-id"),
+					Payload:    domain.CommandPayload{Index: 1, Value: 1},
+					RawPayload: []byte{1, 0, 2, 0xAA},
+					Confirmed:  true,
+				}
+
+				command := domainCommandToDeviceCommand(cmd)
+				payload, err := commandFramePayload(command)
+
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(payload).To(gomega.Equal([]byte{1, 0, 2, 0xAA}))
+				gomega.Expect(command.Confirmed).To(gomega.BeTrue())
+			})
 		})
 	})
 })
@@ -0,0 +1,124 @@
+package workers
+
+import (
+	"sync"
+	"time"
+)
+
+// UplinkVerdict tells whether an uplink should be processed.
+type UplinkVerdict string
+
+const (
+	UplinkAccepted  UplinkVerdict = "accepted"
+	UplinkDuplicate UplinkVerdict = "duplicate" // Same uplink ID delivered again
+	UplinkReplay    UplinkVerdict = "replay"    // Frame counter not ahead of the last accepted uplink
+
+	// _uplinkReplayWindow is how long uplink IDs are remembered and how long after the last accepted
+	// uplink an older frame counter counts as a replay. Past it the counter is trusted again, so a
+	// device that reset its counters without rejoining is only ignored for a while.
+	_uplinkReplayWindow = 10 * time.Minute
+	// _maxRememberedUplinkIDs bounds the uplink IDs kept per device.
+	_maxRememberedUplinkIDs = 32
+)
+
+// UplinkDeduplicator drops uplinks seen twice, which QoS 0 delivery and resubscriptions cause, and
+// uplinks replayed out of order. It tracks, per device, the frame counter of the last accepted
+// uplink and the network server IDs of the recent ones. A frame counter back at 0 is taken as the
+// device restarting its counters without a join, not as a replay.
+type UplinkDeduplicator struct {
+	window  time.Duration
+	mu      sync.Mutex
+	devices map[string]*uplinkHistory
+}
+
+type uplinkHistory struct {
+	frameCounter    uint32
+	hasFrameCounter bool
+	acceptedAt      time.Time
+	uplinkIDs       []rememberedUplinkID
+}
+
+type rememberedUplinkID struct {
+	id         string
+	receivedAt time.Time
+}
+
+func NewUplinkDeduplicator(window time.Duration) *UplinkDeduplicator {
+	return &UplinkDeduplicator{
+		window:  window,
+		devices: make(map[string]*uplinkHistory),
+	}
+}
+
+// Check records the uplink and tells whether it is new. The uplink ID may be empty when the network
+// server did not send one; the frame counter is checked alone then. The frame counter is nil when the
+// uplink came without one, and only the uplink ID is checked then.
+func (d *UplinkDeduplicator) Check(deviceName, uplinkID string, frameCounter *uint32, at time.Time) UplinkVerdict {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	history, found := d.devices[deviceName]
+	if !found {
+		history = &uplinkHistory{}
+		d.devices[deviceName] = history
+	} else {
+		history.forget(at.Add(-d.window))
+		if history.remembers(uplinkID) {
+			return UplinkDuplicate
+		}
+		if history.replays(frameCounter) && at.Sub(history.acceptedAt) < d.window {
+			return UplinkReplay
+		}
+	}
+
+	history.hasFrameCounter = frameCounter != nil
+	if frameCounter != nil {
+		history.frameCounter = *frameCounter
+	}
+	history.acceptedAt = at
+	if uplinkID != "" {
+		history.uplinkIDs = append(history.uplinkIDs, rememberedUplinkID{id: uplinkID, receivedAt: at})
+		if len(history.uplinkIDs) > _maxRememberedUplinkIDs {
+			history.uplinkIDs = history.uplinkIDs[1:]
+		}
+	}
+	return UplinkAccepted
+}
+
+// Reset forgets the device, for example after it joined again and its frame counters restarted.
+func (d *UplinkDeduplicator) Reset(deviceName string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.devices, deviceName)
+}
+
+// replays tells whether the frame counter is not ahead of the last accepted one. Without a counter on
+// either side there is nothing to compare, and a counter back at 0 is a reset.
+func (h *uplinkHistory) replays(frameCounter *uint32) bool {
+	if frameCounter == nil || !h.hasFrameCounter || *frameCounter == 0 {
+		return false
+	}
+	return *frameCounter <= h.frameCounter
+}
+
+func (h *uplinkHistory) remembers(uplinkID string) bool {
+	if uplinkID == "" {
+		return false
+	}
+	for _, remembered := range h.uplinkIDs {
+		if remembered.id == uplinkID {
+			return true
+		}
+	}
+	return false
+}
+
+// forget drops the uplink IDs received before the given time; they are kept in arrival order.
+func (h *uplinkHistory) forget(before time.Time) {
+	expired := 0
+	for expired < len(h.uplinkIDs) && h.uplinkIDs[expired].receivedAt.Before(before) {
+		expired++
+	}
+	h.uplinkIDs = h.uplinkIDs[expired:]
+}
@@ -0,0 +1,61 @@
+package workers
+
+import (
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+func frameCounter(value uint32) *uint32 {
+	return &value
+}
+
+var _ = ginkgo.Describe("UplinkDeduplicator", func() {
+	var (
+		deduplicator *UplinkDeduplicator
+		now          time.Time
+	)
+
+	ginkgo.BeforeEach(func() {
+		deduplicator = NewUplinkDeduplicator(10 * time.Minute)
+		now = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		gomega.Expect(deduplicator.Check("sensor-1", "as:up:01", frameCounter(10), now)).To(gomega.Equal(UplinkAccepted))
+	})
+
+	ginkgo.It("should drop an uplink delivered twice", func() {
+		gomega.Expect(deduplicator.Check("sensor-1", "as:up:01", frameCounter(10), now.Add(time.Second))).To(gomega.Equal(UplinkDuplicate))
+	})
+
+	ginkgo.It("should drop frame counters that are not ahead within the window", func() {
+		gomega.Expect(deduplicator.Check("sensor-1", "as:up:02", frameCounter(10), now.Add(time.Minute))).To(gomega.Equal(UplinkReplay))
+		gomega.Expect(deduplicator.Check("sensor-1", "", frameCounter(9), now.Add(time.Minute))).To(gomega.Equal(UplinkReplay))
+		gomega.Expect(deduplicator.Check("sensor-1", "as:up:03", frameCounter(11), now.Add(time.Minute))).To(gomega.Equal(UplinkAccepted))
+	})
+
+	ginkgo.It("should trust the frame counter again after the window", func() {
+		gomega.Expect(deduplicator.Check("sensor-1", "as:up:02", frameCounter(1), now.Add(11*time.Minute))).To(gomega.Equal(UplinkAccepted))
+	})
+
+	ginkgo.It("should track devices apart", func() {
+		gomega.Expect(deduplicator.Check("sensor-2", "as:up:04", frameCounter(10), now)).To(gomega.Equal(UplinkAccepted))
+	})
+
+	ginkgo.It("should only drop uplinks without a frame counter when their uplink ID was seen", func() {
+		gomega.Expect(deduplicator.Check("sensor-1", "as:up:02", nil, now.Add(time.Minute))).To(gomega.Equal(UplinkAccepted))
+		gomega.Expect(deduplicator.Check("sensor-1", "as:up:02", nil, now.Add(2*time.Minute))).To(gomega.Equal(UplinkDuplicate))
+		gomega.Expect(deduplicator.Check("sensor-1", "as:up:03", frameCounter(1), now.Add(3*time.Minute))).To(gomega.Equal(UplinkAccepted))
+	})
+
+	ginkgo.It("should take a frame counter back at 0 as a reset without a join", func() {
+		gomega.Expect(deduplicator.Check("sensor-1", "as:up:02", frameCounter(0), now.Add(time.Minute))).To(gomega.Equal(UplinkAccepted))
+		gomega.Expect(deduplicator.Check("sensor-1", "as:up:03", frameCounter(1), now.Add(2*time.Minute))).To(gomega.Equal(UplinkAccepted))
+		gomega.Expect(deduplicator.Check("sensor-1", "as:up:04", frameCounter(1), now.Add(3*time.Minute))).To(gomega.Equal(UplinkReplay))
+	})
+
+	ginkgo.It("should accept restarted frame counters after a reset", func() {
+		deduplicator.Reset("sensor-1")
+
+		gomega.Expect(deduplicator.Check("sensor-1", "as:up:05", frameCounter(0), now.Add(time.Second))).To(gomega.Equal(UplinkAccepted))
+	})
+})
@@ -0,0 +1,257 @@
+package async
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnexpectedEvent = errors.New("unexpected event")
+)
+
+// Event is the envelope of the messages published on the internal broker. Type and Version
+// identify the schema of Payload, so consumers decode a known struct instead of asserting on an
+// arbitrary value, and every event serializes to the same JSON layout.
+type Event[T any] struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Version    int       `json:"version"`
+	OccurredAt time.Time `json:"occurred_at"`
+	TenantID   string    `json:"tenant_id,omitempty"`
+	Payload    T         `json:"payload"`
+}
+
+// Message wraps the event in a broker message routed by its type.
+func (e Event[T]) Message() BrokerMessage {
+	return BrokerMessage{
+		Event: e.Type,
+		Value: e,
+	}
+}
+
+// EventType describes one version of an event schema in the catalog.
+type EventType[T any] struct {
+	Name    string
+	Version int
+}
+
+// New creates an event of this type that occurred now.
+func (t EventType[T]) New(tenantID string, payload T) Event[T] {
+	return Event[T]{
+		ID:         uuid.NewString(),
+		Type:       t.Name,
+		Version:    t.Version,
+		OccurredAt: time.Now().UTC(),
+		TenantID:   tenantID,
+		Payload:    payload,
+	}
+}
+
+// Decode returns the event carried by msg. Events received from another process arrive in their
+// JSON form and are decoded into the payload struct; events of another type or version fail with
+// ErrUnexpectedEvent.
+func (t EventType[T]) Decode(msg BrokerMessage) (Event[T], error) {
+	event, ok := msg.Value.(Event[T])
+	if !ok {
+		data, err := json.Marshal(msg.Value)
+		if err != nil {
+			return Event[T]{}, fmt.Errorf("%w: encoding %T: %w", ErrUnexpectedEvent, msg.Value, err)
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return Event[T]{}, fmt.Errorf("%w: decoding %s as %s v%d: %w", ErrUnexpectedEvent, msg.Event, t.Name, t.Version, err)
+		}
+	}
+
+	if event.Type != t.Name || event.Version != t.Version {
+		return Event[T]{}, fmt.Errorf("%w: got %s v%d, want %s v%d", ErrUnexpectedEvent, event.Type, event.Version, t.Name, t.Version)
+	}
+	return event, nil
+}
+
+// EventRegistry maps the type and version of serialized events to their payload struct.
+type EventRegistry struct {
+	mu       sync.RWMutex
+	decoders map[eventKey]func(raw Event[json.RawMessage]) (any, error)
+	latest   map[string]int
+}
+
+type eventKey struct {
+	name    string
+	version int
+}
+
+// DefaultEventRegistry holds the event catalog defined with DefineEvent.
+var DefaultEventRegistry = NewEventRegistry()
+
+func NewEventRegistry() *EventRegistry {
+	return &EventRegistry{
+		decoders: make(map[eventKey]func(raw Event[json.RawMessage]) (any, error)),
+		latest:   make(map[string]int),
+	}
+}
+
+// DefineEvent adds an event type to the default registry. It is meant for package level
+// variables and panics when the type and version are already defined.
+func DefineEvent[T any](name string, version int) EventType[T] {
+	return RegisterEvent[T](DefaultEventRegistry, name, version)
+}
+
+// RegisterEvent adds an event type to registry, panicking when the type and version are already
+// registered.
+func RegisterEvent[T any](registry *EventRegistry, name string, version int) EventType[T] {
+	eventType := EventType[T]{Name: name, Version: version}
+	key := eventKey{name: name, version: version}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, ok := registry.decoders[key]; ok {
+		panic(fmt.Sprintf("event %s v%d is already registered", name, version))
+	}
+	registry.decoders[key] = func(raw Event[json.RawMessage]) (any, error) {
+		return decodeEvent[T](raw)
+	}
+	if version > registry.latest[name] {
+		registry.latest[name] = version
+	}
+	return eventType
+}
+
+// Decode rebuilds an event from its JSON form.
+func (r *EventRegistry) Decode(data []byte) (any, error) {
+	var raw Event[json.RawMessage]
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("decoding event envelope: %w", err)
+	}
+	return r.Build(raw)
+}
+
+// Build decodes the payload of raw into the struct registered for its type and version. A zero
+// version stands for the latest registered one. Events of unknown types keep their payload as
+// plain JSON values, so objects become maps.
+func (r *EventRegistry) Build(raw Event[json.RawMessage]) (any, error) {
+	r.mu.RLock()
+	if raw.Version == 0 {
+		raw.Version = r.latest[raw.Type]
+	}
+	decode, ok := r.decoders[eventKey{name: raw.Type, version: raw.Version}]
+	r.mu.RUnlock()
+	if !ok {
+		decode = decodeEvent[any]
+	}
+
+	event, err := decode(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s v%d event payload: %w", raw.Type, raw.Version, err)
+	}
+	return event, nil
+}
+
+func decodeEvent[T any](raw Event[json.RawMessage]) (any, error) {
+	var payload T
+	if len(raw.Payload) > 0 {
+		if err := json.Unmarshal(raw.Payload, &payload); err != nil {
+			return nil, err
+		}
+	}
+
+	return Event[T]{
+		ID:         raw.ID,
+		Type:       raw.Type,
+		Version:    raw.Version,
+		OccurredAt: raw.OccurredAt,
+		TenantID:   raw.TenantID,
+		Payload:    payload,
+	}, nil
+}
+
+// TypedSubscription is a subscription narrowed to the events of a single type.
+type TypedSubscription[T any] struct {
+	Subscription
+	Type EventType[T]
+}
+
+// Subscribe subscribes to the events of eventType published on topics matching topic.
+func Subscribe[T any](broker InternalBroker, topic BrokerTopicName, eventType EventType[T]) (TypedSubscription[T], error) {
+	subscription, err := broker.Subscribe(topic, eventType.Name)
+	if err != nil {
+		return TypedSubscription[T]{}, err
+	}
+	return TypedSubscription[T]{Subscription: subscription, Type: eventType}, nil
+}
+
+// Decode returns the event carried by a message received from the subscription.
+func (s TypedSubscription[T]) Decode(msg BrokerMessage) (Event[T], error) {
+	return s.Type.Decode(msg)
+}
+
+// EventFields is the JSON form of an event, for consumers that address its fields by path.
+type EventFields map[string]any
+
+// NewEventFields returns the fields of the event carried by msg whatever its payload type.
+func NewEventFields(msg BrokerMessage) (EventFields, error) {
+	data, err := json.Marshal(msg.Value)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s event: %w", msg.Event, err)
+	}
+
+	var fields EventFields
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("%w: %s is not an event", ErrUnexpectedEvent, msg.Event)
+	}
+	return fields, nil
+}
+
+// Get resolves a dot separated path such as "tenant_id" or "payload.device_name". Paths not
+// found in the envelope are resolved against the payload, so "device_name" also finds
+// "payload.device_name".
+func (f EventFields) Get(path string) any {
+	if path == "" {
+		return nil
+	}
+	if value, ok := lookupField(f, path); ok {
+		return value
+	}
+	if value, ok := lookupField(f, "payload."+path); ok {
+		return value
+	}
+	return nil
+}
+
+// String returns the field at path formatted as a string, or "" when it is missing.
+func (f EventFields) String(path string) string {
+	switch value := f.Get(path).(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+// Float64 returns the numeric field at path, or 0 when it is missing or not a number.
+func (f EventFields) Float64(path string) float64 {
+	value, _ := f.Get(path).(float64)
+	return value
+}
+
+func lookupField(fields EventFields, path string) (any, bool) {
+	var current any = map[string]any(fields)
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		current, ok = object[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,165 @@
+package async_test
+
+import (
+	"context"
+	"encoding/json"
+	"zensor-server/internal/infra/async"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type commandAck struct {
+	CommandID string `json:"command_id"`
+	Attempts  int    `json:"attempts"`
+}
+
+var _ = Describe("Event", func() {
+	var (
+		registry *async.EventRegistry
+		ackV1    async.EventType[commandAck]
+		ackV2    async.EventType[map[string]any]
+	)
+
+	BeforeEach(func() {
+		registry = async.NewEventRegistry()
+		ackV1 = async.RegisterEvent[commandAck](registry, "command_ack", 1)
+		ackV2 = async.RegisterEvent[map[string]any](registry, "command_ack", 2)
+	})
+
+	Context("New", func() {
+		It("should fill the envelope", func() {
+			event := ackV1.New("tenant-1", commandAck{CommandID: "command-1"})
+
+			Expect(event.ID).NotTo(BeEmpty())
+			Expect(event.Type).To(Equal("command_ack"))
+			Expect(event.Version).To(Equal(1))
+			Expect(event.OccurredAt).NotTo(BeZero())
+			Expect(event.TenantID).To(Equal("tenant-1"))
+			Expect(event.Message()).To(HaveField("Event", "command_ack"))
+		})
+	})
+
+	Context("Decode", func() {
+		When("the message carries the event", func() {
+			It("should return it", func() {
+				event := ackV1.New("", commandAck{CommandID: "command-1"})
+
+				decoded, err := ackV1.Decode(event.Message())
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(decoded).To(Equal(event))
+			})
+		})
+
+		When("the message carries the JSON form of the event", func() {
+			It("should decode the payload struct", func() {
+				event := ackV1.New("", commandAck{CommandID: "command-1", Attempts: 2})
+				var value map[string]any
+				data, _ := json.Marshal(event)
+				Expect(json.Unmarshal(data, &value)).To(Succeed())
+
+				decoded, err := ackV1.Decode(async.BrokerMessage{Event: "command_ack", Value: value})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(decoded.Payload).To(Equal(commandAck{CommandID: "command-1", Attempts: 2}))
+			})
+		})
+
+		When("the message carries another version", func() {
+			It("should fail", func() {
+				msg := ackV2.New("", map[string]any{"command_id": "command-1"}).Message()
+
+				_, err := ackV1.Decode(msg)
+
+				Expect(err).To(MatchError(async.ErrUnexpectedEvent))
+			})
+		})
+
+		When("the message carries a value that is not an event", func() {
+			It("should fail", func() {
+				_, err := ackV1.Decode(async.BrokerMessage{Event: "command_ack", Value: "command-1"})
+
+				Expect(err).To(MatchError(async.ErrUnexpectedEvent))
+			})
+		})
+	})
+
+	Context("EventRegistry", func() {
+		It("should decode registered events into their payload struct", func() {
+			data, _ := json.Marshal(ackV1.New("tenant-1", commandAck{CommandID: "command-1"}))
+
+			event, err := registry.Decode(data)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event).To(HaveField("Payload", commandAck{CommandID: "command-1"}))
+		})
+
+		It("should use the latest version when the event has none", func() {
+			event, err := registry.Build(async.Event[json.RawMessage]{
+				Type:    "command_ack",
+				Payload: json.RawMessage(`{"command_id":"command-1"}`),
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event).To(BeAssignableToTypeOf(async.Event[map[string]any]{}))
+			Expect(event).To(HaveField("Version", 2))
+		})
+
+		It("should keep the payload of unknown events as JSON values", func() {
+			event, err := registry.Decode([]byte(`{"type":"device_offline","version":1,"payload":{"device_name":"device-1"}}`))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event).To(HaveField("Payload", map[string]any{"device_name": "device-1"}))
+		})
+
+		It("should panic when an event is registered twice", func() {
+			Expect(func() {
+				async.RegisterEvent[commandAck](registry, "command_ack", 1)
+			}).To(Panic())
+		})
+	})
+
+	Context("Subscribe", func() {
+		It("should only receive the events of its type", func() {
+			broker := async.NewLocalBroker()
+			subscription, err := async.Subscribe(broker, "commands", ackV1)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(broker.Publish(context.TODO(), "commands", async.BrokerMessage{Event: "command_sent"})).To(Succeed())
+			Expect(broker.Publish(context.TODO(), "commands", ackV1.New("", commandAck{CommandID: "command-1"}).Message())).To(Succeed())
+
+			var msg async.BrokerMessage
+			Eventually(subscription.Receiver).Should(Receive(&msg))
+			event, err := subscription.Decode(msg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(event.Payload.CommandID).To(Equal("command-1"))
+		})
+	})
+
+	Context("EventFields", func() {
+		var fields async.EventFields
+
+		BeforeEach(func() {
+			var err error
+			fields, err = async.NewEventFields(ackV1.New("tenant-1", commandAck{CommandID: "command-1", Attempts: 3}).Message())
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should resolve envelope and payload paths", func() {
+			Expect(fields.String("tenant_id")).To(Equal("tenant-1"))
+			Expect(fields.String("payload.command_id")).To(Equal("command-1"))
+			Expect(fields.Float64("payload.attempts")).To(Equal(3.0))
+		})
+
+		It("should fall back to the payload for paths missing in the envelope", func() {
+			Expect(fields.String("command_id")).To(Equal("command-1"))
+		})
+
+		It("should return zero values for missing paths", func() {
+			Expect(fields.Get("payload.missing")).To(BeNil())
+			Expect(fields.String("payload.command_id.nested")).To(BeEmpty())
+			Expect(fields.Float64("tenant_id")).To(BeZero())
+		})
+	})
+})
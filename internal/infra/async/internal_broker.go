@@ -4,16 +4,25 @@ package async
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"slices"
+	"strings"
 	"sync"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type BrokerTopicName string
 
 type BrokerMessage struct {
+	// ID identifies messages relayed from the outbox, which may be delivered more than once, so
+	// consumers can discard the ones they already processed. In-memory messages have no ID.
+	ID    string
 	Event string
 	Value any
 	Span  trace.Span
@@ -24,9 +33,15 @@ type InternalBrokerSubscriptor interface {
 	AddSubscription(b InternalBroker)
 }
 
+// InternalBroker delivers messages published on a topic to every subscription whose topic
+// matches it. Topics are hierarchical, with levels separated by "/"; subscriptions may use the
+// MQTT wildcards "+", which matches a single level, and "#", which matches every remaining level
+// and must be the last one. Subscriptions may also narrow the messages they receive to a set of
+// events.
+//
 //go:generate mockgen -source=internal_broker.go -destination=../../../test/unit/doubles/infra/async/internal_broker_mock.go -package=async -mock_names=InternalBroker=MockInternalBroker
 type InternalBroker interface {
-	Subscribe(topic BrokerTopicName) (Subscription, error)
+	Subscribe(topic BrokerTopicName, events ...string) (Subscription, error)
 	Unsubscribe(topic BrokerTopicName, subscription Subscription) error
 	Publish(ctx context.Context, topic BrokerTopicName, msg BrokerMessage) error
 	Stop()
@@ -39,21 +54,108 @@ var _ InternalBroker = (*LocalBroker)(nil)
 var (
 	ErrTopicNotFound       = errors.New("topic not found")
 	ErrSubscriptorNotFound = errors.New("subscriptor not found")
+	ErrInvalidTopic        = errors.New("invalid topic")
+	ErrInvalidBrokerConfig = errors.New("invalid broker config")
 )
 
+// OverflowPolicy decides what happens to a message published to a subscription whose queue is
+// full.
+type OverflowPolicy string
+
+const (
+	// OverflowBlock makes the publisher wait until the subscriber makes room, the subscription is
+	// closed or the publish context is done.
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest discards the oldest queued message to make room for the new one.
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+	// OverflowDropNewest discards the new message.
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+)
+
+const (
+	DefaultQueueCapacity = 256
+	// DefaultOverflowPolicy drops the oldest message so a slow subscriber never stalls the
+	// publishers, such as the uplink path, and keeps receiving the most recent state.
+	DefaultOverflowPolicy = OverflowDropOldest
+)
+
+// LocalBrokerConfig bounds the queue of every subscription. Zero values fall back to the
+// defaults.
+type LocalBrokerConfig struct {
+	QueueCapacity  int
+	OverflowPolicy OverflowPolicy
+}
+
+func (c LocalBrokerConfig) withDefaults() LocalBrokerConfig {
+	if c.QueueCapacity == 0 {
+		c.QueueCapacity = DefaultQueueCapacity
+	}
+	if c.OverflowPolicy == "" {
+		c.OverflowPolicy = DefaultOverflowPolicy
+	}
+	return c
+}
+
+func (c LocalBrokerConfig) Validate() error {
+	if c.QueueCapacity < 0 {
+		return fmt.Errorf("%w: queue capacity must not be negative", ErrInvalidBrokerConfig)
+	}
+	switch c.OverflowPolicy {
+	case "", OverflowBlock, OverflowDropOldest, OverflowDropNewest:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown overflow policy %q", ErrInvalidBrokerConfig, c.OverflowPolicy)
+	}
+}
+
+const (
+	_topicLevelSeparator = "/"
+	_singleLevelWildcard = "+"
+	_multiLevelWildcard  = "#"
+	_topicWildcards      = _singleLevelWildcard + _multiLevelWildcard
+)
+
+// NewLocalBroker creates a broker with the default queue capacity and overflow policy.
 func NewLocalBroker() *LocalBroker {
-	return &LocalBroker{
+	return newLocalBroker(LocalBrokerConfig{}.withDefaults())
+}
+
+func NewLocalBrokerWithConfig(config LocalBrokerConfig) (*LocalBroker, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return newLocalBroker(config.withDefaults()), nil
+}
+
+func newLocalBroker(config LocalBrokerConfig) *LocalBroker {
+	b := &LocalBroker{
 		subscriptors: sync.Map{},
+		config:       config,
 	}
+	b.initializeMetrics()
+	return b
 }
 
+// LocalBroker delivers messages in process. Every subscription has its own bounded queue, which
+// Publish fills synchronously so each subscriber sees the messages of a topic in the order they
+// were published.
 type LocalBroker struct {
+	// mu serializes the changes to the subscriptions of a topic; Publish reads them without it.
+	mu           sync.Mutex
 	subscriptors sync.Map
+	config       LocalBrokerConfig
+
+	droppedMessages     metric.Int64Counter
+	metricsRegistration metric.Registration
 }
 
 type subscriptor struct {
 	once         sync.Once
+	mu           sync.Mutex
 	active       bool
+	closed       chan struct{}
+	topic        BrokerTopicName
+	events       []string
 	subscription Subscription
 }
 
@@ -62,7 +164,13 @@ type Subscription struct {
 	Receiver chan BrokerMessage
 }
 
-func (b *LocalBroker) Subscribe(topic BrokerTopicName) (Subscription, error) {
+func (b *LocalBroker) Subscribe(topic BrokerTopicName, events ...string) (Subscription, error) {
+	if !validTopicFilter(topic) {
+		return Subscription{}, ErrInvalidTopic
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
 	value, ok := b.subscriptors.Load(topic)
 	var subscriptors []*subscriptor
 	if !ok {
@@ -74,14 +182,24 @@ func (b *LocalBroker) Subscribe(topic BrokerTopicName) (Subscription, error) {
 		}
 	}
 	id := uuid.NewString()
-	receiver := make(chan BrokerMessage)
+	receiver := make(chan BrokerMessage, b.config.QueueCapacity)
 	subscription := Subscription{ID: id, Receiver: receiver}
-	subscriptors = append(subscriptors, &subscriptor{subscription: subscription, active: true})
+	subscriptors = append(slices.Clip(subscriptors), &subscriptor{
+		subscription: subscription,
+		active:       true,
+		closed:       make(chan struct{}),
+		topic:        topic,
+		events:       events,
+	})
 	b.subscriptors.Store(topic, subscriptors)
 	return subscription, nil
 }
 
+// Unsubscribe closes the subscription and removes it from its topic.
 func (b *LocalBroker) Unsubscribe(topic BrokerTopicName, subscription Subscription) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
 	value, ok := b.subscriptors.Load(topic)
 	if !ok {
 		return ErrTopicNotFound
@@ -98,34 +216,100 @@ func (b *LocalBroker) Unsubscribe(topic BrokerTopicName, subscription Subscripti
 
 	subscriptors[index].safeClose()
 
+	// Publish may be ranging over the current slice, so the remaining subscriptions are stored in
+	// a new one. The topic is kept, so publishing to it still succeeds.
+	b.subscriptors.Store(topic, slices.Delete(slices.Clone(subscriptors), index, index+1))
+
 	return nil
 }
 
 func (b *LocalBroker) Publish(ctx context.Context, topic BrokerTopicName, msg BrokerMessage) error {
-	msg.Span = trace.SpanFromContext(ctx)
-	topicSubscriptors, ok := b.subscriptors.Load(topic)
-	if !ok {
-		return ErrTopicNotFound
+	if !validTopicName(topic) {
+		return ErrInvalidTopic
 	}
-	subscriptors, ok := topicSubscriptors.([]*subscriptor)
-	if !ok {
+	msg.Span = trace.SpanFromContext(ctx)
+
+	matched := false
+	var subscriptors []*subscriptor
+	b.subscriptors.Range(func(key, value any) bool {
+		filter, ok := key.(BrokerTopicName)
+		if !ok || !topicMatches(filter, topic) {
+			return true
+		}
+		topicSubscriptors, ok := value.([]*subscriptor)
+		if !ok {
+			return true
+		}
+		matched = true
+		for _, s := range topicSubscriptors {
+			if s.accepts(msg.Event) {
+				subscriptors = append(subscriptors, s)
+			}
+		}
+		return true
+	})
+	if !matched {
 		return ErrTopicNotFound
 	}
 
-	go b.publishToSubscriptors(subscriptors, msg)
+	for _, s := range subscriptors {
+		if err := b.enqueue(ctx, s, msg); err != nil {
+			return fmt.Errorf("delivering to subscription %s: %w", s.subscription.ID, err)
+		}
+	}
 
 	return nil
 }
 
-func (b *LocalBroker) publishToSubscriptors(topicSubscriptors []*subscriptor, msg BrokerMessage) {
-	for _, s := range topicSubscriptors {
-		if s.active {
-			s.subscription.Receiver <- msg
+// enqueue adds msg to the subscription queue, applying the overflow policy when it is full.
+func (b *LocalBroker) enqueue(ctx context.Context, s *subscriptor, msg BrokerMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.active {
+		return nil
+	}
+
+	select {
+	case s.subscription.Receiver <- msg:
+		return nil
+	default:
+	}
+
+	switch b.config.OverflowPolicy {
+	case OverflowDropNewest:
+		b.recordDrop(ctx, s)
+		return nil
+	case OverflowDropOldest:
+		for {
+			select {
+			case <-s.subscription.Receiver:
+				b.recordDrop(ctx, s)
+			default:
+			}
+			select {
+			case s.subscription.Receiver <- msg:
+				return nil
+			default:
+			}
+		}
+	default:
+		select {
+		case s.subscription.Receiver <- msg:
+			return nil
+		case <-s.closed:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
 
 func (b *LocalBroker) Stop() {
+	if b.metricsRegistration != nil {
+		if err := b.metricsRegistration.Unregister(); err != nil {
+			slog.Warn("unregistering broker metrics", slog.Any("error", err))
+		}
+	}
 	b.subscriptors.Range(func(key, value any) bool {
 		if subscriptors, ok := value.([]*subscriptor); ok {
 			for _, s := range subscriptors {
@@ -136,9 +320,134 @@ func (b *LocalBroker) Stop() {
 	})
 }
 
+// safeClose closes the subscription once. Publishers blocked on a full queue hold the lock, so
+// they are released through the closed channel before the lock is taken.
 func (s *subscriptor) safeClose() {
 	s.once.Do(func() {
+		close(s.closed)
+		s.mu.Lock()
+		defer s.mu.Unlock()
 		s.active = false
 		close(s.subscription.Receiver)
 	})
 }
+
+func (s *subscriptor) accepts(event string) bool {
+	return len(s.events) == 0 || slices.Contains(s.events, event)
+}
+
+// validTopicName reports whether topic can be published to: it has no empty levels and no
+// wildcards.
+func validTopicName(topic BrokerTopicName) bool {
+	for _, level := range strings.Split(string(topic), _topicLevelSeparator) {
+		if level == "" || strings.ContainsAny(level, _topicWildcards) {
+			return false
+		}
+	}
+	return true
+}
+
+// validTopicFilter reports whether topic can be subscribed to: wildcards take a whole level and
+// "#" is only allowed as the last one.
+func validTopicFilter(topic BrokerTopicName) bool {
+	levels := strings.Split(string(topic), _topicLevelSeparator)
+	for i, level := range levels {
+		switch {
+		case level == "":
+			return false
+		case level == _multiLevelWildcard:
+			if i != len(levels)-1 {
+				return false
+			}
+		case level == _singleLevelWildcard:
+		case strings.ContainsAny(level, _topicWildcards):
+			return false
+		}
+	}
+	return true
+}
+
+func topicMatches(filter, topic BrokerTopicName) bool {
+	if filter == topic {
+		return true
+	}
+	filterLevels := strings.Split(string(filter), _topicLevelSeparator)
+	topicLevels := strings.Split(string(topic), _topicLevelSeparator)
+	for i, level := range filterLevels {
+		if level == _multiLevelWildcard {
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if level != _singleLevelWildcard && level != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
+
+func (b *LocalBroker) initializeMetrics() {
+	meter := otel.Meter("internal-broker")
+
+	droppedMessages, err := meter.Int64Counter(
+		"zensor_server.broker.messages.dropped",
+		metric.WithDescription("Total number of messages dropped because a subscription queue was full"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		slog.Warn("creating broker dropped messages counter", slog.Any("error", err))
+		return
+	}
+
+	queueDepth, err := meter.Int64ObservableGauge(
+		"zensor_server.broker.queue.depth",
+		metric.WithDescription("Number of messages waiting in a subscription queue"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		slog.Warn("creating broker queue depth gauge", slog.Any("error", err))
+		return
+	}
+
+	registration, err := meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		b.subscriptors.Range(func(_, value any) bool {
+			subscriptors, ok := value.([]*subscriptor)
+			if !ok {
+				return true
+			}
+			for _, s := range subscriptors {
+				select {
+				case <-s.closed:
+				default:
+					observer.ObserveInt64(queueDepth, int64(len(s.subscription.Receiver)), metric.WithAttributes(s.attributes()...))
+				}
+			}
+			return true
+		})
+		return nil
+	}, queueDepth)
+	if err != nil {
+		slog.Warn("registering broker queue depth callback", slog.Any("error", err))
+		return
+	}
+
+	b.droppedMessages = droppedMessages
+	b.metricsRegistration = registration
+}
+
+func (b *LocalBroker) recordDrop(ctx context.Context, s *subscriptor) {
+	if b.droppedMessages == nil {
+		return
+	}
+
+	attributes := append(s.attributes(), attribute.String("policy", string(b.config.OverflowPolicy)))
+	b.droppedMessages.Add(ctx, 1, metric.WithAttributes(attributes...))
+}
+
+func (s *subscriptor) attributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("topic", string(s.topic)),
+		attribute.String("subscription_id", s.subscription.ID),
+	}
+}
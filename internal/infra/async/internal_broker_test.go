@@ -2,6 +2,7 @@ package async_test
 
 import (
 	"context"
+	"time"
 	"zensor-server/internal/infra/async"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -106,6 +107,182 @@ var _ = Describe("Local Broker", func() {
 		})
 	})
 
+	Context("Wildcards", func() {
+		When("subscribing with a single level wildcard", func() {
+			BeforeEach(func() {
+				subscription, _ = broker.Subscribe("devices/+/uplink")
+			})
+
+			It("should receive messages of every device on that level", func() {
+				Expect(broker.Publish(ctx, "devices/device-1/uplink", async.BrokerMessage{Event: "uplink"})).To(Succeed())
+
+				Eventually(subscription.Receiver).Should(Receive(HaveField("Event", "uplink")))
+			})
+
+			It("should not match other levels", func() {
+				Expect(broker.Publish(ctx, "devices/device-1/sensors/temperature", async.BrokerMessage{})).
+					To(MatchError(async.ErrTopicNotFound))
+				Expect(broker.Publish(ctx, "devices/device-1", async.BrokerMessage{})).
+					To(MatchError(async.ErrTopicNotFound))
+			})
+		})
+
+		When("subscribing with a multi level wildcard", func() {
+			BeforeEach(func() {
+				subscription, _ = broker.Subscribe("devices/device-1/#")
+			})
+
+			It("should receive every message below the topic", func() {
+				Expect(broker.Publish(ctx, "devices/device-1/sensors/temperature", async.BrokerMessage{Event: "temperature_data_received"})).To(Succeed())
+
+				Eventually(subscription.Receiver).Should(Receive(HaveField("Event", "temperature_data_received")))
+			})
+
+			It("should not receive messages of other devices", func() {
+				Expect(broker.Publish(ctx, "devices/device-2/uplink", async.BrokerMessage{})).
+					To(MatchError(async.ErrTopicNotFound))
+			})
+		})
+
+		When("the topic is not valid", func() {
+			It("should reject misplaced wildcards when subscribing", func() {
+				_, err := broker.Subscribe("devices/#/uplink")
+				Expect(err).To(MatchError(async.ErrInvalidTopic))
+
+				_, err = broker.Subscribe("devices/device+/uplink")
+				Expect(err).To(MatchError(async.ErrInvalidTopic))
+			})
+
+			It("should reject wildcards and empty levels when publishing", func() {
+				Expect(broker.Publish(ctx, "devices/+/uplink", async.BrokerMessage{})).To(MatchError(async.ErrInvalidTopic))
+				Expect(broker.Publish(ctx, "devices//uplink", async.BrokerMessage{})).To(MatchError(async.ErrInvalidTopic))
+			})
+		})
+	})
+
+	Context("Event filters", func() {
+		When("subscribing to a set of events", func() {
+			BeforeEach(func() {
+				subscription, _ = broker.Subscribe("devices/+/+", "command_sent", "command_status_update")
+			})
+
+			It("should only receive those events", func() {
+				Expect(broker.Publish(ctx, "devices/device-1/uplink", async.BrokerMessage{Event: "uplink"})).To(Succeed())
+				Expect(broker.Publish(ctx, "devices/device-1/command_sent", async.BrokerMessage{Event: "command_sent"})).To(Succeed())
+
+				Eventually(subscription.Receiver).Should(Receive(HaveField("Event", "command_sent")))
+				Consistently(subscription.Receiver).ShouldNot(Receive())
+			})
+		})
+	})
+
+	Context("Bounded queues", func() {
+		const topic = "devices/device-1/uplink"
+
+		newBroker := func(policy async.OverflowPolicy) *async.LocalBroker {
+			bounded, err := async.NewLocalBrokerWithConfig(async.LocalBrokerConfig{QueueCapacity: 2, OverflowPolicy: policy})
+			Expect(err).NotTo(HaveOccurred())
+			return bounded
+		}
+
+		publish := func(b *async.LocalBroker, events ...string) {
+			for _, event := range events {
+				Expect(b.Publish(ctx, topic, async.BrokerMessage{Event: event})).To(Succeed())
+			}
+		}
+
+		received := func(subscription async.Subscription) []string {
+			var events []string
+			for {
+				select {
+				case msg := <-subscription.Receiver:
+					events = append(events, msg.Event)
+				default:
+					return events
+				}
+			}
+		}
+
+		When("the subscriber keeps up", func() {
+			It("should deliver messages in the order they were published", func() {
+				bounded := newBroker(async.OverflowBlock)
+				subscription, _ = bounded.Subscribe(topic)
+
+				publish(bounded, "first", "second")
+
+				Expect(received(subscription)).To(Equal([]string{"first", "second"}))
+			})
+		})
+
+		When("the queue is full and the policy drops the oldest message", func() {
+			It("should keep the most recent messages", func() {
+				bounded := newBroker(async.OverflowDropOldest)
+				subscription, _ = bounded.Subscribe(topic)
+
+				publish(bounded, "first", "second", "third")
+
+				Expect(received(subscription)).To(Equal([]string{"second", "third"}))
+			})
+		})
+
+		When("the queue is full and the policy drops the newest message", func() {
+			It("should keep the queued messages", func() {
+				bounded := newBroker(async.OverflowDropNewest)
+				subscription, _ = bounded.Subscribe(topic)
+
+				publish(bounded, "first", "second", "third")
+
+				Expect(received(subscription)).To(Equal([]string{"first", "second"}))
+			})
+		})
+
+		When("the queue is full and the policy blocks", func() {
+			It("should wait until the publish context is done", func() {
+				bounded := newBroker(async.OverflowBlock)
+				subscription, _ = bounded.Subscribe(topic)
+				publish(bounded, "first", "second")
+
+				timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+				defer cancel()
+
+				Expect(bounded.Publish(timeout, topic, async.BrokerMessage{Event: "third"})).To(MatchError(context.DeadlineExceeded))
+			})
+
+			It("should release the publisher when the subscription is closed", func() {
+				bounded := newBroker(async.OverflowBlock)
+				subscription, _ = bounded.Subscribe(topic)
+				publish(bounded, "first", "second")
+
+				published := make(chan error, 1)
+				go func() { published <- bounded.Publish(ctx, topic, async.BrokerMessage{Event: "third"}) }()
+				Consistently(published).ShouldNot(Receive())
+
+				Expect(bounded.Unsubscribe(topic, subscription)).To(Succeed())
+				Eventually(published).Should(Receive(BeNil()))
+			})
+		})
+
+		When("the queue of a broker with the default config is full", func() {
+			It("should drop the oldest message instead of blocking the publisher", func() {
+				subscription, _ = broker.Subscribe(topic)
+				for i := 0; i <= async.DefaultQueueCapacity; i++ {
+					Expect(broker.Publish(ctx, topic, async.BrokerMessage{Value: i})).To(Succeed())
+				}
+
+				Expect(subscription.Receiver).To(HaveLen(async.DefaultQueueCapacity))
+				Expect(subscription.Receiver).To(Receive(HaveField("Value", 1)))
+			})
+		})
+
+		When("the overflow policy is unknown", func() {
+			It("should reject the config", func() {
+				_, err := async.NewLocalBrokerWithConfig(async.LocalBrokerConfig{OverflowPolicy: "discard"})
+
+				Expect(err).To(MatchError(async.ErrInvalidBrokerConfig))
+			})
+		})
+	})
+
 	Context("Unsubscribe", func() {
 		When("there is no subscriptor", func() {
 			BeforeEach(func() {
@@ -138,9 +315,11 @@ var _ = Describe("Local Broker", func() {
 			})
 		})
 		When("subscriptor does exists", func() {
+			var remaining async.Subscription
 			BeforeEach(func() {
 				topic = "b6541d7c-f455-446c-bea0-1e11bf9c76fc"
 				subscription, _ = broker.Subscribe(topic)
+				remaining, _ = broker.Subscribe(topic)
 				Expect(broker.Unsubscribe(topic, subscription)).To(Succeed())
 				message = async.BrokerMessage{
 					Event: "f20a4b57-95bc-4f2a-b3e6-7e36e05f1b23",
@@ -151,10 +330,12 @@ var _ = Describe("Local Broker", func() {
 			It("should not receive any message subscriptor", func() {
 				Expect(broker.Publish(context.TODO(), topic, message)).To(Succeed())
 
-				Eventually(subscription.Receiver).ShouldNot(Receive(And(
-					HaveField("Event", "f20a4b57-95bc-4f2a-b3e6-7e36e05f1b23"),
-					HaveField("Value", "a7024c11-3a52-4a29-9360-bc8e6a29ded1"),
-				)))
+				Eventually(remaining.Receiver).Should(Receive(HaveField("Event", message.Event)))
+				Expect(subscription.Receiver).To(BeClosed())
+			})
+
+			It("should remove it from the topic", func() {
+				Expect(broker.Unsubscribe(topic, subscription)).To(MatchError(async.ErrSubscriptorNotFound))
 			})
 		})
 		When("is called twice", func() {
@@ -164,10 +345,10 @@ var _ = Describe("Local Broker", func() {
 				Expect(broker.Unsubscribe(topic, subscription)).To(Succeed())
 			})
 
-			It("should remove subscriptor and don't panic", func() {
+			It("should not find the removed subscriptor and don't panic", func() {
 				err := broker.Unsubscribe(topic, subscription)
 
-				Expect(err).Should(Succeed())
+				Expect(err).Should(MatchError(async.ErrSubscriptorNotFound))
 			})
 		})
 	})
@@ -0,0 +1,372 @@
+package async
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// StreamClient is the subset of the Redis client used by RedisStreamBroker.
+type StreamClient interface {
+	XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd
+	XReadGroup(ctx context.Context, a *redis.XReadGroupArgs) *redis.XStreamSliceCmd
+	XAck(ctx context.Context, stream, group string, ids ...string) *redis.IntCmd
+	XGroupCreateMkStream(ctx context.Context, stream, group, start string) *redis.StatusCmd
+	XGroupDestroy(ctx context.Context, stream, group string) *redis.IntCmd
+	XInfoGroups(ctx context.Context, key string) *redis.XInfoGroupsCmd
+	XInfoConsumers(ctx context.Context, key string, group string) *redis.XInfoConsumersCmd
+}
+
+const (
+	DefaultBrokerStream           = "zensor_server:broker"
+	DefaultBrokerStreamMaxLen     = 10000
+	DefaultBrokerBlockTimeout     = 2 * time.Second
+	DefaultBrokerGroupIdleTimeout = time.Hour
+
+	_consumerGroupPrefix = "replica:"
+	_readBatchSize       = 100
+	_readRetryDelay      = time.Second
+	_groupCleanupTimeout = 5 * time.Second
+
+	_fieldTopic  = "topic"
+	_fieldEvent  = "event"
+	_fieldID     = "id"
+	_fieldValue  = "value"
+	_fieldError  = "error"
+	_fieldOrigin = "origin"
+	_fieldTrace  = "trace"
+)
+
+// RedisStreamBrokerConfig controls the stream shared by every replica. Consumer names the replica
+// and must be unique among the running ones. Zero values fall back to the defaults.
+type RedisStreamBrokerConfig struct {
+	Stream           string
+	Consumer         string
+	MaxLen           int64
+	BlockTimeout     time.Duration
+	GroupIdleTimeout time.Duration
+	Local            LocalBrokerConfig
+}
+
+func (c RedisStreamBrokerConfig) withDefaults() RedisStreamBrokerConfig {
+	if c.Stream == "" {
+		c.Stream = DefaultBrokerStream
+	}
+	if c.MaxLen == 0 {
+		c.MaxLen = DefaultBrokerStreamMaxLen
+	}
+	if c.BlockTimeout == 0 {
+		c.BlockTimeout = DefaultBrokerBlockTimeout
+	}
+	if c.GroupIdleTimeout == 0 {
+		c.GroupIdleTimeout = DefaultBrokerGroupIdleTimeout
+	}
+	c.Local = c.Local.withDefaults()
+	return c
+}
+
+func (c RedisStreamBrokerConfig) Validate() error {
+	if c.Consumer == "" {
+		return fmt.Errorf("%w: consumer is required", ErrInvalidBrokerConfig)
+	}
+	if c.MaxLen < 0 {
+		return fmt.Errorf("%w: stream max length must not be negative", ErrInvalidBrokerConfig)
+	}
+	return c.Local.Validate()
+}
+
+var _ InternalBroker = (*RedisStreamBroker)(nil)
+
+// RedisStreamBroker shares messages between replicas through a Redis stream. Every replica reads
+// the stream through its own consumer group, so each one sees every message, and hands them to
+// an embedded LocalBroker that applies the topic wildcards, event filters and queue bounds of its
+// subscriptions. Messages are delivered to the subscriptions of the publishing replica directly,
+// without waiting for the round trip through Redis.
+type RedisStreamBroker struct {
+	client   StreamClient
+	registry *EventRegistry
+	config   RedisStreamBrokerConfig
+	group    string
+	local    *LocalBroker
+
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// NewRedisStreamBroker joins the stream through the consumer group of config.Consumer and starts
+// reading it. Events are serialized as JSON and rebuilt through registry. Consumer groups of
+// replicas idle for longer than the group idle timeout are removed.
+func NewRedisStreamBroker(client StreamClient, registry *EventRegistry, config RedisStreamBrokerConfig) (*RedisStreamBroker, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	config = config.withDefaults()
+
+	b := &RedisStreamBroker{
+		client:   client,
+		registry: registry,
+		config:   config,
+		group:    _consumerGroupPrefix + config.Consumer,
+		local:    newLocalBroker(config.Local),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := b.createGroup(ctx); err != nil {
+		cancel()
+		b.local.Stop()
+		return nil, err
+	}
+	b.removeIdleGroups(ctx)
+
+	b.cancel = cancel
+	b.wg.Add(1)
+	go b.read(ctx)
+
+	slog.Info("redis stream broker started",
+		slog.String("stream", config.Stream),
+		slog.String("group", b.group))
+
+	return b, nil
+}
+
+func (b *RedisStreamBroker) Subscribe(topic BrokerTopicName, events ...string) (Subscription, error) {
+	return b.local.Subscribe(topic, events...)
+}
+
+func (b *RedisStreamBroker) Unsubscribe(topic BrokerTopicName, subscription Subscription) error {
+	return b.local.Unsubscribe(topic, subscription)
+}
+
+// Publish delivers msg to the subscriptions of this replica and appends it to the stream for the
+// others. Unlike LocalBroker it does not fail when this replica has no matching subscription,
+// since another replica may have one.
+func (b *RedisStreamBroker) Publish(ctx context.Context, topic BrokerTopicName, msg BrokerMessage) error {
+	if !validTopicName(topic) {
+		return ErrInvalidTopic
+	}
+
+	value, err := json.Marshal(msg.Value)
+	if err != nil {
+		return fmt.Errorf("encoding %s event: %w", msg.Event, err)
+	}
+
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	trace, err := json.Marshal(carrier)
+	if err != nil {
+		return fmt.Errorf("encoding trace context: %w", err)
+	}
+
+	values := map[string]any{
+		_fieldTopic:  string(topic),
+		_fieldEvent:  msg.Event,
+		_fieldID:     msg.ID,
+		_fieldValue:  value,
+		_fieldOrigin: b.config.Consumer,
+		_fieldTrace:  trace,
+	}
+	if msg.Error != nil {
+		values[_fieldError] = msg.Error.Error()
+	}
+
+	if err := b.local.Publish(ctx, topic, msg); err != nil && !errors.Is(err, ErrTopicNotFound) {
+		return err
+	}
+
+	err = b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: b.config.Stream,
+		MaxLen: b.config.MaxLen,
+		Approx: true,
+		Values: values,
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("appending to stream %s: %w", b.config.Stream, err)
+	}
+
+	return nil
+}
+
+// Stop stops reading the stream, removes the consumer group of this replica and closes every
+// subscription.
+func (b *RedisStreamBroker) Stop() {
+	b.stopOnce.Do(func() {
+		b.cancel()
+		b.wg.Wait()
+
+		ctx, cancel := context.WithTimeout(context.Background(), _groupCleanupTimeout)
+		defer cancel()
+		if err := b.client.XGroupDestroy(ctx, b.config.Stream, b.group).Err(); err != nil {
+			slog.Warn("removing broker consumer group", slog.String("group", b.group), slog.Any("error", err))
+		}
+
+		b.local.Stop()
+	})
+}
+
+func (b *RedisStreamBroker) read(ctx context.Context) {
+	defer b.wg.Done()
+
+	for {
+		streams, err := b.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    b.group,
+			Consumer: b.config.Consumer,
+			Streams:  []string{b.config.Stream, ">"},
+			Count:    _readBatchSize,
+			Block:    b.config.BlockTimeout,
+		}).Result()
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			b.recoverFromReadError(ctx, err)
+			continue
+		}
+
+		for _, stream := range streams {
+			ids := make([]string, 0, len(stream.Messages))
+			for _, message := range stream.Messages {
+				b.deliver(ctx, message)
+				ids = append(ids, message.ID)
+			}
+			if len(ids) == 0 {
+				continue
+			}
+			if err := b.client.XAck(ctx, b.config.Stream, b.group, ids...).Err(); err != nil && ctx.Err() == nil {
+				slog.Error("acknowledging broker messages", slog.String("group", b.group), slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// recoverFromReadError waits before the next read and recreates the consumer group when another
+// replica removed it while this one looked idle.
+func (b *RedisStreamBroker) recoverFromReadError(ctx context.Context, err error) {
+	slog.Error("reading broker stream", slog.String("stream", b.config.Stream), slog.Any("error", err))
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(_readRetryDelay):
+	}
+
+	if strings.HasPrefix(err.Error(), "NOGROUP") {
+		if err := b.createGroup(ctx); err != nil {
+			slog.Error("recreating broker consumer group", slog.String("group", b.group), slog.Any("error", err))
+		}
+	}
+}
+
+func (b *RedisStreamBroker) deliver(ctx context.Context, message redis.XMessage) {
+	origin, _ := message.Values[_fieldOrigin].(string)
+	if origin == b.config.Consumer {
+		return
+	}
+
+	topic, _ := message.Values[_fieldTopic].(string)
+	event, _ := message.Values[_fieldEvent].(string)
+	id, _ := message.Values[_fieldID].(string)
+	data, _ := message.Values[_fieldValue].(string)
+
+	var value any
+	var err error
+	if data != "null" {
+		value, err = b.registry.Decode([]byte(data))
+	}
+	if err != nil {
+		slog.Error("decoding broker message",
+			slog.String("message_id", message.ID),
+			slog.String("topic", topic),
+			slog.Any("error", err))
+		return
+	}
+
+	msg := BrokerMessage{
+		ID:    id,
+		Event: event,
+		Value: value,
+	}
+	if text, ok := message.Values[_fieldError].(string); ok {
+		msg.Error = errors.New(text)
+	}
+
+	msgCtx := ctx
+	if trace, ok := message.Values[_fieldTrace].(string); ok {
+		carrier := propagation.MapCarrier{}
+		if err := json.Unmarshal([]byte(trace), &carrier); err == nil {
+			msgCtx = otel.GetTextMapPropagator().Extract(ctx, carrier)
+		}
+	}
+
+	if err := b.local.Publish(msgCtx, BrokerTopicName(topic), msg); err != nil && !errors.Is(err, ErrTopicNotFound) {
+		slog.Error("delivering broker message",
+			slog.String("message_id", message.ID),
+			slog.String("topic", topic),
+			slog.Any("error", err))
+	}
+}
+
+// createGroup joins the stream from its end: replicas only receive messages published after they
+// start.
+func (b *RedisStreamBroker) createGroup(ctx context.Context) error {
+	err := b.client.XGroupCreateMkStream(ctx, b.config.Stream, b.group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("creating consumer group %s: %w", b.group, err)
+	}
+	return nil
+}
+
+// removeIdleGroups removes the consumer groups left behind by replicas that stopped without
+// removing their own, which would otherwise keep tracking unacknowledged messages forever.
+func (b *RedisStreamBroker) removeIdleGroups(ctx context.Context) {
+	groups, err := b.client.XInfoGroups(ctx, b.config.Stream).Result()
+	if err != nil {
+		slog.Warn("listing broker consumer groups", slog.Any("error", err))
+		return
+	}
+
+	for _, group := range groups {
+		if group.Name == b.group || !strings.HasPrefix(group.Name, _consumerGroupPrefix) {
+			continue
+		}
+		consumers, err := b.client.XInfoConsumers(ctx, b.config.Stream, group.Name).Result()
+		if err != nil {
+			slog.Warn("listing broker consumers", slog.String("group", group.Name), slog.Any("error", err))
+			continue
+		}
+		if !allIdle(consumers, b.config.GroupIdleTimeout) {
+			continue
+		}
+		if err := b.client.XGroupDestroy(ctx, b.config.Stream, group.Name).Err(); err != nil {
+			slog.Warn("removing idle broker consumer group", slog.String("group", group.Name), slog.Any("error", err))
+			continue
+		}
+		slog.Info("removed idle broker consumer group", slog.String("group", group.Name))
+	}
+}
+
+// allIdle reports whether every consumer of a group has been idle for timeout. Groups without
+// consumers belong to replicas that have not read yet, so they are not considered idle.
+func allIdle(consumers []redis.XInfoConsumer, timeout time.Duration) bool {
+	if len(consumers) == 0 {
+		return false
+	}
+	for _, consumer := range consumers {
+		if consumer.Idle < timeout {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package async_test
+
+import (
+	"context"
+	"errors"
+	"time"
+	"zensor-server/internal/infra/async"
+
+	"github.com/alicebob/miniredis/v2"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/redis/go-redis/v9"
+)
+
+type sensorReading struct {
+	DeviceName string  `json:"device_name"`
+	Value      float64 `json:"value"`
+}
+
+var _ = Describe("Redis Stream Broker", func() {
+	var (
+		server   *miniredis.Miniredis
+		client   *redis.Client
+		registry *async.EventRegistry
+		reading  async.EventType[sensorReading]
+		replicaA *async.RedisStreamBroker
+		replicaB *async.RedisStreamBroker
+		ctx      context.Context
+	)
+
+	newReplica := func(consumer string) *async.RedisStreamBroker {
+		broker, err := async.NewRedisStreamBroker(client, registry, async.RedisStreamBrokerConfig{
+			Consumer:     consumer,
+			BlockTimeout: 50 * time.Millisecond,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return broker
+	}
+
+	BeforeEach(func() {
+		server = miniredis.RunT(GinkgoT())
+		client = redis.NewClient(&redis.Options{Addr: server.Addr()})
+		registry = async.NewEventRegistry()
+		reading = async.RegisterEvent[sensorReading](registry, "sensor_data_received", 1)
+		ctx = context.Background()
+
+		replicaA = newReplica("replica-a")
+		replicaB = newReplica("replica-b")
+	})
+
+	AfterEach(func() {
+		replicaA.Stop()
+		replicaB.Stop()
+		Expect(client.Close()).To(Succeed())
+	})
+
+	When("a message is published on another replica", func() {
+		It("should be delivered with its registered type", func() {
+			subscription, err := replicaB.Subscribe("devices/+/sensors/#")
+			Expect(err).NotTo(HaveOccurred())
+
+			event := reading.New("tenant-1", sensorReading{DeviceName: "device-1", Value: 21.5})
+			Expect(replicaA.Publish(ctx, "devices/device-1/sensors/temperature", event.Message())).To(Succeed())
+
+			Eventually(subscription.Receiver).Should(Receive(And(
+				HaveField("Event", "sensor_data_received"),
+				HaveField("Value", event),
+			)))
+		})
+
+		It("should decode values of unregistered events into maps", func() {
+			subscription, err := replicaB.Subscribe("device_connectivity", "device_offline")
+			Expect(err).NotTo(HaveOccurred())
+
+			offline := async.EventType[map[string]any]{Name: "device_offline", Version: 1}
+			msg := offline.New("", map[string]any{"device_name": "device-1"}).Message()
+			msg.Error = errors.New("no uplinks")
+			Expect(replicaA.Publish(ctx, "device_connectivity", msg)).To(Succeed())
+
+			Eventually(subscription.Receiver).Should(Receive(And(
+				HaveField("Value", HaveField("Payload", map[string]any{"device_name": "device-1"})),
+				HaveField("Error", MatchError("no uplinks")),
+			)))
+		})
+	})
+
+	When("a message is published on the same replica", func() {
+		It("should be delivered once, without going through the stream", func() {
+			subscription, err := replicaA.Subscribe("devices/+/uplink")
+			Expect(err).NotTo(HaveOccurred())
+
+			event := reading.New("", sensorReading{DeviceName: "device-1"})
+			Expect(replicaA.Publish(ctx, "devices/device-1/uplink", event.Message())).To(Succeed())
+
+			Eventually(subscription.Receiver).Should(Receive(HaveField("Value", event)))
+			Consistently(subscription.Receiver, 200*time.Millisecond).ShouldNot(Receive())
+		})
+	})
+
+	When("no replica subscribed to the topic", func() {
+		It("should still append the message to the stream", func() {
+			Expect(replicaA.Publish(ctx, "scheduled_tasks", async.BrokerMessage{Event: "task_executed"})).To(Succeed())
+
+			Expect(client.XLen(ctx, async.DefaultBrokerStream).Val()).To(Equal(int64(1)))
+		})
+	})
+
+	When("the topic has wildcards", func() {
+		It("should reject the publication", func() {
+			err := replicaA.Publish(ctx, "devices/+/uplink", async.BrokerMessage{})
+
+			Expect(err).To(MatchError(async.ErrInvalidTopic))
+		})
+	})
+
+	When("a replica stops", func() {
+		It("should remove its consumer group and close its subscriptions", func() {
+			subscription, err := replicaB.Subscribe("scheduled_tasks")
+			Expect(err).NotTo(HaveOccurred())
+
+			replicaB.Stop()
+
+			Eventually(subscription.Receiver).Should(BeClosed())
+			groups, err := client.XInfoGroups(ctx, async.DefaultBrokerStream).Result()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(groups).To(ConsistOf(HaveField("Name", "replica:replica-a")))
+		})
+	})
+
+	When("the consumer is missing", func() {
+		It("should reject the config", func() {
+			_, err := async.NewRedisStreamBroker(client, registry, async.RedisStreamBrokerConfig{})
+
+			Expect(err).To(MatchError(async.ErrInvalidBrokerConfig))
+		})
+	})
+})
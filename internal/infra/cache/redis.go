@@ -80,6 +80,31 @@ func NewRedisCache(config *RedisConfig) (*RedisCache, error) {
 		config = DefaultRedisConfig()
 	}
 
+	client, err := Connect(config)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &RedisCache{
+		client: NewRedisClient(client),
+		config: config,
+	}
+
+	slog.Info("Redis cache initialized",
+		slog.String("addr", config.Addr),
+		slog.Int("db", config.DB),
+		slog.Int("pool_size", config.PoolSize))
+
+	return cache, nil
+}
+
+// Connect opens a Redis connection pool and checks the server is reachable. Other Redis backed
+// components share the cache connection settings through it.
+func Connect(config *RedisConfig) (*redis.Client, error) {
+	if config == nil {
+		config = DefaultRedisConfig()
+	}
+
 	client := redis.NewClient(&redis.Options{
 		Addr:         config.Addr,
 		Password:     config.Password,
@@ -100,17 +125,7 @@ func NewRedisCache(config *RedisConfig) (*RedisCache, error) {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	cache := &RedisCache{
-		client: NewRedisClient(client),
-		config: config,
-	}
-
-	slog.Info("Redis cache initialized",
-		slog.String("addr", config.Addr),
-		slog.Int("db", config.DB),
-		slog.Int("pool_size", config.PoolSize))
-
-	return cache, nil
+	return client, nil
 }
 
 // Get retrieves a value from the cache.
@@ -16,6 +16,8 @@ type CacheClient interface {
 	Del(ctx context.Context, keys ...string) *redis.IntCmd
 	Keys(ctx context.Context, pattern string) *redis.StringSliceCmd
 	Ping(ctx context.Context) *redis.StatusCmd
+	SetNX(ctx context.Context, key string, value any, expiration time.Duration) *redis.BoolCmd
+	Eval(ctx context.Context, script string, keys []string, args ...any) *redis.Cmd
 }
 
 // RedisClient wraps the redis.Client to implement CacheClient.
@@ -47,3 +49,11 @@ func (r *RedisClient) Keys(ctx context.Context, pattern string) *redis.StringSli
 func (r *RedisClient) Ping(ctx context.Context) *redis.StatusCmd {
 	return r.client.Ping(ctx)
 }
+
+func (r *RedisClient) SetNX(ctx context.Context, key string, value any, expiration time.Duration) *redis.BoolCmd {
+	return r.client.SetNX(ctx, key, value, expiration)
+}
+
+func (r *RedisClient) Eval(ctx context.Context, script string, keys []string, args ...any) *redis.Cmd {
+	return r.client.Eval(ctx, script, keys, args...)
+}
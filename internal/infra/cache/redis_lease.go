@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	_renewLeaseScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`
+	_releaseLeaseScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`
+)
+
+// RedisLease is a time-bounded exclusive lease stored under a single Redis key. Only the holder
+// that acquired the lease can renew or release it.
+type RedisLease struct {
+	client CacheClient
+	key    string
+}
+
+// NewRedisLease creates a lease stored under key.
+func NewRedisLease(client CacheClient, key string) *RedisLease {
+	return &RedisLease{
+		client: client,
+		key:    key,
+	}
+}
+
+// Lease returns a lease sharing the connection pool of the cache.
+func (c *RedisCache) Lease(key string) *RedisLease {
+	return NewRedisLease(c.client, key)
+}
+
+// Acquire takes the lease for holder, or extends it when holder already owns it. It reports
+// whether holder owns the lease for the next ttl.
+func (l *RedisLease) Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	acquired, err := l.client.SetNX(ctx, l.key, holder, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("acquiring lease %s: %w", l.key, err)
+	}
+	if acquired {
+		return true, nil
+	}
+
+	renewed, err := l.client.Eval(ctx, _renewLeaseScript, []string{l.key}, holder, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("renewing lease %s: %w", l.key, err)
+	}
+
+	return renewed == 1, nil
+}
+
+// Release gives up the lease if holder owns it, so another holder can take it without waiting
+// for it to expire.
+func (l *RedisLease) Release(ctx context.Context, holder string) error {
+	err := l.client.Eval(ctx, _releaseLeaseScript, []string{l.key}, holder).Err()
+	if err != nil {
+		return fmt.Errorf("releasing lease %s: %w", l.key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package cache_test
+
+import (
+	"context"
+	"time"
+	"zensor-server/internal/infra/cache"
+
+	mockcache "zensor-server/test/unit/doubles/infra/cache"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = ginkgo.Describe("RedisLease", func() {
+	var (
+		lease           *cache.RedisLease
+		mockCacheClient *mockcache.MockCacheClient
+		ctrl            *gomock.Controller
+		ctx             context.Context
+	)
+
+	ginkgo.BeforeEach(func() {
+		ctrl = gomock.NewController(ginkgo.GinkgoT())
+		mockCacheClient = mockcache.NewMockCacheClient(ctrl)
+		lease = cache.NewRedisLease(mockCacheClient, "leader")
+		ctx = context.Background()
+	})
+
+	ginkgo.AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	ginkgo.Context("Acquire", func() {
+		ginkgo.When("the lease is free", func() {
+			ginkgo.It("should take it", func() {
+				mockCacheClient.EXPECT().SetNX(ctx, "leader", "replica-1", 15*time.Second).
+					Return(redis.NewBoolResult(true, nil))
+
+				acquired, err := lease.Acquire(ctx, "replica-1", 15*time.Second)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(acquired).To(gomega.BeTrue())
+			})
+		})
+
+		ginkgo.When("the lease is held by the same holder", func() {
+			ginkgo.It("should renew it", func() {
+				mockCacheClient.EXPECT().SetNX(ctx, "leader", "replica-1", 15*time.Second).
+					Return(redis.NewBoolResult(false, nil))
+				mockCacheClient.EXPECT().Eval(ctx, gomock.Any(), []string{"leader"}, "replica-1", int64(15000)).
+					Return(redis.NewCmdResult(int64(1), nil))
+
+				acquired, err := lease.Acquire(ctx, "replica-1", 15*time.Second)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(acquired).To(gomega.BeTrue())
+			})
+		})
+
+		ginkgo.When("the lease is held by another holder", func() {
+			ginkgo.It("should not take it", func() {
+				mockCacheClient.EXPECT().SetNX(ctx, "leader", "replica-2", 15*time.Second).
+					Return(redis.NewBoolResult(false, nil))
+				mockCacheClient.EXPECT().Eval(ctx, gomock.Any(), []string{"leader"}, "replica-2", int64(15000)).
+					Return(redis.NewCmdResult(int64(0), nil))
+
+				acquired, err := lease.Acquire(ctx, "replica-2", 15*time.Second)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(acquired).To(gomega.BeFalse())
+			})
+		})
+	})
+})
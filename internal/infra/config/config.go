@@ -2,7 +2,9 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
 	"sync"
 	"time"
@@ -35,12 +37,10 @@ func LoadConfig() AppConfig {
 			mqtt: MqttConfig{
 				Broker: viper.GetString("mqtt.broker"),
 			},
-			MQTTClient: MQTTClientConfig{
-				Broker:   viper.GetString("mqtt_client.broker"),
-				ClientID: viper.GetString("mqtt_client.client_id"),
-				Username: viper.GetString("mqtt_client.username"),
-				Password: viper.GetString("mqtt_client.password"),
-			},
+			MQTTClient: loadMQTTClientConfig(),
+			MQTTBroker: loadMQTTBrokerConfig(),
+			MQTTBridge: loadMQTTBridgeConfig(),
+			Simulator:  loadSimulatorConfig(),
 			Postgresql: PostgresqlConfig{
 				DSN:          viper.GetString("database.dsn"),
 				QueryTimeout: viper.GetDuration("database.query_timeout"),
@@ -73,6 +73,16 @@ func LoadConfig() AppConfig {
 			ExecutionWorker: ExecutionWorkerConfig{
 				TickerInterval: viper.GetDuration("execution_worker.ticker_interval"),
 			},
+			Broker:         loadBrokerConfig(),
+			Outbox:         loadOutboxConfig(),
+			Webhooks:       loadWebhooksConfig(),
+			Health:         loadHealthConfig(),
+			LeaderElection: loadLeaderElectionConfig(),
+			TTN:            loadTTNConfig(),
+			Secrets: SecretsConfig{
+				PrimaryKeyID: viper.GetString("secrets.primary_key_id"),
+				Keys:         viper.GetString("secrets.keys"),
+			},
 		}
 	})
 
@@ -124,6 +134,7 @@ func loadVictronConfig() VictronConfig {
 		ClientID: viper.GetString("victron.mqtt.client_id"),
 		Username: viper.GetString("victron.mqtt.username"),
 		Password: viper.GetString("victron.mqtt.password"),
+		TLS:      loadMQTTTLSConfig("victron.mqtt.tls"),
 	}
 	return VictronConfig{
 		Enabled:  viper.GetBool("modules.victron.enabled"),
@@ -154,7 +165,7 @@ func loadMetricsConfig() []MetricWorkerConfig {
 						}
 					}
 				}
-				metrics = append(metrics, metric)
+				metrics = append(metrics, withoutDeprecatedMetricNames(metric))
 			}
 		}
 		return metrics
@@ -162,6 +173,64 @@ func loadMetricsConfig() []MetricWorkerConfig {
 	return []MetricWorkerConfig{}
 }
 
+const _sensorDataReceivedEvent = "sensor_data_received"
+
+// _deprecatedMetricEventTypes and _deprecatedMetricPaths map the event types and value paths
+// metrics were configured with before events got a typed catalog to their current names. Sensor
+// readings were also published as "{sensor}_data_received".
+var (
+	_deprecatedMetricEventTypes = map[string]string{
+		"task_executed": "scheduled_task_executed",
+	}
+	_deprecatedMetricPaths = map[string]string{
+		"DeviceName": "device_name",
+		"AppID":      "app_id",
+		"Value":      "value",
+		"Index":      "index",
+	}
+)
+
+// withoutDeprecatedMetricNames reads the deprecated event types and value paths of the metric as
+// their current names, warning so the configuration gets updated.
+func withoutDeprecatedMetricNames(metric MetricWorkerConfig) MetricWorkerConfig {
+	if current, deprecated := deprecatedMetricEventType(metric.EventType); deprecated {
+		warnDeprecatedMetricName(metric.Name, "event_type", metric.EventType, current)
+		metric.EventType = current
+	}
+
+	if current, deprecated := _deprecatedMetricPaths[metric.ValuePropertyName]; deprecated {
+		warnDeprecatedMetricName(metric.Name, "value_property_name", metric.ValuePropertyName, current)
+		metric.ValuePropertyName = current
+	}
+
+	for label, path := range metric.CustomAttributes {
+		if current, deprecated := _deprecatedMetricPaths[path]; deprecated {
+			warnDeprecatedMetricName(metric.Name, "custom_attributes."+label, path, current)
+			metric.CustomAttributes[label] = current
+		}
+	}
+
+	return metric
+}
+
+func deprecatedMetricEventType(eventType string) (string, bool) {
+	if current, deprecated := _deprecatedMetricEventTypes[eventType]; deprecated {
+		return current, true
+	}
+	if eventType != _sensorDataReceivedEvent && strings.HasSuffix(eventType, "_data_received") {
+		return _sensorDataReceivedEvent, true
+	}
+	return "", false
+}
+
+func warnDeprecatedMetricName(metric, key, deprecated, current string) {
+	slog.Warn("deprecated metric configuration value, use the current name",
+		slog.String("metric", metric),
+		slog.String("key", key),
+		slog.String("deprecated", deprecated),
+		slog.String("current", current))
+}
+
 func loadPushNotificationsConfig() []PushNotificationWorkerConfig {
 	notificationsInterface := viper.Get("push_notifications")
 	if notificationsSlice, ok := notificationsInterface.([]interface{}); ok {
@@ -199,6 +268,234 @@ func loadVictoriaMetricsConfig() VictoriaMetricsConfig {
 	}
 }
 
+func loadLeaderElectionConfig() LeaderElectionConfig {
+	leaseKey := viper.GetString("leader_election.lease_key")
+	if leaseKey == "" {
+		leaseKey = "zensor_server:leader:workers"
+	}
+
+	return LeaderElectionConfig{
+		Enabled:  viper.GetBool("leader_election.enabled"),
+		LeaseKey: leaseKey,
+		LeaseTTL: viper.GetDuration("leader_election.lease_ttl"),
+	}
+}
+
+func loadMQTTClientConfig() MQTTClientConfig {
+	config := MQTTClientConfig{
+		Broker:            viper.GetString("mqtt_client.broker"),
+		ClientID:          viper.GetString("mqtt_client.client_id"),
+		Username:          viper.GetString("mqtt_client.username"),
+		Password:          viper.GetString("mqtt_client.password"),
+		PublishQoS:        loadQoS("mqtt_client.publish_qos"),
+		SubscribeQoS:      loadQoS("mqtt_client.subscribe_qos"),
+		PersistentSession: viper.GetBool("mqtt_client.persistent_session"),
+		QueueCapacity:     viper.GetInt("mqtt_client.queue_capacity"),
+		QueueDir:          viper.GetString("mqtt_client.queue_dir"),
+		TLS:               loadMQTTTLSConfig("mqtt_client.tls"),
+	}
+	if !viper.IsSet("mqtt_client.queue_capacity") {
+		config.QueueCapacity = 1000
+	}
+
+	return config
+}
+
+func loadMQTTTLSConfig(prefix string) MQTTTLSConfig {
+	return MQTTTLSConfig{
+		CAFile:             viper.GetString(prefix + ".ca_file"),
+		CertFile:           viper.GetString(prefix + ".cert_file"),
+		KeyFile:            viper.GetString(prefix + ".key_file"),
+		ServerName:         viper.GetString(prefix + ".server_name"),
+		InsecureSkipVerify: viper.GetBool(prefix + ".insecure_skip_verify"),
+	}
+}
+
+func loadMQTTBrokerConfig() MQTTBrokerConfig {
+	config := MQTTBrokerConfig{
+		Enabled:  viper.GetBool("mqtt_broker.enabled"),
+		Address:  viper.GetString("mqtt_broker.address"),
+		Username: viper.GetString("mqtt_broker.username"),
+		Password: viper.GetString("mqtt_broker.password"),
+	}
+	if config.Address == "" {
+		config.Address = "127.0.0.1:1883"
+	}
+
+	return config
+}
+
+func loadMQTTBridgeConfig() MQTTBridgeConfig {
+	config := MQTTBridgeConfig{
+		Enabled:      viper.GetBool("mqtt_bridge.enabled"),
+		Broker:       viper.GetString("mqtt_bridge.broker"),
+		ClientID:     viper.GetString("mqtt_bridge.client_id"),
+		Username:     viper.GetString("mqtt_bridge.username"),
+		Password:     viper.GetString("mqtt_bridge.password"),
+		QoS:          loadQoS("mqtt_bridge.qos"),
+		TLS:          loadMQTTTLSConfig("mqtt_bridge.tls"),
+		SensorTopic:  viper.GetString("mqtt_bridge.sensor_topic"),
+		CommandTopic: viper.GetString("mqtt_bridge.command_topic"),
+		Commands: MQTTBridgeCommandsConfig{
+			Enabled: viper.GetBool("mqtt_bridge.commands.enabled"),
+			Sensors: viper.GetStringSlice("mqtt_bridge.commands.sensors"),
+		},
+	}
+	if config.Enabled && config.Broker == "" {
+		panic(errors.New("fatal error config file: mqtt_bridge.broker is required when mqtt_bridge.enabled"))
+	}
+	if config.Enabled && config.Commands.Enabled && config.Username == "" {
+		panic(errors.New("fatal error config file: mqtt_bridge.username is required when mqtt_bridge.commands.enabled"))
+	}
+	if config.ClientID == "" {
+		config.ClientID = "zensor_bridge"
+	}
+	if !viper.IsSet("mqtt_bridge.qos") {
+		config.QoS = 1
+	}
+	if config.SensorTopic == "" {
+		config.SensorTopic = "zensor/{tenant}/{device}/{sensor}/{index}"
+	}
+	if config.CommandTopic == "" {
+		config.CommandTopic = "zensor/{tenant}/{device}/commands/{index}"
+	}
+	if !viper.IsSet("mqtt_bridge.commands.sensors") {
+		config.Commands.Sensors = []string{"relay"}
+	}
+
+	return config
+}
+
+func loadSimulatorConfig() SimulatorConfig {
+	config := SimulatorConfig{
+		Enabled:        viper.GetBool("simulator.enabled"),
+		Devices:        viper.GetStringSlice("simulator.devices"),
+		UplinkInterval: viper.GetDuration("simulator.uplink_interval"),
+	}
+	if config.UplinkInterval <= 0 {
+		config.UplinkInterval = time.Minute
+	}
+
+	return config
+}
+
+func loadQoS(key string) byte {
+	qos := viper.GetUint(key)
+	if qos > 2 {
+		panic(fmt.Errorf("fatal error config file: %s must be 0, 1 or 2, got %d", key, qos))
+	}
+	return byte(qos)
+}
+
+func loadBrokerConfig() BrokerConfig {
+	config := BrokerConfig{
+		Backend:        viper.GetString("broker.backend"),
+		QueueCapacity:  viper.GetInt("broker.queue_capacity"),
+		OverflowPolicy: viper.GetString("broker.overflow_policy"),
+		Redis: BrokerRedisConfig{
+			Stream:           viper.GetString("broker.redis.stream"),
+			MaxLen:           viper.GetInt64("broker.redis.max_len"),
+			GroupIdleTimeout: viper.GetDuration("broker.redis.group_idle_timeout"),
+		},
+	}
+	if config.Backend == "" {
+		config.Backend = BrokerBackendLocal
+	}
+	if config.QueueCapacity == 0 {
+		config.QueueCapacity = 256
+	}
+	if config.OverflowPolicy == "" {
+		config.OverflowPolicy = "drop_oldest"
+	}
+
+	return config
+}
+
+func loadWebhooksConfig() WebhooksConfig {
+	config := WebhooksConfig{
+		DeliveryInterval:     viper.GetDuration("webhooks.delivery_interval"),
+		Timeout:              viper.GetDuration("webhooks.timeout"),
+		MaxAttempts:          viper.GetInt("webhooks.max_attempts"),
+		InitialBackoff:       viper.GetDuration("webhooks.initial_backoff"),
+		MaxBackoff:           viper.GetDuration("webhooks.max_backoff"),
+		AllowPrivateNetworks: viper.GetBool("webhooks.allow_private_networks"),
+	}
+	if config.DeliveryInterval == 0 {
+		config.DeliveryInterval = time.Second
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.MaxAttempts == 0 {
+		config.MaxAttempts = 8
+	}
+	if config.InitialBackoff == 0 {
+		config.InitialBackoff = 30 * time.Second
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = time.Hour
+	}
+
+	return config
+}
+
+func loadOutboxConfig() OutboxConfig {
+	config := OutboxConfig{
+		RelayInterval:     viper.GetDuration("outbox.relay_interval"),
+		RedeliveryTimeout: viper.GetDuration("outbox.redelivery_timeout"),
+		MaxAttempts:       viper.GetInt("outbox.max_attempts"),
+		Retention:         viper.GetDuration("outbox.retention"),
+	}
+	if config.RelayInterval == 0 {
+		config.RelayInterval = time.Second
+	}
+	if config.RedeliveryTimeout == 0 {
+		config.RedeliveryTimeout = time.Minute
+	}
+	if config.MaxAttempts == 0 {
+		config.MaxAttempts = 10
+	}
+	if config.Retention == 0 {
+		config.Retention = 7 * 24 * time.Hour
+	}
+
+	return config
+}
+
+func loadHealthConfig() HealthConfig {
+	config := HealthConfig{
+		EvaluationInterval:  viper.GetDuration("health.evaluation_interval"),
+		BatteryEmptyVoltage: viper.GetFloat64("health.battery_empty_voltage"),
+		BatteryFullVoltage:  viper.GetFloat64("health.battery_full_voltage"),
+		LowBatteryPercent:   20,
+	}
+	if config.EvaluationInterval == 0 {
+		config.EvaluationInterval = 5 * time.Minute
+	}
+	if config.BatteryEmptyVoltage == 0 && config.BatteryFullVoltage == 0 {
+		config.BatteryEmptyVoltage, config.BatteryFullVoltage = 3.0, 4.2
+	}
+	if viper.IsSet("health.low_battery_percent") {
+		config.LowBatteryPercent = viper.GetInt("health.low_battery_percent")
+	}
+
+	return config
+}
+
+func loadTTNConfig() TTNConfig {
+	return TTNConfig{
+		Provisioning: TTNProvisioningConfig{
+			Enabled:           viper.GetBool("ttn.provisioning.enabled"),
+			BaseURL:           viper.GetString("ttn.provisioning.base_url"),
+			ApplicationID:     viper.GetString("ttn.provisioning.application_id"),
+			APIKey:            viper.GetString("ttn.provisioning.api_key"),
+			FrequencyPlanID:   viper.GetString("ttn.provisioning.frequency_plan_id"),
+			LoRaWANVersion:    viper.GetString("ttn.provisioning.lorawan_version"),
+			LoRaWANPHYVersion: viper.GetString("ttn.provisioning.lorawan_phy_version"),
+		},
+	}
+}
+
 func loadModulesConfig() ModulesConfig {
 	return ModulesConfig{
 		Permaculture: ModuleConfig{
@@ -219,6 +516,9 @@ type AppConfig struct {
 	Auth              AuthConfig
 	mqtt              MqttConfig
 	MQTTClient        MQTTClientConfig
+	MQTTBroker        MQTTBrokerConfig
+	MQTTBridge        MQTTBridgeConfig
+	Simulator         SimulatorConfig
 	Victron           VictronConfig
 	VictoriaMetrics   VictoriaMetricsConfig
 	Postgresql        PostgresqlConfig
@@ -230,6 +530,13 @@ type AppConfig struct {
 	PushNotifications PushNotificationsConfig
 	Modules           ModulesConfig
 	ExecutionWorker   ExecutionWorkerConfig
+	Broker            BrokerConfig
+	Outbox            OutboxConfig
+	Webhooks          WebhooksConfig
+	Health            HealthConfig
+	LeaderElection    LeaderElectionConfig
+	TTN               TTNConfig
+	Secrets           SecretsConfig
 }
 
 type GeneralConfig struct {
@@ -271,13 +578,75 @@ type MqttConfig struct {
 	Broker string
 }
 
+// MQTTClientConfig configures the connection to the LoRaWAN network server broker. Messages
+// published while disconnected are queued, up to QueueCapacity, in memory or in QueueDir when
+// set; a zero QueueCapacity disables the queue.
 type MQTTClientConfig struct {
-	Broker   string
-	ClientID string
+	Broker            string
+	ClientID          string
+	Username          string
+	Password          string
+	PublishQoS        byte
+	SubscribeQoS      byte
+	PersistentSession bool
+	QueueCapacity     int
+	QueueDir          string
+	TLS               MQTTTLSConfig
+}
+
+// MQTTTLSConfig applies to brokers with a ssl://, tls://, tcps://, mqtts:// or wss:// URL. The
+// broker is verified against CAFile, or the system roots when empty. CertFile and KeyFile hold the
+// client certificate that authenticates this client to the broker.
+type MQTTTLSConfig struct {
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+// MQTTBrokerConfig configures the MQTT broker embedded in the server. When Username is set,
+// clients must connect with these credentials; without it, Address must be a loopback address.
+type MQTTBrokerConfig struct {
+	Enabled  bool
+	Address  string
 	Username string
 	Password string
 }
 
+// MQTTBridgeConfig configures the republishing of device readings and command status updates as
+// retained JSON messages on an MQTT topic tree, on its own Broker, which is required when enabled.
+// SensorTopic and CommandTopic are templates whose {tenant}, {device}, {sensor} and {index} levels
+// are filled for every message.
+type MQTTBridgeConfig struct {
+	Enabled      bool
+	Broker       string
+	ClientID     string
+	Username     string
+	Password     string
+	QoS          byte
+	TLS          MQTTTLSConfig
+	SensorTopic  string
+	CommandTopic string
+	Commands     MQTTBridgeCommandsConfig
+}
+
+// MQTTBridgeCommandsConfig controls the commands accepted on the set topics of the bridge. Only the
+// readings of Sensors can be set.
+type MQTTBridgeCommandsConfig struct {
+	Enabled bool
+	Sensors []string
+}
+
+// SimulatorConfig configures the simulator of LoRaWAN devices, which plays the network server on
+// the mqtt_client broker: it publishes uplinks of Devices every UplinkInterval and acknowledges
+// the downlinks sent to them.
+type SimulatorConfig struct {
+	Enabled        bool
+	Devices        []string
+	UplinkInterval time.Duration
+}
+
 type PostgresqlConfig struct {
 	DSN          string
 	QueryTimeout time.Duration
@@ -351,6 +720,7 @@ type VictronMQTTConfig struct {
 	ClientID string
 	Username string
 	Password string
+	TLS      MQTTTLSConfig
 }
 
 // VictoriaMetricsConfig holds the base URL of the VictoriaMetrics instance
@@ -366,3 +736,95 @@ type ModuleConfig struct {
 type ExecutionWorkerConfig struct {
 	TickerInterval time.Duration
 }
+
+// BrokerBackendLocal delivers internal broker messages within the process.
+// BrokerBackendRedis shares them between replicas through a Redis stream.
+const (
+	BrokerBackendLocal = "local"
+	BrokerBackendRedis = "redis"
+)
+
+// BrokerConfig selects the internal broker backend and bounds the queue of every subscription.
+// When a queue is full, OverflowPolicy decides whether publishers block ("block") or a message is
+// dropped ("drop_oldest", "drop_newest").
+type BrokerConfig struct {
+	Backend        string
+	QueueCapacity  int
+	OverflowPolicy string
+	Redis          BrokerRedisConfig
+}
+
+// BrokerRedisConfig controls the stream used by the redis backend. The stream is trimmed to about
+// MaxLen messages, and consumer groups of replicas idle for GroupIdleTimeout are removed.
+type BrokerRedisConfig struct {
+	Stream           string
+	MaxLen           int64
+	GroupIdleTimeout time.Duration
+}
+
+// OutboxConfig controls the relay of events stored in the outbox. Events published but not
+// acknowledged within RedeliveryTimeout are published again, up to MaxAttempts times, and
+// acknowledged events are kept for Retention.
+type OutboxConfig struct {
+	RelayInterval     time.Duration
+	RedeliveryTimeout time.Duration
+	MaxAttempts       int
+	Retention         time.Duration
+}
+
+// WebhooksConfig controls the delivery of events to tenant webhooks. Due deliveries are sent every
+// DeliveryInterval and requests give up after Timeout. A failed delivery is retried after
+// InitialBackoff, doubled on every further failure up to MaxBackoff, and dead-lettered after
+// MaxAttempts attempts. Webhooks only reach loopback, private and link-local addresses with
+// AllowPrivateNetworks.
+type WebhooksConfig struct {
+	DeliveryInterval     time.Duration
+	Timeout              time.Duration
+	MaxAttempts          int
+	InitialBackoff       time.Duration
+	MaxBackoff           time.Duration
+	AllowPrivateNetworks bool
+}
+
+// HealthConfig controls how device health is scored. Battery voltages map linearly to a charge
+// between the empty and full voltages, and devices raise a low battery alert below
+// LowBatteryPercent unless their tenant overrides it; zero disables the alerts.
+type HealthConfig struct {
+	EvaluationInterval  time.Duration
+	BatteryEmptyVoltage float64
+	BatteryFullVoltage  float64
+	LowBatteryPercent   int
+}
+
+// LeaderElectionConfig controls the Redis lease that keeps singleton workers on one replica.
+// When disabled every process considers itself the leader; when enabled the broker must use the
+// Redis backend so the singleton workers consume the events of every replica.
+type LeaderElectionConfig struct {
+	Enabled  bool
+	LeaseKey string
+	LeaseTTL time.Duration
+}
+
+type TTNConfig struct {
+	Provisioning TTNProvisioningConfig
+}
+
+// TTNProvisioningConfig controls the registration of new devices in the TTN end device registries.
+// When disabled devices must be registered in TTN by hand.
+type TTNProvisioningConfig struct {
+	Enabled           bool
+	BaseURL           string
+	ApplicationID     string
+	APIKey            string
+	FrequencyPlanID   string
+	LoRaWANVersion    string
+	LoRaWANPHYVersion string
+}
+
+// SecretsConfig holds the key-encryption keys that seal secrets at rest, such as device root keys.
+// Keys lists "id:base64" pairs of 32-byte keys; retired keys stay listed until every secret sealed
+// with them has been rewrapped with the primary one.
+type SecretsConfig struct {
+	PrimaryKeyID string
+	Keys         string
+}
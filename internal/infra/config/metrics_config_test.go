@@ -0,0 +1,58 @@
+package config
+
+import (
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"github.com/spf13/viper"
+)
+
+var _ = ginkgo.Describe("loadMetricsConfig", func() {
+	ginkgo.AfterEach(func() {
+		viper.Reset()
+	})
+
+	ginkgo.When("a metric uses the event types and value paths renamed by the event catalog", func() {
+		ginkgo.It("should read them as their current names", func() {
+			viper.Set("metrics", []any{
+				map[string]any{
+					"name":                "sensor_temperature",
+					"type":                "gauge",
+					"topic":               "devices/+/sensors/temperature",
+					"event_type":          "temperature_data_received",
+					"value_property_name": "Value",
+					"custom_attributes":   map[string]any{"device_name": "DeviceName", "tenant": "tenant_id"},
+				},
+				map[string]any{
+					"name":       "scheduled_tasks_total",
+					"type":       "counter",
+					"topic":      "scheduled_tasks",
+					"event_type": "task_executed",
+				},
+			})
+
+			metrics := loadMetricsConfig()
+			gomega.Expect(metrics).To(gomega.HaveLen(2))
+			gomega.Expect(metrics[0].EventType).To(gomega.Equal("sensor_data_received"))
+			gomega.Expect(metrics[0].ValuePropertyName).To(gomega.Equal("value"))
+			gomega.Expect(metrics[0].CustomAttributes).To(gomega.Equal(map[string]string{"device_name": "device_name", "tenant": "tenant_id"}))
+			gomega.Expect(metrics[1].EventType).To(gomega.Equal("scheduled_task_executed"))
+		})
+	})
+
+	ginkgo.When("a metric uses the current names", func() {
+		ginkgo.It("should keep them", func() {
+			viper.Set("metrics", []any{
+				map[string]any{
+					"name":                "sensor_battery",
+					"type":                "gauge",
+					"event_type":          "sensor_data_received",
+					"value_property_name": "value",
+				},
+			})
+
+			metrics := loadMetricsConfig()
+			gomega.Expect(metrics[0].EventType).To(gomega.Equal("sensor_data_received"))
+			gomega.Expect(metrics[0].ValuePropertyName).To(gomega.Equal("value"))
+		})
+	})
+})
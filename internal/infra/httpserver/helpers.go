@@ -20,10 +20,11 @@ type ErrorResponse struct {
 type PaginatedResponse struct {
 	Data       any `json:"data"`
 	Pagination struct {
-		Page       int `json:"page"`
-		Limit      int `json:"limit"`
-		Total      int `json:"total"`
-		TotalPages int `json:"total_pages"`
+		Page       int    `json:"page"`
+		Limit      int    `json:"limit"`
+		Total      int    `json:"total"`
+		TotalPages int    `json:"total_pages"`
+		NextCursor string `json:"next_cursor,omitempty"`
 	} `json:"pagination"`
 }
 
@@ -73,6 +74,18 @@ func ExtractPaginationParams(r *http.Request) PaginationParams {
 
 // ReplyWithPaginatedData responds with a paginated set of entities.
 func ReplyWithPaginatedData(w http.ResponseWriter, statusCode int, data any, total int, params PaginationParams) {
+	ReplyJSONResponse(w, statusCode, NewPaginatedResponse(data, total, params))
+}
+
+// ReplyWithCursorPaginatedData responds with a page of entities and the cursor of the next page.
+func ReplyWithCursorPaginatedData(w http.ResponseWriter, statusCode int, data any, total int, params PaginationParams, nextCursor string) {
+	response := NewPaginatedResponse(data, total, params)
+	response.Pagination.NextCursor = nextCursor
+	ReplyJSONResponse(w, statusCode, response)
+}
+
+// NewPaginatedResponse builds a paginated response, for responses that embed it next to other fields.
+func NewPaginatedResponse(data any, total int, params PaginationParams) PaginatedResponse {
 	totalPages := (total + params.Limit - 1) / params.Limit // Ceiling division
 
 	response := PaginatedResponse{
@@ -83,7 +96,7 @@ func ReplyWithPaginatedData(w http.ResponseWriter, statusCode int, data any, tot
 	response.Pagination.Total = total
 	response.Pagination.TotalPages = totalPages
 
-	ReplyJSONResponse(w, statusCode, response)
+	return response
 }
 
 func ReplyWithError(w http.ResponseWriter, statusCode int, errMsg string) {
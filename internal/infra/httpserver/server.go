@@ -223,15 +223,30 @@ func getHealthz() http.HandlerFunc {
 			Status:     "success",
 			Version:    nodeInfo.Version,
 			CommitHash: nodeInfo.CommitHash,
+			Leader:     nodeInfo.IsLeader,
+		}
+		// A component that is down degrades the node without failing the probe, so replicas are
+		// not restarted while a broker they reconnect to is unreachable.
+		if len(nodeInfo.Components) > 0 {
+			output.Components = make(map[string]string, len(nodeInfo.Components))
+			for name, healthy := range nodeInfo.Components {
+				output.Components[name] = "up"
+				if !healthy {
+					output.Components[name] = "down"
+					output.Status = "degraded"
+				}
+			}
 		}
 		ReplyJSONResponse(w, http.StatusOK, output)
 	}
 }
 
 type HealthzResponse struct {
-	Status     string `json:"status"`
-	Version    string `json:"version"`
-	CommitHash string `json:"commit_hash"`
+	Status     string            `json:"status"`
+	Version    string            `json:"version"`
+	CommitHash string            `json:"commit_hash"`
+	Leader     bool              `json:"leader"`
+	Components map[string]string `json:"components,omitempty"`
 }
 
 func getCurrentUser() http.HandlerFunc {
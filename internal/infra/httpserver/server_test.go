@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"time"
+	"zensor-server/internal/infra/node"
 	"zensor-server/internal/shared_kernel/domain"
 
 	"github.com/onsi/ginkgo/v2"
@@ -257,6 +258,27 @@ var _ = ginkgo.Describe("HTTPServer", func() {
 			})
 		})
 
+		ginkgo.When("a component of the node is down", func() {
+			ginkgo.AfterEach(func() {
+				node.SetComponentHealth("mqtt.healthz-test", true)
+			})
+
+			ginkgo.It("should report the node degraded without failing the probe", func() {
+				node.SetComponentHealth("mqtt.healthz-test", false)
+				srv := NewServer(0)
+				req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+				rec := httptest.NewRecorder()
+
+				srv.server.Handler.ServeHTTP(rec, req)
+
+				gomega.Expect(rec.Code).To(gomega.Equal(http.StatusOK))
+				var response HealthzResponse
+				gomega.Expect(json.Unmarshal(rec.Body.Bytes(), &response)).To(gomega.Succeed())
+				gomega.Expect(response.Status).To(gomega.Equal("degraded"))
+				gomega.Expect(response.Components).To(gomega.HaveKeyWithValue("mqtt.healthz-test", "down"))
+			})
+		})
+
 		ginkgo.When("requesting an unmatched path under /v1/", func() {
 			ginkgo.It("should return 404, not the SPA's HTML", func() {
 				srv := NewServer(0)
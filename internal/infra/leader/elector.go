@@ -0,0 +1,180 @@
+// Package leader provides lease-based leader election so singleton workers run on one replica only.
+package leader
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/infra/node"
+)
+
+const (
+	DefaultLeaseTTL = 15 * time.Second
+
+	_releaseTimeout = 5 * time.Second
+)
+
+// Lease is an exclusive, time-bounded lock shared by every replica.
+type Lease interface {
+	Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, holder string) error
+}
+
+// NewElector creates an elector that runs workers only while holder owns the lease. The lease is
+// renewed every third of ttl; a leader that cannot reach the lease store keeps its workers until
+// the last renewed lease expires, and steps down as soon as another replica holds it. A renewal
+// that hangs is abandoned when the lease expires, so a leader never outlives its lease.
+func NewElector(lease Lease, holder string, ttl time.Duration, workers ...async.Worker) *Elector {
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL
+	}
+
+	return &Elector{
+		lease:   lease,
+		holder:  holder,
+		ttl:     ttl,
+		workers: workers,
+	}
+}
+
+var _ async.Worker = &Elector{}
+
+type Elector struct {
+	lease   Lease
+	holder  string
+	ttl     time.Duration
+	workers []async.Worker
+	leading atomic.Bool
+}
+
+type term struct {
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func (e *Elector) Run(ctx context.Context, done func()) {
+	slog.Info("leader elector started", slog.String("holder", e.holder), slog.Duration("lease_ttl", e.ttl))
+	defer done()
+
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	var (
+		current   *term
+		expiresAt time.Time
+	)
+	for {
+		attemptedAt := time.Now()
+		acquired, err := e.acquire(ctx, current != nil, expiresAt)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("acquiring leadership lease", slog.String("holder", e.holder), slog.Any("error", err))
+		}
+		if acquired {
+			expiresAt = attemptedAt.Add(e.ttl)
+		}
+
+		switch {
+		case acquired && current == nil:
+			current = e.lead(ctx)
+		case !acquired && current != nil && err != nil && time.Now().Before(expiresAt):
+			slog.Warn("leadership lease not renewed, keeping singleton workers until it expires",
+				slog.String("holder", e.holder),
+				slog.Time("expires_at", expiresAt))
+		case !acquired && current != nil:
+			slog.Warn("leadership lost, stopping singleton workers", slog.String("holder", e.holder))
+			e.stepDown(current)
+			current = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if current != nil {
+				e.stepDown(current)
+				e.release()
+			}
+			slog.Info("leader elector cancelled", slog.String("holder", e.holder))
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// acquire tries to take or renew the lease. While leading, the attempt is bounded by the expiry of
+// the lease held so far and given up at that instant even if the lease store does not return.
+func (e *Elector) acquire(ctx context.Context, leading bool, expiresAt time.Time) (bool, error) {
+	if leading {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, expiresAt)
+		defer cancel()
+	}
+
+	type attempt struct {
+		acquired bool
+		err      error
+	}
+
+	attempts := make(chan attempt, 1)
+	go func() {
+		acquired, err := e.lease.Acquire(ctx, e.holder, e.ttl)
+		attempts <- attempt{acquired: acquired, err: err}
+	}()
+
+	select {
+	case result := <-attempts:
+		return result.acquired, result.err
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// IsLeader reports whether this replica currently runs the singleton workers.
+func (e *Elector) IsLeader() bool {
+	return e.leading.Load()
+}
+
+func (e *Elector) lead(ctx context.Context) *term {
+	slog.Info("leadership acquired, starting singleton workers",
+		slog.String("holder", e.holder),
+		slog.Int("workers", len(e.workers)))
+
+	termCtx, cancel := context.WithCancel(ctx)
+	current := &term{cancel: cancel}
+	for _, worker := range e.workers {
+		current.wg.Add(1)
+		go worker.Run(termCtx, current.wg.Done)
+	}
+
+	e.setLeading(true)
+	return current
+}
+
+func (e *Elector) stepDown(current *term) {
+	current.cancel()
+	current.wg.Wait()
+	e.setLeading(false)
+}
+
+func (e *Elector) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), _releaseTimeout)
+	defer cancel()
+
+	err := e.lease.Release(ctx, e.holder)
+	if err != nil {
+		slog.Error("releasing leadership lease", slog.String("holder", e.holder), slog.Any("error", err))
+		return
+	}
+
+	slog.Info("leadership released", slog.String("holder", e.holder))
+}
+
+func (e *Elector) setLeading(value bool) {
+	e.leading.Store(value)
+	node.SetLeader(value)
+}
+
+func (e *Elector) Shutdown() {
+	slog.Warn("leader elector shutdown is driven by context cancellation")
+}
@@ -0,0 +1,160 @@
+package leader_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+	"zensor-server/internal/infra/leader"
+	"zensor-server/internal/infra/node"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+type fakeLease struct {
+	granted     atomic.Bool
+	unreachable atomic.Bool
+	hanging     atomic.Bool
+	released    atomic.Bool
+}
+
+func (l *fakeLease) Acquire(context.Context, string, time.Duration) (bool, error) {
+	if l.hanging.Load() {
+		time.Sleep(time.Second)
+		return false, errors.New("i/o timeout")
+	}
+	if l.unreachable.Load() {
+		return false, errors.New("connection refused")
+	}
+	return l.granted.Load(), nil
+}
+
+func (l *fakeLease) Release(context.Context, string) error {
+	l.released.Store(true)
+	return nil
+}
+
+type fakeWorker struct {
+	running atomic.Int32
+	starts  atomic.Int32
+}
+
+func (w *fakeWorker) Run(ctx context.Context, done func()) {
+	defer done()
+	w.starts.Add(1)
+	w.running.Add(1)
+	defer w.running.Add(-1)
+	<-ctx.Done()
+}
+
+func (w *fakeWorker) Shutdown() {}
+
+var _ = ginkgo.Describe("Elector", func() {
+	var (
+		lease   *fakeLease
+		worker  *fakeWorker
+		elector *leader.Elector
+		ttl     time.Duration
+		cancel  context.CancelFunc
+		stopped chan struct{}
+	)
+
+	ginkgo.BeforeEach(func() {
+		lease = &fakeLease{}
+		worker = &fakeWorker{}
+		ttl = 30 * time.Millisecond
+	})
+
+	ginkgo.JustBeforeEach(func() {
+		elector = leader.NewElector(lease, "replica-1", ttl, worker)
+
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		stopped = make(chan struct{})
+		go elector.Run(ctx, func() { close(stopped) })
+	})
+
+	ginkgo.AfterEach(func() {
+		cancel()
+		gomega.Eventually(stopped).Should(gomega.BeClosed())
+	})
+
+	ginkgo.Context("Run", func() {
+		ginkgo.When("another replica holds the lease", func() {
+			ginkgo.It("should not start the workers", func() {
+				gomega.Consistently(worker.starts.Load, 50*time.Millisecond).Should(gomega.BeZero())
+				gomega.Expect(elector.IsLeader()).To(gomega.BeFalse())
+			})
+		})
+
+		ginkgo.When("the lease is acquired", func() {
+			ginkgo.BeforeEach(func() {
+				lease.granted.Store(true)
+			})
+
+			ginkgo.It("should start the workers once and report leadership", func() {
+				gomega.Eventually(worker.running.Load).Should(gomega.Equal(int32(1)))
+				gomega.Consistently(worker.starts.Load, 50*time.Millisecond).Should(gomega.Equal(int32(1)))
+				gomega.Expect(elector.IsLeader()).To(gomega.BeTrue())
+				gomega.Expect(node.GetNodeInfo().IsLeader).To(gomega.BeTrue())
+			})
+
+			ginkgo.It("should stop the workers when the lease is lost", func() {
+				gomega.Eventually(worker.running.Load).Should(gomega.Equal(int32(1)))
+
+				lease.granted.Store(false)
+
+				gomega.Eventually(worker.running.Load).Should(gomega.BeZero())
+				gomega.Eventually(elector.IsLeader).Should(gomega.BeFalse())
+			})
+
+			ginkgo.When("the lease store is unreachable", func() {
+				ginkgo.BeforeEach(func() {
+					ttl = 300 * time.Millisecond
+				})
+
+				ginkgo.It("should keep the workers until the lease expires", func() {
+					gomega.Eventually(worker.running.Load).Should(gomega.Equal(int32(1)))
+
+					lease.unreachable.Store(true)
+
+					gomega.Consistently(elector.IsLeader, 150*time.Millisecond).Should(gomega.BeTrue())
+					gomega.Eventually(worker.running.Load, time.Second).Should(gomega.BeZero())
+					gomega.Expect(elector.IsLeader()).To(gomega.BeFalse())
+				})
+
+				ginkgo.It("should stop the workers when the lease expires during a hanging renewal", func() {
+					gomega.Eventually(worker.running.Load).Should(gomega.Equal(int32(1)))
+
+					lease.hanging.Store(true)
+
+					gomega.Eventually(worker.running.Load, 600*time.Millisecond).Should(gomega.BeZero())
+					gomega.Expect(elector.IsLeader()).To(gomega.BeFalse())
+				})
+
+				ginkgo.It("should keep leading when renewals succeed again", func() {
+					gomega.Eventually(worker.running.Load).Should(gomega.Equal(int32(1)))
+
+					lease.unreachable.Store(true)
+					time.Sleep(ttl / 2)
+					lease.unreachable.Store(false)
+
+					gomega.Consistently(elector.IsLeader, ttl).Should(gomega.BeTrue())
+					gomega.Expect(worker.starts.Load()).To(gomega.Equal(int32(1)))
+				})
+			})
+
+			ginkgo.It("should stop the workers and release the lease on shutdown", func() {
+				gomega.Eventually(worker.running.Load).Should(gomega.Equal(int32(1)))
+
+				cancel()
+
+				gomega.Eventually(stopped).Should(gomega.BeClosed())
+				gomega.Expect(worker.running.Load()).To(gomega.BeZero())
+				gomega.Expect(lease.released.Load()).To(gomega.BeTrue())
+				gomega.Expect(elector.IsLeader()).To(gomega.BeFalse())
+			})
+		})
+	})
+})
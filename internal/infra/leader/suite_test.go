@@ -0,0 +1,19 @@
+package leader_test
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+func TestLeader(t *testing.T) {
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "Leader Suite")
+}
+
+var _ = ginkgo.BeforeEach(func() {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+})
@@ -0,0 +1,529 @@
+package mqtt
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+	"github.com/google/uuid"
+)
+
+const (
+	_connectTimeout       = 10 * time.Second
+	_sessionQueueCapacity = 256
+	// _writeTimeout disconnects clients that stop reading, which would otherwise hold back the
+	// publishers waiting to queue QoS 1 messages for them.
+	_writeTimeout = 10 * time.Second
+	// _maxGrantedQoS is the highest quality of service granted to subscriptions: messages are
+	// delivered once to connected sessions and never stored for redelivery.
+	_maxGrantedQoS       = 1
+	_subscriptionFailure = 0x80
+)
+
+var (
+	errUnsupportedQoS = errors.New("QoS 2 is not supported")
+
+	ErrEmbeddedBrokerExposed = errors.New("the embedded MQTT broker needs a username to listen on a non-loopback address")
+)
+
+// EmbeddedBrokerOpts configures the embedded broker. When Username is set, clients must connect
+// with these credentials; without it, the broker only listens on loopback addresses.
+type EmbeddedBrokerOpts struct {
+	Address  string
+	Username string
+	Password string
+}
+
+func NewEmbeddedBroker(opts EmbeddedBrokerOpts) *EmbeddedBroker {
+	return &EmbeddedBroker{
+		opts:     opts,
+		sessions: make(map[string]*brokerSession),
+		retained: make(map[string]*packets.PublishPacket),
+	}
+}
+
+// EmbeddedBroker is a minimal MQTT 3.1.1 broker running in the server process, so a single
+// binary serves edge deployments and local development without an external broker. It keeps
+// clean sessions only: subscriptions and in-flight messages are lost when a client disconnects,
+// and clients asking for a persistent session are refused. Subscriptions are granted QoS 0 or 1,
+// QoS 2 publishers are disconnected, and messages are not redelivered. Retained messages and
+// wills are supported.
+type EmbeddedBroker struct {
+	opts     EmbeddedBrokerOpts
+	listener net.Listener
+	mu       sync.RWMutex
+	sessions map[string]*brokerSession
+	retained map[string]*packets.PublishPacket
+	wg       sync.WaitGroup
+	closed   atomic.Bool
+}
+
+// Start listens on the configured address and accepts clients in the background. It refuses to
+// accept anonymous clients from other hosts, who could inject uplinks and read every downlink.
+func (b *EmbeddedBroker) Start() error {
+	if b.opts.Username == "" && !isLoopbackAddress(b.opts.Address) {
+		return fmt.Errorf("%w: %q", ErrEmbeddedBrokerExposed, b.opts.Address)
+	}
+
+	listener, err := net.Listen("tcp", b.opts.Address)
+	if err != nil {
+		return fmt.Errorf("listening for MQTT clients: %w", err)
+	}
+	b.listener = listener
+	slog.Info("embedded MQTT broker listening", slog.String("address", listener.Addr().String()))
+
+	b.wg.Add(1)
+	go b.accept()
+	return nil
+}
+
+// isLoopbackAddress reports whether address only accepts connections from this host. An address
+// without host listens on every interface.
+func isLoopbackAddress(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Addr returns the address the broker listens on, useful when started on port 0.
+func (b *EmbeddedBroker) Addr() string {
+	return b.listener.Addr().String()
+}
+
+// Shutdown stops accepting clients and disconnects the connected ones.
+func (b *EmbeddedBroker) Shutdown() {
+	if b.listener == nil || !b.closed.CompareAndSwap(false, true) {
+		return
+	}
+	_ = b.listener.Close()
+
+	b.mu.RLock()
+	for _, session := range b.sessions {
+		session.close()
+	}
+	b.mu.RUnlock()
+
+	b.wg.Wait()
+}
+
+func (b *EmbeddedBroker) accept() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			if !b.closed.Load() {
+				slog.Error("accepting MQTT client", slog.Any("error", err))
+			}
+			return
+		}
+		b.wg.Add(1)
+		go b.serve(conn)
+	}
+}
+
+func (b *EmbeddedBroker) serve(conn net.Conn) {
+	defer b.wg.Done()
+
+	session, connect, err := b.handshake(conn)
+	if err != nil {
+		slog.Debug("rejecting MQTT client", slog.String("remote_addr", conn.RemoteAddr().String()), slog.Any("error", err))
+		_ = conn.Close()
+		return
+	}
+	defer b.unregister(session)
+
+	cleanDisconnect := false
+	for {
+		if connect.Keepalive > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(time.Duration(connect.Keepalive) * time.Second * 3 / 2))
+		}
+		packet, err := packets.ReadPacket(conn)
+		if err != nil {
+			break
+		}
+		if _, ok := packet.(*packets.DisconnectPacket); ok {
+			cleanDisconnect = true
+			break
+		}
+		if err := b.handle(session, packet); err != nil {
+			slog.Warn("closing MQTT client connection", slog.String("client_id", session.clientID), slog.Any("error", err))
+			break
+		}
+	}
+
+	if !cleanDisconnect && connect.WillFlag && !session.replaced.Load() {
+		b.publish(&packets.PublishPacket{
+			FixedHeader: packets.FixedHeader{MessageType: packets.Publish, Qos: connect.WillQos, Retain: connect.WillRetain},
+			TopicName:   connect.WillTopic,
+			Payload:     connect.WillMessage,
+		})
+	}
+}
+
+var errUnexpectedPacket = errors.New("unexpected packet")
+
+// handshake reads the CONNECT packet, authenticates the client and registers its session,
+// replacing the session of a client already connected with the same ID.
+func (b *EmbeddedBroker) handshake(conn net.Conn) (*brokerSession, *packets.ConnectPacket, error) {
+	_ = conn.SetReadDeadline(time.Now().Add(_connectTimeout))
+	packet, err := packets.ReadPacket(conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	connect, ok := packet.(*packets.ConnectPacket)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s before CONNECT", errUnexpectedPacket, packet)
+	}
+
+	code := connect.Validate()
+	if code == packets.Accepted && connect.WillFlag && !validTopicName(connect.WillTopic) {
+		// A will is published like any message, so its topic must be one publishers may use.
+		return nil, nil, fmt.Errorf("invalid will topic %q", connect.WillTopic)
+	}
+	if code == packets.Accepted && !b.authorized(connect) {
+		code = packets.ErrRefusedNotAuthorised
+	}
+	if code == packets.Accepted && !connect.CleanSession {
+		// Sessions are not stored, so accepting would silently lose the subscriptions and
+		// messages the client expects to be kept.
+		slog.Warn("refusing MQTT client asking for a persistent session", slog.String("client_id", connect.ClientIdentifier))
+		code = packets.ErrRefusedServerUnavailable
+	}
+	connack := packets.NewControlPacket(packets.Connack).(*packets.ConnackPacket) //nolint:forcetypeassert // created by type
+	connack.ReturnCode = code
+	if err := connack.Write(conn); err != nil {
+		return nil, nil, err
+	}
+	if code != packets.Accepted {
+		return nil, nil, packets.ConnErrors[code]
+	}
+
+	if connect.ClientIdentifier == "" {
+		connect.ClientIdentifier = uuid.NewString()
+	}
+	session := newBrokerSession(connect.ClientIdentifier, conn)
+
+	b.mu.Lock()
+	previous, found := b.sessions[session.clientID]
+	b.sessions[session.clientID] = session
+	b.mu.Unlock()
+	if found {
+		previous.replaced.Store(true)
+		previous.close()
+	}
+	if b.closed.Load() {
+		session.close()
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		session.write()
+	}()
+
+	return session, connect, nil
+}
+
+func (b *EmbeddedBroker) authorized(connect *packets.ConnectPacket) bool {
+	if b.opts.Username == "" {
+		return true
+	}
+	usernameMatches := subtle.ConstantTimeCompare([]byte(connect.Username), []byte(b.opts.Username)) == 1
+	passwordMatches := subtle.ConstantTimeCompare(connect.Password, []byte(b.opts.Password)) == 1
+	return usernameMatches && passwordMatches
+}
+
+func (b *EmbeddedBroker) unregister(session *brokerSession) {
+	session.close()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.sessions[session.clientID] == session {
+		delete(b.sessions, session.clientID)
+	}
+}
+
+func (b *EmbeddedBroker) handle(session *brokerSession, packet packets.ControlPacket) error {
+	switch p := packet.(type) {
+	case *packets.PublishPacket:
+		return b.handlePublish(session, p)
+	case *packets.SubscribePacket:
+		b.handleSubscribe(session, p)
+	case *packets.UnsubscribePacket:
+		session.unsubscribe(p.Topics)
+		unsuback := packets.NewControlPacket(packets.Unsuback).(*packets.UnsubackPacket) //nolint:forcetypeassert // created by type
+		unsuback.MessageID = p.MessageID
+		session.send(unsuback)
+	case *packets.PingreqPacket:
+		session.send(packets.NewControlPacket(packets.Pingresp))
+	case *packets.PubackPacket:
+		// Deliveries are not tracked for redelivery, so acknowledgements need no handling.
+	default:
+		return fmt.Errorf("%w: %s", errUnexpectedPacket, packet)
+	}
+	return nil
+}
+
+func (b *EmbeddedBroker) handlePublish(session *brokerSession, publish *packets.PublishPacket) error {
+	if !validTopicName(publish.TopicName) {
+		return fmt.Errorf("invalid topic name %q", publish.TopicName)
+	}
+
+	switch publish.Qos {
+	case 1:
+		puback := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket) //nolint:forcetypeassert // created by type
+		puback.MessageID = publish.MessageID
+		session.send(puback)
+	case 2:
+		// Exactly-once delivery needs the message held until PUBREL, which the broker does not
+		// do. MQTT 3.1.1 has no way to refuse a PUBLISH, so the connection is closed.
+		return errUnsupportedQoS
+	}
+
+	b.publish(publish)
+	return nil
+}
+
+// publish stores the message when retained and delivers it to every session with a matching
+// subscription, once per session at the highest QoS granted among its matching subscriptions.
+func (b *EmbeddedBroker) publish(publish *packets.PublishPacket) {
+	b.mu.Lock()
+	if publish.Retain {
+		if len(publish.Payload) == 0 {
+			delete(b.retained, publish.TopicName)
+		} else {
+			b.retained[publish.TopicName] = publish
+		}
+	}
+	sessions := make([]*brokerSession, 0, len(b.sessions))
+	for _, session := range b.sessions {
+		sessions = append(sessions, session)
+	}
+	b.mu.Unlock()
+
+	for _, session := range sessions {
+		if qos, matched := session.grantedQoS(publish.TopicName); matched {
+			session.deliver(publish, min(publish.Qos, qos), false)
+		}
+	}
+}
+
+func (b *EmbeddedBroker) handleSubscribe(session *brokerSession, subscribe *packets.SubscribePacket) {
+	suback := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket) //nolint:forcetypeassert // created by type
+	suback.MessageID = subscribe.MessageID
+	suback.ReturnCodes = make([]byte, len(subscribe.Topics))
+
+	granted := make(map[string]byte, len(subscribe.Topics))
+	for i, filter := range subscribe.Topics {
+		if !validTopicFilter(filter) {
+			suback.ReturnCodes[i] = _subscriptionFailure
+			continue
+		}
+		qos := min(subscribe.Qoss[i], _maxGrantedQoS)
+		granted[filter] = qos
+		suback.ReturnCodes[i] = qos
+	}
+	session.subscribe(granted)
+	session.send(suback)
+
+	// Deliveries may wait for the session queue, so they are made without holding the lock.
+	type delivery struct {
+		publish *packets.PublishPacket
+		qos     byte
+	}
+	var deliveries []delivery
+	b.mu.RLock()
+	for topic, retained := range b.retained {
+		for filter, qos := range granted {
+			if topicMatchesFilter(filter, topic) {
+				deliveries = append(deliveries, delivery{retained, min(retained.Qos, qos)})
+				break
+			}
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, delivery := range deliveries {
+		session.deliver(delivery.publish, delivery.qos, true)
+	}
+}
+
+func newBrokerSession(clientID string, conn net.Conn) *brokerSession {
+	return &brokerSession{
+		clientID:      clientID,
+		conn:          conn,
+		outbound:      make(chan packets.ControlPacket, _sessionQueueCapacity),
+		done:          make(chan struct{}),
+		subscriptions: make(map[string]byte),
+	}
+}
+
+// brokerSession is a connected client. Packets are written by a single goroutine from a bounded
+// queue. When it is full, QoS 0 messages to a slow client are dropped, while acknowledgements and
+// QoS 1 messages wait for room; clients that stop reading are disconnected after _writeTimeout.
+type brokerSession struct {
+	clientID      string
+	conn          net.Conn
+	outbound      chan packets.ControlPacket
+	done          chan struct{}
+	closeOnce     sync.Once
+	replaced      atomic.Bool
+	mu            sync.RWMutex
+	subscriptions map[string]byte
+	messageID     atomic.Uint32
+}
+
+func (s *brokerSession) subscribe(granted map[string]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for filter, qos := range granted {
+		s.subscriptions[filter] = qos
+	}
+}
+
+func (s *brokerSession) unsubscribe(filters []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, filter := range filters {
+		delete(s.subscriptions, filter)
+	}
+}
+
+func (s *brokerSession) grantedQoS(topic string) (byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var granted byte
+	matched := false
+	for filter, qos := range s.subscriptions {
+		if topicMatchesFilter(filter, topic) {
+			granted = max(granted, qos)
+			matched = true
+		}
+	}
+	return granted, matched
+}
+
+func (s *brokerSession) deliver(publish *packets.PublishPacket, qos byte, retained bool) {
+	delivery := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket) //nolint:forcetypeassert // created by type
+	delivery.TopicName = publish.TopicName
+	delivery.Payload = publish.Payload
+	delivery.Qos = qos
+	delivery.Retain = retained
+	if qos == 0 {
+		s.offer(delivery)
+		return
+	}
+	delivery.MessageID = s.nextMessageID()
+	s.send(delivery)
+}
+
+// nextMessageID returns packet identifiers from 1 to 65535, as 0 is not a valid one.
+func (s *brokerSession) nextMessageID() uint16 {
+	return uint16(s.messageID.Add(1)%65535) + 1 //nolint:gosec // bounded by the modulo
+}
+
+// send queues the packet, waiting for room until the session is closed.
+func (s *brokerSession) send(packet packets.ControlPacket) {
+	select {
+	case <-s.done:
+	case s.outbound <- packet:
+	}
+}
+
+// offer queues the packet unless the queue is full, for messages that may be lost.
+func (s *brokerSession) offer(packet packets.ControlPacket) {
+	select {
+	case <-s.done:
+	case s.outbound <- packet:
+	default:
+		slog.Warn("dropping MQTT packet for slow client", slog.String("client_id", s.clientID), slog.String("packet", packet.String()))
+	}
+}
+
+func (s *brokerSession) write() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case packet := <-s.outbound:
+			_ = s.conn.SetWriteDeadline(time.Now().Add(_writeTimeout))
+			if err := packet.Write(s.conn); err != nil {
+				s.close()
+				return
+			}
+		}
+	}
+}
+
+func (s *brokerSession) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		_ = s.conn.Close()
+	})
+}
+
+// validTopicName reports whether messages may be published on the topic, which must not have
+// wildcards.
+func validTopicName(topic string) bool {
+	return topic != "" && !strings.ContainsAny(topic, "+#")
+}
+
+func validTopicFilter(filter string) bool {
+	if filter == "" {
+		return false
+	}
+	levels := strings.Split(filter, "/")
+	for i, level := range levels {
+		switch {
+		case level == "#":
+			if i != len(levels)-1 {
+				return false
+			}
+		case level == "+":
+		case strings.ContainsAny(level, "+#"):
+			return false
+		}
+	}
+	return true
+}
+
+// topicMatchesFilter matches a topic name against a filter with MQTT wildcards. Topics starting
+// with $ are not matched by a leading wildcard.
+func topicMatchesFilter(filter, topic string) bool {
+	if filter == topic {
+		return true
+	}
+	if strings.HasPrefix(topic, "$") && (strings.HasPrefix(filter, "+") || strings.HasPrefix(filter, "#")) {
+		return false
+	}
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if level != "+" && level != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
@@ -0,0 +1,338 @@
+package mqtt_test
+
+import (
+	"context"
+	"net"
+	"time"
+	"zensor-server/internal/infra/mqtt"
+	"zensor-server/internal/infra/node"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/eclipse/paho.mqtt.golang/packets"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("EmbeddedBroker", func() {
+	var broker *mqtt.EmbeddedBroker
+
+	startBroker := func(opts mqtt.EmbeddedBrokerOpts) {
+		opts.Address = "127.0.0.1:0"
+		broker = mqtt.NewEmbeddedBroker(opts)
+		gomega.Expect(broker.Start()).To(gomega.Succeed())
+	}
+
+	connect := func(clientID string, configure ...func(*paho.ClientOptions)) (paho.Client, paho.Token) {
+		opts := paho.NewClientOptions().
+			AddBroker("tcp://" + broker.Addr()).
+			SetClientID(clientID).
+			SetAutoReconnect(false)
+		for _, apply := range configure {
+			apply(opts)
+		}
+		client := paho.NewClient(opts)
+		token := client.Connect()
+		gomega.Expect(token.WaitTimeout(5 * time.Second)).To(gomega.BeTrue())
+		ginkgo.DeferCleanup(func() { client.Disconnect(0) })
+		return client, token
+	}
+
+	mustConnect := func(clientID string, configure ...func(*paho.ClientOptions)) paho.Client {
+		client, token := connect(clientID, configure...)
+		gomega.Expect(token.Error()).NotTo(gomega.HaveOccurred())
+		return client
+	}
+
+	type received struct {
+		topic    string
+		payload  string
+		qos      byte
+		retained bool
+	}
+
+	subscribe := func(client paho.Client, filter string, qos byte) chan received {
+		messages := make(chan received, 10)
+		token := client.Subscribe(filter, qos, func(_ paho.Client, msg paho.Message) {
+			messages <- received{msg.Topic(), string(msg.Payload()), msg.Qos(), msg.Retained()}
+		})
+		gomega.Expect(token.WaitTimeout(5 * time.Second)).To(gomega.BeTrue())
+		gomega.Expect(token.Error()).NotTo(gomega.HaveOccurred())
+		return messages
+	}
+
+	publish := func(client paho.Client, topic string, qos byte, retained bool, payload string) {
+		token := client.Publish(topic, qos, retained, payload)
+		gomega.Expect(token.WaitTimeout(5 * time.Second)).To(gomega.BeTrue())
+		gomega.Expect(token.Error()).NotTo(gomega.HaveOccurred())
+	}
+
+	// rawConnect connects without a client library, to control which packets are read and when.
+	rawConnect := func(clientID string, cleanSession bool) (net.Conn, *packets.ConnackPacket) {
+		conn, err := net.Dial("tcp", broker.Addr())
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		ginkgo.DeferCleanup(func() { _ = conn.Close() })
+
+		connectPacket := packets.NewControlPacket(packets.Connect).(*packets.ConnectPacket) //nolint:forcetypeassert // created by type
+		connectPacket.ProtocolName = "MQTT"
+		connectPacket.ProtocolVersion = 4
+		connectPacket.CleanSession = cleanSession
+		connectPacket.ClientIdentifier = clientID
+		gomega.Expect(connectPacket.Write(conn)).To(gomega.Succeed())
+
+		connack, err := packets.ReadPacket(conn)
+		gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		gomega.Expect(connack).To(gomega.BeAssignableToTypeOf(&packets.ConnackPacket{}))
+		return conn, connack.(*packets.ConnackPacket) //nolint:forcetypeassert // checked above
+	}
+
+	ginkgo.AfterEach(func() {
+		broker.Shutdown()
+	})
+
+	ginkgo.When("clients publish", func() {
+		ginkgo.BeforeEach(func() {
+			startBroker(mqtt.EmbeddedBrokerOpts{})
+		})
+
+		ginkgo.It("should route the messages to the matching subscriptions", func() {
+			subscriber := mustConnect("subscriber")
+			devices := subscribe(subscriber, "v3/app/devices/+/up", 1)
+			everything := subscribe(subscriber, "other/#", 0)
+
+			publisher := mustConnect("publisher")
+			publish(publisher, "v3/app/devices/device-1/up", 1, false, "uplink")
+			publish(publisher, "v3/app/devices/device-1/down/ack", 1, false, "ignored")
+			publish(publisher, "other/a/b", 1, false, "nested")
+
+			gomega.Eventually(devices).Should(gomega.Receive(gomega.Equal(received{"v3/app/devices/device-1/up", "uplink", 1, false})))
+			gomega.Eventually(everything).Should(gomega.Receive(gomega.Equal(received{"other/a/b", "nested", 0, false})))
+			gomega.Consistently(devices, 200*time.Millisecond).ShouldNot(gomega.Receive())
+		})
+
+		ginkgo.It("should deliver the retained messages to new subscriptions until cleared", func() {
+			publisher := mustConnect("publisher")
+			publish(publisher, "zensor/tenant/device/temperature/1", 1, true, "21.5")
+
+			subscriber := mustConnect("subscriber")
+			messages := subscribe(subscriber, "zensor/#", 1)
+			gomega.Eventually(messages).Should(gomega.Receive(gomega.Equal(received{"zensor/tenant/device/temperature/1", "21.5", 1, true})))
+
+			publish(publisher, "zensor/tenant/device/temperature/1", 1, true, "")
+			gomega.Eventually(messages).Should(gomega.Receive(gomega.Equal(received{"zensor/tenant/device/temperature/1", "", 1, false})))
+
+			late := subscribe(mustConnect("late-subscriber"), "zensor/#", 1)
+			gomega.Consistently(late, 200*time.Millisecond).ShouldNot(gomega.Receive())
+		})
+
+		ginkgo.It("should stop routing messages after unsubscribing", func() {
+			subscriber := mustConnect("subscriber")
+			messages := subscribe(subscriber, "topic", 0)
+			token := subscriber.Unsubscribe("topic")
+			gomega.Expect(token.WaitTimeout(5 * time.Second)).To(gomega.BeTrue())
+
+			publish(mustConnect("publisher"), "topic", 0, false, "value")
+			gomega.Consistently(messages, 200*time.Millisecond).ShouldNot(gomega.Receive())
+		})
+
+		ginkgo.It("should publish the will of clients that drop their connection", func() {
+			wills := subscribe(mustConnect("subscriber"), "status/+", 0)
+
+			conn, err := net.Dial("tcp", broker.Addr())
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			connectPacket := packets.NewControlPacket(packets.Connect).(*packets.ConnectPacket) //nolint:forcetypeassert // created by type
+			connectPacket.ProtocolName = "MQTT"
+			connectPacket.ProtocolVersion = 4
+			connectPacket.CleanSession = true
+			connectPacket.ClientIdentifier = "device"
+			connectPacket.WillFlag = true
+			connectPacket.WillTopic = "status/device"
+			connectPacket.WillMessage = []byte("offline")
+			gomega.Expect(connectPacket.Write(conn)).To(gomega.Succeed())
+			connack, err := packets.ReadPacket(conn)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(connack).To(gomega.HaveField("ReturnCode", byte(packets.Accepted)))
+
+			gomega.Expect(conn.Close()).To(gomega.Succeed())
+			gomega.Eventually(wills).Should(gomega.Receive(gomega.Equal(received{"status/device", "offline", 0, false})))
+		})
+
+		ginkgo.It("should not publish the will of clients that disconnect", func() {
+			wills := subscribe(mustConnect("subscriber"), "status/+", 0)
+			device := mustConnect("device", func(opts *paho.ClientOptions) {
+				opts.SetWill("status/device", "offline", 0, false)
+			})
+
+			device.Disconnect(100)
+			gomega.Consistently(wills, 200*time.Millisecond).ShouldNot(gomega.Receive())
+		})
+
+		ginkgo.It("should serve the MQTT client", func() {
+			subscriber := mustConnect("subscriber")
+			messages := subscribe(subscriber, "downlinks/#", 1)
+
+			client, err := mqtt.NewSimpleClient(mqtt.SimpleClientOpts{
+				Name:     "embedded",
+				Broker:   "tcp://" + broker.Addr(),
+				ClientID: "simple-client",
+				QoS:      1,
+			})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			defer client.Disconnect()
+
+			gomega.Eventually(func() error {
+				return client.Publish(context.Background(), "downlinks/device-1", map[string]int{"value": 1})
+			}, 5*time.Second).Should(gomega.Succeed())
+			gomega.Eventually(messages).Should(gomega.Receive(gomega.Equal(received{"downlinks/device-1", `{"value":1}`, 1, false})))
+		})
+	})
+
+	ginkgo.When("clients ask for what the broker does not keep", func() {
+		ginkgo.BeforeEach(func() {
+			startBroker(mqtt.EmbeddedBrokerOpts{})
+		})
+
+		ginkgo.It("should refuse persistent sessions", func() {
+			_, token := connect("persistent", func(opts *paho.ClientOptions) {
+				opts.SetCleanSession(false)
+			})
+			gomega.Expect(token.Error()).To(gomega.MatchError(packets.ErrorRefusedServerUnavailable))
+		})
+
+		ginkgo.It("should close the connection of QoS 2 publishers without routing the message", func() {
+			messages := subscribe(mustConnect("subscriber"), "topic", 1)
+			conn, connack := rawConnect("publisher", true)
+			gomega.Expect(connack.ReturnCode).To(gomega.Equal(byte(packets.Accepted)))
+
+			publishPacket := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket) //nolint:forcetypeassert // created by type
+			publishPacket.Qos = 2
+			publishPacket.MessageID = 1
+			publishPacket.TopicName = "topic"
+			publishPacket.Payload = []byte("exactly once")
+			gomega.Expect(publishPacket.Write(conn)).To(gomega.Succeed())
+
+			_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			_, err := packets.ReadPacket(conn)
+			gomega.Expect(err).To(gomega.HaveOccurred())
+			gomega.Consistently(messages, 200*time.Millisecond).ShouldNot(gomega.Receive())
+		})
+	})
+
+	ginkgo.When("a subscriber falls behind", func() {
+		const messages = 600
+
+		ginkgo.BeforeEach(func() {
+			startBroker(mqtt.EmbeddedBrokerOpts{})
+		})
+
+		ginkgo.It("should deliver every QoS 1 message once it reads again", func() {
+			conn, _ := rawConnect("slow-subscriber", true)
+			subscribePacket := packets.NewControlPacket(packets.Subscribe).(*packets.SubscribePacket) //nolint:forcetypeassert // created by type
+			subscribePacket.MessageID = 1
+			subscribePacket.Topics = []string{"bulk"}
+			subscribePacket.Qoss = []byte{1}
+			gomega.Expect(subscribePacket.Write(conn)).To(gomega.Succeed())
+			suback, err := packets.ReadPacket(conn)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(suback).To(gomega.HaveField("ReturnCodes", []byte{1}))
+
+			// The payloads overflow the socket buffers and the session queue while the subscriber
+			// does not read.
+			publisher := mustConnect("publisher")
+			payload := make([]byte, 64*1024)
+			published := make(chan struct{})
+			go func() {
+				defer ginkgo.GinkgoRecover()
+				defer close(published)
+				for range messages {
+					token := publisher.Publish("bulk", 1, false, payload)
+					gomega.Expect(token.WaitTimeout(5 * time.Second)).To(gomega.BeTrue())
+				}
+			}()
+			time.Sleep(200 * time.Millisecond)
+
+			received := 0
+			_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+			for received < messages {
+				packet, err := packets.ReadPacket(conn)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				if _, ok := packet.(*packets.PublishPacket); ok {
+					received++
+				}
+			}
+			gomega.Eventually(published).Should(gomega.BeClosed())
+		})
+	})
+
+	ginkgo.When("the MQTT client subscribes", func() {
+		ginkgo.BeforeEach(func() {
+			startBroker(mqtt.EmbeddedBrokerOpts{})
+		})
+
+		ginkgo.It("should deliver every QoS 0 message on a topic", func() {
+			client, err := mqtt.NewSimpleClient(mqtt.SimpleClientOpts{
+				Name:     "subscriber",
+				Broker:   "tcp://" + broker.Addr(),
+				ClientID: "simple-subscriber",
+			})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			defer client.Disconnect()
+
+			payloads := make(chan string, 10)
+			gomega.Expect(client.Subscribe("devices/device-1/up", 0, func(_ mqtt.Client, msg mqtt.Message) {
+				payloads <- string(msg.Payload())
+			})).To(gomega.Succeed())
+			gomega.Eventually(func() bool { return node.GetNodeInfo().Components["mqtt.subscriber"] }, 5*time.Second).Should(gomega.BeTrue())
+
+			publisher := mustConnect("publisher")
+			publish(publisher, "devices/device-1/up", 0, false, "first")
+			publish(publisher, "devices/device-1/up", 0, false, "second")
+
+			gomega.Eventually(payloads).Should(gomega.Receive(gomega.Equal("first")))
+			gomega.Eventually(payloads).Should(gomega.Receive(gomega.Equal("second")))
+		})
+	})
+
+	ginkgo.When("clients connect with a will", func() {
+		ginkgo.BeforeEach(func() {
+			startBroker(mqtt.EmbeddedBrokerOpts{})
+		})
+
+		ginkgo.It("should refuse wills on topics with wildcards", func() {
+			_, token := connect("client", func(opts *paho.ClientOptions) {
+				opts.SetWill("devices/+/status", "offline", 0, false)
+			})
+			gomega.Expect(token.Error()).To(gomega.HaveOccurred())
+		})
+	})
+
+	ginkgo.When("the address is not a loopback one", func() {
+		ginkgo.It("should refuse to start without credentials", func() {
+			broker = mqtt.NewEmbeddedBroker(mqtt.EmbeddedBrokerOpts{Address: ":0"})
+			gomega.Expect(broker.Start()).To(gomega.MatchError(mqtt.ErrEmbeddedBrokerExposed))
+		})
+
+		ginkgo.It("should start with credentials", func() {
+			broker = mqtt.NewEmbeddedBroker(mqtt.EmbeddedBrokerOpts{Address: ":0", Username: "zensor", Password: "secret"})
+			gomega.Expect(broker.Start()).To(gomega.Succeed())
+		})
+	})
+
+	ginkgo.When("credentials are configured", func() {
+		ginkgo.BeforeEach(func() {
+			startBroker(mqtt.EmbeddedBrokerOpts{Username: "zensor", Password: "secret"})
+		})
+
+		ginkgo.It("should accept clients with the credentials", func() {
+			mustConnect("client", func(opts *paho.ClientOptions) {
+				opts.SetUsername("zensor").SetPassword("secret")
+			})
+		})
+
+		ginkgo.It("should refuse clients with other credentials", func() {
+			_, token := connect("client", func(opts *paho.ClientOptions) {
+				opts.SetUsername("zensor").SetPassword("wrong")
+			})
+			gomega.Expect(token.Error()).To(gomega.MatchError(gomega.ContainSubstring("not Authorized")))
+		})
+	})
+})
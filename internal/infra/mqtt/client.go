@@ -3,21 +3,26 @@ package mqtt
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
+	"zensor-server/internal/infra/node"
 
 	paho "github.com/eclipse/paho.mqtt.golang"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	_defaultQoS            = 0 // At most once
+	_defaultName           = "mqtt"
 	_defaultRetained       = false
 	_publishTimeout        = 5 * time.Second
 	_maxReconnectInterval  = 1 * time.Minute
@@ -26,18 +31,69 @@ const (
 	_disconnectQuiesceMsec = 5000
 )
 
+// ErrNotConnected is returned when publishing while disconnected from the broker without an
+// outbound queue.
+var ErrNotConnected = errors.New("not connected to the MQTT broker")
+
 type Client interface {
 	Subscribe(topic string, qos byte, callback MessageHandler) error
-	Publish(ctx context.Context, topic string, msg any) error
+	Unsubscribe(topics ...string) error
+	Publish(ctx context.Context, topic string, msg any, opts ...PublishOption) error
 
 	Disconnect()
 }
 
+type publishOptions struct {
+	qos      *byte
+	retained bool
+	unqueued bool
+}
+
+// PublishOption overrides how a single message is published.
+type PublishOption func(*publishOptions)
+
+// WithQoS publishes the message with the given quality of service instead of the client default.
+func WithQoS(qos byte) PublishOption {
+	return func(o *publishOptions) {
+		o.qos = &qos
+	}
+}
+
+// WithRetain asks the broker to keep the message as the last value of the topic.
+func WithRetain() PublishOption {
+	return func(o *publishOptions) {
+		o.retained = true
+	}
+}
+
+// WithoutQueue fails with ErrNotConnected while disconnected instead of queueing the message, for
+// messages that must not reach the broker late, such as downlinks actuating devices. The message
+// skips ahead of the ones still queued.
+func WithoutQueue() PublishOption {
+	return func(o *publishOptions) {
+		o.unqueued = true
+	}
+}
+
 type SimpleClientOpts struct {
+	// Name identifies the connection in metrics and in the components reported by /healthz.
+	Name     string
 	Broker   string
 	ClientID string
 	Username string
 	Password string
+	// QoS is the quality of service of the messages published without WithQoS.
+	QoS byte
+	// PersistentSession keeps the session on the broker across reconnections, so subscriptions
+	// and QoS 1 and 2 messages sent while disconnected are not lost. ClientID is then used as is
+	// and must be unique to each replica.
+	PersistentSession bool
+	// Queue holds the messages published while disconnected until the client reconnects. Without
+	// a queue, publishing while disconnected fails.
+	Queue OutboundQueue
+	// TLS secures the connection to brokers with a ssl://, tls://, tcps://, mqtts:// or wss://
+	// URL and is ignored for plain ones.
+	TLS TLSOpts
 }
 
 // Subscription tracks a topic subscription for reconnection recovery.
@@ -47,22 +103,53 @@ type subscription struct {
 	callback MessageHandler
 }
 
-func NewSimpleClient(opts SimpleClientOpts) *SimpleClient {
+func NewSimpleClient(opts SimpleClientOpts) (*SimpleClient, error) {
+	if opts.Name == "" {
+		opts.Name = _defaultName
+	}
+
+	var tlsConfig *tls.Config
+	switch {
+	case usesTLS(opts.Broker):
+		config, err := opts.TLS.Config()
+		if err != nil {
+			return nil, fmt.Errorf("configuring TLS for MQTT client %s: %w", opts.Name, err)
+		}
+		tlsConfig = config
+	case !opts.TLS.isZero():
+		slog.Warn("ignoring TLS settings for a plain MQTT broker URL",
+			slog.String("client", opts.Name),
+			slog.String("broker", opts.Broker),
+		)
+	}
+
 	simpleClient := &SimpleClient{
+		name:          opts.Name,
+		qos:           opts.QoS,
+		queue:         opts.Queue,
 		subscriptions: make(map[string]subscription),
 		mu:            sync.RWMutex{},
 	}
-
-	// Generate a unique client ID to prevent session conflicts
-	uniqueClientID := fmt.Sprintf("%s-%s", opts.ClientID, uuid.NewString()[:8])
+	simpleClient.initializeMetrics()
+	node.SetComponentHealth(simpleClient.component(), false)
+
+	// Clean sessions get a unique client ID to prevent session conflicts between replicas, while
+	// persistent sessions need the same one on every connection to be resumed.
+	uniqueClientID := opts.ClientID
+	if !opts.PersistentSession {
+		uniqueClientID = fmt.Sprintf("%s-%s", opts.ClientID, uuid.NewString()[:8])
+	}
 
 	onConnectHandler := func(client paho.Client) {
 		slog.Info("connected to MQTT broker", "client_id", uniqueClientID)
+		simpleClient.setConnected(true)
 		simpleClient.resubscribeAll(client)
+		go simpleClient.replay()
 	}
 
 	onConnectionLostHandler := func(_ paho.Client, err error) {
 		slog.Error("connection lost to MQTT broker", "error", err, "client_id", uniqueClientID)
+		simpleClient.setConnected(false)
 	}
 
 	pahoOpts := paho.NewClientOptions().
@@ -75,13 +162,17 @@ func NewSimpleClient(opts SimpleClientOpts) *SimpleClient {
 		SetConnectionLostHandler(onConnectionLostHandler).
 		SetKeepAlive(10 * time.Second).
 		SetConnectTimeout(5 * time.Second).
-		SetCleanSession(true).                          // Always start with a clean session
+		SetCleanSession(!opts.PersistentSession).
+		SetResumeSubs(opts.PersistentSession).
 		SetMaxReconnectInterval(_maxReconnectInterval). // Limit reconnection attempts
 		SetConnectRetry(true).                          // Keep retrying the initial connection instead of failing hard
 		SetConnectRetryInterval(_connectRetryInterval).
 		SetDefaultPublishHandler(func(client paho.Client, msg paho.Message) {
 			slog.Debug("received message on default handler", "topic", msg.Topic())
 		})
+	if tlsConfig != nil {
+		pahoOpts.SetTLSConfig(tlsConfig)
+	}
 
 	client := paho.NewClient(pahoOpts)
 	simpleClient.client = client
@@ -99,16 +190,26 @@ func NewSimpleClient(opts SimpleClientOpts) *SimpleClient {
 		)
 	}
 
-	return simpleClient
+	return simpleClient, nil
 }
 
 var _ Client = (*SimpleClient)(nil)
 
 type SimpleClient struct {
+	name          string
+	qos           byte
 	client        paho.Client
 	subscriptions map[string]subscription
 	mu            sync.RWMutex
 	processedMsgs sync.Map // Track processed message IDs to prevent duplicates
+
+	queue     OutboundQueue
+	replaying atomic.Bool
+	connected atomic.Bool
+
+	connectionEvents    metric.Int64Counter
+	droppedMessages     metric.Int64Counter
+	metricsRegistration metric.Registration
 }
 
 // resubscribeAll re-establishes all subscriptions after reconnection.
@@ -135,6 +236,13 @@ func (c *SimpleClient) resubscribeAll(client paho.Client) {
 
 func (c *SimpleClient) subscribeOnBroker(client paho.Client, sub subscription) error {
 	pahoCallback := func(_ paho.Client, msg paho.Message) {
+		// QoS 0 messages have no ID and are never redelivered, so only QoS 1 and 2 ones are checked
+		// for duplicates.
+		if msg.Qos() == 0 {
+			sub.callback(c, msg)
+			return
+		}
+
 		// Check for duplicate messages
 		msgKey := fmt.Sprintf("%s-%d", msg.Topic(), msg.MessageID())
 		if _, exists := c.processedMsgs.LoadOrStore(msgKey, true); exists {
@@ -188,6 +296,33 @@ func (c *SimpleClient) Subscribe(topic string, qos byte, callback MessageHandler
 	return nil
 }
 
+// Unsubscribe removes the topics from the broker and forgets them, so they are
+// not restored after a reconnection.
+func (c *SimpleClient) Unsubscribe(topics ...string) error {
+	if len(topics) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	for _, topic := range topics {
+		delete(c.subscriptions, topic)
+	}
+	c.mu.Unlock()
+
+	if !c.client.IsConnectionOpen() {
+		return nil
+	}
+
+	token := c.client.Unsubscribe(topics...)
+	token.WaitTimeout(_subscribeWaitTimeout)
+	if token.Error() != nil {
+		return fmt.Errorf("unsubscribing from topics %v: %w", topics, token.Error())
+	}
+
+	slog.Info("unsubscribed from MQTT topics", "topics", topics)
+	return nil
+}
+
 type MessageHandler func(Client, Message)
 
 type Message = paho.Message
@@ -202,9 +337,19 @@ func (c *SimpleClient) Disconnect() {
 	c.processedMsgs = sync.Map{}
 
 	c.client.Disconnect(_disconnectQuiesceMsec)
+	c.setConnected(false)
+
+	if c.metricsRegistration != nil {
+		if err := c.metricsRegistration.Unregister(); err != nil {
+			slog.Warn("unregistering MQTT client metrics", slog.Any("error", err))
+		}
+	}
 }
 
-func (c *SimpleClient) Publish(ctx context.Context, topic string, msg any) error {
+// Publish sends the message to the broker. While disconnected, and until the messages queued
+// meanwhile are replayed, messages go to the queue so they reach the broker in order, unless
+// published WithoutQueue.
+func (c *SimpleClient) Publish(ctx context.Context, topic string, msg any, opts ...PublishOption) error {
 	tracer := otel.Tracer("zensor-server")
 	_, span := tracer.Start(ctx, "mqtt.publish",
 		trace.WithAttributes(
@@ -223,12 +368,199 @@ func (c *SimpleClient) Publish(ctx context.Context, topic string, msg any) error
 		return fmt.Errorf("marshaling message: %w", err)
 	}
 
-	token := c.client.Publish(topic, _defaultQoS, _defaultRetained, payload)
+	options := publishOptions{retained: _defaultRetained}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	queued := QueuedMessage{Topic: topic, QoS: c.qos, Retained: options.retained, Payload: payload}
+	if options.qos != nil {
+		queued.QoS = *options.qos
+	}
+
+	if c.queue != nil && !options.unqueued && (!c.client.IsConnectionOpen() || c.queue.Len() > 0) {
+		if err := c.enqueue(ctx, queued); err != nil {
+			span.RecordError(err)
+			return err
+		}
+		span.SetAttributes(attribute.Bool("messaging.mqtt.queued", true))
+		go c.replay()
+		return nil
+	}
+
+	// paho accepts messages while it is still connecting and holds them in memory, where they
+	// would be lost on restart, so publishing without a queue fails instead.
+	if !c.client.IsConnectionOpen() {
+		err := fmt.Errorf("publishing to topic %s: %w", topic, ErrNotConnected)
+		span.RecordError(err)
+		return err
+	}
+
+	if err := c.publish(queued); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *SimpleClient) publish(msg QueuedMessage) error {
+	token := c.client.Publish(msg.Topic, msg.QoS, msg.Retained, msg.Payload)
 	token.WaitTimeout(_publishTimeout)
 	if token.Error() != nil {
-		span.RecordError(token.Error())
-		return fmt.Errorf("publishing to topic %s: %w", topic, token.Error())
+		return fmt.Errorf("publishing to topic %s: %w", msg.Topic, token.Error())
 	}
 
 	return nil
 }
+
+func (c *SimpleClient) enqueue(ctx context.Context, msg QueuedMessage) error {
+	dropped, err := c.queue.Push(msg)
+	if err != nil {
+		return fmt.Errorf("queueing message to topic %s: %w", msg.Topic, err)
+	}
+
+	slog.Debug("MQTT broker not connected, message queued", "client", c.name, "topic", msg.Topic)
+	if dropped {
+		slog.Warn("MQTT outbound queue full, oldest message dropped", "client", c.name)
+		if c.droppedMessages != nil {
+			c.droppedMessages.Add(ctx, 1, metric.WithAttributes(attribute.String("client", c.name)))
+		}
+	}
+
+	return nil
+}
+
+// replay publishes the queued messages in order while connected. Only one replay runs at a time;
+// a message whose publication fails stays queued for the next reconnection.
+func (c *SimpleClient) replay() {
+	if c.queue == nil {
+		return
+	}
+
+	for c.queue.Len() > 0 && c.client.IsConnectionOpen() {
+		if !c.replaying.CompareAndSwap(false, true) {
+			return
+		}
+		err := c.drain()
+		c.replaying.Store(false)
+		if err != nil {
+			slog.Warn("replaying queued MQTT messages, retrying on reconnection", "client", c.name, "error", err)
+			return
+		}
+	}
+}
+
+func (c *SimpleClient) drain() error {
+	replayed := 0
+	defer func() {
+		if replayed > 0 {
+			slog.Info("replayed queued MQTT messages", "client", c.name, "count", replayed)
+		}
+	}()
+
+	for c.client.IsConnectionOpen() {
+		msg, found, err := c.queue.Peek()
+		if err != nil {
+			return err
+		}
+		if !found {
+			return nil
+		}
+
+		if err := c.publish(msg); err != nil {
+			return err
+		}
+		if err := c.queue.Pop(); err != nil {
+			return err
+		}
+		replayed++
+	}
+
+	return nil
+}
+
+// setConnected tracks the connection state reported as the mqtt.{name} component of the node.
+func (c *SimpleClient) setConnected(connected bool) {
+	c.connected.Store(connected)
+	node.SetComponentHealth(c.component(), connected)
+
+	if c.connectionEvents != nil {
+		event := "lost"
+		if connected {
+			event = "connected"
+		}
+		c.connectionEvents.Add(context.Background(), 1, metric.WithAttributes(
+			attribute.String("client", c.name),
+			attribute.String("event", event),
+		))
+	}
+}
+
+func (c *SimpleClient) component() string {
+	return "mqtt." + c.name
+}
+
+func (c *SimpleClient) initializeMetrics() {
+	meter := otel.Meter("mqtt-client")
+
+	connectionEvents, err := meter.Int64Counter(
+		"zensor_server.mqtt.connection.events",
+		metric.WithDescription("Total number of connections to and disconnections from the MQTT broker"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		slog.Warn("creating MQTT connection events counter", slog.Any("error", err))
+		return
+	}
+
+	droppedMessages, err := meter.Int64Counter(
+		"zensor_server.mqtt.queue.dropped",
+		metric.WithDescription("Total number of queued messages dropped because the outbound queue was full"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		slog.Warn("creating MQTT dropped messages counter", slog.Any("error", err))
+		return
+	}
+
+	connected, err := meter.Int64ObservableGauge(
+		"zensor_server.mqtt.connected",
+		metric.WithDescription("Whether the client is connected to the MQTT broker"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		slog.Warn("creating MQTT connected gauge", slog.Any("error", err))
+		return
+	}
+
+	queueDepth, err := meter.Int64ObservableGauge(
+		"zensor_server.mqtt.queue.depth",
+		metric.WithDescription("Number of messages waiting in the outbound queue for the client to reconnect"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		slog.Warn("creating MQTT queue depth gauge", slog.Any("error", err))
+		return
+	}
+
+	registration, err := meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		attributes := metric.WithAttributes(attribute.String("client", c.name))
+		var value int64
+		if c.connected.Load() {
+			value = 1
+		}
+		observer.ObserveInt64(connected, value, attributes)
+		if c.queue != nil {
+			observer.ObserveInt64(queueDepth, int64(c.queue.Len()), attributes)
+		}
+		return nil
+	}, connected, queueDepth)
+	if err != nil {
+		slog.Warn("registering MQTT client metrics callback", slog.Any("error", err))
+		return
+	}
+
+	c.connectionEvents = connectionEvents
+	c.droppedMessages = droppedMessages
+	c.metricsRegistration = registration
+}
@@ -3,6 +3,7 @@ package mqtt_test
 import (
 	"context"
 	"zensor-server/internal/infra/mqtt"
+	"zensor-server/internal/infra/node"
 
 	paho "github.com/eclipse/paho.mqtt.golang"
 	"github.com/onsi/ginkgo/v2"
@@ -35,6 +36,12 @@ var _ = ginkgo.Describe("MQTT Client", func() {
 	ginkgo.Context("NewSimpleClient", func() {
 		var opts mqtt.SimpleClientOpts
 
+		newClient := func() *mqtt.SimpleClient {
+			client, err := mqtt.NewSimpleClient(opts)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			return client
+		}
+
 		ginkgo.When("the broker is unreachable", func() {
 			ginkgo.BeforeEach(func() {
 				opts = mqtt.SimpleClientOpts{
@@ -46,18 +53,60 @@ var _ = ginkgo.Describe("MQTT Client", func() {
 			})
 
 			ginkgo.It("should not panic", func() {
-				gomega.Expect(func() { mqtt.NewSimpleClient(opts) }).NotTo(gomega.Panic())
+				gomega.Expect(func() { _, _ = mqtt.NewSimpleClient(opts) }).NotTo(gomega.Panic())
 			})
 
 			ginkgo.It("should return a usable client", func() {
-				gomega.Expect(mqtt.NewSimpleClient(opts)).NotTo(gomega.BeNil())
+				gomega.Expect(newClient()).NotTo(gomega.BeNil())
 			})
 
 			ginkgo.It("should defer subscriptions instead of failing", func() {
-				client := mqtt.NewSimpleClient(opts)
+				client := newClient()
 				err := client.Subscribe("any/topic", 0, func(mqtt.Client, mqtt.Message) {})
 				gomega.Expect(err).NotTo(gomega.HaveOccurred())
 			})
+
+			ginkgo.It("should report its connection as down", func() {
+				opts.Name = "unreachable"
+				newClient()
+				gomega.Expect(node.GetNodeInfo().Components).To(gomega.HaveKeyWithValue("mqtt.unreachable", false))
+			})
+
+			ginkgo.It("should fail to publish without a queue", func() {
+				client := newClient()
+				err := client.Publish(context.Background(), "any/topic", map[string]string{"k": "v"})
+				gomega.Expect(err).To(gomega.MatchError(mqtt.ErrNotConnected))
+			})
+
+			ginkgo.It("should fail to publish messages that must not be queued", func() {
+				queue := mqtt.NewMemoryQueue(10)
+				opts.Queue = queue
+				client := newClient()
+
+				err := client.Publish(context.Background(), "any/topic", map[string]string{"k": "v"}, mqtt.WithoutQueue())
+				gomega.Expect(err).To(gomega.MatchError(mqtt.ErrNotConnected))
+				gomega.Expect(queue.Len()).To(gomega.BeZero())
+			})
+
+			ginkgo.It("should queue the messages published until it connects", func() {
+				queue := mqtt.NewMemoryQueue(10)
+				opts.Queue = queue
+				opts.QoS = 1
+				client := newClient()
+
+				gomega.Expect(client.Publish(context.Background(), "any/topic", map[string]string{"k": "v"})).To(gomega.Succeed())
+				gomega.Expect(client.Publish(context.Background(), "other/topic", "value", mqtt.WithQoS(2), mqtt.WithRetain())).To(gomega.Succeed())
+
+				gomega.Expect(queue.Len()).To(gomega.Equal(2))
+				first, _, err := queue.Peek()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(first).To(gomega.Equal(mqtt.QueuedMessage{Topic: "any/topic", QoS: 1, Payload: []byte(`{"k":"v"}`)}))
+
+				gomega.Expect(queue.Pop()).To(gomega.Succeed())
+				second, _, err := queue.Peek()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(second).To(gomega.Equal(mqtt.QueuedMessage{Topic: "other/topic", QoS: 2, Retained: true, Payload: []byte(`"value"`)}))
+			})
 		})
 	})
 
@@ -16,8 +16,13 @@ func (c *NoOpClient) Subscribe(topic string, qos byte, callback MessageHandler)
 	return nil
 }
 
+// Unsubscribe implements Client.
+func (c *NoOpClient) Unsubscribe(topics ...string) error {
+	return nil
+}
+
 // Publish implements Client.
-func (c *NoOpClient) Publish(ctx context.Context, topic string, msg any) error {
+func (c *NoOpClient) Publish(ctx context.Context, topic string, msg any, opts ...PublishOption) error {
 	return ctx.Err()
 }
 
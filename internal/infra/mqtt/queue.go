@@ -0,0 +1,219 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const _queuedMessageExtension = ".json"
+
+// QueuedMessage is a message published while the client was disconnected.
+type QueuedMessage struct {
+	Topic    string `json:"topic"`
+	QoS      byte   `json:"qos"`
+	Retained bool   `json:"retained"`
+	Payload  []byte `json:"payload"`
+}
+
+// OutboundQueue holds the messages published while the client is disconnected, in order, until
+// they are replayed on reconnection. Queues are bounded: Push drops the oldest message when the
+// queue is full and reports it.
+type OutboundQueue interface {
+	Push(msg QueuedMessage) (dropped bool, err error)
+	// Peek returns the oldest message without removing it, so a message whose replay fails is
+	// kept for the next reconnection.
+	Peek() (QueuedMessage, bool, error)
+	Pop() error
+	Len() int
+}
+
+func NewMemoryQueue(capacity int) *MemoryQueue {
+	return &MemoryQueue{capacity: capacity}
+}
+
+var _ OutboundQueue = (*MemoryQueue)(nil)
+
+// MemoryQueue is an OutboundQueue lost on restart.
+type MemoryQueue struct {
+	mu       sync.Mutex
+	capacity int
+	messages []QueuedMessage
+}
+
+func (q *MemoryQueue) Push(msg QueuedMessage) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	dropped := false
+	if len(q.messages) >= q.capacity {
+		q.messages = q.messages[1:]
+		dropped = true
+	}
+	q.messages = append(q.messages, msg)
+	return dropped, nil
+}
+
+func (q *MemoryQueue) Peek() (QueuedMessage, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.messages) == 0 {
+		return QueuedMessage{}, false, nil
+	}
+	return q.messages[0], true, nil
+}
+
+func (q *MemoryQueue) Pop() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.messages) > 0 {
+		q.messages = q.messages[1:]
+	}
+	return nil
+}
+
+func (q *MemoryQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.messages)
+}
+
+// NewFileQueue opens the queue stored in dir, creating the directory when missing, and resumes
+// the messages left by a previous process.
+func NewFileQueue(dir string, capacity int) (*FileQueue, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating queue directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading queue directory: %w", err)
+	}
+
+	sequences := make([]uint64, 0, len(entries))
+	for _, entry := range entries {
+		name, found := strings.CutSuffix(entry.Name(), _queuedMessageExtension)
+		if entry.IsDir() || !found {
+			continue
+		}
+		sequence, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		sequences = append(sequences, sequence)
+	}
+	slices.Sort(sequences)
+
+	queue := &FileQueue{dir: dir, capacity: capacity, sequences: sequences}
+	if len(sequences) > 0 {
+		queue.next = sequences[len(sequences)-1] + 1
+	}
+	return queue, nil
+}
+
+var _ OutboundQueue = (*FileQueue)(nil)
+
+// FileQueue is an OutboundQueue that survives restarts. Every message is a file in dir named
+// after its sequence number, written to a temporary file first so a crash never leaves a
+// partial message behind.
+type FileQueue struct {
+	mu        sync.Mutex
+	dir       string
+	capacity  int
+	sequences []uint64
+	next      uint64
+}
+
+func (q *FileQueue) Push(msg QueuedMessage) (bool, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return false, fmt.Errorf("encoding queued message: %w", err)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	path := q.path(q.next)
+	temporary := path + ".tmp"
+	if err := os.WriteFile(temporary, data, 0o600); err != nil {
+		return false, fmt.Errorf("writing queued message: %w", err)
+	}
+	if err := os.Rename(temporary, path); err != nil {
+		return false, fmt.Errorf("storing queued message: %w", err)
+	}
+	q.sequences = append(q.sequences, q.next)
+	q.next++
+
+	dropped := false
+	if len(q.sequences) > q.capacity {
+		if err := q.removeOldest(); err != nil {
+			return false, err
+		}
+		dropped = true
+	}
+	return dropped, nil
+}
+
+// Peek discards the messages that cannot be decoded, so a corrupted file does not block the ones
+// queued after it.
+func (q *FileQueue) Peek() (QueuedMessage, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.sequences) > 0 {
+		data, err := os.ReadFile(q.path(q.sequences[0]))
+		if err != nil {
+			return QueuedMessage{}, false, fmt.Errorf("reading queued message: %w", err)
+		}
+
+		var msg QueuedMessage
+		if err := json.Unmarshal(data, &msg); err == nil {
+			return msg, true, nil
+		}
+
+		slog.Warn("discarding corrupted queued MQTT message", slog.String("path", q.path(q.sequences[0])))
+		if err := q.removeOldest(); err != nil {
+			return QueuedMessage{}, false, err
+		}
+	}
+
+	return QueuedMessage{}, false, nil
+}
+
+func (q *FileQueue) Pop() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.sequences) == 0 {
+		return nil
+	}
+	return q.removeOldest()
+}
+
+func (q *FileQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.sequences)
+}
+
+func (q *FileQueue) removeOldest() error {
+	err := os.Remove(q.path(q.sequences[0]))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing queued message: %w", err)
+	}
+	q.sequences = q.sequences[1:]
+	return nil
+}
+
+func (q *FileQueue) path(sequence uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", sequence, _queuedMessageExtension))
+}
@@ -0,0 +1,116 @@
+package mqtt_test
+
+import (
+	"os"
+	"path/filepath"
+	"zensor-server/internal/infra/mqtt"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("OutboundQueue", func() {
+	message := func(topic string) mqtt.QueuedMessage {
+		return mqtt.QueuedMessage{Topic: topic, QoS: 1, Payload: []byte(`{"topic":"` + topic + `"}`)}
+	}
+
+	drain := func(queue mqtt.OutboundQueue) []string {
+		var topics []string
+		for {
+			msg, found, err := queue.Peek()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			if !found {
+				return topics
+			}
+			topics = append(topics, msg.Topic)
+			gomega.Expect(queue.Pop()).To(gomega.Succeed())
+		}
+	}
+
+	behavesAsQueue := func(newQueue func(capacity int) mqtt.OutboundQueue) {
+		ginkgo.It("should return the messages in order", func() {
+			queue := newQueue(10)
+			for _, topic := range []string{"a", "b", "c"} {
+				dropped, err := queue.Push(message(topic))
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(dropped).To(gomega.BeFalse())
+			}
+
+			gomega.Expect(queue.Len()).To(gomega.Equal(3))
+			gomega.Expect(drain(queue)).To(gomega.Equal([]string{"a", "b", "c"}))
+			gomega.Expect(queue.Len()).To(gomega.BeZero())
+		})
+
+		ginkgo.It("should keep the message peeked until it is popped", func() {
+			queue := newQueue(10)
+			_, err := queue.Push(message("a"))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			first, _, err := queue.Peek()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			second, found, err := queue.Peek()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(found).To(gomega.BeTrue())
+			gomega.Expect(second).To(gomega.Equal(first))
+			gomega.Expect(second.QoS).To(gomega.Equal(byte(1)))
+		})
+
+		ginkgo.It("should drop the oldest messages when full", func() {
+			queue := newQueue(2)
+			_, _ = queue.Push(message("a"))
+			_, _ = queue.Push(message("b"))
+
+			dropped, err := queue.Push(message("c"))
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(dropped).To(gomega.BeTrue())
+			gomega.Expect(drain(queue)).To(gomega.Equal([]string{"b", "c"}))
+		})
+	}
+
+	ginkgo.Context("MemoryQueue", func() {
+		behavesAsQueue(func(capacity int) mqtt.OutboundQueue {
+			return mqtt.NewMemoryQueue(capacity)
+		})
+	})
+
+	ginkgo.Context("FileQueue", func() {
+		var dir string
+
+		ginkgo.BeforeEach(func() {
+			dir = filepath.Join(ginkgo.GinkgoT().TempDir(), "outbound")
+		})
+
+		behavesAsQueue(func(capacity int) mqtt.OutboundQueue {
+			queue, err := mqtt.NewFileQueue(dir, capacity)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			return queue
+		})
+
+		ginkgo.It("should resume the messages left by a previous process", func() {
+			queue, err := mqtt.NewFileQueue(dir, 10)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			_, _ = queue.Push(message("a"))
+			_, _ = queue.Push(message("b"))
+			gomega.Expect(queue.Pop()).To(gomega.Succeed())
+
+			reopened, err := mqtt.NewFileQueue(dir, 10)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			_, _ = reopened.Push(message("c"))
+
+			gomega.Expect(drain(reopened)).To(gomega.Equal([]string{"b", "c"}))
+		})
+
+		ginkgo.It("should discard corrupted messages", func() {
+			queue, err := mqtt.NewFileQueue(dir, 10)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			_, _ = queue.Push(message("a"))
+			_, _ = queue.Push(message("b"))
+
+			entries, err := os.ReadDir(dir)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(os.WriteFile(filepath.Join(dir, entries[0].Name()), []byte("{"), 0o600)).To(gomega.Succeed())
+
+			gomega.Expect(drain(queue)).To(gomega.Equal([]string{"b"}))
+		})
+	})
+})
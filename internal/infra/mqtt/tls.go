@@ -0,0 +1,78 @@
+package mqtt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+// _tlsSchemes are the broker URL schemes paho connects to over TLS.
+var _tlsSchemes = []string{"ssl", "tls", "tcps", "mqtts", "wss"}
+
+var ErrTLSKeyPairIncomplete = errors.New("the client certificate and key must be set together")
+
+// TLSOpts configures the TLS connection to brokers with a ssl://, tls://, tcps://, mqtts:// or
+// wss:// URL. Without CAFile the system roots verify the broker; CertFile and KeyFile
+// authenticate the client with a certificate.
+type TLSOpts struct {
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+func (o TLSOpts) isZero() bool {
+	return o == TLSOpts{}
+}
+
+// Config builds the TLS configuration, loading the CA bundle and the client key pair from disk.
+func (o TLSOpts) Config() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify, //nolint:gosec // opt-in, for brokers with self-signed certificates
+	}
+
+	if o.CAFile != "" {
+		bundle, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no PEM certificates found in CA bundle %s", o.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return nil, ErrTLSKeyPairIncomplete
+	}
+	if o.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
+}
+
+// usesTLS reports whether paho connects to the broker over TLS.
+func usesTLS(broker string) bool {
+	scheme, _, found := strings.Cut(broker, "://")
+	if !found {
+		return false
+	}
+	if parsed, err := url.Parse(broker); err == nil {
+		scheme = parsed.Scheme
+	}
+	return slices.Contains(_tlsSchemes, strings.ToLower(scheme))
+}
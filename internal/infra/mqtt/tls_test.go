@@ -0,0 +1,199 @@
+package mqtt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+	"zensor-server/internal/infra/mqtt"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	der         []byte
+}
+
+// issueCertificate signs a certificate for commonName with parent, or self-signs it when parent
+// is nil.
+func issueCertificate(commonName string, parent *testCertificate, ca bool) testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if ca {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.certificate, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	certificate, err := x509.ParseCertificate(der)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+	return testCertificate{certificate: certificate, key: key, der: der}
+}
+
+func (c testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+// write stores the certificate and its key as PEM files in dir.
+func (c testCertificate) write(dir, name string) (certFile, keyFile string) {
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	gomega.Expect(err).NotTo(gomega.HaveOccurred())
+	gomega.Expect(os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0o600)).To(gomega.Succeed())
+	gomega.Expect(os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)).To(gomega.Succeed())
+	return certFile, keyFile
+}
+
+var _ = ginkgo.Describe("TLSOpts", func() {
+	var (
+		dir               string
+		ca                testCertificate
+		caFile            string
+		certFile, keyFile string
+		clientCertificate testCertificate
+	)
+
+	ginkgo.BeforeEach(func() {
+		dir = ginkgo.GinkgoT().TempDir()
+		ca = issueCertificate("test-ca", nil, true)
+		caFile, _ = ca.write(dir, "ca")
+		clientCertificate = issueCertificate("zensor-client", &ca, false)
+		certFile, keyFile = clientCertificate.write(dir, "client")
+	})
+
+	ginkgo.Context("Config", func() {
+		ginkgo.It("should trust the CA bundle and present the client certificate", func() {
+			config, err := mqtt.TLSOpts{CAFile: caFile, CertFile: certFile, KeyFile: keyFile, ServerName: "broker"}.Config()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			gomega.Expect(config.MinVersion).To(gomega.Equal(uint16(tls.VersionTLS12)))
+			gomega.Expect(config.ServerName).To(gomega.Equal("broker"))
+			gomega.Expect(config.RootCAs).NotTo(gomega.BeNil())
+			gomega.Expect(config.Certificates).To(gomega.HaveLen(1))
+		})
+
+		ginkgo.It("should use the system roots without a CA bundle", func() {
+			config, err := mqtt.TLSOpts{}.Config()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(config.RootCAs).To(gomega.BeNil())
+			gomega.Expect(config.Certificates).To(gomega.BeEmpty())
+		})
+
+		ginkgo.It("should fail when the CA bundle has no certificates", func() {
+			empty := filepath.Join(dir, "empty.pem")
+			gomega.Expect(os.WriteFile(empty, []byte("not a certificate"), 0o600)).To(gomega.Succeed())
+
+			_, err := mqtt.TLSOpts{CAFile: empty}.Config()
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("no PEM certificates")))
+		})
+
+		ginkgo.It("should fail when the CA bundle is missing", func() {
+			_, err := mqtt.TLSOpts{CAFile: filepath.Join(dir, "missing.pem")}.Config()
+			gomega.Expect(err).To(gomega.MatchError(os.ErrNotExist))
+		})
+
+		ginkgo.It("should fail when only the certificate is set", func() {
+			_, err := mqtt.TLSOpts{CertFile: certFile}.Config()
+			gomega.Expect(err).To(gomega.MatchError(mqtt.ErrTLSKeyPairIncomplete))
+		})
+
+		ginkgo.It("should fail when the key does not match the certificate", func() {
+			_, otherKey := issueCertificate("other", &ca, false).write(dir, "other")
+
+			_, err := mqtt.TLSOpts{CertFile: certFile, KeyFile: otherKey}.Config()
+			gomega.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("loading client certificate")))
+		})
+	})
+
+	ginkgo.Context("NewSimpleClient", func() {
+		ginkgo.It("should fail on invalid TLS settings for a TLS broker", func() {
+			_, err := mqtt.NewSimpleClient(mqtt.SimpleClientOpts{
+				Broker:   "mqtts://127.0.0.1:1",
+				ClientID: "tls-client",
+				TLS:      mqtt.TLSOpts{KeyFile: keyFile},
+			})
+			gomega.Expect(err).To(gomega.MatchError(mqtt.ErrTLSKeyPairIncomplete))
+		})
+
+		ginkgo.It("should ignore the TLS settings for a plain broker", func() {
+			client, err := mqtt.NewSimpleClient(mqtt.SimpleClientOpts{
+				Broker:   "tcp://127.0.0.1:1",
+				ClientID: "plain-client",
+				TLS:      mqtt.TLSOpts{KeyFile: keyFile},
+			})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			client.Disconnect()
+		})
+
+		ginkgo.It("should authenticate to the broker with the client certificate", func() {
+			clientCAs := x509.NewCertPool()
+			clientCAs.AddCert(ca.certificate)
+			listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{issueCertificate("broker", &ca, false).tlsCertificate()},
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+				ClientCAs:    clientCAs,
+			})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			defer listener.Close()
+
+			peers := make(chan string, 1)
+			go func() {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				tlsConn := conn.(*tls.Conn) //nolint:forcetypeassert // accepted from a TLS listener
+				if err := tlsConn.Handshake(); err != nil {
+					peers <- "handshake failed: " + err.Error()
+					return
+				}
+				peers <- tlsConn.ConnectionState().PeerCertificates[0].Subject.CommonName
+			}()
+
+			client, err := mqtt.NewSimpleClient(mqtt.SimpleClientOpts{
+				Broker:   "ssl://" + listener.Addr().String(),
+				ClientID: "tls-client",
+				TLS:      mqtt.TLSOpts{CAFile: caFile, CertFile: certFile, KeyFile: keyFile},
+			})
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			defer client.Disconnect()
+
+			gomega.Eventually(peers, 5*time.Second).Should(gomega.Receive(gomega.Equal("zensor-client")))
+		})
+	})
+})
@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +18,10 @@ type Node struct {
 	IPAddress  string
 	Version    string
 	CommitHash string
+	IsLeader   bool
+	// Components maps the connections the node depends on, such as MQTT brokers, to whether
+	// they are up.
+	Components map[string]bool
 }
 
 var (
@@ -29,6 +34,8 @@ var (
 	nodeIDOnce sync.Once
 	nodeIP     string
 	nodeIPOnce sync.Once
+	leader     atomic.Bool
+	components sync.Map
 )
 
 // GetNodeInfo returns the current node information.
@@ -38,9 +45,33 @@ func GetNodeInfo() *Node {
 		IPAddress:  getNodeIPAddress(),
 		Version:    Version,
 		CommitHash: CommitHash,
+		IsLeader:   leader.Load(),
+		Components: getComponents(),
 	}
 }
 
+// SetLeader records whether the current node holds the lease that runs singleton workers.
+func SetLeader(value bool) {
+	leader.Store(value)
+}
+
+// SetComponentHealth records whether a connection the node depends on is up. Components are
+// reported from their first call on.
+func SetComponentHealth(name string, healthy bool) {
+	components.Store(name, healthy)
+}
+
+func getComponents() map[string]bool {
+	result := make(map[string]bool)
+	components.Range(func(key, value any) bool {
+		name, _ := key.(string)
+		healthy, _ := value.(bool)
+		result[name] = healthy
+		return true
+	})
+	return result
+}
+
 // getNodeID returns the current node ID.
 func getNodeID() string {
 	nodeIDOnce.Do(func() {
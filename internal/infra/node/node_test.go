@@ -53,5 +53,13 @@ var _ = ginkgo.Describe("Node", func() {
 			gomega.Expect(nodeInfo.CommitHash).To(gomega.BeAssignableToTypeOf(""))
 			gomega.Expect(nodeInfo.CommitHash).To(gomega.Not(gomega.BeEmpty()))
 		})
+
+		ginkgo.It("should report the health of the components", func() {
+			node.SetComponentHealth("mqtt.test", false)
+			gomega.Expect(node.GetNodeInfo().Components).To(gomega.HaveKeyWithValue("mqtt.test", false))
+
+			node.SetComponentHealth("mqtt.test", true)
+			gomega.Expect(node.GetNodeInfo().Components).To(gomega.HaveKeyWithValue("mqtt.test", true))
+		})
 	})
 })
@@ -0,0 +1,259 @@
+// Package secrets encrypts secrets at rest with AES-GCM envelope encryption.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrNoKeys            = errors.New("at least one key-encryption key is required")
+	ErrPrimaryKeyUnknown = errors.New("the primary key is not in the keyring")
+	ErrInvalidKey        = errors.New("key-encryption keys must be 32 bytes")
+	ErrInvalidKeyID      = errors.New("key IDs must be non-empty and must not contain ':'")
+	ErrDuplicatedKeyID   = errors.New("key IDs must be unique")
+	ErrMalformedSecret   = errors.New("malformed sealed secret")
+	ErrUnknownKey        = errors.New("the secret was sealed with a key that is not in the keyring")
+	ErrSecretCorrupted   = errors.New("the secret could not be authenticated")
+	ErrInvalidKeySpec    = errors.New("keys must be given as comma separated id:base64 pairs")
+)
+
+const (
+	_sealedPrefix  = "enc:v1:"
+	_dataKeyLength = 32
+)
+
+// Cipher seals and opens secrets. The associated data binds a secret to its owner, so a sealed
+// value copied to another owner fails to open.
+type Cipher interface {
+	Seal(plaintext, associatedData []byte) (string, error)
+	Open(sealed string, associatedData []byte) ([]byte, error)
+	// Rewrap seals the data key of the secret again with the primary key, without decrypting
+	// the secret itself.
+	Rewrap(sealed string) (string, error)
+	// PrimaryKeyID is the ID of the key new secrets are sealed with.
+	PrimaryKeyID() string
+}
+
+// Key is a key-encryption key. Retired keys stay in the keyring to open the secrets they sealed
+// until those are rewrapped with the primary key.
+type Key struct {
+	ID       string
+	Material []byte
+}
+
+// ParseKeys reads keys given as "id:base64,id:base64", the form used by the configuration.
+func ParseKeys(spec string) ([]Key, error) {
+	var keys []Key
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		id, encoded, found := strings.Cut(pair, ":")
+		if !found {
+			return nil, ErrInvalidKeySpec
+		}
+		material, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %s is not base64", ErrInvalidKeySpec, id)
+		}
+		keys = append(keys, Key{ID: id, Material: material})
+	}
+
+	return keys, nil
+}
+
+var _ Cipher = (*Keyring)(nil)
+
+// Keyring seals each secret with its own random data key and wraps that data key with the
+// primary key-encryption key. A sealed secret reads "enc:v1:<key id>:<wrapped data key>:<ciphertext>".
+type Keyring struct {
+	primaryID string
+	keys      map[string]cipher.AEAD
+}
+
+func NewKeyring(primaryID string, keys []Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	ring := &Keyring{
+		primaryID: primaryID,
+		keys:      make(map[string]cipher.AEAD, len(keys)),
+	}
+	for _, key := range keys {
+		if key.ID == "" || strings.Contains(key.ID, ":") {
+			return nil, ErrInvalidKeyID
+		}
+		if len(key.Material) != 32 {
+			return nil, fmt.Errorf("%w: key %s has %d bytes", ErrInvalidKey, key.ID, len(key.Material))
+		}
+		if _, found := ring.keys[key.ID]; found {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicatedKeyID, key.ID)
+		}
+
+		aead, err := newAEAD(key.Material)
+		if err != nil {
+			return nil, err
+		}
+		ring.keys[key.ID] = aead
+	}
+
+	if _, found := ring.keys[primaryID]; !found {
+		return nil, fmt.Errorf("%w: %s", ErrPrimaryKeyUnknown, primaryID)
+	}
+
+	return ring, nil
+}
+
+// IsSealed tells whether the value was produced by Seal, as opposed to legacy plaintext.
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, _sealedPrefix)
+}
+
+func (k *Keyring) Seal(plaintext, associatedData []byte) (string, error) {
+	dataKey := make([]byte, _dataKeyLength)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("generating data key: %w", err)
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataAEAD, plaintext, associatedData)
+	if err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := seal(k.keys[k.primaryID], dataKey, []byte(k.primaryID))
+	if err != nil {
+		return "", err
+	}
+
+	return format(k.primaryID, wrappedKey, ciphertext), nil
+}
+
+func (k *Keyring) Open(sealed string, associatedData []byte) ([]byte, error) {
+	keyID, wrappedKey, ciphertext, err := parse(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := k.unwrap(keyID, wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	dataAEAD, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(dataAEAD, ciphertext, associatedData)
+}
+
+func (k *Keyring) Rewrap(sealed string) (string, error) {
+	keyID, wrappedKey, ciphertext, err := parse(sealed)
+	if err != nil {
+		return "", err
+	}
+	if keyID == k.primaryID {
+		return sealed, nil
+	}
+
+	dataKey, err := k.unwrap(keyID, wrappedKey)
+	if err != nil {
+		return "", err
+	}
+
+	rewrapped, err := seal(k.keys[k.primaryID], dataKey, []byte(k.primaryID))
+	if err != nil {
+		return "", err
+	}
+
+	return format(k.primaryID, rewrapped, ciphertext), nil
+}
+
+func (k *Keyring) PrimaryKeyID() string {
+	return k.primaryID
+}
+
+// SealedPrefix is how every secret sealed with the key starts, to find them in storage.
+func SealedPrefix(keyID string) string {
+	return _sealedPrefix + keyID + ":"
+}
+
+func (k *Keyring) unwrap(keyID string, wrappedKey []byte) ([]byte, error) {
+	keyAEAD, found := k.keys[keyID]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+
+	return open(keyAEAD, wrappedKey, []byte(keyID))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts with a random nonce, which is prepended to the result.
+func seal(aead cipher.AEAD, plaintext, associatedData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+func open(aead cipher.AEAD, sealed, associatedData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformedSecret
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, associatedData)
+	if err != nil {
+		return nil, ErrSecretCorrupted
+	}
+	return plaintext, nil
+}
+
+func format(keyID string, wrappedKey, ciphertext []byte) string {
+	return SealedPrefix(keyID) +
+		base64.RawStdEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext)
+}
+
+func parse(sealed string) (string, []byte, []byte, error) {
+	if !IsSealed(sealed) {
+		return "", nil, nil, ErrMalformedSecret
+	}
+
+	parts := strings.Split(strings.TrimPrefix(sealed, _sealedPrefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrMalformedSecret
+	}
+
+	wrappedKey, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, ErrMalformedSecret
+	}
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrMalformedSecret
+	}
+
+	return parts[0], wrappedKey, ciphertext, nil
+}
@@ -0,0 +1,134 @@
+package secrets_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"zensor-server/internal/infra/secrets"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Keyring", func() {
+	var (
+		oldKey secrets.Key
+		newKey secrets.Key
+		owner  []byte
+	)
+
+	BeforeEach(func() {
+		oldKey = secrets.Key{ID: "2025", Material: bytes.Repeat([]byte{1}, 32)}
+		newKey = secrets.Key{ID: "2026", Material: bytes.Repeat([]byte{2}, 32)}
+		owner = []byte("device-1")
+	})
+
+	It("should open what it sealed", func() {
+		ring, err := secrets.NewKeyring("2026", []secrets.Key{newKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		sealed, err := ring.Seal([]byte("00112233445566778899AABBCCDDEEFF"), owner)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sealed).To(HavePrefix(secrets.SealedPrefix("2026")))
+		Expect(sealed).NotTo(ContainSubstring("00112233"))
+		Expect(secrets.IsSealed(sealed)).To(BeTrue())
+
+		plaintext, err := ring.Open(sealed, owner)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(plaintext)).To(Equal("00112233445566778899AABBCCDDEEFF"))
+	})
+
+	It("should not open a secret moved to another owner", func() {
+		ring, err := secrets.NewKeyring("2026", []secrets.Key{newKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		sealed, err := ring.Seal([]byte("secret"), owner)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = ring.Open(sealed, []byte("device-2"))
+		Expect(err).To(MatchError(secrets.ErrSecretCorrupted))
+	})
+
+	It("should reject tampered and malformed secrets", func() {
+		ring, err := secrets.NewKeyring("2026", []secrets.Key{newKey})
+		Expect(err).NotTo(HaveOccurred())
+
+		sealed, err := ring.Seal([]byte("secret"), owner)
+		Expect(err).NotTo(HaveOccurred())
+		tampered := sealed[:len(sealed)-2] + "AA"
+		if tampered == sealed {
+			tampered = sealed[:len(sealed)-2] + "BB"
+		}
+
+		_, err = ring.Open(tampered, owner)
+		Expect(err).To(MatchError(secrets.ErrSecretCorrupted))
+
+		_, err = ring.Open("00112233445566778899AABBCCDDEEFF", owner)
+		Expect(err).To(MatchError(secrets.ErrMalformedSecret))
+	})
+
+	When("the primary key is rotated", func() {
+		It("should open old secrets and rewrap them with the new key", func() {
+			before, err := secrets.NewKeyring("2025", []secrets.Key{oldKey})
+			Expect(err).NotTo(HaveOccurred())
+			sealed, err := before.Seal([]byte("secret"), owner)
+			Expect(err).NotTo(HaveOccurred())
+
+			after, err := secrets.NewKeyring("2026", []secrets.Key{oldKey, newKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			plaintext, err := after.Open(sealed, owner)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(plaintext)).To(Equal("secret"))
+
+			rewrapped, err := after.Rewrap(sealed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rewrapped).To(HavePrefix(secrets.SealedPrefix("2026")))
+
+			retired, err := secrets.NewKeyring("2026", []secrets.Key{newKey})
+			Expect(err).NotTo(HaveOccurred())
+			plaintext, err = retired.Open(rewrapped, owner)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(plaintext)).To(Equal("secret"))
+
+			_, err = retired.Open(sealed, owner)
+			Expect(err).To(MatchError(secrets.ErrUnknownKey))
+		})
+	})
+
+	DescribeTable("should reject invalid keyrings",
+		func(primaryID string, keys []secrets.Key, expected error) {
+			_, err := secrets.NewKeyring(primaryID, keys)
+			Expect(err).To(MatchError(expected))
+		},
+		Entry("no keys", "2026", nil, secrets.ErrNoKeys),
+		Entry("short key", "2026", []secrets.Key{{ID: "2026", Material: []byte("short")}}, secrets.ErrInvalidKey),
+		Entry("unknown primary", "2027", []secrets.Key{{ID: "2026", Material: bytes.Repeat([]byte{2}, 32)}}, secrets.ErrPrimaryKeyUnknown),
+		Entry("key ID with a colon", "a:b", []secrets.Key{{ID: "a:b", Material: bytes.Repeat([]byte{2}, 32)}}, secrets.ErrInvalidKeyID),
+		Entry("duplicated key ID", "2026", []secrets.Key{
+			{ID: "2026", Material: bytes.Repeat([]byte{2}, 32)},
+			{ID: "2026", Material: bytes.Repeat([]byte{3}, 32)},
+		}, secrets.ErrDuplicatedKeyID),
+	)
+
+	Describe("ParseKeys", func() {
+		It("should read id:base64 pairs", func() {
+			encoded := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+
+			keys, err := secrets.ParseKeys("2025:" + encoded + ", 2026:" + encoded)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys).To(HaveLen(2))
+			Expect(keys[1].ID).To(Equal("2026"))
+			Expect(keys[1].Material).To(HaveLen(32))
+		})
+
+		It("should reject pairs without a key", func() {
+			_, err := secrets.ParseKeys("2025")
+			Expect(err).To(MatchError(secrets.ErrInvalidKeySpec))
+
+			_, err = secrets.ParseKeys("2025:" + strings.Repeat("!", 8))
+			Expect(err).To(MatchError(secrets.ErrInvalidKeySpec))
+		})
+	})
+})
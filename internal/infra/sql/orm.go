@@ -11,6 +11,7 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 //go:generate mockgen -source=orm.go -destination=../../../test/unit/doubles/infra/sql/orm_mock.go -package=sql -mock_names=ORM=MockORM
@@ -25,9 +26,13 @@ type ORM interface {
 	Limit(limit int) ORM
 	Model(value any) ORM
 	Offset(offset int) ORM
+	// OnConflictDoNothing makes the following Create skip the rows conflicting with existing
+	// ones; RowsAffected then tells whether they were inserted.
+	OnConflictDoNothing() ORM
 	Order(value any) ORM
 	Preload(query string, args ...any) ORM
 	Save(value any) ORM
+	Updates(values any) ORM
 	Transaction(fc func(tx ORM) error, opts ...*sql.TxOptions) error
 	Unscoped() ORM
 	Where(query any, args ...any) ORM
@@ -37,6 +42,7 @@ type ORM interface {
 	InnerJoins(value string, args ...any) ORM
 
 	Error() error
+	RowsAffected() int64
 }
 
 type DB struct {
@@ -121,6 +127,12 @@ func (d DB) Offset(value int) ORM {
 	return &d
 }
 
+func (d DB) OnConflictDoNothing() ORM {
+	tx := d.DB.Clauses(clause.OnConflict{DoNothing: true})
+	d.DB = tx
+	return &d
+}
+
 func (d DB) Order(value any) ORM {
 	tx := d.DB.Order(value)
 	d.DB = tx
@@ -140,6 +152,17 @@ func (d DB) Save(value any) ORM {
 	return &d
 }
 
+func (d DB) Updates(values any) ORM {
+	d.createSpan("updates")
+	tx := d.DB.Updates(values)
+	d.DB = tx
+	return &d
+}
+
+func (d DB) RowsAffected() int64 {
+	return d.DB.RowsAffected
+}
+
 func (d DB) Unscoped() ORM {
 	tx := d.DB.Unscoped()
 	d.DB = tx
@@ -152,7 +175,12 @@ func (d DB) Where(value any, conds ...any) ORM {
 	return &d
 }
 
+// WithContext binds the queries to the context, and to the transaction it carries if any.
 func (d DB) WithContext(value context.Context) ORM {
+	if tx, ok := value.Value(transactionKey{}).(*DB); ok {
+		d.DB = tx.DB
+	}
+
 	if d.timeout > 0 {
 		timeoutCtx, cancel := context.WithTimeout(value, d.timeout)
 		// Store the cancel function to be called when the context is done
@@ -182,6 +210,14 @@ func (d DB) WithTimeout(ctx context.Context, timeout time.Duration) ORM {
 	return &d
 }
 
+type transactionKey struct{}
+
+// ContextWithTransaction returns a context whose queries run in the transaction, so repositories
+// called with it join a transaction opened by their caller.
+func ContextWithTransaction(ctx context.Context, tx ORM) context.Context {
+	return context.WithValue(ctx, transactionKey{}, tx)
+}
+
 func (d DB) Transaction(f func(ORM) error, opts ...*sql.TxOptions) error {
 	d.createSpan("transaction")
 	return d.DB.Transaction(func(tx *gorm.DB) error {
@@ -0,0 +1,58 @@
+package ttn
+
+import (
+	"context"
+	"sync"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+func NewFakeProvisioner() *FakeProvisioner {
+	return &FakeProvisioner{
+		devices: make(map[string]domain.Device),
+	}
+}
+
+// FakeProvisioner keeps the registered devices in memory. It stands in for TTN in tests and in
+// ENV=local runs.
+type FakeProvisioner struct {
+	mu      sync.Mutex
+	devices map[string]domain.Device
+	err     error
+}
+
+// FailWith makes every following call return err until it is called again with nil.
+func (p *FakeProvisioner) FailWith(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+// Device returns the registration of the device with the given name.
+func (p *FakeProvisioner) Device(name string) (domain.Device, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	device, ok := p.devices[name]
+	return device, ok
+}
+
+func (p *FakeProvisioner) Provision(_ context.Context, device domain.Device) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+
+	p.devices[device.Name] = device
+	return nil
+}
+
+func (p *FakeProvisioner) Deprovision(_ context.Context, device domain.Device) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+
+	delete(p.devices, device.Name)
+	return nil
+}
@@ -0,0 +1,252 @@
+// Package ttn provisions end devices in The Things Stack (TTN v3) registries.
+package ttn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+var (
+	ErrBaseURLRequired       = errors.New("TTN base URL is required")
+	ErrApplicationIDRequired = errors.New("TTN application ID is required")
+	ErrAPIKeyRequired        = errors.New("TTN API key is required")
+	ErrRegistryError         = errors.New("TTN registry error")
+)
+
+const (
+	_defaultFrequencyPlanID   = "AU_915_928_FSB_2"
+	_defaultLoRaWANVersion    = "MAC_V1_0_3"
+	_defaultLoRaWANPHYVersion = "PHY_V1_0_3_REV_A"
+)
+
+// RegistryConfig holds the TTN application devices are registered in and the radio settings
+// they are registered with.
+type RegistryConfig struct {
+	BaseURL           string
+	ApplicationID     string
+	APIKey            string
+	FrequencyPlanID   string
+	LoRaWANVersion    string
+	LoRaWANPHYVersion string
+}
+
+func (c *RegistryConfig) validateConfig() error {
+	if c.BaseURL == "" {
+		return ErrBaseURLRequired
+	}
+	if c.ApplicationID == "" {
+		return ErrApplicationIDRequired
+	}
+	if c.APIKey == "" {
+		return ErrAPIKeyRequired
+	}
+	return nil
+}
+
+// RegistryClient registers devices through the end device registries of the Identity, Join,
+// Network and Application servers. The TTN device ID is the Zensor device name.
+type RegistryClient struct {
+	config     RegistryConfig
+	host       string
+	httpClient *http.Client
+}
+
+func NewRegistryClient(config RegistryConfig) *RegistryClient {
+	if err := config.validateConfig(); err != nil {
+		panic(err)
+	}
+
+	if config.FrequencyPlanID == "" {
+		config.FrequencyPlanID = _defaultFrequencyPlanID
+	}
+	if config.LoRaWANVersion == "" {
+		config.LoRaWANVersion = _defaultLoRaWANVersion
+	}
+	if config.LoRaWANPHYVersion == "" {
+		config.LoRaWANPHYVersion = _defaultLoRaWANPHYVersion
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	host := config.BaseURL
+	if parsed, err := url.Parse(config.BaseURL); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+
+	return &RegistryClient{
+		config: config,
+		host:   host,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Provision creates or updates the device in every registry. It is safe to call again after a
+// partial failure.
+func (c *RegistryClient) Provision(ctx context.Context, device domain.Device) error {
+	ids := c.endDeviceIDs(device)
+
+	identity := endDeviceRequest{
+		EndDevice: map[string]any{
+			"ids":                        ids,
+			"join_server_address":        c.host,
+			"network_server_address":     c.host,
+			"application_server_address": c.host,
+		},
+		FieldMask: fieldMask("ids.dev_eui", "ids.join_eui", "join_server_address", "network_server_address", "application_server_address"),
+	}
+	status, err := c.do(ctx, http.MethodPost, c.applicationPath("", ""), identity)
+	if status == http.StatusConflict {
+		_, err = c.do(ctx, http.MethodPut, c.applicationPath("", device.Name), identity)
+	}
+	if err != nil {
+		return fmt.Errorf("registering in identity server: %w", err)
+	}
+
+	join := endDeviceRequest{
+		EndDevice: map[string]any{
+			"ids":                        ids,
+			"network_server_address":     c.host,
+			"application_server_address": c.host,
+			"root_keys": map[string]any{
+				"app_key": map[string]string{"key": device.AppKey},
+			},
+		},
+		FieldMask: fieldMask("ids.device_id", "ids.dev_eui", "ids.join_eui", "ids.application_ids.application_id",
+			"network_server_address", "application_server_address", "root_keys.app_key.key"),
+	}
+	if _, err := c.do(ctx, http.MethodPut, c.applicationPath("js", device.Name), join); err != nil {
+		return fmt.Errorf("registering in join server: %w", err)
+	}
+
+	network := endDeviceRequest{
+		EndDevice: map[string]any{
+			"ids":                 ids,
+			"frequency_plan_id":   c.config.FrequencyPlanID,
+			"lorawan_version":     c.config.LoRaWANVersion,
+			"lorawan_phy_version": c.config.LoRaWANPHYVersion,
+			"supports_join":       true,
+		},
+		FieldMask: fieldMask("ids.device_id", "ids.dev_eui", "ids.join_eui", "ids.application_ids.application_id",
+			"frequency_plan_id", "lorawan_version", "lorawan_phy_version", "supports_join"),
+	}
+	if _, err := c.do(ctx, http.MethodPut, c.applicationPath("ns", device.Name), network); err != nil {
+		return fmt.Errorf("registering in network server: %w", err)
+	}
+
+	application := endDeviceRequest{
+		EndDevice: map[string]any{"ids": ids},
+		FieldMask: fieldMask("ids.device_id", "ids.dev_eui", "ids.join_eui", "ids.application_ids.application_id"),
+	}
+	if _, err := c.do(ctx, http.MethodPut, c.applicationPath("as", device.Name), application); err != nil {
+		return fmt.Errorf("registering in application server: %w", err)
+	}
+
+	return nil
+}
+
+// Deprovision removes the device from every registry, in the reverse order of Provision. Devices
+// already missing from a registry are skipped.
+func (c *RegistryClient) Deprovision(ctx context.Context, device domain.Device) error {
+	for _, server := range []string{"as", "ns", "js", ""} {
+		status, err := c.do(ctx, http.MethodDelete, c.applicationPath(server, device.Name), nil)
+		if status == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("deleting from %s registry: %w", registryName(server), err)
+		}
+	}
+
+	return nil
+}
+
+type endDeviceRequest struct {
+	EndDevice map[string]any `json:"end_device"`
+	FieldMask map[string]any `json:"field_mask"`
+}
+
+func fieldMask(paths ...string) map[string]any {
+	return map[string]any{"paths": paths}
+}
+
+func (c *RegistryClient) endDeviceIDs(device domain.Device) map[string]any {
+	return map[string]any{
+		"device_id": device.Name,
+		"dev_eui":   strings.ToUpper(device.DevEUI),
+		"join_eui":  strings.ToUpper(device.AppEUI),
+		"application_ids": map[string]string{
+			"application_id": c.config.ApplicationID,
+		},
+	}
+}
+
+// applicationPath builds the devices path of the given server registry; an empty server is the
+// Identity Server and an empty device ID is the devices collection.
+func (c *RegistryClient) applicationPath(server, deviceID string) string {
+	path := "/api/v3"
+	if server != "" {
+		path += "/" + server
+	}
+	path += "/applications/" + url.PathEscape(c.config.ApplicationID) + "/devices"
+	if deviceID != "" {
+		path += "/" + url.PathEscape(deviceID)
+	}
+	return path
+}
+
+func (c *RegistryClient) do(ctx context.Context, method, path string, body any) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("marshaling request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.config.BaseURL+path, reader)
+	if err != nil {
+		return 0, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("%w: %s %s returned %d: %s",
+			ErrRegistryError, method, path, resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	return resp.StatusCode, nil
+}
+
+func registryName(server string) string {
+	switch server {
+	case "as":
+		return "application server"
+	case "ns":
+		return "network server"
+	case "js":
+		return "join server"
+	default:
+		return "identity server"
+	}
+}
@@ -0,0 +1,146 @@
+package ttn_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"zensor-server/internal/infra/ttn"
+	"zensor-server/internal/shared_kernel/domain"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type registryCall struct {
+	Method string
+	Path   string
+	Body   map[string]any
+}
+
+var _ = Describe("RegistryClient", func() {
+	var (
+		mu       sync.Mutex
+		calls    []registryCall
+		statuses map[string]int
+		registry *httptest.Server
+		client   *ttn.RegistryClient
+		device   domain.Device
+		ctx      context.Context
+	)
+
+	BeforeEach(func() {
+		calls = nil
+		statuses = make(map[string]int)
+		registry = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer secret"))
+
+			call := registryCall{Method: r.Method, Path: r.URL.Path}
+			if r.Body != nil && r.ContentLength != 0 {
+				Expect(json.NewDecoder(r.Body).Decode(&call.Body)).To(Succeed())
+			}
+
+			mu.Lock()
+			calls = append(calls, call)
+			status, ok := statuses[r.Method+" "+r.URL.Path]
+			mu.Unlock()
+
+			if !ok {
+				status = http.StatusOK
+			}
+			w.WriteHeader(status)
+		}))
+		DeferCleanup(registry.Close)
+
+		client = ttn.NewRegistryClient(ttn.RegistryConfig{
+			BaseURL:       registry.URL,
+			ApplicationID: "zensor",
+			APIKey:        "secret",
+		})
+		device = domain.Device{
+			Name:   "valve-1",
+			DevEUI: "0004a30b001c0530",
+			AppEUI: "70B3D57ED0000001",
+			AppKey: "000102030405060708090A0B0C0D0E0F",
+		}
+		ctx = context.Background()
+	})
+
+	paths := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		result := make([]string, len(calls))
+		for i, call := range calls {
+			result[i] = call.Method + " " + call.Path
+		}
+		return result
+	}
+
+	Context("Provision", func() {
+		It("should register the device in every server", func() {
+			Expect(client.Provision(ctx, device)).To(Succeed())
+
+			Expect(paths()).To(Equal([]string{
+				"POST /api/v3/applications/zensor/devices",
+				"PUT /api/v3/js/applications/zensor/devices/valve-1",
+				"PUT /api/v3/ns/applications/zensor/devices/valve-1",
+				"PUT /api/v3/as/applications/zensor/devices/valve-1",
+			}))
+
+			ids := calls[0].Body["end_device"].(map[string]any)["ids"].(map[string]any)
+			Expect(ids["device_id"]).To(Equal("valve-1"))
+			Expect(ids["dev_eui"]).To(Equal("0004A30B001C0530"))
+
+			join := calls[1].Body["end_device"].(map[string]any)
+			Expect(join["root_keys"]).To(Equal(map[string]any{
+				"app_key": map[string]any{"key": "000102030405060708090A0B0C0D0E0F"},
+			}))
+
+			network := calls[2].Body["end_device"].(map[string]any)
+			Expect(network["frequency_plan_id"]).To(Equal("AU_915_928_FSB_2"))
+			Expect(network["supports_join"]).To(BeTrue())
+		})
+
+		When("the device already exists in the identity server", func() {
+			BeforeEach(func() {
+				statuses["POST /api/v3/applications/zensor/devices"] = http.StatusConflict
+			})
+
+			It("should update it and carry on", func() {
+				Expect(client.Provision(ctx, device)).To(Succeed())
+
+				Expect(paths()).To(HaveLen(5))
+				Expect(paths()[1]).To(Equal("PUT /api/v3/applications/zensor/devices/valve-1"))
+			})
+		})
+
+		When("a registry fails", func() {
+			BeforeEach(func() {
+				statuses["PUT /api/v3/ns/applications/zensor/devices/valve-1"] = http.StatusServiceUnavailable
+			})
+
+			It("should return a registry error", func() {
+				err := client.Provision(ctx, device)
+
+				Expect(err).To(MatchError(ttn.ErrRegistryError))
+				Expect(err.Error()).To(ContainSubstring("network server"))
+			})
+		})
+	})
+
+	Context("Deprovision", func() {
+		It("should delete the device from every server, skipping the ones that do not know it", func() {
+			statuses["DELETE /api/v3/js/applications/zensor/devices/valve-1"] = http.StatusNotFound
+
+			Expect(client.Deprovision(ctx, device)).To(Succeed())
+
+			Expect(paths()).To(Equal([]string{
+				"DELETE /api/v3/as/applications/zensor/devices/valve-1",
+				"DELETE /api/v3/ns/applications/zensor/devices/valve-1",
+				"DELETE /api/v3/js/applications/zensor/devices/valve-1",
+				"DELETE /api/v3/applications/zensor/devices/valve-1",
+			}))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package ttn_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTTN(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TTN Suite")
+}
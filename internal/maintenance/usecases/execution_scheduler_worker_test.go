@@ -12,6 +12,7 @@ import (
 	maintenanceDomain "zensor-server/internal/maintenance/domain"
 	maintenanceUsecases "zensor-server/internal/maintenance/usecases"
 	shareddomain "zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
 	mockasync "zensor-server/test/unit/doubles/infra/async"
 	mockmaintenance "zensor-server/test/unit/doubles/maintenance/usecases"
 	mocksharedkernel "zensor-server/test/unit/doubles/shared_kernel/usecases"
@@ -190,8 +191,10 @@ var _ = Describe("ExecutionWorker", func() {
 					DoAndReturn(func(_ context.Context, topic async.BrokerTopicName, msg async.BrokerMessage) error {
 						Expect(topic).To(Equal(async.BrokerTopicName("maintenance_executions")))
 						Expect(msg.Event).To(Equal("execution_created"))
-						Expect(msg.Value).To(HaveKey("activity_id"))
-						Expect(msg.Value).To(HaveKey("scheduled_date"))
+						event, err := events.ExecutionCreated.Decode(msg)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(event.Payload.ActivityID).NotTo(BeEmpty())
+						Expect(event.Payload.ScheduledDate).NotTo(BeZero())
 						return nil
 					}).
 					Times(3)
@@ -262,8 +265,10 @@ var _ = Describe("ExecutionWorker", func() {
 					DoAndReturn(func(_ context.Context, topic async.BrokerTopicName, msg async.BrokerMessage) error {
 						Expect(topic).To(Equal(async.BrokerTopicName("maintenance_executions")))
 						Expect(msg.Event).To(Equal("execution_creation_failed"))
-						Expect(msg.Value).To(HaveKey("activity_id"))
-						Expect(msg.Value).To(HaveKey("error"))
+						event, err := events.ExecutionCreationFailed.Decode(msg)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(event.Payload.ActivityID).NotTo(BeEmpty())
+						Expect(event.Payload.Error).NotTo(BeEmpty())
 						return nil
 					})
 
@@ -290,8 +295,10 @@ var _ = Describe("ExecutionWorker", func() {
 					DoAndReturn(func(_ context.Context, topic async.BrokerTopicName, msg async.BrokerMessage) error {
 						Expect(topic).To(Equal(async.BrokerTopicName("maintenance_executions")))
 						Expect(msg.Event).To(Equal("execution_creation_failed"))
-						Expect(msg.Value).To(HaveKey("activity_id"))
-						Expect(msg.Value).To(HaveKey("error"))
+						event, err := events.ExecutionCreationFailed.Decode(msg)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(event.Payload.ActivityID).NotTo(BeEmpty())
+						Expect(event.Payload.Error).NotTo(BeEmpty())
 						return nil
 					})
 
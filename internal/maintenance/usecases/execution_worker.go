@@ -13,16 +13,13 @@ import (
 
 	maintenanceDomain "zensor-server/internal/maintenance/domain"
 	shareddomain "zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
 )
 
 const (
-	_defaultTimezone               = "UTC"
-	_executionsTopic               = "maintenance_executions"
-	_executionCreatedEvent         = "execution_created"
-	_executionCreationFailed       = "execution_creation_failed"
-	_executionReadyForNotification = "execution_ready_for_notification"
-	_executionOverdue              = "execution_overdue"
-	_nextExecutionsCount           = 3
+	_defaultTimezone     = "UTC"
+	_executionsTopic     = "maintenance_executions"
+	_nextExecutionsCount = 3
 )
 
 func NewExecutionWorker(
@@ -226,27 +223,21 @@ func (w *ExecutionWorker) buildFieldValuesFromActivity(activity maintenanceDomai
 }
 
 func (w *ExecutionWorker) publishSuccessEvent(ctx context.Context, activity maintenanceDomain.Activity, scheduledDate time.Time) {
-	brokerMsg := async.BrokerMessage{
-		Event: _executionCreatedEvent,
-		Value: map[string]any{
-			"activity_id":    activity.ID.String(),
-			"scheduled_date": scheduledDate,
-		},
-	}
+	brokerMsg := events.ExecutionCreated.New(activity.TenantID.String(), events.ExecutionCreation{
+		ActivityID:    activity.ID.String(),
+		ScheduledDate: scheduledDate,
+	}).Message()
 	if err := w.broker.Publish(ctx, async.BrokerTopicName(_executionsTopic), brokerMsg); err != nil {
 		slog.Error("failed to publish execution created event", slog.Any("error", err))
 	}
 }
 
 func (w *ExecutionWorker) publishFailureEvent(ctx context.Context, activity maintenanceDomain.Activity, err error) {
-	brokerMsg := async.BrokerMessage{
-		Event: _executionCreationFailed,
-		Value: map[string]any{
-			"activity_id": activity.ID.String(),
-			"error":       err.Error(),
-		},
-		Error: err,
-	}
+	brokerMsg := events.ExecutionCreationFailed.New(activity.TenantID.String(), events.ExecutionCreation{
+		ActivityID: activity.ID.String(),
+		Error:      err.Error(),
+	}).Message()
+	brokerMsg.Error = err
 	if err := w.broker.Publish(ctx, async.BrokerTopicName(_executionsTopic), brokerMsg); err != nil {
 		slog.Error("failed to publish execution creation failed event", slog.Any("error", err))
 	}
@@ -324,33 +315,36 @@ func (w *ExecutionWorker) checkOverdueExecutions(ctx context.Context, currentDat
 }
 
 func (w *ExecutionWorker) publishReadyForNotificationEvent(ctx context.Context, execution maintenanceDomain.Execution, activity maintenanceDomain.Activity, daysBefore int) {
-	w.publishExecutionCountEvent(ctx, execution, activity, _executionReadyForNotification, "days_before", "ready for notification", daysBefore)
+	reminder := executionReminder(execution, activity)
+	reminder.DaysBefore = &daysBefore
+	w.publishExecutionReminder(ctx, events.ExecutionReadyForNotification, activity, reminder, "ready for notification", slog.Int("days_before", daysBefore))
 }
 
 func (w *ExecutionWorker) publishOverdueEvent(ctx context.Context, execution maintenanceDomain.Execution, activity maintenanceDomain.Activity, overdueDays int) {
-	w.publishExecutionCountEvent(ctx, execution, activity, _executionOverdue, "overdue_days", "overdue", overdueDays)
+	reminder := executionReminder(execution, activity)
+	reminder.OverdueDays = &overdueDays
+	w.publishExecutionReminder(ctx, events.ExecutionOverdue, activity, reminder, "overdue", slog.Int("overdue_days", overdueDays))
 }
 
-func (w *ExecutionWorker) publishExecutionCountEvent(ctx context.Context, execution maintenanceDomain.Execution, activity maintenanceDomain.Activity, event string, countKey string, eventLabel string, count int) {
-	brokerMsg := async.BrokerMessage{
-		Event: event,
-		Value: map[string]any{
-			"execution_id":   execution.ID.String(),
-			"activity_id":    activity.ID.String(),
-			"activity_name":  string(activity.Name),
-			"tenant_id":      activity.TenantID.String(),
-			"scheduled_date": execution.ScheduledDate.Time,
-			countKey:         count,
-		},
+func executionReminder(execution maintenanceDomain.Execution, activity maintenanceDomain.Activity) events.ExecutionReminder {
+	return events.ExecutionReminder{
+		ExecutionID:   execution.ID.String(),
+		ActivityID:    activity.ID.String(),
+		ActivityName:  string(activity.Name),
+		ScheduledDate: execution.ScheduledDate.Time,
 	}
+}
+
+func (w *ExecutionWorker) publishExecutionReminder(ctx context.Context, eventType async.EventType[events.ExecutionReminder], activity maintenanceDomain.Activity, reminder events.ExecutionReminder, eventLabel string, count slog.Attr) {
+	brokerMsg := eventType.New(activity.TenantID.String(), reminder).Message()
 	if err := w.broker.Publish(ctx, async.BrokerTopicName(_executionsTopic), brokerMsg); err != nil {
 		slog.Error("failed to publish execution "+eventLabel+" event",
-			slog.String("execution_id", execution.ID.String()),
+			slog.String("execution_id", reminder.ExecutionID),
 			slog.Any("error", err))
 	} else {
 		slog.Info("published execution "+eventLabel+" event",
-			slog.String("execution_id", execution.ID.String()),
-			slog.Int(countKey, count))
+			slog.String("execution_id", reminder.ExecutionID),
+			count)
 	}
 }
 
@@ -12,6 +12,7 @@ import (
 	maintenanceDomain "zensor-server/internal/maintenance/domain"
 	maintenanceUsecases "zensor-server/internal/maintenance/usecases"
 	shareddomain "zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
 	mockasync "zensor-server/test/unit/doubles/infra/async"
 	mockmaintenance "zensor-server/test/unit/doubles/maintenance/usecases"
 	mocksharedkernel "zensor-server/test/unit/doubles/shared_kernel/usecases"
@@ -190,8 +191,10 @@ var _ = Describe("ExecutionWorker", func() {
 					DoAndReturn(func(_ context.Context, topic async.BrokerTopicName, msg async.BrokerMessage) error {
 						Expect(topic).To(Equal(async.BrokerTopicName("maintenance_executions")))
 						Expect(msg.Event).To(Equal("execution_created"))
-						Expect(msg.Value).To(HaveKey("activity_id"))
-						Expect(msg.Value).To(HaveKey("scheduled_date"))
+						event, err := events.ExecutionCreated.Decode(msg)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(event.Payload.ActivityID).NotTo(BeEmpty())
+						Expect(event.Payload.ScheduledDate).NotTo(BeZero())
 						return nil
 					}).
 					Times(3)
@@ -262,8 +265,10 @@ var _ = Describe("ExecutionWorker", func() {
 					DoAndReturn(func(_ context.Context, topic async.BrokerTopicName, msg async.BrokerMessage) error {
 						Expect(topic).To(Equal(async.BrokerTopicName("maintenance_executions")))
 						Expect(msg.Event).To(Equal("execution_creation_failed"))
-						Expect(msg.Value).To(HaveKey("activity_id"))
-						Expect(msg.Value).To(HaveKey("error"))
+						event, err := events.ExecutionCreationFailed.Decode(msg)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(event.Payload.ActivityID).NotTo(BeEmpty())
+						Expect(event.Payload.Error).NotTo(BeEmpty())
 						return nil
 					})
 
@@ -290,8 +295,10 @@ var _ = Describe("ExecutionWorker", func() {
 					DoAndReturn(func(_ context.Context, topic async.BrokerTopicName, msg async.BrokerMessage) error {
 						Expect(topic).To(Equal(async.BrokerTopicName("maintenance_executions")))
 						Expect(msg.Event).To(Equal("execution_creation_failed"))
-						Expect(msg.Value).To(HaveKey("activity_id"))
-						Expect(msg.Value).To(HaveKey("error"))
+						event, err := events.ExecutionCreationFailed.Decode(msg)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(event.Payload.ActivityID).NotTo(BeEmpty())
+						Expect(event.Payload.Error).NotTo(BeEmpty())
 						return nil
 					})
 
@@ -541,8 +548,10 @@ var _ = Describe("ExecutionWorker", func() {
 					Publish(gomock.Any(), async.BrokerTopicName("maintenance_executions"), gomock.Any()).
 					DoAndReturn(func(_ context.Context, topic async.BrokerTopicName, msg async.BrokerMessage) error {
 						Expect(msg.Event).To(Equal("execution_ready_for_notification"))
-						Expect(msg.Value).To(HaveKeyWithValue("days_before", 2))
-						Expect(msg.Value).To(HaveKeyWithValue("activity_name", "Test Activity"))
+						event, err := events.ExecutionReadyForNotification.Decode(msg)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(event.Payload.DaysBefore).To(HaveValue(Equal(2)))
+						Expect(event.Payload.ActivityName).To(Equal("Test Activity"))
 						return nil
 					})
 
@@ -5,11 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
 	"strings"
 	"zensor-server/internal/infra/async"
 	"zensor-server/internal/infra/config"
 	"zensor-server/internal/infra/notification"
-	"zensor-server/internal/infra/utils"
 	"zensor-server/internal/shared_kernel/domain"
 
 	sharedUsecases "zensor-server/internal/shared_kernel/usecases"
@@ -24,6 +24,8 @@ const (
 	_metricKeyPushNotifications = "push_notifications"
 )
 
+var _templatePlaceholder = regexp.MustCompile(`{{[^{}]+}}`)
+
 type PushNotificationWorker struct {
 	config             config.PushNotificationWorkerConfig
 	broker             async.InternalBroker
@@ -67,7 +69,7 @@ func (w *PushNotificationWorker) Run(ctx context.Context, done func()) {
 		slog.String("topic", w.config.Topic),
 		slog.String("event_type", w.config.EventType))
 
-	subscription, err := w.broker.Subscribe(async.BrokerTopicName(w.config.Topic))
+	subscription, err := w.broker.Subscribe(async.BrokerTopicName(w.config.Topic), w.config.EventType)
 	if err != nil {
 		slog.Error("failed to subscribe to topic",
 			slog.String("topic", w.config.Topic),
@@ -90,9 +92,7 @@ func (w *PushNotificationWorker) Run(ctx context.Context, done func()) {
 			slog.Info("push notification worker cancelled", slog.String("name", w.config.Name))
 			return
 		case msg := <-subscription.Receiver:
-			if msg.Event == w.config.EventType {
-				w.handleNotification(ctx, msg)
-			}
+			w.handleNotification(ctx, msg)
 		}
 	}
 }
@@ -115,7 +115,15 @@ func (w *PushNotificationWorker) handleNotification(ctx context.Context, msg asy
 	span.SetAttributes(attribute.String("notification.name", w.config.Name))
 	span.SetAttributes(attribute.String("event.type", msg.Event))
 
-	tenantIDStr := utils.ExtractStringValue(msg.Value, w.config.TenantIDPath)
+	fields, err := async.NewEventFields(msg)
+	if err != nil {
+		slog.Warn("notification event without fields",
+			slog.String("notification", w.config.Name),
+			slog.Any("error", err))
+		return
+	}
+
+	tenantIDStr := fields.String(w.config.TenantIDPath)
 	if tenantIDStr == "" {
 		slog.Warn("tenant ID not found in message",
 			slog.String("path", w.config.TenantIDPath),
@@ -128,20 +136,20 @@ func (w *PushNotificationWorker) handleNotification(ctx context.Context, msg asy
 	tenantID := domain.ID(tenantIDStr)
 	span.SetAttributes(attribute.String("tenant.id", tenantIDStr))
 
-	userIDStr := utils.ExtractStringValue(msg.Value, w.config.UserIDPath)
+	userIDStr := fields.String(w.config.UserIDPath)
 	if userIDStr == "" {
 		slog.Warn("user ID not found in message, will send to all tenant users",
 			slog.String("path", w.config.UserIDPath),
 			slog.String("notification", w.config.Name))
-		w.sendToTenantUsers(ctx, tenantID, msg)
+		w.sendToTenantUsers(ctx, tenantID, fields)
 		return
 	}
 
 	userID := domain.ID(userIDStr)
-	w.sendToUser(ctx, userID, msg)
+	w.sendToUser(ctx, userID, fields)
 }
 
-func (w *PushNotificationWorker) sendToUser(ctx context.Context, userID domain.ID, msg async.BrokerMessage) {
+func (w *PushNotificationWorker) sendToUser(ctx context.Context, userID domain.ID, fields async.EventFields) {
 	tokens, err := w.pushTokenService.ListTokensByUserID(ctx, userID)
 	if err != nil {
 		if errors.Is(err, sharedUsecases.ErrPushTokenNotFound) {
@@ -156,9 +164,9 @@ func (w *PushNotificationWorker) sendToUser(ctx context.Context, userID domain.I
 		return
 	}
 
-	title := w.buildTitle(msg)
-	body := w.buildBody(msg)
-	deepLink := w.buildDeepLink(msg)
+	title := w.buildTitle(fields)
+	body := w.buildBody(fields)
+	deepLink := w.buildDeepLink(fields)
 
 	anySuccess := false
 	for _, pushToken := range tokens {
@@ -197,7 +205,7 @@ func (w *PushNotificationWorker) sendToUser(ctx context.Context, userID domain.I
 	}
 }
 
-func (w *PushNotificationWorker) sendToTenantUsers(ctx context.Context, tenantID domain.ID, msg async.BrokerMessage) {
+func (w *PushNotificationWorker) sendToTenantUsers(ctx context.Context, tenantID domain.ID, fields async.EventFields) {
 	users, err := w.userService.FindByTenant(ctx, tenantID)
 	if err != nil {
 		slog.Error("failed to find users for tenant",
@@ -207,13 +215,13 @@ func (w *PushNotificationWorker) sendToTenantUsers(ctx context.Context, tenantID
 	}
 
 	for _, user := range users {
-		w.sendToUser(ctx, user.ID, msg)
+		w.sendToUser(ctx, user.ID, fields)
 	}
 }
 
-func (w *PushNotificationWorker) buildTitle(msg async.BrokerMessage) string {
+func (w *PushNotificationWorker) buildTitle(fields async.EventFields) string {
 	if w.config.TitleTemplate != "" {
-		interpolated := w.interpolateTemplate(w.config.TitleTemplate, msg.Value)
+		interpolated := w.interpolateTemplate(w.config.TitleTemplate, fields)
 		if !strings.Contains(interpolated, "{{") && !strings.Contains(interpolated, "%s") {
 			return interpolated
 		}
@@ -221,49 +229,41 @@ func (w *PushNotificationWorker) buildTitle(msg async.BrokerMessage) string {
 	return w.config.Title
 }
 
-func (w *PushNotificationWorker) buildBody(msg async.BrokerMessage) string {
+func (w *PushNotificationWorker) buildBody(fields async.EventFields) string {
 	if w.config.BodyTemplate != "" {
-		return w.interpolateTemplate(w.config.BodyTemplate, msg.Value)
+		return w.interpolateTemplate(w.config.BodyTemplate, fields)
 	}
 	return w.config.Body
 }
 
-func (w *PushNotificationWorker) buildDeepLink(msg async.BrokerMessage) string {
+func (w *PushNotificationWorker) buildDeepLink(fields async.EventFields) string {
 	if w.config.DeepLinkTemplate != "" {
-		return w.interpolateTemplate(w.config.DeepLinkTemplate, msg.Value)
+		return w.interpolateTemplate(w.config.DeepLinkTemplate, fields)
 	}
 	return w.config.DeepLink
 }
 
-func (w *PushNotificationWorker) interpolateTemplate(template string, data any) string {
-	result := template
-
-	if mapData, ok := data.(map[string]any); ok {
-		for key, value := range mapData {
-			placeholder := "{{" + key + "}}"
-			if strings.Contains(result, placeholder) {
-				result = strings.ReplaceAll(result, placeholder, fmt.Sprintf("%v", value))
-			}
+// interpolateTemplate replaces the {{path}} placeholders with the event fields at path, such as
+// {{payload.activity_name}} or {{tenant_id}}. Templates with a %s verb get the execution ID,
+// activity ID or activity name, whichever the event carries first.
+func (w *PushNotificationWorker) interpolateTemplate(template string, fields async.EventFields) string {
+	result := _templatePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		path := strings.TrimSpace(placeholder[2 : len(placeholder)-2])
+		if fields.Get(path) == nil {
+			return placeholder
 		}
-	}
+		return fields.String(path)
+	})
 
 	if strings.Contains(result, "{{") {
 		return result
 	}
 
-	executionID := utils.ExtractStringValue(data, "execution_id")
-	activityID := utils.ExtractStringValue(data, "activity_id")
-	activityName := utils.ExtractStringValue(data, "activity_name")
-
 	if strings.Contains(result, "%s") {
-		if executionID != "" {
-			return fmt.Sprintf(result, executionID)
-		}
-		if activityID != "" {
-			return fmt.Sprintf(result, activityID)
-		}
-		if activityName != "" {
-			return fmt.Sprintf(result, activityName)
+		for _, path := range []string{"execution_id", "activity_id", "activity_name"} {
+			if value := fields.String(path); value != "" {
+				return fmt.Sprintf(result, value)
+			}
 		}
 	}
 
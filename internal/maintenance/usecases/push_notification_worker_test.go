@@ -6,6 +6,7 @@ import (
 	"zensor-server/internal/infra/config"
 	"zensor-server/internal/infra/notification"
 	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/events"
 
 	maintenanceUsecases "zensor-server/internal/maintenance/usecases"
 
@@ -226,6 +227,40 @@ var _ = Describe("PushNotificationWorker", func() {
 			})
 		})
 
+		When("a catalog event is published", func() {
+			It("should resolve the template paths against the event payload", func() {
+				userService.EXPECT().
+					FindByTenant(gomock.Any(), domain.ID("tenant-1")).
+					Return([]domain.User{{ID: "user-1"}}, nil)
+				pushTokenService.EXPECT().
+					ListTokensByUserID(gomock.Any(), domain.ID("user-1")).
+					Return([]domain.PushToken{{ID: "tok-a", UserID: "user-1", Token: "fcm-token", Platform: "android"}}, nil)
+
+				sent := make(chan notification.PushNotificationRequest, 1)
+				notificationClient.EXPECT().
+					SendPushNotification(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, req notification.PushNotificationRequest) error {
+						sent <- req
+						return nil
+					}).
+					Times(1)
+
+				msg := events.ExecutionReadyForNotification.New("tenant-1", events.ExecutionReminder{
+					ExecutionID:  "execution-1",
+					ActivityID:   "activity-1",
+					ActivityName: "Filter Replacement",
+				}).Message()
+				Eventually(func() error {
+					return broker.Publish(context.Background(), "maintenance_executions", msg)
+				}).Should(Succeed())
+
+				var req notification.PushNotificationRequest
+				Eventually(sent).Should(Receive(&req))
+				Expect(req.Title).To(Equal("Execution Reminder: Filter Replacement"))
+				Expect(req.DeepLink).To(Equal("/maintenance/executions/execution-1"))
+			})
+		})
+
 		When("the activity name is missing from the message", func() {
 			It("should fall back to the generic title", func() {
 				tokens := []domain.PushToken{
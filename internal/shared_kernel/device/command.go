@@ -15,6 +15,8 @@ type Command struct {
 	DeviceName    string         `json:"device_name"`
 	TaskID        string         `json:"task_id"`
 	Payload       CommandPayload `json:"payload"`
+	RawPayload    []byte         `json:"raw_payload,omitempty"`
+	Confirmed     bool           `json:"confirmed,omitempty"`
 	DispatchAfter utils.Time     `json:"dispatch_after"`
 	Port          uint8          `json:"port"`
 	Priority      string         `json:"priority"`
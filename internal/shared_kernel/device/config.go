@@ -0,0 +1,49 @@
+package device
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// EncodeConfig encodes device settings as MessagePack, the format commands already use. Keys are
+// sorted so the same settings always give the same bytes, and JSON numbers without a fractional
+// part are encoded as the smallest integer that holds them.
+func EncodeConfig(settings map[string]any) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := msgpack.NewEncoder(&buffer)
+	encoder.SetSortMapKeys(true)
+	encoder.UseCompactInts(true)
+
+	err := encoder.Encode(compactNumbers(settings))
+	if err != nil {
+		return nil, fmt.Errorf("msgpack marshaling: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+func compactNumbers(value any) any {
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= math.MaxInt64/2 {
+			return int64(v)
+		}
+		return v
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = compactNumbers(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = compactNumbers(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
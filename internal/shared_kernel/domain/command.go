@@ -22,11 +22,12 @@ type (
 type CommandStatus string
 
 const (
-	CommandStatusPending CommandStatus = "pending" // Initial state when command is created
-	CommandStatusQueued  CommandStatus = "queued"  // Command is queued in TTN server
-	CommandStatusSent    CommandStatus = "sent"    // Command was sent to device
-	CommandStatusAck     CommandStatus = "ack"     // Command was acknowledged by device
-	CommandStatusFailed  CommandStatus = "failed"  // Command failed to be delivered
+	CommandStatusPending   CommandStatus = "pending"   // Initial state when command is created
+	CommandStatusQueued    CommandStatus = "queued"    // Command is queued in TTN server
+	CommandStatusSent      CommandStatus = "sent"      // Command was sent to device
+	CommandStatusAck       CommandStatus = "ack"       // Command was acknowledged by device
+	CommandStatusFailed    CommandStatus = "failed"    // Command failed to be delivered
+	CommandStatusCancelled CommandStatus = "cancelled" // Command was withdrawn before dispatch
 )
 
 type CommandSequence struct {
@@ -53,11 +54,15 @@ type Command struct {
 	QueuedAt     *utils.Time   `json:"queued_at,omitempty"`     // When command was queued in TTN
 	AckedAt      *utils.Time   `json:"acked_at,omitempty"`      // When command was acknowledged by device
 	FailedAt     *utils.Time   `json:"failed_at,omitempty"`     // When command failed
+
+	// Frame fields, used by configuration pushes
+	RawPayload []byte // Sent as the frame payload instead of the encoded Payload when set
+	Confirmed  bool   // Asks the device to acknowledge the downlink, reported as ack
 }
 
-// IsCompleted returns true if the command has reached a final state (ack or failed).
+// IsCompleted returns true if the command has reached a final state (ack, failed or cancelled).
 func (c Command) IsCompleted() bool {
-	return c.Status == CommandStatusAck || c.Status == CommandStatusFailed
+	return c.Status == CommandStatusAck || c.Status == CommandStatusFailed || c.Status == CommandStatusCancelled
 }
 
 // IsFailed returns true if the command has failed.
@@ -65,6 +70,11 @@ func (c Command) IsFailed() bool {
 	return c.Status == CommandStatusFailed
 }
 
+// IsCancelled returns true if the command was withdrawn before it was dispatched.
+func (c Command) IsCancelled() bool {
+	return c.Status == CommandStatusCancelled
+}
+
 // IsSuccessful returns true if the command was acknowledged.
 func (c Command) IsSuccessful() bool {
 	return c.Status == CommandStatusAck
@@ -87,6 +97,9 @@ func (c *Command) UpdateStatus(status CommandStatus, errorMessage *string) {
 	case CommandStatusFailed:
 		c.FailedAt = &now
 		c.ErrorMessage = errorMessage
+	case CommandStatusCancelled:
+		c.Ready = false
+		c.ErrorMessage = errorMessage
 	}
 }
 
@@ -98,6 +111,11 @@ type CommandPayload struct {
 // OverlapsWith checks if this command overlaps with another command.
 // Commands overlap if they target the same index (sensor/actuator) and their execution times could conflict.
 func (c Command) OverlapsWith(other Command) bool {
+	// Raw frames, such as configuration fragments, carry no sensor/actuator index
+	if c.RawPayload != nil || other.RawPayload != nil {
+		return false
+	}
+
 	// Commands overlap if they target the same index (sensor/actuator)
 	// and their execution times could conflict
 	if c.Payload.Index != other.Payload.Index {
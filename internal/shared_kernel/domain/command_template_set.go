@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"errors"
+	"time"
+	"zensor-server/internal/infra/utils"
+)
+
+var errCommandTemplateSetNameRequired = errors.New("command template set name is required")
+
+// CommandTemplateSet is a named, tenant-level list of command templates that several scheduled
+// tasks can reference. Its templates carry no device; the device is set when a task is created.
+type CommandTemplateSet struct {
+	ID               ID
+	Version          Version
+	Tenant           Tenant
+	Name             string
+	CommandTemplates []CommandTemplate
+	CreatedAt        utils.Time
+	UpdatedAt        utils.Time
+	DeletedAt        *utils.Time
+}
+
+// Validate reports whether the set can be stored, so updates are checked like new sets.
+func (s *CommandTemplateSet) Validate() error {
+	if s.Tenant.ID == "" {
+		return errTenantRequired
+	}
+
+	if s.Name == "" {
+		return errCommandTemplateSetNameRequired
+	}
+
+	if len(s.CommandTemplates) == 0 {
+		return errCommandTemplatesRequired
+	}
+
+	return nil
+}
+
+func (s *CommandTemplateSet) IsDeleted() bool {
+	return s.DeletedAt != nil
+}
+
+func (s *CommandTemplateSet) SoftDelete() {
+	now := utils.Time{Time: time.Now()}
+	s.DeletedAt = &now
+	s.UpdatedAt = now
+}
+
+func NewCommandTemplateSetBuilder() *commandTemplateSetBuilder {
+	return &commandTemplateSetBuilder{}
+}
+
+type commandTemplateSetBuilder struct {
+	actions []commandTemplateSetHandler
+}
+
+type commandTemplateSetHandler func(v *CommandTemplateSet) error
+
+func (b *commandTemplateSetBuilder) WithTenant(value Tenant) *commandTemplateSetBuilder {
+	b.actions = append(b.actions, func(s *CommandTemplateSet) error {
+		s.Tenant = value
+		return nil
+	})
+	return b
+}
+
+func (b *commandTemplateSetBuilder) WithName(value string) *commandTemplateSetBuilder {
+	b.actions = append(b.actions, func(s *CommandTemplateSet) error {
+		s.Name = value
+		return nil
+	})
+	return b
+}
+
+func (b *commandTemplateSetBuilder) WithCommandTemplates(value []CommandTemplate) *commandTemplateSetBuilder {
+	b.actions = append(b.actions, func(s *CommandTemplateSet) error {
+		s.CommandTemplates = value
+		return nil
+	})
+	return b
+}
+
+func (b *commandTemplateSetBuilder) Build() (CommandTemplateSet, error) {
+	now := utils.Time{Time: time.Now()}
+	result := CommandTemplateSet{
+		ID:               ID(utils.GenerateUUID()),
+		Version:          1,
+		CommandTemplates: make([]CommandTemplate, 0),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+
+	for _, a := range b.actions {
+		if err := a(&result); err != nil {
+			return CommandTemplateSet{}, err
+		}
+	}
+
+	if err := result.Validate(); err != nil {
+		return CommandTemplateSet{}, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package domain_test
+
+import (
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("CommandTemplateSet", func() {
+	ginkgo.Context("Build", func() {
+		var templates []domain.CommandTemplate
+		var name string
+
+		ginkgo.BeforeEach(func() {
+			name = "Zone A morning cycle"
+			templates = []domain.CommandTemplate{{Payload: domain.CommandPayload{Index: 1, Value: 1}}}
+		})
+
+		build := func() (domain.CommandTemplateSet, error) {
+			return domain.NewCommandTemplateSetBuilder().
+				WithTenant(domain.Tenant{ID: "tenant-1"}).
+				WithName(name).
+				WithCommandTemplates(templates).
+				Build()
+		}
+
+		ginkgo.When("all fields are given", func() {
+			ginkgo.It("should build the set", func() {
+				set, err := build()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(set.ID).NotTo(gomega.BeEmpty())
+				gomega.Expect(set.Version).To(gomega.Equal(domain.Version(1)))
+				gomega.Expect(set.CommandTemplates).To(gomega.HaveLen(1))
+			})
+		})
+
+		ginkgo.When("the name is missing", func() {
+			ginkgo.BeforeEach(func() {
+				name = ""
+			})
+
+			ginkgo.It("should return an error", func() {
+				_, err := build()
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+
+		ginkgo.When("no templates are given", func() {
+			ginkgo.BeforeEach(func() {
+				templates = nil
+			})
+
+			ginkgo.It("should return an error", func() {
+				_, err := build()
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+	})
+})
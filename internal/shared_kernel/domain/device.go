@@ -1,21 +1,64 @@
 package domain
 
 import (
+	"errors"
+	"regexp"
+	"strings"
 	"time"
 	"zensor-server/internal/infra/utils"
 )
 
 type Device struct {
-	ID                    ID
-	Name                  string
-	DisplayName           string // User-friendly name that can be edited in tenant portal
-	AppEUI                string
-	DevEUI                string
-	AppKey                string
-	TenantID              *ID // Optional tenant association, nil means orphan device
-	Sector                *Sector
-	EvaluationRules       []EvaluationRule
-	LastMessageReceivedAt utils.Time
+	ID                     ID
+	Name                   string
+	DisplayName            string // User-friendly name that can be edited in tenant portal
+	AppEUI                 string
+	DevEUI                 string
+	AppKey                 string
+	TenantID               *ID    // Optional tenant association, nil means orphan device
+	Profile                string // Hardware or network profile the device was provisioned with
+	Sector                 *Sector
+	EvaluationRules        []EvaluationRule
+	LastMessageReceivedAt  utils.Time
+	ExpectedUplinkInterval time.Duration // Zero means DefaultExpectedUplinkInterval
+	Provisioning           DeviceProvisioning
+	Tags                   map[string]string // Lowercase keys, used to filter device listings
+	Metadata               map[string]any    // Free-form attributes owned by the operators
+	Battery                *BatteryReading   // Last battery voltage reported, nil until the first one
+	Health                 DeviceHealth      // Last health evaluation
+	DeletedAt              *utils.Time       // Set once the device is decommissioned
+}
+
+// DefaultExpectedUplinkInterval is used for devices that do not declare how often they uplink.
+const DefaultExpectedUplinkInterval = 5 * time.Minute
+
+var (
+	errExpectedUplinkIntervalNegative = errors.New("expected uplink interval must not be negative")
+
+	ErrInvalidDevEUI     = errors.New("dev_eui must be 16 hexadecimal characters")
+	ErrInvalidJoinEUI    = errors.New("join_eui must be 16 hexadecimal characters")
+	ErrInvalidAppKey     = errors.New("app_key must be 32 hexadecimal characters")
+	ErrInvalidDeviceName = errors.New("device names must not contain '/', '+' or '#'")
+)
+
+var (
+	euiPattern    = regexp.MustCompile(`^[0-9A-Fa-f]{16}$`)
+	appKeyPattern = regexp.MustCompile(`^[0-9A-Fa-f]{32}$`)
+)
+
+// ValidateLoRaWANKeys checks the format of the OTAA identifiers and root key of the device.
+func (d *Device) ValidateLoRaWANKeys() error {
+	if !euiPattern.MatchString(d.DevEUI) {
+		return ErrInvalidDevEUI
+	}
+	if !euiPattern.MatchString(d.AppEUI) {
+		return ErrInvalidJoinEUI
+	}
+	if !appKeyPattern.MatchString(d.AppKey) {
+		return ErrInvalidAppKey
+	}
+
+	return nil
 }
 
 func (d *Device) AddEvaluationRule(evaluationRule EvaluationRule) {
@@ -34,6 +77,21 @@ func (d *Device) BelongsToTenant(tenantID ID) bool {
 	return d.TenantID != nil && *d.TenantID == tenantID
 }
 
+// Release detaches the device from its tenant, turning it back into an orphan.
+func (d *Device) Release() {
+	d.TenantID = nil
+}
+
+func (d *Device) IsDeleted() bool {
+	return d.DeletedAt != nil
+}
+
+// Decommission soft-deletes the device. Its tenant is kept so history stays attributed.
+func (d *Device) Decommission() {
+	now := utils.Time{Time: time.Now()}
+	d.DeletedAt = &now
+}
+
 func (d *Device) UpdateDisplayName(displayName string) {
 	d.DisplayName = displayName
 }
@@ -43,22 +101,43 @@ func (d *Device) UpdateLastMessageReceivedAt(timestamp utils.Time) {
 	d.LastMessageReceivedAt = timestamp
 }
 
-// IsOnline returns true if the device received a message within the last 5 minutes.
+// UplinkInterval returns how long the device may stay silent before it is considered offline.
+func (d *Device) UplinkInterval() time.Duration {
+	if d.ExpectedUplinkInterval > 0 {
+		return d.ExpectedUplinkInterval
+	}
+	return DefaultExpectedUplinkInterval
+}
+
+// UpdateExpectedUplinkInterval sets the uplink interval; zero restores the default.
+func (d *Device) UpdateExpectedUplinkInterval(interval time.Duration) error {
+	if interval < 0 {
+		return errExpectedUplinkIntervalNegative
+	}
+	d.ExpectedUplinkInterval = interval
+	return nil
+}
+
+// IsOnline returns true if the device received a message within its uplink interval.
 func (d *Device) IsOnline() bool {
+	return d.ConnectivityAt(time.Now()) == ConnectivityStateOnline
+}
+
+// ConnectivityAt evaluates whether the device is online at the given instant.
+func (d *Device) ConnectivityAt(now time.Time) ConnectivityState {
 	if d.LastMessageReceivedAt.IsZero() {
-		return false
+		return ConnectivityStateOffline
 	}
 
-	fiveMinutesAgo := time.Now().Add(-5 * time.Minute)
-	return d.LastMessageReceivedAt.After(fiveMinutesAgo)
+	if now.Sub(d.LastMessageReceivedAt.Time) < d.UplinkInterval() {
+		return ConnectivityStateOnline
+	}
+	return ConnectivityStateOffline
 }
 
 // GetStatus returns "online" or "offline" based on last message timestamp.
 func (d *Device) GetStatus() string {
-	if d.IsOnline() {
-		return "online"
-	}
-	return "offline"
+	return string(d.ConnectivityAt(time.Now()))
 }
 
 func NewDeviceBuilder() *deviceBuilder {
@@ -71,8 +150,13 @@ type deviceBuilder struct {
 
 type deviceHandler func(v *Device) error
 
+// WithName rejects names that would not fit in a single level of the device topics, which are
+// built from the name.
 func (b *deviceBuilder) WithName(value string) *deviceBuilder {
 	b.actions = append(b.actions, func(d *Device) error {
+		if strings.ContainsAny(value, "/+#") {
+			return ErrInvalidDeviceName
+		}
 		d.Name = value
 		return nil
 	})
@@ -119,6 +203,43 @@ func (b *deviceBuilder) WithAppKey(value string) *deviceBuilder {
 	return b
 }
 
+func (b *deviceBuilder) WithProfile(value string) *deviceBuilder {
+	b.actions = append(b.actions, func(d *Device) error {
+		d.Profile = value
+		return nil
+	})
+	return b
+}
+
+func (b *deviceBuilder) WithSector(name string) *deviceBuilder {
+	b.actions = append(b.actions, func(d *Device) error {
+		d.Sector = &Sector{Name: Name(name)}
+		return nil
+	})
+	return b
+}
+
+func (b *deviceBuilder) WithExpectedUplinkInterval(value time.Duration) *deviceBuilder {
+	b.actions = append(b.actions, func(d *Device) error {
+		return d.UpdateExpectedUplinkInterval(value)
+	})
+	return b
+}
+
+func (b *deviceBuilder) WithTags(value map[string]string) *deviceBuilder {
+	b.actions = append(b.actions, func(d *Device) error {
+		return d.UpdateTags(value)
+	})
+	return b
+}
+
+func (b *deviceBuilder) WithMetadata(value map[string]any) *deviceBuilder {
+	b.actions = append(b.actions, func(d *Device) error {
+		return d.UpdateMetadata(value)
+	})
+	return b
+}
+
 func (b *deviceBuilder) Build() (Device, error) {
 	result := Device{
 		ID:              ID(utils.GenerateUUID()),
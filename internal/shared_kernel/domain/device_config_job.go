@@ -0,0 +1,264 @@
+package domain
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"time"
+	"zensor-server/internal/infra/utils"
+)
+
+// DeviceConfigJobStatus tracks the push of a configuration blob to a device.
+type DeviceConfigJobStatus string
+
+const (
+	DeviceConfigJobStatusPending   DeviceConfigJobStatus = "pending"   // No fragment was sent yet
+	DeviceConfigJobStatusRunning   DeviceConfigJobStatus = "running"   // Fragments are being delivered
+	DeviceConfigJobStatusCompleted DeviceConfigJobStatus = "completed" // Every fragment was acknowledged
+	DeviceConfigJobStatusFailed    DeviceConfigJobStatus = "failed"    // A fragment ran out of attempts, it can be resumed
+	DeviceConfigJobStatusCancelled DeviceConfigJobStatus = "cancelled" // Withdrawn before completion
+)
+
+// DeviceConfigFragmentStatus tracks the delivery of one fragment of a configuration push.
+type DeviceConfigFragmentStatus string
+
+const (
+	DeviceConfigFragmentStatusPending DeviceConfigFragmentStatus = "pending" // Waiting to be sent, or to be sent again
+	DeviceConfigFragmentStatusSent    DeviceConfigFragmentStatus = "sent"    // Queued as a confirmed downlink
+	DeviceConfigFragmentStatusAcked   DeviceConfigFragmentStatus = "acked"   // Acknowledged by the device
+)
+
+const (
+	// DeviceConfigPort is the FPort configuration fragments are sent on, apart from commands.
+	DeviceConfigPort Port = 16
+
+	DefaultDeviceConfigFragmentSize = 47 // Fits, with the header, the 51 bytes of the slowest data rates
+	MinDeviceConfigFragmentSize     = 8
+	MaxDeviceConfigFragmentSize     = 218 // Fits, with the header, the largest LoRaWAN application payload
+	MaxDeviceConfigSize             = 4096
+	DefaultDeviceConfigMaxAttempts  = 5
+
+	deviceConfigFragmentHeaderSize = 4
+	deviceConfigChecksumSize       = 4
+	maxDeviceConfigFragments       = 255
+)
+
+var (
+	ErrDeviceConfigEmpty                 = errors.New("the device configuration is empty")
+	ErrDeviceConfigTooLarge              = errors.New("the device configuration must be at most 4096 bytes")
+	ErrInvalidDeviceConfigFragmentSize   = errors.New("the fragment size must be between 8 and 218 bytes")
+	ErrDeviceConfigTooManyFragments      = errors.New("the device configuration must fit in 255 fragments")
+	ErrDeviceConfigJobNotActive          = errors.New("the configuration job is already finished")
+	ErrDeviceConfigJobNotResumable       = errors.New("only failed configuration jobs can be resumed")
+	ErrDeviceConfigFragmentOutOfSequence = errors.New("the fragment is not the one being delivered")
+)
+
+// DeviceConfigFragment is one confirmed downlink of a configuration push. Its payload starts with a
+// 4-byte header: the low 16 bits of the configuration checksum, big-endian, which tell pushes
+// apart, then the fragment index and the fragment count.
+type DeviceConfigFragment struct {
+	Index     int
+	Payload   []byte
+	Status    DeviceConfigFragmentStatus
+	Attempts  int
+	CommandID *ID // Command carrying the last attempt
+	SentAt    *utils.Time
+	AckedAt   *utils.Time
+}
+
+// DeviceConfigJob pushes a configuration blob to a device split in fragments that fit a LoRa
+// downlink. The blob is followed by its CRC-32, big-endian, so the device can check the
+// reassembled configuration. Fragments are delivered one at a time, in order, each as a confirmed
+// downlink acknowledged through the command correlation, and a failed fragment is retried up to
+// MaxAttempts times before the job fails. A failed job resumes from the first unacknowledged fragment.
+type DeviceConfigJob struct {
+	ID           ID
+	Version      int
+	DeviceID     ID
+	Port         Port
+	Size         int
+	Checksum     uint32
+	FragmentSize int
+	MaxAttempts  int
+	Fragments    []DeviceConfigFragment
+	Status       DeviceConfigJobStatus
+	LastError    string
+	CreatedAt    utils.Time
+	UpdatedAt    utils.Time
+	CompletedAt  *utils.Time
+}
+
+// NewDeviceConfigJob splits the configuration in fragments of fragmentSize bytes; zero picks the default size.
+func NewDeviceConfigJob(deviceID ID, config []byte, fragmentSize int, now time.Time) (DeviceConfigJob, error) {
+	if len(config) == 0 {
+		return DeviceConfigJob{}, ErrDeviceConfigEmpty
+	}
+	if len(config) > MaxDeviceConfigSize {
+		return DeviceConfigJob{}, ErrDeviceConfigTooLarge
+	}
+	if fragmentSize == 0 {
+		fragmentSize = DefaultDeviceConfigFragmentSize
+	}
+	if fragmentSize < MinDeviceConfigFragmentSize || fragmentSize > MaxDeviceConfigFragmentSize {
+		return DeviceConfigJob{}, ErrInvalidDeviceConfigFragmentSize
+	}
+
+	checksum := crc32.ChecksumIEEE(config)
+	data := make([]byte, 0, len(config)+deviceConfigChecksumSize)
+	data = binary.BigEndian.AppendUint32(append(data, config...), checksum)
+	count := (len(data) + fragmentSize - 1) / fragmentSize
+	if count > maxDeviceConfigFragments {
+		return DeviceConfigJob{}, ErrDeviceConfigTooManyFragments
+	}
+
+	fragments := make([]DeviceConfigFragment, count)
+	for i := range fragments {
+		chunk := data[i*fragmentSize : min((i+1)*fragmentSize, len(data))]
+		payload := make([]byte, 0, deviceConfigFragmentHeaderSize+len(chunk))
+		payload = binary.BigEndian.AppendUint16(payload, uint16(checksum))
+		payload = append(payload, byte(i), byte(count))
+		payload = append(payload, chunk...)
+
+		fragments[i] = DeviceConfigFragment{
+			Index:   i,
+			Payload: payload,
+			Status:  DeviceConfigFragmentStatusPending,
+		}
+	}
+
+	createdAt := utils.Time{Time: now}
+	return DeviceConfigJob{
+		ID:           ID(utils.GenerateUUID()),
+		Version:      1,
+		DeviceID:     deviceID,
+		Port:         DeviceConfigPort,
+		Size:         len(config),
+		Checksum:     checksum,
+		FragmentSize: fragmentSize,
+		MaxAttempts:  DefaultDeviceConfigMaxAttempts,
+		Fragments:    fragments,
+		Status:       DeviceConfigJobStatusPending,
+		CreatedAt:    createdAt,
+		UpdatedAt:    createdAt,
+	}, nil
+}
+
+// IsActive tells whether fragments are still to be delivered.
+func (j DeviceConfigJob) IsActive() bool {
+	return j.Status == DeviceConfigJobStatusPending || j.Status == DeviceConfigJobStatusRunning
+}
+
+// CurrentFragment returns the index of the first fragment not acknowledged yet.
+func (j DeviceConfigJob) CurrentFragment() (int, bool) {
+	for i, fragment := range j.Fragments {
+		if fragment.Status != DeviceConfigFragmentStatusAcked {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// AckedFragments counts the fragments acknowledged by the device.
+func (j DeviceConfigJob) AckedFragments() int {
+	acked := 0
+	for _, fragment := range j.Fragments {
+		if fragment.Status == DeviceConfigFragmentStatusAcked {
+			acked++
+		}
+	}
+	return acked
+}
+
+// RecordFragmentSent records that the current fragment was queued as the given command.
+func (j *DeviceConfigJob) RecordFragmentSent(index int, commandID ID, at time.Time) error {
+	if err := j.checkCurrent(index); err != nil {
+		return err
+	}
+
+	sentAt := utils.Time{Time: at}
+	fragment := &j.Fragments[index]
+	fragment.Status = DeviceConfigFragmentStatusSent
+	fragment.Attempts++
+	fragment.CommandID = &commandID
+	fragment.SentAt = &sentAt
+
+	j.Status = DeviceConfigJobStatusRunning
+	j.UpdatedAt = sentAt
+	return nil
+}
+
+// RecordFragmentAcked records the acknowledgement of the current fragment and completes the job
+// after the last one.
+func (j *DeviceConfigJob) RecordFragmentAcked(index int, at time.Time) error {
+	if err := j.checkCurrent(index); err != nil {
+		return err
+	}
+
+	ackedAt := utils.Time{Time: at}
+	fragment := &j.Fragments[index]
+	fragment.Status = DeviceConfigFragmentStatusAcked
+	fragment.AckedAt = &ackedAt
+
+	j.UpdatedAt = ackedAt
+	if _, pending := j.CurrentFragment(); !pending {
+		j.Status = DeviceConfigJobStatusCompleted
+		j.LastError = ""
+		j.CompletedAt = &ackedAt
+	}
+	return nil
+}
+
+// RecordFragmentFailure puts the current fragment back to be sent again, or fails the job once the
+// fragment has used all its attempts.
+func (j *DeviceConfigJob) RecordFragmentFailure(index int, reason string, at time.Time) error {
+	if err := j.checkCurrent(index); err != nil {
+		return err
+	}
+
+	fragment := &j.Fragments[index]
+	fragment.Status = DeviceConfigFragmentStatusPending
+	fragment.CommandID = nil
+
+	j.LastError = reason
+	j.UpdatedAt = utils.Time{Time: at}
+	if fragment.Attempts >= j.MaxAttempts {
+		j.Status = DeviceConfigJobStatusFailed
+	}
+	return nil
+}
+
+// Cancel withdraws an active job; fragments already acknowledged stay on the device.
+func (j *DeviceConfigJob) Cancel(reason string, at time.Time) error {
+	if !j.IsActive() {
+		return ErrDeviceConfigJobNotActive
+	}
+
+	j.Status = DeviceConfigJobStatusCancelled
+	j.LastError = reason
+	j.UpdatedAt = utils.Time{Time: at}
+	return nil
+}
+
+// Resume restarts a failed job from its first unacknowledged fragment with a fresh set of attempts.
+func (j *DeviceConfigJob) Resume(at time.Time) error {
+	if j.Status != DeviceConfigJobStatusFailed {
+		return ErrDeviceConfigJobNotResumable
+	}
+
+	if index, found := j.CurrentFragment(); found {
+		j.Fragments[index].Attempts = 0
+	}
+	j.Status = DeviceConfigJobStatusRunning
+	j.UpdatedAt = utils.Time{Time: at}
+	return nil
+}
+
+func (j DeviceConfigJob) checkCurrent(index int) error {
+	if !j.IsActive() {
+		return ErrDeviceConfigJobNotActive
+	}
+	if current, found := j.CurrentFragment(); !found || current != index {
+		return ErrDeviceConfigFragmentOutOfSequence
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package domain_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("DeviceConfigJob", func() {
+	var (
+		now    time.Time
+		config []byte
+	)
+
+	ginkgo.BeforeEach(func() {
+		now = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+		config = bytes.Repeat([]byte{0xAB}, 20)
+	})
+
+	ginkgo.When("a job is created", func() {
+		ginkgo.It("should split the configuration and its checksum in headed fragments", func() {
+			job, err := domain.NewDeviceConfigJob(domain.ID("device-1"), config, 10, now)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+			gomega.Expect(job.Status).To(gomega.Equal(domain.DeviceConfigJobStatusPending))
+			gomega.Expect(job.Port).To(gomega.Equal(domain.DeviceConfigPort))
+			gomega.Expect(job.Fragments).To(gomega.HaveLen(3))
+
+			checksum := crc32.ChecksumIEEE(config)
+			var reassembled []byte
+			for i, fragment := range job.Fragments {
+				gomega.Expect(binary.BigEndian.Uint16(fragment.Payload)).To(gomega.Equal(uint16(checksum)))
+				gomega.Expect(fragment.Payload[2]).To(gomega.Equal(byte(i)))
+				gomega.Expect(fragment.Payload[3]).To(gomega.Equal(byte(3)))
+				reassembled = append(reassembled, fragment.Payload[4:]...)
+			}
+			gomega.Expect(reassembled).To(gomega.Equal(binary.BigEndian.AppendUint32(config, checksum)))
+		})
+
+		ginkgo.It("should reject empty, oversized and badly fragmented configurations", func() {
+			_, err := domain.NewDeviceConfigJob(domain.ID("device-1"), nil, 0, now)
+			gomega.Expect(err).To(gomega.MatchError(domain.ErrDeviceConfigEmpty))
+
+			_, err = domain.NewDeviceConfigJob(domain.ID("device-1"), make([]byte, 5000), 0, now)
+			gomega.Expect(err).To(gomega.MatchError(domain.ErrDeviceConfigTooLarge))
+
+			_, err = domain.NewDeviceConfigJob(domain.ID("device-1"), config, 4, now)
+			gomega.Expect(err).To(gomega.MatchError(domain.ErrInvalidDeviceConfigFragmentSize))
+
+			_, err = domain.NewDeviceConfigJob(domain.ID("device-1"), make([]byte, 4096), 8, now)
+			gomega.Expect(err).To(gomega.MatchError(domain.ErrDeviceConfigTooManyFragments))
+		})
+	})
+
+	ginkgo.When("fragments are delivered", func() {
+		var job domain.DeviceConfigJob
+
+		ginkgo.BeforeEach(func() {
+			var err error
+			job, err = domain.NewDeviceConfigJob(domain.ID("device-1"), config, 16, now)
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(job.Fragments).To(gomega.HaveLen(2))
+		})
+
+		ginkgo.It("should complete after the last acknowledgement", func() {
+			gomega.Expect(job.RecordFragmentSent(0, domain.ID("command-1"), now)).To(gomega.Succeed())
+			gomega.Expect(job.Status).To(gomega.Equal(domain.DeviceConfigJobStatusRunning))
+			gomega.Expect(job.RecordFragmentAcked(0, now)).To(gomega.Succeed())
+
+			gomega.Expect(job.RecordFragmentSent(0, domain.ID("command-2"), now)).
+				To(gomega.MatchError(domain.ErrDeviceConfigFragmentOutOfSequence))
+			gomega.Expect(job.RecordFragmentSent(1, domain.ID("command-2"), now)).To(gomega.Succeed())
+			gomega.Expect(job.RecordFragmentAcked(1, now)).To(gomega.Succeed())
+
+			gomega.Expect(job.Status).To(gomega.Equal(domain.DeviceConfigJobStatusCompleted))
+			gomega.Expect(job.AckedFragments()).To(gomega.Equal(2))
+			gomega.Expect(job.CompletedAt).NotTo(gomega.BeNil())
+		})
+
+		ginkgo.It("should fail once a fragment runs out of attempts and resume from it", func() {
+			gomega.Expect(job.RecordFragmentSent(0, domain.ID("command-0"), now)).To(gomega.Succeed())
+			gomega.Expect(job.RecordFragmentAcked(0, now)).To(gomega.Succeed())
+
+			for attempt := range job.MaxAttempts {
+				gomega.Expect(job.Status).To(gomega.Equal(domain.DeviceConfigJobStatusRunning))
+				gomega.Expect(job.RecordFragmentSent(1, domain.ID("command-1"), now)).To(gomega.Succeed())
+				gomega.Expect(job.RecordFragmentFailure(1, "downlink failed", now)).To(gomega.Succeed())
+				gomega.Expect(job.Fragments[1].Attempts).To(gomega.Equal(attempt + 1))
+			}
+
+			gomega.Expect(job.Status).To(gomega.Equal(domain.DeviceConfigJobStatusFailed))
+			gomega.Expect(job.LastError).To(gomega.Equal("downlink failed"))
+
+			gomega.Expect(job.Resume(now)).To(gomega.Succeed())
+			index, found := job.CurrentFragment()
+			gomega.Expect(found).To(gomega.BeTrue())
+			gomega.Expect(index).To(gomega.Equal(1))
+			gomega.Expect(job.Fragments[1].Attempts).To(gomega.BeZero())
+			gomega.Expect(job.Fragments[0].Status).To(gomega.Equal(domain.DeviceConfigFragmentStatusAcked))
+		})
+
+		ginkgo.It("should only cancel active jobs and only resume failed ones", func() {
+			gomega.Expect(job.Resume(now)).To(gomega.MatchError(domain.ErrDeviceConfigJobNotResumable))
+			gomega.Expect(job.Cancel("superseded", now)).To(gomega.Succeed())
+			gomega.Expect(job.Cancel("superseded", now)).To(gomega.MatchError(domain.ErrDeviceConfigJobNotActive))
+			gomega.Expect(job.RecordFragmentSent(0, domain.ID("command-1"), now)).
+				To(gomega.MatchError(domain.ErrDeviceConfigJobNotActive))
+		})
+	})
+})
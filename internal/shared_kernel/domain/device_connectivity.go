@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"time"
+	"zensor-server/internal/infra/utils"
+)
+
+type ConnectivityState string
+
+const (
+	ConnectivityStateUnknown ConnectivityState = "unknown" // No evaluation recorded yet
+	ConnectivityStateOnline  ConnectivityState = "online"
+	ConnectivityStateOffline ConnectivityState = "offline"
+)
+
+// DeviceConnectivityTransition records a device going online or offline. The latest transition
+// of a device holds its last known connectivity state.
+type DeviceConnectivityTransition struct {
+	ID                    ID
+	DeviceID              ID
+	TenantID              *ID
+	From                  ConnectivityState
+	To                    ConnectivityState
+	LastMessageReceivedAt *utils.Time
+	OccurredAt            utils.Time
+}
+
+// IsBaseline reports whether the transition only records the first evaluated state of the
+// device, which is not announced as a change.
+func (t DeviceConnectivityTransition) IsBaseline() bool {
+	return t.From == ConnectivityStateUnknown
+}
+
+// EvaluateConnectivity compares the device state at now with the previously known state and
+// returns the transition to record, if any.
+func EvaluateConnectivity(device Device, previous ConnectivityState, now time.Time) (DeviceConnectivityTransition, bool) {
+	current := device.ConnectivityAt(now)
+	if current == previous {
+		return DeviceConnectivityTransition{}, false
+	}
+
+	transition := DeviceConnectivityTransition{
+		ID:         ID(utils.GenerateUUID()),
+		DeviceID:   device.ID,
+		TenantID:   device.TenantID,
+		From:       previous,
+		To:         current,
+		OccurredAt: utils.Time{Time: now},
+	}
+
+	if !device.LastMessageReceivedAt.IsZero() {
+		lastMessageReceivedAt := device.LastMessageReceivedAt
+		transition.LastMessageReceivedAt = &lastMessageReceivedAt
+	}
+
+	return transition, true
+}
@@ -0,0 +1,84 @@
+package domain_test
+
+import (
+	"time"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("DeviceConnectivity", func() {
+	var (
+		now    time.Time
+		device domain.Device
+	)
+
+	ginkgo.BeforeEach(func() {
+		now = time.Now()
+		device = domain.Device{ID: domain.ID("device-1")}
+	})
+
+	ginkgo.Context("ConnectivityAt", func() {
+		ginkgo.When("the device never sent a message", func() {
+			ginkgo.It("should be offline", func() {
+				gomega.Expect(device.ConnectivityAt(now)).To(gomega.Equal(domain.ConnectivityStateOffline))
+			})
+		})
+
+		ginkgo.When("the device uses the default interval", func() {
+			ginkgo.It("should go offline after five minutes of silence", func() {
+				device.UpdateLastMessageReceivedAt(utils.Time{Time: now.Add(-4 * time.Minute)})
+				gomega.Expect(device.ConnectivityAt(now)).To(gomega.Equal(domain.ConnectivityStateOnline))
+				gomega.Expect(device.ConnectivityAt(now.Add(2 * time.Minute))).To(gomega.Equal(domain.ConnectivityStateOffline))
+			})
+		})
+
+		ginkgo.When("the device uplinks every 30 minutes", func() {
+			ginkgo.It("should stay online within its expected interval", func() {
+				gomega.Expect(device.UpdateExpectedUplinkInterval(30 * time.Minute)).To(gomega.Succeed())
+				device.UpdateLastMessageReceivedAt(utils.Time{Time: now.Add(-20 * time.Minute)})
+				gomega.Expect(device.ConnectivityAt(now)).To(gomega.Equal(domain.ConnectivityStateOnline))
+			})
+		})
+
+		ginkgo.When("the expected interval is negative", func() {
+			ginkgo.It("should reject it", func() {
+				gomega.Expect(device.UpdateExpectedUplinkInterval(-time.Minute)).NotTo(gomega.Succeed())
+			})
+		})
+	})
+
+	ginkgo.Context("EvaluateConnectivity", func() {
+		ginkgo.When("the state did not change", func() {
+			ginkgo.It("should not return a transition", func() {
+				_, changed := domain.EvaluateConnectivity(device, domain.ConnectivityStateOffline, now)
+				gomega.Expect(changed).To(gomega.BeFalse())
+			})
+		})
+
+		ginkgo.When("the device is evaluated for the first time", func() {
+			ginkgo.It("should return a baseline transition", func() {
+				transition, changed := domain.EvaluateConnectivity(device, domain.ConnectivityStateUnknown, now)
+				gomega.Expect(changed).To(gomega.BeTrue())
+				gomega.Expect(transition.IsBaseline()).To(gomega.BeTrue())
+				gomega.Expect(transition.To).To(gomega.Equal(domain.ConnectivityStateOffline))
+				gomega.Expect(transition.LastMessageReceivedAt).To(gomega.BeNil())
+			})
+		})
+
+		ginkgo.When("an online device goes silent", func() {
+			ginkgo.It("should return an offline transition", func() {
+				device.UpdateLastMessageReceivedAt(utils.Time{Time: now.Add(-time.Hour)})
+
+				transition, changed := domain.EvaluateConnectivity(device, domain.ConnectivityStateOnline, now)
+				gomega.Expect(changed).To(gomega.BeTrue())
+				gomega.Expect(transition.IsBaseline()).To(gomega.BeFalse())
+				gomega.Expect(transition.From).To(gomega.Equal(domain.ConnectivityStateOnline))
+				gomega.Expect(transition.To).To(gomega.Equal(domain.ConnectivityStateOffline))
+				gomega.Expect(transition.LastMessageReceivedAt).NotTo(gomega.BeNil())
+			})
+		})
+	})
+})
@@ -0,0 +1,260 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"time"
+	"zensor-server/internal/infra/utils"
+)
+
+// DeviceHealthStatus buckets the health score of a device.
+type DeviceHealthStatus string
+
+const (
+	DeviceHealthStatusUnknown  DeviceHealthStatus = "unknown"  // Nothing to score the device on yet
+	DeviceHealthStatusHealthy  DeviceHealthStatus = "healthy"  // Score of 80 or more
+	DeviceHealthStatusDegraded DeviceHealthStatus = "degraded" // Score of 50 or more
+	DeviceHealthStatusCritical DeviceHealthStatus = "critical" // Score below 50
+)
+
+const (
+	healthyScore  = 80
+	degradedScore = 50
+
+	// Weights of the health components; missing components are left out of the average.
+	batteryWeight        = 30
+	signalWeight         = 25
+	uplinkWeight         = 30
+	commandFailureWeight = 15
+
+	// Best-reception RSSI and SNR mapped to a signal quality of 0 and 100.
+	poorRSSI      = -120.0
+	excellentRSSI = -70.0
+	poorSNR       = -20.0
+	excellentSNR  = 10.0
+
+	// lowBatteryHysteresis is how many points above the threshold the battery must climb back to
+	// before a low battery is considered recovered, so readings around the threshold do not flap.
+	lowBatteryHysteresis = 5
+)
+
+var (
+	ErrInvalidBatteryVoltageRange = errors.New("the full battery voltage must be above the empty one")
+	ErrInvalidLowBatteryThreshold = errors.New("the low battery threshold must be between 0 and 100")
+)
+
+// BatteryReading is the last battery voltage a device reported.
+type BatteryReading struct {
+	Voltage    float64
+	ReportedAt utils.Time
+}
+
+// RecordBatteryReading keeps the reading unless a later one was already recorded.
+func (d *Device) RecordBatteryReading(voltage float64, at time.Time) bool {
+	if d.Battery != nil && d.Battery.ReportedAt.After(at) {
+		return false
+	}
+
+	d.Battery = &BatteryReading{Voltage: voltage, ReportedAt: utils.Time{Time: at}}
+	return true
+}
+
+// DeviceHealthPolicy turns battery voltages into charge levels and tells when a battery is low.
+// A LowBatteryPercent of zero disables low battery alerts.
+type DeviceHealthPolicy struct {
+	BatteryEmptyVoltage float64
+	BatteryFullVoltage  float64
+	LowBatteryPercent   int
+}
+
+func (p DeviceHealthPolicy) Validate() error {
+	if p.BatteryFullVoltage <= p.BatteryEmptyVoltage {
+		return ErrInvalidBatteryVoltageRange
+	}
+	if p.LowBatteryPercent < 0 || p.LowBatteryPercent > 100 {
+		return ErrInvalidLowBatteryThreshold
+	}
+	return nil
+}
+
+// WithLowBatteryPercent returns the policy with a tenant specific threshold, when there is one.
+func (p DeviceHealthPolicy) WithLowBatteryPercent(threshold *int) DeviceHealthPolicy {
+	if threshold != nil {
+		p.LowBatteryPercent = *threshold
+	}
+	return p
+}
+
+// BatteryPercent maps the voltage linearly between the empty and full voltages.
+func (p DeviceHealthPolicy) BatteryPercent(voltage float64) int {
+	return percent((voltage - p.BatteryEmptyVoltage) / (p.BatteryFullVoltage - p.BatteryEmptyVoltage))
+}
+
+// CommandOutcomes counts the commands of a device that reached a final delivery status.
+type CommandOutcomes struct {
+	Succeeded int
+	Failed    int
+}
+
+// DeviceHealthInputs is what the health of a device is computed from.
+type DeviceHealthInputs struct {
+	Battery         *BatteryReading
+	LinkQuality     LinkQualitySummary
+	UplinksReceived int
+	UplinksExpected int // Zero when the device was not expected to uplink yet
+	Commands        CommandOutcomes
+}
+
+// DeviceHealth is the last health evaluation of a device. Every component is a percentage and is
+// nil when there is no data for it.
+type DeviceHealth struct {
+	Status             DeviceHealthStatus
+	Score              *int
+	BatteryPercent     *int
+	SignalQuality      *int
+	UplinkRegularity   *int // Share of the expected uplinks received
+	CommandFailureRate *int // Share of the delivered or failed commands that failed
+	LowBattery         bool
+	EvaluatedAt        *utils.Time
+}
+
+// EvaluateDeviceHealth scores the device from 0 to 100 as the weighted average of the components
+// it has data for. The previous evaluation only matters to keep a low battery flagged until it
+// recovers past the threshold.
+func EvaluateDeviceHealth(previous DeviceHealth, inputs DeviceHealthInputs, policy DeviceHealthPolicy, now time.Time) DeviceHealth {
+	evaluatedAt := utils.Time{Time: now}
+	health := DeviceHealth{
+		Status:      DeviceHealthStatusUnknown,
+		EvaluatedAt: &evaluatedAt,
+	}
+
+	weighted, weights := 0, 0
+	add := func(value int, weight int) *int {
+		weighted += value * weight
+		weights += weight
+		return &value
+	}
+
+	if inputs.Battery != nil {
+		battery := policy.BatteryPercent(inputs.Battery.Voltage)
+		health.BatteryPercent = add(battery, batteryWeight)
+		health.LowBattery = isLowBattery(previous.LowBattery, battery, policy.LowBatteryPercent)
+	}
+
+	if inputs.LinkQuality.Samples > 0 {
+		rssi := (inputs.LinkQuality.AverageRSSI - poorRSSI) / (excellentRSSI - poorRSSI)
+		snr := (inputs.LinkQuality.AverageSNR - poorSNR) / (excellentSNR - poorSNR)
+		health.SignalQuality = add(percent((rssi+snr)/2), signalWeight)
+	}
+
+	if inputs.UplinksExpected > 0 {
+		regularity := float64(inputs.UplinksReceived) / float64(inputs.UplinksExpected)
+		health.UplinkRegularity = add(percent(regularity), uplinkWeight)
+	}
+
+	if total := inputs.Commands.Succeeded + inputs.Commands.Failed; total > 0 {
+		failureRate := percent(float64(inputs.Commands.Failed) / float64(total))
+		health.CommandFailureRate = &failureRate
+		add(100-failureRate, commandFailureWeight)
+	}
+
+	if weights == 0 {
+		return health
+	}
+
+	score := int(math.Round(float64(weighted) / float64(weights)))
+	health.Score = &score
+	switch {
+	case score >= healthyScore:
+		health.Status = DeviceHealthStatusHealthy
+	case score >= degradedScore:
+		health.Status = DeviceHealthStatusDegraded
+	default:
+		health.Status = DeviceHealthStatusCritical
+	}
+
+	return health
+}
+
+func isLowBattery(wasLow bool, battery, threshold int) bool {
+	if threshold == 0 {
+		return false
+	}
+	if wasLow {
+		return battery < threshold+lowBatteryHysteresis
+	}
+	return battery < threshold
+}
+
+// percent turns a ratio into a percentage clamped to 0-100.
+func percent(ratio float64) int {
+	return int(math.Round(math.Max(0, math.Min(1, ratio)) * 100))
+}
+
+// FleetHealth aggregates the health of the devices of a tenant.
+type FleetHealth struct {
+	Devices       int
+	Online        int
+	LowBattery    int
+	ByStatus      map[DeviceHealthStatus]int
+	AverageScore  *int
+	AverageSignal *int
+	Weakest       []Device // Scored devices with the lowest scores first
+}
+
+// SummarizeFleetHealth aggregates the last health evaluations of the devices, keeping the weakest
+// ones up to the given limit.
+func SummarizeFleetHealth(devices []Device, weakestLimit int, now time.Time) FleetHealth {
+	summary := FleetHealth{
+		Devices:  len(devices),
+		ByStatus: make(map[DeviceHealthStatus]int),
+	}
+
+	scoreSum, scored := 0, 0
+	signalSum, signals := 0, 0
+	var weakest []Device
+	for _, device := range devices {
+		health := device.Health
+		status := health.Status
+		if status == "" {
+			status = DeviceHealthStatusUnknown
+		}
+		summary.ByStatus[status]++
+
+		if device.ConnectivityAt(now) == ConnectivityStateOnline {
+			summary.Online++
+		}
+		if health.LowBattery {
+			summary.LowBattery++
+		}
+		if health.SignalQuality != nil {
+			signalSum += *health.SignalQuality
+			signals++
+		}
+		if health.Score != nil {
+			scoreSum += *health.Score
+			scored++
+			weakest = append(weakest, device)
+		}
+	}
+
+	if scored > 0 {
+		average := int(math.Round(float64(scoreSum) / float64(scored)))
+		summary.AverageScore = &average
+	}
+	if signals > 0 {
+		average := int(math.Round(float64(signalSum) / float64(signals)))
+		summary.AverageSignal = &average
+	}
+
+	slices.SortStableFunc(weakest, func(a, b Device) int {
+		return *a.Health.Score - *b.Health.Score
+	})
+	if len(weakest) > weakestLimit {
+		weakest = weakest[:weakestLimit]
+	}
+	summary.Weakest = weakest
+
+	return summary
+}
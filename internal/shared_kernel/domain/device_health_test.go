@@ -0,0 +1,133 @@
+package domain_test
+
+import (
+	"time"
+	"zensor-server/internal/infra/utils"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("EvaluateDeviceHealth", func() {
+	var (
+		now    time.Time
+		policy domain.DeviceHealthPolicy
+	)
+
+	ginkgo.BeforeEach(func() {
+		now = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+		policy = domain.DeviceHealthPolicy{BatteryEmptyVoltage: 3.0, BatteryFullVoltage: 4.2, LowBatteryPercent: 20}
+	})
+
+	battery := func(voltage float64) *domain.BatteryReading {
+		return &domain.BatteryReading{Voltage: voltage, ReportedAt: utils.Time{Time: now}}
+	}
+
+	ginkgo.When("there is nothing to score the device on", func() {
+		ginkgo.It("should leave the health unknown", func() {
+			health := domain.EvaluateDeviceHealth(domain.DeviceHealth{}, domain.DeviceHealthInputs{}, policy, now)
+
+			gomega.Expect(health.Status).To(gomega.Equal(domain.DeviceHealthStatusUnknown))
+			gomega.Expect(health.Score).To(gomega.BeNil())
+			gomega.Expect(health.EvaluatedAt.Time).To(gomega.Equal(now))
+		})
+	})
+
+	ginkgo.When("every component is known", func() {
+		ginkgo.It("should weigh them into the score", func() {
+			health := domain.EvaluateDeviceHealth(domain.DeviceHealth{}, domain.DeviceHealthInputs{
+				Battery:         battery(4.2),
+				LinkQuality:     domain.LinkQualitySummary{Samples: 10, AverageRSSI: -95, AverageSNR: -5},
+				UplinksReceived: 9,
+				UplinksExpected: 10,
+				Commands:        domain.CommandOutcomes{Succeeded: 3, Failed: 1},
+			}, policy, now)
+
+			gomega.Expect(*health.BatteryPercent).To(gomega.Equal(100))
+			gomega.Expect(*health.SignalQuality).To(gomega.Equal(50))
+			gomega.Expect(*health.UplinkRegularity).To(gomega.Equal(90))
+			gomega.Expect(*health.CommandFailureRate).To(gomega.Equal(25))
+			// (100*30 + 50*25 + 90*30 + 75*15) / 100
+			gomega.Expect(*health.Score).To(gomega.Equal(81))
+			gomega.Expect(health.Status).To(gomega.Equal(domain.DeviceHealthStatusHealthy))
+			gomega.Expect(health.LowBattery).To(gomega.BeFalse())
+		})
+	})
+
+	ginkgo.When("only some components are known", func() {
+		ginkgo.It("should score the device on those alone", func() {
+			health := domain.EvaluateDeviceHealth(domain.DeviceHealth{}, domain.DeviceHealthInputs{
+				UplinksReceived: 4,
+				UplinksExpected: 10,
+			}, policy, now)
+
+			gomega.Expect(health.BatteryPercent).To(gomega.BeNil())
+			gomega.Expect(*health.Score).To(gomega.Equal(40))
+			gomega.Expect(health.Status).To(gomega.Equal(domain.DeviceHealthStatusCritical))
+		})
+	})
+
+	ginkgo.When("the battery drains", func() {
+		ginkgo.It("should flag it low below the threshold and clear it once recovered past the hysteresis", func() {
+			low := domain.EvaluateDeviceHealth(domain.DeviceHealth{}, domain.DeviceHealthInputs{Battery: battery(3.2)}, policy, now)
+			gomega.Expect(*low.BatteryPercent).To(gomega.Equal(17))
+			gomega.Expect(low.LowBattery).To(gomega.BeTrue())
+
+			stillLow := domain.EvaluateDeviceHealth(low, domain.DeviceHealthInputs{Battery: battery(3.26)}, policy, now)
+			gomega.Expect(*stillLow.BatteryPercent).To(gomega.Equal(22))
+			gomega.Expect(stillLow.LowBattery).To(gomega.BeTrue())
+
+			recovered := domain.EvaluateDeviceHealth(stillLow, domain.DeviceHealthInputs{Battery: battery(3.4)}, policy, now)
+			gomega.Expect(recovered.LowBattery).To(gomega.BeFalse())
+		})
+
+		ginkgo.It("should not flag it when the tenant disabled low battery alerts", func() {
+			disabled := 0
+			health := domain.EvaluateDeviceHealth(domain.DeviceHealth{}, domain.DeviceHealthInputs{Battery: battery(3.0)},
+				policy.WithLowBatteryPercent(&disabled), now)
+
+			gomega.Expect(*health.BatteryPercent).To(gomega.BeZero())
+			gomega.Expect(health.LowBattery).To(gomega.BeFalse())
+		})
+	})
+})
+
+var _ = ginkgo.Describe("DeviceHealthPolicy", func() {
+	ginkgo.It("should reject inverted voltages and out of range thresholds", func() {
+		gomega.Expect(domain.DeviceHealthPolicy{BatteryEmptyVoltage: 4.2, BatteryFullVoltage: 3.0}.Validate()).
+			To(gomega.MatchError(domain.ErrInvalidBatteryVoltageRange))
+		gomega.Expect(domain.DeviceHealthPolicy{BatteryEmptyVoltage: 3.0, BatteryFullVoltage: 4.2, LowBatteryPercent: 120}.Validate()).
+			To(gomega.MatchError(domain.ErrInvalidLowBatteryThreshold))
+	})
+})
+
+var _ = ginkgo.Describe("SummarizeFleetHealth", func() {
+	ginkgo.It("should count devices by status and keep the weakest first", func() {
+		now := time.Now()
+		score := func(value int) *int { return &value }
+		devices := []domain.Device{
+			{ID: "a", Health: domain.DeviceHealth{Status: domain.DeviceHealthStatusHealthy, Score: score(90), SignalQuality: score(80)}},
+			{ID: "b", Health: domain.DeviceHealth{Status: domain.DeviceHealthStatusCritical, Score: score(30), LowBattery: true}},
+			{ID: "c", Health: domain.DeviceHealth{Status: domain.DeviceHealthStatusDegraded, Score: score(60), SignalQuality: score(40)}},
+			{ID: "d", LastMessageReceivedAt: utils.Time{Time: now}},
+		}
+
+		summary := domain.SummarizeFleetHealth(devices, 2, now)
+
+		gomega.Expect(summary.Devices).To(gomega.Equal(4))
+		gomega.Expect(summary.Online).To(gomega.Equal(1))
+		gomega.Expect(summary.LowBattery).To(gomega.Equal(1))
+		gomega.Expect(summary.ByStatus).To(gomega.Equal(map[domain.DeviceHealthStatus]int{
+			domain.DeviceHealthStatusHealthy:  1,
+			domain.DeviceHealthStatusDegraded: 1,
+			domain.DeviceHealthStatusCritical: 1,
+			domain.DeviceHealthStatusUnknown:  1,
+		}))
+		gomega.Expect(*summary.AverageScore).To(gomega.Equal(60))
+		gomega.Expect(*summary.AverageSignal).To(gomega.Equal(60))
+		gomega.Expect(summary.Weakest).To(gomega.HaveLen(2))
+		gomega.Expect(summary.Weakest[0].ID).To(gomega.Equal(domain.ID("b")))
+		gomega.Expect(summary.Weakest[1].ID).To(gomega.Equal(domain.ID("c")))
+	})
+})
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"time"
+	"zensor-server/internal/infra/utils"
+)
+
+// DeviceKeyAccess records an administrator revealing the root keys of a device.
+type DeviceKeyAccess struct {
+	ID         ID
+	DeviceID   ID
+	UserID     ID
+	UserEmail  string
+	AccessedAt utils.Time
+}
+
+// NewDeviceKeyAccess records that the user revealed the keys of the device at the given instant.
+func NewDeviceKeyAccess(deviceID, userID ID, userEmail string, at time.Time) DeviceKeyAccess {
+	return DeviceKeyAccess{
+		ID:         ID(utils.GenerateUUID()),
+		DeviceID:   deviceID,
+		UserID:     userID,
+		UserEmail:  userEmail,
+		AccessedAt: utils.Time{Time: at},
+	}
+}
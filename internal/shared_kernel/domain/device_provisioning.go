@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"time"
+	"zensor-server/internal/infra/utils"
+)
+
+// ProvisioningStatus tracks the registration of a device in the LoRaWAN network server. Devices
+// without a status were registered by hand and are left alone.
+type ProvisioningStatus string
+
+const (
+	ProvisioningStatusPending              ProvisioningStatus = "pending"               // Waiting for the first attempt
+	ProvisioningStatusProvisioned          ProvisioningStatus = "provisioned"           // Registered in the network server
+	ProvisioningStatusFailed               ProvisioningStatus = "failed"                // Registration failed, it is retried
+	ProvisioningStatusDeprovisioned        ProvisioningStatus = "deprovisioned"         // Removed after the device was decommissioned
+	ProvisioningStatusDeprovisioningFailed ProvisioningStatus = "deprovisioning_failed" // Removal failed, it is retried
+)
+
+const (
+	provisioningRetryBaseDelay = 30 * time.Second
+	provisioningRetryMaxDelay  = time.Hour
+)
+
+// DeviceProvisioning is the outcome of the last attempt to sync the device with the network server.
+type DeviceProvisioning struct {
+	Status        ProvisioningStatus
+	Attempts      int // Consecutive failed attempts
+	LastError     string
+	NextAttemptAt *utils.Time
+	UpdatedAt     *utils.Time
+}
+
+// IsManaged tells whether the network server registration of the device is owned by Zensor.
+func (p DeviceProvisioning) IsManaged() bool {
+	return p.Status != ""
+}
+
+// IsDue tells whether a pending or failed sync should be attempted at the given instant.
+func (p DeviceProvisioning) IsDue(now time.Time) bool {
+	switch p.Status {
+	case ProvisioningStatusPending, ProvisioningStatusFailed, ProvisioningStatusDeprovisioningFailed:
+		return p.NextAttemptAt == nil || !now.Before(p.NextAttemptAt.Time)
+	default:
+		return false
+	}
+}
+
+// RequestProvisioning marks the device to be synced with the network server: registered while the
+// device is active, removed once it is decommissioned.
+func (d *Device) RequestProvisioning(at time.Time) {
+	attemptAt := utils.Time{Time: at}
+	d.Provisioning = DeviceProvisioning{
+		Status:        ProvisioningStatusPending,
+		NextAttemptAt: &attemptAt,
+		UpdatedAt:     &attemptAt,
+	}
+}
+
+// RecordProvisioningSuccess records that the network server matches the device: registered while
+// the device is active, removed once it is decommissioned.
+func (d *Device) RecordProvisioningSuccess(at time.Time) {
+	updatedAt := utils.Time{Time: at}
+	status := ProvisioningStatusProvisioned
+	if d.IsDeleted() {
+		status = ProvisioningStatusDeprovisioned
+	}
+
+	d.Provisioning = DeviceProvisioning{
+		Status:    status,
+		UpdatedAt: &updatedAt,
+	}
+}
+
+// RecordProvisioningFailure records a failed sync and schedules the next attempt with an
+// exponential backoff capped at one hour.
+func (d *Device) RecordProvisioningFailure(cause error, at time.Time) {
+	status := ProvisioningStatusFailed
+	if d.IsDeleted() {
+		status = ProvisioningStatusDeprovisioningFailed
+	}
+
+	attempts := 1
+	if d.Provisioning.Status == status {
+		attempts = d.Provisioning.Attempts + 1
+	}
+
+	delay := provisioningRetryMaxDelay
+	if attempts <= 7 {
+		delay = min(provisioningRetryBaseDelay<<(attempts-1), provisioningRetryMaxDelay)
+	}
+
+	updatedAt := utils.Time{Time: at}
+	nextAttemptAt := utils.Time{Time: at.Add(delay)}
+	d.Provisioning = DeviceProvisioning{
+		Status:        status,
+		Attempts:      attempts,
+		LastError:     cause.Error(),
+		NextAttemptAt: &nextAttemptAt,
+		UpdatedAt:     &updatedAt,
+	}
+}
@@ -0,0 +1,84 @@
+package domain_test
+
+import (
+	"errors"
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("DeviceProvisioning", func() {
+	var (
+		now    time.Time
+		device domain.Device
+		cause  error
+	)
+
+	ginkgo.BeforeEach(func() {
+		now = time.Now()
+		device = domain.Device{ID: domain.ID("device-1"), Name: "device-1"}
+		cause = errors.New("registry unavailable")
+	})
+
+	ginkgo.When("the device was registered by hand", func() {
+		ginkgo.It("should not be managed nor due", func() {
+			gomega.Expect(device.Provisioning.IsManaged()).To(gomega.BeFalse())
+			gomega.Expect(device.Provisioning.IsDue(now)).To(gomega.BeFalse())
+		})
+	})
+
+	ginkgo.When("provisioning is requested", func() {
+		ginkgo.It("should be due right away", func() {
+			device.RequestProvisioning(now)
+
+			gomega.Expect(device.Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusPending))
+			gomega.Expect(device.Provisioning.IsDue(now)).To(gomega.BeTrue())
+		})
+	})
+
+	ginkgo.When("provisioning keeps failing", func() {
+		ginkgo.It("should back off exponentially up to one hour", func() {
+			device.RequestProvisioning(now)
+
+			var delays []time.Duration
+			for range 9 {
+				device.RecordProvisioningFailure(cause, now)
+				delays = append(delays, device.Provisioning.NextAttemptAt.Sub(now))
+			}
+
+			gomega.Expect(device.Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusFailed))
+			gomega.Expect(device.Provisioning.Attempts).To(gomega.Equal(9))
+			gomega.Expect(device.Provisioning.LastError).To(gomega.Equal(cause.Error()))
+			gomega.Expect(delays).To(gomega.Equal([]time.Duration{
+				30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute,
+				16 * time.Minute, 32 * time.Minute, time.Hour, time.Hour,
+			}))
+			gomega.Expect(device.Provisioning.IsDue(now)).To(gomega.BeFalse())
+			gomega.Expect(device.Provisioning.IsDue(now.Add(time.Hour))).To(gomega.BeTrue())
+		})
+	})
+
+	ginkgo.When("a decommissioned device is synced", func() {
+		ginkgo.BeforeEach(func() {
+			device.RecordProvisioningFailure(cause, now)
+			device.RecordProvisioningSuccess(now)
+			device.Decommission()
+		})
+
+		ginkgo.It("should restart the attempt count on a failed removal", func() {
+			device.RecordProvisioningFailure(cause, now)
+
+			gomega.Expect(device.Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusDeprovisioningFailed))
+			gomega.Expect(device.Provisioning.Attempts).To(gomega.Equal(1))
+		})
+
+		ginkgo.It("should end deprovisioned", func() {
+			device.RecordProvisioningSuccess(now)
+
+			gomega.Expect(device.Provisioning.Status).To(gomega.Equal(domain.ProvisioningStatusDeprovisioned))
+			gomega.Expect(device.Provisioning.IsDue(now)).To(gomega.BeFalse())
+		})
+	})
+})
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"slices"
+	"time"
+	"zensor-server/internal/infra/utils"
+)
+
+// DeviceSession holds the LoRaWAN session of a device as established by its last join.
+type DeviceSession struct {
+	DeviceID       ID
+	DevAddr        string
+	JoinedAt       *utils.Time
+	FrameCounterUp uint32
+	LastUplinkAt   *utils.Time
+}
+
+// Join starts a new session: the network assigned a new DevAddr and frame counters restart.
+func (s *DeviceSession) Join(devAddr string, at time.Time) {
+	joinedAt := utils.Time{Time: at}
+	s.DevAddr = devAddr
+	s.JoinedAt = &joinedAt
+	s.FrameCounterUp = 0
+}
+
+// RecordUplink advances the session with an uplink frame. A DevAddr change means the device
+// rejoined without the join being observed.
+func (s *DeviceSession) RecordUplink(devAddr string, frameCounter uint32, at time.Time) {
+	if devAddr != "" && s.DevAddr != devAddr {
+		s.DevAddr = devAddr
+	}
+
+	receivedAt := utils.Time{Time: at}
+	s.FrameCounterUp = frameCounter
+	s.LastUplinkAt = &receivedAt
+}
+
+// GatewayReception is how one gateway heard an uplink.
+type GatewayReception struct {
+	GatewayID string
+	RSSI      float64
+	SNR       float64
+}
+
+// LinkQualitySample is the radio metadata of a single uplink.
+type LinkQualitySample struct {
+	ID              ID
+	DeviceID        ID
+	DevAddr         string
+	FrameCounter    uint32
+	SpreadingFactor int
+	Bandwidth       int
+	FrequencyHz     int64
+	Gateways        []GatewayReception
+	ReceivedAt      utils.Time
+}
+
+// BestReception returns the gateway that heard the uplink with the strongest signal.
+func (s LinkQualitySample) BestReception() (GatewayReception, bool) {
+	if len(s.Gateways) == 0 {
+		return GatewayReception{}, false
+	}
+
+	best := s.Gateways[0]
+	for _, gateway := range s.Gateways[1:] {
+		if gateway.RSSI > best.RSSI {
+			best = gateway
+		}
+	}
+
+	return best, true
+}
+
+// LinkQualitySummary aggregates the best reception of a set of uplinks.
+type LinkQualitySummary struct {
+	Samples          int
+	AverageRSSI      float64
+	AverageSNR       float64
+	MinRSSI          float64
+	MaxRSSI          float64
+	AverageGateways  float64
+	SpreadingFactors map[int]int
+	GatewayIDs       []string
+}
+
+func SummarizeLinkQuality(samples []LinkQualitySample) LinkQualitySummary {
+	summary := LinkQualitySummary{SpreadingFactors: make(map[int]int)}
+	gatewayIDs := make(map[string]struct{})
+	gatewayCount := 0
+
+	for _, sample := range samples {
+		if sample.SpreadingFactor > 0 {
+			summary.SpreadingFactors[sample.SpreadingFactor]++
+		}
+		for _, gateway := range sample.Gateways {
+			gatewayIDs[gateway.GatewayID] = struct{}{}
+		}
+
+		best, ok := sample.BestReception()
+		if !ok {
+			continue
+		}
+
+		if summary.Samples == 0 || best.RSSI < summary.MinRSSI {
+			summary.MinRSSI = best.RSSI
+		}
+		if summary.Samples == 0 || best.RSSI > summary.MaxRSSI {
+			summary.MaxRSSI = best.RSSI
+		}
+		summary.AverageRSSI += best.RSSI
+		summary.AverageSNR += best.SNR
+		gatewayCount += len(sample.Gateways)
+		summary.Samples++
+	}
+
+	if summary.Samples > 0 {
+		summary.AverageRSSI /= float64(summary.Samples)
+		summary.AverageSNR /= float64(summary.Samples)
+		summary.AverageGateways = float64(gatewayCount) / float64(summary.Samples)
+	}
+
+	for id := range gatewayIDs {
+		summary.GatewayIDs = append(summary.GatewayIDs, id)
+	}
+	slices.Sort(summary.GatewayIDs)
+
+	return summary
+}
@@ -0,0 +1,75 @@
+package domain_test
+
+import (
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("DeviceSession", func() {
+	ginkgo.Context("Join", func() {
+		ginkgo.It("should record the new DevAddr and reset the frame counter", func() {
+			session := domain.DeviceSession{DevAddr: "old", FrameCounterUp: 120}
+			now := time.Now()
+
+			session.Join("260B1234", now)
+
+			gomega.Expect(session.DevAddr).To(gomega.Equal("260B1234"))
+			gomega.Expect(session.FrameCounterUp).To(gomega.BeZero())
+			gomega.Expect(session.JoinedAt.Time).To(gomega.Equal(now))
+		})
+	})
+
+	ginkgo.Context("RecordUplink", func() {
+		ginkgo.It("should advance the frame counter and keep the DevAddr when none is reported", func() {
+			session := domain.DeviceSession{DevAddr: "260B1234"}
+
+			session.RecordUplink("", 7, time.Now())
+
+			gomega.Expect(session.DevAddr).To(gomega.Equal("260B1234"))
+			gomega.Expect(session.FrameCounterUp).To(gomega.Equal(uint32(7)))
+			gomega.Expect(session.LastUplinkAt).NotTo(gomega.BeNil())
+		})
+	})
+})
+
+var _ = ginkgo.Describe("SummarizeLinkQuality", func() {
+	ginkgo.When("there are no samples", func() {
+		ginkgo.It("should return an empty summary", func() {
+			summary := domain.SummarizeLinkQuality(nil)
+			gomega.Expect(summary.Samples).To(gomega.BeZero())
+			gomega.Expect(summary.GatewayIDs).To(gomega.BeEmpty())
+		})
+	})
+
+	ginkgo.When("uplinks were heard by several gateways", func() {
+		ginkgo.It("should aggregate the best reception of each uplink", func() {
+			samples := []domain.LinkQualitySample{
+				{
+					SpreadingFactor: 7,
+					Gateways: []domain.GatewayReception{
+						{GatewayID: "gw-2", RSSI: -110, SNR: -5},
+						{GatewayID: "gw-1", RSSI: -80, SNR: 8},
+					},
+				},
+				{
+					SpreadingFactor: 10,
+					Gateways:        []domain.GatewayReception{{GatewayID: "gw-1", RSSI: -100, SNR: 2}},
+				},
+			}
+
+			summary := domain.SummarizeLinkQuality(samples)
+
+			gomega.Expect(summary.Samples).To(gomega.Equal(2))
+			gomega.Expect(summary.AverageRSSI).To(gomega.Equal(-90.0))
+			gomega.Expect(summary.AverageSNR).To(gomega.Equal(5.0))
+			gomega.Expect(summary.MinRSSI).To(gomega.Equal(-100.0))
+			gomega.Expect(summary.MaxRSSI).To(gomega.Equal(-80.0))
+			gomega.Expect(summary.AverageGateways).To(gomega.Equal(1.5))
+			gomega.Expect(summary.SpreadingFactors).To(gomega.Equal(map[int]int{7: 1, 10: 1}))
+			gomega.Expect(summary.GatewayIDs).To(gomega.Equal([]string{"gw-1", "gw-2"}))
+		})
+	})
+})
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"regexp"
+	"strings"
+)
+
+const (
+	maxDeviceTags            = 32
+	maxDeviceTagValueLength  = 128
+	maxDeviceMetadataBytes   = 8 * 1024
+	deviceTagKeyValueDivider = ":"
+)
+
+var (
+	ErrInvalidDeviceTagKey    = errors.New("tag keys must be 1 to 64 lowercase letters, digits, '.', '-' or '_' starting with a letter or digit")
+	ErrDeviceTagValueTooLong  = errors.New("tag values must be at most 128 characters")
+	ErrTooManyDeviceTags      = errors.New("a device can have at most 32 tags")
+	ErrDeviceMetadataTooLarge = errors.New("device metadata must be at most 8 KiB once encoded")
+	ErrInvalidDeviceMetadata  = errors.New("device metadata must be encodable as JSON")
+)
+
+var deviceTagKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// NormalizeDeviceTagKey lowercases and trims a tag key so lookups match how tags are stored.
+func NormalizeDeviceTagKey(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
+
+// ParseDeviceTag splits a "key:value" tag selector. A selector without a value matches any value.
+func ParseDeviceTag(selector string) (key, value string, hasValue bool, err error) {
+	key, value, hasValue = strings.Cut(selector, deviceTagKeyValueDivider)
+	key = NormalizeDeviceTagKey(key)
+	if !deviceTagKeyPattern.MatchString(key) {
+		return "", "", false, ErrInvalidDeviceTagKey
+	}
+	return key, value, hasValue, nil
+}
+
+// UpdateTags replaces the tags of the device. Keys are lowercased; empty or nil tags clear them.
+func (d *Device) UpdateTags(tags map[string]string) error {
+	if len(tags) > maxDeviceTags {
+		return ErrTooManyDeviceTags
+	}
+
+	normalized := make(map[string]string, len(tags))
+	for key, value := range tags {
+		key = NormalizeDeviceTagKey(key)
+		if !deviceTagKeyPattern.MatchString(key) {
+			return ErrInvalidDeviceTagKey
+		}
+		if len([]rune(value)) > maxDeviceTagValueLength {
+			return ErrDeviceTagValueTooLong
+		}
+		normalized[key] = value
+	}
+
+	if len(normalized) == 0 {
+		normalized = nil
+	}
+	d.Tags = normalized
+	return nil
+}
+
+// HasTag tells whether the device carries the tag with the given value.
+func (d *Device) HasTag(key, value string) bool {
+	current, ok := d.Tags[NormalizeDeviceTagKey(key)]
+	return ok && current == value
+}
+
+// UpdateMetadata replaces the free-form metadata of the device; nil clears it.
+func (d *Device) UpdateMetadata(metadata map[string]any) error {
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return ErrInvalidDeviceMetadata
+	}
+	if len(encoded) > maxDeviceMetadataBytes {
+		return ErrDeviceMetadataTooLarge
+	}
+
+	if len(metadata) == 0 {
+		d.Metadata = nil
+		return nil
+	}
+	d.Metadata = maps.Clone(metadata)
+	return nil
+}
@@ -0,0 +1,88 @@
+package domain_test
+
+import (
+	"fmt"
+	"strings"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("DeviceTags", func() {
+	var device domain.Device
+
+	ginkgo.BeforeEach(func() {
+		device = domain.Device{ID: domain.ID("device-1"), Name: "device-1"}
+	})
+
+	ginkgo.When("tags are updated", func() {
+		ginkgo.It("should lowercase the keys", func() {
+			err := device.UpdateTags(map[string]string{" Zone ": "B", "kind": "valve"})
+
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(device.Tags).To(gomega.Equal(map[string]string{"zone": "B", "kind": "valve"}))
+			gomega.Expect(device.HasTag("ZONE", "B")).To(gomega.BeTrue())
+			gomega.Expect(device.HasTag("zone", "b")).To(gomega.BeFalse())
+		})
+
+		ginkgo.It("should clear them when empty", func() {
+			gomega.Expect(device.UpdateTags(map[string]string{"zone": "b"})).To(gomega.Succeed())
+			gomega.Expect(device.UpdateTags(map[string]string{})).To(gomega.Succeed())
+
+			gomega.Expect(device.Tags).To(gomega.BeNil())
+		})
+
+		ginkgo.It("should reject invalid keys", func() {
+			gomega.Expect(device.UpdateTags(map[string]string{"zone b": "x"})).To(gomega.MatchError(domain.ErrInvalidDeviceTagKey))
+			gomega.Expect(device.UpdateTags(map[string]string{"": "x"})).To(gomega.MatchError(domain.ErrInvalidDeviceTagKey))
+		})
+
+		ginkgo.It("should reject long values and too many tags", func() {
+			gomega.Expect(device.UpdateTags(map[string]string{"zone": strings.Repeat("b", 129)})).
+				To(gomega.MatchError(domain.ErrDeviceTagValueTooLong))
+
+			tags := make(map[string]string)
+			for i := range 33 {
+				tags[fmt.Sprintf("tag-%d", i)] = "x"
+			}
+			gomega.Expect(device.UpdateTags(tags)).To(gomega.MatchError(domain.ErrTooManyDeviceTags))
+		})
+	})
+
+	ginkgo.When("a tag selector is parsed", func() {
+		ginkgo.It("should split the key and the value", func() {
+			key, value, hasValue, err := domain.ParseDeviceTag("Zone:B:1")
+
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(key).To(gomega.Equal("zone"))
+			gomega.Expect(value).To(gomega.Equal("B:1"))
+			gomega.Expect(hasValue).To(gomega.BeTrue())
+		})
+
+		ginkgo.It("should accept a key alone", func() {
+			key, _, hasValue, err := domain.ParseDeviceTag("zone")
+
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(key).To(gomega.Equal("zone"))
+			gomega.Expect(hasValue).To(gomega.BeFalse())
+		})
+	})
+
+	ginkgo.When("metadata is updated", func() {
+		ginkgo.It("should keep a copy", func() {
+			metadata := map[string]any{"install": map[string]any{"depth_cm": 30}}
+			gomega.Expect(device.UpdateMetadata(metadata)).To(gomega.Succeed())
+
+			metadata["other"] = true
+			gomega.Expect(device.Metadata).To(gomega.HaveLen(1))
+		})
+
+		ginkgo.It("should reject large or unencodable metadata", func() {
+			gomega.Expect(device.UpdateMetadata(map[string]any{"notes": strings.Repeat("x", 9000)})).
+				To(gomega.MatchError(domain.ErrDeviceMetadataTooLarge))
+			gomega.Expect(device.UpdateMetadata(map[string]any{"fn": func() {}})).
+				To(gomega.MatchError(domain.ErrInvalidDeviceMetadata))
+		})
+	})
+})
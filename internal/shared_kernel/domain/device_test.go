@@ -0,0 +1,28 @@
+package domain_test
+
+import (
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("DeviceBuilder", func() {
+	ginkgo.When("the name is a single topic level", func() {
+		ginkgo.It("should build the device", func() {
+			device, err := domain.NewDeviceBuilder().WithName("valve-1").Build()
+
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(device.Name).To(gomega.Equal("valve-1"))
+		})
+	})
+
+	ginkgo.When("the name contains a topic separator or wildcard", func() {
+		ginkgo.It("should reject it", func() {
+			for _, name := range []string{"field/valve-1", "valve+1", "valve#1"} {
+				_, err := domain.NewDeviceBuilder().WithName(name).Build()
+				gomega.Expect(err).To(gomega.MatchError(domain.ErrInvalidDeviceName), name)
+			}
+		})
+	})
+})
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"zensor-server/internal/infra/utils"
+)
+
+// OutboxEvent is a broker message stored alongside the state change that produced it, so it is
+// published at least once even if the process stops before delivering it. It is redelivered
+// until a consumer acknowledges it or it runs out of attempts. TenantID is set on the relayed
+// envelope when the event belongs to a tenant.
+type OutboxEvent struct {
+	ID          ID
+	TenantID    ID
+	Topic       string
+	Event       string
+	Payload     []byte
+	Attempts    int
+	CreatedAt   utils.Time
+	PublishedAt *utils.Time
+	AckedAt     *utils.Time
+}
+
+func NewOutboxEvent(topic, event string, value any, now time.Time) (OutboxEvent, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return OutboxEvent{}, fmt.Errorf("encoding %s event payload: %w", event, err)
+	}
+
+	return OutboxEvent{
+		ID:        ID(utils.GenerateUUID()),
+		Topic:     topic,
+		Event:     event,
+		Payload:   payload,
+		CreatedAt: utils.Time{Time: now},
+	}, nil
+}
+
+// Decode unmarshals the payload into target.
+func (e OutboxEvent) Decode(target any) error {
+	if err := json.Unmarshal(e.Payload, target); err != nil {
+		return fmt.Errorf("decoding %s event payload: %w", e.Event, err)
+	}
+	return nil
+}
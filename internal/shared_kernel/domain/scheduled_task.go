@@ -12,26 +12,36 @@ var (
 	errTenantRequired                             = errors.New("tenant is required")
 	errDeviceRequired                             = errors.New("device is required")
 	errCommandTemplatesRequired                   = errors.New("command templates are required")
+	errCommandTemplatesAndSetMutuallyExclusive    = errors.New("command templates and command template set are mutually exclusive")
 	errScheduleOrSchedulingConfigRequired         = errors.New("either schedule or scheduling configuration is required")
 	errInitialDayRequiredForIntervalScheduling    = errors.New("initial_day is required for interval scheduling")
 	errDayIntervalMustBeGreaterThanZero           = errors.New("day_interval must be greater than 0 for interval scheduling")
 	errExecutionTimeRequiredForIntervalScheduling = errors.New("execution_time is required for interval scheduling")
 	errInitialDayWithExecutionTimeMustBeInFuture  = errors.New("initial_day with execution_time must be in the future")
+
+	ErrPauseUntilMustBeInFuture = errors.New("pause until must be in the future")
 )
 
+// ScheduledTask creates one task per target device each time its schedule fires. Device owns the
+// scheduled task and Devices lists additional targets. When CommandTemplateSet is set, its
+// templates are used instead of CommandTemplates; only the set ID is loaded with the task.
 type ScheduledTask struct {
-	ID               ID
-	Version          Version
-	Tenant           Tenant
-	Device           Device
-	CommandTemplates []CommandTemplate
-	Schedule         string
-	Scheduling       SchedulingConfiguration
-	IsActive         bool
-	CreatedAt        utils.Time
-	UpdatedAt        utils.Time
-	LastExecutedAt   *utils.Time
-	DeletedAt        *utils.Time
+	ID                 ID
+	Version            Version
+	Tenant             Tenant
+	Device             Device
+	Devices            []Device
+	CommandTemplates   []CommandTemplate
+	CommandTemplateSet *CommandTemplateSet
+	Schedule           string
+	Scheduling         SchedulingConfiguration
+	IsActive           bool
+	CreatedAt          utils.Time
+	UpdatedAt          utils.Time
+	LastExecutedAt     *utils.Time
+	PausedAt           *utils.Time
+	PausedUntil        *utils.Time
+	DeletedAt          *utils.Time
 }
 
 type SchedulingConfiguration struct {
@@ -48,6 +58,31 @@ const (
 	SchedulingTypeInterval SchedulingType = "interval"
 )
 
+// TargetDevices returns the owning device followed by every additional device, without duplicates.
+func (st *ScheduledTask) TargetDevices() []Device {
+	targets := []Device{st.Device}
+	seen := map[ID]bool{st.Device.ID: true}
+	for _, device := range st.Devices {
+		if seen[device.ID] {
+			continue
+		}
+		seen[device.ID] = true
+		targets = append(targets, device)
+	}
+
+	return targets
+}
+
+func (st *ScheduledTask) TargetsDevice(id ID) bool {
+	for _, device := range st.TargetDevices() {
+		if device.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (st *ScheduledTask) IsDeleted() bool {
 	return st.DeletedAt != nil
 }
@@ -59,6 +94,44 @@ func (st *ScheduledTask) SoftDelete() {
 	st.UpdatedAt = now
 }
 
+// Pause stops the scheduled task from firing until the given instant. A nil until pauses the
+// task until Resume is called.
+func (st *ScheduledTask) Pause(until *utils.Time) error {
+	now := utils.Time{Time: time.Now()}
+	if until != nil && !until.After(now.Time) {
+		return ErrPauseUntilMustBeInFuture
+	}
+
+	st.PausedAt = &now
+	st.PausedUntil = until
+	st.UpdatedAt = now
+	return nil
+}
+
+func (st *ScheduledTask) Resume() {
+	st.PausedAt = nil
+	st.PausedUntil = nil
+	st.UpdatedAt = utils.Time{Time: time.Now()}
+}
+
+// IsPaused reports whether the task is paused at the given instant. Pauses with an end date
+// lift by themselves once that date is reached.
+func (st *ScheduledTask) IsPaused(now time.Time) bool {
+	if st.PausedAt == nil {
+		return false
+	}
+
+	return st.PausedUntil == nil || now.Before(st.PausedUntil.Time)
+}
+
+// SkipExecution consumes a due execution without running it, so that runs missed while paused
+// or held are not caught up once the schedule resumes.
+func (st *ScheduledTask) SkipExecution(now time.Time) {
+	skippedAt := utils.Time{Time: now}
+	st.LastExecutedAt = &skippedAt
+	st.UpdatedAt = skippedAt
+}
+
 func (st *ScheduledTask) CalculateNextExecution(tenantTimezone string) (time.Time, error) {
 	if st.Scheduling.Type != SchedulingTypeInterval {
 		return time.Time{}, errCalculateNextExecutionIntervalOnly
@@ -169,6 +242,22 @@ func (b *scheduledTaskBuilder) WithDevice(value Device) *scheduledTaskBuilder {
 	return b
 }
 
+func (b *scheduledTaskBuilder) WithDevices(value []Device) *scheduledTaskBuilder {
+	b.actions = append(b.actions, func(d *ScheduledTask) error {
+		d.Devices = value
+		return nil
+	})
+	return b
+}
+
+func (b *scheduledTaskBuilder) WithCommandTemplateSet(value CommandTemplateSet) *scheduledTaskBuilder {
+	b.actions = append(b.actions, func(d *ScheduledTask) error {
+		d.CommandTemplateSet = &value
+		return nil
+	})
+	return b
+}
+
 func (b *scheduledTaskBuilder) WithCommandTemplates(value []CommandTemplate) *scheduledTaskBuilder {
 	b.actions = append(b.actions, func(d *ScheduledTask) error {
 		d.CommandTemplates = value
@@ -234,7 +323,11 @@ func (b *scheduledTaskBuilder) Build() (ScheduledTask, error) {
 		return ScheduledTask{}, errDeviceRequired
 	}
 
-	if len(result.CommandTemplates) == 0 {
+	if result.CommandTemplateSet != nil && len(result.CommandTemplates) > 0 {
+		return ScheduledTask{}, errCommandTemplatesAndSetMutuallyExclusive
+	}
+
+	if result.CommandTemplateSet == nil && len(result.CommandTemplates) == 0 {
 		return ScheduledTask{}, errCommandTemplatesRequired
 	}
 
@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"errors"
+	"time"
+	"zensor-server/internal/infra/utils"
+)
+
+var (
+	errScheduledTaskRunScheduledTaskRequired = errors.New("scheduled task is required")
+	errScheduledTaskRunOutcomeRequired       = errors.New("outcome is required")
+)
+
+// ScheduledTaskRunOutcome represents what happened when a due scheduled task was evaluated.
+type ScheduledTaskRunOutcome string
+
+const (
+	ScheduledTaskRunOutcomeFired           ScheduledTaskRunOutcome = "fired"            // A task was created
+	ScheduledTaskRunOutcomeSkipped         ScheduledTaskRunOutcome = "skipped"          // The task was paused or the tenant held
+	ScheduledTaskRunOutcomeFailed          ScheduledTaskRunOutcome = "failed"           // The task could not be created
+	ScheduledTaskRunOutcomeOverlapRejected ScheduledTaskRunOutcome = "overlap_rejected" // Pending commands overlapped the new ones
+)
+
+// CommandOutcome aggregates the status of every command of the task created by a run.
+type CommandOutcome string
+
+const (
+	CommandOutcomeNone      CommandOutcome = "none"      // The run did not create any command
+	CommandOutcomePending   CommandOutcome = "pending"   // At least one command has not completed yet
+	CommandOutcomeSucceeded CommandOutcome = "succeeded" // Every command was acknowledged
+	CommandOutcomeFailed    CommandOutcome = "failed"    // Every command failed
+	CommandOutcomePartial   CommandOutcome = "partial"   // Some commands were acknowledged and some failed
+)
+
+type ScheduledTaskRun struct {
+	ID              ID
+	ScheduledTaskID ID
+	TenantID        ID
+	DeviceID        ID
+	Outcome         ScheduledTaskRunOutcome
+	TaskID          *ID
+	Error           string
+	CommandOutcome  CommandOutcome
+	EvaluatedAt     utils.Time
+}
+
+// AggregateCommandOutcome derives CommandOutcome from the commands of the task created by the run.
+func (r *ScheduledTaskRun) AggregateCommandOutcome(commands []Command) {
+	if r.TaskID == nil || len(commands) == 0 {
+		r.CommandOutcome = CommandOutcomeNone
+		return
+	}
+
+	var succeeded, failed int
+	for _, command := range commands {
+		switch {
+		case command.IsSuccessful():
+			succeeded++
+		case command.IsFailed(), command.IsCancelled():
+			failed++
+		default:
+			r.CommandOutcome = CommandOutcomePending
+			return
+		}
+	}
+
+	switch {
+	case failed == 0:
+		r.CommandOutcome = CommandOutcomeSucceeded
+	case succeeded == 0:
+		r.CommandOutcome = CommandOutcomeFailed
+	default:
+		r.CommandOutcome = CommandOutcomePartial
+	}
+}
+
+// IsFailure reports whether the run did not deliver its commands, either because no task was
+// created or because any of its commands failed. Skipped runs are not failures.
+func (r ScheduledTaskRun) IsFailure() bool {
+	switch r.Outcome {
+	case ScheduledTaskRunOutcomeFailed, ScheduledTaskRunOutcomeOverlapRejected:
+		return true
+	}
+
+	return r.CommandOutcome == CommandOutcomeFailed || r.CommandOutcome == CommandOutcomePartial
+}
+
+func NewScheduledTaskRunBuilder() *scheduledTaskRunBuilder {
+	return &scheduledTaskRunBuilder{}
+}
+
+type scheduledTaskRunBuilder struct {
+	actions []scheduledTaskRunHandler
+}
+
+type scheduledTaskRunHandler func(v *ScheduledTaskRun) error
+
+func (b *scheduledTaskRunBuilder) WithScheduledTask(value ScheduledTask) *scheduledTaskRunBuilder {
+	b.actions = append(b.actions, func(r *ScheduledTaskRun) error {
+		r.ScheduledTaskID = value.ID
+		r.TenantID = value.Tenant.ID
+		r.DeviceID = value.Device.ID
+		return nil
+	})
+	return b
+}
+
+// WithDevice overrides the owning device of the scheduled task for runs of additional targets.
+func (b *scheduledTaskRunBuilder) WithDevice(value Device) *scheduledTaskRunBuilder {
+	b.actions = append(b.actions, func(r *ScheduledTaskRun) error {
+		r.DeviceID = value.ID
+		return nil
+	})
+	return b
+}
+
+func (b *scheduledTaskRunBuilder) WithOutcome(value ScheduledTaskRunOutcome) *scheduledTaskRunBuilder {
+	b.actions = append(b.actions, func(r *ScheduledTaskRun) error {
+		r.Outcome = value
+		return nil
+	})
+	return b
+}
+
+func (b *scheduledTaskRunBuilder) WithTaskID(value ID) *scheduledTaskRunBuilder {
+	b.actions = append(b.actions, func(r *ScheduledTaskRun) error {
+		r.TaskID = &value
+		return nil
+	})
+	return b
+}
+
+func (b *scheduledTaskRunBuilder) WithError(value error) *scheduledTaskRunBuilder {
+	b.actions = append(b.actions, func(r *ScheduledTaskRun) error {
+		if value != nil {
+			r.Error = value.Error()
+		}
+		return nil
+	})
+	return b
+}
+
+func (b *scheduledTaskRunBuilder) WithEvaluatedAt(value time.Time) *scheduledTaskRunBuilder {
+	b.actions = append(b.actions, func(r *ScheduledTaskRun) error {
+		r.EvaluatedAt = utils.Time{Time: value}
+		return nil
+	})
+	return b
+}
+
+func (b *scheduledTaskRunBuilder) Build() (ScheduledTaskRun, error) {
+	result := ScheduledTaskRun{
+		ID:             ID(utils.GenerateUUID()),
+		CommandOutcome: CommandOutcomeNone,
+		EvaluatedAt:    utils.Time{Time: time.Now()},
+	}
+
+	for _, a := range b.actions {
+		if err := a(&result); err != nil {
+			return ScheduledTaskRun{}, err
+		}
+	}
+
+	if result.ScheduledTaskID == "" {
+		return ScheduledTaskRun{}, errScheduledTaskRunScheduledTaskRequired
+	}
+
+	if result.Outcome == "" {
+		return ScheduledTaskRun{}, errScheduledTaskRunOutcomeRequired
+	}
+
+	return result, nil
+}
@@ -0,0 +1,110 @@
+package domain_test
+
+import (
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("ScheduledTaskRun", func() {
+	var scheduledTask domain.ScheduledTask
+
+	ginkgo.BeforeEach(func() {
+		scheduledTask = domain.ScheduledTask{
+			ID:     domain.ID("scheduled-task-1"),
+			Tenant: domain.Tenant{ID: domain.ID("tenant-1")},
+			Device: domain.Device{ID: domain.ID("device-1")},
+		}
+	})
+
+	ginkgo.Context("Build", func() {
+		ginkgo.When("the outcome is missing", func() {
+			ginkgo.It("should fail", func() {
+				_, err := domain.NewScheduledTaskRunBuilder().WithScheduledTask(scheduledTask).Build()
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+
+		ginkgo.When("the task could not be created", func() {
+			ginkgo.It("should count as a failure", func() {
+				run, err := domain.NewScheduledTaskRunBuilder().
+					WithScheduledTask(scheduledTask).
+					WithOutcome(domain.ScheduledTaskRunOutcomeOverlapRejected).
+					Build()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(run.TenantID).To(gomega.Equal(domain.ID("tenant-1")))
+				gomega.Expect(run.CommandOutcome).To(gomega.Equal(domain.CommandOutcomeNone))
+				gomega.Expect(run.IsFailure()).To(gomega.BeTrue())
+			})
+		})
+	})
+
+	ginkgo.Context("AggregateCommandOutcome", func() {
+		var run domain.ScheduledTaskRun
+
+		ginkgo.BeforeEach(func() {
+			var err error
+			run, err = domain.NewScheduledTaskRunBuilder().
+				WithScheduledTask(scheduledTask).
+				WithOutcome(domain.ScheduledTaskRunOutcomeFired).
+				WithTaskID(domain.ID("task-1")).
+				Build()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.When("a command is still in flight", func() {
+			ginkgo.It("should be pending", func() {
+				run.AggregateCommandOutcome([]domain.Command{
+					{Status: domain.CommandStatusAck},
+					{Status: domain.CommandStatusSent},
+				})
+				gomega.Expect(run.CommandOutcome).To(gomega.Equal(domain.CommandOutcomePending))
+				gomega.Expect(run.IsFailure()).To(gomega.BeFalse())
+			})
+		})
+
+		ginkgo.When("every command was acknowledged", func() {
+			ginkgo.It("should succeed", func() {
+				run.AggregateCommandOutcome([]domain.Command{
+					{Status: domain.CommandStatusAck},
+					{Status: domain.CommandStatusAck},
+				})
+				gomega.Expect(run.CommandOutcome).To(gomega.Equal(domain.CommandOutcomeSucceeded))
+				gomega.Expect(run.IsFailure()).To(gomega.BeFalse())
+			})
+		})
+
+		ginkgo.When("some commands failed", func() {
+			ginkgo.It("should be partial and count as a failure", func() {
+				run.AggregateCommandOutcome([]domain.Command{
+					{Status: domain.CommandStatusAck},
+					{Status: domain.CommandStatusFailed},
+				})
+				gomega.Expect(run.CommandOutcome).To(gomega.Equal(domain.CommandOutcomePartial))
+				gomega.Expect(run.IsFailure()).To(gomega.BeTrue())
+			})
+		})
+
+		ginkgo.When("the remaining commands were cancelled", func() {
+			ginkgo.It("should count them as undelivered", func() {
+				run.AggregateCommandOutcome([]domain.Command{
+					{Status: domain.CommandStatusAck},
+					{Status: domain.CommandStatusCancelled},
+				})
+				gomega.Expect(run.CommandOutcome).To(gomega.Equal(domain.CommandOutcomePartial))
+				gomega.Expect(run.IsFailure()).To(gomega.BeTrue())
+			})
+		})
+
+		ginkgo.When("every command failed", func() {
+			ginkgo.It("should fail", func() {
+				run.AggregateCommandOutcome([]domain.Command{
+					{Status: domain.CommandStatusFailed},
+				})
+				gomega.Expect(run.CommandOutcome).To(gomega.Equal(domain.CommandOutcomeFailed))
+				gomega.Expect(run.IsFailure()).To(gomega.BeTrue())
+			})
+		})
+	})
+})
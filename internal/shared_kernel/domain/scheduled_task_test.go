@@ -123,4 +123,144 @@ var _ = ginkgo.Describe("ScheduledTask", func() {
 			})
 		})
 	})
+
+	ginkgo.Context("Pause", func() {
+		var scheduledTask domain.ScheduledTask
+		var until *utils.Time
+
+		ginkgo.BeforeEach(func() {
+			scheduledTask = domain.ScheduledTask{
+				ID:       domain.ID(utils.GenerateUUID()),
+				Schedule: "0 0 * * *",
+				IsActive: true,
+			}
+		})
+
+		ginkgo.When("no end date is given", func() {
+			ginkgo.BeforeEach(func() {
+				until = nil
+			})
+
+			ginkgo.It("should stay paused until resumed", func() {
+				err := scheduledTask.Pause(until)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(scheduledTask.IsPaused(time.Now().AddDate(1, 0, 0))).To(gomega.BeTrue())
+
+				scheduledTask.Resume()
+				gomega.Expect(scheduledTask.IsPaused(time.Now())).To(gomega.BeFalse())
+				gomega.Expect(scheduledTask.PausedAt).To(gomega.BeNil())
+			})
+		})
+
+		ginkgo.When("an end date in the future is given", func() {
+			ginkgo.BeforeEach(func() {
+				until = &utils.Time{Time: time.Now().Add(time.Hour)}
+			})
+
+			ginkgo.It("should lift the pause once the end date is reached", func() {
+				err := scheduledTask.Pause(until)
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(scheduledTask.IsPaused(time.Now())).To(gomega.BeTrue())
+				gomega.Expect(scheduledTask.IsPaused(until.Add(time.Second))).To(gomega.BeFalse())
+			})
+		})
+
+		ginkgo.When("an end date in the past is given", func() {
+			ginkgo.BeforeEach(func() {
+				until = &utils.Time{Time: time.Now().Add(-time.Hour)}
+			})
+
+			ginkgo.It("should return an error", func() {
+				err := scheduledTask.Pause(until)
+				gomega.Expect(err).To(gomega.MatchError(domain.ErrPauseUntilMustBeInFuture))
+				gomega.Expect(scheduledTask.PausedAt).To(gomega.BeNil())
+			})
+		})
+	})
+
+	ginkgo.Context("SkipExecution", func() {
+		var scheduledTask domain.ScheduledTask
+		var now time.Time
+
+		ginkgo.When("a due execution is skipped", func() {
+			ginkgo.BeforeEach(func() {
+				scheduledTask = domain.ScheduledTask{
+					ID:       domain.ID(utils.GenerateUUID()),
+					Schedule: "0 0 * * *",
+					IsActive: true,
+				}
+				now = time.Now()
+			})
+
+			ginkgo.It("should count it as the last execution", func() {
+				scheduledTask.SkipExecution(now)
+				gomega.Expect(scheduledTask.LastExecutedAt).NotTo(gomega.BeNil())
+				gomega.Expect(scheduledTask.LastExecutedAt.Time).To(gomega.Equal(now))
+			})
+		})
+	})
+
+	ginkgo.Context("TargetDevices", func() {
+		var scheduledTask domain.ScheduledTask
+
+		ginkgo.When("additional devices repeat the owning device", func() {
+			ginkgo.BeforeEach(func() {
+				scheduledTask = domain.ScheduledTask{
+					Device: domain.Device{ID: "device-1"},
+					Devices: []domain.Device{
+						{ID: "device-2"},
+						{ID: "device-1"},
+						{ID: "device-3"},
+					},
+				}
+			})
+
+			ginkgo.It("should list every device once with the owner first", func() {
+				targets := scheduledTask.TargetDevices()
+				gomega.Expect(targets).To(gomega.HaveLen(3))
+				gomega.Expect(targets[0].ID).To(gomega.Equal(domain.ID("device-1")))
+				gomega.Expect(targets[1].ID).To(gomega.Equal(domain.ID("device-2")))
+				gomega.Expect(targets[2].ID).To(gomega.Equal(domain.ID("device-3")))
+				gomega.Expect(scheduledTask.TargetsDevice("device-3")).To(gomega.BeTrue())
+				gomega.Expect(scheduledTask.TargetsDevice("device-4")).To(gomega.BeFalse())
+			})
+		})
+	})
+
+	ginkgo.Context("Build", func() {
+		var builder func() (domain.ScheduledTask, error)
+		var templates []domain.CommandTemplate
+
+		ginkgo.BeforeEach(func() {
+			templates = []domain.CommandTemplate{{Payload: domain.CommandPayload{Index: 1, Value: 1}}}
+			builder = func() (domain.ScheduledTask, error) {
+				return domain.NewScheduledTaskBuilder().
+					WithTenant(domain.Tenant{ID: "tenant-1"}).
+					WithDevice(domain.Device{ID: "device-1"}).
+					WithSchedule("0 0 * * *").
+					WithCommandTemplateSet(domain.CommandTemplateSet{ID: "set-1"}).
+					WithCommandTemplates(templates).
+					Build()
+			}
+		})
+
+		ginkgo.When("a command template set is referenced without templates", func() {
+			ginkgo.BeforeEach(func() {
+				templates = nil
+			})
+
+			ginkgo.It("should build the scheduled task", func() {
+				scheduledTask, err := builder()
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(scheduledTask.CommandTemplateSet.ID).To(gomega.Equal(domain.ID("set-1")))
+			})
+		})
+
+		ginkgo.When("both a command template set and templates are given", func() {
+			ginkgo.It("should return an error", func() {
+				_, err := builder()
+				gomega.Expect(err).To(gomega.HaveOccurred())
+			})
+		})
+	})
 })
@@ -1,18 +1,54 @@
 package domain
 
 import (
+	"errors"
 	"time"
 	"zensor-server/internal/infra/utils"
 )
 
+var ErrSchedulesHoldUntilMustBeInFuture = errors.New("schedules hold until must be in the future")
+
 type TenantConfiguration struct {
 	ID                ID
 	TenantID          ID
 	Timezone          string
 	NotificationEmail string
-	Version           int
-	CreatedAt         time.Time
-	UpdatedAt         time.Time
+	SchedulesHold     *SchedulesHold
+	// LowBatteryThreshold overrides the server wide battery percent below which devices raise a
+	// low battery alert; nil keeps the server default and zero disables the alerts.
+	LowBatteryThreshold *int
+	Version             int
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// SchedulesHold suspends every scheduled task of a tenant until the given instant, e.g. during
+// maintenance windows or the rain season.
+type SchedulesHold struct {
+	Until  time.Time
+	Reason string
+}
+
+func (tc *TenantConfiguration) HoldSchedules(until time.Time, reason string) error {
+	now := time.Now()
+	if !until.After(now) {
+		return ErrSchedulesHoldUntilMustBeInFuture
+	}
+
+	tc.SchedulesHold = &SchedulesHold{Until: until, Reason: reason}
+	tc.UpdatedAt = now
+	return nil
+}
+
+func (tc *TenantConfiguration) ReleaseSchedulesHold() {
+	tc.SchedulesHold = nil
+	tc.UpdatedAt = time.Now()
+}
+
+// IsSchedulesHeld reports whether the tenant schedules hold is in effect at the given instant.
+// Holds lift by themselves once their end date is reached.
+func (tc *TenantConfiguration) IsSchedulesHeld(now time.Time) bool {
+	return tc.SchedulesHold != nil && now.Before(tc.SchedulesHold.Until)
 }
 
 func (tc *TenantConfiguration) UpdateTimezone(timezone string) error {
@@ -33,6 +69,15 @@ func (tc *TenantConfiguration) UpdateNotificationEmail(email string) error {
 	return nil
 }
 
+func (tc *TenantConfiguration) UpdateLowBatteryThreshold(threshold int) error {
+	if threshold < 0 || threshold > 100 {
+		return ErrInvalidLowBatteryThreshold
+	}
+	tc.LowBatteryThreshold = &threshold
+	tc.UpdatedAt = time.Now()
+	return nil
+}
+
 func NewTenantConfigurationBuilder() *tenantConfigurationBuilder {
 	return &tenantConfigurationBuilder{}
 }
@@ -73,6 +118,17 @@ func (b *tenantConfigurationBuilder) WithNotificationEmail(email string) *tenant
 	return b
 }
 
+func (b *tenantConfigurationBuilder) WithLowBatteryThreshold(threshold int) *tenantConfigurationBuilder {
+	b.actions = append(b.actions, func(tc *TenantConfiguration) error {
+		if threshold < 0 || threshold > 100 {
+			return ErrInvalidLowBatteryThreshold
+		}
+		tc.LowBatteryThreshold = &threshold
+		return nil
+	})
+	return b
+}
+
 func (b *tenantConfigurationBuilder) Build() (TenantConfiguration, error) {
 	now := time.Now()
 	result := TenantConfiguration{
@@ -0,0 +1,60 @@
+package domain_test
+
+import (
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("TenantConfiguration", func() {
+	ginkgo.Context("HoldSchedules", func() {
+		var config domain.TenantConfiguration
+		var until time.Time
+
+		ginkgo.BeforeEach(func() {
+			var err error
+			config, err = domain.NewTenantConfigurationBuilder().
+				WithTenantID(domain.ID("tenant-1")).
+				WithTimezone("UTC").
+				Build()
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+		})
+
+		ginkgo.When("the hold ends in the future", func() {
+			ginkgo.BeforeEach(func() {
+				until = time.Now().Add(24 * time.Hour)
+			})
+
+			ginkgo.It("should hold schedules until the end date", func() {
+				err := config.HoldSchedules(until, "rain season")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+				gomega.Expect(config.IsSchedulesHeld(time.Now())).To(gomega.BeTrue())
+				gomega.Expect(config.IsSchedulesHeld(until)).To(gomega.BeFalse())
+				gomega.Expect(config.SchedulesHold.Reason).To(gomega.Equal("rain season"))
+			})
+
+			ginkgo.It("should stop holding schedules once released", func() {
+				err := config.HoldSchedules(until, "maintenance")
+				gomega.Expect(err).NotTo(gomega.HaveOccurred())
+
+				config.ReleaseSchedulesHold()
+				gomega.Expect(config.IsSchedulesHeld(time.Now())).To(gomega.BeFalse())
+				gomega.Expect(config.SchedulesHold).To(gomega.BeNil())
+			})
+		})
+
+		ginkgo.When("the hold ends in the past", func() {
+			ginkgo.BeforeEach(func() {
+				until = time.Now().Add(-time.Minute)
+			})
+
+			ginkgo.It("should return an error", func() {
+				err := config.HoldSchedules(until, "")
+				gomega.Expect(err).To(gomega.MatchError(domain.ErrSchedulesHoldUntilMustBeInFuture))
+				gomega.Expect(config.SchedulesHold).To(gomega.BeNil())
+			})
+		})
+	})
+})
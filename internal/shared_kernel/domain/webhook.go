@@ -0,0 +1,293 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+	"zensor-server/internal/infra/utils"
+)
+
+// WebhookEventTypes are the platform events tenants can subscribe webhooks to.
+var WebhookEventTypes = []string{
+	"uplink",
+	"sensor_data_received",
+	"command_status_update",
+	"scheduled_task_executed",
+	"device_online",
+	"device_offline",
+	"device_battery_low",
+	"device_battery_recovered",
+	"execution_created",
+	"execution_creation_failed",
+	"execution_ready_for_notification",
+	"execution_overdue",
+}
+
+// WebhookDeliveryStatus tracks the delivery of one event to one webhook subscription.
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"   // Waiting for its first attempt or a retry
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded" // Accepted by the receiver with a 2xx response
+	WebhookDeliveryStatusDead      WebhookDeliveryStatus = "dead"      // Ran out of attempts, kept as a dead letter
+)
+
+const (
+	DefaultWebhookMaxAttempts    = 8
+	DefaultWebhookInitialBackoff = 30 * time.Second
+	DefaultWebhookMaxBackoff     = time.Hour
+
+	// maxWebhookLastErrorSize keeps the error of a failed attempt short, as receivers may answer
+	// with whole pages.
+	maxWebhookLastErrorSize = 512
+)
+
+var (
+	ErrWebhookURLInvalid           = errors.New("the webhook URL must be an absolute http or https URL")
+	ErrWebhookSecretRequired       = errors.New("the webhook secret is required")
+	ErrWebhookEventTypesRequired   = errors.New("the webhook must subscribe to at least one event type")
+	ErrWebhookEventTypeUnknown     = errors.New("unknown webhook event type")
+	ErrWebhookDeliveryNotRetryable = errors.New("only dead webhook deliveries can be redelivered")
+)
+
+// WebhookSubscription tells the platform to POST the events of the given types that happen in
+// the tenant to URL. Requests are signed with Secret so the receiver can authenticate them.
+type WebhookSubscription struct {
+	ID         ID
+	Version    Version
+	Tenant     Tenant
+	URL        string
+	Secret     string
+	EventTypes []string
+	CreatedAt  utils.Time
+	UpdatedAt  utils.Time
+	DeletedAt  *utils.Time
+}
+
+// Validate reports whether the subscription can be stored, so updates are checked like new
+// subscriptions.
+func (s *WebhookSubscription) Validate() error {
+	if s.Tenant.ID == "" {
+		return errTenantRequired
+	}
+
+	target, err := url.Parse(s.URL)
+	if err != nil || !target.IsAbs() || target.Host == "" || (target.Scheme != "http" && target.Scheme != "https") {
+		return ErrWebhookURLInvalid
+	}
+
+	if s.Secret == "" {
+		return ErrWebhookSecretRequired
+	}
+
+	if len(s.EventTypes) == 0 {
+		return ErrWebhookEventTypesRequired
+	}
+
+	for _, eventType := range s.EventTypes {
+		if !slices.Contains(WebhookEventTypes, eventType) {
+			return fmt.Errorf("%w: %s", ErrWebhookEventTypeUnknown, eventType)
+		}
+	}
+
+	return nil
+}
+
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+	return slices.Contains(s.EventTypes, eventType)
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the timestamp, in Unix seconds, and the body joined
+// by a dot. Covering the timestamp lets receivers reject replayed requests.
+func (s *WebhookSubscription) Sign(timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(s.Secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *WebhookSubscription) IsDeleted() bool {
+	return s.DeletedAt != nil
+}
+
+func (s *WebhookSubscription) SoftDelete() {
+	now := utils.Time{Time: time.Now()}
+	s.DeletedAt = &now
+	s.UpdatedAt = now
+}
+
+func NewWebhookSubscriptionBuilder() *webhookSubscriptionBuilder {
+	return &webhookSubscriptionBuilder{}
+}
+
+type webhookSubscriptionBuilder struct {
+	actions []webhookSubscriptionHandler
+}
+
+type webhookSubscriptionHandler func(v *WebhookSubscription) error
+
+func (b *webhookSubscriptionBuilder) WithTenant(value Tenant) *webhookSubscriptionBuilder {
+	b.actions = append(b.actions, func(s *WebhookSubscription) error {
+		s.Tenant = value
+		return nil
+	})
+	return b
+}
+
+func (b *webhookSubscriptionBuilder) WithURL(value string) *webhookSubscriptionBuilder {
+	b.actions = append(b.actions, func(s *WebhookSubscription) error {
+		s.URL = value
+		return nil
+	})
+	return b
+}
+
+func (b *webhookSubscriptionBuilder) WithSecret(value string) *webhookSubscriptionBuilder {
+	b.actions = append(b.actions, func(s *WebhookSubscription) error {
+		s.Secret = value
+		return nil
+	})
+	return b
+}
+
+func (b *webhookSubscriptionBuilder) WithEventTypes(value []string) *webhookSubscriptionBuilder {
+	b.actions = append(b.actions, func(s *WebhookSubscription) error {
+		s.EventTypes = value
+		return nil
+	})
+	return b
+}
+
+func (b *webhookSubscriptionBuilder) Build() (WebhookSubscription, error) {
+	now := utils.Time{Time: time.Now()}
+	result := WebhookSubscription{
+		ID:         ID(utils.GenerateUUID()),
+		Version:    1,
+		EventTypes: make([]string, 0),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	for _, a := range b.actions {
+		if err := a(&result); err != nil {
+			return WebhookSubscription{}, err
+		}
+	}
+
+	if err := result.Validate(); err != nil {
+		return WebhookSubscription{}, err
+	}
+
+	return result, nil
+}
+
+// WebhookRetryPolicy spaces the attempts of a delivery with an exponential backoff: the n-th
+// retry waits InitialBackoff doubled n-1 times, capped at MaxBackoff. A delivery that fails
+// MaxAttempts times is dead.
+type WebhookRetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Backoff returns the wait after the given number of failed attempts.
+func (p WebhookRetryPolicy) Backoff(attempts int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempts && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, p.MaxBackoff)
+}
+
+// WebhookDelivery is the delivery of one event to one subscription, and the log of its attempts.
+// Payload holds the request body, so retries send the same bytes the first attempt did.
+type WebhookDelivery struct {
+	ID             ID
+	SubscriptionID ID
+	TenantID       ID
+	EventID        string
+	EventType      string
+	Payload        []byte
+	Status         WebhookDeliveryStatus
+	Attempts       int
+	NextAttemptAt  utils.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      utils.Time
+	UpdatedAt      utils.Time
+	DeliveredAt    *utils.Time
+}
+
+// NewWebhookDelivery creates a delivery of the event due right away.
+func NewWebhookDelivery(subscription WebhookSubscription, eventID, eventType string, payload []byte, now time.Time) WebhookDelivery {
+	createdAt := utils.Time{Time: now}
+	return WebhookDelivery{
+		ID:             ID(utils.GenerateUUID()),
+		SubscriptionID: subscription.ID,
+		TenantID:       subscription.Tenant.ID,
+		EventID:        eventID,
+		EventType:      eventType,
+		Payload:        payload,
+		Status:         WebhookDeliveryStatusPending,
+		NextAttemptAt:  createdAt,
+		CreatedAt:      createdAt,
+		UpdatedAt:      createdAt,
+	}
+}
+
+func (d *WebhookDelivery) RecordSuccess(statusCode int, now time.Time) {
+	at := utils.Time{Time: now}
+	d.Attempts++
+	d.Status = WebhookDeliveryStatusSucceeded
+	d.LastStatusCode = statusCode
+	d.LastError = ""
+	d.DeliveredAt = &at
+	d.UpdatedAt = at
+}
+
+// RecordFailure schedules the next attempt after the policy backoff, or kills the delivery once
+// it ran out of attempts. statusCode is zero when no response was received.
+func (d *WebhookDelivery) RecordFailure(statusCode int, reason string, policy WebhookRetryPolicy, now time.Time) {
+	d.Attempts++
+	d.LastStatusCode = statusCode
+	if len(reason) > maxWebhookLastErrorSize {
+		reason = reason[:maxWebhookLastErrorSize]
+	}
+	d.LastError = reason
+	d.UpdatedAt = utils.Time{Time: now}
+
+	if d.Attempts >= policy.MaxAttempts {
+		d.Status = WebhookDeliveryStatusDead
+		return
+	}
+	d.NextAttemptAt = utils.Time{Time: now.Add(policy.Backoff(d.Attempts))}
+}
+
+// Redeliver takes a dead delivery out of the dead-letter list with a fresh set of attempts.
+func (d *WebhookDelivery) Redeliver(now time.Time) error {
+	if d.Status != WebhookDeliveryStatusDead {
+		return ErrWebhookDeliveryNotRetryable
+	}
+
+	at := utils.Time{Time: now}
+	d.Status = WebhookDeliveryStatusPending
+	d.Attempts = 0
+	d.NextAttemptAt = at
+	d.UpdatedAt = at
+	return nil
+}
+
+// Abandon moves a pending delivery to the dead-letter list without attempting it, such as when
+// its subscription was deleted.
+func (d *WebhookDelivery) Abandon(reason string, now time.Time) {
+	d.Status = WebhookDeliveryStatusDead
+	d.LastError = reason
+	d.UpdatedAt = utils.Time{Time: now}
+}
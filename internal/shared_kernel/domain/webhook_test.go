@@ -0,0 +1,132 @@
+package domain_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Webhook", func() {
+	var (
+		now    time.Time
+		tenant domain.Tenant
+		policy domain.WebhookRetryPolicy
+	)
+
+	ginkgo.BeforeEach(func() {
+		now = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+		tenant = domain.Tenant{ID: "tenant-1"}
+		policy = domain.WebhookRetryPolicy{MaxAttempts: 3, InitialBackoff: time.Minute, MaxBackoff: 3 * time.Minute}
+	})
+
+	ginkgo.Context("WebhookSubscription", func() {
+		build := func(url, secret string, eventTypes ...string) (domain.WebhookSubscription, error) {
+			return domain.NewWebhookSubscriptionBuilder().
+				WithTenant(tenant).
+				WithURL(url).
+				WithSecret(secret).
+				WithEventTypes(eventTypes).
+				Build()
+		}
+
+		ginkgo.It("should build a valid subscription", func() {
+			subscription, err := build("https://example.com/hooks", "secret", "uplink", "device_offline")
+
+			gomega.Expect(err).NotTo(gomega.HaveOccurred())
+			gomega.Expect(subscription.ID).NotTo(gomega.BeEmpty())
+			gomega.Expect(subscription.Subscribes("device_offline")).To(gomega.BeTrue())
+			gomega.Expect(subscription.Subscribes("device_online")).To(gomega.BeFalse())
+		})
+
+		ginkgo.It("should reject invalid subscriptions", func() {
+			_, err := build("ftp://example.com/hooks", "secret", "uplink")
+			gomega.Expect(err).To(gomega.MatchError(domain.ErrWebhookURLInvalid))
+
+			_, err = build("/hooks", "secret", "uplink")
+			gomega.Expect(err).To(gomega.MatchError(domain.ErrWebhookURLInvalid))
+
+			_, err = build("https://example.com/hooks", "", "uplink")
+			gomega.Expect(err).To(gomega.MatchError(domain.ErrWebhookSecretRequired))
+
+			_, err = build("https://example.com/hooks", "secret")
+			gomega.Expect(err).To(gomega.MatchError(domain.ErrWebhookEventTypesRequired))
+
+			_, err = build("https://example.com/hooks", "secret", "uplink", "unknown")
+			gomega.Expect(err).To(gomega.MatchError(domain.ErrWebhookEventTypeUnknown))
+		})
+
+		ginkgo.It("should sign the timestamp and the body", func() {
+			subscription := domain.WebhookSubscription{Secret: "secret"}
+			body := []byte(`{"type":"uplink"}`)
+
+			mac := hmac.New(sha256.New, []byte("secret"))
+			mac.Write([]byte("1772359200." + string(body)))
+
+			gomega.Expect(subscription.Sign(now, body)).To(gomega.Equal(hex.EncodeToString(mac.Sum(nil))))
+		})
+	})
+
+	ginkgo.Context("WebhookRetryPolicy", func() {
+		ginkgo.It("should double the backoff up to the maximum", func() {
+			gomega.Expect(policy.Backoff(1)).To(gomega.Equal(time.Minute))
+			gomega.Expect(policy.Backoff(2)).To(gomega.Equal(2 * time.Minute))
+			gomega.Expect(policy.Backoff(3)).To(gomega.Equal(3 * time.Minute))
+			gomega.Expect(policy.Backoff(10)).To(gomega.Equal(3 * time.Minute))
+		})
+	})
+
+	ginkgo.Context("WebhookDelivery", func() {
+		var delivery domain.WebhookDelivery
+
+		ginkgo.BeforeEach(func() {
+			subscription := domain.WebhookSubscription{ID: "subscription-1", Tenant: tenant}
+			delivery = domain.NewWebhookDelivery(subscription, "event-1", "uplink", []byte(`{}`), now)
+		})
+
+		ginkgo.It("should be due right away", func() {
+			gomega.Expect(delivery.Status).To(gomega.Equal(domain.WebhookDeliveryStatusPending))
+			gomega.Expect(delivery.TenantID).To(gomega.Equal(domain.ID("tenant-1")))
+			gomega.Expect(delivery.NextAttemptAt.Time).To(gomega.Equal(now))
+		})
+
+		ginkgo.It("should retry failures with backoff until it runs out of attempts", func() {
+			delivery.RecordFailure(500, "unexpected status 500", policy, now)
+			gomega.Expect(delivery.Status).To(gomega.Equal(domain.WebhookDeliveryStatusPending))
+			gomega.Expect(delivery.Attempts).To(gomega.Equal(1))
+			gomega.Expect(delivery.NextAttemptAt.Time).To(gomega.Equal(now.Add(time.Minute)))
+
+			delivery.RecordFailure(0, "connection refused", policy, now)
+			gomega.Expect(delivery.NextAttemptAt.Time).To(gomega.Equal(now.Add(2 * time.Minute)))
+
+			delivery.RecordFailure(502, "unexpected status 502", policy, now)
+			gomega.Expect(delivery.Status).To(gomega.Equal(domain.WebhookDeliveryStatusDead))
+			gomega.Expect(delivery.LastStatusCode).To(gomega.Equal(502))
+		})
+
+		ginkgo.It("should record successes", func() {
+			delivery.RecordFailure(500, "unexpected status 500", policy, now)
+			delivery.RecordSuccess(204, now)
+
+			gomega.Expect(delivery.Status).To(gomega.Equal(domain.WebhookDeliveryStatusSucceeded))
+			gomega.Expect(delivery.Attempts).To(gomega.Equal(2))
+			gomega.Expect(delivery.LastError).To(gomega.BeEmpty())
+			gomega.Expect(delivery.DeliveredAt).NotTo(gomega.BeNil())
+		})
+
+		ginkgo.It("should only redeliver dead deliveries", func() {
+			gomega.Expect(delivery.Redeliver(now)).To(gomega.MatchError(domain.ErrWebhookDeliveryNotRetryable))
+
+			delivery.Abandon("the webhook subscription was deleted", now)
+			gomega.Expect(delivery.Redeliver(now.Add(time.Hour))).To(gomega.Succeed())
+
+			gomega.Expect(delivery.Status).To(gomega.Equal(domain.WebhookDeliveryStatusPending))
+			gomega.Expect(delivery.Attempts).To(gomega.BeZero())
+			gomega.Expect(delivery.NextAttemptAt.Time).To(gomega.Equal(now.Add(time.Hour)))
+		})
+	})
+})
@@ -0,0 +1,77 @@
+// Package events defines the catalog of events published on the internal broker and their payloads.
+package events
+
+import (
+	"time"
+	"zensor-server/internal/data_plane/dto"
+	"zensor-server/internal/infra/async"
+	"zensor-server/internal/shared_kernel/device"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+var (
+	Uplink                = async.DefineEvent[dto.Envelop]("uplink", 1)
+	SensorDataReceived    = async.DefineEvent[SensorData]("sensor_data_received", 1)
+	CommandSent           = async.DefineEvent[device.Command]("command_sent", 1)
+	CommandStatusUpdated  = async.DefineEvent[domain.CommandStatusUpdate]("command_status_update", 1)
+	CommandProcessed      = async.DefineEvent[domain.Command]("command_processed", 1)
+	ScheduledTaskExecuted = async.DefineEvent[domain.ScheduledTask]("scheduled_task_executed", 1)
+
+	DeviceOnline           = async.DefineEvent[DeviceConnectivity]("device_online", 1)
+	DeviceOffline          = async.DefineEvent[DeviceConnectivity]("device_offline", 1)
+	DeviceBatteryLow       = async.DefineEvent[DeviceBattery]("device_battery_low", 1)
+	DeviceBatteryRecovered = async.DefineEvent[DeviceBattery]("device_battery_recovered", 1)
+
+	ExecutionCreated              = async.DefineEvent[ExecutionCreation]("execution_created", 1)
+	ExecutionCreationFailed       = async.DefineEvent[ExecutionCreation]("execution_creation_failed", 1)
+	ExecutionReadyForNotification = async.DefineEvent[ExecutionReminder]("execution_ready_for_notification", 1)
+	ExecutionOverdue              = async.DefineEvent[ExecutionReminder]("execution_overdue", 1)
+)
+
+// SensorData is a single reading decoded from an uplink. The topic it is published on names the
+// sensor as well, for subscribers of a single sensor.
+type SensorData struct {
+	DeviceName string  `json:"device_name"`
+	Sensor     string  `json:"sensor,omitempty"`
+	AppID      string  `json:"app_id"`
+	Value      float64 `json:"value"`
+	Index      uint    `json:"index"`
+}
+
+// DeviceConnectivity describes a device crossing between online and offline.
+type DeviceConnectivity struct {
+	DeviceID                      string     `json:"device_id"`
+	DeviceName                    string     `json:"device_name"`
+	DisplayName                   string     `json:"display_name"`
+	Status                        string     `json:"status"`
+	From                          string     `json:"from"`
+	LastMessageReceivedAt         *time.Time `json:"last_message_received_at,omitempty"`
+	ExpectedUplinkIntervalSeconds int        `json:"expected_uplink_interval_seconds"`
+}
+
+// DeviceBattery describes a device battery crossing the low battery threshold of its tenant.
+type DeviceBattery struct {
+	DeviceID          string  `json:"device_id"`
+	DeviceName        string  `json:"device_name"`
+	DisplayName       string  `json:"display_name"`
+	BatteryPercent    int     `json:"battery_percent"`
+	BatteryVoltage    float64 `json:"battery_voltage"`
+	LowBatteryPercent int     `json:"low_battery_percent"`
+}
+
+// ExecutionCreation reports the outcome of creating the next execution of a maintenance activity.
+type ExecutionCreation struct {
+	ActivityID    string    `json:"activity_id"`
+	ScheduledDate time.Time `json:"scheduled_date,omitzero"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// ExecutionReminder reports a maintenance execution that is due soon or overdue.
+type ExecutionReminder struct {
+	ExecutionID   string    `json:"execution_id"`
+	ActivityID    string    `json:"activity_id"`
+	ActivityName  string    `json:"activity_name"`
+	ScheduledDate time.Time `json:"scheduled_date"`
+	DaysBefore    *int      `json:"days_before,omitempty"`
+	OverdueDays   *int      `json:"overdue_days,omitempty"`
+}
@@ -0,0 +1,84 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"zensor-server/internal/infra/httpserver"
+	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/usecases"
+)
+
+var (
+	ErrInvalidDeviceStatus   = errors.New("status must be online or offline")
+	ErrInvalidDeviceSort     = errors.New("sort must be name, display_name or last_seen, optionally prefixed with -")
+	ErrInvalidDeviceLastSeen = errors.New("last_seen_after and last_seen_before must be RFC 3339 timestamps")
+)
+
+// ParseDeviceQuery reads the filters, sort, page and cursor of a device listing from the query
+// string. Tags are given as repeated tag=key:value parameters, or tag=key to match any value.
+func ParseDeviceQuery(r *http.Request) (usecases.DeviceQuery, httpserver.PaginationParams, error) {
+	values := r.URL.Query()
+	params := httpserver.ExtractPaginationParams(r)
+	query := usecases.DeviceQuery{
+		Filter: usecases.DeviceFilter{
+			Profile:    values.Get("profile"),
+			NamePrefix: values.Get("name_prefix"),
+		},
+		Limit:  params.Limit,
+		Offset: (params.Page - 1) * params.Limit,
+		Cursor: values.Get("cursor"),
+	}
+
+	switch status := domain.ConnectivityState(values.Get("status")); status {
+	case "":
+	case domain.ConnectivityStateOnline, domain.ConnectivityStateOffline:
+		query.Filter.Connectivity = status
+	default:
+		return usecases.DeviceQuery{}, params, ErrInvalidDeviceStatus
+	}
+
+	for _, selector := range values["tag"] {
+		key, value, hasValue, err := domain.ParseDeviceTag(selector)
+		if err != nil {
+			return usecases.DeviceQuery{}, params, err
+		}
+		query.Filter.Tags = append(query.Filter.Tags, usecases.DeviceTagSelector{Key: key, Value: value, HasValue: hasValue})
+	}
+
+	var err error
+	query.Filter.LastSeenAfter, err = parseDeviceLastSeen(values.Get("last_seen_after"))
+	if err != nil {
+		return usecases.DeviceQuery{}, params, err
+	}
+	query.Filter.LastSeenBefore, err = parseDeviceLastSeen(values.Get("last_seen_before"))
+	if err != nil {
+		return usecases.DeviceQuery{}, params, err
+	}
+
+	sort := values.Get("sort")
+	query.Sort.Descending = strings.HasPrefix(sort, "-")
+	switch field := usecases.DeviceSortField(strings.TrimPrefix(sort, "-")); field {
+	case "":
+		query.Sort.Field = usecases.DeviceSortByName
+	case usecases.DeviceSortByName, usecases.DeviceSortByDisplayName, usecases.DeviceSortByLastSeen:
+		query.Sort.Field = field
+	default:
+		return usecases.DeviceQuery{}, params, ErrInvalidDeviceSort
+	}
+
+	return query, params, nil
+}
+
+func parseDeviceLastSeen(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, ErrInvalidDeviceLastSeen
+	}
+	return &parsed, nil
+}
@@ -7,13 +7,27 @@ import (
 
 // TenantConfigurationResponse represents the response for tenant configuration operations.
 type TenantConfigurationResponse struct {
-	ID                string    `json:"id"`
-	TenantID          string    `json:"tenant_id"`
-	Timezone          string    `json:"timezone"`
-	NotificationEmail string    `json:"notification_email,omitempty"`
-	Version           int       `json:"version"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
+	ID                  string                 `json:"id"`
+	TenantID            string                 `json:"tenant_id"`
+	Timezone            string                 `json:"timezone"`
+	NotificationEmail   string                 `json:"notification_email,omitempty"`
+	SchedulesHold       *SchedulesHoldResponse `json:"schedules_hold,omitempty"`
+	LowBatteryThreshold *int                   `json:"low_battery_threshold,omitempty"`
+	Version             int                    `json:"version"`
+	CreatedAt           time.Time              `json:"created_at"`
+	UpdatedAt           time.Time              `json:"updated_at"`
+}
+
+// SchedulesHoldRequest represents the request for holding every scheduled task of a tenant.
+type SchedulesHoldRequest struct {
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// SchedulesHoldResponse represents a tenant-wide hold that suspends every scheduled task.
+type SchedulesHoldResponse struct {
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason,omitempty"`
 }
 
 // TenantConfigurationCreateRequest represents the request for creating a tenant configuration.
@@ -24,19 +38,30 @@ type TenantConfigurationCreateRequest struct {
 
 // TenantConfigurationUpdateRequest represents the request for updating a tenant configuration.
 type TenantConfigurationUpdateRequest struct {
-	Timezone          string  `json:"timezone" validate:"required"`
-	NotificationEmail *string `json:"notification_email,omitempty"`
+	Timezone            string  `json:"timezone" validate:"required"`
+	NotificationEmail   *string `json:"notification_email,omitempty"`
+	LowBatteryThreshold *int    `json:"low_battery_threshold,omitempty"`
 }
 
 // ToTenantConfigurationResponse converts a domain.TenantConfiguration to TenantConfigurationResponse.
 func ToTenantConfigurationResponse(config domain.TenantConfiguration) TenantConfigurationResponse {
-	return TenantConfigurationResponse{
-		ID:                config.ID.String(),
-		TenantID:          config.TenantID.String(),
-		Timezone:          config.Timezone,
-		NotificationEmail: config.NotificationEmail,
-		Version:           config.Version,
-		CreatedAt:         config.CreatedAt,
-		UpdatedAt:         config.UpdatedAt,
+	response := TenantConfigurationResponse{
+		ID:                  config.ID.String(),
+		TenantID:            config.TenantID.String(),
+		Timezone:            config.Timezone,
+		NotificationEmail:   config.NotificationEmail,
+		LowBatteryThreshold: config.LowBatteryThreshold,
+		Version:             config.Version,
+		CreatedAt:           config.CreatedAt,
+		UpdatedAt:           config.UpdatedAt,
 	}
+
+	if config.IsSchedulesHeld(time.Now()) {
+		response.SchedulesHold = &SchedulesHoldResponse{
+			Until:  config.SchedulesHold.Until,
+			Reason: config.SchedulesHold.Reason,
+		}
+	}
+
+	return response
 }
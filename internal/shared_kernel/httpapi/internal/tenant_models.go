@@ -47,10 +47,41 @@ type DeviceResponse struct {
 	DisplayName           string     `json:"display_name"`
 	AppEUI                string     `json:"app_eui"`
 	DevEUI                string     `json:"dev_eui"`
-	AppKey                string     `json:"app_key"`
 	TenantID              *string    `json:"tenant_id,omitempty"`
+	Profile               string     `json:"profile,omitempty"`
 	Status                string     `json:"status"`
 	LastMessageReceivedAt *time.Time `json:"last_message_received_at,omitempty"`
+
+	Tags     map[string]string `json:"tags,omitempty"`
+	Metadata map[string]any    `json:"metadata,omitempty"`
+
+	Health DeviceHealthResponse `json:"health"`
+}
+
+// DeviceHealthResponse is the last health evaluation of a device; components are percentages
+// left out when there is no data for them.
+type DeviceHealthResponse struct {
+	Status             string     `json:"status"`
+	Score              *int       `json:"score,omitempty"`
+	BatteryPercent     *int       `json:"battery_percent,omitempty"`
+	BatteryVoltage     *float64   `json:"battery_voltage,omitempty"`
+	BatteryReportedAt  *time.Time `json:"battery_reported_at,omitempty"`
+	SignalQuality      *int       `json:"signal_quality,omitempty"`
+	UplinkRegularity   *int       `json:"uplink_regularity,omitempty"`
+	CommandFailureRate *int       `json:"command_failure_rate,omitempty"`
+	LowBattery         bool       `json:"low_battery"`
+	EvaluatedAt        *time.Time `json:"evaluated_at,omitempty"`
+}
+
+// FleetHealthResponse aggregates the health of the devices of a tenant.
+type FleetHealthResponse struct {
+	Devices        int              `json:"devices"`
+	Online         int              `json:"online"`
+	LowBattery     int              `json:"low_battery"`
+	ByStatus       map[string]int   `json:"by_status"`
+	AverageScore   *int             `json:"average_score,omitempty"`
+	AverageSignal  *int             `json:"average_signal_quality,omitempty"`
+	WeakestDevices []DeviceResponse `json:"weakest_devices"`
 }
 
 // Conversion functions.
@@ -87,8 +118,11 @@ func ToDeviceResponse(device domain.Device) DeviceResponse {
 		DisplayName: device.DisplayName,
 		AppEUI:      device.AppEUI,
 		DevEUI:      device.DevEUI,
-		AppKey:      device.AppKey,
+		Profile:     device.Profile,
 		Status:      device.GetStatus(),
+		Tags:        device.Tags,
+		Metadata:    device.Metadata,
+		Health:      toDeviceHealthResponse(device),
 	}
 
 	if !device.LastMessageReceivedAt.IsZero() {
@@ -102,3 +136,53 @@ func ToDeviceResponse(device domain.Device) DeviceResponse {
 
 	return response
 }
+
+func toDeviceHealthResponse(device domain.Device) DeviceHealthResponse {
+	health := device.Health
+	response := DeviceHealthResponse{
+		Status:             string(health.Status),
+		Score:              health.Score,
+		BatteryPercent:     health.BatteryPercent,
+		SignalQuality:      health.SignalQuality,
+		UplinkRegularity:   health.UplinkRegularity,
+		CommandFailureRate: health.CommandFailureRate,
+		LowBattery:         health.LowBattery,
+	}
+
+	if response.Status == "" {
+		response.Status = string(domain.DeviceHealthStatusUnknown)
+	}
+
+	if device.Battery != nil {
+		response.BatteryVoltage = &device.Battery.Voltage
+		response.BatteryReportedAt = &device.Battery.ReportedAt.Time
+	}
+
+	if health.EvaluatedAt != nil {
+		response.EvaluatedAt = &health.EvaluatedAt.Time
+	}
+
+	return response
+}
+
+func ToFleetHealthResponse(health domain.FleetHealth) FleetHealthResponse {
+	response := FleetHealthResponse{
+		Devices:        health.Devices,
+		Online:         health.Online,
+		LowBattery:     health.LowBattery,
+		ByStatus:       make(map[string]int, len(health.ByStatus)),
+		AverageScore:   health.AverageScore,
+		AverageSignal:  health.AverageSignal,
+		WeakestDevices: make([]DeviceResponse, len(health.Weakest)),
+	}
+
+	for status, count := range health.ByStatus {
+		response.ByStatus[string(status)] = count
+	}
+
+	for i, device := range health.Weakest {
+		response.WeakestDevices[i] = ToDeviceResponse(device)
+	}
+
+	return response
+}
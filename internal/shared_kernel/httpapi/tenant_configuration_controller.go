@@ -15,6 +15,9 @@ const (
 	getTenantConfigurationErrMessage      = "failed to get tenant configuration"
 	tenantConfigurationNotFoundErrMessage = "tenant configuration not found"
 	invalidTimezoneErrMessage             = "invalid timezone"
+	holdSchedulesErrMessage               = "failed to hold tenant schedules"
+	releaseSchedulesHoldErrMessage        = "failed to release tenant schedules hold"
+	invalidSchedulesHoldUntilErrMessage   = "until must be in the future"
 )
 
 func NewTenantConfigurationController(service usecases.TenantConfigurationService) *TenantConfigurationController {
@@ -32,6 +35,8 @@ type TenantConfigurationController struct {
 func (c *TenantConfigurationController) AddRoutes(router *http.ServeMux) {
 	router.Handle("GET /v1/tenants/{id}/configuration", c.getTenantConfiguration())
 	router.Handle("PUT /v1/tenants/{id}/configuration", c.upsertTenantConfiguration())
+	router.Handle("PUT /v1/tenants/{id}/configuration/schedules-hold", c.holdSchedules())
+	router.Handle("DELETE /v1/tenants/{id}/configuration/schedules-hold", c.releaseSchedulesHold())
 }
 
 func (c *TenantConfigurationController) getTenantConfiguration() http.HandlerFunc {
@@ -90,7 +95,15 @@ func (c *TenantConfigurationController) upsertTenantConfiguration() http.Handler
 			builder = builder.WithNotificationEmail(*body.NotificationEmail)
 		}
 
+		if body.LowBatteryThreshold != nil {
+			builder = builder.WithLowBatteryThreshold(*body.LowBatteryThreshold)
+		}
+
 		config, err := builder.Build()
+		if errors.Is(err, domain.ErrInvalidLowBatteryThreshold) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if err != nil {
 			slog.Error("building tenant configuration", slog.String("error", err.Error()))
 			http.Error(w, invalidTimezoneErrMessage, http.StatusBadRequest)
@@ -122,3 +135,96 @@ func (c *TenantConfigurationController) upsertTenantConfiguration() http.Handler
 		httpserver.ReplyJSONResponse(w, http.StatusOK, response)
 	}
 }
+
+func (c *TenantConfigurationController) holdSchedules() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := r.PathValue("id")
+		if tenantID == "" {
+			http.Error(w, "tenant id is required", http.StatusBadRequest)
+			return
+		}
+
+		userEmail := r.Header.Get("X-User-Email")
+		if userEmail == "" {
+			slog.Error("missing user email in auth header")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var body internal.SchedulesHoldRequest
+		err := httpserver.DecodeJSONBody(r, &body)
+		if err != nil {
+			slog.Error("decoding schedules hold request", slog.String("error", err.Error()))
+			http.Error(w, holdSchedulesErrMessage, http.StatusBadRequest)
+			return
+		}
+
+		tenant := domain.Tenant{ID: domain.ID(tenantID)}
+		hold := domain.SchedulesHold{Until: body.Until, Reason: body.Reason}
+		config, err := c.service.HoldSchedules(r.Context(), userEmail, tenant, hold)
+		if errors.Is(err, domain.ErrSchedulesHoldUntilMustBeInFuture) {
+			http.Error(w, invalidSchedulesHoldUntilErrMessage, http.StatusBadRequest)
+			return
+		}
+		if replyAccessError(w, err) {
+			return
+		}
+		if err != nil {
+			slog.Error("holding tenant schedules", slog.String("error", err.Error()))
+			http.Error(w, holdSchedulesErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		response := internal.ToTenantConfigurationResponse(config)
+		httpserver.ReplyJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+func (c *TenantConfigurationController) releaseSchedulesHold() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := r.PathValue("id")
+		if tenantID == "" {
+			http.Error(w, "tenant id is required", http.StatusBadRequest)
+			return
+		}
+
+		userEmail := r.Header.Get("X-User-Email")
+		if userEmail == "" {
+			slog.Error("missing user email in auth header")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		tenant := domain.Tenant{ID: domain.ID(tenantID)}
+		config, err := c.service.ReleaseSchedulesHold(r.Context(), userEmail, tenant)
+		if errors.Is(err, usecases.ErrTenantConfigurationNotFound) {
+			http.Error(w, tenantConfigurationNotFoundErrMessage, http.StatusNotFound)
+			return
+		}
+		if replyAccessError(w, err) {
+			return
+		}
+		if err != nil {
+			slog.Error("releasing tenant schedules hold", slog.String("error", err.Error()))
+			http.Error(w, releaseSchedulesHoldErrMessage, http.StatusInternalServerError)
+			return
+		}
+
+		response := internal.ToTenantConfigurationResponse(config)
+		httpserver.ReplyJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+func replyAccessError(w http.ResponseWriter, err error) bool {
+	if errors.Is(err, usecases.ErrUserNotFound) {
+		slog.Warn("user not found")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return true
+	}
+	if errors.Is(err, usecases.ErrForbiddenTenantConfigurationAccess) {
+		slog.Warn("forbidden access to tenant configuration")
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return true
+	}
+	return false
+}
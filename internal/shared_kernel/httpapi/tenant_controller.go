@@ -47,6 +47,7 @@ func (c *TenantController) AddRoutes(router *http.ServeMux) {
 	router.Handle("POST /v1/tenants/{id}/deactivate", c.deactivateTenant())
 	router.Handle("POST /v1/tenants/{id}/adopt", c.adoptDevice())
 	router.Handle("GET /v1/tenants/{id}/devices", c.listTenantDevices())
+	router.Handle("GET /v1/tenants/{id}/fleet-health", c.getFleetHealth())
 }
 
 func (c *TenantController) listTenants() http.HandlerFunc {
@@ -310,10 +311,17 @@ func (c *TenantController) listTenantDevices() http.HandlerFunc {
 			return
 		}
 
-		params := httpserver.ExtractPaginationParams(r)
-		pagination := usecases.Pagination{Limit: params.Limit, Offset: (params.Page - 1) * params.Limit}
+		query, params, err := ParseDeviceQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
-		devices, total, err := c.service.ListTenantDevices(r.Context(), domain.ID(tenantID), pagination)
+		page, err := c.service.ListTenantDevices(r.Context(), domain.ID(tenantID), query)
+		if errors.Is(err, usecases.ErrInvalidDeviceCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		if errors.Is(err, usecases.ErrTenantNotFound) {
 			http.Error(w, tenantNotFoundErrMessage, http.StatusNotFound)
 			return
@@ -328,11 +336,38 @@ func (c *TenantController) listTenantDevices() http.HandlerFunc {
 			return
 		}
 
-		responses := make([]internal.DeviceResponse, len(devices))
-		for i, device := range devices {
+		responses := make([]internal.DeviceResponse, len(page.Devices))
+		for i, device := range page.Devices {
 			responses[i] = internal.ToDeviceResponse(device)
 		}
 
-		httpserver.ReplyWithPaginatedData(w, http.StatusOK, responses, total, params)
+		httpserver.ReplyWithCursorPaginatedData(w, http.StatusOK, responses, page.Total, params, page.NextCursor)
+	}
+}
+
+func (c *TenantController) getFleetHealth() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenantID := r.PathValue("id")
+		if tenantID == "" {
+			http.Error(w, "tenant id is required", http.StatusBadRequest)
+			return
+		}
+
+		health, err := c.service.FleetHealth(r.Context(), domain.ID(tenantID))
+		if errors.Is(err, usecases.ErrTenantNotFound) {
+			http.Error(w, tenantNotFoundErrMessage, http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, usecases.ErrTenantSoftDeleted) {
+			http.Error(w, tenantSoftDeletedErrMessage, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			slog.Error("getting tenant fleet health", slog.String("error", err.Error()))
+			http.Error(w, "failed to get tenant fleet health", http.StatusInternalServerError)
+			return
+		}
+
+		httpserver.ReplyJSONResponse(w, http.StatusOK, internal.ToFleetHealthResponse(health))
 	}
 }
@@ -0,0 +1,93 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"zensor-server/internal/shared_kernel/domain"
+	"zensor-server/internal/shared_kernel/httpapi"
+	"zensor-server/internal/shared_kernel/usecases"
+
+	mockusecases "zensor-server/test/unit/doubles/shared_kernel/usecases"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+)
+
+var _ = ginkgo.Describe("TenantController", func() {
+	var (
+		ctrl    *gomock.Controller
+		service *mockusecases.MockTenantService
+		router  *http.ServeMux
+	)
+
+	ginkgo.BeforeEach(func() {
+		ctrl = gomock.NewController(ginkgo.GinkgoT())
+		service = mockusecases.NewMockTenantService(ctrl)
+		router = http.NewServeMux()
+		httpapi.NewTenantController(service).AddRoutes(router)
+	})
+
+	ginkgo.AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	ginkgo.Context("FleetHealth", func() {
+		fleetHealthRequest := func() *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/v1/tenants/tenant-1/fleet-health", nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			return rec
+		}
+
+		ginkgo.When("the tenant has devices", func() {
+			ginkgo.It("should return the aggregated health with the weakest devices", func() {
+				score, battery := 35, 12
+				service.EXPECT().FleetHealth(gomock.Any(), domain.ID("tenant-1")).Return(domain.FleetHealth{
+					Devices:      3,
+					Online:       2,
+					LowBattery:   1,
+					AverageScore: &score,
+					ByStatus: map[domain.DeviceHealthStatus]int{
+						domain.DeviceHealthStatusHealthy:  2,
+						domain.DeviceHealthStatusCritical: 1,
+					},
+					Weakest: []domain.Device{{
+						ID:     domain.ID("device-1"),
+						Name:   "device-1",
+						Health: domain.DeviceHealth{Status: domain.DeviceHealthStatusCritical, Score: &score, BatteryPercent: &battery, LowBattery: true},
+					}},
+				}, nil)
+
+				rec := fleetHealthRequest()
+
+				gomega.Expect(rec.Code).To(gomega.Equal(http.StatusOK))
+
+				var body map[string]any
+				gomega.Expect(json.Unmarshal(rec.Body.Bytes(), &body)).To(gomega.Succeed())
+				gomega.Expect(body["devices"]).To(gomega.BeEquivalentTo(3))
+				gomega.Expect(body["low_battery"]).To(gomega.BeEquivalentTo(1))
+				gomega.Expect(body["average_score"]).To(gomega.BeEquivalentTo(35))
+				gomega.Expect(body["by_status"]).To(gomega.HaveKeyWithValue("critical", gomega.BeEquivalentTo(1)))
+				gomega.Expect(body).NotTo(gomega.HaveKey("average_signal_quality"))
+
+				weakest := body["weakest_devices"].([]any)
+				gomega.Expect(weakest).To(gomega.HaveLen(1))
+				health := weakest[0].(map[string]any)["health"].(map[string]any)
+				gomega.Expect(health["status"]).To(gomega.Equal("critical"))
+				gomega.Expect(health["battery_percent"]).To(gomega.BeEquivalentTo(12))
+				gomega.Expect(health["low_battery"]).To(gomega.BeTrue())
+			})
+		})
+
+		ginkgo.When("the tenant does not exist", func() {
+			ginkgo.It("should return 404", func() {
+				service.EXPECT().FleetHealth(gomock.Any(), domain.ID("tenant-1")).
+					Return(domain.FleetHealth{}, usecases.ErrTenantNotFound)
+
+				gomega.Expect(fleetHealthRequest().Code).To(gomega.Equal(http.StatusNotFound))
+			})
+		})
+	})
+})
@@ -6,13 +6,16 @@ import (
 )
 
 type TenantConfiguration struct {
-	ID                string    `json:"id" gorm:"primaryKey"`
-	TenantID          string    `json:"tenant_id" gorm:"uniqueIndex;not null"`
-	Timezone          string    `json:"timezone" gorm:"not null"`
-	NotificationEmail string    `json:"notification_email"`
-	Version           int       `json:"version"`
-	CreatedAt         time.Time `json:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at"`
+	ID                  string     `json:"id" gorm:"primaryKey"`
+	TenantID            string     `json:"tenant_id" gorm:"uniqueIndex;not null"`
+	Timezone            string     `json:"timezone" gorm:"not null"`
+	NotificationEmail   string     `json:"notification_email"`
+	SchedulesHeldUntil  *time.Time `json:"schedules_held_until"`
+	SchedulesHoldReason string     `json:"schedules_hold_reason"`
+	LowBatteryThreshold *int       `json:"low_battery_threshold"`
+	Version             int        `json:"version"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 }
 
 func (TenantConfiguration) TableName() string {
@@ -20,25 +23,43 @@ func (TenantConfiguration) TableName() string {
 }
 
 func (tc TenantConfiguration) ToDomain() domain.TenantConfiguration {
+	var schedulesHold *domain.SchedulesHold
+	if tc.SchedulesHeldUntil != nil {
+		schedulesHold = &domain.SchedulesHold{
+			Until:  *tc.SchedulesHeldUntil,
+			Reason: tc.SchedulesHoldReason,
+		}
+	}
+
 	return domain.TenantConfiguration{
-		ID:                domain.ID(tc.ID),
-		TenantID:          domain.ID(tc.TenantID),
-		Timezone:          tc.Timezone,
-		NotificationEmail: tc.NotificationEmail,
-		Version:           tc.Version,
-		CreatedAt:         tc.CreatedAt,
-		UpdatedAt:         tc.UpdatedAt,
+		ID:                  domain.ID(tc.ID),
+		TenantID:            domain.ID(tc.TenantID),
+		Timezone:            tc.Timezone,
+		NotificationEmail:   tc.NotificationEmail,
+		SchedulesHold:       schedulesHold,
+		LowBatteryThreshold: tc.LowBatteryThreshold,
+		Version:             tc.Version,
+		CreatedAt:           tc.CreatedAt,
+		UpdatedAt:           tc.UpdatedAt,
 	}
 }
 
 func FromTenantConfiguration(value domain.TenantConfiguration) TenantConfiguration {
-	return TenantConfiguration{
-		ID:                value.ID.String(),
-		TenantID:          value.TenantID.String(),
-		Timezone:          value.Timezone,
-		NotificationEmail: value.NotificationEmail,
-		Version:           value.Version,
-		CreatedAt:         value.CreatedAt,
-		UpdatedAt:         value.UpdatedAt,
+	result := TenantConfiguration{
+		ID:                  value.ID.String(),
+		TenantID:            value.TenantID.String(),
+		Timezone:            value.Timezone,
+		NotificationEmail:   value.NotificationEmail,
+		LowBatteryThreshold: value.LowBatteryThreshold,
+		Version:             value.Version,
+		CreatedAt:           value.CreatedAt,
+		UpdatedAt:           value.UpdatedAt,
 	}
+
+	if value.SchedulesHold != nil {
+		result.SchedulesHeldUntil = &value.SchedulesHold.Until
+		result.SchedulesHoldReason = value.SchedulesHold.Reason
+	}
+
+	return result
 }
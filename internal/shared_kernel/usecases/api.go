@@ -18,6 +18,8 @@ type TenantConfigurationService interface {
 	UpsertTenantConfiguration(ctx context.Context, userEmail string, config domain.TenantConfiguration) (domain.TenantConfiguration, error)
 	GetTenantConfiguration(ctx context.Context, tenant domain.Tenant) (domain.TenantConfiguration, error)
 	GetOrCreateTenantConfiguration(ctx context.Context, tenant domain.Tenant, defaultTimezone string) (domain.TenantConfiguration, error)
+	HoldSchedules(ctx context.Context, userEmail string, tenant domain.Tenant, hold domain.SchedulesHold) (domain.TenantConfiguration, error)
+	ReleaseSchedulesHold(ctx context.Context, userEmail string, tenant domain.Tenant) (domain.TenantConfiguration, error)
 }
 
 type TenantService interface {
@@ -30,7 +32,10 @@ type TenantService interface {
 	ActivateTenant(ctx context.Context, id domain.ID) error
 	DeactivateTenant(ctx context.Context, id domain.ID) error
 	AdoptDevice(ctx context.Context, tenantID, deviceID domain.ID) error
-	ListTenantDevices(ctx context.Context, tenantID domain.ID, pagination Pagination) ([]domain.Device, int, error)
+	// ListTenantDevices searches the devices of the tenant; the tenant filter of the query is overridden.
+	ListTenantDevices(ctx context.Context, tenantID domain.ID, query DeviceQuery) (DevicePage, error)
+	// FleetHealth aggregates the last health evaluation of every device of the tenant.
+	FleetHealth(ctx context.Context, tenantID domain.ID) (domain.FleetHealth, error)
 }
 
 type PushTokenService interface {
@@ -56,5 +61,5 @@ type UserPushMessageSender interface {
 
 type DeviceAdopter interface {
 	AdoptDeviceToTenant(ctx context.Context, tenantID, deviceID domain.ID) error
-	DevicesByTenant(ctx context.Context, tenantID domain.ID, pagination Pagination) ([]domain.Device, int, error)
+	SearchDevices(ctx context.Context, query DeviceQuery) (DevicePage, error)
 }
@@ -0,0 +1,58 @@
+package usecases
+
+import (
+	"errors"
+	"time"
+	"zensor-server/internal/shared_kernel/domain"
+)
+
+var ErrInvalidDeviceCursor = errors.New("invalid device cursor")
+
+// DeviceSortField is the column device listings are ordered by; ties are broken by device ID.
+type DeviceSortField string
+
+const (
+	DeviceSortByName        DeviceSortField = "name"
+	DeviceSortByDisplayName DeviceSortField = "display_name"
+	DeviceSortByLastSeen    DeviceSortField = "last_seen"
+)
+
+// DeviceTagSelector matches devices carrying the tag; without a value any value matches.
+type DeviceTagSelector struct {
+	Key      string
+	Value    string
+	HasValue bool
+}
+
+// DeviceFilter narrows a device listing. Zero fields do not filter and all the set ones must match.
+type DeviceFilter struct {
+	TenantID       *domain.ID
+	Connectivity   domain.ConnectivityState
+	Tags           []DeviceTagSelector
+	Profile        string
+	NamePrefix     string
+	LastSeenAfter  *time.Time
+	LastSeenBefore *time.Time
+}
+
+type DeviceSort struct {
+	Field      DeviceSortField
+	Descending bool
+}
+
+// DeviceQuery lists devices page by page. When Cursor is set it takes over Offset and the page
+// starts right after the device the cursor was issued for.
+type DeviceQuery struct {
+	Filter DeviceFilter
+	Sort   DeviceSort
+	Limit  int
+	Offset int
+	Cursor string
+}
+
+// DevicePage holds one page of a device listing. NextCursor is empty on the last page.
+type DevicePage struct {
+	Devices    []domain.Device
+	Total      int
+	NextCursor string
+}
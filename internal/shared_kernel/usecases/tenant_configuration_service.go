@@ -8,6 +8,8 @@ import (
 	"zensor-server/internal/shared_kernel/domain"
 )
 
+const _defaultTimezone = "UTC"
+
 var (
 	ErrInvalidTimezone                    = errors.New("invalid timezone")
 	ErrForbiddenTenantConfigurationAccess = errors.New("forbidden tenant configuration access")
@@ -28,22 +30,9 @@ type SimpleTenantConfigurationService struct {
 }
 
 func (s *SimpleTenantConfigurationService) UpsertTenantConfiguration(ctx context.Context, userEmail string, config domain.TenantConfiguration) (domain.TenantConfiguration, error) {
-	// Convert email to domain.ID for user lookup
-	userID := domain.ID(userEmail)
-	user, err := s.userService.GetUser(ctx, userID)
+	err := s.authorizeTenantAccess(ctx, userEmail, config.TenantID)
 	if err != nil {
-		if errors.Is(err, ErrUserNotFound) {
-			slog.Warn("user not found", slog.String("user_email", userEmail))
-			return domain.TenantConfiguration{}, ErrUserNotFound
-		}
-		return domain.TenantConfiguration{}, fmt.Errorf("getting user: %w", err)
-	}
-
-	if !user.HasTenant(config.TenantID) {
-		slog.Warn("user does not have permission to access tenant configuration",
-			slog.String("user_email", userEmail),
-			slog.String("tenant_id", config.TenantID.String()))
-		return domain.TenantConfiguration{}, ErrForbiddenTenantConfigurationAccess
+		return domain.TenantConfiguration{}, err
 	}
 
 	existingConfig, err := s.repository.GetByTenantID(ctx, config.TenantID)
@@ -88,6 +77,13 @@ func (s *SimpleTenantConfigurationService) UpsertTenantConfiguration(ctx context
 			slog.String("existing_email", existingConfig.NotificationEmail))
 	}
 
+	if config.LowBatteryThreshold != nil {
+		err = existingConfig.UpdateLowBatteryThreshold(*config.LowBatteryThreshold)
+		if err != nil {
+			return domain.TenantConfiguration{}, fmt.Errorf("updating low battery threshold: %w", err)
+		}
+	}
+
 	err = s.repository.Update(ctx, existingConfig)
 	slog.Info("updating tenant configuration in database",
 		slog.String("notification_email", existingConfig.NotificationEmail))
@@ -103,6 +99,82 @@ func (s *SimpleTenantConfigurationService) UpsertTenantConfiguration(ctx context
 	return existingConfig, nil
 }
 
+func (s *SimpleTenantConfigurationService) authorizeTenantAccess(ctx context.Context, userEmail string, tenantID domain.ID) error {
+	// Convert email to domain.ID for user lookup
+	userID := domain.ID(userEmail)
+	user, err := s.userService.GetUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			slog.Warn("user not found", slog.String("user_email", userEmail))
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("getting user: %w", err)
+	}
+
+	if !user.HasTenant(tenantID) {
+		slog.Warn("user does not have permission to access tenant configuration",
+			slog.String("user_email", userEmail),
+			slog.String("tenant_id", tenantID.String()))
+		return ErrForbiddenTenantConfigurationAccess
+	}
+
+	return nil
+}
+
+func (s *SimpleTenantConfigurationService) HoldSchedules(ctx context.Context, userEmail string, tenant domain.Tenant, hold domain.SchedulesHold) (domain.TenantConfiguration, error) {
+	err := s.authorizeTenantAccess(ctx, userEmail, tenant.ID)
+	if err != nil {
+		return domain.TenantConfiguration{}, err
+	}
+
+	config, err := s.GetOrCreateTenantConfiguration(ctx, tenant, _defaultTimezone)
+	if err != nil {
+		return domain.TenantConfiguration{}, err
+	}
+
+	err = config.HoldSchedules(hold.Until, hold.Reason)
+	if err != nil {
+		return domain.TenantConfiguration{}, err
+	}
+
+	err = s.repository.Update(ctx, config)
+	if err != nil {
+		slog.Error("holding tenant schedules", slog.String("error", err.Error()))
+		return domain.TenantConfiguration{}, fmt.Errorf("holding tenant schedules: %w", err)
+	}
+
+	slog.Info("tenant schedules held",
+		slog.String("tenant_id", tenant.ID.String()),
+		slog.Time("until", hold.Until),
+		slog.String("reason", hold.Reason))
+
+	return config, nil
+}
+
+func (s *SimpleTenantConfigurationService) ReleaseSchedulesHold(ctx context.Context, userEmail string, tenant domain.Tenant) (domain.TenantConfiguration, error) {
+	err := s.authorizeTenantAccess(ctx, userEmail, tenant.ID)
+	if err != nil {
+		return domain.TenantConfiguration{}, err
+	}
+
+	config, err := s.GetTenantConfiguration(ctx, tenant)
+	if err != nil {
+		return domain.TenantConfiguration{}, err
+	}
+
+	config.ReleaseSchedulesHold()
+
+	err = s.repository.Update(ctx, config)
+	if err != nil {
+		slog.Error("releasing tenant schedules hold", slog.String("error", err.Error()))
+		return domain.TenantConfiguration{}, fmt.Errorf("releasing tenant schedules hold: %w", err)
+	}
+
+	slog.Info("tenant schedules hold released", slog.String("tenant_id", tenant.ID.String()))
+
+	return config, nil
+}
+
 func (s *SimpleTenantConfigurationService) GetTenantConfiguration(ctx context.Context, tenant domain.Tenant) (domain.TenantConfiguration, error) {
 	config, err := s.repository.GetByTenantID(ctx, tenant.ID)
 	if err != nil {
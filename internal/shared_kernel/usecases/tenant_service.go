@@ -5,9 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 	"zensor-server/internal/shared_kernel/domain"
 )
 
+const (
+	_fleetHealthPageSize       = 500
+	_fleetHealthWeakestDevices = 10
+)
+
 func NewTenantService(repository TenantRepository, deviceAdopter DeviceAdopter) *SimpleTenantService {
 	return &SimpleTenantService{
 		repository:    repository,
@@ -226,29 +232,53 @@ func (s *SimpleTenantService) AdoptDevice(ctx context.Context, tenantID, deviceI
 	return nil
 }
 
-func (s *SimpleTenantService) ListTenantDevices(ctx context.Context, tenantID domain.ID, pagination Pagination) ([]domain.Device, int, error) {
+func (s *SimpleTenantService) ListTenantDevices(ctx context.Context, tenantID domain.ID, query DeviceQuery) (DevicePage, error) {
 	// First verify that the tenant exists and is not soft deleted
 	tenant, err := s.repository.GetByID(ctx, tenantID)
 	if err != nil {
 		if errors.Is(err, ErrTenantNotFound) {
-			return nil, 0, ErrTenantNotFound
+			return DevicePage{}, ErrTenantNotFound
 		}
-		return nil, 0, fmt.Errorf("getting tenant: %w", err)
+		return DevicePage{}, fmt.Errorf("getting tenant: %w", err)
 	}
 
 	if tenant.IsDeleted() {
-		return nil, 0, ErrTenantSoftDeleted
+		return DevicePage{}, ErrTenantSoftDeleted
 	}
 
-	// Get devices belonging to this tenant
-	devices, total, err := s.deviceAdopter.DevicesByTenant(ctx, tenantID, pagination)
+	// Search only among the devices belonging to this tenant
+	query.Filter.TenantID = &tenantID
+	page, err := s.deviceAdopter.SearchDevices(ctx, query)
 	if err != nil {
-		return nil, 0, fmt.Errorf("getting devices for tenant: %w", err)
+		return DevicePage{}, fmt.Errorf("searching devices for tenant: %w", err)
 	}
 
 	slog.Info("retrieved devices for tenant",
 		slog.String("tenant_id", tenantID.String()),
-		slog.Int("device_count", len(devices)))
+		slog.Int("device_count", len(page.Devices)))
+
+	return page, nil
+}
+
+func (s *SimpleTenantService) FleetHealth(ctx context.Context, tenantID domain.ID) (domain.FleetHealth, error) {
+	query := DeviceQuery{
+		Filter: DeviceFilter{TenantID: &tenantID},
+		Limit:  _fleetHealthPageSize,
+	}
+
+	var devices []domain.Device
+	for {
+		page, err := s.ListTenantDevices(ctx, tenantID, query)
+		if err != nil {
+			return domain.FleetHealth{}, err
+		}
+
+		devices = append(devices, page.Devices...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
 
-	return devices, total, nil
+	return domain.SummarizeFleetHealth(devices, _fleetHealthWeakestDevices, time.Now()), nil
 }
@@ -9,6 +9,7 @@ import (
 	"zensor-server/internal/infra/async"
 	"zensor-server/internal/victron/dto"
 	"zensor-server/internal/victron/httpapi"
+	"zensor-server/internal/victron/usecases"
 
 	"github.com/onsi/ginkgo/v2"
 	"github.com/onsi/gomega"
@@ -48,10 +49,7 @@ var _ = ginkgo.Describe("VictronWebSocketController latest data endpoint", func(
 			Topic:       "N/d41243b4e8e4/" + serviceType + "/0/" + path,
 		}
 
-		err := broker.Publish(context.Background(), async.BrokerTopicName("victron_data"), async.BrokerMessage{
-			Event: serviceType + "_" + path,
-			Value: telemetry,
-		})
+		err := broker.Publish(context.Background(), async.BrokerTopicName("victron_data"), usecases.VictronTelemetryReceived.New("", telemetry).Message())
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	}
 
@@ -10,6 +10,7 @@ import (
 	"zensor-server/internal/infra/async"
 	"zensor-server/internal/victron/dto"
 	"zensor-server/internal/victron/httpapi"
+	"zensor-server/internal/victron/usecases"
 
 	"github.com/onsi/ginkgo/v2"
 	"github.com/onsi/gomega"
@@ -49,10 +50,7 @@ var _ = ginkgo.Describe("VictronWebSocketController summary endpoint", func() {
 			Topic:       "N/d41243b4e8e4/system/0/" + path,
 		}
 
-		err := broker.Publish(context.Background(), async.BrokerTopicName("victron_data"), async.BrokerMessage{
-			Event: "system_" + path,
-			Value: telemetry,
-		})
+		err := broker.Publish(context.Background(), async.BrokerTopicName("victron_data"), usecases.VictronTelemetryReceived.New("", telemetry).Message())
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	}
 
@@ -66,10 +64,7 @@ var _ = ginkgo.Describe("VictronWebSocketController summary endpoint", func() {
 			Topic:       "N/d41243b4e8e4/vebus/0/" + path,
 		}
 
-		err := broker.Publish(context.Background(), async.BrokerTopicName("victron_data"), async.BrokerMessage{
-			Event: "vebus_" + path,
-			Value: telemetry,
-		})
+		err := broker.Publish(context.Background(), async.BrokerTopicName("victron_data"), usecases.VictronTelemetryReceived.New("", telemetry).Message())
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	}
 
@@ -83,10 +78,7 @@ var _ = ginkgo.Describe("VictronWebSocketController summary endpoint", func() {
 			Topic:       fmt.Sprintf("N/d41243b4e8e4/battery/%d/%s", instance, path),
 		}
 
-		err := broker.Publish(context.Background(), async.BrokerTopicName("victron_data"), async.BrokerMessage{
-			Event: "battery_" + path,
-			Value: telemetry,
-		})
+		err := broker.Publish(context.Background(), async.BrokerTopicName("victron_data"), usecases.VictronTelemetryReceived.New("", telemetry).Message())
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	}
 
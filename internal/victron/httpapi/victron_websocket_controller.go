@@ -11,6 +11,7 @@ import (
 	"zensor-server/internal/infra/httpserver"
 
 	victrondto "zensor-server/internal/victron/dto"
+	"zensor-server/internal/victron/usecases"
 
 	"github.com/gorilla/websocket"
 )
@@ -267,8 +268,8 @@ func (wsc *VictronWebSocketController) run() {
 			})
 
 		case brokerMsg := <-subscription.Receiver:
-			if telemetry, ok := brokerMsg.Value.(victrondto.VictronTelemetry); ok {
-				wsc.handleTelemetryUpdate(telemetry)
+			if event, err := usecases.VictronTelemetryReceived.Decode(brokerMsg); err == nil {
+				wsc.handleTelemetryUpdate(event.Payload)
 			}
 		}
 	}
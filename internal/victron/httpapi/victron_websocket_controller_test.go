@@ -9,6 +9,7 @@ import (
 	"zensor-server/internal/infra/async"
 	"zensor-server/internal/victron/dto"
 	"zensor-server/internal/victron/httpapi"
+	"zensor-server/internal/victron/usecases"
 
 	"github.com/gorilla/websocket"
 	"github.com/onsi/ginkgo/v2"
@@ -49,10 +50,7 @@ var _ = ginkgo.Describe("VictronWebSocketController", func() {
 			Topic:       "N/d41243b4e8e4/system/0/" + path,
 		}
 
-		err := broker.Publish(context.Background(), async.BrokerTopicName("victron_data"), async.BrokerMessage{
-			Event: "system_" + path,
-			Value: telemetry,
-		})
+		err := broker.Publish(context.Background(), async.BrokerTopicName("victron_data"), usecases.VictronTelemetryReceived.New("", telemetry).Message())
 		gomega.Expect(err).NotTo(gomega.HaveOccurred())
 	}
 
@@ -117,10 +115,7 @@ var _ = ginkgo.Describe("VictronWebSocketController", func() {
 					Value:       dto.VictronValue{Value: 250},
 					Topic:       "N/d41243b4e8e4/acload/0/Ac/0/Power",
 				}
-				err = broker.Publish(context.Background(), async.BrokerTopicName("victron_data"), async.BrokerMessage{
-					Event: "acload_ac_0_power",
-					Value: telemetry,
-				})
+				err = broker.Publish(context.Background(), async.BrokerTopicName("victron_data"), usecases.VictronTelemetryReceived.New("", telemetry).Message())
 				gomega.Expect(err).NotTo(gomega.HaveOccurred())
 
 				var last httpapi.VictronSystemStatusMessage
@@ -170,10 +165,7 @@ var _ = ginkgo.Describe("VictronWebSocketController", func() {
 							Value:       dto.VictronValue{Value: 87},
 							Topic:       "N/d41243b4e8e4/system/0/Dc/Battery/Soc",
 						}
-						_ = pingBroker.Publish(context.Background(), async.BrokerTopicName("victron_data"), async.BrokerMessage{
-							Event: "system_battery_soc",
-							Value: telemetry,
-						})
+						_ = pingBroker.Publish(context.Background(), async.BrokerTopicName("victron_data"), usecases.VictronTelemetryReceived.New("", telemetry).Message())
 						time.Sleep(5 * time.Millisecond)
 					}
 				}()
@@ -46,7 +46,7 @@ var _ async.Worker = (*VictronMetricWorker)(nil)
 func (w *VictronMetricWorker) Run(ctx context.Context, done func()) {
 	defer done()
 
-	subscription, err := w.broker.Subscribe(BrokerTopicVictronData)
+	subscription, err := w.broker.Subscribe(BrokerTopicVictronData, VictronTelemetryReceived.Name)
 	if err != nil {
 		slog.Error("subscribing to victron metric topic", slog.Any("error", err))
 		return
@@ -83,10 +83,11 @@ func (w *VictronMetricWorker) Shutdown() {
 }
 
 func (w *VictronMetricWorker) handleMessage(msg async.BrokerMessage) {
-	telemetry, ok := msg.Value.(victrondto.VictronTelemetry)
-	if !ok {
+	event, err := VictronTelemetryReceived.Decode(msg)
+	if err != nil {
 		return
 	}
+	telemetry := event.Payload
 	if !telemetry.Value.IsNumeric() {
 		return
 	}
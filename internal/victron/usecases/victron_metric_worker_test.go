@@ -97,15 +97,13 @@ var _ = ginkgo.Describe("VictronMetricWorker", func() {
 	ginkgo.Context("when telemetry points arrive on the victron topic", func() {
 		ginkgo.When("a numeric battery voltage is published", func() {
 			ginkgo.It("should record a gauge named after the service type and path", func() {
-				gomega.Expect(broker.Publish(context.Background(), usecases.BrokerTopicVictronData, async.BrokerMessage{
-					Value: victrondto.VictronTelemetry{
-						PortalID:    "d41243b4e8e4",
-						ServiceType: "battery",
-						Instance:    512,
-						Path:        "Dc/0/Voltage",
-						Value:       victrondto.VictronValue{Value: 12.8, Numeric: true},
-					},
-				})).To(gomega.Succeed())
+				gomega.Expect(broker.Publish(context.Background(), usecases.BrokerTopicVictronData, usecases.VictronTelemetryReceived.New("", victrondto.VictronTelemetry{
+					PortalID:    "d41243b4e8e4",
+					ServiceType: "battery",
+					Instance:    512,
+					Path:        "Dc/0/Voltage",
+					Value:       victrondto.VictronValue{Value: 12.8, Numeric: true},
+				}).Message())).To(gomega.Succeed())
 
 				gomega.Eventually(func() (float64, error) {
 					value, ok := collectVictronGauge(context.Background(), reader, "zensor_server_victron_battery_dc_0_voltage")
@@ -117,15 +115,13 @@ var _ = ginkgo.Describe("VictronMetricWorker", func() {
 			})
 
 			ginkgo.It("should tag the gauge with portal id, service type, instance, and path", func() {
-				gomega.Expect(broker.Publish(context.Background(), usecases.BrokerTopicVictronData, async.BrokerMessage{
-					Value: victrondto.VictronTelemetry{
-						PortalID:    "d41243b4e8e4",
-						ServiceType: "battery",
-						Instance:    512,
-						Path:        "Soc",
-						Value:       victrondto.VictronValue{Value: 87, Numeric: true},
-					},
-				})).To(gomega.Succeed())
+				gomega.Expect(broker.Publish(context.Background(), usecases.BrokerTopicVictronData, usecases.VictronTelemetryReceived.New("", victrondto.VictronTelemetry{
+					PortalID:    "d41243b4e8e4",
+					ServiceType: "battery",
+					Instance:    512,
+					Path:        "Soc",
+					Value:       victrondto.VictronValue{Value: 87, Numeric: true},
+				}).Message())).To(gomega.Succeed())
 
 				gomega.Eventually(func() []attribute.KeyValue {
 					return victronGaugeAttributes(context.Background(), reader, "zensor_server_victron_battery_soc")
@@ -140,15 +136,13 @@ var _ = ginkgo.Describe("VictronMetricWorker", func() {
 
 		ginkgo.When("a text-only value such as a serial number is published", func() {
 			ginkgo.It("should not record a metric", func() {
-				gomega.Expect(broker.Publish(context.Background(), usecases.BrokerTopicVictronData, async.BrokerMessage{
-					Value: victrondto.VictronTelemetry{
-						PortalID:    "d41243b4e8e4",
-						ServiceType: "system",
-						Instance:    0,
-						Path:        "Serial",
-						Value:       victrondto.VictronValue{Text: "d41243b4e8e4"},
-					},
-				})).To(gomega.Succeed())
+				gomega.Expect(broker.Publish(context.Background(), usecases.BrokerTopicVictronData, usecases.VictronTelemetryReceived.New("", victrondto.VictronTelemetry{
+					PortalID:    "d41243b4e8e4",
+					ServiceType: "system",
+					Instance:    0,
+					Path:        "Serial",
+					Value:       victrondto.VictronValue{Text: "d41243b4e8e4"},
+				}).Message())).To(gomega.Succeed())
 
 				gomega.Consistently(func() bool {
 					_, ok := collectVictronGauge(context.Background(), reader, "zensor_server_victron_system_serial")
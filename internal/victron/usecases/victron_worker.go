@@ -31,6 +31,10 @@ const (
 	heartbeatTopic            = "heartbeat"
 )
 
+// VictronTelemetryReceived is published on BrokerTopicVictronData for every value read from the
+// GX device.
+var VictronTelemetryReceived = async.DefineEvent[victrondto.VictronTelemetry]("victron_telemetry", 1)
+
 func NewVictronWorker(
 	portalID string,
 	mqttClient mqtt.Client,
@@ -179,15 +183,7 @@ func (w *VictronWorker) handleMessage(_ mqtt.Client, msg mqtt.Message) {
 
 	w.snapshot.Update(telemetry)
 
-	eventName := fmt.Sprintf("%s_%s",
-		victrondto.ToSnakeName(telemetry.ServiceType),
-		normalizePath(telemetry.Path),
-	)
-
-	brokerMsg := async.BrokerMessage{
-		Event: eventName,
-		Value: telemetry,
-	}
+	brokerMsg := VictronTelemetryReceived.New("", telemetry).Message()
 
 	ctx := context.Background()
 	tracer := otel.Tracer("zensor_server")
@@ -198,7 +194,7 @@ func (w *VictronWorker) handleMessage(_ mqtt.Client, msg mqtt.Message) {
 
 	if err := w.broker.Publish(ctx, BrokerTopicVictronData, brokerMsg); err != nil {
 		slog.Error("publishing victron data to internal broker",
-			slog.String("event", eventName),
+			slog.String("path", telemetry.Path),
 			slog.Any("error", err),
 		)
 	}
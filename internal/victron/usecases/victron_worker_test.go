@@ -41,7 +41,11 @@ func (c *fakeMQTTClient) Subscribe(_ string, _ byte, callback mqtt.MessageHandle
 	return nil
 }
 
-func (c *fakeMQTTClient) Publish(_ context.Context, topic string, _ any) error {
+func (c *fakeMQTTClient) Unsubscribe(...string) error {
+	return nil
+}
+
+func (c *fakeMQTTClient) Publish(_ context.Context, topic string, _ any, _ ...mqtt.PublishOption) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	if strings.HasSuffix(topic, "/keepalive") {
@@ -12,7 +12,9 @@ package usecases
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 	usecases "zensor-server/internal/control_plane/usecases"
+	utils "zensor-server/internal/infra/utils"
 	domain "zensor-server/internal/shared_kernel/domain"
 
 	gomock "go.uber.org/mock/gomock"
@@ -73,11 +75,12 @@ func (mr *MockDeviceServiceMockRecorder) AllDevices(arg0, arg1 any) *gomock.Call
 }
 
 // CreateDevice mocks base method.
-func (m *MockDeviceService) CreateDevice(arg0 context.Context, arg1 domain.Device) error {
+func (m *MockDeviceService) CreateDevice(arg0 context.Context, arg1 domain.Device) (domain.Device, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateDevice", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(domain.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // CreateDevice indicates an expected call of CreateDevice.
@@ -86,22 +89,6 @@ func (mr *MockDeviceServiceMockRecorder) CreateDevice(arg0, arg1 any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDevice", reflect.TypeOf((*MockDeviceService)(nil).CreateDevice), arg0, arg1)
 }
 
-// DevicesByTenant mocks base method.
-func (m *MockDeviceService) DevicesByTenant(arg0 context.Context, arg1 domain.ID, arg2 usecases.Pagination) ([]domain.Device, int, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DevicesByTenant", arg0, arg1, arg2)
-	ret0, _ := ret[0].([]domain.Device)
-	ret1, _ := ret[1].(int)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
-}
-
-// DevicesByTenant indicates an expected call of DevicesByTenant.
-func (mr *MockDeviceServiceMockRecorder) DevicesByTenant(arg0, arg1, arg2 any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DevicesByTenant", reflect.TypeOf((*MockDeviceService)(nil).DevicesByTenant), arg0, arg1, arg2)
-}
-
 // GetDevice mocks base method.
 func (m *MockDeviceService) GetDevice(arg0 context.Context, arg1 domain.ID) (domain.Device, error) {
 	m.ctrl.T.Helper()
@@ -145,6 +132,35 @@ func (mr *MockDeviceServiceMockRecorder) QueueCommandSequence(arg0, arg1 any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueCommandSequence", reflect.TypeOf((*MockDeviceService)(nil).QueueCommandSequence), arg0, arg1)
 }
 
+// RecordBatteryReading mocks base method.
+func (m *MockDeviceService) RecordBatteryReading(ctx context.Context, deviceName string, voltage float64, reportedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordBatteryReading", ctx, deviceName, voltage, reportedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordBatteryReading indicates an expected call of RecordBatteryReading.
+func (mr *MockDeviceServiceMockRecorder) RecordBatteryReading(ctx, deviceName, voltage, reportedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordBatteryReading", reflect.TypeOf((*MockDeviceService)(nil).RecordBatteryReading), ctx, deviceName, voltage, reportedAt)
+}
+
+// SearchDevices mocks base method.
+func (m *MockDeviceService) SearchDevices(arg0 context.Context, arg1 usecases.DeviceQuery) (usecases.DevicePage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchDevices", arg0, arg1)
+	ret0, _ := ret[0].(usecases.DevicePage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchDevices indicates an expected call of SearchDevices.
+func (mr *MockDeviceServiceMockRecorder) SearchDevices(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchDevices", reflect.TypeOf((*MockDeviceService)(nil).SearchDevices), arg0, arg1)
+}
+
 // UpdateDeviceDisplayName mocks base method.
 func (m *MockDeviceService) UpdateDeviceDisplayName(arg0 context.Context, arg1 domain.ID, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -159,6 +175,48 @@ func (mr *MockDeviceServiceMockRecorder) UpdateDeviceDisplayName(arg0, arg1, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeviceDisplayName", reflect.TypeOf((*MockDeviceService)(nil).UpdateDeviceDisplayName), arg0, arg1, arg2)
 }
 
+// UpdateDeviceExpectedUplinkInterval mocks base method.
+func (m *MockDeviceService) UpdateDeviceExpectedUplinkInterval(arg0 context.Context, arg1 domain.ID, arg2 time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDeviceExpectedUplinkInterval", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDeviceExpectedUplinkInterval indicates an expected call of UpdateDeviceExpectedUplinkInterval.
+func (mr *MockDeviceServiceMockRecorder) UpdateDeviceExpectedUplinkInterval(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeviceExpectedUplinkInterval", reflect.TypeOf((*MockDeviceService)(nil).UpdateDeviceExpectedUplinkInterval), arg0, arg1, arg2)
+}
+
+// UpdateDeviceMetadata mocks base method.
+func (m *MockDeviceService) UpdateDeviceMetadata(arg0 context.Context, arg1 domain.ID, arg2 map[string]any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDeviceMetadata", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDeviceMetadata indicates an expected call of UpdateDeviceMetadata.
+func (mr *MockDeviceServiceMockRecorder) UpdateDeviceMetadata(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeviceMetadata", reflect.TypeOf((*MockDeviceService)(nil).UpdateDeviceMetadata), arg0, arg1, arg2)
+}
+
+// UpdateDeviceTags mocks base method.
+func (m *MockDeviceService) UpdateDeviceTags(arg0 context.Context, arg1 domain.ID, arg2 map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDeviceTags", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDeviceTags indicates an expected call of UpdateDeviceTags.
+func (mr *MockDeviceServiceMockRecorder) UpdateDeviceTags(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeviceTags", reflect.TypeOf((*MockDeviceService)(nil).UpdateDeviceTags), arg0, arg1, arg2)
+}
+
 // UpdateLastMessageReceivedAt mocks base method.
 func (m *MockDeviceService) UpdateLastMessageReceivedAt(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -173,6 +231,351 @@ func (mr *MockDeviceServiceMockRecorder) UpdateLastMessageReceivedAt(arg0, arg1
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastMessageReceivedAt", reflect.TypeOf((*MockDeviceService)(nil).UpdateLastMessageReceivedAt), arg0, arg1)
 }
 
+// MockDeviceLifecycleService is a mock of DeviceLifecycleService interface.
+type MockDeviceLifecycleService struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceLifecycleServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockDeviceLifecycleServiceMockRecorder is the mock recorder for MockDeviceLifecycleService.
+type MockDeviceLifecycleServiceMockRecorder struct {
+	mock *MockDeviceLifecycleService
+}
+
+// NewMockDeviceLifecycleService creates a new mock instance.
+func NewMockDeviceLifecycleService(ctrl *gomock.Controller) *MockDeviceLifecycleService {
+	mock := &MockDeviceLifecycleService{ctrl: ctrl}
+	mock.recorder = &MockDeviceLifecycleServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceLifecycleService) EXPECT() *MockDeviceLifecycleServiceMockRecorder {
+	return m.recorder
+}
+
+// Decommission mocks base method.
+func (m *MockDeviceLifecycleService) Decommission(ctx context.Context, deviceID domain.ID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decommission", ctx, deviceID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Decommission indicates an expected call of Decommission.
+func (mr *MockDeviceLifecycleServiceMockRecorder) Decommission(ctx, deviceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decommission", reflect.TypeOf((*MockDeviceLifecycleService)(nil).Decommission), ctx, deviceID)
+}
+
+// Release mocks base method.
+func (m *MockDeviceLifecycleService) Release(ctx context.Context, deviceID domain.ID) (domain.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, deviceID)
+	ret0, _ := ret[0].(domain.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockDeviceLifecycleServiceMockRecorder) Release(ctx, deviceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockDeviceLifecycleService)(nil).Release), ctx, deviceID)
+}
+
+// Transfer mocks base method.
+func (m *MockDeviceLifecycleService) Transfer(ctx context.Context, deviceID, tenantID domain.ID, mode usecases.DeviceTransferMode) (domain.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfer", ctx, deviceID, tenantID, mode)
+	ret0, _ := ret[0].(domain.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Transfer indicates an expected call of Transfer.
+func (mr *MockDeviceLifecycleServiceMockRecorder) Transfer(ctx, deviceID, tenantID, mode any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockDeviceLifecycleService)(nil).Transfer), ctx, deviceID, tenantID, mode)
+}
+
+// MockDeviceImportService is a mock of DeviceImportService interface.
+type MockDeviceImportService struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceImportServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockDeviceImportServiceMockRecorder is the mock recorder for MockDeviceImportService.
+type MockDeviceImportServiceMockRecorder struct {
+	mock *MockDeviceImportService
+}
+
+// NewMockDeviceImportService creates a new mock instance.
+func NewMockDeviceImportService(ctrl *gomock.Controller) *MockDeviceImportService {
+	mock := &MockDeviceImportService{ctrl: ctrl}
+	mock.recorder = &MockDeviceImportServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceImportService) EXPECT() *MockDeviceImportServiceMockRecorder {
+	return m.recorder
+}
+
+// Export mocks base method.
+func (m *MockDeviceImportService) Export(ctx context.Context, tenantID *domain.ID) ([]domain.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, tenantID)
+	ret0, _ := ret[0].([]domain.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockDeviceImportServiceMockRecorder) Export(ctx, tenantID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockDeviceImportService)(nil).Export), ctx, tenantID)
+}
+
+// Import mocks base method.
+func (m *MockDeviceImportService) Import(ctx context.Context, rows []usecases.DeviceImportRow, dryRun bool) (usecases.DeviceImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", ctx, rows, dryRun)
+	ret0, _ := ret[0].(usecases.DeviceImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockDeviceImportServiceMockRecorder) Import(ctx, rows, dryRun any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockDeviceImportService)(nil).Import), ctx, rows, dryRun)
+}
+
+// MockDeviceSessionService is a mock of DeviceSessionService interface.
+type MockDeviceSessionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceSessionServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockDeviceSessionServiceMockRecorder is the mock recorder for MockDeviceSessionService.
+type MockDeviceSessionServiceMockRecorder struct {
+	mock *MockDeviceSessionService
+}
+
+// NewMockDeviceSessionService creates a new mock instance.
+func NewMockDeviceSessionService(ctrl *gomock.Controller) *MockDeviceSessionService {
+	mock := &MockDeviceSessionService{ctrl: ctrl}
+	mock.recorder = &MockDeviceSessionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceSessionService) EXPECT() *MockDeviceSessionServiceMockRecorder {
+	return m.recorder
+}
+
+// LinkQuality mocks base method.
+func (m *MockDeviceSessionService) LinkQuality(ctx context.Context, deviceID domain.ID, pagination usecases.Pagination) (usecases.DeviceLinkQuality, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkQuality", ctx, deviceID, pagination)
+	ret0, _ := ret[0].(usecases.DeviceLinkQuality)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LinkQuality indicates an expected call of LinkQuality.
+func (mr *MockDeviceSessionServiceMockRecorder) LinkQuality(ctx, deviceID, pagination any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkQuality", reflect.TypeOf((*MockDeviceSessionService)(nil).LinkQuality), ctx, deviceID, pagination)
+}
+
+// RecordJoin mocks base method.
+func (m *MockDeviceSessionService) RecordJoin(ctx context.Context, deviceName, devAddr string, joinedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordJoin", ctx, deviceName, devAddr, joinedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordJoin indicates an expected call of RecordJoin.
+func (mr *MockDeviceSessionServiceMockRecorder) RecordJoin(ctx, deviceName, devAddr, joinedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordJoin", reflect.TypeOf((*MockDeviceSessionService)(nil).RecordJoin), ctx, deviceName, devAddr, joinedAt)
+}
+
+// RecordUplink mocks base method.
+func (m *MockDeviceSessionService) RecordUplink(ctx context.Context, deviceName string, sample domain.LinkQualitySample) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordUplink", ctx, deviceName, sample)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordUplink indicates an expected call of RecordUplink.
+func (mr *MockDeviceSessionServiceMockRecorder) RecordUplink(ctx, deviceName, sample any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordUplink", reflect.TypeOf((*MockDeviceSessionService)(nil).RecordUplink), ctx, deviceName, sample)
+}
+
+// MockDeviceConfigJobService is a mock of DeviceConfigJobService interface.
+type MockDeviceConfigJobService struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceConfigJobServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockDeviceConfigJobServiceMockRecorder is the mock recorder for MockDeviceConfigJobService.
+type MockDeviceConfigJobServiceMockRecorder struct {
+	mock *MockDeviceConfigJobService
+}
+
+// NewMockDeviceConfigJobService creates a new mock instance.
+func NewMockDeviceConfigJobService(ctrl *gomock.Controller) *MockDeviceConfigJobService {
+	mock := &MockDeviceConfigJobService{ctrl: ctrl}
+	mock.recorder = &MockDeviceConfigJobServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceConfigJobService) EXPECT() *MockDeviceConfigJobServiceMockRecorder {
+	return m.recorder
+}
+
+// Cancel mocks base method.
+func (m *MockDeviceConfigJobService) Cancel(ctx context.Context, deviceID, jobID domain.ID) (domain.DeviceConfigJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cancel", ctx, deviceID, jobID)
+	ret0, _ := ret[0].(domain.DeviceConfigJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Cancel indicates an expected call of Cancel.
+func (mr *MockDeviceConfigJobServiceMockRecorder) Cancel(ctx, deviceID, jobID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockDeviceConfigJobService)(nil).Cancel), ctx, deviceID, jobID)
+}
+
+// Create mocks base method.
+func (m *MockDeviceConfigJobService) Create(ctx context.Context, deviceID domain.ID, config []byte, fragmentSize int) (domain.DeviceConfigJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, deviceID, config, fragmentSize)
+	ret0, _ := ret[0].(domain.DeviceConfigJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockDeviceConfigJobServiceMockRecorder) Create(ctx, deviceID, config, fragmentSize any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDeviceConfigJobService)(nil).Create), ctx, deviceID, config, fragmentSize)
+}
+
+// FindAllByDevice mocks base method.
+func (m *MockDeviceConfigJobService) FindAllByDevice(ctx context.Context, deviceID domain.ID, pagination usecases.Pagination) ([]domain.DeviceConfigJob, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllByDevice", ctx, deviceID, pagination)
+	ret0, _ := ret[0].([]domain.DeviceConfigJob)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindAllByDevice indicates an expected call of FindAllByDevice.
+func (mr *MockDeviceConfigJobServiceMockRecorder) FindAllByDevice(ctx, deviceID, pagination any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByDevice", reflect.TypeOf((*MockDeviceConfigJobService)(nil).FindAllByDevice), ctx, deviceID, pagination)
+}
+
+// Get mocks base method.
+func (m *MockDeviceConfigJobService) Get(ctx context.Context, deviceID, jobID domain.ID) (domain.DeviceConfigJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, deviceID, jobID)
+	ret0, _ := ret[0].(domain.DeviceConfigJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockDeviceConfigJobServiceMockRecorder) Get(ctx, deviceID, jobID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDeviceConfigJobService)(nil).Get), ctx, deviceID, jobID)
+}
+
+// Resume mocks base method.
+func (m *MockDeviceConfigJobService) Resume(ctx context.Context, deviceID, jobID domain.ID) (domain.DeviceConfigJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resume", ctx, deviceID, jobID)
+	ret0, _ := ret[0].(domain.DeviceConfigJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resume indicates an expected call of Resume.
+func (mr *MockDeviceConfigJobServiceMockRecorder) Resume(ctx, deviceID, jobID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resume", reflect.TypeOf((*MockDeviceConfigJobService)(nil).Resume), ctx, deviceID, jobID)
+}
+
+// MockDeviceKeyService is a mock of DeviceKeyService interface.
+type MockDeviceKeyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceKeyServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockDeviceKeyServiceMockRecorder is the mock recorder for MockDeviceKeyService.
+type MockDeviceKeyServiceMockRecorder struct {
+	mock *MockDeviceKeyService
+}
+
+// NewMockDeviceKeyService creates a new mock instance.
+func NewMockDeviceKeyService(ctrl *gomock.Controller) *MockDeviceKeyService {
+	mock := &MockDeviceKeyService{ctrl: ctrl}
+	mock.recorder = &MockDeviceKeyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceKeyService) EXPECT() *MockDeviceKeyServiceMockRecorder {
+	return m.recorder
+}
+
+// KeyAccesses mocks base method.
+func (m *MockDeviceKeyService) KeyAccesses(ctx context.Context, deviceID domain.ID, pagination usecases.Pagination) ([]domain.DeviceKeyAccess, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "KeyAccesses", ctx, deviceID, pagination)
+	ret0, _ := ret[0].([]domain.DeviceKeyAccess)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// KeyAccesses indicates an expected call of KeyAccesses.
+func (mr *MockDeviceKeyServiceMockRecorder) KeyAccesses(ctx, deviceID, pagination any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "KeyAccesses", reflect.TypeOf((*MockDeviceKeyService)(nil).KeyAccesses), ctx, deviceID, pagination)
+}
+
+// RevealKeys mocks base method.
+func (m *MockDeviceKeyService) RevealKeys(ctx context.Context, deviceID, userID domain.ID, userEmail string) (domain.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevealKeys", ctx, deviceID, userID, userEmail)
+	ret0, _ := ret[0].(domain.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevealKeys indicates an expected call of RevealKeys.
+func (mr *MockDeviceKeyServiceMockRecorder) RevealKeys(ctx, deviceID, userID, userEmail any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevealKeys", reflect.TypeOf((*MockDeviceKeyService)(nil).RevealKeys), ctx, deviceID, userID, userEmail)
+}
+
 // MockEvaluationRuleService is a mock of EvaluationRuleService interface.
 type MockEvaluationRuleService struct {
 	ctrl     *gomock.Controller
@@ -379,6 +782,22 @@ func (mr *MockScheduledTaskServiceMockRecorder) FindAllByTenantAndDevice(arg0, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByTenantAndDevice", reflect.TypeOf((*MockScheduledTaskService)(nil).FindAllByTenantAndDevice), arg0, arg1, arg2, arg3)
 }
 
+// FindRuns mocks base method.
+func (m *MockScheduledTaskService) FindRuns(ctx context.Context, scheduledTaskID domain.ID, filter usecases.ScheduledTaskRunFilter, pagination usecases.Pagination) ([]domain.ScheduledTaskRun, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindRuns", ctx, scheduledTaskID, filter, pagination)
+	ret0, _ := ret[0].([]domain.ScheduledTaskRun)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindRuns indicates an expected call of FindRuns.
+func (mr *MockScheduledTaskServiceMockRecorder) FindRuns(ctx, scheduledTaskID, filter, pagination any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRuns", reflect.TypeOf((*MockScheduledTaskService)(nil).FindRuns), ctx, scheduledTaskID, filter, pagination)
+}
+
 // GetByID mocks base method.
 func (m *MockScheduledTaskService) GetByID(arg0 context.Context, arg1 domain.ID) (domain.ScheduledTask, error) {
 	m.ctrl.T.Helper()
@@ -394,6 +813,36 @@ func (mr *MockScheduledTaskServiceMockRecorder) GetByID(arg0, arg1 any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockScheduledTaskService)(nil).GetByID), arg0, arg1)
 }
 
+// Pause mocks base method.
+func (m *MockScheduledTaskService) Pause(arg0 context.Context, arg1 domain.ScheduledTask, arg2 *utils.Time) (domain.ScheduledTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pause", arg0, arg1, arg2)
+	ret0, _ := ret[0].(domain.ScheduledTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Pause indicates an expected call of Pause.
+func (mr *MockScheduledTaskServiceMockRecorder) Pause(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pause", reflect.TypeOf((*MockScheduledTaskService)(nil).Pause), arg0, arg1, arg2)
+}
+
+// Resume mocks base method.
+func (m *MockScheduledTaskService) Resume(arg0 context.Context, arg1 domain.ScheduledTask) (domain.ScheduledTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resume", arg0, arg1)
+	ret0, _ := ret[0].(domain.ScheduledTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resume indicates an expected call of Resume.
+func (mr *MockScheduledTaskServiceMockRecorder) Resume(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resume", reflect.TypeOf((*MockScheduledTaskService)(nil).Resume), arg0, arg1)
+}
+
 // Update mocks base method.
 func (m *MockScheduledTaskService) Update(arg0 context.Context, arg1 domain.ScheduledTask) error {
 	m.ctrl.T.Helper()
@@ -407,3 +856,228 @@ func (mr *MockScheduledTaskServiceMockRecorder) Update(arg0, arg1 any) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockScheduledTaskService)(nil).Update), arg0, arg1)
 }
+
+// MockCommandTemplateSetService is a mock of CommandTemplateSetService interface.
+type MockCommandTemplateSetService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommandTemplateSetServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockCommandTemplateSetServiceMockRecorder is the mock recorder for MockCommandTemplateSetService.
+type MockCommandTemplateSetServiceMockRecorder struct {
+	mock *MockCommandTemplateSetService
+}
+
+// NewMockCommandTemplateSetService creates a new mock instance.
+func NewMockCommandTemplateSetService(ctrl *gomock.Controller) *MockCommandTemplateSetService {
+	mock := &MockCommandTemplateSetService{ctrl: ctrl}
+	mock.recorder = &MockCommandTemplateSetServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommandTemplateSetService) EXPECT() *MockCommandTemplateSetServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockCommandTemplateSetService) Create(arg0 context.Context, arg1 domain.CommandTemplateSet) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockCommandTemplateSetServiceMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockCommandTemplateSetService)(nil).Create), arg0, arg1)
+}
+
+// Delete mocks base method.
+func (m *MockCommandTemplateSetService) Delete(arg0 context.Context, arg1 domain.ID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCommandTemplateSetServiceMockRecorder) Delete(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCommandTemplateSetService)(nil).Delete), arg0, arg1)
+}
+
+// FindAllByTenant mocks base method.
+func (m *MockCommandTemplateSetService) FindAllByTenant(arg0 context.Context, arg1 domain.ID, arg2 usecases.Pagination) ([]domain.CommandTemplateSet, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllByTenant", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]domain.CommandTemplateSet)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindAllByTenant indicates an expected call of FindAllByTenant.
+func (mr *MockCommandTemplateSetServiceMockRecorder) FindAllByTenant(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByTenant", reflect.TypeOf((*MockCommandTemplateSetService)(nil).FindAllByTenant), arg0, arg1, arg2)
+}
+
+// GetByID mocks base method.
+func (m *MockCommandTemplateSetService) GetByID(arg0 context.Context, arg1 domain.ID) (domain.CommandTemplateSet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1)
+	ret0, _ := ret[0].(domain.CommandTemplateSet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockCommandTemplateSetServiceMockRecorder) GetByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockCommandTemplateSetService)(nil).GetByID), arg0, arg1)
+}
+
+// Update mocks base method.
+func (m *MockCommandTemplateSetService) Update(arg0 context.Context, arg1 domain.CommandTemplateSet) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockCommandTemplateSetServiceMockRecorder) Update(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockCommandTemplateSetService)(nil).Update), arg0, arg1)
+}
+
+// MockWebhookService is a mock of WebhookService interface.
+type MockWebhookService struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockWebhookServiceMockRecorder is the mock recorder for MockWebhookService.
+type MockWebhookServiceMockRecorder struct {
+	mock *MockWebhookService
+}
+
+// NewMockWebhookService creates a new mock instance.
+func NewMockWebhookService(ctrl *gomock.Controller) *MockWebhookService {
+	mock := &MockWebhookService{ctrl: ctrl}
+	mock.recorder = &MockWebhookServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookService) EXPECT() *MockWebhookServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockWebhookService) Create(arg0 context.Context, arg1 domain.WebhookSubscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockWebhookServiceMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWebhookService)(nil).Create), arg0, arg1)
+}
+
+// Delete mocks base method.
+func (m *MockWebhookService) Delete(arg0 context.Context, arg1 domain.ID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockWebhookServiceMockRecorder) Delete(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockWebhookService)(nil).Delete), arg0, arg1)
+}
+
+// Deliveries mocks base method.
+func (m *MockWebhookService) Deliveries(ctx context.Context, subscriptionID domain.ID, filter usecases.WebhookDeliveryFilter, pagination usecases.Pagination) ([]domain.WebhookDelivery, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deliveries", ctx, subscriptionID, filter, pagination)
+	ret0, _ := ret[0].([]domain.WebhookDelivery)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Deliveries indicates an expected call of Deliveries.
+func (mr *MockWebhookServiceMockRecorder) Deliveries(ctx, subscriptionID, filter, pagination any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deliveries", reflect.TypeOf((*MockWebhookService)(nil).Deliveries), ctx, subscriptionID, filter, pagination)
+}
+
+// FindAllByTenant mocks base method.
+func (m *MockWebhookService) FindAllByTenant(arg0 context.Context, arg1 domain.ID, arg2 usecases.Pagination) ([]domain.WebhookSubscription, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllByTenant", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]domain.WebhookSubscription)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindAllByTenant indicates an expected call of FindAllByTenant.
+func (mr *MockWebhookServiceMockRecorder) FindAllByTenant(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByTenant", reflect.TypeOf((*MockWebhookService)(nil).FindAllByTenant), arg0, arg1, arg2)
+}
+
+// GetByID mocks base method.
+func (m *MockWebhookService) GetByID(arg0 context.Context, arg1 domain.ID) (domain.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1)
+	ret0, _ := ret[0].(domain.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockWebhookServiceMockRecorder) GetByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockWebhookService)(nil).GetByID), arg0, arg1)
+}
+
+// Redeliver mocks base method.
+func (m *MockWebhookService) Redeliver(ctx context.Context, subscriptionID, deliveryID domain.ID) (domain.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redeliver", ctx, subscriptionID, deliveryID)
+	ret0, _ := ret[0].(domain.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Redeliver indicates an expected call of Redeliver.
+func (mr *MockWebhookServiceMockRecorder) Redeliver(ctx, subscriptionID, deliveryID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeliver", reflect.TypeOf((*MockWebhookService)(nil).Redeliver), ctx, subscriptionID, deliveryID)
+}
+
+// Update mocks base method.
+func (m *MockWebhookService) Update(arg0 context.Context, arg1 domain.WebhookSubscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockWebhookServiceMockRecorder) Update(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockWebhookService)(nil).Update), arg0, arg1)
+}
@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: provisioning_ports.go
+//
+// Generated by this command:
+//
+//	mockgen -source=provisioning_ports.go -destination=../../../test/unit/doubles/control_plane/usecases/provisioning_ports_mock.go -package=usecases -mock_names=NetworkServerProvisioner=MockNetworkServerProvisioner
+//
+
+// Package usecases is a generated GoMock package.
+package usecases
+
+import (
+	context "context"
+	reflect "reflect"
+	domain "zensor-server/internal/shared_kernel/domain"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockNetworkServerProvisioner is a mock of NetworkServerProvisioner interface.
+type MockNetworkServerProvisioner struct {
+	ctrl     *gomock.Controller
+	recorder *MockNetworkServerProvisionerMockRecorder
+	isgomock struct{}
+}
+
+// MockNetworkServerProvisionerMockRecorder is the mock recorder for MockNetworkServerProvisioner.
+type MockNetworkServerProvisionerMockRecorder struct {
+	mock *MockNetworkServerProvisioner
+}
+
+// NewMockNetworkServerProvisioner creates a new mock instance.
+func NewMockNetworkServerProvisioner(ctrl *gomock.Controller) *MockNetworkServerProvisioner {
+	mock := &MockNetworkServerProvisioner{ctrl: ctrl}
+	mock.recorder = &MockNetworkServerProvisionerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNetworkServerProvisioner) EXPECT() *MockNetworkServerProvisionerMockRecorder {
+	return m.recorder
+}
+
+// Deprovision mocks base method.
+func (m *MockNetworkServerProvisioner) Deprovision(ctx context.Context, device domain.Device) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deprovision", ctx, device)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Deprovision indicates an expected call of Deprovision.
+func (mr *MockNetworkServerProvisionerMockRecorder) Deprovision(ctx, device any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deprovision", reflect.TypeOf((*MockNetworkServerProvisioner)(nil).Deprovision), ctx, device)
+}
+
+// Provision mocks base method.
+func (m *MockNetworkServerProvisioner) Provision(ctx context.Context, device domain.Device) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Provision", ctx, device)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Provision indicates an expected call of Provision.
+func (mr *MockNetworkServerProvisionerMockRecorder) Provision(ctx, device any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Provision", reflect.TypeOf((*MockNetworkServerProvisioner)(nil).Provision), ctx, device)
+}
@@ -3,7 +3,7 @@
 //
 // Generated by this command:
 //
-//	mockgen -source=repository_port.go -destination=../../../test/unit/doubles/control_plane/usecases/repository_port_mock.go -package=usecases -mock_names=DeviceRepository=MockDeviceRepository,CommandRepository=MockCommandRepository,EvaluationRuleRepository=MockEvaluationRuleRepository,TaskRepository=MockTaskRepository,ScheduledTaskRepository=MockScheduledTaskRepository
+//	mockgen -source=repository_port.go -destination=../../../test/unit/doubles/control_plane/usecases/repository_port_mock.go -package=usecases -mock_names=DeviceRepository=MockDeviceRepository,CommandRepository=MockCommandRepository,EvaluationRuleRepository=MockEvaluationRuleRepository,TaskRepository=MockTaskRepository,ScheduledTaskRepository=MockScheduledTaskRepository,ScheduledTaskRunRepository=MockScheduledTaskRunRepository,CommandTemplateSetRepository=MockCommandTemplateSetRepository,DeviceConnectivityRepository=MockDeviceConnectivityRepository,DeviceSessionRepository=MockDeviceSessionRepository,DeviceKeyAccessRepository=MockDeviceKeyAccessRepository,DeviceConfigJobRepository=MockDeviceConfigJobRepository,OutboxRepository=MockOutboxRepository,WebhookSubscriptionRepository=MockWebhookSubscriptionRepository,WebhookDeliveryRepository=MockWebhookDeliveryRepository,Transactor=MockTransactor
 //
 
 // Package usecases is a generated GoMock package.
@@ -12,6 +12,7 @@ package usecases
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 	usecases "zensor-server/internal/control_plane/usecases"
 	domain "zensor-server/internal/shared_kernel/domain"
 
@@ -70,6 +71,20 @@ func (mr *MockDeviceRepositoryMockRecorder) CreateDevice(arg0, arg1 any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDevice", reflect.TypeOf((*MockDeviceRepository)(nil).CreateDevice), arg0, arg1)
 }
 
+// CreateDevices mocks base method.
+func (m *MockDeviceRepository) CreateDevices(arg0 context.Context, arg1 []domain.Device) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDevices", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateDevices indicates an expected call of CreateDevices.
+func (mr *MockDeviceRepositoryMockRecorder) CreateDevices(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDevices", reflect.TypeOf((*MockDeviceRepository)(nil).CreateDevices), arg0, arg1)
+}
+
 // FindAll mocks base method.
 func (m *MockDeviceRepository) FindAll(arg0 context.Context, arg1 usecases.Pagination) ([]domain.Device, int, error) {
 	m.ctrl.T.Helper()
@@ -101,6 +116,21 @@ func (mr *MockDeviceRepositoryMockRecorder) FindAllEvaluationRules(arg0, arg1 an
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllEvaluationRules", reflect.TypeOf((*MockDeviceRepository)(nil).FindAllEvaluationRules), arg0, arg1)
 }
 
+// FindByDevEUI mocks base method.
+func (m *MockDeviceRepository) FindByDevEUI(arg0 context.Context, arg1 string) (domain.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByDevEUI", arg0, arg1)
+	ret0, _ := ret[0].(domain.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByDevEUI indicates an expected call of FindByDevEUI.
+func (mr *MockDeviceRepositoryMockRecorder) FindByDevEUI(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByDevEUI", reflect.TypeOf((*MockDeviceRepository)(nil).FindByDevEUI), arg0, arg1)
+}
+
 // FindByName mocks base method.
 func (m *MockDeviceRepository) FindByName(arg0 context.Context, arg1 string) (domain.Device, error) {
 	m.ctrl.T.Helper()
@@ -132,6 +162,36 @@ func (mr *MockDeviceRepositoryMockRecorder) FindByTenant(arg0, arg1, arg2 any) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByTenant", reflect.TypeOf((*MockDeviceRepository)(nil).FindByTenant), arg0, arg1, arg2)
 }
 
+// FindDevices mocks base method.
+func (m *MockDeviceRepository) FindDevices(arg0 context.Context, arg1 usecases.DeviceQuery) (usecases.DevicePage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDevices", arg0, arg1)
+	ret0, _ := ret[0].(usecases.DevicePage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDevices indicates an expected call of FindDevices.
+func (mr *MockDeviceRepositoryMockRecorder) FindDevices(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDevices", reflect.TypeOf((*MockDeviceRepository)(nil).FindDevices), arg0, arg1)
+}
+
+// FindDueForProvisioning mocks base method.
+func (m *MockDeviceRepository) FindDueForProvisioning(ctx context.Context, now time.Time, limit int) ([]domain.Device, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDueForProvisioning", ctx, now, limit)
+	ret0, _ := ret[0].([]domain.Device)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDueForProvisioning indicates an expected call of FindDueForProvisioning.
+func (mr *MockDeviceRepositoryMockRecorder) FindDueForProvisioning(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDueForProvisioning", reflect.TypeOf((*MockDeviceRepository)(nil).FindDueForProvisioning), ctx, now, limit)
+}
+
 // Get mocks base method.
 func (m *MockDeviceRepository) Get(arg0 context.Context, arg1 string) (domain.Device, error) {
 	m.ctrl.T.Helper()
@@ -147,6 +207,35 @@ func (mr *MockDeviceRepositoryMockRecorder) Get(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDeviceRepository)(nil).Get), arg0, arg1)
 }
 
+// ResealAppKeys mocks base method.
+func (m *MockDeviceRepository) ResealAppKeys(ctx context.Context, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResealAppKeys", ctx, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResealAppKeys indicates an expected call of ResealAppKeys.
+func (mr *MockDeviceRepositoryMockRecorder) ResealAppKeys(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResealAppKeys", reflect.TypeOf((*MockDeviceRepository)(nil).ResealAppKeys), ctx, limit)
+}
+
+// UpdateBattery mocks base method.
+func (m *MockDeviceRepository) UpdateBattery(arg0 context.Context, arg1 domain.Device) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBattery", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBattery indicates an expected call of UpdateBattery.
+func (mr *MockDeviceRepositoryMockRecorder) UpdateBattery(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBattery", reflect.TypeOf((*MockDeviceRepository)(nil).UpdateBattery), arg0, arg1)
+}
+
 // UpdateDevice mocks base method.
 func (m *MockDeviceRepository) UpdateDevice(arg0 context.Context, arg1 domain.Device) error {
 	m.ctrl.T.Helper()
@@ -161,6 +250,34 @@ func (mr *MockDeviceRepositoryMockRecorder) UpdateDevice(arg0, arg1 any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDevice", reflect.TypeOf((*MockDeviceRepository)(nil).UpdateDevice), arg0, arg1)
 }
 
+// UpdateHealth mocks base method.
+func (m *MockDeviceRepository) UpdateHealth(arg0 context.Context, arg1 domain.Device) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateHealth", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateHealth indicates an expected call of UpdateHealth.
+func (mr *MockDeviceRepositoryMockRecorder) UpdateHealth(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateHealth", reflect.TypeOf((*MockDeviceRepository)(nil).UpdateHealth), arg0, arg1)
+}
+
+// UpdateProvisioning mocks base method.
+func (m *MockDeviceRepository) UpdateProvisioning(arg0 context.Context, arg1 domain.Device) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProvisioning", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProvisioning indicates an expected call of UpdateProvisioning.
+func (mr *MockDeviceRepositoryMockRecorder) UpdateProvisioning(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProvisioning", reflect.TypeOf((*MockDeviceRepository)(nil).UpdateProvisioning), arg0, arg1)
+}
+
 // MockCommandRepository is a mock of CommandRepository interface.
 type MockCommandRepository struct {
 	ctrl     *gomock.Controller
@@ -185,6 +302,51 @@ func (m *MockCommandRepository) EXPECT() *MockCommandRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CancelPendingByDevice mocks base method.
+func (m *MockCommandRepository) CancelPendingByDevice(ctx context.Context, deviceID domain.ID, reason string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelPendingByDevice", ctx, deviceID, reason)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelPendingByDevice indicates an expected call of CancelPendingByDevice.
+func (mr *MockCommandRepositoryMockRecorder) CancelPendingByDevice(ctx, deviceID, reason any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelPendingByDevice", reflect.TypeOf((*MockCommandRepository)(nil).CancelPendingByDevice), ctx, deviceID, reason)
+}
+
+// ClaimReadyToDispatch mocks base method.
+func (m *MockCommandRepository) ClaimReadyToDispatch(ctx context.Context, claimer string, lease time.Duration) ([]domain.Command, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimReadyToDispatch", ctx, claimer, lease)
+	ret0, _ := ret[0].([]domain.Command)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimReadyToDispatch indicates an expected call of ClaimReadyToDispatch.
+func (mr *MockCommandRepositoryMockRecorder) ClaimReadyToDispatch(ctx, claimer, lease any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimReadyToDispatch", reflect.TypeOf((*MockCommandRepository)(nil).ClaimReadyToDispatch), ctx, claimer, lease)
+}
+
+// CountOutcomesByDevice mocks base method.
+func (m *MockCommandRepository) CountOutcomesByDevice(ctx context.Context, deviceID domain.ID, since time.Time) (domain.CommandOutcomes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountOutcomesByDevice", ctx, deviceID, since)
+	ret0, _ := ret[0].(domain.CommandOutcomes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountOutcomesByDevice indicates an expected call of CountOutcomesByDevice.
+func (mr *MockCommandRepositoryMockRecorder) CountOutcomesByDevice(ctx, deviceID, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountOutcomesByDevice", reflect.TypeOf((*MockCommandRepository)(nil).CountOutcomesByDevice), ctx, deviceID, since)
+}
+
 // Create mocks base method.
 func (m *MockCommandRepository) Create(arg0 context.Context, arg1 domain.Command) error {
 	m.ctrl.T.Helper()
@@ -214,21 +376,6 @@ func (mr *MockCommandRepositoryMockRecorder) FindAllPending(arg0 any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllPending", reflect.TypeOf((*MockCommandRepository)(nil).FindAllPending), arg0)
 }
 
-// FindAllReadyToDispatch mocks base method.
-func (m *MockCommandRepository) FindAllReadyToDispatch(arg0 context.Context) ([]domain.Command, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindAllReadyToDispatch", arg0)
-	ret0, _ := ret[0].([]domain.Command)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// FindAllReadyToDispatch indicates an expected call of FindAllReadyToDispatch.
-func (mr *MockCommandRepositoryMockRecorder) FindAllReadyToDispatch(arg0 any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllReadyToDispatch", reflect.TypeOf((*MockCommandRepository)(nil).FindAllReadyToDispatch), arg0)
-}
-
 // FindByTaskID mocks base method.
 func (m *MockCommandRepository) FindByTaskID(arg0 context.Context, arg1 domain.ID) ([]domain.Command, error) {
 	m.ctrl.T.Helper()
@@ -274,99 +421,74 @@ func (mr *MockCommandRepositoryMockRecorder) GetByID(arg0, arg1 any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockCommandRepository)(nil).GetByID), arg0, arg1)
 }
 
-// Update mocks base method.
-func (m *MockCommandRepository) Update(arg0 context.Context, arg1 domain.Command) error {
+// MarkSent mocks base method.
+func (m *MockCommandRepository) MarkSent(ctx context.Context, id domain.ID, sentAt time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret := m.ctrl.Call(m, "MarkSent", ctx, id, sentAt)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Update indicates an expected call of Update.
-func (mr *MockCommandRepositoryMockRecorder) Update(arg0, arg1 any) *gomock.Call {
+// MarkSent indicates an expected call of MarkSent.
+func (mr *MockCommandRepositoryMockRecorder) MarkSent(ctx, id, sentAt any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockCommandRepository)(nil).Update), arg0, arg1)
-}
-
-// MockEvaluationRuleRepository is a mock of EvaluationRuleRepository interface.
-type MockEvaluationRuleRepository struct {
-	ctrl     *gomock.Controller
-	recorder *MockEvaluationRuleRepositoryMockRecorder
-	isgomock struct{}
-}
-
-// MockEvaluationRuleRepositoryMockRecorder is the mock recorder for MockEvaluationRuleRepository.
-type MockEvaluationRuleRepositoryMockRecorder struct {
-	mock *MockEvaluationRuleRepository
-}
-
-// NewMockEvaluationRuleRepository creates a new mock instance.
-func NewMockEvaluationRuleRepository(ctrl *gomock.Controller) *MockEvaluationRuleRepository {
-	mock := &MockEvaluationRuleRepository{ctrl: ctrl}
-	mock.recorder = &MockEvaluationRuleRepositoryMockRecorder{mock}
-	return mock
-}
-
-// EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockEvaluationRuleRepository) EXPECT() *MockEvaluationRuleRepositoryMockRecorder {
-	return m.recorder
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkSent", reflect.TypeOf((*MockCommandRepository)(nil).MarkSent), ctx, id, sentAt)
 }
 
-// AddToDevice mocks base method.
-func (m *MockEvaluationRuleRepository) AddToDevice(arg0 context.Context, arg1 domain.Device, arg2 domain.EvaluationRule) error {
+// ReleaseClaim mocks base method.
+func (m *MockCommandRepository) ReleaseClaim(ctx context.Context, id domain.ID, claimer string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddToDevice", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "ReleaseClaim", ctx, id, claimer)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// AddToDevice indicates an expected call of AddToDevice.
-func (mr *MockEvaluationRuleRepositoryMockRecorder) AddToDevice(arg0, arg1, arg2 any) *gomock.Call {
+// ReleaseClaim indicates an expected call of ReleaseClaim.
+func (mr *MockCommandRepositoryMockRecorder) ReleaseClaim(ctx, id, claimer any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToDevice", reflect.TypeOf((*MockEvaluationRuleRepository)(nil).AddToDevice), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseClaim", reflect.TypeOf((*MockCommandRepository)(nil).ReleaseClaim), ctx, id, claimer)
 }
 
-// FindAllByDeviceID mocks base method.
-func (m *MockEvaluationRuleRepository) FindAllByDeviceID(ctx context.Context, deviceID string) ([]domain.EvaluationRule, error) {
+// Update mocks base method.
+func (m *MockCommandRepository) Update(arg0 context.Context, arg1 domain.Command) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindAllByDeviceID", ctx, deviceID)
-	ret0, _ := ret[0].([]domain.EvaluationRule)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// FindAllByDeviceID indicates an expected call of FindAllByDeviceID.
-func (mr *MockEvaluationRuleRepositoryMockRecorder) FindAllByDeviceID(ctx, deviceID any) *gomock.Call {
+// Update indicates an expected call of Update.
+func (mr *MockCommandRepositoryMockRecorder) Update(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByDeviceID", reflect.TypeOf((*MockEvaluationRuleRepository)(nil).FindAllByDeviceID), ctx, deviceID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockCommandRepository)(nil).Update), arg0, arg1)
 }
 
-// MockTaskRepository is a mock of TaskRepository interface.
-type MockTaskRepository struct {
+// MockDeviceConnectivityRepository is a mock of DeviceConnectivityRepository interface.
+type MockDeviceConnectivityRepository struct {
 	ctrl     *gomock.Controller
-	recorder *MockTaskRepositoryMockRecorder
+	recorder *MockDeviceConnectivityRepositoryMockRecorder
 	isgomock struct{}
 }
 
-// MockTaskRepositoryMockRecorder is the mock recorder for MockTaskRepository.
-type MockTaskRepositoryMockRecorder struct {
-	mock *MockTaskRepository
+// MockDeviceConnectivityRepositoryMockRecorder is the mock recorder for MockDeviceConnectivityRepository.
+type MockDeviceConnectivityRepositoryMockRecorder struct {
+	mock *MockDeviceConnectivityRepository
 }
 
-// NewMockTaskRepository creates a new mock instance.
-func NewMockTaskRepository(ctrl *gomock.Controller) *MockTaskRepository {
-	mock := &MockTaskRepository{ctrl: ctrl}
-	mock.recorder = &MockTaskRepositoryMockRecorder{mock}
+// NewMockDeviceConnectivityRepository creates a new mock instance.
+func NewMockDeviceConnectivityRepository(ctrl *gomock.Controller) *MockDeviceConnectivityRepository {
+	mock := &MockDeviceConnectivityRepository{ctrl: ctrl}
+	mock.recorder = &MockDeviceConnectivityRepositoryMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockTaskRepository) EXPECT() *MockTaskRepositoryMockRecorder {
+func (m *MockDeviceConnectivityRepository) EXPECT() *MockDeviceConnectivityRepositoryMockRecorder {
 	return m.recorder
 }
 
 // Create mocks base method.
-func (m *MockTaskRepository) Create(arg0 context.Context, arg1 domain.Task) error {
+func (m *MockDeviceConnectivityRepository) Create(arg0 context.Context, arg1 domain.DeviceConnectivityTransition) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Create", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -374,69 +496,68 @@ func (m *MockTaskRepository) Create(arg0 context.Context, arg1 domain.Task) erro
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockTaskRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+func (mr *MockDeviceConnectivityRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTaskRepository)(nil).Create), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDeviceConnectivityRepository)(nil).Create), arg0, arg1)
 }
 
 // FindAllByDevice mocks base method.
-func (m *MockTaskRepository) FindAllByDevice(ctx context.Context, device domain.Device, pagination usecases.Pagination) ([]domain.Task, int, error) {
+func (m *MockDeviceConnectivityRepository) FindAllByDevice(ctx context.Context, deviceID domain.ID, pagination usecases.Pagination) ([]domain.DeviceConnectivityTransition, int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindAllByDevice", ctx, device, pagination)
-	ret0, _ := ret[0].([]domain.Task)
+	ret := m.ctrl.Call(m, "FindAllByDevice", ctx, deviceID, pagination)
+	ret0, _ := ret[0].([]domain.DeviceConnectivityTransition)
 	ret1, _ := ret[1].(int)
 	ret2, _ := ret[2].(error)
 	return ret0, ret1, ret2
 }
 
 // FindAllByDevice indicates an expected call of FindAllByDevice.
-func (mr *MockTaskRepositoryMockRecorder) FindAllByDevice(ctx, device, pagination any) *gomock.Call {
+func (mr *MockDeviceConnectivityRepositoryMockRecorder) FindAllByDevice(ctx, deviceID, pagination any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByDevice", reflect.TypeOf((*MockTaskRepository)(nil).FindAllByDevice), ctx, device, pagination)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByDevice", reflect.TypeOf((*MockDeviceConnectivityRepository)(nil).FindAllByDevice), ctx, deviceID, pagination)
 }
 
-// FindAllByScheduledTask mocks base method.
-func (m *MockTaskRepository) FindAllByScheduledTask(ctx context.Context, scheduledTaskID domain.ID, pagination usecases.Pagination) ([]domain.Task, int, error) {
+// FindLatestByDevice mocks base method.
+func (m *MockDeviceConnectivityRepository) FindLatestByDevice(ctx context.Context, deviceID domain.ID) (domain.DeviceConnectivityTransition, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindAllByScheduledTask", ctx, scheduledTaskID, pagination)
-	ret0, _ := ret[0].([]domain.Task)
-	ret1, _ := ret[1].(int)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret := m.ctrl.Call(m, "FindLatestByDevice", ctx, deviceID)
+	ret0, _ := ret[0].(domain.DeviceConnectivityTransition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// FindAllByScheduledTask indicates an expected call of FindAllByScheduledTask.
-func (mr *MockTaskRepositoryMockRecorder) FindAllByScheduledTask(ctx, scheduledTaskID, pagination any) *gomock.Call {
+// FindLatestByDevice indicates an expected call of FindLatestByDevice.
+func (mr *MockDeviceConnectivityRepositoryMockRecorder) FindLatestByDevice(ctx, deviceID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByScheduledTask", reflect.TypeOf((*MockTaskRepository)(nil).FindAllByScheduledTask), ctx, scheduledTaskID, pagination)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindLatestByDevice", reflect.TypeOf((*MockDeviceConnectivityRepository)(nil).FindLatestByDevice), ctx, deviceID)
 }
 
-// MockScheduledTaskRepository is a mock of ScheduledTaskRepository interface.
-type MockScheduledTaskRepository struct {
+// MockDeviceConfigJobRepository is a mock of DeviceConfigJobRepository interface.
+type MockDeviceConfigJobRepository struct {
 	ctrl     *gomock.Controller
-	recorder *MockScheduledTaskRepositoryMockRecorder
+	recorder *MockDeviceConfigJobRepositoryMockRecorder
 	isgomock struct{}
 }
 
-// MockScheduledTaskRepositoryMockRecorder is the mock recorder for MockScheduledTaskRepository.
-type MockScheduledTaskRepositoryMockRecorder struct {
-	mock *MockScheduledTaskRepository
+// MockDeviceConfigJobRepositoryMockRecorder is the mock recorder for MockDeviceConfigJobRepository.
+type MockDeviceConfigJobRepositoryMockRecorder struct {
+	mock *MockDeviceConfigJobRepository
 }
 
-// NewMockScheduledTaskRepository creates a new mock instance.
-func NewMockScheduledTaskRepository(ctrl *gomock.Controller) *MockScheduledTaskRepository {
-	mock := &MockScheduledTaskRepository{ctrl: ctrl}
-	mock.recorder = &MockScheduledTaskRepositoryMockRecorder{mock}
+// NewMockDeviceConfigJobRepository creates a new mock instance.
+func NewMockDeviceConfigJobRepository(ctrl *gomock.Controller) *MockDeviceConfigJobRepository {
+	mock := &MockDeviceConfigJobRepository{ctrl: ctrl}
+	mock.recorder = &MockDeviceConfigJobRepositoryMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockScheduledTaskRepository) EXPECT() *MockScheduledTaskRepositoryMockRecorder {
+func (m *MockDeviceConfigJobRepository) EXPECT() *MockDeviceConfigJobRepositoryMockRecorder {
 	return m.recorder
 }
 
 // Create mocks base method.
-func (m *MockScheduledTaskRepository) Create(arg0 context.Context, arg1 domain.ScheduledTask) error {
+func (m *MockDeviceConfigJobRepository) Create(arg0 context.Context, arg1 domain.DeviceConfigJob) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Create", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -444,88 +565,74 @@ func (m *MockScheduledTaskRepository) Create(arg0 context.Context, arg1 domain.S
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockScheduledTaskRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockScheduledTaskRepository)(nil).Create), arg0, arg1)
-}
-
-// Delete mocks base method.
-func (m *MockScheduledTaskRepository) Delete(arg0 context.Context, arg1 domain.ID) error {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
-}
-
-// Delete indicates an expected call of Delete.
-func (mr *MockScheduledTaskRepositoryMockRecorder) Delete(arg0, arg1 any) *gomock.Call {
+func (mr *MockDeviceConfigJobRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockScheduledTaskRepository)(nil).Delete), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDeviceConfigJobRepository)(nil).Create), arg0, arg1)
 }
 
-// FindAllActive mocks base method.
-func (m *MockScheduledTaskRepository) FindAllActive(arg0 context.Context) ([]domain.ScheduledTask, error) {
+// FindActive mocks base method.
+func (m *MockDeviceConfigJobRepository) FindActive(ctx context.Context, limit int) ([]domain.DeviceConfigJob, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindAllActive", arg0)
-	ret0, _ := ret[0].([]domain.ScheduledTask)
+	ret := m.ctrl.Call(m, "FindActive", ctx, limit)
+	ret0, _ := ret[0].([]domain.DeviceConfigJob)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindAllActive indicates an expected call of FindAllActive.
-func (mr *MockScheduledTaskRepositoryMockRecorder) FindAllActive(arg0 any) *gomock.Call {
+// FindActive indicates an expected call of FindActive.
+func (mr *MockDeviceConfigJobRepositoryMockRecorder) FindActive(ctx, limit any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllActive", reflect.TypeOf((*MockScheduledTaskRepository)(nil).FindAllActive), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindActive", reflect.TypeOf((*MockDeviceConfigJobRepository)(nil).FindActive), ctx, limit)
 }
 
-// FindAllByTenant mocks base method.
-func (m *MockScheduledTaskRepository) FindAllByTenant(arg0 context.Context, arg1 domain.ID) ([]domain.ScheduledTask, error) {
+// FindActiveByDevice mocks base method.
+func (m *MockDeviceConfigJobRepository) FindActiveByDevice(ctx context.Context, deviceID domain.ID) ([]domain.DeviceConfigJob, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindAllByTenant", arg0, arg1)
-	ret0, _ := ret[0].([]domain.ScheduledTask)
+	ret := m.ctrl.Call(m, "FindActiveByDevice", ctx, deviceID)
+	ret0, _ := ret[0].([]domain.DeviceConfigJob)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FindAllByTenant indicates an expected call of FindAllByTenant.
-func (mr *MockScheduledTaskRepositoryMockRecorder) FindAllByTenant(arg0, arg1 any) *gomock.Call {
+// FindActiveByDevice indicates an expected call of FindActiveByDevice.
+func (mr *MockDeviceConfigJobRepositoryMockRecorder) FindActiveByDevice(ctx, deviceID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByTenant", reflect.TypeOf((*MockScheduledTaskRepository)(nil).FindAllByTenant), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindActiveByDevice", reflect.TypeOf((*MockDeviceConfigJobRepository)(nil).FindActiveByDevice), ctx, deviceID)
 }
 
-// FindAllByTenantAndDevice mocks base method.
-func (m *MockScheduledTaskRepository) FindAllByTenantAndDevice(arg0 context.Context, arg1, arg2 domain.ID, arg3 usecases.Pagination) ([]domain.ScheduledTask, int, error) {
+// FindAllByDevice mocks base method.
+func (m *MockDeviceConfigJobRepository) FindAllByDevice(ctx context.Context, deviceID domain.ID, pagination usecases.Pagination) ([]domain.DeviceConfigJob, int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindAllByTenantAndDevice", arg0, arg1, arg2, arg3)
-	ret0, _ := ret[0].([]domain.ScheduledTask)
+	ret := m.ctrl.Call(m, "FindAllByDevice", ctx, deviceID, pagination)
+	ret0, _ := ret[0].([]domain.DeviceConfigJob)
 	ret1, _ := ret[1].(int)
 	ret2, _ := ret[2].(error)
 	return ret0, ret1, ret2
 }
 
-// FindAllByTenantAndDevice indicates an expected call of FindAllByTenantAndDevice.
-func (mr *MockScheduledTaskRepositoryMockRecorder) FindAllByTenantAndDevice(arg0, arg1, arg2, arg3 any) *gomock.Call {
+// FindAllByDevice indicates an expected call of FindAllByDevice.
+func (mr *MockDeviceConfigJobRepositoryMockRecorder) FindAllByDevice(ctx, deviceID, pagination any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByTenantAndDevice", reflect.TypeOf((*MockScheduledTaskRepository)(nil).FindAllByTenantAndDevice), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByDevice", reflect.TypeOf((*MockDeviceConfigJobRepository)(nil).FindAllByDevice), ctx, deviceID, pagination)
 }
 
-// GetByID mocks base method.
-func (m *MockScheduledTaskRepository) GetByID(arg0 context.Context, arg1 domain.ID) (domain.ScheduledTask, error) {
+// Get mocks base method.
+func (m *MockDeviceConfigJobRepository) Get(ctx context.Context, id domain.ID) (domain.DeviceConfigJob, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByID", arg0, arg1)
-	ret0, _ := ret[0].(domain.ScheduledTask)
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(domain.DeviceConfigJob)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetByID indicates an expected call of GetByID.
-func (mr *MockScheduledTaskRepositoryMockRecorder) GetByID(arg0, arg1 any) *gomock.Call {
+// Get indicates an expected call of Get.
+func (mr *MockDeviceConfigJobRepositoryMockRecorder) Get(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockScheduledTaskRepository)(nil).GetByID), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockDeviceConfigJobRepository)(nil).Get), ctx, id)
 }
 
 // Update mocks base method.
-func (m *MockScheduledTaskRepository) Update(arg0 context.Context, arg1 domain.ScheduledTask) error {
+func (m *MockDeviceConfigJobRepository) Update(arg0 context.Context, arg1 domain.DeviceConfigJob) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Update", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -533,7 +640,1020 @@ func (m *MockScheduledTaskRepository) Update(arg0 context.Context, arg1 domain.S
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockScheduledTaskRepositoryMockRecorder) Update(arg0, arg1 any) *gomock.Call {
+func (mr *MockDeviceConfigJobRepositoryMockRecorder) Update(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockScheduledTaskRepository)(nil).Update), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockDeviceConfigJobRepository)(nil).Update), arg0, arg1)
+}
+
+// MockDeviceKeyAccessRepository is a mock of DeviceKeyAccessRepository interface.
+type MockDeviceKeyAccessRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceKeyAccessRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockDeviceKeyAccessRepositoryMockRecorder is the mock recorder for MockDeviceKeyAccessRepository.
+type MockDeviceKeyAccessRepositoryMockRecorder struct {
+	mock *MockDeviceKeyAccessRepository
+}
+
+// NewMockDeviceKeyAccessRepository creates a new mock instance.
+func NewMockDeviceKeyAccessRepository(ctrl *gomock.Controller) *MockDeviceKeyAccessRepository {
+	mock := &MockDeviceKeyAccessRepository{ctrl: ctrl}
+	mock.recorder = &MockDeviceKeyAccessRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceKeyAccessRepository) EXPECT() *MockDeviceKeyAccessRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockDeviceKeyAccessRepository) Create(arg0 context.Context, arg1 domain.DeviceKeyAccess) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockDeviceKeyAccessRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockDeviceKeyAccessRepository)(nil).Create), arg0, arg1)
+}
+
+// FindAllByDevice mocks base method.
+func (m *MockDeviceKeyAccessRepository) FindAllByDevice(ctx context.Context, deviceID domain.ID, pagination usecases.Pagination) ([]domain.DeviceKeyAccess, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllByDevice", ctx, deviceID, pagination)
+	ret0, _ := ret[0].([]domain.DeviceKeyAccess)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindAllByDevice indicates an expected call of FindAllByDevice.
+func (mr *MockDeviceKeyAccessRepositoryMockRecorder) FindAllByDevice(ctx, deviceID, pagination any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByDevice", reflect.TypeOf((*MockDeviceKeyAccessRepository)(nil).FindAllByDevice), ctx, deviceID, pagination)
+}
+
+// MockDeviceSessionRepository is a mock of DeviceSessionRepository interface.
+type MockDeviceSessionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeviceSessionRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockDeviceSessionRepositoryMockRecorder is the mock recorder for MockDeviceSessionRepository.
+type MockDeviceSessionRepositoryMockRecorder struct {
+	mock *MockDeviceSessionRepository
+}
+
+// NewMockDeviceSessionRepository creates a new mock instance.
+func NewMockDeviceSessionRepository(ctrl *gomock.Controller) *MockDeviceSessionRepository {
+	mock := &MockDeviceSessionRepository{ctrl: ctrl}
+	mock.recorder = &MockDeviceSessionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeviceSessionRepository) EXPECT() *MockDeviceSessionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateSample mocks base method.
+func (m *MockDeviceSessionRepository) CreateSample(arg0 context.Context, arg1 domain.LinkQualitySample) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSample", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateSample indicates an expected call of CreateSample.
+func (mr *MockDeviceSessionRepositoryMockRecorder) CreateSample(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSample", reflect.TypeOf((*MockDeviceSessionRepository)(nil).CreateSample), arg0, arg1)
+}
+
+// FindSamplesByDevice mocks base method.
+func (m *MockDeviceSessionRepository) FindSamplesByDevice(ctx context.Context, deviceID domain.ID, pagination usecases.Pagination) ([]domain.LinkQualitySample, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSamplesByDevice", ctx, deviceID, pagination)
+	ret0, _ := ret[0].([]domain.LinkQualitySample)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindSamplesByDevice indicates an expected call of FindSamplesByDevice.
+func (mr *MockDeviceSessionRepositoryMockRecorder) FindSamplesByDevice(ctx, deviceID, pagination any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSamplesByDevice", reflect.TypeOf((*MockDeviceSessionRepository)(nil).FindSamplesByDevice), ctx, deviceID, pagination)
+}
+
+// FindSamplesSince mocks base method.
+func (m *MockDeviceSessionRepository) FindSamplesSince(ctx context.Context, deviceID domain.ID, since time.Time, limit int) ([]domain.LinkQualitySample, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSamplesSince", ctx, deviceID, since, limit)
+	ret0, _ := ret[0].([]domain.LinkQualitySample)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindSamplesSince indicates an expected call of FindSamplesSince.
+func (mr *MockDeviceSessionRepositoryMockRecorder) FindSamplesSince(ctx, deviceID, since, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSamplesSince", reflect.TypeOf((*MockDeviceSessionRepository)(nil).FindSamplesSince), ctx, deviceID, since, limit)
+}
+
+// GetSession mocks base method.
+func (m *MockDeviceSessionRepository) GetSession(ctx context.Context, deviceID domain.ID) (domain.DeviceSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", ctx, deviceID)
+	ret0, _ := ret[0].(domain.DeviceSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockDeviceSessionRepositoryMockRecorder) GetSession(ctx, deviceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockDeviceSessionRepository)(nil).GetSession), ctx, deviceID)
+}
+
+// SaveSession mocks base method.
+func (m *MockDeviceSessionRepository) SaveSession(arg0 context.Context, arg1 domain.DeviceSession) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSession", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSession indicates an expected call of SaveSession.
+func (mr *MockDeviceSessionRepositoryMockRecorder) SaveSession(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSession", reflect.TypeOf((*MockDeviceSessionRepository)(nil).SaveSession), arg0, arg1)
+}
+
+// MockEvaluationRuleRepository is a mock of EvaluationRuleRepository interface.
+type MockEvaluationRuleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEvaluationRuleRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockEvaluationRuleRepositoryMockRecorder is the mock recorder for MockEvaluationRuleRepository.
+type MockEvaluationRuleRepositoryMockRecorder struct {
+	mock *MockEvaluationRuleRepository
+}
+
+// NewMockEvaluationRuleRepository creates a new mock instance.
+func NewMockEvaluationRuleRepository(ctrl *gomock.Controller) *MockEvaluationRuleRepository {
+	mock := &MockEvaluationRuleRepository{ctrl: ctrl}
+	mock.recorder = &MockEvaluationRuleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEvaluationRuleRepository) EXPECT() *MockEvaluationRuleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddToDevice mocks base method.
+func (m *MockEvaluationRuleRepository) AddToDevice(arg0 context.Context, arg1 domain.Device, arg2 domain.EvaluationRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddToDevice", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddToDevice indicates an expected call of AddToDevice.
+func (mr *MockEvaluationRuleRepositoryMockRecorder) AddToDevice(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToDevice", reflect.TypeOf((*MockEvaluationRuleRepository)(nil).AddToDevice), arg0, arg1, arg2)
+}
+
+// DisableAllByDeviceID mocks base method.
+func (m *MockEvaluationRuleRepository) DisableAllByDeviceID(ctx context.Context, deviceID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableAllByDeviceID", ctx, deviceID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableAllByDeviceID indicates an expected call of DisableAllByDeviceID.
+func (mr *MockEvaluationRuleRepositoryMockRecorder) DisableAllByDeviceID(ctx, deviceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableAllByDeviceID", reflect.TypeOf((*MockEvaluationRuleRepository)(nil).DisableAllByDeviceID), ctx, deviceID)
+}
+
+// FindAllByDeviceID mocks base method.
+func (m *MockEvaluationRuleRepository) FindAllByDeviceID(ctx context.Context, deviceID string) ([]domain.EvaluationRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllByDeviceID", ctx, deviceID)
+	ret0, _ := ret[0].([]domain.EvaluationRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllByDeviceID indicates an expected call of FindAllByDeviceID.
+func (mr *MockEvaluationRuleRepositoryMockRecorder) FindAllByDeviceID(ctx, deviceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByDeviceID", reflect.TypeOf((*MockEvaluationRuleRepository)(nil).FindAllByDeviceID), ctx, deviceID)
+}
+
+// MockTaskRepository is a mock of TaskRepository interface.
+type MockTaskRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTaskRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockTaskRepositoryMockRecorder is the mock recorder for MockTaskRepository.
+type MockTaskRepositoryMockRecorder struct {
+	mock *MockTaskRepository
+}
+
+// NewMockTaskRepository creates a new mock instance.
+func NewMockTaskRepository(ctrl *gomock.Controller) *MockTaskRepository {
+	mock := &MockTaskRepository{ctrl: ctrl}
+	mock.recorder = &MockTaskRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTaskRepository) EXPECT() *MockTaskRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockTaskRepository) Create(arg0 context.Context, arg1 domain.Task) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockTaskRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockTaskRepository)(nil).Create), arg0, arg1)
+}
+
+// FindAllByDevice mocks base method.
+func (m *MockTaskRepository) FindAllByDevice(ctx context.Context, device domain.Device, pagination usecases.Pagination) ([]domain.Task, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllByDevice", ctx, device, pagination)
+	ret0, _ := ret[0].([]domain.Task)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindAllByDevice indicates an expected call of FindAllByDevice.
+func (mr *MockTaskRepositoryMockRecorder) FindAllByDevice(ctx, device, pagination any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByDevice", reflect.TypeOf((*MockTaskRepository)(nil).FindAllByDevice), ctx, device, pagination)
+}
+
+// FindAllByScheduledTask mocks base method.
+func (m *MockTaskRepository) FindAllByScheduledTask(ctx context.Context, scheduledTaskID domain.ID, pagination usecases.Pagination) ([]domain.Task, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllByScheduledTask", ctx, scheduledTaskID, pagination)
+	ret0, _ := ret[0].([]domain.Task)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindAllByScheduledTask indicates an expected call of FindAllByScheduledTask.
+func (mr *MockTaskRepositoryMockRecorder) FindAllByScheduledTask(ctx, scheduledTaskID, pagination any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByScheduledTask", reflect.TypeOf((*MockTaskRepository)(nil).FindAllByScheduledTask), ctx, scheduledTaskID, pagination)
+}
+
+// MockScheduledTaskRepository is a mock of ScheduledTaskRepository interface.
+type MockScheduledTaskRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduledTaskRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockScheduledTaskRepositoryMockRecorder is the mock recorder for MockScheduledTaskRepository.
+type MockScheduledTaskRepositoryMockRecorder struct {
+	mock *MockScheduledTaskRepository
+}
+
+// NewMockScheduledTaskRepository creates a new mock instance.
+func NewMockScheduledTaskRepository(ctrl *gomock.Controller) *MockScheduledTaskRepository {
+	mock := &MockScheduledTaskRepository{ctrl: ctrl}
+	mock.recorder = &MockScheduledTaskRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduledTaskRepository) EXPECT() *MockScheduledTaskRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CountByCommandTemplateSet mocks base method.
+func (m *MockScheduledTaskRepository) CountByCommandTemplateSet(ctx context.Context, setID domain.ID) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByCommandTemplateSet", ctx, setID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByCommandTemplateSet indicates an expected call of CountByCommandTemplateSet.
+func (mr *MockScheduledTaskRepositoryMockRecorder) CountByCommandTemplateSet(ctx, setID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByCommandTemplateSet", reflect.TypeOf((*MockScheduledTaskRepository)(nil).CountByCommandTemplateSet), ctx, setID)
+}
+
+// Create mocks base method.
+func (m *MockScheduledTaskRepository) Create(arg0 context.Context, arg1 domain.ScheduledTask) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockScheduledTaskRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockScheduledTaskRepository)(nil).Create), arg0, arg1)
+}
+
+// Delete mocks base method.
+func (m *MockScheduledTaskRepository) Delete(arg0 context.Context, arg1 domain.ID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockScheduledTaskRepositoryMockRecorder) Delete(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockScheduledTaskRepository)(nil).Delete), arg0, arg1)
+}
+
+// FindAllActive mocks base method.
+func (m *MockScheduledTaskRepository) FindAllActive(arg0 context.Context) ([]domain.ScheduledTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllActive", arg0)
+	ret0, _ := ret[0].([]domain.ScheduledTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllActive indicates an expected call of FindAllActive.
+func (mr *MockScheduledTaskRepositoryMockRecorder) FindAllActive(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllActive", reflect.TypeOf((*MockScheduledTaskRepository)(nil).FindAllActive), arg0)
+}
+
+// FindAllByDevice mocks base method.
+func (m *MockScheduledTaskRepository) FindAllByDevice(ctx context.Context, deviceID domain.ID) ([]domain.ScheduledTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllByDevice", ctx, deviceID)
+	ret0, _ := ret[0].([]domain.ScheduledTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllByDevice indicates an expected call of FindAllByDevice.
+func (mr *MockScheduledTaskRepositoryMockRecorder) FindAllByDevice(ctx, deviceID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByDevice", reflect.TypeOf((*MockScheduledTaskRepository)(nil).FindAllByDevice), ctx, deviceID)
+}
+
+// FindAllByTenant mocks base method.
+func (m *MockScheduledTaskRepository) FindAllByTenant(arg0 context.Context, arg1 domain.ID) ([]domain.ScheduledTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllByTenant", arg0, arg1)
+	ret0, _ := ret[0].([]domain.ScheduledTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAllByTenant indicates an expected call of FindAllByTenant.
+func (mr *MockScheduledTaskRepositoryMockRecorder) FindAllByTenant(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByTenant", reflect.TypeOf((*MockScheduledTaskRepository)(nil).FindAllByTenant), arg0, arg1)
+}
+
+// FindAllByTenantAndDevice mocks base method.
+func (m *MockScheduledTaskRepository) FindAllByTenantAndDevice(arg0 context.Context, arg1, arg2 domain.ID, arg3 usecases.Pagination) ([]domain.ScheduledTask, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllByTenantAndDevice", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]domain.ScheduledTask)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindAllByTenantAndDevice indicates an expected call of FindAllByTenantAndDevice.
+func (mr *MockScheduledTaskRepositoryMockRecorder) FindAllByTenantAndDevice(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByTenantAndDevice", reflect.TypeOf((*MockScheduledTaskRepository)(nil).FindAllByTenantAndDevice), arg0, arg1, arg2, arg3)
+}
+
+// GetByID mocks base method.
+func (m *MockScheduledTaskRepository) GetByID(arg0 context.Context, arg1 domain.ID) (domain.ScheduledTask, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1)
+	ret0, _ := ret[0].(domain.ScheduledTask)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockScheduledTaskRepositoryMockRecorder) GetByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockScheduledTaskRepository)(nil).GetByID), arg0, arg1)
+}
+
+// RecordExecution mocks base method.
+func (m *MockScheduledTaskRepository) RecordExecution(ctx context.Context, scheduledTask domain.ScheduledTask, event domain.OutboxEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordExecution", ctx, scheduledTask, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordExecution indicates an expected call of RecordExecution.
+func (mr *MockScheduledTaskRepositoryMockRecorder) RecordExecution(ctx, scheduledTask, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordExecution", reflect.TypeOf((*MockScheduledTaskRepository)(nil).RecordExecution), ctx, scheduledTask, event)
+}
+
+// Update mocks base method.
+func (m *MockScheduledTaskRepository) Update(arg0 context.Context, arg1 domain.ScheduledTask) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockScheduledTaskRepositoryMockRecorder) Update(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockScheduledTaskRepository)(nil).Update), arg0, arg1)
+}
+
+// MockScheduledTaskRunRepository is a mock of ScheduledTaskRunRepository interface.
+type MockScheduledTaskRunRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockScheduledTaskRunRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockScheduledTaskRunRepositoryMockRecorder is the mock recorder for MockScheduledTaskRunRepository.
+type MockScheduledTaskRunRepositoryMockRecorder struct {
+	mock *MockScheduledTaskRunRepository
+}
+
+// NewMockScheduledTaskRunRepository creates a new mock instance.
+func NewMockScheduledTaskRunRepository(ctrl *gomock.Controller) *MockScheduledTaskRunRepository {
+	mock := &MockScheduledTaskRunRepository{ctrl: ctrl}
+	mock.recorder = &MockScheduledTaskRunRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScheduledTaskRunRepository) EXPECT() *MockScheduledTaskRunRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockScheduledTaskRunRepository) Create(arg0 context.Context, arg1 domain.ScheduledTaskRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockScheduledTaskRunRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockScheduledTaskRunRepository)(nil).Create), arg0, arg1)
+}
+
+// FindAllByScheduledTask mocks base method.
+func (m *MockScheduledTaskRunRepository) FindAllByScheduledTask(ctx context.Context, scheduledTaskID domain.ID, filter usecases.ScheduledTaskRunFilter, pagination usecases.Pagination) ([]domain.ScheduledTaskRun, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllByScheduledTask", ctx, scheduledTaskID, filter, pagination)
+	ret0, _ := ret[0].([]domain.ScheduledTaskRun)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindAllByScheduledTask indicates an expected call of FindAllByScheduledTask.
+func (mr *MockScheduledTaskRunRepositoryMockRecorder) FindAllByScheduledTask(ctx, scheduledTaskID, filter, pagination any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByScheduledTask", reflect.TypeOf((*MockScheduledTaskRunRepository)(nil).FindAllByScheduledTask), ctx, scheduledTaskID, filter, pagination)
+}
+
+// ReassignTenant mocks base method.
+func (m *MockScheduledTaskRunRepository) ReassignTenant(ctx context.Context, scheduledTaskID, tenantID domain.ID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReassignTenant", ctx, scheduledTaskID, tenantID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReassignTenant indicates an expected call of ReassignTenant.
+func (mr *MockScheduledTaskRunRepositoryMockRecorder) ReassignTenant(ctx, scheduledTaskID, tenantID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReassignTenant", reflect.TypeOf((*MockScheduledTaskRunRepository)(nil).ReassignTenant), ctx, scheduledTaskID, tenantID)
+}
+
+// MockCommandTemplateSetRepository is a mock of CommandTemplateSetRepository interface.
+type MockCommandTemplateSetRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCommandTemplateSetRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockCommandTemplateSetRepositoryMockRecorder is the mock recorder for MockCommandTemplateSetRepository.
+type MockCommandTemplateSetRepositoryMockRecorder struct {
+	mock *MockCommandTemplateSetRepository
+}
+
+// NewMockCommandTemplateSetRepository creates a new mock instance.
+func NewMockCommandTemplateSetRepository(ctrl *gomock.Controller) *MockCommandTemplateSetRepository {
+	mock := &MockCommandTemplateSetRepository{ctrl: ctrl}
+	mock.recorder = &MockCommandTemplateSetRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCommandTemplateSetRepository) EXPECT() *MockCommandTemplateSetRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockCommandTemplateSetRepository) Create(arg0 context.Context, arg1 domain.CommandTemplateSet) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockCommandTemplateSetRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockCommandTemplateSetRepository)(nil).Create), arg0, arg1)
+}
+
+// Delete mocks base method.
+func (m *MockCommandTemplateSetRepository) Delete(arg0 context.Context, arg1 domain.ID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockCommandTemplateSetRepositoryMockRecorder) Delete(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockCommandTemplateSetRepository)(nil).Delete), arg0, arg1)
+}
+
+// FindAllByTenant mocks base method.
+func (m *MockCommandTemplateSetRepository) FindAllByTenant(ctx context.Context, tenantID domain.ID, pagination usecases.Pagination) ([]domain.CommandTemplateSet, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllByTenant", ctx, tenantID, pagination)
+	ret0, _ := ret[0].([]domain.CommandTemplateSet)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindAllByTenant indicates an expected call of FindAllByTenant.
+func (mr *MockCommandTemplateSetRepositoryMockRecorder) FindAllByTenant(ctx, tenantID, pagination any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByTenant", reflect.TypeOf((*MockCommandTemplateSetRepository)(nil).FindAllByTenant), ctx, tenantID, pagination)
+}
+
+// GetByID mocks base method.
+func (m *MockCommandTemplateSetRepository) GetByID(arg0 context.Context, arg1 domain.ID) (domain.CommandTemplateSet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1)
+	ret0, _ := ret[0].(domain.CommandTemplateSet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockCommandTemplateSetRepositoryMockRecorder) GetByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockCommandTemplateSetRepository)(nil).GetByID), arg0, arg1)
+}
+
+// Update mocks base method.
+func (m *MockCommandTemplateSetRepository) Update(arg0 context.Context, arg1 domain.CommandTemplateSet) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockCommandTemplateSetRepositoryMockRecorder) Update(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockCommandTemplateSetRepository)(nil).Update), arg0, arg1)
+}
+
+// MockOutboxRepository is a mock of OutboxRepository interface.
+type MockOutboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockOutboxRepositoryMockRecorder is the mock recorder for MockOutboxRepository.
+type MockOutboxRepositoryMockRecorder struct {
+	mock *MockOutboxRepository
+}
+
+// NewMockOutboxRepository creates a new mock instance.
+func NewMockOutboxRepository(ctrl *gomock.Controller) *MockOutboxRepository {
+	mock := &MockOutboxRepository{ctrl: ctrl}
+	mock.recorder = &MockOutboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxRepository) EXPECT() *MockOutboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Append mocks base method.
+func (m *MockOutboxRepository) Append(arg0 context.Context, arg1 domain.OutboxEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockOutboxRepositoryMockRecorder) Append(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockOutboxRepository)(nil).Append), arg0, arg1)
+}
+
+// ClaimProcessing mocks base method.
+func (m *MockOutboxRepository) ClaimProcessing(ctx context.Context, consumer string, eventID domain.ID, at time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimProcessing", ctx, consumer, eventID, at)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimProcessing indicates an expected call of ClaimProcessing.
+func (mr *MockOutboxRepositoryMockRecorder) ClaimProcessing(ctx, consumer, eventID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimProcessing", reflect.TypeOf((*MockOutboxRepository)(nil).ClaimProcessing), ctx, consumer, eventID, at)
+}
+
+// DeleteAcknowledgedBefore mocks base method.
+func (m *MockOutboxRepository) DeleteAcknowledgedBefore(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAcknowledgedBefore", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAcknowledgedBefore indicates an expected call of DeleteAcknowledgedBefore.
+func (mr *MockOutboxRepositoryMockRecorder) DeleteAcknowledgedBefore(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAcknowledgedBefore", reflect.TypeOf((*MockOutboxRepository)(nil).DeleteAcknowledgedBefore), ctx, before)
+}
+
+// FindPending mocks base method.
+func (m *MockOutboxRepository) FindPending(ctx context.Context, redeliverBefore time.Time, maxAttempts, limit int) ([]domain.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPending", ctx, redeliverBefore, maxAttempts, limit)
+	ret0, _ := ret[0].([]domain.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindPending indicates an expected call of FindPending.
+func (mr *MockOutboxRepositoryMockRecorder) FindPending(ctx, redeliverBefore, maxAttempts, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPending", reflect.TypeOf((*MockOutboxRepository)(nil).FindPending), ctx, redeliverBefore, maxAttempts, limit)
+}
+
+// MarkProcessed mocks base method.
+func (m *MockOutboxRepository) MarkProcessed(ctx context.Context, consumer string, eventID domain.ID, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkProcessed", ctx, consumer, eventID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkProcessed indicates an expected call of MarkProcessed.
+func (mr *MockOutboxRepositoryMockRecorder) MarkProcessed(ctx, consumer, eventID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkProcessed", reflect.TypeOf((*MockOutboxRepository)(nil).MarkProcessed), ctx, consumer, eventID, at)
+}
+
+// MarkPublished mocks base method.
+func (m *MockOutboxRepository) MarkPublished(ctx context.Context, event domain.OutboxEvent, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPublished", ctx, event, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkPublished indicates an expected call of MarkPublished.
+func (mr *MockOutboxRepositoryMockRecorder) MarkPublished(ctx, event, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPublished", reflect.TypeOf((*MockOutboxRepository)(nil).MarkPublished), ctx, event, at)
+}
+
+// ReleaseProcessing mocks base method.
+func (m *MockOutboxRepository) ReleaseProcessing(ctx context.Context, consumer string, eventID domain.ID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseProcessing", ctx, consumer, eventID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseProcessing indicates an expected call of ReleaseProcessing.
+func (mr *MockOutboxRepositoryMockRecorder) ReleaseProcessing(ctx, consumer, eventID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseProcessing", reflect.TypeOf((*MockOutboxRepository)(nil).ReleaseProcessing), ctx, consumer, eventID)
+}
+
+// MockWebhookSubscriptionRepository is a mock of WebhookSubscriptionRepository interface.
+type MockWebhookSubscriptionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookSubscriptionRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockWebhookSubscriptionRepositoryMockRecorder is the mock recorder for MockWebhookSubscriptionRepository.
+type MockWebhookSubscriptionRepositoryMockRecorder struct {
+	mock *MockWebhookSubscriptionRepository
+}
+
+// NewMockWebhookSubscriptionRepository creates a new mock instance.
+func NewMockWebhookSubscriptionRepository(ctrl *gomock.Controller) *MockWebhookSubscriptionRepository {
+	mock := &MockWebhookSubscriptionRepository{ctrl: ctrl}
+	mock.recorder = &MockWebhookSubscriptionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookSubscriptionRepository) EXPECT() *MockWebhookSubscriptionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockWebhookSubscriptionRepository) Create(arg0 context.Context, arg1 domain.WebhookSubscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockWebhookSubscriptionRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWebhookSubscriptionRepository)(nil).Create), arg0, arg1)
+}
+
+// Delete mocks base method.
+func (m *MockWebhookSubscriptionRepository) Delete(arg0 context.Context, arg1 domain.ID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockWebhookSubscriptionRepositoryMockRecorder) Delete(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockWebhookSubscriptionRepository)(nil).Delete), arg0, arg1)
+}
+
+// FindActiveByTenant mocks base method.
+func (m *MockWebhookSubscriptionRepository) FindActiveByTenant(ctx context.Context, tenantID domain.ID) ([]domain.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindActiveByTenant", ctx, tenantID)
+	ret0, _ := ret[0].([]domain.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindActiveByTenant indicates an expected call of FindActiveByTenant.
+func (mr *MockWebhookSubscriptionRepositoryMockRecorder) FindActiveByTenant(ctx, tenantID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindActiveByTenant", reflect.TypeOf((*MockWebhookSubscriptionRepository)(nil).FindActiveByTenant), ctx, tenantID)
+}
+
+// FindAllByTenant mocks base method.
+func (m *MockWebhookSubscriptionRepository) FindAllByTenant(ctx context.Context, tenantID domain.ID, pagination usecases.Pagination) ([]domain.WebhookSubscription, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllByTenant", ctx, tenantID, pagination)
+	ret0, _ := ret[0].([]domain.WebhookSubscription)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindAllByTenant indicates an expected call of FindAllByTenant.
+func (mr *MockWebhookSubscriptionRepositoryMockRecorder) FindAllByTenant(ctx, tenantID, pagination any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllByTenant", reflect.TypeOf((*MockWebhookSubscriptionRepository)(nil).FindAllByTenant), ctx, tenantID, pagination)
+}
+
+// GetByID mocks base method.
+func (m *MockWebhookSubscriptionRepository) GetByID(arg0 context.Context, arg1 domain.ID) (domain.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1)
+	ret0, _ := ret[0].(domain.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockWebhookSubscriptionRepositoryMockRecorder) GetByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockWebhookSubscriptionRepository)(nil).GetByID), arg0, arg1)
+}
+
+// ResealSecrets mocks base method.
+func (m *MockWebhookSubscriptionRepository) ResealSecrets(ctx context.Context, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResealSecrets", ctx, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResealSecrets indicates an expected call of ResealSecrets.
+func (mr *MockWebhookSubscriptionRepositoryMockRecorder) ResealSecrets(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResealSecrets", reflect.TypeOf((*MockWebhookSubscriptionRepository)(nil).ResealSecrets), ctx, limit)
+}
+
+// Update mocks base method.
+func (m *MockWebhookSubscriptionRepository) Update(arg0 context.Context, arg1 domain.WebhookSubscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockWebhookSubscriptionRepositoryMockRecorder) Update(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockWebhookSubscriptionRepository)(nil).Update), arg0, arg1)
+}
+
+// MockWebhookDeliveryRepository is a mock of WebhookDeliveryRepository interface.
+type MockWebhookDeliveryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookDeliveryRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockWebhookDeliveryRepositoryMockRecorder is the mock recorder for MockWebhookDeliveryRepository.
+type MockWebhookDeliveryRepositoryMockRecorder struct {
+	mock *MockWebhookDeliveryRepository
+}
+
+// NewMockWebhookDeliveryRepository creates a new mock instance.
+func NewMockWebhookDeliveryRepository(ctrl *gomock.Controller) *MockWebhookDeliveryRepository {
+	mock := &MockWebhookDeliveryRepository{ctrl: ctrl}
+	mock.recorder = &MockWebhookDeliveryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebhookDeliveryRepository) EXPECT() *MockWebhookDeliveryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockWebhookDeliveryRepository) Create(arg0 context.Context, arg1 domain.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockWebhookDeliveryRepositoryMockRecorder) Create(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWebhookDeliveryRepository)(nil).Create), arg0, arg1)
+}
+
+// FindAllBySubscription mocks base method.
+func (m *MockWebhookDeliveryRepository) FindAllBySubscription(ctx context.Context, subscriptionID domain.ID, filter usecases.WebhookDeliveryFilter, pagination usecases.Pagination) ([]domain.WebhookDelivery, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllBySubscription", ctx, subscriptionID, filter, pagination)
+	ret0, _ := ret[0].([]domain.WebhookDelivery)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindAllBySubscription indicates an expected call of FindAllBySubscription.
+func (mr *MockWebhookDeliveryRepositoryMockRecorder) FindAllBySubscription(ctx, subscriptionID, filter, pagination any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllBySubscription", reflect.TypeOf((*MockWebhookDeliveryRepository)(nil).FindAllBySubscription), ctx, subscriptionID, filter, pagination)
+}
+
+// FindDue mocks base method.
+func (m *MockWebhookDeliveryRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDue", ctx, now, limit)
+	ret0, _ := ret[0].([]domain.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDue indicates an expected call of FindDue.
+func (mr *MockWebhookDeliveryRepositoryMockRecorder) FindDue(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDue", reflect.TypeOf((*MockWebhookDeliveryRepository)(nil).FindDue), ctx, now, limit)
+}
+
+// GetByID mocks base method.
+func (m *MockWebhookDeliveryRepository) GetByID(arg0 context.Context, arg1 domain.ID) (domain.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", arg0, arg1)
+	ret0, _ := ret[0].(domain.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockWebhookDeliveryRepositoryMockRecorder) GetByID(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockWebhookDeliveryRepository)(nil).GetByID), arg0, arg1)
+}
+
+// Update mocks base method.
+func (m *MockWebhookDeliveryRepository) Update(arg0 context.Context, arg1 domain.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockWebhookDeliveryRepositoryMockRecorder) Update(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockWebhookDeliveryRepository)(nil).Update), arg0, arg1)
+}
+
+// MockTransactor is a mock of Transactor interface.
+type MockTransactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactorMockRecorder
+	isgomock struct{}
+}
+
+// MockTransactorMockRecorder is the mock recorder for MockTransactor.
+type MockTransactorMockRecorder struct {
+	mock *MockTransactor
+}
+
+// NewMockTransactor creates a new mock instance.
+func NewMockTransactor(ctrl *gomock.Controller) *MockTransactor {
+	mock := &MockTransactor{ctrl: ctrl}
+	mock.recorder = &MockTransactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactor) EXPECT() *MockTransactorMockRecorder {
+	return m.recorder
+}
+
+// InTransaction mocks base method.
+func (m *MockTransactor) InTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InTransaction indicates an expected call of InTransaction.
+func (mr *MockTransactorMockRecorder) InTransaction(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InTransaction", reflect.TypeOf((*MockTransactor)(nil).InTransaction), ctx, fn)
 }
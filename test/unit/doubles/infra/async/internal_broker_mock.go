@@ -104,18 +104,23 @@ func (mr *MockInternalBrokerMockRecorder) Stop() *gomock.Call {
 }
 
 // Subscribe mocks base method.
-func (m *MockInternalBroker) Subscribe(topic async.BrokerTopicName) (async.Subscription, error) {
+func (m *MockInternalBroker) Subscribe(topic async.BrokerTopicName, events ...string) (async.Subscription, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Subscribe", topic)
+	varargs := []any{topic}
+	for _, a := range events {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Subscribe", varargs...)
 	ret0, _ := ret[0].(async.Subscription)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Subscribe indicates an expected call of Subscribe.
-func (mr *MockInternalBrokerMockRecorder) Subscribe(topic any) *gomock.Call {
+func (mr *MockInternalBrokerMockRecorder) Subscribe(topic any, events ...any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockInternalBroker)(nil).Subscribe), topic)
+	varargs := append([]any{topic}, events...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockInternalBroker)(nil).Subscribe), varargs...)
 }
 
 // Unsubscribe mocks base method.
@@ -240,6 +240,20 @@ func (mr *MockORMMockRecorder) Offset(offset any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Offset", reflect.TypeOf((*MockORM)(nil).Offset), offset)
 }
 
+// OnConflictDoNothing mocks base method.
+func (m *MockORM) OnConflictDoNothing() sql0.ORM {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OnConflictDoNothing")
+	ret0, _ := ret[0].(sql0.ORM)
+	return ret0
+}
+
+// OnConflictDoNothing indicates an expected call of OnConflictDoNothing.
+func (mr *MockORMMockRecorder) OnConflictDoNothing() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnConflictDoNothing", reflect.TypeOf((*MockORM)(nil).OnConflictDoNothing))
+}
+
 // Order mocks base method.
 func (m *MockORM) Order(value any) sql0.ORM {
 	m.ctrl.T.Helper()
@@ -273,6 +287,20 @@ func (mr *MockORMMockRecorder) Preload(query any, args ...any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Preload", reflect.TypeOf((*MockORM)(nil).Preload), varargs...)
 }
 
+// RowsAffected mocks base method.
+func (m *MockORM) RowsAffected() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RowsAffected")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// RowsAffected indicates an expected call of RowsAffected.
+func (mr *MockORMMockRecorder) RowsAffected() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RowsAffected", reflect.TypeOf((*MockORM)(nil).RowsAffected))
+}
+
 // Save mocks base method.
 func (m *MockORM) Save(value any) sql0.ORM {
 	m.ctrl.T.Helper()
@@ -320,6 +348,20 @@ func (mr *MockORMMockRecorder) Unscoped() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unscoped", reflect.TypeOf((*MockORM)(nil).Unscoped))
 }
 
+// Updates mocks base method.
+func (m *MockORM) Updates(values any) sql0.ORM {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Updates", values)
+	ret0, _ := ret[0].(sql0.ORM)
+	return ret0
+}
+
+// Updates indicates an expected call of Updates.
+func (mr *MockORMMockRecorder) Updates(values any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Updates", reflect.TypeOf((*MockORM)(nil).Updates), values)
+}
+
 // Where mocks base method.
 func (m *MockORM) Where(query any, args ...any) sql0.ORM {
 	m.ctrl.T.Helper()
@@ -140,6 +140,36 @@ func (mr *MockTenantConfigurationServiceMockRecorder) GetTenantConfiguration(ctx
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTenantConfiguration", reflect.TypeOf((*MockTenantConfigurationService)(nil).GetTenantConfiguration), ctx, tenant)
 }
 
+// HoldSchedules mocks base method.
+func (m *MockTenantConfigurationService) HoldSchedules(ctx context.Context, userEmail string, tenant domain.Tenant, hold domain.SchedulesHold) (domain.TenantConfiguration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HoldSchedules", ctx, userEmail, tenant, hold)
+	ret0, _ := ret[0].(domain.TenantConfiguration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HoldSchedules indicates an expected call of HoldSchedules.
+func (mr *MockTenantConfigurationServiceMockRecorder) HoldSchedules(ctx, userEmail, tenant, hold any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HoldSchedules", reflect.TypeOf((*MockTenantConfigurationService)(nil).HoldSchedules), ctx, userEmail, tenant, hold)
+}
+
+// ReleaseSchedulesHold mocks base method.
+func (m *MockTenantConfigurationService) ReleaseSchedulesHold(ctx context.Context, userEmail string, tenant domain.Tenant) (domain.TenantConfiguration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseSchedulesHold", ctx, userEmail, tenant)
+	ret0, _ := ret[0].(domain.TenantConfiguration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseSchedulesHold indicates an expected call of ReleaseSchedulesHold.
+func (mr *MockTenantConfigurationServiceMockRecorder) ReleaseSchedulesHold(ctx, userEmail, tenant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseSchedulesHold", reflect.TypeOf((*MockTenantConfigurationService)(nil).ReleaseSchedulesHold), ctx, userEmail, tenant)
+}
+
 // UpsertTenantConfiguration mocks base method.
 func (m *MockTenantConfigurationService) UpsertTenantConfiguration(ctx context.Context, userEmail string, config domain.TenantConfiguration) (domain.TenantConfiguration, error) {
 	m.ctrl.T.Helper()
@@ -235,6 +265,21 @@ func (mr *MockTenantServiceMockRecorder) DeactivateTenant(ctx, id any) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeactivateTenant", reflect.TypeOf((*MockTenantService)(nil).DeactivateTenant), ctx, id)
 }
 
+// FleetHealth mocks base method.
+func (m *MockTenantService) FleetHealth(ctx context.Context, tenantID domain.ID) (domain.FleetHealth, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FleetHealth", ctx, tenantID)
+	ret0, _ := ret[0].(domain.FleetHealth)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FleetHealth indicates an expected call of FleetHealth.
+func (mr *MockTenantServiceMockRecorder) FleetHealth(ctx, tenantID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FleetHealth", reflect.TypeOf((*MockTenantService)(nil).FleetHealth), ctx, tenantID)
+}
+
 // GetTenant mocks base method.
 func (m *MockTenantService) GetTenant(ctx context.Context, id domain.ID) (domain.Tenant, error) {
 	m.ctrl.T.Helper()
@@ -266,19 +311,18 @@ func (mr *MockTenantServiceMockRecorder) GetTenantByName(ctx, name any) *gomock.
 }
 
 // ListTenantDevices mocks base method.
-func (m *MockTenantService) ListTenantDevices(ctx context.Context, tenantID domain.ID, pagination usecases.Pagination) ([]domain.Device, int, error) {
+func (m *MockTenantService) ListTenantDevices(ctx context.Context, tenantID domain.ID, query usecases.DeviceQuery) (usecases.DevicePage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListTenantDevices", ctx, tenantID, pagination)
-	ret0, _ := ret[0].([]domain.Device)
-	ret1, _ := ret[1].(int)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret := m.ctrl.Call(m, "ListTenantDevices", ctx, tenantID, query)
+	ret0, _ := ret[0].(usecases.DevicePage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // ListTenantDevices indicates an expected call of ListTenantDevices.
-func (mr *MockTenantServiceMockRecorder) ListTenantDevices(ctx, tenantID, pagination any) *gomock.Call {
+func (mr *MockTenantServiceMockRecorder) ListTenantDevices(ctx, tenantID, query any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTenantDevices", reflect.TypeOf((*MockTenantService)(nil).ListTenantDevices), ctx, tenantID, pagination)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTenantDevices", reflect.TypeOf((*MockTenantService)(nil).ListTenantDevices), ctx, tenantID, query)
 }
 
 // ListTenants mocks base method.
@@ -483,18 +527,17 @@ func (mr *MockDeviceAdopterMockRecorder) AdoptDeviceToTenant(ctx, tenantID, devi
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdoptDeviceToTenant", reflect.TypeOf((*MockDeviceAdopter)(nil).AdoptDeviceToTenant), ctx, tenantID, deviceID)
 }
 
-// DevicesByTenant mocks base method.
-func (m *MockDeviceAdopter) DevicesByTenant(ctx context.Context, tenantID domain.ID, pagination usecases.Pagination) ([]domain.Device, int, error) {
+// SearchDevices mocks base method.
+func (m *MockDeviceAdopter) SearchDevices(ctx context.Context, query usecases.DeviceQuery) (usecases.DevicePage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DevicesByTenant", ctx, tenantID, pagination)
-	ret0, _ := ret[0].([]domain.Device)
-	ret1, _ := ret[1].(int)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret := m.ctrl.Call(m, "SearchDevices", ctx, query)
+	ret0, _ := ret[0].(usecases.DevicePage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DevicesByTenant indicates an expected call of DevicesByTenant.
-func (mr *MockDeviceAdopterMockRecorder) DevicesByTenant(ctx, tenantID, pagination any) *gomock.Call {
+// SearchDevices indicates an expected call of SearchDevices.
+func (mr *MockDeviceAdopterMockRecorder) SearchDevices(ctx, query any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DevicesByTenant", reflect.TypeOf((*MockDeviceAdopter)(nil).DevicesByTenant), ctx, tenantID, pagination)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchDevices", reflect.TypeOf((*MockDeviceAdopter)(nil).SearchDevices), ctx, query)
 }